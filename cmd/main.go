@@ -48,6 +48,11 @@ func main() {
 	logger.Infof("配置信息: 服务端口=%d, 运行模式=%s, 日志级别=%s",
 		config.Server.Port, config.Server.Mode, config.Log.Level)
 
+	// release模式下拒绝使用默认或过短的JWT密钥
+	if err := config.JWT.Validate(config.Server.Mode); err != nil {
+		logger.Fatalf("JWT配置不安全，拒绝启动: %v", err)
+	}
+
 	// 3. 初始化数据库
 	logger.Info("初始化MongoDB数据库连接...")
 	db, err := database.InitMongoDB(config.Database.MongoDB)
@@ -0,0 +1,84 @@
+// reencrypt 加密已有的敏感字段，或在轮换主密钥、盲索引密钥后重新包装数据密钥并重建盲索引。
+//
+// 用法：
+//
+//	go run ./cmd/reencrypt -collection all -dry-run
+//	go run ./cmd/reencrypt -collection policies
+//
+// 须先在配置中启用 security.encryption，轮换时旧主密钥保留在密钥文件中直到本命令执行完毕；
+// 处理期间被修改而跳过的文档重新执行即可。
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"YufungProject/configs"
+	"YufungProject/internal/repository"
+	"YufungProject/internal/service"
+	"YufungProject/pkg/database"
+	"YufungProject/pkg/logger"
+)
+
+// encryptedCollections 含加密字段的集合及其加密字段
+var encryptedCollections = []struct {
+	name   string
+	fields []string
+}{
+	{name: repository.PolicyCollection, fields: repository.PolicyEncryptedFields},
+	{name: "users", fields: repository.UserEncryptedFields},
+	{name: repository.CustomerCollection, fields: repository.CustomerEncryptedFields},
+}
+
+func main() {
+	collection := flag.String("collection", "all", "要处理的集合：policies、users、customers 或 all")
+	dryRun := flag.Bool("dry-run", false, "只统计需要处理的文档，不写入")
+	flag.Parse()
+
+	config, err := configs.LoadConfig()
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+	if err := logger.InitLogger(logger.LogConfig{
+		Level:  config.Log.Level,
+		Format: config.Log.Format,
+		Output: "stdout",
+	}); err != nil {
+		log.Fatalf("初始化日志系统失败: %v", err)
+	}
+
+	codec, err := service.NewFieldCodec(config.Security.Encryption)
+	if err != nil {
+		logger.Fatalf("加载字段加密密钥失败: %v", err)
+	}
+	if codec == nil {
+		logger.Fatalf("未启用字段加密，请先配置 security.encryption")
+	}
+	repository.SetFieldCodec(codec)
+
+	db, err := database.InitMongoDB(config.Database.MongoDB)
+	if err != nil {
+		logger.Fatalf("数据库连接失败: %v", err)
+	}
+	defer database.DisconnectMongoDB()
+
+	ctx := context.Background()
+	processed := false
+	for _, target := range encryptedCollections {
+		if *collection != "all" && *collection != target.name {
+			continue
+		}
+		processed = true
+
+		result, err := repository.ReencryptCollection(ctx, db, target.name, target.fields, *dryRun)
+		if err != nil {
+			logger.Fatalf("重新加密 %s 失败: %v", target.name, err)
+		}
+		logger.Infof("%s: 扫描=%d, 加密=%d, 更换主密钥=%d, 重建盲索引=%d, 跳过=%d, 当前主密钥=%s, 试运行=%v",
+			target.name, result.Scanned, result.Encrypted, result.Rewrapped, result.Reindexed, result.Skipped, codec.ActiveKeyID(), *dryRun)
+	}
+	if !processed {
+		logger.Fatalf("未知的集合: %s", *collection)
+	}
+}
@@ -1,24 +1,34 @@
 package configs
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
 // Config 应用配置结构
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	Redis    RedisConfig    `yaml:"redis"`
-	JWT      JWTConfig      `yaml:"jwt"`
-	Log      LogConfig      `yaml:"log"`
-	Upload   UploadConfig   `yaml:"upload"`
-	Security SecurityConfig `yaml:"security"`
+	Server       ServerConfig       `yaml:"server"`
+	Database     DatabaseConfig     `yaml:"database"`
+	Redis        RedisConfig        `yaml:"redis"`
+	JWT          JWTConfig          `yaml:"jwt"`
+	Log          LogConfig          `yaml:"log"`
+	Upload       UploadConfig       `yaml:"upload"`
+	Security     SecurityConfig     `yaml:"security"`
+	Notification NotificationConfig `yaml:"notification"`
+	Mail         MailConfig         `yaml:"mail"`
+	RateLimit    RateLimitConfig    `yaml:"rate_limit"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port int    `yaml:"port"`
-	Mode string `yaml:"mode"`
+	Port           int      `yaml:"port"`
+	Mode           string   `yaml:"mode"`
+	TrustedProxies []string `yaml:"trusted_proxies"` // 可信代理IP或CIDR，只有来自这些地址的 X-Forwarded-For 才用于识别客户端IP；为空时不信任任何代理
 }
 
 // DatabaseConfig 数据库配置
@@ -44,9 +54,57 @@ type RedisConfig struct {
 
 // JWTConfig JWT配置
 type JWTConfig struct {
-	Secret           string `yaml:"secret"`
-	ExpiresIn        string `yaml:"expires_in"`
-	RefreshExpiresIn string `yaml:"refresh_expires_in"`
+	Secret           string         `yaml:"secret"` // HMAC共享密钥；配置 keys 后只用于校验不带 kid 的旧令牌，旧令牌全部过期后可清空
+	ExpiresIn        string         `yaml:"expires_in"`
+	RefreshExpiresIn string         `yaml:"refresh_expires_in"`
+	Keys             []JWTKeyConfig `yaml:"keys"` // 签名密钥，第一个签发新令牌，其余只校验旧令牌；未配置时使用 secret 以 HS256 签名
+}
+
+// JWTKeyConfig JWT签名密钥配置
+type JWTKeyConfig struct {
+	KID            string `yaml:"kid"`              // 密钥标识，写入令牌头
+	Algorithm      string `yaml:"algorithm"`        // 签名算法：HS256, RS256, EdDSA
+	Secret         string `yaml:"secret"`           // HS256 共享密钥
+	PrivateKeyFile string `yaml:"private_key_file"` // RS256/EdDSA 私钥PEM文件
+	PublicKeyFile  string `yaml:"public_key_file"`  // RS256/EdDSA 公钥PEM文件，只校验旧令牌的密钥可只配置公钥
+}
+
+// minJWTSecretLength release模式下HMAC共享密钥的最小长度
+const minJWTSecretLength = 32
+
+// weakJWTSecrets 示例配置和常见的默认密钥
+var weakJWTSecrets = map[string]bool{
+	"yf2025":                               true,
+	"secret":                               true,
+	"jwt-secret":                           true,
+	"your-secret-key":                      true,
+	"your-secret-key-change-in-production": true,
+	"changeme":                             true,
+}
+
+// Validate 校验JWT配置，release模式下拒绝使用默认或过短的HMAC共享密钥
+func (c *JWTConfig) Validate(mode string) error {
+	if len(c.Keys) == 0 && c.Secret == "" {
+		return errors.New("未配置 jwt.secret 或 jwt.keys")
+	}
+	if mode != "release" {
+		return nil
+	}
+
+	if c.Secret != "" && isWeakJWTSecret(c.Secret) {
+		return fmt.Errorf("release模式下 jwt.secret 不能使用默认密钥或少于%d个字符，请通过环境变量 INSURANCE_JWT_SECRET 设置随机密钥，或改用 jwt.keys 配置 RS256/EdDSA 密钥", minJWTSecretLength)
+	}
+	for _, key := range c.Keys {
+		if key.Algorithm == "HS256" && isWeakJWTSecret(key.Secret) {
+			return fmt.Errorf("release模式下 jwt.keys 中 %s 的 secret 不能使用默认密钥或少于%d个字符", key.KID, minJWTSecretLength)
+		}
+	}
+	return nil
+}
+
+// isWeakJWTSecret 是否为默认密钥或长度不足的密钥
+func isWeakJWTSecret(secret string) bool {
+	return weakJWTSecrets[strings.ToLower(secret)] || len(secret) < minJWTSecretLength
 }
 
 // LogConfig 日志配置
@@ -68,11 +126,155 @@ type UploadConfig struct {
 	Path         string   `yaml:"path"`
 }
 
+// defaultUploadMaxSize 未配置上传大小限制时的默认值（10MB）
+const defaultUploadMaxSize int64 = 10 << 20
+
+// MaxSizeBytes 解析上传文件大小限制，支持 B、KB、MB、GB 单位（如 10MB），未配置时默认10MB
+func (c UploadConfig) MaxSizeBytes() (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(c.MaxSize))
+	if value == "" {
+		return defaultUploadMaxSize, nil
+	}
+
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("invalid upload.max_size: %q", c.MaxSize)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
 // SecurityConfig 安全配置
 type SecurityConfig struct {
-	PasswordMinLength int    `yaml:"password_min_length"`
-	MaxLoginAttempts  int    `yaml:"max_login_attempts"`
-	LockoutDuration   string `yaml:"lockout_duration"`
+	PasswordMinLength int                  `yaml:"password_min_length"`
+	MaxLoginAttempts  int                  `yaml:"max_login_attempts"`
+	LockoutDuration   string               `yaml:"lockout_duration"`
+	PasswordReset     PasswordResetConfig  `yaml:"password_reset"`
+	PasswordPolicy    PasswordPolicyConfig `yaml:"password_policy"`
+	Invitation        InvitationConfig     `yaml:"invitation"`
+	Impersonation     ImpersonationConfig  `yaml:"impersonation"`
+	Encryption        EncryptionConfig     `yaml:"encryption"`
+	Masking           MaskingConfig        `yaml:"masking"`
+}
+
+// RateLimitConfig 限流配置
+type RateLimitConfig struct {
+	Enabled     bool                     `yaml:"enabled"`
+	Backend     string                   `yaml:"backend"`      // memory/redis，redis 使用 redis 配置，连接失败或出错时使用进程内存
+	KeyPrefix   string                   `yaml:"key_prefix"`   // Redis键前缀
+	AuthIP      RateLimitRule            `yaml:"auth_ip"`      // /api/auth 接口按IP限流
+	AuthAccount RateLimitRule            `yaml:"auth_account"` // 登录失败、忘记密码按用户名分别限流（不区分IP）
+	UserLimits  map[string]RateLimitRule `yaml:"user_limits"`  // 耗资源接口按用户限流，键为接口类别，如 export、import
+}
+
+// RateLimitRule 令牌桶规则：period 内最多 capacity 次请求，令牌匀速补充；capacity 为0表示不限流
+type RateLimitRule struct {
+	Capacity int    `yaml:"capacity"`
+	Period   string `yaml:"period"`
+}
+
+// PasswordPolicyConfig 平台默认密码策略，平台管理员在系统中保存策略后以保存的为准；最小长度使用 password_min_length
+type PasswordPolicyConfig struct {
+	RequireDigit        bool `yaml:"require_digit"`         // 必须包含数字
+	RequireLowercase    bool `yaml:"require_lowercase"`     // 必须包含小写字母
+	RequireUppercase    bool `yaml:"require_uppercase"`     // 必须包含大写字母
+	RequireSpecial      bool `yaml:"require_special"`       // 必须包含特殊字符
+	MinCharClasses      int  `yaml:"min_char_classes"`      // 数字、小写、大写、特殊字符中至少包含的种类数
+	DisallowUsername    bool `yaml:"disallow_username"`     // 不允许包含用户名
+	DisallowCompanyName bool `yaml:"disallow_company_name"` // 不允许包含公司名称或公司代码
+	HistoryCount        int  `yaml:"history_count"`         // 不允许与最近N次使用过的密码相同
+	MaxAgeDays          int  `yaml:"max_age_days"`          // 密码最长使用天数，0表示不过期
+}
+
+// PasswordResetConfig 自助重置密码配置
+type PasswordResetConfig struct {
+	TokenTTL     string `yaml:"token_ttl"`     // 重置令牌有效期，如 30m
+	ResetURL     string `yaml:"reset_url"`     // 前端重置密码页面地址，令牌以 token 查询参数附加
+	AccountLimit int    `yaml:"account_limit"` // 统计窗口内同一账户最多发送的重置邮件数量
+	IPLimit      int    `yaml:"ip_limit"`      // 统计窗口内同一IP最多提交的忘记密码请求数量
+	Window       string `yaml:"window"`        // 限流统计窗口，如 1h
+}
+
+// InvitationConfig 邀请注册配置
+type InvitationConfig struct {
+	Enabled   bool   `yaml:"enabled"`    // 启用后关闭公开注册，新用户只能通过公司管理员的邀请加入
+	TokenTTL  string `yaml:"token_ttl"`  // 邀请链接有效期，如 72h
+	AcceptURL string `yaml:"accept_url"` // 前端接受邀请页面地址，令牌以 token 查询参数附加
+}
+
+// ImpersonationConfig 平台管理员模拟登录配置
+type ImpersonationConfig struct {
+	Enabled     bool   `yaml:"enabled"`      // 是否允许平台管理员模拟其他用户登录
+	TokenTTL    string `yaml:"token_ttl"`    // 模拟登录令牌有效期，如 30m，不签发刷新令牌
+	BlockWrites bool   `yaml:"block_writes"` // 模拟登录期间一律禁止写操作；为false时由发起人按次选择是否只读
+}
+
+// EncryptionConfig 敏感字段加密配置：保单客户号、客户姓名、账户号及用户邮箱、手机号加密保存
+type EncryptionConfig struct {
+	Enabled  bool   `yaml:"enabled"`  // 是否加密保存敏感字段；启用后须运行 cmd/reencrypt 加密已有数据并生成盲索引
+	Provider string `yaml:"provider"` // 主密钥来源：keyfile=本地密钥文件
+	KeyFile  string `yaml:"key_file"` // 本地密钥文件路径（JSON：active_key_id、keys、index_key），轮换时新增主密钥并修改 active_key_id
+}
+
+// MaskingConfig 敏感字段脱敏配置：没有查看权限的用户在接口响应和导出文件中只能看到脱敏后的值
+type MaskingConfig struct {
+	Enabled bool                `yaml:"enabled"` // 是否启用脱敏
+	Rules   []MaskingRuleConfig `yaml:"rules"`   // 脱敏规则，未配置时使用默认规则（见 service.DefaultMaskingRules）
+}
+
+// MaskingRuleConfig 单个字段的脱敏规则，拥有权限标识或角色之一即可查看完整值，平台管理员不脱敏
+type MaskingRuleConfig struct {
+	Field      string   `yaml:"field"`      // 字段，如 policy.account_number、user.email
+	Strategy   string   `yaml:"strategy"`   // 脱敏方式：last4=只显示后4位, name=只显示姓名首字, email, phone, hidden=隐藏
+	Permission string   `yaml:"permission"` // 可查看完整值的权限标识，如 policy:view_sensitive
+	Roles      []string `yaml:"roles"`      // 可查看完整值的角色ID
+}
+
+// NotificationConfig 站内通知提醒规则配置
+type NotificationConfig struct {
+	SchedulerEnabled  bool   `yaml:"scheduler_enabled"`   // 是否启动提醒规则定时任务
+	SchedulerInterval string `yaml:"scheduler_interval"`  // 提醒规则执行间隔，如 1h
+	PremiumDueDays    int    `yaml:"premium_due_days"`    // 续期保费到期前多少天提醒
+	AnniversaryDays   int    `yaml:"anniversary_days"`    // 保单周年日前多少天提醒
+	CoolingOffDays    int    `yaml:"cooling_off_days"`    // 冷静期天数（自生效日起算）
+	CompanyExpiryDays int    `yaml:"company_expiry_days"` // 公司有效期结束前多少天提醒
+}
+
+// MailConfig 邮件发送配置
+type MailConfig struct {
+	Driver          string     `yaml:"driver"`           // 发送方式：smtp=SMTP服务器, file=写入本地.eml文件（开发和测试环境）
+	From            string     `yaml:"from"`             // 发件人地址
+	FromName        string     `yaml:"from_name"`        // 发件人名称
+	DefaultLanguage string     `yaml:"default_language"` // 默认模板语言：zh/en
+	OutboxDir       string     `yaml:"outbox_dir"`       // file方式的.eml文件输出目录
+	WorkerEnabled   bool       `yaml:"worker_enabled"`   // 是否启动发件箱定时发送任务
+	WorkerInterval  string     `yaml:"worker_interval"`  // 发件箱处理间隔，如 30s
+	BatchSize       int        `yaml:"batch_size"`       // 每次处理的最大邮件数量
+	MaxAttempts     int        `yaml:"max_attempts"`     // 最大发送次数，超过后标记为失败
+	RetryBaseDelay  string     `yaml:"retry_base_delay"` // 首次重试间隔，之后按指数退避，如 1m
+	RetryMaxDelay   string     `yaml:"retry_max_delay"`  // 重试间隔上限，如 1h
+	SMTP            SMTPConfig `yaml:"smtp"`
+}
+
+// SMTPConfig SMTP服务器配置
+type SMTPConfig struct {
+	Host       string `yaml:"host"`
+	Port       int    `yaml:"port"`
+	Username   string `yaml:"username"`
+	Password   string `yaml:"password"`
+	Encryption string `yaml:"encryption"` // 加密方式：none, starttls, tls
+	Timeout    string `yaml:"timeout"`    // 连接和发送超时，如 30s
 }
 
 var AppConfig *Config
@@ -87,14 +289,18 @@ func LoadConfig() (*Config, error) {
 
 	// 设置环境变量前缀
 	viper.SetEnvPrefix("INSURANCE")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_")) // jwt.secret 对应 INSURANCE_JWT_SECRET
 	viper.AutomaticEnv()
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, err
 	}
 
+	// 配置结构体使用 yaml 标签声明下划线风格的键名（如 max_size），解码时按 yaml 标签匹配
 	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
+	if err := viper.Unmarshal(&config, func(dc *mapstructure.DecoderConfig) {
+		dc.TagName = "yaml"
+	}); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,362 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/model"
+	"YufungProject/internal/service"
+)
+
+type AnnouncementController struct {
+	announcementService *service.AnnouncementService
+}
+
+func NewAnnouncementController(announcementService *service.AnnouncementService) *AnnouncementController {
+	return &AnnouncementController{
+		announcementService: announcementService,
+	}
+}
+
+// announcementOwner 获取公告发布方：平台管理员管理平台公告（返回空），其他用户管理本公司公告
+func announcementOwner(ctx *gin.Context) string {
+	roleIDs, _ := middleware.GetRoleIDs(ctx)
+	if middleware.IsAdminRoles(roleIDs) {
+		return ""
+	}
+	companyID, _ := middleware.GetCompanyID(ctx)
+	return companyID
+}
+
+// ==========================
+// 公告管理
+// ==========================
+
+// CreateAnnouncement 创建公告
+// @Summary 创建公告
+// @Description 平台管理员创建平台公告，公司用户创建本公司内部公告；publish=false时保存为草稿
+// @Tags 通知公告
+// @Accept json
+// @Produce json
+// @Param request body model.AnnouncementCreateRequest true "公告信息"
+// @Success 200 {object} model.Response{data=model.Announcement} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 403 {object} model.Response "权限不足"
+// @Router /api/announcements/manage [post]
+func (c *AnnouncementController) CreateAnnouncement(ctx *gin.Context) {
+	var req model.AnnouncementCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	announcement, err := c.announcementService.CreateAnnouncement(ctx.Request.Context(), &req, userID, announcementOwner(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("创建成功", announcement))
+}
+
+// ListManagedAnnouncements 获取公告管理列表
+// @Summary 获取公告管理列表
+// @Description 分页查询平台公告（平台管理员）或本公司公告，附带已读和已确认人数
+// @Tags 通知公告
+// @Accept json
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param keyword query string false "标题关键字"
+// @Param status query string false "状态" Enums(draft, published, withdrawn)
+// @Param priority query string false "优先级" Enums(low, normal, high, urgent)
+// @Success 200 {object} model.Response{data=model.AnnouncementManageListResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 403 {object} model.Response "权限不足"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/announcements/manage [get]
+func (c *AnnouncementController) ListManagedAnnouncements(ctx *gin.Context) {
+	var req model.AnnouncementManageQueryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	result, err := c.announcementService.ListManagedAnnouncements(ctx.Request.Context(), &req, announcementOwner(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
+
+// GetManagedAnnouncement 获取公告管理详情
+// @Summary 获取公告管理详情
+// @Description 获取平台公告（平台管理员）或本公司公告的详情
+// @Tags 通知公告
+// @Accept json
+// @Produce json
+// @Param id path string true "公告ID"
+// @Success 200 {object} model.Response{data=model.Announcement} "成功"
+// @Failure 403 {object} model.Response "权限不足"
+// @Failure 404 {object} model.Response "公告不存在"
+// @Router /api/announcements/manage/{id} [get]
+func (c *AnnouncementController) GetManagedAnnouncement(ctx *gin.Context) {
+	announcement, err := c.announcementService.GetManagedAnnouncement(ctx.Request.Context(), ctx.Param("id"), announcementOwner(ctx))
+	if err != nil {
+		if err.Error() == "公告不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(announcement))
+}
+
+// UpdateAnnouncement 更新公告
+// @Summary 更新公告
+// @Description 更新公告内容、受众、有效期或状态（draft/published/withdrawn）；需确认的已发布公告修改标题或内容后，受众需重新确认
+// @Tags 通知公告
+// @Accept json
+// @Produce json
+// @Param id path string true "公告ID"
+// @Param request body model.AnnouncementUpdateRequest true "更新内容"
+// @Success 200 {object} model.Response{data=model.Announcement} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 403 {object} model.Response "权限不足"
+// @Failure 404 {object} model.Response "公告不存在"
+// @Router /api/announcements/manage/{id} [put]
+func (c *AnnouncementController) UpdateAnnouncement(ctx *gin.Context) {
+	var req model.AnnouncementUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	announcement, err := c.announcementService.UpdateAnnouncement(ctx.Request.Context(), ctx.Param("id"), &req, userID, announcementOwner(ctx))
+	if err != nil {
+		if err.Error() == "公告不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("更新成功", announcement))
+}
+
+// DeleteAnnouncement 删除公告
+// @Summary 删除公告
+// @Description 删除公告及其阅读回执
+// @Tags 通知公告
+// @Accept json
+// @Produce json
+// @Param id path string true "公告ID"
+// @Success 200 {object} model.Response "成功"
+// @Failure 403 {object} model.Response "权限不足"
+// @Failure 404 {object} model.Response "公告不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/announcements/manage/{id} [delete]
+func (c *AnnouncementController) DeleteAnnouncement(ctx *gin.Context) {
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	if err := c.announcementService.DeleteAnnouncement(ctx.Request.Context(), ctx.Param("id"), userID, announcementOwner(ctx)); err != nil {
+		if err.Error() == "公告不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("删除成功", nil))
+}
+
+// GetReceipts 获取公告回执
+// @Summary 获取公告回执
+// @Description 获取公告受众的阅读和确认情况，未读的排在前面
+// @Tags 通知公告
+// @Accept json
+// @Produce json
+// @Param id path string true "公告ID"
+// @Success 200 {object} model.Response{data=model.AnnouncementReceiptResponse} "成功"
+// @Failure 403 {object} model.Response "权限不足"
+// @Failure 404 {object} model.Response "公告不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/announcements/manage/{id}/receipts [get]
+func (c *AnnouncementController) GetReceipts(ctx *gin.Context) {
+	result, err := c.announcementService.GetReceipts(ctx.Request.Context(), ctx.Param("id"), announcementOwner(ctx))
+	if err != nil {
+		if err.Error() == "公告不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
+
+// ==========================
+// 公告查看
+// ==========================
+
+// ListAnnouncements 获取我的公告
+// @Summary 获取我的公告
+// @Description 分页查询当前用户可见的公告（已发布、处于生效期内且属于受众），附带本人阅读状态
+// @Tags 通知公告
+// @Accept json
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param priority query string false "优先级" Enums(low, normal, high, urgent)
+// @Param unread_only query bool false "只看未读"
+// @Success 200 {object} model.Response{data=model.AnnouncementListResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/announcements [get]
+func (c *AnnouncementController) ListAnnouncements(ctx *gin.Context) {
+	var req model.AnnouncementQueryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+	companyID, _ := middleware.GetCompanyID(ctx)
+	roleIDs, _ := middleware.GetRoleIDs(ctx)
+
+	result, err := c.announcementService.ListAnnouncements(ctx.Request.Context(), &req, userID, companyID, roleIDs)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
+
+// GetPendingAcknowledgements 获取待确认公告
+// @Summary 获取待确认公告
+// @Description 获取当前用户需要确认但尚未确认的公告，紧急和高优先级排在前面；前端在登录后展示
+// @Tags 通知公告
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response{data=[]model.AnnouncementBrief} "成功"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/announcements/pending-acknowledgements [get]
+func (c *AnnouncementController) GetPendingAcknowledgements(ctx *gin.Context) {
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+	companyID, _ := middleware.GetCompanyID(ctx)
+	roleIDs, _ := middleware.GetRoleIDs(ctx)
+
+	result, err := c.announcementService.ListPendingAcknowledgements(ctx.Request.Context(), userID, companyID, roleIDs)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
+
+// GetAnnouncement 查看公告
+// @Summary 查看公告
+// @Description 查看当前用户可见的公告详情，并记录已读
+// @Tags 通知公告
+// @Accept json
+// @Produce json
+// @Param id path string true "公告ID"
+// @Success 200 {object} model.Response{data=model.AnnouncementItem} "成功"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "公告不存在"
+// @Router /api/announcements/{id} [get]
+func (c *AnnouncementController) GetAnnouncement(ctx *gin.Context) {
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+	companyID, _ := middleware.GetCompanyID(ctx)
+	roleIDs, _ := middleware.GetRoleIDs(ctx)
+
+	item, err := c.announcementService.GetAnnouncement(ctx.Request.Context(), ctx.Param("id"), userID, companyID, roleIDs)
+	if err != nil {
+		if err.Error() == "公告不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(item))
+}
+
+// AcknowledgeAnnouncement 确认公告
+// @Summary 确认公告
+// @Description 确认已阅读需要确认的公告，重复确认保留首次确认时间
+// @Tags 通知公告
+// @Accept json
+// @Produce json
+// @Param id path string true "公告ID"
+// @Success 200 {object} model.Response "成功"
+// @Failure 400 {object} model.Response "该公告无需确认"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "公告不存在"
+// @Router /api/announcements/{id}/acknowledge [post]
+func (c *AnnouncementController) AcknowledgeAnnouncement(ctx *gin.Context) {
+	userID, exists := middleware.GetUserID(ctx)
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+	companyID, _ := middleware.GetCompanyID(ctx)
+	roleIDs, _ := middleware.GetRoleIDs(ctx)
+
+	if err := c.announcementService.AcknowledgeAnnouncement(ctx.Request.Context(), ctx.Param("id"), userID, companyID, roleIDs); err != nil {
+		switch err.Error() {
+		case "公告不存在":
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+		case "该公告无需确认":
+			ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("确认成功", nil))
+}
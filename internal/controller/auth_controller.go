@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strings"
 
+	"YufungProject/internal/middleware"
 	"YufungProject/internal/model"
 	"YufungProject/internal/service"
 	"YufungProject/pkg/logger"
@@ -13,13 +14,15 @@ import (
 
 // AuthController 认证控制器
 type AuthController struct {
-	authService service.AuthService
+	authService         service.AuthService
+	announcementService *service.AnnouncementService
 }
 
 // NewAuthController 创建认证控制器实例
-func NewAuthController(authService service.AuthService) *AuthController {
+func NewAuthController(authService service.AuthService, announcementService *service.AnnouncementService) *AuthController {
 	return &AuthController{
-		authService: authService,
+		authService:         authService,
+		announcementService: announcementService,
 	}
 }
 
@@ -34,7 +37,8 @@ func NewAuthController(authService service.AuthService) *AuthController {
 //	@Success		200		{object}	model.Response{data=model.LoginResponse}	"登录成功"
 //	@Failure		400		{object}	model.Response{data=string}					"请求参数错误"
 //	@Failure		401		{object}	model.Response{data=string}					"认证失败"
-//	@Failure		423		{object}	model.Response{data=string}					"账户被锁定"
+//	@Failure		403		{object}	model.Response{data=string}					"账户已被禁用或当前网络不在公司IP白名单内"
+//	@Failure		429		{object}	model.Response{data=string}					"请求过于频繁"
 //	@Failure		500		{object}	model.Response{data=string}					"服务器内部错误"
 //	@Router			/auth/login [post]
 func (c *AuthController) Login(ctx *gin.Context) {
@@ -57,18 +61,19 @@ func (c *AuthController) Login(ctx *gin.Context) {
 	// 记录登录尝试
 	logger.AuthLog("login_attempt", req.Username, clientIP, false, "开始登录验证")
 
-	loginResp, err := c.authService.Login(ctx, &req)
+	loginResp, err := c.authService.Login(ctx.Request.Context(), &req, clientIP, ctx.Request.UserAgent())
 	if err != nil {
 		// 记录登录失败
 		logger.AuthLog("login_failed", req.Username, clientIP, false, err.Error())
 
+		// 用户不存在、密码错误、账户锁定统一返回相同提示，避免探测用户名；具体原因只记录在日志中
 		switch err.Error() {
-		case "用户不存在", "密码错误":
+		case "用户不存在", "密码错误", "账户已被锁定":
 			ctx.JSON(http.StatusUnauthorized, model.ErrorResponse(model.CodeAuthFailed, "用户名或密码错误", nil))
-		case "账户已被锁定":
-			ctx.JSON(http.StatusLocked, model.ErrorResponse(model.CodeAccountLocked, "账户已被锁定，请稍后再试", nil))
 		case "账户已被禁用":
 			ctx.JSON(http.StatusForbidden, model.ErrorResponse(model.CodeAccountDisabled, "账户已被禁用", nil))
+		case service.ErrIPNotAllowed.Error():
+			ctx.JSON(http.StatusForbidden, model.ErrorResponse(model.CodeIPNotAllowed, err.Error(), nil))
 		default:
 			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeServerError, "登录失败", err.Error()))
 		}
@@ -79,6 +84,14 @@ func (c *AuthController) Login(ctx *gin.Context) {
 	logger.AuthLog("login_success", req.Username, clientIP, true, "登录成功")
 	logger.BusinessLog("认证管理", "用户登录", loginResp.User.UserID, "用户登录成功")
 
+	// 附带待确认公告，查询失败不影响登录
+	pending, err := c.announcementService.ListPendingAcknowledgements(ctx.Request.Context(), loginResp.User.UserID, loginResp.User.CompanyID, loginResp.User.RoleIDs)
+	if err != nil {
+		logger.Errorf("查询待确认公告失败: UserID=%s, Error=%v", loginResp.User.UserID, err)
+	} else {
+		loginResp.PendingAnnouncements = pending
+	}
+
 	ctx.JSON(http.StatusOK, model.SuccessResponse("登录成功", loginResp))
 }
 
@@ -92,6 +105,7 @@ func (c *AuthController) Login(ctx *gin.Context) {
 //	@Param			request	body		model.RegisterRequest	true	"注册请求参数"
 //	@Success		201		{object}	model.Response{data=model.UserInfo}	"注册成功"
 //	@Failure		400		{object}	model.Response{data=string}				"请求参数错误"
+//	@Failure		403		{object}	model.Response{data=string}				"公开注册已关闭"
 //	@Failure		409		{object}	model.Response{data=string}				"用户名或邮箱已存在"
 //	@Failure		500		{object}	model.Response{data=string}				"服务器内部错误"
 //	@Router			/auth/register [post]
@@ -113,7 +127,13 @@ func (c *AuthController) Register(ctx *gin.Context) {
 		// 记录注册失败
 		logger.AuthLog("register_failed", req.Username, clientIP, false, err.Error())
 
+		if service.IsPasswordPolicyError(err) {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodePasswordPolicy, err.Error(), nil))
+			return
+		}
 		switch err.Error() {
+		case "公开注册已关闭":
+			ctx.JSON(http.StatusForbidden, model.ErrorResponse(model.CodeForbidden, "公开注册已关闭，请通过邀请链接注册", nil))
 		case "用户名已存在":
 			ctx.JSON(http.StatusConflict, model.ErrorResponse(model.CodeUserExists, "用户名已存在", nil))
 		case "邮箱已存在":
@@ -173,6 +193,10 @@ func (c *AuthController) ChangePassword(ctx *gin.Context) {
 		// 记录密码修改失败
 		logger.AuthLog("change_password_failed", username, clientIP, false, err.Error())
 
+		if service.IsPasswordPolicyError(err) {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodePasswordPolicy, err.Error(), nil))
+			return
+		}
 		switch err.Error() {
 		case "当前密码错误":
 			ctx.JSON(http.StatusForbidden, model.ErrorResponse(model.CodeAuthFailed, "当前密码错误", nil))
@@ -189,6 +213,91 @@ func (c *AuthController) ChangePassword(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, model.SuccessResponse("密码修改成功", nil))
 }
 
+// ForgotPassword 忘记密码
+//
+//	@Summary		忘记密码
+//	@Description	提交用户名或邮箱，向账户绑定的邮箱发送一次性重置链接；无论账户是否存在均返回相同结果
+//	@Tags			认证管理
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		model.ForgotPasswordRequest	true	"用户名或邮箱"
+//	@Success		200		{object}	model.Response{data=string}		"请求已受理"
+//	@Failure		400		{object}	model.Response{data=string}		"请求参数错误"
+//	@Failure		429		{object}	model.Response{data=string}		"请求过于频繁"
+//	@Failure		500		{object}	model.Response{data=string}		"服务器内部错误"
+//	@Router			/auth/forgot-password [post]
+func (c *AuthController) ForgotPassword(ctx *gin.Context) {
+	var req model.ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Warnf("忘记密码请求参数错误: %v", err)
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeInvalidParams, "请求参数错误", err.Error()))
+		return
+	}
+
+	clientIP := ctx.ClientIP()
+
+	if err := c.authService.ForgotPassword(ctx.Request.Context(), &req, clientIP); err != nil {
+		logger.AuthLog("forgot_password_failed", req.Account, clientIP, false, err.Error())
+
+		switch err.Error() {
+		case "请求过于频繁，请稍后再试":
+			ctx.JSON(http.StatusTooManyRequests, model.ErrorResponse(model.CodeTooManyRequests, err.Error(), nil))
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeServerError, "请求失败，请稍后再试", nil))
+		}
+		return
+	}
+
+	logger.AuthLog("forgot_password", req.Account, clientIP, true, "忘记密码请求已受理")
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("如果该账户存在且已绑定邮箱，重置密码邮件将很快送达", nil))
+}
+
+// ResetPasswordWithToken 通过重置链接设置新密码
+//
+//	@Summary		通过重置链接设置新密码
+//	@Description	使用邮件中的一次性令牌设置新密码；成功后令牌失效，该账户已登录的会话全部失效
+//	@Tags			认证管理
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		model.ResetPasswordWithTokenRequest	true	"重置令牌和新密码"
+//	@Success		200		{object}	model.Response{data=string}				"密码重置成功"
+//	@Failure		400		{object}	model.Response{data=string}				"重置链接无效或已过期、新密码不符合密码策略"
+//	@Failure		500		{object}	model.Response{data=string}				"服务器内部错误"
+//	@Router			/auth/reset-password [post]
+func (c *AuthController) ResetPasswordWithToken(ctx *gin.Context) {
+	var req model.ResetPasswordWithTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.Warnf("重置密码请求参数错误: %v", err)
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeInvalidParams, "请求参数错误", err.Error()))
+		return
+	}
+
+	clientIP := ctx.ClientIP()
+
+	userID, err := c.authService.ResetPasswordWithToken(ctx.Request.Context(), &req, clientIP)
+	if err != nil {
+		logger.AuthLog("reset_password_failed", "", clientIP, false, err.Error())
+
+		if service.IsPasswordPolicyError(err) {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodePasswordPolicy, err.Error(), nil))
+			return
+		}
+		switch err.Error() {
+		case "重置链接无效或已过期":
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeInvalidParams, err.Error(), nil))
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeServerError, "密码重置失败", nil))
+		}
+		return
+	}
+
+	logger.AuthLog("reset_password_success", userID, clientIP, true, "通过重置链接重置密码")
+	logger.BusinessLog("认证管理", "重置密码", userID, "通过重置链接重置密码，已吊销全部会话")
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("密码重置成功，请使用新密码登录", nil))
+}
+
 // RefreshToken 刷新令牌
 //
 //	@Summary		刷新访问令牌
@@ -278,7 +387,7 @@ func (c *AuthController) Logout(ctx *gin.Context) {
 // GetUserInfo 获取用户信息
 //
 //	@Summary		获取当前用户信息
-//	@Description	获取当前登录用户的详细信息
+//	@Description	获取当前登录用户的详细信息；平台管理员模拟登录时返回被模拟用户的信息，并在 impersonation 中返回实际操作人
 //	@Tags			认证管理
 //	@Accept			json
 //	@Produce		json
@@ -309,7 +418,26 @@ func (c *AuthController) GetUserInfo(ctx *gin.Context) {
 		return
 	}
 
+	// 模拟登录时返回实际操作人，前端据此显示模拟登录提示条；被模拟用户的改密要求不影响模拟登录
+	if impersonation, ok := middleware.GetImpersonation(ctx); ok {
+		userInfo.Impersonation = impersonation
+		userInfo.MustChangePassword = false
+	}
+
 	logger.BusinessLog("认证管理", "获取用户信息", userID, "获取用户信息成功")
 
 	ctx.JSON(http.StatusOK, model.SuccessResponse("获取成功", userInfo))
 }
+
+// GetJWKS 获取校验令牌的公钥集合
+//
+//	@Summary		获取JWKS公钥集合
+//	@Description	返回RS256/EdDSA签名密钥的公钥（JSON Web Key Set 标准格式，不包装响应结构），供其他内部服务按令牌头的 kid 校验令牌；HS256 共享密钥不公开
+//	@Tags			认证管理
+//	@Produce		json
+//	@Success		200	{object}	utils.JWKS	"获取成功"
+//	@Router			/.well-known/jwks.json [get]
+func (c *AuthController) GetJWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, c.authService.JWKS())
+}
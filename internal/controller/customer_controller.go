@@ -0,0 +1,407 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"YufungProject/internal/model"
+	"YufungProject/internal/service"
+)
+
+type CustomerController struct {
+	customerService *service.CustomerService
+}
+
+func NewCustomerController(customerService *service.CustomerService) *CustomerController {
+	return &CustomerController{
+		customerService: customerService,
+	}
+}
+
+// CreateCustomer 创建客户
+// @Summary 创建客户
+// @Description 新建客户档案，客户号在公司内唯一；该客户号下已有的保单会自动关联
+// @Tags 客户管理
+// @Accept json
+// @Produce json
+// @Param request body model.CustomerCreateRequest true "创建客户请求"
+// @Success 200 {object} model.Response{data=model.Customer} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/customers [post]
+func (c *CustomerController) CreateCustomer(ctx *gin.Context) {
+	var req model.CustomerCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	customer, err := c.customerService.CreateCustomer(ctx.Request.Context(), &req, userID.(string), companyID.(string), ctx.ClientIP(), ctx.GetHeader("User-Agent"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(customer))
+}
+
+// ListCustomers 获取客户列表
+// @Summary 获取客户列表
+// @Description 分页查询当前公司的客户档案
+// @Tags 客户管理
+// @Accept json
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param keyword query string false "关键词（客户号、姓名、证件号码、电话）"
+// @Param risk_level query string false "风险承受等级" Enums(R1, R2, R3, R4, R5)
+// @Param source query string false "来源" Enums(manual, policy)
+// @Success 200 {object} model.Response{data=model.CustomerListResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/customers [get]
+func (c *CustomerController) ListCustomers(ctx *gin.Context) {
+	var req model.CustomerQueryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	result, err := c.customerService.ListCustomers(ctx.Request.Context(), &req, companyID.(string))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
+
+// GetCustomer 获取客户详情
+// @Summary 获取客户详情
+// @Description 获取客户档案及其全部保单，并按币种汇总保费和AUM
+// @Tags 客户管理
+// @Accept json
+// @Produce json
+// @Param id path string true "客户ID"
+// @Success 200 {object} model.Response{data=model.CustomerDetailResponse} "成功"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "客户不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/customers/{id} [get]
+func (c *CustomerController) GetCustomer(ctx *gin.Context) {
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	detail, err := c.customerService.GetCustomerDetail(ctx.Request.Context(), ctx.Param("id"), companyID.(string))
+	if err != nil {
+		if err.Error() == "客户不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	service.MaskCustomerDetail(ctx.Request.Context(), detail)
+	ctx.JSON(http.StatusOK, model.Success(detail))
+}
+
+// UpdateCustomer 更新客户
+// @Summary 更新客户
+// @Description 更新客户档案，客户号或姓名变更时同步到关联保单并记录保单变更
+// @Tags 客户管理
+// @Accept json
+// @Produce json
+// @Param id path string true "客户ID"
+// @Param request body model.CustomerUpdateRequest true "更新客户请求"
+// @Success 200 {object} model.Response{data=model.Customer} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "客户不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/customers/{id} [put]
+func (c *CustomerController) UpdateCustomer(ctx *gin.Context) {
+	var req model.CustomerUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	customer, err := c.customerService.UpdateCustomer(ctx.Request.Context(), ctx.Param("id"), &req, userID.(string), companyID.(string), ctx.ClientIP(), ctx.GetHeader("User-Agent"))
+	if err != nil {
+		if err.Error() == "客户不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(customer))
+}
+
+// DeleteCustomer 删除客户
+// @Summary 删除客户
+// @Description 删除客户档案，存在关联保单时不允许删除
+// @Tags 客户管理
+// @Accept json
+// @Produce json
+// @Param id path string true "客户ID"
+// @Success 200 {object} model.Response "成功"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "客户不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/customers/{id} [delete]
+func (c *CustomerController) DeleteCustomer(ctx *gin.Context) {
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	if err := c.customerService.DeleteCustomer(ctx.Request.Context(), ctx.Param("id"), companyID.(string)); err != nil {
+		if err.Error() == "客户不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("删除成功", nil))
+}
+
+// LinkExistingPolicies 关联历史保单
+// @Summary 关联历史保单
+// @Description 为尚未关联客户档案的保单按客户号创建或关联客户档案
+// @Tags 客户管理
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response{data=model.CustomerLinkPoliciesResponse} "成功"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/customers/link-policies [post]
+func (c *CustomerController) LinkExistingPolicies(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	result, err := c.customerService.LinkExistingPolicies(ctx.Request.Context(), userID.(string), companyID.(string), ctx.ClientIP(), ctx.GetHeader("User-Agent"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
+
+// ScanDuplicateCustomers 扫描疑似重复客户
+// @Summary 扫描疑似重复客户
+// @Description 按规范化后的中文名（全半角、繁简、大小写、空格）、英文名（拼音）词重合及共用账户号为客户对评分，达到最低得分的加入待审核队列
+// @Tags 客户管理
+// @Accept json
+// @Produce json
+// @Param request body model.CustomerDuplicateScanRequest false "扫描请求"
+// @Success 200 {object} model.Response{data=model.CustomerDuplicateScanResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/customers/duplicates/scan [post]
+func (c *CustomerController) ScanDuplicateCustomers(ctx *gin.Context) {
+	var req model.CustomerDuplicateScanRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+			return
+		}
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	result, err := c.customerService.ScanDuplicateCustomers(ctx.Request.Context(), &req, userID.(string), companyID.(string))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
+
+// ListDuplicateCustomers 获取疑似重复客户审核队列
+// @Summary 获取疑似重复客户审核队列
+// @Description 分页查询疑似重复客户，按得分倒序，附带双方客户档案
+// @Tags 客户管理
+// @Accept json
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param status query string false "审核状态，默认pending" Enums(pending, merged, ignored)
+// @Success 200 {object} model.Response{data=model.CustomerDuplicateListResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/customers/duplicates [get]
+func (c *CustomerController) ListDuplicateCustomers(ctx *gin.Context) {
+	var req model.CustomerDuplicateQueryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	result, err := c.customerService.ListDuplicateCustomers(ctx.Request.Context(), &req, companyID.(string))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
+
+// IgnoreDuplicateCustomer 忽略疑似重复客户
+// @Summary 忽略疑似重复客户
+// @Description 确认两个客户并非同一人，重新扫描时不再提示
+// @Tags 客户管理
+// @Accept json
+// @Produce json
+// @Param id path string true "疑似重复记录ID"
+// @Success 200 {object} model.Response "成功"
+// @Failure 400 {object} model.Response "该记录已处理"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "疑似重复记录不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/customers/duplicates/{id}/ignore [post]
+func (c *CustomerController) IgnoreDuplicateCustomer(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	if err := c.customerService.IgnoreDuplicateCustomer(ctx.Request.Context(), ctx.Param("id"), userID.(string), companyID.(string)); err != nil {
+		switch err.Error() {
+		case "疑似重复记录不存在":
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+		case "该记录已处理":
+			ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("已忽略", nil))
+}
+
+// MergeCustomers 合并客户
+// @Summary 合并客户
+// @Description 将被合并客户的保单转移到保留客户并记录变更，被合并客户的客户号保留为曾用客户号，之后使用旧客户号录入或导入的保单自动关联到保留客户
+// @Tags 客户管理
+// @Accept json
+// @Produce json
+// @Param request body model.CustomerMergeRequest true "合并客户请求"
+// @Success 200 {object} model.Response{data=model.CustomerMergeResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "客户不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/customers/merge [post]
+func (c *CustomerController) MergeCustomers(ctx *gin.Context) {
+	var req model.CustomerMergeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	result, err := c.customerService.MergeCustomers(ctx.Request.Context(), &req, userID.(string), companyID.(string), ctx.ClientIP(), ctx.GetHeader("User-Agent"))
+	if err != nil {
+		switch err.Error() {
+		case "客户不存在":
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+		case "不能将客户与自身合并":
+			ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
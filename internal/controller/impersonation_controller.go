@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/model"
+	"YufungProject/internal/service"
+	"YufungProject/pkg/logger"
+)
+
+type ImpersonationController struct {
+	impersonationService *service.ImpersonationService
+}
+
+func NewImpersonationController(impersonationService *service.ImpersonationService) *ImpersonationController {
+	return &ImpersonationController{
+		impersonationService: impersonationService,
+	}
+}
+
+// StartImpersonation 开始模拟登录
+// @Summary 开始模拟登录
+// @Description 平台管理员以目标用户的身份登录，用于排查问题；返回的短期令牌不能刷新，期间的活动记录和变更记录同时记录实际操作人。配置禁止写操作或未指定 read_only=false 时为只读模式
+// @Tags 模拟登录
+// @Accept json
+// @Produce json
+// @Param request body model.ImpersonationStartRequest true "模拟登录信息"
+// @Success 200 {object} model.Response{data=model.ImpersonationStartResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误或目标用户不能被模拟"
+// @Failure 403 {object} model.Response "需要管理员权限或模拟登录未启用"
+// @Failure 404 {object} model.Response "用户不存在"
+// @Router /api/impersonation [post]
+func (c *ImpersonationController) StartImpersonation(ctx *gin.Context) {
+	var req model.ImpersonationStartRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+	username, _ := middleware.GetUsername(ctx)
+
+	result, err := c.impersonationService.StartImpersonation(ctx.Request.Context(), &req, userID, username, ctx.ClientIP(), ctx.Request.UserAgent())
+	if err != nil {
+		switch err.Error() {
+		case "模拟登录未启用":
+			ctx.JSON(http.StatusForbidden, model.ForbiddenError(err.Error()))
+		case "用户不存在":
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+		case "不能模拟自己登录", "只能模拟状态正常的用户", "不能模拟平台管理员":
+			ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		}
+		return
+	}
+
+	logger.BusinessLog("模拟登录", "开始模拟登录", userID, "模拟用户 "+result.Session.TargetUsername+" 登录，会话 "+result.Session.SessionID+"，原因："+req.Reason)
+	ctx.JSON(http.StatusOK, model.SuccessResponse("已开始模拟登录", result))
+}
+
+// StopImpersonation 结束当前模拟登录
+// @Summary 结束当前模拟登录
+// @Description 使用模拟登录令牌调用，结束后该令牌立即失效，前端恢复使用管理员令牌
+// @Tags 模拟登录
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response "成功"
+// @Failure 400 {object} model.Response "当前不是模拟登录"
+// @Router /api/impersonation/stop [post]
+func (c *ImpersonationController) StopImpersonation(ctx *gin.Context) {
+	impersonation, ok := middleware.GetImpersonation(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, "当前不是模拟登录"))
+		return
+	}
+
+	if err := c.impersonationService.StopImpersonation(ctx.Request.Context(), impersonation.SessionID, impersonation.ActorID, ctx.ClientIP()); err != nil {
+		if err.Error() == "模拟登录会话不存在或已结束" {
+			ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	logger.BusinessLog("模拟登录", "结束模拟登录", impersonation.ActorID, "结束模拟登录会话 "+impersonation.SessionID)
+	ctx.JSON(http.StatusOK, model.SuccessResponse("已结束模拟登录", nil))
+}
+
+// ListImpersonationSessions 获取模拟登录会话
+// @Summary 获取模拟登录会话
+// @Description 分页查询平台管理员的模拟登录记录，包括原因、时长和被拦截的写操作次数
+// @Tags 模拟登录
+// @Accept json
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param status query string false "状态" Enums(active, ended, expired)
+// @Param actor_id query string false "操作人ID"
+// @Param target_user_id query string false "被模拟用户ID"
+// @Param company_id query string false "公司ID"
+// @Success 200 {object} model.Response{data=model.ImpersonationListResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Router /api/impersonation/sessions [get]
+func (c *ImpersonationController) ListImpersonationSessions(ctx *gin.Context) {
+	var req model.ImpersonationQueryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	result, err := c.impersonationService.ListSessions(ctx.Request.Context(), &req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
+
+// EndImpersonationSession 强制结束模拟登录会话
+// @Summary 强制结束模拟登录会话
+// @Description 平台管理员结束任意进行中的模拟登录会话，会话的令牌立即失效
+// @Tags 模拟登录
+// @Accept json
+// @Produce json
+// @Param id path string true "会话ID"
+// @Success 200 {object} model.Response "成功"
+// @Failure 404 {object} model.Response "会话不存在或已结束"
+// @Router /api/impersonation/sessions/{id}/end [post]
+func (c *ImpersonationController) EndImpersonationSession(ctx *gin.Context) {
+	sessionID := ctx.Param("id")
+	userID, _ := middleware.GetUserID(ctx)
+
+	if err := c.impersonationService.StopImpersonation(ctx.Request.Context(), sessionID, userID, ctx.ClientIP()); err != nil {
+		if err.Error() == "模拟登录会话不存在或已结束" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	logger.BusinessLog("模拟登录", "强制结束模拟登录", userID, "结束模拟登录会话 "+sessionID)
+	ctx.JSON(http.StatusOK, model.SuccessResponse("模拟登录会话已结束", nil))
+}
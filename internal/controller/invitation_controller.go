@@ -0,0 +1,285 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/model"
+	"YufungProject/internal/service"
+	"YufungProject/pkg/logger"
+)
+
+type InvitationController struct {
+	invitationService *service.InvitationService
+}
+
+func NewInvitationController(invitationService *service.InvitationService) *InvitationController {
+	return &InvitationController{
+		invitationService: invitationService,
+	}
+}
+
+// CreateInvitation 邀请用户
+// @Summary 邀请用户
+// @Description 向邮箱发送一次性邀请链接并预分配角色；平台管理员可邀请到任意公司，其他用户只能邀请到本公司且不能分配平台管理员角色
+// @Tags 邀请管理
+// @Accept json
+// @Produce json
+// @Param request body model.InvitationCreateRequest true "邀请信息"
+// @Success 200 {object} model.Response{data=model.Invitation} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 403 {object} model.Response "权限不足"
+// @Failure 409 {object} model.Response "邮箱已存在或已有待接受的邀请"
+// @Router /api/invitations [post]
+func (c *InvitationController) CreateInvitation(ctx *gin.Context) {
+	var req model.InvitationCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+	roleIDs, _ := middleware.GetRoleIDs(ctx)
+	isAdmin := middleware.IsAdminRoles(roleIDs)
+	if !isAdmin {
+		if middleware.IsAdminRoles(req.RoleIDs) {
+			ctx.JSON(http.StatusForbidden, model.ForbiddenError("不能邀请平台管理员"))
+			return
+		}
+		req.CompanyID, _ = middleware.GetCompanyID(ctx)
+	} else if req.CompanyID == "" {
+		req.CompanyID, _ = middleware.GetCompanyID(ctx)
+	}
+
+	invitation, err := c.invitationService.CreateInvitation(ctx.Request.Context(), &req, userID, isAdmin)
+	if err != nil {
+		switch {
+		case err.Error() == "公司不存在":
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+		case err.Error() == "邮箱已存在":
+			ctx.JSON(http.StatusConflict, model.ErrorResponse(model.CodeEmailExists, err.Error(), nil))
+		case err.Error() == "该邮箱已有待接受的邀请":
+			ctx.JSON(http.StatusConflict, model.ConflictError(err.Error()))
+		case err.Error() == "公司用户数量已达配额上限":
+			ctx.JSON(http.StatusBadRequest, model.Error(model.CodeUserQuotaExceeded, err.Error()))
+		case err.Error() == "公司已停用，不能邀请用户", strings.HasPrefix(err.Error(), "角色"):
+			ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		}
+		return
+	}
+
+	logger.BusinessLog("邀请管理", "邀请用户", userID, "邀请 "+invitation.Email+" 加入公司 "+invitation.CompanyID)
+	ctx.JSON(http.StatusOK, model.SuccessResponse("邀请已发送", invitation))
+}
+
+// ListInvitations 获取邀请列表
+// @Summary 获取邀请列表
+// @Description 分页查询邀请；非平台管理员只能查看本公司的邀请
+// @Tags 邀请管理
+// @Accept json
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param status query string false "状态" Enums(pending, accepted, revoked, expired)
+// @Param email query string false "邮箱"
+// @Param company_id query string false "公司ID"
+// @Success 200 {object} model.Response{data=model.InvitationListResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 403 {object} model.Response "权限不足"
+// @Router /api/invitations [get]
+func (c *InvitationController) ListInvitations(ctx *gin.Context) {
+	var req model.InvitationQueryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	roleIDs, _ := middleware.GetRoleIDs(ctx)
+	if !middleware.IsAdminRoles(roleIDs) {
+		req.CompanyID, _ = middleware.GetCompanyID(ctx)
+	}
+
+	result, err := c.invitationService.ListInvitations(ctx.Request.Context(), &req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
+
+// ResendInvitation 重新发送邀请
+// @Summary 重新发送邀请
+// @Description 更换邀请链接并重新计算有效期，之前发出的链接失效
+// @Tags 邀请管理
+// @Accept json
+// @Produce json
+// @Param id path string true "邀请ID"
+// @Success 200 {object} model.Response{data=model.Invitation} "成功"
+// @Failure 400 {object} model.Response "只能重新发送待接受的邀请"
+// @Failure 404 {object} model.Response "邀请不存在"
+// @Router /api/invitations/{id}/resend [post]
+func (c *InvitationController) ResendInvitation(ctx *gin.Context) {
+	if !c.checkInvitationScope(ctx) {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	invitation, err := c.invitationService.ResendInvitation(ctx.Request.Context(), ctx.Param("id"), userID)
+	if err != nil {
+		switch err.Error() {
+		case "邀请不存在":
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+		case "公司用户数量已达配额上限":
+			ctx.JSON(http.StatusBadRequest, model.Error(model.CodeUserQuotaExceeded, err.Error()))
+		case "只能重新发送待接受的邀请", "公司已停用，不能邀请用户":
+			ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		}
+		return
+	}
+
+	logger.BusinessLog("邀请管理", "重新发送邀请", userID, "重新发送邀请 "+invitation.InvitationID)
+	ctx.JSON(http.StatusOK, model.SuccessResponse("邀请已重新发送", invitation))
+}
+
+// RevokeInvitation 撤销邀请
+// @Summary 撤销邀请
+// @Description 撤销待接受的邀请，邀请链接立即失效
+// @Tags 邀请管理
+// @Accept json
+// @Produce json
+// @Param id path string true "邀请ID"
+// @Success 200 {object} model.Response "成功"
+// @Failure 400 {object} model.Response "只能撤销待接受的邀请"
+// @Failure 404 {object} model.Response "邀请不存在"
+// @Router /api/invitations/{id}/revoke [post]
+func (c *InvitationController) RevokeInvitation(ctx *gin.Context) {
+	if !c.checkInvitationScope(ctx) {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	if err := c.invitationService.RevokeInvitation(ctx.Request.Context(), ctx.Param("id"), userID); err != nil {
+		switch err.Error() {
+		case "邀请不存在":
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+		case "只能撤销待接受的邀请":
+			ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		}
+		return
+	}
+
+	logger.BusinessLog("邀请管理", "撤销邀请", userID, "撤销邀请 "+ctx.Param("id"))
+	ctx.JSON(http.StatusOK, model.SuccessResponse("邀请已撤销", nil))
+}
+
+// PreviewInvitation 查看邀请信息
+// @Summary 查看邀请信息
+// @Description 受邀人打开邀请链接时获取受邀邮箱和公司名称，无需登录
+// @Tags 认证管理
+// @Accept json
+// @Produce json
+// @Param token query string true "邀请令牌"
+// @Success 200 {object} model.Response{data=model.InvitationPreview} "成功"
+// @Failure 400 {object} model.Response "邀请链接无效或已过期"
+// @Router /api/auth/invitation [get]
+func (c *InvitationController) PreviewInvitation(ctx *gin.Context) {
+	preview, err := c.invitationService.PreviewInvitation(ctx.Request.Context(), ctx.Query("token"))
+	if err != nil {
+		if err.Error() == "邀请链接无效或已过期" {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeInvalidParams, err.Error(), nil))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(preview))
+}
+
+// AcceptInvitation 接受邀请
+// @Summary 接受邀请
+// @Description 受邀人设置用户名和密码后创建账户，邮箱、公司和角色以邀请为准；链接只能使用一次，无需登录
+// @Tags 认证管理
+// @Accept json
+// @Produce json
+// @Param request body model.AcceptInvitationRequest true "账户信息"
+// @Success 201 {object} model.Response{data=model.UserInfo} "成功"
+// @Failure 400 {object} model.Response "邀请链接无效或已过期、密码不符合策略或公司用户数量已达上限"
+// @Failure 409 {object} model.Response "用户名或邮箱已存在"
+// @Router /api/auth/accept-invitation [post]
+func (c *InvitationController) AcceptInvitation(ctx *gin.Context) {
+	var req model.AcceptInvitationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	clientIP := ctx.ClientIP()
+
+	user, err := c.invitationService.AcceptInvitation(ctx.Request.Context(), &req, clientIP)
+	if err != nil {
+		logger.AuthLog("accept_invitation_failed", req.Username, clientIP, false, err.Error())
+
+		if service.IsPasswordPolicyError(err) {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodePasswordPolicy, err.Error(), nil))
+			return
+		}
+		switch err.Error() {
+		case "邀请链接无效或已过期":
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeInvalidParams, err.Error(), nil))
+		case "用户名已存在":
+			ctx.JSON(http.StatusConflict, model.ErrorResponse(model.CodeUserExists, err.Error(), nil))
+		case "邮箱已存在":
+			ctx.JSON(http.StatusConflict, model.ErrorResponse(model.CodeEmailExists, err.Error(), nil))
+		case "公司用户数量已达配额上限":
+			ctx.JSON(http.StatusBadRequest, model.Error(model.CodeUserQuotaExceeded, err.Error()))
+		case "公司已停用，不能加入":
+			ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+		case "系统繁忙，请稍后再试":
+			ctx.JSON(http.StatusServiceUnavailable, model.Error(model.CodeServerError, err.Error()))
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeServerError, "接受邀请失败", err.Error()))
+		}
+		return
+	}
+
+	logger.AuthLog("accept_invitation_success", user.Username, clientIP, true, "接受邀请并创建账户")
+	logger.BusinessLog("认证管理", "接受邀请", user.UserID, "通过邀请加入公司 "+user.CompanyID)
+
+	ctx.JSON(http.StatusCreated, model.SuccessResponse("注册成功", user))
+}
+
+// checkInvitationScope 非平台管理员只能操作本公司的邀请，其他公司的邀请按不存在处理
+func (c *InvitationController) checkInvitationScope(ctx *gin.Context) bool {
+	roleIDs, _ := middleware.GetRoleIDs(ctx)
+	if middleware.IsAdminRoles(roleIDs) {
+		return true
+	}
+
+	invitation, err := c.invitationService.GetInvitation(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		if err.Error() == "邀请不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return false
+	}
+
+	companyID, _ := middleware.GetCompanyID(ctx)
+	if invitation.CompanyID != companyID {
+		ctx.JSON(http.StatusNotFound, model.NotFoundError("邀请不存在"))
+		return false
+	}
+	return true
+}
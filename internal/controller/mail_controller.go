@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/model"
+	"YufungProject/internal/service"
+)
+
+type MailController struct {
+	mailService *service.MailService
+}
+
+func NewMailController(mailService *service.MailService) *MailController {
+	return &MailController{
+		mailService: mailService,
+	}
+}
+
+// ListMessages 获取发件箱列表
+// @Summary 获取发件箱列表
+// @Description 分页查询已发送、待发送和发送失败的邮件（不含正文），同时返回各状态数量
+// @Tags 邮件管理
+// @Accept json
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param status query string false "状态" Enums(pending, sending, sent, failed)
+// @Param template query string false "模板名称"
+// @Param to query string false "收件人"
+// @Param company_id query string false "公司ID"
+// @Param start_date query string false "开始日期(2006-01-02)"
+// @Param end_date query string false "结束日期(2006-01-02)"
+// @Success 200 {object} model.Response{data=model.MailListResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 403 {object} model.Response "需要管理员权限"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/mail/messages [get]
+func (c *MailController) ListMessages(ctx *gin.Context) {
+	var req model.MailQueryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	result, err := c.mailService.ListMessages(ctx.Request.Context(), &req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
+
+// GetMessage 获取邮件详情
+// @Summary 获取邮件详情
+// @Description 获取邮件详情及发送记录，包含重置链接等敏感内容的邮件不返回正文
+// @Tags 邮件管理
+// @Accept json
+// @Produce json
+// @Param id path string true "邮件ID"
+// @Success 200 {object} model.Response{data=model.MailMessage} "成功"
+// @Failure 403 {object} model.Response "需要管理员权限"
+// @Failure 404 {object} model.Response "邮件不存在"
+// @Router /api/mail/messages/{id} [get]
+func (c *MailController) GetMessage(ctx *gin.Context) {
+	message, err := c.mailService.GetMessage(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		if err.Error() == "邮件不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(message))
+}
+
+// RetryMessage 重新发送失败的邮件
+// @Summary 重新发送失败的邮件
+// @Description 将发送失败的邮件重新放入发件箱，发送次数重新计算
+// @Tags 邮件管理
+// @Accept json
+// @Produce json
+// @Param id path string true "邮件ID"
+// @Success 200 {object} model.Response "成功"
+// @Failure 400 {object} model.Response "只能重新发送失败的邮件"
+// @Failure 403 {object} model.Response "需要管理员权限"
+// @Failure 404 {object} model.Response "邮件不存在"
+// @Router /api/mail/messages/{id}/retry [post]
+func (c *MailController) RetryMessage(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	if err := c.mailService.RetryMessage(ctx.Request.Context(), ctx.Param("id"), userID); err != nil {
+		switch err.Error() {
+		case "邮件不存在":
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+		case "只能重新发送失败的邮件":
+			ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("已重新加入发件箱", nil))
+}
+
+// SendTestMail 发送测试邮件
+// @Summary 发送测试邮件
+// @Description 向指定地址发送测试邮件，用于检查邮件发送配置；邮件入队后由发件箱任务发送
+// @Tags 邮件管理
+// @Accept json
+// @Produce json
+// @Param request body model.MailTestRequest true "收件人"
+// @Success 200 {object} model.Response{data=model.MailMessage} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 403 {object} model.Response "需要管理员权限"
+// @Router /api/mail/test [post]
+func (c *MailController) SendTestMail(ctx *gin.Context) {
+	var req model.MailTestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+
+	message, err := c.mailService.SendTestMail(ctx.Request.Context(), &req, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("测试邮件已加入发件箱", message))
+}
+
+// ProcessOutbox 立即处理发件箱
+// @Summary 立即处理发件箱
+// @Description 立即发送到期的邮件，不等待定时任务
+// @Tags 邮件管理
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response{data=model.MailProcessResult} "成功"
+// @Failure 403 {object} model.Response "需要管理员权限"
+// @Failure 409 {object} model.Response "发件箱正在处理中"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/mail/process [post]
+func (c *MailController) ProcessOutbox(ctx *gin.Context) {
+	result, err := c.mailService.ProcessOutbox(ctx.Request.Context(), time.Now())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+	if result == nil {
+		ctx.JSON(http.StatusConflict, model.ConflictError("发件箱正在处理中，请稍后再试"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
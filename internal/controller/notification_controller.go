@@ -0,0 +1,212 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"YufungProject/internal/model"
+	"YufungProject/internal/service"
+)
+
+type NotificationController struct {
+	notificationService *service.NotificationService
+}
+
+func NewNotificationController(notificationService *service.NotificationService) *NotificationController {
+	return &NotificationController{
+		notificationService: notificationService,
+	}
+}
+
+// ListNotifications 获取通知列表
+// @Summary 获取通知列表
+// @Description 分页查询当前用户的站内通知，按创建时间倒序，同时返回未读数量
+// @Tags 通知中心
+// @Accept json
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param type query string false "通知类型" Enums(premium_due, cooling_off_end, policy_anniversary, company_expiry, account_locked)
+// @Param is_read query bool false "是否已读"
+// @Success 200 {object} model.Response{data=model.NotificationListResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/notifications [get]
+func (c *NotificationController) ListNotifications(ctx *gin.Context) {
+	var req model.NotificationQueryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	result, err := c.notificationService.ListNotifications(ctx.Request.Context(), &req, userID.(string))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
+
+// GetUnreadCount 获取未读通知数量
+// @Summary 获取未读通知数量
+// @Description 获取当前用户的未读通知数量
+// @Tags 通知中心
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response{data=map[string]int64} "成功"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/notifications/unread-count [get]
+func (c *NotificationController) GetUnreadCount(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	count, err := c.notificationService.CountUnread(ctx.Request.Context(), userID.(string))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(gin.H{"unread_count": count}))
+}
+
+// MarkRead 标记通知已读
+// @Summary 标记通知已读
+// @Description 将当前用户的通知标记为已读，不传通知ID时标记全部未读通知
+// @Tags 通知中心
+// @Accept json
+// @Produce json
+// @Param request body model.NotificationMarkReadRequest false "标记已读请求"
+// @Success 200 {object} model.Response{data=map[string]int64} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/notifications/read [post]
+func (c *NotificationController) MarkRead(ctx *gin.Context) {
+	var req model.NotificationMarkReadRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+			return
+		}
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	count, err := c.notificationService.MarkRead(ctx.Request.Context(), &req, userID.(string))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(gin.H{"updated": count}))
+}
+
+// GetPreferences 获取通知偏好
+// @Summary 获取通知偏好
+// @Description 获取当前用户各类提醒的接收设置，未设置的类型默认接收
+// @Tags 通知中心
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response{data=[]model.NotificationPreferenceItem} "成功"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/notifications/preferences [get]
+func (c *NotificationController) GetPreferences(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	items, err := c.notificationService.GetPreferences(ctx.Request.Context(), userID.(string))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(items))
+}
+
+// UpdatePreferences 更新通知偏好
+// @Summary 更新通知偏好
+// @Description 设置当前用户是否接收各类提醒，只更新传入的类型
+// @Tags 通知中心
+// @Accept json
+// @Produce json
+// @Param request body model.NotificationPreferenceUpdateRequest true "通知偏好"
+// @Success 200 {object} model.Response{data=[]model.NotificationPreferenceItem} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/notifications/preferences [put]
+func (c *NotificationController) UpdatePreferences(ctx *gin.Context) {
+	var req model.NotificationPreferenceUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	items, err := c.notificationService.UpdatePreferences(ctx.Request.Context(), &req, userID.(string), companyID.(string))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(items))
+}
+
+// RunReminderRules 立即执行提醒规则
+// @Summary 立即执行提醒规则
+// @Description 管理员手动执行一次提醒规则，已生成过的提醒不会重复发送
+// @Tags 通知中心
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response{data=model.NotificationRunResult} "成功"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 403 {object} model.Response "需要管理员权限"
+// @Failure 409 {object} model.Response "提醒规则正在执行"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/notifications/run [post]
+func (c *NotificationController) RunReminderRules(ctx *gin.Context) {
+	result, err := c.notificationService.RunReminderRules(ctx.Request.Context(), time.Now())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+	if result == nil {
+		ctx.JSON(http.StatusConflict, model.ConflictError("提醒规则正在执行，请稍后再试"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
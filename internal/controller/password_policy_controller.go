@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/model"
+	"YufungProject/internal/service"
+)
+
+type PasswordPolicyController struct {
+	policyService *service.PasswordPolicyService
+}
+
+func NewPasswordPolicyController(policyService *service.PasswordPolicyService) *PasswordPolicyController {
+	return &PasswordPolicyController{
+		policyService: policyService,
+	}
+}
+
+// canManageCompanyPolicy 平台管理员可管理所有公司的策略，其他用户只能管理本公司的策略
+func canManageCompanyPolicy(ctx *gin.Context, companyID string) bool {
+	roleIDs, _ := middleware.GetRoleIDs(ctx)
+	if middleware.IsAdminRoles(roleIDs) {
+		return true
+	}
+	ownCompanyID, _ := middleware.GetCompanyID(ctx)
+	return ownCompanyID == companyID
+}
+
+// GetMyPolicy 获取当前用户适用的密码策略
+// @Summary 获取当前用户适用的密码策略
+// @Description 返回当前用户所属公司实际生效的密码策略，用于修改密码页面提示；须修改密码的用户也可访问
+// @Tags 密码策略
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response{data=model.PasswordPolicy} "成功"
+// @Failure 401 {object} model.Response "未登录"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/auth/password-policy [get]
+func (c *PasswordPolicyController) GetMyPolicy(ctx *gin.Context) {
+	companyID, _ := middleware.GetCompanyID(ctx)
+
+	policy, err := c.policyService.EffectivePolicy(ctx.Request.Context(), companyID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(policy))
+}
+
+// GetPlatformPolicy 获取平台密码策略
+// @Summary 获取平台密码策略
+// @Description 未在系统中保存时返回配置文件中的默认策略
+// @Tags 密码策略
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response{data=model.PasswordPolicy} "成功"
+// @Failure 403 {object} model.Response "需要管理员权限"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/password-policies/platform [get]
+func (c *PasswordPolicyController) GetPlatformPolicy(ctx *gin.Context) {
+	policy, err := c.policyService.GetPlatformPolicy(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(policy))
+}
+
+// UpdatePlatformPolicy 设置平台密码策略
+// @Summary 设置平台密码策略
+// @Description 平台策略对所有公司生效，公司策略只能在此基础上加强
+// @Tags 密码策略
+// @Accept json
+// @Produce json
+// @Param request body model.PasswordPolicyRequest true "密码策略"
+// @Success 200 {object} model.Response{data=model.PasswordPolicy} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 403 {object} model.Response "需要管理员权限"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/password-policies/platform [put]
+func (c *PasswordPolicyController) UpdatePlatformPolicy(ctx *gin.Context) {
+	var req model.PasswordPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+
+	policy, err := c.policyService.UpdatePlatformPolicy(ctx.Request.Context(), &req, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("平台密码策略已保存", policy))
+}
+
+// GetCompanyPolicy 获取公司密码策略
+// @Summary 获取公司密码策略
+// @Description 返回平台策略、公司策略（未设置时为空）及实际生效的策略
+// @Tags 密码策略
+// @Accept json
+// @Produce json
+// @Param company_id path string true "公司ID"
+// @Success 200 {object} model.Response{data=model.PasswordPolicyDetail} "成功"
+// @Failure 403 {object} model.Response "没有操作权限"
+// @Failure 404 {object} model.Response "公司不存在"
+// @Router /api/password-policies/companies/{company_id} [get]
+func (c *PasswordPolicyController) GetCompanyPolicy(ctx *gin.Context) {
+	companyID := ctx.Param("company_id")
+	if !canManageCompanyPolicy(ctx, companyID) {
+		ctx.JSON(http.StatusForbidden, model.ForbiddenError("只能查看本公司的密码策略"))
+		return
+	}
+
+	detail, err := c.policyService.GetCompanyPolicyDetail(ctx.Request.Context(), companyID)
+	if err != nil {
+		if err.Error() == "公司不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(detail))
+}
+
+// UpdateCompanyPolicy 设置公司密码策略
+// @Summary 设置公司密码策略
+// @Description 公司策略与平台策略逐项取更严格的设置，低于平台策略的设置不生效
+// @Tags 密码策略
+// @Accept json
+// @Produce json
+// @Param company_id path string true "公司ID"
+// @Param request body model.PasswordPolicyRequest true "密码策略"
+// @Success 200 {object} model.Response{data=model.PasswordPolicyDetail} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 403 {object} model.Response "没有操作权限"
+// @Failure 404 {object} model.Response "公司不存在"
+// @Router /api/password-policies/companies/{company_id} [put]
+func (c *PasswordPolicyController) UpdateCompanyPolicy(ctx *gin.Context) {
+	companyID := ctx.Param("company_id")
+	if !canManageCompanyPolicy(ctx, companyID) {
+		ctx.JSON(http.StatusForbidden, model.ForbiddenError("只能设置本公司的密码策略"))
+		return
+	}
+
+	var req model.PasswordPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+
+	detail, err := c.policyService.UpdateCompanyPolicy(ctx.Request.Context(), companyID, &req, userID)
+	if err != nil {
+		if err.Error() == "公司不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("公司密码策略已保存", detail))
+}
+
+// DeleteCompanyPolicy 删除公司密码策略
+// @Summary 删除公司密码策略
+// @Description 删除后该公司使用平台策略
+// @Tags 密码策略
+// @Accept json
+// @Produce json
+// @Param company_id path string true "公司ID"
+// @Success 200 {object} model.Response "成功"
+// @Failure 403 {object} model.Response "没有操作权限"
+// @Failure 404 {object} model.Response "公司未设置密码策略"
+// @Router /api/password-policies/companies/{company_id} [delete]
+func (c *PasswordPolicyController) DeleteCompanyPolicy(ctx *gin.Context) {
+	companyID := ctx.Param("company_id")
+	if !canManageCompanyPolicy(ctx, companyID) {
+		ctx.JSON(http.StatusForbidden, model.ForbiddenError("只能设置本公司的密码策略"))
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+
+	if err := c.policyService.DeleteCompanyPolicy(ctx.Request.Context(), companyID, userID); err != nil {
+		if err.Error() == "公司未设置密码策略" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("已恢复使用平台密码策略", nil))
+}
@@ -0,0 +1,192 @@
+package controller
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"YufungProject/internal/model"
+	"YufungProject/internal/service"
+	"YufungProject/pkg/logger"
+)
+
+type PolicyAttachmentController struct {
+	attachmentService *service.PolicyAttachmentService
+}
+
+func NewPolicyAttachmentController(attachmentService *service.PolicyAttachmentService) *PolicyAttachmentController {
+	return &PolicyAttachmentController{
+		attachmentService: attachmentService,
+	}
+}
+
+// UploadAttachment 上传保单附件
+// @Summary 上传保单附件
+// @Description 上传保单相关文档，文件类型和大小受上传配置限制，并按文件内容校验类型
+// @Tags 保单管理
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "保单ID"
+// @Param file formData file true "附件文件"
+// @Param category formData string true "附件类别" Enums(application_form, id_copy, receipt, other)
+// @Param remark formData string false "备注"
+// @Success 200 {object} model.Response{data=model.PolicyAttachment} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "保单不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/policies/{id}/attachments [post]
+func (c *PolicyAttachmentController) UploadAttachment(ctx *gin.Context) {
+	file, header, err := ctx.Request.FormFile("file")
+	if err != nil {
+		logger.Warnf("获取上传文件失败: %v", err)
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeInvalidParams, "请选择要上传的文件", err.Error()))
+		return
+	}
+	defer file.Close()
+
+	var req model.PolicyAttachmentUploadRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	attachment, err := c.attachmentService.UploadAttachment(ctx.Request.Context(), ctx.Param("id"), &req, file, header, userID.(string), companyID.(string), ctx.ClientIP(), ctx.GetHeader("User-Agent"))
+	if err != nil {
+		switch err.Error() {
+		case "保单不存在":
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+		default:
+			ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("上传成功", attachment))
+}
+
+// ListAttachments 获取保单附件列表
+// @Summary 获取保单附件列表
+// @Description 获取保单的全部附件，按上传时间倒序
+// @Tags 保单管理
+// @Accept json
+// @Produce json
+// @Param id path string true "保单ID"
+// @Success 200 {object} model.Response{data=[]model.PolicyAttachment} "成功"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "保单不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/policies/{id}/attachments [get]
+func (c *PolicyAttachmentController) ListAttachments(ctx *gin.Context) {
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	attachments, err := c.attachmentService.ListAttachments(ctx.Request.Context(), ctx.Param("id"), companyID.(string))
+	if err != nil {
+		if err.Error() == "保单不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(attachments))
+}
+
+// DownloadAttachment 下载保单附件
+// @Summary 下载保单附件
+// @Description 下载本公司保单的附件
+// @Tags 保单管理
+// @Produce octet-stream
+// @Param id path string true "保单ID"
+// @Param attachment_id path string true "附件ID"
+// @Success 200 {file} file "附件文件"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "附件不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/policies/{id}/attachments/{attachment_id}/download [get]
+func (c *PolicyAttachmentController) DownloadAttachment(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	attachment, reader, err := c.attachmentService.OpenAttachment(ctx.Request.Context(), ctx.Param("id"), ctx.Param("attachment_id"), userID.(string), companyID.(string))
+	if err != nil {
+		if err.Error() == "附件不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+	defer reader.Close()
+
+	headers := map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+	}
+	ctx.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, reader, headers)
+}
+
+// DeleteAttachment 删除保单附件
+// @Summary 删除保单附件
+// @Description 删除保单附件并记录变更
+// @Tags 保单管理
+// @Accept json
+// @Produce json
+// @Param id path string true "保单ID"
+// @Param attachment_id path string true "附件ID"
+// @Success 200 {object} model.Response "成功"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "附件不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/policies/{id}/attachments/{attachment_id} [delete]
+func (c *PolicyAttachmentController) DeleteAttachment(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	if err := c.attachmentService.DeleteAttachment(ctx.Request.Context(), ctx.Param("id"), ctx.Param("attachment_id"), userID.(string), companyID.(string), ctx.ClientIP(), ctx.GetHeader("User-Agent")); err != nil {
+		if err.Error() == "附件不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("删除成功", nil))
+}
@@ -8,6 +8,7 @@ import (
 	"YufungProject/internal/model"
 	"YufungProject/internal/service"
 	"YufungProject/pkg/logger"
+	"YufungProject/pkg/validator"
 	"errors"
 )
 
@@ -55,10 +56,16 @@ func (c *PolicyController) CreatePolicy(ctx *gin.Context) {
 
 	policy, err := c.policyService.CreatePolicy(ctx.Request.Context(), &req, userID.(string), companyID.(string))
 	if err != nil {
+		var validationErrs validator.Errors
+		if errors.As(err, &validationErrs) {
+			ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
 		return
 	}
 
+	service.MaskPolicyResponses(ctx.Request.Context(), policy)
 	ctx.JSON(http.StatusOK, model.Success(policy))
 }
 
@@ -98,6 +105,7 @@ func (c *PolicyController) GetPolicy(ctx *gin.Context) {
 		return
 	}
 
+	service.MaskPolicyResponses(ctx.Request.Context(), policy)
 	ctx.JSON(http.StatusOK, model.Success(policy))
 }
 
@@ -161,10 +169,16 @@ func (c *PolicyController) UpdatePolicy(ctx *gin.Context) {
 			ctx.JSON(http.StatusNotFound, model.NotFoundError("保单不存在"))
 			return
 		}
+		var validationErrs validator.Errors
+		if errors.As(err, &validationErrs) {
+			ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
 		return
 	}
 
+	service.MaskPolicyResponses(ctx.Request.Context(), policy)
 	ctx.JSON(http.StatusOK, model.Success(policy))
 }
 
@@ -243,6 +257,7 @@ func (c *PolicyController) DeletePolicy(ctx *gin.Context) {
 // @Param effective_date_end query string false "生效日期结束" format(date)
 // @Param sort_by query string false "排序字段"
 // @Param sort_order query string false "排序方向" Enums(asc, desc)
+// @Param custom_fields[字段名] query string false "自定义字段筛选（文本模糊匹配，其他类型精确匹配）"
 // @Success 200 {object} model.Response{data=model.PolicyListResponse} "成功"
 // @Failure 400 {object} model.Response "请求参数错误"
 // @Failure 401 {object} model.Response "未授权"
@@ -254,6 +269,7 @@ func (c *PolicyController) ListPolicies(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
 		return
 	}
+	req.CustomFields = ctx.QueryMap("custom_fields")
 
 	companyID, exists := ctx.Get("company_id")
 	if !exists {
@@ -267,6 +283,12 @@ func (c *PolicyController) ListPolicies(ctx *gin.Context) {
 		return
 	}
 
+	responses := make([]*model.PolicyResponse, len(policies.List))
+	for i := range policies.List {
+		responses[i] = &policies.List[i]
+	}
+	service.MaskPolicyResponses(ctx.Request.Context(), responses...)
+
 	ctx.JSON(http.StatusOK, model.Success(policies))
 }
 
@@ -395,18 +417,25 @@ func (c *PolicyController) ExportPolicies(ctx *gin.Context) {
 
 // DownloadPolicyTemplate 下载保单导入模板
 // @Summary 下载保单导入模板
-// @Description 下载用于批量导入的保单模板文件
+// @Description 下载用于批量导入的保单模板文件，包含当前公司的自定义字段列
 // @Tags 保单管理
 // @Accept json
 // @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Param type query string false "模板类型" Enums(xlsx, csv) default(xlsx)
 // @Success 200 {file} file "模板文件"
+// @Failure 401 {object} model.Response "未授权"
 // @Failure 500 {object} model.Response "服务器错误"
 // @Router /api/policies/template [get]
 func (c *PolicyController) DownloadPolicyTemplate(ctx *gin.Context) {
 	templateType := ctx.DefaultQuery("type", "xlsx")
 
-	fileData, fileName, err := c.policyService.GeneratePolicyTemplate(ctx.Request.Context(), templateType)
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	fileData, fileName, err := c.policyService.GeneratePolicyTemplate(ctx.Request.Context(), templateType, companyID.(string))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
 		return
@@ -456,6 +485,11 @@ func (c *PolicyController) PreviewPolicyImport(ctx *gin.Context) {
 		return
 	}
 
+	// 预览时按当前公司的字典校验模式检查数据
+	if companyID, exists := ctx.Get("company_id"); exists {
+		req.CompanyID = companyID.(string)
+	}
+
 	// 记录操作日志
 	userID, _ := ctx.Get("user_id")
 	logger.BusinessLog("保单管理", "预览导入", userID.(string), "文件名: "+header.Filename)
@@ -527,92 +561,118 @@ func (c *PolicyController) ImportPoliciesFromFile(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, model.SuccessResponse("导入完成", response))
 }
 
+// RemapDictionaryValues 批量重映射保单字典值
+// @Summary 批量重映射保单字典值
+// @Description 将保单中港分客户经理、转介分行、合作伙伴的历史值按系统配置规范化为配置键，支持手工映射与预览
+// @Tags 保单管理
+// @Accept json
+// @Produce json
+// @Param request body model.PolicyDictionaryRemapRequest true "重映射请求"
+// @Success 200 {object} model.Response{data=model.PolicyDictionaryRemapResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/policies/dictionary-remap [post]
+func (c *PolicyController) RemapDictionaryValues(ctx *gin.Context) {
+	var req model.PolicyDictionaryRemapRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	result, err := c.policyService.RemapDictionaryValues(ctx.Request.Context(), &req, userID.(string), companyID.(string), ctx.ClientIP(), ctx.GetHeader("User-Agent"))
+	if err != nil {
+		if errors.Is(err, service.ErrDictionaryRemapInvalid) {
+			ctx.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeInvalidParams, err.Error(), nil))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
+
+// RecalculateReferralFees 按规则重算保单转介费
+// @Summary 按规则重算保单转介费
+// @Description 按当前转介费规则重算指定或筛选范围内保单的转介费率及预计转介费，返回变更前后差异，支持仅预览
+// @Tags 保单管理
+// @Accept json
+// @Produce json
+// @Param request body model.ReferralFeeRecalculateRequest true "重算请求"
+// @Success 200 {object} model.Response{data=model.ReferralFeeRecalculateResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/policies/recalculate-fees [post]
+func (c *PolicyController) RecalculateReferralFees(ctx *gin.Context) {
+	var req model.ReferralFeeRecalculateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	result, err := c.policyService.RecalculateReferralFees(
+		ctx.Request.Context(),
+		&req,
+		userID.(string),
+		companyID.(string),
+		ctx.ClientIP(),
+		ctx.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
+
 // GetPolicyValidationRules 获取保单字段验证规则
 // @Summary 获取保单字段验证规则
-// @Description 获取保单各字段的验证规则，用于前端表单验证
+// @Description 获取保单各字段（含公司自定义字段）的验证规则，与保存及导入时执行的规则一致，用于前端表单验证
 // @Tags 保单管理
 // @Accept json
 // @Produce json
-// @Success 200 {object} model.Response{data=map[string]interface{}} "成功"
+// @Success 200 {object} model.Response{data=map[string]validator.Rule} "成功"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
 // @Router /api/policies/validation-rules [get]
 func (c *PolicyController) GetPolicyValidationRules(ctx *gin.Context) {
-	rules := map[string]interface{}{
-		"account_number": map[string]interface{}{
-			"required":  false,
-			"type":      "string",
-			"maxLength": 50,
-		},
-		"customer_number": map[string]interface{}{
-			"required":  true,
-			"type":      "string",
-			"maxLength": 50,
-		},
-		"customer_name_cn": map[string]interface{}{
-			"required":  true,
-			"type":      "string",
-			"maxLength": 100,
-		},
-		"customer_name_en": map[string]interface{}{
-			"required":  false,
-			"type":      "string",
-			"maxLength": 100,
-		},
-		"proposal_number": map[string]interface{}{
-			"required":  true,
-			"type":      "string",
-			"maxLength": 50,
-		},
-		"policy_currency": map[string]interface{}{
-			"required": true,
-			"type":     "string",
-			"enum":     []string{"USD", "HKD", "CNY"},
-		},
-		"payment_method": map[string]interface{}{
-			"required": false,
-			"type":     "string",
-			"enum":     []string{"期缴", "趸缴", "预缴"},
-		},
-		"actual_premium": map[string]interface{}{
-			"required": false,
-			"type":     "number",
-			"min":      0,
-		},
-		"aum": map[string]interface{}{
-			"required": false,
-			"type":     "number",
-			"min":      0,
-		},
-		"referral_rate": map[string]interface{}{
-			"required": false,
-			"type":     "number",
-			"min":      0,
-			"max":      100,
-		},
-		"exchange_rate": map[string]interface{}{
-			"required": false,
-			"type":     "number",
-			"min":      0,
-		},
-		"expected_fee": map[string]interface{}{
-			"required": false,
-			"type":     "number",
-			"min":      0,
-		},
-		"insurance_company": map[string]interface{}{
-			"required":  true,
-			"type":      "string",
-			"maxLength": 100,
-		},
-		"product_name": map[string]interface{}{
-			"required":  true,
-			"type":      "string",
-			"maxLength": 200,
-		},
-		"product_type": map[string]interface{}{
-			"required":  true,
-			"type":      "string",
-			"maxLength": 100,
-		},
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	rules, err := c.policyService.GetPolicyValidationRules(ctx.Request.Context(), companyID.(string))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
 	}
 
 	ctx.JSON(http.StatusOK, model.Success(rules))
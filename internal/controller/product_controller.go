@@ -0,0 +1,525 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"YufungProject/internal/model"
+	"YufungProject/internal/service"
+	"YufungProject/pkg/logger"
+)
+
+type ProductController struct {
+	productService *service.ProductService
+}
+
+func NewProductController(productService *service.ProductService) *ProductController {
+	return &ProductController{
+		productService: productService,
+	}
+}
+
+// ==========================
+// 承保公司
+// ==========================
+
+// CreateInsurer 创建承保公司
+// @Summary 创建承保公司
+// @Description 在产品目录中新增承保公司
+// @Tags 产品管理
+// @Accept json
+// @Produce json
+// @Param request body model.InsurerCreateRequest true "创建承保公司请求"
+// @Success 200 {object} model.Response{data=model.Insurer} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/insurers [post]
+func (c *ProductController) CreateInsurer(ctx *gin.Context) {
+	var req model.InsurerCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	insurer, err := c.productService.CreateInsurer(ctx.Request.Context(), &req, userID.(string), companyID.(string))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(insurer))
+}
+
+// ListInsurers 获取承保公司列表
+// @Summary 获取承保公司列表
+// @Description 获取当前公司的承保公司列表
+// @Tags 产品管理
+// @Accept json
+// @Produce json
+// @Param status query string false "状态" Enums(enable, disable)
+// @Success 200 {object} model.Response{data=[]model.Insurer} "成功"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/insurers [get]
+func (c *ProductController) ListInsurers(ctx *gin.Context) {
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	insurers, err := c.productService.ListInsurers(ctx.Request.Context(), companyID.(string), ctx.Query("status"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(insurers))
+}
+
+// UpdateInsurer 更新承保公司
+// @Summary 更新承保公司
+// @Description 更新承保公司信息，名称变更时同步产品及保单中的冗余名称
+// @Tags 产品管理
+// @Accept json
+// @Produce json
+// @Param id path string true "承保公司ID"
+// @Param request body model.InsurerUpdateRequest true "更新承保公司请求"
+// @Success 200 {object} model.Response{data=model.Insurer} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "承保公司不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/insurers/{id} [put]
+func (c *ProductController) UpdateInsurer(ctx *gin.Context) {
+	insurerID := ctx.Param("id")
+	if insurerID == "" {
+		ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, "承保公司ID不能为空"))
+		return
+	}
+
+	var req model.InsurerUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	insurer, err := c.productService.UpdateInsurer(ctx.Request.Context(), insurerID, &req, userID.(string), companyID.(string))
+	if err != nil {
+		if err.Error() == "承保公司不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(insurer))
+}
+
+// DeleteInsurer 删除承保公司
+// @Summary 删除承保公司
+// @Description 删除承保公司，其下仍有产品时不允许删除
+// @Tags 产品管理
+// @Accept json
+// @Produce json
+// @Param id path string true "承保公司ID"
+// @Success 200 {object} model.Response "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "承保公司不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/insurers/{id} [delete]
+func (c *ProductController) DeleteInsurer(ctx *gin.Context) {
+	insurerID := ctx.Param("id")
+	if insurerID == "" {
+		ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, "承保公司ID不能为空"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	if err := c.productService.DeleteInsurer(ctx.Request.Context(), insurerID, companyID.(string)); err != nil {
+		if err.Error() == "承保公司不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("删除成功", nil))
+}
+
+// ==========================
+// 保险产品
+// ==========================
+
+// CreateProduct 创建保险产品
+// @Summary 创建保险产品
+// @Description 在产品目录中新增保险产品
+// @Tags 产品管理
+// @Accept json
+// @Produce json
+// @Param request body model.ProductCreateRequest true "创建产品请求"
+// @Success 200 {object} model.Response{data=model.Product} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/products [post]
+func (c *ProductController) CreateProduct(ctx *gin.Context) {
+	var req model.ProductCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	product, err := c.productService.CreateProduct(ctx.Request.Context(), &req, userID.(string), companyID.(string))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(product))
+}
+
+// GetProduct 获取保险产品详情
+// @Summary 获取保险产品详情
+// @Description 根据产品ID获取产品详细信息
+// @Tags 产品管理
+// @Accept json
+// @Produce json
+// @Param id path string true "产品ID"
+// @Success 200 {object} model.Response{data=model.Product} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "产品不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/products/{id} [get]
+func (c *ProductController) GetProduct(ctx *gin.Context) {
+	productID := ctx.Param("id")
+	if productID == "" {
+		ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, "产品ID不能为空"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	product, err := c.productService.GetProduct(ctx.Request.Context(), productID, companyID.(string))
+	if err != nil {
+		if err.Error() == "产品不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(product))
+}
+
+// ListProducts 获取保险产品列表
+// @Summary 获取保险产品列表
+// @Description 分页查询产品目录，支持按承保公司、产品类型、状态及关键词筛选
+// @Tags 产品管理
+// @Accept json
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param insurer_id query string false "承保公司ID"
+// @Param product_type query string false "产品类型"
+// @Param status query string false "状态" Enums(enable, disable)
+// @Param keyword query string false "关键词"
+// @Success 200 {object} model.Response{data=model.ProductListResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/products [get]
+func (c *ProductController) ListProducts(ctx *gin.Context) {
+	var req model.ProductQueryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	products, err := c.productService.ListProducts(ctx.Request.Context(), &req, companyID.(string))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(products))
+}
+
+// UpdateProduct 更新保险产品
+// @Summary 更新保险产品
+// @Description 更新产品信息，名称或类型变更时同步引用该产品的保单
+// @Tags 产品管理
+// @Accept json
+// @Produce json
+// @Param id path string true "产品ID"
+// @Param request body model.ProductUpdateRequest true "更新产品请求"
+// @Success 200 {object} model.Response{data=model.Product} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "产品不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/products/{id} [put]
+func (c *ProductController) UpdateProduct(ctx *gin.Context) {
+	productID := ctx.Param("id")
+	if productID == "" {
+		ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, "产品ID不能为空"))
+		return
+	}
+
+	var req model.ProductUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	product, err := c.productService.UpdateProduct(ctx.Request.Context(), productID, &req, userID.(string), companyID.(string))
+	if err != nil {
+		if err.Error() == "产品不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(product))
+}
+
+// DeleteProduct 删除保险产品
+// @Summary 删除保险产品
+// @Description 删除产品，已被保单引用的产品不允许删除
+// @Tags 产品管理
+// @Accept json
+// @Produce json
+// @Param id path string true "产品ID"
+// @Success 200 {object} model.Response "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "产品不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/products/{id} [delete]
+func (c *ProductController) DeleteProduct(ctx *gin.Context) {
+	productID := ctx.Param("id")
+	if productID == "" {
+		ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, "产品ID不能为空"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	if err := c.productService.DeleteProduct(ctx.Request.Context(), productID, companyID.(string)); err != nil {
+		if err.Error() == "产品不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("删除成功", nil))
+}
+
+// ==========================
+// 导入导出
+// ==========================
+
+// DownloadProductTemplate 下载产品导入模板
+// @Summary 下载产品导入模板
+// @Description 下载用于批量导入的产品目录模板文件
+// @Tags 产品管理
+// @Accept json
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param type query string false "模板类型" Enums(xlsx, csv) default(xlsx)
+// @Success 200 {file} file "模板文件"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/products/template [get]
+func (c *ProductController) DownloadProductTemplate(ctx *gin.Context) {
+	templateType := ctx.DefaultQuery("type", "xlsx")
+
+	fileData, fileName, err := c.productService.GenerateProductTemplate(templateType)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	writeProductFile(ctx, templateType, fileName, fileData)
+}
+
+// ExportProducts 导出产品目录
+// @Summary 导出产品目录
+// @Description 按筛选条件导出产品目录为Excel或CSV格式
+// @Tags 产品管理
+// @Accept json
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "导出格式" Enums(xlsx, csv) default(xlsx)
+// @Param insurer_id query string false "承保公司ID"
+// @Param product_type query string false "产品类型"
+// @Param status query string false "状态" Enums(enable, disable)
+// @Param keyword query string false "关键词"
+// @Success 200 {file} file "导出文件"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/products/export [get]
+func (c *ProductController) ExportProducts(ctx *gin.Context) {
+	var req model.ProductQueryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	format := ctx.DefaultQuery("format", "xlsx")
+	fileData, fileName, err := c.productService.ExportProductsToFile(ctx.Request.Context(), &req, companyID.(string), format)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	writeProductFile(ctx, format, fileName, fileData)
+}
+
+// ImportProductsFromFile 从文件导入产品目录
+// @Summary 从文件导入产品目录
+// @Description 从Excel或CSV文件批量导入产品，承保公司不存在时自动创建
+// @Tags 产品管理
+// @Accept multipart/form-data
+// @Produce json
+// @Param Authorization header string true "Bearer JWT令牌"
+// @Param file formData file true "导入文件"
+// @Param skip_header formData bool false "是否跳过表头行"
+// @Param update_existing formData bool false "是否更新已存在的产品"
+// @Success 200 {object} model.Response{data=model.ProductImportResponse} "导入成功"
+// @Failure 400 {object} model.Response{data=string} "请求参数错误"
+// @Failure 500 {object} model.Response{data=string} "服务器内部错误"
+// @Router /api/products/import [post]
+func (c *ProductController) ImportProductsFromFile(ctx *gin.Context) {
+	file, header, err := ctx.Request.FormFile("file")
+	if err != nil {
+		logger.Warnf("获取上传文件失败: %v", err)
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeInvalidParams, "请选择要上传的文件", err.Error()))
+		return
+	}
+	defer file.Close()
+
+	var req model.ProductImportFileRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		logger.Warnf("产品导入请求参数错误: %v", err)
+		ctx.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodeInvalidParams, "请求参数错误", err.Error()))
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse(model.CodeUnauthorized, "用户未登录", ""))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.ErrorResponse(model.CodeUnauthorized, "公司信息缺失", ""))
+		return
+	}
+
+	logger.BusinessLog("产品管理", "导入产品", userID.(string), "文件名: "+header.Filename)
+
+	response, err := c.productService.ImportProductsFromFile(ctx.Request.Context(), file, header, &req, userID.(string), companyID.(string))
+	if err != nil {
+		logger.Errorf("导入产品数据失败: %v", err)
+		ctx.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeServerError, "导入失败", err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("导入完成", response))
+}
+
+// writeProductFile 按格式写出产品文件
+func writeProductFile(ctx *gin.Context, format, fileName string, fileData []byte) {
+	if format == "csv" {
+		ctx.Header("Content-Type", "text/csv")
+	} else {
+		ctx.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	}
+	ctx.Header("Content-Disposition", "attachment; filename="+fileName)
+
+	ctx.Data(http.StatusOK, "application/octet-stream", fileData)
+}
@@ -0,0 +1,216 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"YufungProject/internal/model"
+	"YufungProject/internal/service"
+)
+
+type ReferralFeeController struct {
+	referralFeeService *service.ReferralFeeService
+}
+
+func NewReferralFeeController(referralFeeService *service.ReferralFeeService) *ReferralFeeController {
+	return &ReferralFeeController{
+		referralFeeService: referralFeeService,
+	}
+}
+
+// CreateRule 创建转介费规则
+// @Summary 创建转介费规则
+// @Description 按合作伙伴、产品、缴费方式、缴费年期及生效日期范围配置分档费率与封顶金额
+// @Tags 转介费规则
+// @Accept json
+// @Produce json
+// @Param request body model.ReferralFeeRuleRequest true "转介费规则请求"
+// @Success 200 {object} model.Response{data=model.ReferralFeeRule} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/referral-fee-rules [post]
+func (c *ReferralFeeController) CreateRule(ctx *gin.Context) {
+	var req model.ReferralFeeRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	rule, err := c.referralFeeService.CreateRule(ctx.Request.Context(), &req, userID.(string), companyID.(string))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(rule))
+}
+
+// ListRules 获取转介费规则列表
+// @Summary 获取转介费规则列表
+// @Description 获取当前公司的转介费规则，按优先级降序
+// @Tags 转介费规则
+// @Accept json
+// @Produce json
+// @Param status query string false "状态" Enums(enable, disable)
+// @Success 200 {object} model.Response{data=[]model.ReferralFeeRule} "成功"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/referral-fee-rules [get]
+func (c *ReferralFeeController) ListRules(ctx *gin.Context) {
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	rules, err := c.referralFeeService.ListRules(ctx.Request.Context(), companyID.(string), ctx.Query("status"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(rules))
+}
+
+// GetRule 获取转介费规则详情
+// @Summary 获取转介费规则详情
+// @Description 根据规则ID获取转介费规则
+// @Tags 转介费规则
+// @Accept json
+// @Produce json
+// @Param id path string true "规则ID"
+// @Success 200 {object} model.Response{data=model.ReferralFeeRule} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "规则不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/referral-fee-rules/{id} [get]
+func (c *ReferralFeeController) GetRule(ctx *gin.Context) {
+	ruleID := ctx.Param("id")
+	if ruleID == "" {
+		ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, "规则ID不能为空"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	rule, err := c.referralFeeService.GetRule(ctx.Request.Context(), ruleID, companyID.(string))
+	if err != nil {
+		if err.Error() == "转介费规则不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(rule))
+}
+
+// UpdateRule 更新转介费规则
+// @Summary 更新转介费规则
+// @Description 整体替换规则的匹配条件与费率档位，已计算的保单需通过重算接口更新
+// @Tags 转介费规则
+// @Accept json
+// @Produce json
+// @Param id path string true "规则ID"
+// @Param request body model.ReferralFeeRuleRequest true "转介费规则请求"
+// @Success 200 {object} model.Response{data=model.ReferralFeeRule} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "规则不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/referral-fee-rules/{id} [put]
+func (c *ReferralFeeController) UpdateRule(ctx *gin.Context) {
+	ruleID := ctx.Param("id")
+	if ruleID == "" {
+		ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, "规则ID不能为空"))
+		return
+	}
+
+	var req model.ReferralFeeRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("用户未登录"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	rule, err := c.referralFeeService.UpdateRule(ctx.Request.Context(), ruleID, &req, userID.(string), companyID.(string))
+	if err != nil {
+		if err.Error() == "转介费规则不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(rule))
+}
+
+// DeleteRule 删除转介费规则
+// @Summary 删除转介费规则
+// @Description 删除转介费规则，已被保单使用的规则不允许删除
+// @Tags 转介费规则
+// @Accept json
+// @Produce json
+// @Param id path string true "规则ID"
+// @Success 200 {object} model.Response "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 401 {object} model.Response "未授权"
+// @Failure 404 {object} model.Response "规则不存在"
+// @Failure 500 {object} model.Response "服务器错误"
+// @Router /api/referral-fee-rules/{id} [delete]
+func (c *ReferralFeeController) DeleteRule(ctx *gin.Context) {
+	ruleID := ctx.Param("id")
+	if ruleID == "" {
+		ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, "规则ID不能为空"))
+		return
+	}
+
+	companyID, exists := ctx.Get("company_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, model.UnauthorizedError("公司信息缺失"))
+		return
+	}
+
+	if err := c.referralFeeService.DeleteRule(ctx.Request.Context(), ruleID, companyID.(string)); err != nil {
+		if err.Error() == "转介费规则不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.SuccessResponse("删除成功", nil))
+}
@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/model"
+	"YufungProject/internal/service"
+	"YufungProject/pkg/logger"
+)
+
+type SecurityController struct {
+	securityService *service.SecurityService
+}
+
+func NewSecurityController(securityService *service.SecurityService) *SecurityController {
+	return &SecurityController{
+		securityService: securityService,
+	}
+}
+
+// GetCompanyIPAllowlist 获取公司IP白名单
+// @Summary 获取公司IP白名单
+// @Description 获取公司允许访问的IP或CIDR，并返回当前请求的客户端IP；非平台管理员只能查看本公司
+// @Tags 安全管理
+// @Accept json
+// @Produce json
+// @Param id path string true "公司ID"
+// @Success 200 {object} model.Response{data=model.CompanyIPAllowlistResponse} "成功"
+// @Failure 404 {object} model.Response "公司不存在"
+// @Router /api/security/companies/{id}/ip-allowlist [get]
+func (c *SecurityController) GetCompanyIPAllowlist(ctx *gin.Context) {
+	companyID, ok := c.scopedCompanyID(ctx)
+	if !ok {
+		return
+	}
+
+	allowedIPs, err := c.securityService.GetCompanyAllowlist(ctx.Request.Context(), companyID)
+	if err != nil {
+		if err.Error() == "公司不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(&model.CompanyIPAllowlistResponse{
+		CompanyID:  companyID,
+		AllowedIPs: allowedIPs,
+		CurrentIP:  ctx.ClientIP(),
+	}))
+}
+
+// UpdateCompanyIPAllowlist 设置公司IP白名单
+// @Summary 设置公司IP白名单
+// @Description 设置后该公司用户只能从白名单内的IP登录和访问系统（平台管理员不受限制），传空数组表示取消限制；非平台管理员只能设置本公司，且白名单须包含自己当前的IP
+// @Tags 安全管理
+// @Accept json
+// @Produce json
+// @Param id path string true "公司ID"
+// @Param request body model.CompanyIPAllowlistRequest true "IP白名单"
+// @Success 200 {object} model.Response{data=model.CompanyIPAllowlistResponse} "成功"
+// @Failure 400 {object} model.Response "IP格式错误或白名单未包含当前IP"
+// @Failure 404 {object} model.Response "公司不存在"
+// @Router /api/security/companies/{id}/ip-allowlist [put]
+func (c *SecurityController) UpdateCompanyIPAllowlist(ctx *gin.Context) {
+	var req model.CompanyIPAllowlistRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+	companyID, ok := c.scopedCompanyID(ctx)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+	roleIDs, _ := middleware.GetRoleIDs(ctx)
+	clientIP := ctx.ClientIP()
+
+	allowedIPs, err := c.securityService.UpdateCompanyAllowlist(ctx.Request.Context(), companyID, req.AllowedIPs, userID, clientIP, middleware.IsAdminRoles(roleIDs))
+	if err != nil {
+		switch {
+		case err.Error() == "公司不存在":
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+		case strings.HasPrefix(err.Error(), "IP白名单"):
+			ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		}
+		return
+	}
+
+	logger.BusinessLog("安全管理", "设置IP白名单", userID, "设置公司 "+companyID+" 的IP白名单: "+strings.Join(allowedIPs, ","))
+	ctx.JSON(http.StatusOK, model.SuccessResponse("IP白名单已更新", &model.CompanyIPAllowlistResponse{
+		CompanyID:  companyID,
+		AllowedIPs: allowedIPs,
+		CurrentIP:  clientIP,
+	}))
+}
+
+// ListSecurityEvents 获取安全事件
+// @Summary 获取安全事件
+// @Description 分页查询安全事件：type=ip_denied 为因IP不在白名单内被拒绝的登录和访问，type=new_login 为新IP或新设备登录；非平台管理员只能查看本公司
+// @Tags 安全管理
+// @Accept json
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param type query string false "事件类型" Enums(ip_denied, new_login)
+// @Param company_id query string false "公司ID"
+// @Param user_id query string false "用户ID"
+// @Param ip_address query string false "IP"
+// @Param start_time query string false "开始日期（2006-01-02）"
+// @Param end_time query string false "结束日期（2006-01-02）"
+// @Success 200 {object} model.Response{data=model.SecurityEventListResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Router /api/security/events [get]
+func (c *SecurityController) ListSecurityEvents(ctx *gin.Context) {
+	var req model.SecurityEventQueryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	roleIDs, _ := middleware.GetRoleIDs(ctx)
+	if !middleware.IsAdminRoles(roleIDs) {
+		req.CompanyID, _ = middleware.GetCompanyID(ctx)
+	}
+
+	result, err := c.securityService.ListEvents(ctx.Request.Context(), &req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
+
+// scopedCompanyID 路径中的公司ID，非平台管理员只能操作本公司，其他公司按不存在处理
+func (c *SecurityController) scopedCompanyID(ctx *gin.Context) (string, bool) {
+	companyID := ctx.Param("id")
+
+	roleIDs, _ := middleware.GetRoleIDs(ctx)
+	if !middleware.IsAdminRoles(roleIDs) {
+		ownCompanyID, _ := middleware.GetCompanyID(ctx)
+		if companyID != ownCompanyID {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError("公司不存在"))
+			return "", false
+		}
+	}
+	return companyID, true
+}
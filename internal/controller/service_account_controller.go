@@ -0,0 +1,287 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/model"
+	"YufungProject/internal/service"
+	"YufungProject/pkg/logger"
+)
+
+type ServiceAccountController struct {
+	serviceAccountService *service.ServiceAccountService
+}
+
+func NewServiceAccountController(serviceAccountService *service.ServiceAccountService) *ServiceAccountController {
+	return &ServiceAccountController{
+		serviceAccountService: serviceAccountService,
+	}
+}
+
+// CreateServiceAccount 创建服务账号
+// @Summary 创建服务账号
+// @Description 创建供合作机构系统调用接口的服务账号并签发第一个API密钥，密钥明文只在响应中返回一次；非平台管理员只能为本公司创建且只能授予自己拥有的权限
+// @Tags 服务账号管理
+// @Accept json
+// @Produce json
+// @Param request body model.ServiceAccountCreateRequest true "服务账号信息"
+// @Success 200 {object} model.Response{data=model.ServiceAccountCreateResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 403 {object} model.Response "权限不足"
+// @Failure 409 {object} model.Response "服务账号名称已存在"
+// @Router /api/service-accounts [post]
+func (c *ServiceAccountController) CreateServiceAccount(ctx *gin.Context) {
+	var req model.ServiceAccountCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+	roleIDs, _ := middleware.GetRoleIDs(ctx)
+	isAdmin := middleware.IsAdminRoles(roleIDs)
+	if !isAdmin || req.CompanyID == "" {
+		req.CompanyID, _ = middleware.GetCompanyID(ctx)
+	}
+
+	result, err := c.serviceAccountService.CreateServiceAccount(ctx.Request.Context(), &req, userID, isAdmin)
+	if err != nil {
+		switch {
+		case err.Error() == "公司不存在":
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+		case err.Error() == "服务账号名称已存在":
+			ctx.JSON(http.StatusConflict, model.ConflictError(err.Error()))
+		case strings.HasPrefix(err.Error(), "权限范围无效"), strings.HasPrefix(err.Error(), "IP白名单格式错误"):
+			ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		}
+		return
+	}
+
+	logger.BusinessLog("服务账号管理", "创建服务账号", userID, "创建服务账号 "+result.ServiceAccount.Name+"（"+result.ServiceAccount.ServiceAccountID+"）")
+	ctx.JSON(http.StatusOK, model.SuccessResponse("服务账号已创建，请妥善保存API密钥", result))
+}
+
+// ListServiceAccounts 获取服务账号列表
+// @Summary 获取服务账号列表
+// @Description 分页查询服务账号；非平台管理员只能查看本公司的服务账号
+// @Tags 服务账号管理
+// @Accept json
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param status query string false "状态" Enums(active, disabled)
+// @Param name query string false "名称"
+// @Param company_id query string false "公司ID"
+// @Success 200 {object} model.Response{data=model.ServiceAccountListResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 403 {object} model.Response "权限不足"
+// @Router /api/service-accounts [get]
+func (c *ServiceAccountController) ListServiceAccounts(ctx *gin.Context) {
+	var req model.ServiceAccountQueryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+
+	roleIDs, _ := middleware.GetRoleIDs(ctx)
+	if !middleware.IsAdminRoles(roleIDs) {
+		req.CompanyID, _ = middleware.GetCompanyID(ctx)
+	}
+
+	result, err := c.serviceAccountService.ListServiceAccounts(ctx.Request.Context(), &req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(result))
+}
+
+// GetServiceAccount 获取服务账号详情
+// @Summary 获取服务账号详情
+// @Description 获取服务账号及其API密钥（只返回前缀、有效期和最后使用时间，不含密钥明文）
+// @Tags 服务账号管理
+// @Accept json
+// @Produce json
+// @Param id path string true "服务账号ID"
+// @Success 200 {object} model.Response{data=model.ServiceAccount} "成功"
+// @Failure 404 {object} model.Response "服务账号不存在"
+// @Router /api/service-accounts/{id} [get]
+func (c *ServiceAccountController) GetServiceAccount(ctx *gin.Context) {
+	account, ok := c.loadServiceAccount(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.Success(account))
+}
+
+// UpdateServiceAccount 更新服务账号
+// @Summary 更新服务账号
+// @Description 更新名称、说明、权限范围、IP白名单或状态；停用后该服务账号的全部API密钥立即不可用
+// @Tags 服务账号管理
+// @Accept json
+// @Produce json
+// @Param id path string true "服务账号ID"
+// @Param request body model.ServiceAccountUpdateRequest true "更新信息"
+// @Success 200 {object} model.Response "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 404 {object} model.Response "服务账号不存在"
+// @Failure 409 {object} model.Response "服务账号名称已存在"
+// @Router /api/service-accounts/{id} [put]
+func (c *ServiceAccountController) UpdateServiceAccount(ctx *gin.Context) {
+	var req model.ServiceAccountUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+	if _, ok := c.loadServiceAccount(ctx); !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
+	roleIDs, _ := middleware.GetRoleIDs(ctx)
+
+	err := c.serviceAccountService.UpdateServiceAccount(ctx.Request.Context(), ctx.Param("id"), &req, userID, middleware.IsAdminRoles(roleIDs))
+	if err != nil {
+		switch {
+		case err.Error() == "服务账号不存在":
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+		case err.Error() == "服务账号名称已存在":
+			ctx.JSON(http.StatusConflict, model.ConflictError(err.Error()))
+		case strings.HasPrefix(err.Error(), "权限范围无效"), strings.HasPrefix(err.Error(), "IP白名单格式错误"):
+			ctx.JSON(http.StatusBadRequest, model.Error(model.CodeInvalidParams, err.Error()))
+		default:
+			ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		}
+		return
+	}
+
+	logger.BusinessLog("服务账号管理", "更新服务账号", userID, "更新服务账号 "+ctx.Param("id"))
+	ctx.JSON(http.StatusOK, model.SuccessResponse("更新成功", nil))
+}
+
+// DeleteServiceAccount 删除服务账号
+// @Summary 删除服务账号
+// @Description 删除服务账号及其全部API密钥
+// @Tags 服务账号管理
+// @Accept json
+// @Produce json
+// @Param id path string true "服务账号ID"
+// @Success 200 {object} model.Response "成功"
+// @Failure 404 {object} model.Response "服务账号不存在"
+// @Router /api/service-accounts/{id} [delete]
+func (c *ServiceAccountController) DeleteServiceAccount(ctx *gin.Context) {
+	if _, ok := c.loadServiceAccount(ctx); !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	if err := c.serviceAccountService.DeleteServiceAccount(ctx.Request.Context(), ctx.Param("id"), userID); err != nil {
+		if err.Error() == "服务账号不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	logger.BusinessLog("服务账号管理", "删除服务账号", userID, "删除服务账号 "+ctx.Param("id"))
+	ctx.JSON(http.StatusOK, model.SuccessResponse("删除成功", nil))
+}
+
+// CreateAPIKey 签发API密钥
+// @Summary 签发API密钥
+// @Description 为服务账号签发新的API密钥，密钥明文只在响应中返回一次；revoke_existing 为true时用于轮换，现有密钥在 grace_period_hours 小时后失效
+// @Tags 服务账号管理
+// @Accept json
+// @Produce json
+// @Param id path string true "服务账号ID"
+// @Param request body model.APIKeyCreateRequest true "密钥信息"
+// @Success 200 {object} model.Response{data=model.APIKeyCreateResponse} "成功"
+// @Failure 400 {object} model.Response "请求参数错误"
+// @Failure 404 {object} model.Response "服务账号不存在"
+// @Router /api/service-accounts/{id}/keys [post]
+func (c *ServiceAccountController) CreateAPIKey(ctx *gin.Context) {
+	var req model.APIKeyCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, model.ValidationError(err))
+		return
+	}
+	if _, ok := c.loadServiceAccount(ctx); !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	result, err := c.serviceAccountService.CreateKey(ctx.Request.Context(), ctx.Param("id"), &req, userID)
+	if err != nil {
+		if err.Error() == "服务账号不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	logger.BusinessLog("服务账号管理", "签发API密钥", userID, "为服务账号 "+ctx.Param("id")+" 签发密钥 "+result.APIKey.Prefix)
+	ctx.JSON(http.StatusOK, model.SuccessResponse("API密钥已签发，请妥善保存", result))
+}
+
+// RevokeAPIKey 吊销API密钥
+// @Summary 吊销API密钥
+// @Description 立即吊销服务账号的API密钥
+// @Tags 服务账号管理
+// @Accept json
+// @Produce json
+// @Param id path string true "服务账号ID"
+// @Param key_id path string true "密钥ID"
+// @Success 200 {object} model.Response "成功"
+// @Failure 404 {object} model.Response "服务账号或API密钥不存在"
+// @Router /api/service-accounts/{id}/keys/{key_id} [delete]
+func (c *ServiceAccountController) RevokeAPIKey(ctx *gin.Context) {
+	if _, ok := c.loadServiceAccount(ctx); !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(ctx)
+
+	if err := c.serviceAccountService.RevokeKey(ctx.Request.Context(), ctx.Param("id"), ctx.Param("key_id"), userID); err != nil {
+		if err.Error() == "API密钥不存在或已吊销" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return
+	}
+
+	logger.BusinessLog("服务账号管理", "吊销API密钥", userID, "吊销服务账号 "+ctx.Param("id")+" 的密钥 "+ctx.Param("key_id"))
+	ctx.JSON(http.StatusOK, model.SuccessResponse("API密钥已吊销", nil))
+}
+
+// loadServiceAccount 获取路径中的服务账号，非平台管理员只能操作本公司的服务账号，其他公司的按不存在处理
+func (c *ServiceAccountController) loadServiceAccount(ctx *gin.Context) (*model.ServiceAccount, bool) {
+	account, err := c.serviceAccountService.GetServiceAccount(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		if err.Error() == "服务账号不存在" {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError(err.Error()))
+			return nil, false
+		}
+		ctx.JSON(http.StatusInternalServerError, model.ServerError(err.Error()))
+		return nil, false
+	}
+
+	roleIDs, _ := middleware.GetRoleIDs(ctx)
+	if !middleware.IsAdminRoles(roleIDs) {
+		companyID, _ := middleware.GetCompanyID(ctx)
+		if account.CompanyID != companyID {
+			ctx.JSON(http.StatusNotFound, model.NotFoundError("服务账号不存在"))
+			return nil, false
+		}
+	}
+	return account, true
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -41,11 +42,14 @@ func (ctrl *SystemConfigController) CreateSystemConfig(c *gin.Context) {
 	}
 
 	userID, _ := middleware.GetUserID(c)
+	companyID, _ := middleware.GetCompanyID(c)
+	roleIDs, _ := middleware.GetRoleIDs(c)
 
-	config, err := ctrl.systemConfigService.CreateSystemConfig(c.Request.Context(), &req, userID)
+	config, err := ctrl.systemConfigService.CreateSystemConfig(c.Request.Context(), &req, userID, companyID, roleIDs)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			Code:    http.StatusInternalServerError,
+		status := configErrorStatus(err)
+		c.JSON(status, model.Response{
+			Code:    status,
 			Message: err.Error(),
 		})
 		return
@@ -76,10 +80,14 @@ func (ctrl *SystemConfigController) GetSystemConfig(c *gin.Context) {
 		return
 	}
 
-	config, err := ctrl.systemConfigService.GetSystemConfigByID(c.Request.Context(), configID)
+	companyID, _ := middleware.GetCompanyID(c)
+	roleIDs, _ := middleware.GetRoleIDs(c)
+
+	config, err := ctrl.systemConfigService.GetSystemConfigByID(c.Request.Context(), configID, companyID, roleIDs)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			Code:    http.StatusInternalServerError,
+		status := configErrorStatus(err)
+		c.JSON(status, model.Response{
+			Code:    status,
 			Message: err.Error(),
 		})
 		return
@@ -121,11 +129,14 @@ func (ctrl *SystemConfigController) UpdateSystemConfig(c *gin.Context) {
 	}
 
 	userID, _ := middleware.GetUserID(c)
+	companyID, _ := middleware.GetCompanyID(c)
+	roleIDs, _ := middleware.GetRoleIDs(c)
 
-	config, err := ctrl.systemConfigService.UpdateSystemConfig(c.Request.Context(), configID, &req, userID)
+	config, err := ctrl.systemConfigService.UpdateSystemConfig(c.Request.Context(), configID, &req, userID, companyID, roleIDs)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			Code:    http.StatusInternalServerError,
+		status := configErrorStatus(err)
+		c.JSON(status, model.Response{
+			Code:    status,
 			Message: err.Error(),
 		})
 		return
@@ -156,10 +167,14 @@ func (ctrl *SystemConfigController) DeleteSystemConfig(c *gin.Context) {
 		return
 	}
 
-	err := ctrl.systemConfigService.DeleteSystemConfig(c.Request.Context(), configID)
+	companyID, _ := middleware.GetCompanyID(c)
+	roleIDs, _ := middleware.GetRoleIDs(c)
+
+	err := ctrl.systemConfigService.DeleteSystemConfig(c.Request.Context(), configID, companyID, roleIDs)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			Code:    http.StatusInternalServerError,
+		status := configErrorStatus(err)
+		c.JSON(status, model.Response{
+			Code:    status,
 			Message: err.Error(),
 		})
 		return
@@ -176,6 +191,7 @@ func (ctrl *SystemConfigController) DeleteSystemConfig(c *gin.Context) {
 // @Tags 系统配置管理
 // @Accept json
 // @Produce json
+// @Param company_id query string false "所属公司ID"
 // @Param config_type query string false "配置类型"
 // @Param status query string false "状态"
 // @Param keyword query string false "关键词"
@@ -193,8 +209,11 @@ func (ctrl *SystemConfigController) ListSystemConfigs(c *gin.Context) {
 		return
 	}
 
-	// 系统配置是全局的，不需要公司ID限制
-	result, err := ctrl.systemConfigService.ListSystemConfigs(c.Request.Context(), &req)
+	// 平台级配置项对所有公司可见，公司配置项只对所属公司可见（平台管理员不受限制）
+	companyID, _ := middleware.GetCompanyID(c)
+	roleIDs, _ := middleware.GetRoleIDs(c)
+
+	result, err := ctrl.systemConfigService.ListSystemConfigs(c.Request.Context(), &req, companyID, roleIDs)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, model.Response{
 			Code:    http.StatusInternalServerError,
@@ -212,10 +231,14 @@ func (ctrl *SystemConfigController) ListSystemConfigs(c *gin.Context) {
 
 // GetConfigOptions 获取配置选项
 // @Summary 根据配置类型获取配置选项
+// @Description 返回当前公司生效的配置项（继承的平台配置项 + 公司自有配置项），source标记来源。指定parent_key时只返回其下级选项，用于级联选择；tree=true时返回包含所有下级类型的树形结构
 // @Tags 系统配置管理
 // @Accept json
 // @Produce json
 // @Param type path string true "配置类型"
+// @Param parent_key query string false "上级配置键"
+// @Param tree query bool false "是否返回树形结构"
+// @Param include_hidden query bool false "是否包含已隐藏的平台配置项"
 // @Success 200 {object} model.Response{data=[]model.SystemConfigResponse}
 // @Router /api/system-configs/options/{type} [get]
 func (ctrl *SystemConfigController) GetConfigOptions(c *gin.Context) {
@@ -228,7 +251,24 @@ func (ctrl *SystemConfigController) GetConfigOptions(c *gin.Context) {
 		return
 	}
 
-	configs, err := ctrl.systemConfigService.GetConfigsByType(c.Request.Context(), configType)
+	var req model.SystemConfigOptionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	companyID, _ := middleware.GetCompanyID(c)
+
+	var configs interface{}
+	var err error
+	if req.Tree {
+		configs, err = ctrl.systemConfigService.GetConfigTree(c.Request.Context(), configType, companyID)
+	} else {
+		configs, err = ctrl.systemConfigService.GetConfigsByType(c.Request.Context(), configType, companyID, &req)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, model.Response{
 			Code:    http.StatusInternalServerError,
@@ -243,3 +283,249 @@ func (ctrl *SystemConfigController) GetConfigOptions(c *gin.Context) {
 		Data:    configs,
 	})
 }
+
+// SetConfigOverride 设置平台配置项的公司覆盖
+// @Summary 设置平台配置项的公司覆盖
+// @Description 当前公司隐藏继承的平台配置项，或覆盖其显示名称和排序
+// @Tags 系统配置管理
+// @Accept json
+// @Produce json
+// @Param id path string true "平台配置项ID"
+// @Param request body model.SystemConfigOverrideRequest true "覆盖设置"
+// @Success 200 {object} model.Response{data=model.SystemConfigResponse}
+// @Router /api/system-configs/{id}/override [put]
+func (ctrl *SystemConfigController) SetConfigOverride(c *gin.Context) {
+	configID := c.Param("id")
+	if configID == "" {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "系统配置ID不能为空",
+		})
+		return
+	}
+
+	var req model.SystemConfigOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	companyID, _ := middleware.GetCompanyID(c)
+
+	config, err := ctrl.systemConfigService.SetConfigOverride(c.Request.Context(), configID, &req, userID, companyID)
+	if err != nil {
+		status := configErrorStatus(err)
+		c.JSON(status, model.Response{
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "设置成功",
+		Data:    config,
+	})
+}
+
+// RemoveConfigOverride 取消平台配置项的公司覆盖
+// @Summary 取消平台配置项的公司覆盖
+// @Tags 系统配置管理
+// @Accept json
+// @Produce json
+// @Param id path string true "平台配置项ID"
+// @Success 200 {object} model.Response
+// @Router /api/system-configs/{id}/override [delete]
+func (ctrl *SystemConfigController) RemoveConfigOverride(c *gin.Context) {
+	configID := c.Param("id")
+	if configID == "" {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "系统配置ID不能为空",
+		})
+		return
+	}
+
+	companyID, _ := middleware.GetCompanyID(c)
+
+	if err := ctrl.systemConfigService.RemoveConfigOverride(c.Request.Context(), configID, companyID); err != nil {
+		status := configErrorStatus(err)
+		c.JSON(status, model.Response{
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "已恢复为平台配置",
+	})
+}
+
+// ListConfigTypes 获取字典类型列表
+// @Summary 获取字典类型列表
+// @Description 返回当前公司可见的字典类型，包括内置类型、平台级类型和本公司类型
+// @Tags 系统配置管理
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response{data=[]model.SystemConfigType}
+// @Router /api/system-config-types [get]
+func (ctrl *SystemConfigController) ListConfigTypes(c *gin.Context) {
+	companyID, _ := middleware.GetCompanyID(c)
+
+	configTypes, err := ctrl.systemConfigService.ListConfigTypes(c.Request.Context(), companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "获取成功",
+		Data:    configTypes,
+	})
+}
+
+// CreateConfigType 注册字典类型
+// @Summary 注册字典类型
+// @Tags 系统配置管理
+// @Accept json
+// @Produce json
+// @Param request body model.SystemConfigTypeCreateRequest true "注册字典类型请求"
+// @Success 200 {object} model.Response{data=model.SystemConfigType}
+// @Router /api/system-config-types [post]
+func (ctrl *SystemConfigController) CreateConfigType(c *gin.Context) {
+	var req model.SystemConfigTypeCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	companyID, _ := middleware.GetCompanyID(c)
+	roleIDs, _ := middleware.GetRoleIDs(c)
+
+	configType, err := ctrl.systemConfigService.CreateConfigType(c.Request.Context(), &req, userID, companyID, roleIDs)
+	if err != nil {
+		status := configErrorStatus(err)
+		c.JSON(status, model.Response{
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "创建成功",
+		Data:    configType,
+	})
+}
+
+// UpdateConfigType 更新字典类型
+// @Summary 更新字典类型
+// @Tags 系统配置管理
+// @Accept json
+// @Produce json
+// @Param id path string true "字典类型ID"
+// @Param request body model.SystemConfigTypeUpdateRequest true "更新字典类型请求"
+// @Success 200 {object} model.Response{data=model.SystemConfigType}
+// @Router /api/system-config-types/{id} [put]
+func (ctrl *SystemConfigController) UpdateConfigType(c *gin.Context) {
+	typeID := c.Param("id")
+	if typeID == "" {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "字典类型ID不能为空",
+		})
+		return
+	}
+
+	var req model.SystemConfigTypeUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	companyID, _ := middleware.GetCompanyID(c)
+	roleIDs, _ := middleware.GetRoleIDs(c)
+
+	configType, err := ctrl.systemConfigService.UpdateConfigType(c.Request.Context(), typeID, &req, userID, companyID, roleIDs)
+	if err != nil {
+		status := configErrorStatus(err)
+		c.JSON(status, model.Response{
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "更新成功",
+		Data:    configType,
+	})
+}
+
+// DeleteConfigType 删除字典类型
+// @Summary 删除字典类型
+// @Tags 系统配置管理
+// @Accept json
+// @Produce json
+// @Param id path string true "字典类型ID"
+// @Success 200 {object} model.Response
+// @Router /api/system-config-types/{id} [delete]
+func (ctrl *SystemConfigController) DeleteConfigType(c *gin.Context) {
+	typeID := c.Param("id")
+	if typeID == "" {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "字典类型ID不能为空",
+		})
+		return
+	}
+
+	companyID, _ := middleware.GetCompanyID(c)
+	roleIDs, _ := middleware.GetRoleIDs(c)
+
+	if err := ctrl.systemConfigService.DeleteConfigType(c.Request.Context(), typeID, companyID, roleIDs); err != nil {
+		status := configErrorStatus(err)
+		c.JSON(status, model.Response{
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "删除成功",
+	})
+}
+
+// configErrorStatus 无权维护平台级或其他公司的配置时返回403，参数错误返回400，其他错误返回500
+func configErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrConfigForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrConfigInvalid):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
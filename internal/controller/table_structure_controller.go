@@ -0,0 +1,671 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/model"
+	"YufungProject/internal/service"
+)
+
+// TableStructureController 动态表结构控制器
+type TableStructureController struct {
+	tableStructureService service.TableStructureService
+}
+
+// NewTableStructureController 创建动态表结构控制器实例
+func NewTableStructureController(tableStructureService service.TableStructureService) *TableStructureController {
+	return &TableStructureController{
+		tableStructureService: tableStructureService,
+	}
+}
+
+// CreateTable 创建表结构
+// @Summary 创建表结构
+// @Description 公司用户创建本公司表结构，平台用户创建平台表结构
+// @Tags 动态表结构管理
+// @Accept json
+// @Produce json
+// @Param request body model.TableStructureCreateRequest true "创建表结构请求"
+// @Success 200 {object} model.Response{data=model.TableStructure}
+// @Router /api/table-structures [post]
+func (ctrl *TableStructureController) CreateTable(c *gin.Context) {
+	var req model.TableStructureCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	companyID, _ := middleware.GetCompanyID(c)
+
+	table, err := ctrl.tableStructureService.CreateTable(c.Request.Context(), &req, companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "创建成功",
+		Data:    table,
+	})
+}
+
+// ListTables 获取表结构列表
+// @Summary 获取表结构列表
+// @Description 获取平台表结构及本公司表结构
+// @Tags 动态表结构管理
+// @Accept json
+// @Produce json
+// @Param table_type query string false "表类型" Enums(system, custom)
+// @Param status query string false "状态" Enums(active, inactive)
+// @Param keyword query string false "关键词（表名/显示名称）"
+// @Success 200 {object} model.Response{data=[]model.TableStructure}
+// @Router /api/table-structures [get]
+func (ctrl *TableStructureController) ListTables(c *gin.Context) {
+	var req model.TableStructureQueryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	companyID, _ := middleware.GetCompanyID(c)
+
+	tables, err := ctrl.tableStructureService.ListTables(c.Request.Context(), &req, companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "获取成功",
+		Data:    tables,
+	})
+}
+
+// GetTable 获取表结构详情
+// @Summary 获取表结构详情
+// @Description 获取表结构及其字段定义
+// @Tags 动态表结构管理
+// @Accept json
+// @Produce json
+// @Param id path string true "表结构ID"
+// @Success 200 {object} model.Response{data=model.TableStructureResponse}
+// @Router /api/table-structures/{id} [get]
+func (ctrl *TableStructureController) GetTable(c *gin.Context) {
+	tableID := c.Param("id")
+	if tableID == "" {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "表结构ID不能为空",
+		})
+		return
+	}
+
+	companyID, _ := middleware.GetCompanyID(c)
+
+	table, err := ctrl.tableStructureService.GetTable(c.Request.Context(), tableID, companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "获取成功",
+		Data:    table,
+	})
+}
+
+// UpdateTable 更新表结构
+// @Summary 更新表结构
+// @Description 更新表结构的显示名称、描述和状态，表名不可修改
+// @Tags 动态表结构管理
+// @Accept json
+// @Produce json
+// @Param id path string true "表结构ID"
+// @Param request body model.TableStructureUpdateRequest true "更新表结构请求"
+// @Success 200 {object} model.Response{data=model.TableStructure}
+// @Router /api/table-structures/{id} [put]
+func (ctrl *TableStructureController) UpdateTable(c *gin.Context) {
+	tableID := c.Param("id")
+	if tableID == "" {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "表结构ID不能为空",
+		})
+		return
+	}
+
+	var req model.TableStructureUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	companyID, _ := middleware.GetCompanyID(c)
+
+	table, err := ctrl.tableStructureService.UpdateTable(c.Request.Context(), tableID, &req, companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "更新成功",
+		Data:    table,
+	})
+}
+
+// DeleteTable 删除表结构
+// @Summary 删除表结构
+// @Description 删除表结构及其字段定义，系统表或已有字段数据的表不允许删除
+// @Tags 动态表结构管理
+// @Accept json
+// @Produce json
+// @Param id path string true "表结构ID"
+// @Success 200 {object} model.Response
+// @Router /api/table-structures/{id} [delete]
+func (ctrl *TableStructureController) DeleteTable(c *gin.Context) {
+	tableID := c.Param("id")
+	if tableID == "" {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "表结构ID不能为空",
+		})
+		return
+	}
+
+	companyID, _ := middleware.GetCompanyID(c)
+
+	if err := ctrl.tableStructureService.DeleteTable(c.Request.Context(), tableID, companyID); err != nil {
+		c.JSON(http.StatusInternalServerError, model.Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "删除成功",
+	})
+}
+
+// ListFields 获取字段定义列表
+// @Summary 获取字段定义列表
+// @Description 获取表结构的字段定义，按排序号升序
+// @Tags 动态表结构管理
+// @Accept json
+// @Produce json
+// @Param id path string true "表结构ID"
+// @Success 200 {object} model.Response{data=[]model.FieldDefinition}
+// @Router /api/table-structures/{id}/fields [get]
+func (ctrl *TableStructureController) ListFields(c *gin.Context) {
+	tableID := c.Param("id")
+	if tableID == "" {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "表结构ID不能为空",
+		})
+		return
+	}
+
+	companyID, _ := middleware.GetCompanyID(c)
+
+	fields, err := ctrl.tableStructureService.ListFields(c.Request.Context(), tableID, companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "获取成功",
+		Data:    fields,
+	})
+}
+
+// CreateField 创建字段定义
+// @Summary 创建字段定义
+// @Description 为表结构新增字段，支持文本、数字、日期、布尔、枚举和文件类型
+// @Tags 动态表结构管理
+// @Accept json
+// @Produce json
+// @Param id path string true "表结构ID"
+// @Param request body model.FieldDefinitionCreateRequest true "创建字段定义请求"
+// @Success 200 {object} model.Response{data=model.FieldDefinition}
+// @Router /api/table-structures/{id}/fields [post]
+func (ctrl *TableStructureController) CreateField(c *gin.Context) {
+	tableID := c.Param("id")
+	if tableID == "" {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "表结构ID不能为空",
+		})
+		return
+	}
+
+	var req model.FieldDefinitionCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	companyID, _ := middleware.GetCompanyID(c)
+
+	field, err := ctrl.tableStructureService.CreateField(c.Request.Context(), tableID, &req, userID, companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "创建成功",
+		Data:    field,
+	})
+}
+
+// UpdateField 更新字段定义
+// @Summary 更新字段定义
+// @Description 更新字段定义并发布新的表结构版本，字段名和字段类型需通过迁移接口修改
+// @Tags 动态表结构管理
+// @Accept json
+// @Produce json
+// @Param id path string true "表结构ID"
+// @Param fieldId path string true "字段ID"
+// @Param request body model.FieldDefinitionUpdateRequest true "更新字段定义请求"
+// @Success 200 {object} model.Response{data=model.FieldDefinition}
+// @Router /api/table-structures/{id}/fields/{fieldId} [put]
+func (ctrl *TableStructureController) UpdateField(c *gin.Context) {
+	tableID := c.Param("id")
+	fieldID := c.Param("fieldId")
+	if tableID == "" || fieldID == "" {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "表结构ID和字段ID不能为空",
+		})
+		return
+	}
+
+	var req model.FieldDefinitionUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	companyID, _ := middleware.GetCompanyID(c)
+
+	field, err := ctrl.tableStructureService.UpdateField(c.Request.Context(), tableID, fieldID, &req, userID, companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "更新成功",
+		Data:    field,
+	})
+}
+
+// DeleteField 删除字段定义
+// @Summary 删除字段定义
+// @Description 删除字段定义，已有数据的字段不允许删除，可改为隐藏
+// @Tags 动态表结构管理
+// @Accept json
+// @Produce json
+// @Param id path string true "表结构ID"
+// @Param fieldId path string true "字段ID"
+// @Success 200 {object} model.Response
+// @Router /api/table-structures/{id}/fields/{fieldId} [delete]
+func (ctrl *TableStructureController) DeleteField(c *gin.Context) {
+	tableID := c.Param("id")
+	fieldID := c.Param("fieldId")
+	if tableID == "" || fieldID == "" {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "表结构ID和字段ID不能为空",
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	companyID, _ := middleware.GetCompanyID(c)
+
+	if err := ctrl.tableStructureService.DeleteField(c.Request.Context(), tableID, fieldID, userID, companyID); err != nil {
+		c.JSON(http.StatusInternalServerError, model.Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "删除成功",
+	})
+}
+
+// SortFields 字段排序
+// @Summary 字段排序
+// @Description 按给定的字段ID顺序重排字段，须包含表结构的全部字段
+// @Tags 动态表结构管理
+// @Accept json
+// @Produce json
+// @Param id path string true "表结构ID"
+// @Param request body model.FieldSortRequest true "字段排序请求"
+// @Success 200 {object} model.Response{data=[]model.FieldDefinition}
+// @Router /api/table-structures/{id}/fields/sort [put]
+func (ctrl *TableStructureController) SortFields(c *gin.Context) {
+	tableID := c.Param("id")
+	if tableID == "" {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "表结构ID不能为空",
+		})
+		return
+	}
+
+	var req model.FieldSortRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	companyID, _ := middleware.GetCompanyID(c)
+
+	fields, err := ctrl.tableStructureService.SortFields(c.Request.Context(), tableID, &req, userID, companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "排序成功",
+		Data:    fields,
+	})
+}
+
+// SetFieldVisibility 设置字段显示
+// @Summary 设置字段显示
+// @Description 设置字段是否在表单和列表中显示
+// @Tags 动态表结构管理
+// @Accept json
+// @Produce json
+// @Param id path string true "表结构ID"
+// @Param fieldId path string true "字段ID"
+// @Param request body model.FieldVisibilityRequest true "字段显示设置请求"
+// @Success 200 {object} model.Response{data=model.FieldDefinition}
+// @Router /api/table-structures/{id}/fields/{fieldId}/visibility [put]
+func (ctrl *TableStructureController) SetFieldVisibility(c *gin.Context) {
+	tableID := c.Param("id")
+	fieldID := c.Param("fieldId")
+	if tableID == "" || fieldID == "" {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "表结构ID和字段ID不能为空",
+		})
+		return
+	}
+
+	var req model.FieldVisibilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	companyID, _ := middleware.GetCompanyID(c)
+
+	field, err := ctrl.tableStructureService.SetFieldVisibility(c.Request.Context(), tableID, fieldID, *req.Visible, userID, companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "设置成功",
+		Data:    field,
+	})
+}
+
+// MigrateField 迁移字段
+// @Summary 迁移字段
+// @Description 重命名字段或修改字段类型，按新定义转换已有数据并发布新的表结构版本；dry_run为true时仅返回无法转换的值
+// @Tags 动态表结构管理
+// @Accept json
+// @Produce json
+// @Param id path string true "表结构ID"
+// @Param fieldId path string true "字段ID"
+// @Param request body model.FieldMigrationRequest true "字段迁移请求"
+// @Success 200 {object} model.Response{data=model.FieldMigrationResponse}
+// @Router /api/table-structures/{id}/fields/{fieldId}/migrate [post]
+func (ctrl *TableStructureController) MigrateField(c *gin.Context) {
+	tableID := c.Param("id")
+	fieldID := c.Param("fieldId")
+	if tableID == "" || fieldID == "" {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "表结构ID和字段ID不能为空",
+		})
+		return
+	}
+
+	var req model.FieldMigrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	companyID, _ := middleware.GetCompanyID(c)
+
+	result, err := ctrl.tableStructureService.MigrateField(c.Request.Context(), tableID, fieldID, &req, userID, companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	message := "迁移成功"
+	if result.DryRun {
+		message = "预览成功"
+	} else if !result.Applied {
+		message = "存在无法转换的值，未执行迁移"
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: message,
+		Data:    result,
+	})
+}
+
+// ListVersions 获取表结构版本列表
+// @Summary 获取表结构版本列表
+// @Description 获取表结构已发布的版本，按版本号降序
+// @Tags 动态表结构管理
+// @Accept json
+// @Produce json
+// @Param id path string true "表结构ID"
+// @Success 200 {object} model.Response{data=[]model.TableStructureVersionSummary}
+// @Router /api/table-structures/{id}/versions [get]
+func (ctrl *TableStructureController) ListVersions(c *gin.Context) {
+	tableID := c.Param("id")
+	if tableID == "" {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "表结构ID不能为空",
+		})
+		return
+	}
+
+	companyID, _ := middleware.GetCompanyID(c)
+
+	versions, err := ctrl.tableStructureService.ListVersions(c.Request.Context(), tableID, companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "获取成功",
+		Data:    versions,
+	})
+}
+
+// GetVersion 获取表结构版本详情
+// @Summary 获取表结构版本详情
+// @Description 获取表结构指定版本的字段定义快照
+// @Tags 动态表结构管理
+// @Accept json
+// @Produce json
+// @Param id path string true "表结构ID"
+// @Param version path int true "版本号"
+// @Success 200 {object} model.Response{data=model.TableStructureVersion}
+// @Router /api/table-structures/{id}/versions/{version} [get]
+func (ctrl *TableStructureController) GetVersion(c *gin.Context) {
+	tableID := c.Param("id")
+	version, err := strconv.Atoi(c.Param("version"))
+	if tableID == "" || err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "表结构ID或版本号无效",
+		})
+		return
+	}
+
+	companyID, _ := middleware.GetCompanyID(c)
+
+	result, err := ctrl.tableStructureService.GetVersion(c.Request.Context(), tableID, version, companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "获取成功",
+		Data:    result,
+	})
+}
+
+// DiffVersions 对比表结构版本
+// @Summary 对比表结构版本
+// @Description 对比两个版本的字段定义，列出新增、删除和修改的字段
+// @Tags 动态表结构管理
+// @Accept json
+// @Produce json
+// @Param id path string true "表结构ID"
+// @Param from query int false "起始版本（0表示空结构）"
+// @Param to query int true "目标版本"
+// @Success 200 {object} model.Response{data=model.TableStructureVersionDiff}
+// @Router /api/table-structures/{id}/versions/diff [get]
+func (ctrl *TableStructureController) DiffVersions(c *gin.Context) {
+	tableID := c.Param("id")
+	if tableID == "" {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "表结构ID不能为空",
+		})
+		return
+	}
+
+	var req model.TableStructureVersionDiffRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	companyID, _ := middleware.GetCompanyID(c)
+
+	diff, err := ctrl.tableStructureService.DiffVersions(c.Request.Context(), tableID, &req, companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		Code:    http.StatusOK,
+		Message: "获取成功",
+		Data:    diff,
+	})
+}
@@ -61,6 +61,10 @@ func (uc *UserController) CreateUser(c *gin.Context) {
 	user, err := uc.userService.CreateUser(c.Request.Context(), &req)
 	if err != nil {
 		logger.Error("创建用户失败", err)
+		if service.IsPasswordPolicyError(err) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodePasswordPolicy, err.Error(), nil))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, model.Response{
 			Code:    http.StatusInternalServerError,
 			Message: "创建用户失败: " + err.Error(),
@@ -68,6 +72,8 @@ func (uc *UserController) CreateUser(c *gin.Context) {
 		return
 	}
 
+	service.MaskUserInfos(c.Request.Context(), user)
+
 	c.JSON(http.StatusOK, model.Response{
 		Code:    http.StatusOK,
 		Message: "创建用户成功",
@@ -133,6 +139,12 @@ func (uc *UserController) GetUserList(c *gin.Context) {
 		return
 	}
 
+	users := make([]*model.UserInfo, len(response.Users))
+	for i := range response.Users {
+		users[i] = &response.Users[i]
+	}
+	service.MaskUserInfos(c.Request.Context(), users...)
+
 	c.JSON(http.StatusOK, model.Response{
 		Code:    http.StatusOK,
 		Message: "获取用户列表成功",
@@ -172,6 +184,7 @@ func (uc *UserController) GetUserByID(c *gin.Context) {
 		return
 	}
 
+	service.MaskUserInfos(c.Request.Context(), user)
 	c.JSON(http.StatusOK, model.Response{
 		Code:    http.StatusOK,
 		Message: "获取用户详情成功",
@@ -350,6 +363,10 @@ func (uc *UserController) ResetUserPassword(c *gin.Context) {
 	err := uc.userService.ResetPassword(c.Request.Context(), &req)
 	if err != nil {
 		logger.Error("重置密码失败", err)
+		if service.IsPasswordPolicyError(err) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse(model.CodePasswordPolicy, err.Error(), nil))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, model.Response{
 			Code:    http.StatusInternalServerError,
 			Message: "重置密码失败: " + err.Error(),
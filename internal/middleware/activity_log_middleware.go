@@ -26,6 +26,9 @@ func ActivityLogMiddleware(activityLogService *service.ActivityLogService) gin.H
 		username, _ := c.Get("username")
 		companyID, _ := c.Get("company_id")
 		companyName, _ := c.Get("company_name")
+		actorType := c.GetString("actor_type")
+		apiKeyPrefix := c.GetString("api_key_prefix")
+		impersonation, _ := GetImpersonation(c)
 
 		// 如果用户信息缺失，跳过记录
 		if userID == nil || username == nil || companyID == nil {
@@ -77,6 +80,13 @@ func ActivityLogMiddleware(activityLogService *service.ActivityLogService) gin.H
 				OperationTime: time.Now(),
 				ExecutionTime: executionTime,
 				ResultStatus:  resultStatus,
+				ActorType:     actorType,
+				APIKeyPrefix:  apiKeyPrefix,
+			}
+			if impersonation != nil {
+				log.ImpersonatorID = impersonation.ActorID
+				log.ImpersonatorName = impersonation.ActorName
+				log.ImpersonationID = impersonation.SessionID
 			}
 
 			if err := activityLogService.CreateActivityLog(ctx, log); err != nil {
@@ -167,6 +177,10 @@ func parseOperationInfo(url, method string) (operationType, moduleName, operatio
 		moduleName = model.ModuleCompany
 	case contains(url, "/policies"):
 		moduleName = model.ModulePolicy
+	case contains(url, "/products") || contains(url, "/insurers"):
+		moduleName = model.ModuleProduct
+	case contains(url, "/referral-fee-rules"):
+		moduleName = model.ModuleFeeRule
 	case contains(url, "/customers"):
 		moduleName = model.ModuleCustomer
 	case contains(url, "/system"):
@@ -1,33 +1,176 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"YufungProject/configs"
 	"YufungProject/internal/model"
+	"YufungProject/internal/repository"
+	"YufungProject/internal/service"
 	"YufungProject/pkg/logger"
 	"YufungProject/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware JWT认证中间件
-func AuthMiddleware(config *configs.Config) gin.HandlerFunc {
-	// 解析时间配置
+// SessionChecker 会话校验：判断已通过签名校验的令牌是否已被吊销（如重置密码后）；
+// 返回 service.ErrPasswordChangeRequired 时只允许访问修改密码等少数接口
+type SessionChecker interface {
+	CheckSession(ctx context.Context, claims *utils.Claims) error
+}
+
+var sessionChecker SessionChecker
+
+// passwordChangeExemptPaths 须修改密码的用户仍可访问的接口
+var passwordChangeExemptPaths = map[string]bool{
+	"/api/auth/change-password": true,
+	"/api/auth/logout":          true,
+	"/api/auth/user-info":       true,
+	"/api/auth/password-policy": true,
+}
+
+// jwtUtil 校验令牌的JWT工具，启动时注入以支持密钥轮换和非对称算法
+var jwtUtil *utils.JWTUtil
+
+// SetJWTUtil 设置校验令牌的JWT工具；未设置时使用 jwt.secret 校验
+func SetJWTUtil(util *utils.JWTUtil) {
+	jwtUtil = util
+}
+
+// tokenParser 返回校验令牌的JWT工具
+func tokenParser(config *configs.Config) *utils.JWTUtil {
+	if jwtUtil != nil {
+		return jwtUtil
+	}
 	expiresIn, _ := time.ParseDuration(config.JWT.ExpiresIn)
 	refreshExpiresIn, _ := time.ParseDuration(config.JWT.RefreshExpiresIn)
+	return utils.NewJWTUtil(config.JWT.Secret, expiresIn, refreshExpiresIn)
+}
+
+// SetSessionChecker 设置会话校验器，启动时注入；未设置时只校验令牌签名和有效期
+func SetSessionChecker(checker SessionChecker) {
+	sessionChecker = checker
+}
 
-	jwtUtil := utils.NewJWTUtil(
-		config.JWT.Secret,
-		expiresIn,
-		refreshExpiresIn,
-	)
+// IPAccessChecker 公司IP白名单校验：客户端IP不在用户所属公司的白名单内时返回 service.ErrIPNotAllowed
+type IPAccessChecker interface {
+	CheckIPAccess(ctx context.Context, attempt *model.IPAccessAttempt) error
+}
+
+var ipAccessChecker IPAccessChecker
+
+// SetIPAccessChecker 设置公司IP白名单校验器，启动时注入；未设置时不校验客户端IP
+func SetIPAccessChecker(checker IPAccessChecker) {
+	ipAccessChecker = checker
+}
+
+// ImpersonationChecker 模拟登录校验：判断模拟登录令牌对应的会话是否仍在进行中，
+// 会话已结束或已过期时返回 service.ErrImpersonationEnded
+type ImpersonationChecker interface {
+	CheckImpersonation(ctx context.Context, claims *utils.Claims) error
+	RecordBlockedWrite(ctx context.Context, sessionID, path string)
+}
+
+var impersonationChecker ImpersonationChecker
+
+// impersonationWriteExemptPaths 只读模拟登录仍可执行的写操作
+var impersonationWriteExemptPaths = map[string]bool{
+	"/api/impersonation/stop": true,
+	"/api/auth/logout":        true,
+}
+
+// impersonationForbiddenPaths 模拟登录期间一律禁止访问的接口
+var impersonationForbiddenPaths = map[string]bool{
+	"/api/auth/change-password": true,
+}
+
+// SetImpersonationChecker 设置模拟登录校验器，启动时注入；未设置时拒绝所有模拟登录令牌
+func SetImpersonationChecker(checker ImpersonationChecker) {
+	impersonationChecker = checker
+}
+
+// APIKeyAuthenticator API密钥认证：校验服务账号的API密钥并返回其身份
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, rawKey, clientIP string) (*model.ServiceIdentity, error)
+}
+
+// APIKeyRoute 允许服务账号通过API密钥访问的接口及所需权限范围
+type APIKeyRoute struct {
+	Method string // 请求方法
+	Path   string // 路由路径，与 gin 的 FullPath 一致，如 /api/policies/:id
+	Scope  string // 所需权限范围
+}
+
+var (
+	apiKeyAuthenticator APIKeyAuthenticator
+	apiKeyRoutes        = map[string]string{}
+)
+
+// SetAPIKeyAuthenticator 设置API密钥认证器，启动时注入；未设置时不接受API密钥
+func SetAPIKeyAuthenticator(authenticator APIKeyAuthenticator) {
+	apiKeyAuthenticator = authenticator
+}
+
+// RegisterAPIKeyRoutes 登记允许API密钥访问的接口；未登记的接口一律拒绝API密钥访问
+func RegisterAPIKeyRoutes(routes ...APIKeyRoute) {
+	for _, route := range routes {
+		apiKeyRoutes[route.Method+" "+route.Path] = route.Scope
+	}
+}
+
+// fieldMasking 敏感字段脱敏服务，未设置时响应不脱敏
+var fieldMasking *service.FieldMaskingService
+
+// SetFieldMasking 设置敏感字段脱敏服务，启动时注入
+func SetFieldMasking(svc *service.FieldMaskingService) {
+	fieldMasking = svc
+}
+
+// attachFieldMasker 按认证后的身份为请求创建脱敏器并写入请求上下文
+func attachFieldMasker(c *gin.Context) {
+	if fieldMasking == nil {
+		return
+	}
+	roleIDs, _ := GetRoleIDs(c)
+	impersonation, _ := GetImpersonation(c)
+	actorType := c.GetString("actor_type")
+	if actorType == "" {
+		actorType = model.ActorTypeUser
+	}
+	masker := fieldMasking.NewMasker(service.MaskViewer{
+		UserID:        c.GetString("user_id"),
+		Username:      c.GetString("username"),
+		CompanyID:     c.GetString("company_id"),
+		RoleIDs:       roleIDs,
+		ActorType:     actorType,
+		Scopes:        c.GetStringSlice("api_scopes"),
+		KeyPrefix:     c.GetString("api_key_prefix"),
+		RequestURL:    c.Request.URL.Path,
+		RequestMethod: c.Request.Method,
+		IPAddress:     c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+		Impersonation: impersonation,
+	})
+	c.Request = c.Request.WithContext(service.WithFieldMasker(c.Request.Context(), masker))
+}
+
+// AuthMiddleware JWT认证中间件，未携带Authorization头时接受服务账号的API密钥
+func AuthMiddleware(config *configs.Config) gin.HandlerFunc {
+	parser := tokenParser(config)
 
 	return func(c *gin.Context) {
 		// 获取Authorization头
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" && apiKeyAuthenticator != nil {
+			if rawKey := c.GetHeader(model.APIKeyHeader); rawKey != "" {
+				authenticateAPIKey(c, rawKey)
+				return
+			}
+		}
 		if authHeader == "" {
 			logger.Warnf("认证失败 - 缺少Authorization头: %s", c.ClientIP())
 			c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.CodeUnauthorized, "未提供认证令牌", nil))
@@ -45,7 +188,7 @@ func AuthMiddleware(config *configs.Config) gin.HandlerFunc {
 		}
 
 		// 解析令牌
-		claims, err := jwtUtil.ParseToken(tokenParts[1])
+		claims, err := parser.ParseToken(tokenParts[1])
 		if err != nil {
 			logger.Warnf("认证失败 - 令牌解析错误: %v, IP: %s", err, c.ClientIP())
 			c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.CodeTokenInvalid, "令牌无效或已过期", nil))
@@ -53,6 +196,58 @@ func AuthMiddleware(config *configs.Config) gin.HandlerFunc {
 			return
 		}
 
+		// 模拟登录令牌校验模拟会话，不校验被模拟用户的会话和公司IP白名单
+		if claims.Impersonation != nil {
+			if !checkImpersonation(c, claims) {
+				return
+			}
+			attachFieldMasker(c)
+			c.Next()
+			return
+		}
+
+		// 检查会话是否已被吊销、是否须先修改密码
+		if sessionChecker != nil {
+			if err := sessionChecker.CheckSession(c.Request.Context(), claims); err != nil {
+				if errors.Is(err, service.ErrPasswordChangeRequired) {
+					if !passwordChangeExemptPaths[c.FullPath()] {
+						logger.Warnf("访问被拒绝 - 须先修改密码: UserID=%s, Path=%s", claims.UserID, c.FullPath())
+						c.JSON(http.StatusForbidden, model.ErrorResponse(model.CodePasswordChange, err.Error(), nil))
+						c.Abort()
+						return
+					}
+				} else {
+					logger.Warnf("认证失败 - 会话已失效: UserID=%s, Error=%v, IP: %s", claims.UserID, err, c.ClientIP())
+					c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.CodeTokenInvalid, "登录状态已失效，请重新登录", nil))
+					c.Abort()
+					return
+				}
+			}
+		}
+
+		// 校验公司IP白名单，平台管理员不受限制
+		if ipAccessChecker != nil && !IsAdminRoles(claims.RoleIDs) {
+			err := ipAccessChecker.CheckIPAccess(c.Request.Context(), &model.IPAccessAttempt{
+				UserID:    claims.UserID,
+				Username:  claims.Username,
+				CompanyID: claims.CompanyID,
+				IPAddress: c.ClientIP(),
+				UserAgent: c.Request.UserAgent(),
+				Path:      c.Request.Method + " " + c.FullPath(),
+				Source:    model.IPAccessSourceRequest,
+			})
+			if err != nil {
+				if errors.Is(err, service.ErrIPNotAllowed) {
+					c.JSON(http.StatusForbidden, model.ErrorResponse(model.CodeIPNotAllowed, err.Error(), nil))
+				} else {
+					logger.Errorf("认证失败 - 校验公司IP白名单出错: UserID=%s, Error=%v", claims.UserID, err)
+					c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeServerError, "访问校验失败", nil))
+				}
+				c.Abort()
+				return
+			}
+		}
+
 		// 记录认证成功日志
 		logger.Debugf("用户认证成功: UserID=%s, Username=%s, IP=%s", claims.UserID, claims.Username, c.ClientIP())
 
@@ -61,29 +256,147 @@ func AuthMiddleware(config *configs.Config) gin.HandlerFunc {
 		c.Set("username", claims.Username)
 		c.Set("company_id", claims.CompanyID)
 		c.Set("role_ids", claims.RoleIDs)
+		attachFieldMasker(c)
 
 		c.Next()
 	}
 }
 
+// checkImpersonation 校验模拟登录令牌：会话须仍在进行中，只读模式下拦截写操作；
+// 通过后写入被模拟用户的身份，并在请求上下文中记录实际操作人
+func checkImpersonation(c *gin.Context, claims *utils.Claims) bool {
+	impersonation := claims.Impersonation
+	if impersonationChecker == nil {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.CodeTokenInvalid, service.ErrImpersonationEnded.Error(), nil))
+		c.Abort()
+		return false
+	}
+
+	if err := impersonationChecker.CheckImpersonation(c.Request.Context(), claims); err != nil {
+		if errors.Is(err, service.ErrImpersonationEnded) {
+			logger.Warnf("认证失败 - 模拟登录已结束: SessionID=%s, ActorID=%s, UserID=%s, IP=%s", impersonation.SessionID, impersonation.ActorID, claims.UserID, c.ClientIP())
+			c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.CodeTokenInvalid, err.Error(), nil))
+		} else {
+			logger.Errorf("认证失败 - 校验模拟登录会话出错: SessionID=%s, Error=%v", impersonation.SessionID, err)
+			c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeServerError, "访问校验失败", nil))
+		}
+		c.Abort()
+		return false
+	}
+
+	path := c.FullPath()
+	if impersonationForbiddenPaths[path] {
+		c.JSON(http.StatusForbidden, model.ErrorResponse(model.CodeForbidden, "模拟登录期间不能执行该操作", nil))
+		c.Abort()
+		return false
+	}
+
+	method := c.Request.Method
+	isWrite := method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+	if impersonation.ReadOnly && isWrite && !impersonationWriteExemptPaths[path] {
+		logger.Warnf("模拟登录写操作被拦截: SessionID=%s, ActorID=%s, UserID=%s, Path=%s %s", impersonation.SessionID, impersonation.ActorID, claims.UserID, method, path)
+		go impersonationChecker.RecordBlockedWrite(context.Background(), impersonation.SessionID, method+" "+path)
+		c.JSON(http.StatusForbidden, model.ErrorResponse(model.CodeForbidden, "模拟登录为只读模式，不能执行写操作", nil))
+		c.Abort()
+		return false
+	}
+
+	logger.Debugf("模拟登录认证成功: SessionID=%s, ActorID=%s, UserID=%s, IP=%s", impersonation.SessionID, impersonation.ActorID, claims.UserID, c.ClientIP())
+
+	var expiresAt time.Time
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("company_id", claims.CompanyID)
+	c.Set("role_ids", claims.RoleIDs)
+	c.Set("impersonation", &model.ImpersonationInfo{
+		SessionID: impersonation.SessionID,
+		ActorID:   impersonation.ActorID,
+		ActorName: impersonation.ActorName,
+		ReadOnly:  impersonation.ReadOnly,
+		ExpiresAt: expiresAt,
+	})
+	c.Request = c.Request.WithContext(service.WithActor(c.Request.Context(), service.Actor{
+		ID:               claims.UserID,
+		Name:             claims.Username,
+		Type:             model.ActorTypeUser,
+		ImpersonatorID:   impersonation.ActorID,
+		ImpersonatorName: impersonation.ActorName,
+	}))
+	return true
+}
+
+// authenticateAPIKey 服务账号API密钥认证，只允许访问已登记且在其权限范围内的接口
+func authenticateAPIKey(c *gin.Context, rawKey string) {
+	routeKey := c.Request.Method + " " + c.FullPath()
+	scope, ok := apiKeyRoutes[routeKey]
+	if !ok {
+		logger.Warnf("认证失败 - 接口不支持API密钥访问: %s, IP: %s", routeKey, c.ClientIP())
+		c.JSON(http.StatusForbidden, model.ErrorResponse(model.CodePermissionDeny, "该接口不支持API密钥访问", nil))
+		c.Abort()
+		return
+	}
+
+	identity, err := apiKeyAuthenticator.AuthenticateAPIKey(c.Request.Context(), rawKey, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, service.ErrAPIKeyIPNotAllowed) {
+			c.JSON(http.StatusForbidden, model.ErrorResponse(model.CodeForbidden, err.Error(), nil))
+		} else {
+			c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.CodeTokenInvalid, service.ErrInvalidAPIKey.Error(), nil))
+		}
+		c.Abort()
+		return
+	}
+
+	if !identity.HasScope(scope) {
+		logger.Warnf("权限验证失败 - 服务账号缺少权限范围: ServiceAccountID=%s, Scope=%s, IP=%s", identity.ServiceAccountID, scope, c.ClientIP())
+		c.JSON(http.StatusForbidden, model.ErrorResponse(model.CodePermissionDeny, "API密钥没有该接口的权限", nil))
+		c.Abort()
+		return
+	}
+
+	logger.Debugf("服务账号认证成功: ServiceAccountID=%s, Prefix=%s, IP=%s", identity.ServiceAccountID, identity.KeyPrefix, c.ClientIP())
+
+	// 服务账号以自身ID作为操作人，没有角色，只能访问所属公司的数据
+	username := "svc:" + identity.Name
+	c.Set("user_id", identity.ServiceAccountID)
+	c.Set("username", username)
+	c.Set("company_id", identity.CompanyID)
+	c.Set("role_ids", []string{})
+	c.Set("actor_type", model.ActorTypeServiceAccount)
+	c.Set("api_key_prefix", identity.KeyPrefix)
+	c.Set("api_scopes", identity.Scopes)
+	c.Request = c.Request.WithContext(service.WithActor(c.Request.Context(), service.Actor{
+		ID:   identity.ServiceAccountID,
+		Name: username,
+		Type: model.ActorTypeServiceAccount,
+	}))
+	attachFieldMasker(c)
+
+	c.Next()
+}
+
 // OptionalAuthMiddleware 可选认证中间件（用于某些不强制登录的接口）
 func OptionalAuthMiddleware(config *configs.Config) gin.HandlerFunc {
-	// 解析时间配置
-	expiresIn, _ := time.ParseDuration(config.JWT.ExpiresIn)
-	refreshExpiresIn, _ := time.ParseDuration(config.JWT.RefreshExpiresIn)
-
-	jwtUtil := utils.NewJWTUtil(
-		config.JWT.Secret,
-		expiresIn,
-		refreshExpiresIn,
-	)
+	parser := tokenParser(config)
 
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader != "" {
 			tokenParts := strings.SplitN(authHeader, " ", 2)
 			if len(tokenParts) == 2 && tokenParts[0] == "Bearer" {
-				claims, err := jwtUtil.ParseToken(tokenParts[1])
+				claims, err := parser.ParseToken(tokenParts[1])
+				if err == nil && claims.Impersonation != nil {
+					// 模拟登录令牌须经 AuthMiddleware 校验模拟会话，可选认证按未登录处理
+					err = errors.New("模拟登录令牌不用于可选认证")
+				}
+				if err == nil && sessionChecker != nil {
+					if checkErr := sessionChecker.CheckSession(c.Request.Context(), claims); checkErr != nil && !errors.Is(checkErr, service.ErrPasswordChangeRequired) {
+						err = checkErr
+					}
+				}
 				if err == nil {
 					c.Set("user_id", claims.UserID)
 					c.Set("username", claims.Username)
@@ -117,16 +430,7 @@ func AdminRequiredMiddleware() gin.HandlerFunc {
 		}
 
 		// 检查是否为平台管理员（超级管理员）
-		// 假设超级管理员的角色ID为 "ADMIN" 或 "SUPER_ADMIN"
-		isAdmin := false
-		for _, roleID := range roleIDs {
-			if roleID == "ADMIN" || roleID == "SUPER_ADMIN" || roleID == "platform_admin" {
-				isAdmin = true
-				break
-			}
-		}
-
-		if !isAdmin {
+		if !IsAdminRoles(roleIDs) {
 			userID, _ := GetUserID(c)
 			logger.Warnf("权限验证失败 - 非管理员用户: UserID=%s, RoleIDs=%v, IP=%s", userID, roleIDs, c.ClientIP())
 			c.JSON(http.StatusForbidden, model.ErrorResponse(model.CodePermissionDeny, "需要管理员权限", nil))
@@ -141,6 +445,57 @@ func AdminRequiredMiddleware() gin.HandlerFunc {
 	}
 }
 
+// PermissionRequiredMiddleware 权限标识验证中间件，平台管理员直接放行，其他用户需通过角色菜单拥有指定权限标识
+func PermissionRequiredMiddleware(rbacRepo repository.RBACRepository, permissionCode string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 服务账号按API密钥的权限范围校验
+		if c.GetString("actor_type") == model.ActorTypeServiceAccount {
+			if !containsString(c.GetStringSlice("api_scopes"), permissionCode) {
+				c.JSON(http.StatusForbidden, model.ErrorResponse(model.CodePermissionDeny, "API密钥没有该接口的权限", nil))
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		roleIDs, _ := GetRoleIDs(c)
+		if IsAdminRoles(roleIDs) {
+			c.Next()
+			return
+		}
+
+		userID, exists := GetUserID(c)
+		if !exists {
+			c.JSON(http.StatusUnauthorized, model.ErrorResponse(model.CodeUnauthorized, "用户未登录", nil))
+			c.Abort()
+			return
+		}
+
+		allowed, err := rbacRepo.CheckUserPermission(c.Request.Context(), userID, permissionCode)
+		if err != nil {
+			logger.Errorf("权限验证失败 - 查询用户权限出错: UserID=%s, Permission=%s, Error=%v", userID, permissionCode, err)
+			c.JSON(http.StatusInternalServerError, model.ErrorResponse(model.CodeServerError, "权限验证失败", nil))
+			c.Abort()
+			return
+		}
+		if !allowed {
+			logger.Warnf("权限验证失败 - 缺少权限: UserID=%s, Permission=%s, IP=%s", userID, permissionCode, c.ClientIP())
+			c.JSON(http.StatusForbidden, model.ErrorResponse(model.CodePermissionDeny, "没有操作权限", nil))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// IsAdminRoles 判断角色列表中是否包含平台管理员（超级管理员）角色
+// 超级管理员的角色ID为 "ADMIN"、"SUPER_ADMIN" 或 "platform_admin"，见 model.IsPlatformAdminRoles
+func IsAdminRoles(roleIDs []string) bool {
+	return model.IsPlatformAdminRoles(roleIDs)
+}
+
 // GetUserID 从上下文获取用户ID
 func GetUserID(c *gin.Context) (string, bool) {
 	userID, exists := c.Get("user_id")
@@ -176,3 +531,22 @@ func GetRoleIDs(c *gin.Context) ([]string, bool) {
 	}
 	return roleIDs.([]string), true
 }
+
+// GetImpersonation 从上下文获取模拟登录信息，非模拟登录时返回 false
+func GetImpersonation(c *gin.Context) (*model.ImpersonationInfo, bool) {
+	impersonation, exists := c.Get("impersonation")
+	if !exists {
+		return nil, false
+	}
+	return impersonation.(*model.ImpersonationInfo), true
+}
+
+// containsString 字符串切片是否包含指定值
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
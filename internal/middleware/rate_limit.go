@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"YufungProject/configs"
+	"YufungProject/internal/model"
+	"YufungProject/pkg/logger"
+	"YufungProject/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimitBodyLimit 按用户名限流时读取请求体的最大字节数
+const rateLimitBodyLimit = 64 << 10
+
+var (
+	rateLimiter     ratelimit.Limiter
+	authIPRule      ratelimit.Rule
+	authAccountRule ratelimit.Rule
+	userLimitRules  = map[string]ratelimit.Rule{}
+)
+
+// SetRateLimiter 设置限流器和规则，启动时注入；未设置或未启用时各限流中间件直接放行
+func SetRateLimiter(limiter ratelimit.Limiter, config configs.RateLimitConfig) {
+	if !config.Enabled {
+		rateLimiter = nil
+		return
+	}
+
+	rateLimiter = limiter
+	authIPRule = parseRateLimitRule(config.AuthIP)
+	authAccountRule = parseRateLimitRule(config.AuthAccount)
+	userLimitRules = make(map[string]ratelimit.Rule, len(config.UserLimits))
+	for scope, rule := range config.UserLimits {
+		userLimitRules[scope] = parseRateLimitRule(rule)
+	}
+}
+
+// parseRateLimitRule 解析限流规则，周期无效时该规则不生效
+func parseRateLimitRule(rule configs.RateLimitRule) ratelimit.Rule {
+	period, err := time.ParseDuration(strings.TrimSpace(rule.Period))
+	if err != nil {
+		period = 0
+	}
+	return ratelimit.Rule{Capacity: rule.Capacity, Period: period}
+}
+
+// AuthIPRateLimitMiddleware 认证接口按IP限流
+func AuthIPRateLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !allowRequest(c, "auth:ip:"+c.ClientIP(), authIPRule) {
+			logger.Warnf("认证请求过于频繁 - IP限流: IP=%s, Path=%s", c.ClientIP(), c.FullPath())
+			return
+		}
+		c.Next()
+	}
+}
+
+// LoginRateLimitMiddleware 登录按请求体中的用户名限流，不区分IP，防止从多个IP针对同一账户猜测密码；
+// 只对失败的登录计数（登录成功时归还令牌），避免他人用已知用户名耗尽配额使真实用户无法登录
+func LoginRateLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		account, ok := rateLimitAccount(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		key := "auth:account:" + account
+		if !allowRequest(c, key, authAccountRule) {
+			logger.Warnf("认证请求过于频繁 - 账户限流: Account=%s, IP=%s, Path=%s", account, c.ClientIP(), c.FullPath())
+			return
+		}
+		c.Next()
+
+		if c.Writer.Status() < http.StatusBadRequest {
+			if err := rateLimiter.Refund(c.Request.Context(), key, authAccountRule); err != nil {
+				logger.Errorf("归还登录限流令牌失败: Key=%s, Error=%v", key, err)
+			}
+		}
+	}
+}
+
+// PasswordResetRateLimitMiddleware 忘记密码按请求体中的账户限流，与登录分别计数，
+// 重置请求被刷满时不影响该账户登录
+func PasswordResetRateLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		account, ok := rateLimitAccount(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		if !allowRequest(c, "auth:reset:"+account, authAccountRule) {
+			logger.Warnf("认证请求过于频繁 - 重置密码限流: Account=%s, IP=%s, Path=%s", account, c.ClientIP(), c.FullPath())
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitAccount 读取请求体中的用户名（username 或 account 字段）并还原请求体；
+// 未启用账户限流或没有用户名时返回false
+func rateLimitAccount(c *gin.Context) (string, bool) {
+	if rateLimiter == nil || !authAccountRule.Enabled() || c.Request.Body == nil {
+		return "", false
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, rateLimitBodyLimit))
+	if err != nil {
+		return "", false
+	}
+	// 读取后还原请求体，供后续绑定参数
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var payload struct {
+		Username string `json:"username"`
+		Account  string `json:"account"`
+	}
+	_ = json.Unmarshal(body, &payload)
+	account := payload.Username
+	if account == "" {
+		account = payload.Account
+	}
+	account = strings.ToLower(strings.TrimSpace(account))
+	return account, account != ""
+}
+
+// UserRateLimitMiddleware 耗资源接口（导出、导入等）按当前用户限流，scope 对应 rate_limit.user_limits 中的配置项，
+// 需放在认证中间件之后
+func UserRateLimitMiddleware(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := GetUserID(c)
+		if !exists || userID == "" {
+			c.Next()
+			return
+		}
+
+		if !allowRequest(c, "user:"+scope+":"+userID, userLimitRules[scope]) {
+			logger.Warnf("请求过于频繁 - 用户限流: UserID=%s, Scope=%s, Path=%s", userID, scope, c.FullPath())
+			return
+		}
+		c.Next()
+	}
+}
+
+// allowRequest 取令牌，被限流时返回429并中止请求；限流器出错时放行
+func allowRequest(c *gin.Context, key string, rule ratelimit.Rule) bool {
+	if rateLimiter == nil || !rule.Enabled() {
+		return true
+	}
+
+	result, err := rateLimiter.Allow(c.Request.Context(), key, rule)
+	if err != nil {
+		logger.Errorf("限流检查失败，放行请求: Key=%s, Error=%v", key, err)
+		return true
+	}
+	if result.Allowed {
+		return true
+	}
+
+	retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, model.ErrorResponse(model.CodeTooManyRequests, "请求过于频繁，请稍后再试", gin.H{"retry_after": retryAfter}))
+	c.Abort()
+	return false
+}
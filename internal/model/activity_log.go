@@ -22,10 +22,16 @@ type ActivityLog struct {
 	IPAddress     string             `bson:"ip_address" json:"ip_address"`
 	UserAgent     string             `bson:"user_agent" json:"user_agent"`
 	OperationTime time.Time          `bson:"operation_time" json:"operation_time"`
-	ExecutionTime int64              `bson:"execution_time" json:"execution_time"`     // 执行耗时(ms)
-	ResultStatus  string             `bson:"result_status" json:"result_status"`       // success/failure
-	TargetID      string             `bson:"target_id" json:"target_id,omitempty"`     // 操作目标ID
-	TargetName    string             `bson:"target_name" json:"target_name,omitempty"` // 操作目标名称
+	ExecutionTime int64              `bson:"execution_time" json:"execution_time"`                     // 执行耗时(ms)
+	ResultStatus  string             `bson:"result_status" json:"result_status"`                       // success/failure
+	TargetID      string             `bson:"target_id" json:"target_id,omitempty"`                     // 操作目标ID
+	TargetName    string             `bson:"target_name" json:"target_name,omitempty"`                 // 操作目标名称
+	ActorType     string             `bson:"actor_type,omitempty" json:"actor_type,omitempty"`         // 操作人类型：user/service_account
+	APIKeyPrefix  string             `bson:"api_key_prefix,omitempty" json:"api_key_prefix,omitempty"` // 服务账号调用时使用的API密钥前缀
+
+	ImpersonatorID   string `bson:"impersonator_id,omitempty" json:"impersonator_id,omitempty"`     // 模拟登录时的实际操作人（平台管理员）用户ID
+	ImpersonatorName string `bson:"impersonator_name,omitempty" json:"impersonator_name,omitempty"` // 模拟登录时的实际操作人用户名
+	ImpersonationID  string `bson:"impersonation_id,omitempty" json:"impersonation_id,omitempty"`   // 模拟登录会话ID
 }
 
 // ActivityLogQuery 活动记录查询参数
@@ -65,6 +71,8 @@ const (
 	ModuleMenu     = "菜单管理"
 	ModuleCompany  = "公司管理"
 	ModulePolicy   = "保单管理"
+	ModuleProduct  = "产品管理"
+	ModuleFeeRule  = "转介费规则"
 	ModuleCustomer = "客户管理"
 	ModuleSystem   = "系统管理"
 	ModuleAuth     = "认证授权"
@@ -0,0 +1,167 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 公告状态
+const (
+	AnnouncementStatusDraft     = "draft"     // 草稿
+	AnnouncementStatusPublished = "published" // 已发布
+	AnnouncementStatusWithdrawn = "withdrawn" // 已撤回
+)
+
+// 公告优先级
+const (
+	AnnouncementPriorityLow    = "low"    // 低
+	AnnouncementPriorityNormal = "normal" // 普通
+	AnnouncementPriorityHigh   = "high"   // 高
+	AnnouncementPriorityUrgent = "urgent" // 紧急
+)
+
+// 公告受众类型
+const (
+	AnnouncementAudienceAll       = "all"       // 全部：平台公告为所有公司，公司公告为本公司全部用户
+	AnnouncementAudienceCompanies = "companies" // 指定公司（仅平台公告）
+	AnnouncementAudienceRoles     = "roles"     // 指定角色：平台公告为所有公司中的该角色，公司公告为本公司中的该角色
+)
+
+// Announcement 通知公告，CompanyID为空表示平台公告，否则为该公司的内部公告
+type Announcement struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`                    // MongoDB主键ID
+	AnnouncementID  string             `bson:"announcement_id" json:"announcement_id"`     // 公告唯一标识，业务主键
+	CompanyID       string             `bson:"company_id" json:"company_id"`               // 发布公司ID，平台公告为空
+	Title           string             `bson:"title" json:"title"`                         // 标题
+	Content         string             `bson:"content" json:"content"`                     // 富文本内容（已清理的HTML）
+	Priority        string             `bson:"priority" json:"priority"`                   // 优先级：low/normal/high/urgent
+	Status          string             `bson:"status" json:"status"`                       // 状态：draft/published/withdrawn
+	RequireAck      bool               `bson:"require_ack" json:"require_ack"`             // 是否需要用户确认
+	AudienceType    string             `bson:"audience_type" json:"audience_type"`         // 受众类型：all/companies/roles
+	TargetCompanies []string           `bson:"target_companies" json:"target_companies"`   // 受众公司ID（audience_type=companies）
+	TargetRoles     []string           `bson:"target_roles" json:"target_roles"`           // 受众角色ID（audience_type=roles）
+	PublishAt       time.Time          `bson:"publish_at" json:"publish_at"`               // 生效时间，发布后到达该时间才对受众可见
+	ExpireAt        *time.Time         `bson:"expire_at,omitempty" json:"expire_at"`       // 失效时间，为空表示长期有效
+	PublishedAt     *time.Time         `bson:"published_at,omitempty" json:"published_at"` // 发布操作时间
+	CreatedBy       string             `bson:"created_by" json:"created_by"`               // 创建人
+	CreatedByName   string             `bson:"created_by_name" json:"created_by_name"`     // 创建人名称
+	UpdatedBy       string             `bson:"updated_by" json:"updated_by"`               // 最后更新人
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`               // 创建时间
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`               // 更新时间
+}
+
+// AnnouncementRead 公告阅读回执，每个用户每条公告一条
+type AnnouncementRead struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	AnnouncementID string             `bson:"announcement_id" json:"announcement_id"` // 公告ID
+	UserID         string             `bson:"user_id" json:"user_id"`                 // 用户ID
+	CompanyID      string             `bson:"company_id" json:"company_id"`           // 用户所属公司ID
+	ReadAt         time.Time          `bson:"read_at" json:"read_at"`                 // 首次阅读时间
+	AcknowledgedAt *time.Time         `bson:"acknowledged_at" json:"acknowledged_at"` // 确认时间
+}
+
+// AnnouncementCreateRequest 创建公告请求
+type AnnouncementCreateRequest struct {
+	Title           string     `json:"title" binding:"required,max=200" label:"标题"`
+	Content         string     `json:"content" binding:"required" label:"内容"`
+	Priority        string     `json:"priority" binding:"omitempty,oneof=low normal high urgent" label:"优先级"`
+	RequireAck      bool       `json:"require_ack" label:"是否需要确认"`
+	AudienceType    string     `json:"audience_type" binding:"required,oneof=all companies roles" label:"受众类型"`
+	TargetCompanies []string   `json:"target_companies" label:"受众公司"`
+	TargetRoles     []string   `json:"target_roles" label:"受众角色"`
+	PublishAt       *time.Time `json:"publish_at" label:"生效时间"` // 为空时发布即生效
+	ExpireAt        *time.Time `json:"expire_at" label:"失效时间"`
+	Publish         bool       `json:"publish" label:"立即发布"` // 为false时保存为草稿
+}
+
+// AnnouncementUpdateRequest 更新公告请求，只更新传入的字段
+type AnnouncementUpdateRequest struct {
+	Title           *string    `json:"title" binding:"omitempty,max=200" label:"标题"`
+	Content         *string    `json:"content" label:"内容"`
+	Priority        *string    `json:"priority" binding:"omitempty,oneof=low normal high urgent" label:"优先级"`
+	RequireAck      *bool      `json:"require_ack" label:"是否需要确认"`
+	AudienceType    *string    `json:"audience_type" binding:"omitempty,oneof=all companies roles" label:"受众类型"`
+	TargetCompanies []string   `json:"target_companies" label:"受众公司"`
+	TargetRoles     []string   `json:"target_roles" label:"受众角色"`
+	PublishAt       *time.Time `json:"publish_at" label:"生效时间"`
+	ExpireAt        *time.Time `json:"expire_at" label:"失效时间"`
+	ClearExpireAt   bool       `json:"clear_expire_at" label:"清除失效时间"`
+	Status          *string    `json:"status" binding:"omitempty,oneof=draft published withdrawn" label:"状态"`
+}
+
+// AnnouncementManageQueryRequest 公告管理查询请求
+type AnnouncementManageQueryRequest struct {
+	Page     int    `form:"page" label:"页码"`
+	PageSize int    `form:"page_size" binding:"omitempty,max=100" label:"每页数量"`
+	Keyword  string `form:"keyword" label:"关键字"`
+	Status   string `form:"status" binding:"omitempty,oneof=draft published withdrawn" label:"状态"`
+	Priority string `form:"priority" binding:"omitempty,oneof=low normal high urgent" label:"优先级"`
+}
+
+// AnnouncementManageItem 公告管理列表项，附带阅读统计
+type AnnouncementManageItem struct {
+	Announcement `bson:",inline"`
+	ReadCount    int64 `json:"read_count"`         // 已读人数
+	AckCount     int64 `json:"acknowledged_count"` // 已确认人数
+}
+
+// AnnouncementManageListResponse 公告管理列表响应
+type AnnouncementManageListResponse struct {
+	List     []AnnouncementManageItem `json:"list"`
+	Total    int64                    `json:"total"`
+	Page     int                      `json:"page"`
+	PageSize int                      `json:"page_size"`
+}
+
+// AnnouncementQueryRequest 查询当前用户可见公告请求
+type AnnouncementQueryRequest struct {
+	Page       int    `form:"page" label:"页码"`
+	PageSize   int    `form:"page_size" binding:"omitempty,max=100" label:"每页数量"`
+	Priority   string `form:"priority" binding:"omitempty,oneof=low normal high urgent" label:"优先级"`
+	UnreadOnly bool   `form:"unread_only" label:"只看未读"`
+}
+
+// AnnouncementItem 当前用户可见的公告，附带本人阅读状态
+type AnnouncementItem struct {
+	Announcement   `bson:",inline"`
+	IsRead         bool       `json:"is_read"`         // 是否已读
+	ReadAt         *time.Time `json:"read_at"`         // 阅读时间
+	IsAcknowledged bool       `json:"is_acknowledged"` // 是否已确认
+	AcknowledgedAt *time.Time `json:"acknowledged_at"` // 确认时间
+}
+
+// AnnouncementListResponse 当前用户可见公告列表响应
+type AnnouncementListResponse struct {
+	List     []AnnouncementItem `json:"list"`
+	Total    int64              `json:"total"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+}
+
+// AnnouncementBrief 待确认公告摘要（登录时返回）
+type AnnouncementBrief struct {
+	AnnouncementID string    `json:"announcement_id"` // 公告ID
+	Title          string    `json:"title"`           // 标题
+	Priority       string    `json:"priority"`        // 优先级
+	PublishAt      time.Time `json:"publish_at"`      // 生效时间
+}
+
+// AnnouncementReceiptItem 公告回执明细
+type AnnouncementReceiptItem struct {
+	UserID         string     `json:"user_id"`         // 用户ID
+	Username       string     `json:"username"`        // 用户名
+	DisplayName    string     `json:"display_name"`    // 显示名称
+	CompanyID      string     `json:"company_id"`      // 所属公司ID
+	ReadAt         *time.Time `json:"read_at"`         // 阅读时间，未读为空
+	AcknowledgedAt *time.Time `json:"acknowledged_at"` // 确认时间，未确认为空
+}
+
+// AnnouncementReceiptResponse 公告回执统计
+type AnnouncementReceiptResponse struct {
+	AnnouncementID string                    `json:"announcement_id"`    // 公告ID
+	AudienceCount  int                       `json:"audience_count"`     // 受众总人数
+	ReadCount      int                       `json:"read_count"`         // 已读人数
+	AckCount       int                       `json:"acknowledged_count"` // 已确认人数
+	List           []AnnouncementReceiptItem `json:"list"`               // 受众回执明细，未读的排在前面
+}
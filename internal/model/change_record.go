@@ -14,6 +14,7 @@ type ChangeRecord struct {
 	RecordID      string                 `bson:"record_id" json:"record_id"`                             // 记录ID
 	UserID        string                 `bson:"user_id" json:"user_id"`                                 // 操作用户ID
 	Username      string                 `bson:"username" json:"username"`                               // 用户名
+	ActorType     string                 `bson:"actor_type,omitempty" json:"actor_type,omitempty"`       // 操作人类型：user/service_account
 	CompanyID     string                 `bson:"company_id" json:"company_id"`                           // 所属公司ID
 	ChangeType    string                 `bson:"change_type" json:"change_type"`                         // 变更类型：insert/update/delete
 	OldValues     map[string]interface{} `bson:"old_values,omitempty" json:"old_values,omitempty"`       // 变更前数据
@@ -23,6 +24,9 @@ type ChangeRecord struct {
 	ChangeReason  string                 `bson:"change_reason,omitempty" json:"change_reason,omitempty"` // 变更原因
 	IPAddress     string                 `bson:"ip_address,omitempty" json:"ip_address,omitempty"`       // IP地址
 	UserAgent     string                 `bson:"user_agent,omitempty" json:"user_agent,omitempty"`       // 浏览器信息
+
+	ImpersonatorID   string `bson:"impersonator_id,omitempty" json:"impersonator_id,omitempty"`     // 模拟登录时的实际操作人（平台管理员）用户ID
+	ImpersonatorName string `bson:"impersonator_name,omitempty" json:"impersonator_name,omitempty"` // 模拟登录时的实际操作人用户名
 }
 
 // ChangeRecordListParams 变更记录查询参数
@@ -55,6 +59,9 @@ type ChangeRecordResponse struct {
 	ChangeReason  string                 `json:"change_reason,omitempty"`
 	IPAddress     string                 `json:"ip_address,omitempty"`
 	UserAgent     string                 `json:"user_agent,omitempty"`
+	// 模拟登录时的实际操作人
+	ImpersonatorID   string `json:"impersonator_id,omitempty"`
+	ImpersonatorName string `json:"impersonator_name,omitempty"`
 	// 格式化显示字段
 	ChangeTimeFormatted string         `json:"change_time_formatted"`
 	ChangeDetails       []ChangeDetail `json:"change_details"`
@@ -72,31 +79,44 @@ type ChangeDetail struct {
 
 // FieldLabel 字段标签映射（用于显示友好的字段名）
 var PolicyFieldLabels = map[string]string{
-	"account_number":      "账户号",
-	"customer_number":     "客户号",
-	"customer_name_cn":    "客户中文名",
-	"customer_name_en":    "客户英文名",
-	"proposal_number":     "投保单号",
-	"policy_currency":     "保单币种",
-	"partner":             "合作伙伴",
-	"referral_code":       "转介编号",
-	"hk_manager":          "港分客户经理",
-	"referral_pm":         "转介理财经理",
-	"referral_branch":     "转介分行",
-	"referral_sub_branch": "转介支行",
-	"referral_date":       "转介日期",
-	"is_surrendered":      "签单后是否退保",
-	"payment_date":        "缴费日期",
-	"effective_date":      "生效日期",
-	"payment_method":      "缴费方式",
-	"payment_years":       "缴费年期",
-	"payment_periods":     "期缴期数",
-	"actual_premium":      "实际缴纳保费",
-	"aum":                 "AUM",
-	"commission_rate":     "佣金比例",
-	"policy_year":         "保单年度",
-	"remark":              "备注",
-	"status":              "状态",
+	"account_number":       "账户号",
+	"customer_id":          "客户",
+	"customer_number":      "客户号",
+	"customer_name_cn":     "客户中文名",
+	"customer_name_en":     "客户英文名",
+	"proposal_number":      "投保单号",
+	"policy_currency":      "保单币种",
+	"partner":              "合作伙伴",
+	"referral_code":        "转介编号",
+	"hk_manager":           "港分客户经理",
+	"referral_pm":          "转介理财经理",
+	"referral_branch":      "转介分行",
+	"referral_sub_branch":  "转介支行",
+	"referral_date":        "转介日期",
+	"is_surrendered":       "签单后是否退保",
+	"payment_date":         "缴费日期",
+	"effective_date":       "生效日期",
+	"payment_method":       "缴费方式",
+	"payment_years":        "缴费年期",
+	"payment_periods":      "期缴期数",
+	"actual_premium":       "实际缴纳保费",
+	"aum":                  "AUM",
+	"referral_rate":        "转介费率",
+	"exchange_rate":        "汇率",
+	"expected_fee":         "预计转介费",
+	"fee_rule_id":          "转介费规则",
+	"manual_referral_rate": "手工转介费率",
+	"manual_expected_fee":  "手工预计转介费",
+	"product_id":           "产品",
+	"insurance_company":    "承保公司",
+	"product_name":         "保险产品名称",
+	"product_type":         "产品类型",
+	"commission_rate":      "佣金比例",
+	"policy_year":          "保单年度",
+	"remark":               "备注",
+	"custom_fields":        "自定义字段",
+	"schema_version":       "表结构版本",
+	"status":               "状态",
 }
 
 // GetFieldLabel 获取字段显示标签
@@ -106,6 +126,14 @@ func GetFieldLabel(tableName, fieldName string) string {
 		if label, exists := PolicyFieldLabels[fieldName]; exists {
 			return label
 		}
+	case "customers":
+		if label, exists := CustomerFieldLabels[fieldName]; exists {
+			return label
+		}
+	case PolicyAttachmentTableName:
+		if label, exists := PolicyAttachmentFieldLabels[fieldName]; exists {
+			return label
+		}
 	}
 	return fieldName
 }
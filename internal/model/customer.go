@@ -0,0 +1,215 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 客户来源
+const (
+	CustomerSourceManual = "manual" // 手工创建
+	CustomerSourcePolicy = "policy" // 录入或导入保单时自动创建
+)
+
+// Customer 客户档案表模型，同一公司内以客户号唯一
+type Customer struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`                  // MongoDB主键ID
+	CustomerID     string             `bson:"customer_id" json:"customer_id"`           // 客户唯一标识，业务主键
+	CustomerNumber string             `bson:"customer_number" json:"customer_number"`   // 客户号（公司内唯一）
+	CustomerNameCN string             `bson:"customer_name_cn" json:"customer_name_cn"` // 客户中文名
+	CustomerNameEN string             `bson:"customer_name_en" json:"customer_name_en"` // 客户英文名
+	Aliases        []string           `bson:"aliases" json:"aliases"`                   // 曾用客户号（合并客户时被合并方的客户号），录入保单时按别名自动关联
+
+	// 身份信息
+	Gender   string     `bson:"gender" json:"gender"`       // 性别：male/female
+	Birthday *time.Time `bson:"birthday" json:"birthday"`   // 出生日期
+	IDType   string     `bson:"id_type" json:"id_type"`     // 证件类型：id_card/passport/hk_macao_permit/other
+	IDNumber string     `bson:"id_number" json:"id_number"` // 证件号码
+
+	// 联系方式
+	Phone   string `bson:"phone" json:"phone"`     // 联系电话
+	Email   string `bson:"email" json:"email"`     // 电子邮箱
+	Address string `bson:"address" json:"address"` // 联系地址
+
+	// 风险评估
+	RiskLevel      string     `bson:"risk_level" json:"risk_level"`             // 风险承受等级：R1-R5
+	RiskAssessedAt *time.Time `bson:"risk_assessed_at" json:"risk_assessed_at"` // 风险评估日期
+
+	Source    string    `bson:"source" json:"source"`         // 来源：manual/policy
+	CompanyID string    `bson:"company_id" json:"company_id"` // 所属公司ID（多租户隔离）
+	Remark    string    `bson:"remark" json:"remark"`         // 备注
+	CreatedBy string    `bson:"created_by" json:"created_by"` // 创建人
+	UpdatedBy string    `bson:"updated_by" json:"updated_by"` // 更新人
+	CreatedAt time.Time `bson:"created_at" json:"created_at"` // 创建时间
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"` // 更新时间
+}
+
+// CustomerCreateRequest 创建客户请求
+type CustomerCreateRequest struct {
+	CustomerNumber string     `json:"customer_number" binding:"required,max=50" label:"客户号"`
+	CustomerNameCN string     `json:"customer_name_cn" binding:"required,max=100" label:"客户中文名"`
+	CustomerNameEN string     `json:"customer_name_en" binding:"max=100" label:"客户英文名"`
+	Gender         string     `json:"gender" binding:"omitempty,oneof=male female" label:"性别"`
+	Birthday       *time.Time `json:"birthday" label:"出生日期"`
+	IDType         string     `json:"id_type" binding:"omitempty,oneof=id_card passport hk_macao_permit other" label:"证件类型"`
+	IDNumber       string     `json:"id_number" binding:"max=50" label:"证件号码"`
+	Phone          string     `json:"phone" binding:"omitempty,phone_pattern" label:"联系电话"`
+	Email          string     `json:"email" binding:"omitempty,email" label:"电子邮箱"`
+	Address        string     `json:"address" binding:"max=200" label:"联系地址"`
+	RiskLevel      string     `json:"risk_level" binding:"omitempty,oneof=R1 R2 R3 R4 R5" label:"风险承受等级"`
+	RiskAssessedAt *time.Time `json:"risk_assessed_at" label:"风险评估日期"`
+	Remark         string     `json:"remark" label:"备注"`
+}
+
+// CustomerUpdateRequest 更新客户请求，客户号或姓名变更时同步到关联保单
+type CustomerUpdateRequest struct {
+	CustomerNumber string     `json:"customer_number" binding:"max=50" label:"客户号"`
+	CustomerNameCN string     `json:"customer_name_cn" binding:"max=100" label:"客户中文名"`
+	CustomerNameEN string     `json:"customer_name_en" binding:"max=100" label:"客户英文名"`
+	Gender         string     `json:"gender" binding:"omitempty,oneof=male female" label:"性别"`
+	Birthday       *time.Time `json:"birthday" label:"出生日期"`
+	IDType         string     `json:"id_type" binding:"omitempty,oneof=id_card passport hk_macao_permit other" label:"证件类型"`
+	IDNumber       string     `json:"id_number" binding:"max=50" label:"证件号码"`
+	Phone          string     `json:"phone" binding:"omitempty,phone_pattern" label:"联系电话"`
+	Email          string     `json:"email" binding:"omitempty,email" label:"电子邮箱"`
+	Address        string     `json:"address" binding:"max=200" label:"联系地址"`
+	RiskLevel      string     `json:"risk_level" binding:"omitempty,oneof=R1 R2 R3 R4 R5" label:"风险承受等级"`
+	RiskAssessedAt *time.Time `json:"risk_assessed_at" label:"风险评估日期"`
+	Remark         string     `json:"remark" label:"备注"`
+}
+
+// CustomerQueryRequest 查询客户请求
+type CustomerQueryRequest struct {
+	Page      int    `form:"page" label:"页码"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" label:"每页数量"`
+	Keyword   string `form:"keyword" label:"关键词"` // 匹配客户号、中英文名、证件号码、电话
+	RiskLevel string `form:"risk_level" label:"风险承受等级"`
+	Source    string `form:"source" label:"来源"`
+}
+
+// CustomerListResponse 客户列表响应
+type CustomerListResponse struct {
+	List     []Customer `json:"list"`      // 客户列表
+	Total    int64      `json:"total"`     // 总数
+	Page     int        `json:"page"`      // 当前页
+	PageSize int        `json:"page_size"` // 每页数量
+}
+
+// CustomerPolicySummary 客户保单汇总
+type CustomerPolicySummary struct {
+	PolicyCount      int64                     `json:"policy_count"`      // 保单数量
+	SurrenderedCount int64                     `json:"surrendered_count"` // 退保数量
+	TotalPremium     float64                   `json:"total_premium"`     // 实际缴纳保费合计（各币种直接相加）
+	TotalAUM         float64                   `json:"total_aum"`         // AUM合计（各币种直接相加）
+	ByCurrency       []CustomerCurrencySummary `json:"by_currency"`       // 按保单币种汇总
+}
+
+// CustomerCurrencySummary 客户保单按币种汇总
+type CustomerCurrencySummary struct {
+	Currency     string  `json:"currency"`      // 保单币种
+	PolicyCount  int64   `json:"policy_count"`  // 保单数量
+	TotalPremium float64 `json:"total_premium"` // 实际缴纳保费合计
+	TotalAUM     float64 `json:"total_aum"`     // AUM合计
+}
+
+// CustomerDetailResponse 客户详情响应（含关联保单及汇总）
+type CustomerDetailResponse struct {
+	*Customer
+	Policies []Policy              `json:"policies"` // 关联保单，按创建时间倒序
+	Summary  CustomerPolicySummary `json:"summary"`  // 保单汇总
+}
+
+// CustomerLinkPoliciesResponse 按客户号关联历史保单的结果
+type CustomerLinkPoliciesResponse struct {
+	CreatedCustomers int64 `json:"created_customers"` // 新建客户数量
+	LinkedPolicies   int64 `json:"linked_policies"`   // 关联保单数量
+}
+
+// 疑似重复客户审核状态
+const (
+	CustomerDuplicatePending = "pending" // 待审核
+	CustomerDuplicateMerged  = "merged"  // 已合并
+	CustomerDuplicateIgnored = "ignored" // 已忽略（确认非同一客户，重新扫描时不再提示）
+)
+
+// CustomerDuplicate 疑似重复客户，每条记录对应公司内的一对客户
+type CustomerDuplicate struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`            // MongoDB主键ID
+	DuplicateID string             `bson:"duplicate_id" json:"duplicate_id"`   // 疑似重复记录唯一标识
+	CompanyID   string             `bson:"company_id" json:"company_id"`       // 所属公司ID（多租户隔离）
+	CustomerAID string             `bson:"customer_a_id" json:"customer_a_id"` // 客户A的ID（两个客户ID中较小者）
+	CustomerBID string             `bson:"customer_b_id" json:"customer_b_id"` // 客户B的ID
+	Score       int                `bson:"score" json:"score"`                 // 相似度得分（0-100）
+	Reasons     []string           `bson:"reasons" json:"reasons"`             // 得分依据
+	Status      string             `bson:"status" json:"status"`               // 审核状态：pending/merged/ignored
+	ReviewedBy  string             `bson:"reviewed_by" json:"reviewed_by"`     // 审核人
+	ReviewedAt  *time.Time         `bson:"reviewed_at" json:"reviewed_at"`     // 审核时间
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`       // 创建时间
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`       // 更新时间
+}
+
+// CustomerDuplicateItem 疑似重复客户审核列表项，附带双方客户档案
+type CustomerDuplicateItem struct {
+	CustomerDuplicate `bson:",inline"`
+	CustomerA         *Customer `json:"customer_a"` // 客户A档案
+	CustomerB         *Customer `json:"customer_b"` // 客户B档案
+}
+
+// CustomerDuplicateScanRequest 扫描疑似重复客户请求
+type CustomerDuplicateScanRequest struct {
+	MinScore int `json:"min_score" binding:"omitempty,min=1,max=100" label:"最低得分"` // 不填默认50
+}
+
+// CustomerDuplicateScanResponse 扫描疑似重复客户结果
+type CustomerDuplicateScanResponse struct {
+	ScannedCustomers int `json:"scanned_customers"` // 参与扫描的客户数量
+	Candidates       int `json:"candidates"`        // 达到最低得分的客户对数量
+	NewCandidates    int `json:"new_candidates"`    // 新增的待审核数量
+}
+
+// CustomerDuplicateQueryRequest 查询疑似重复客户请求
+type CustomerDuplicateQueryRequest struct {
+	Page     int    `form:"page" label:"页码"`
+	PageSize int    `form:"page_size" binding:"omitempty,max=100" label:"每页数量"`
+	Status   string `form:"status" binding:"omitempty,oneof=pending merged ignored" label:"审核状态"` // 不填默认pending
+}
+
+// CustomerDuplicateListResponse 疑似重复客户列表响应
+type CustomerDuplicateListResponse struct {
+	List     []CustomerDuplicateItem `json:"list"`      // 疑似重复客户列表，按得分倒序
+	Total    int64                   `json:"total"`     // 总数
+	Page     int                     `json:"page"`      // 当前页
+	PageSize int                     `json:"page_size"` // 每页数量
+}
+
+// CustomerMergeRequest 合并客户请求，被合并客户的保单转移到保留客户，其客户号记为保留客户的曾用客户号
+type CustomerMergeRequest struct {
+	SurvivorID string   `json:"survivor_id" binding:"required" label:"保留客户"`
+	MergedIDs  []string `json:"merged_ids" binding:"required,min=1,dive,required" label:"被合并客户"`
+	Reason     string   `json:"reason" binding:"max=200" label:"合并原因"`
+}
+
+// CustomerMergeResponse 合并客户结果
+type CustomerMergeResponse struct {
+	Customer      *Customer `json:"customer"`       // 合并后的保留客户
+	MovedPolicies int64     `json:"moved_policies"` // 转移的保单数量
+}
+
+// CustomerFieldLabels 客户字段显示名称，用于变更记录
+var CustomerFieldLabels = map[string]string{
+	"customer_number":  "客户号",
+	"customer_name_cn": "客户中文名",
+	"customer_name_en": "客户英文名",
+	"aliases":          "曾用客户号",
+	"gender":           "性别",
+	"birthday":         "出生日期",
+	"id_type":          "证件类型",
+	"id_number":        "证件号码",
+	"phone":            "联系电话",
+	"email":            "电子邮箱",
+	"address":          "联系地址",
+	"risk_level":       "风险承受等级",
+	"risk_assessed_at": "风险评估日期",
+	"remark":           "备注",
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 模拟登录会话状态
+const (
+	ImpersonationStatusActive  = "active"  // 进行中
+	ImpersonationStatusEnded   = "ended"   // 已结束
+	ImpersonationStatusExpired = "expired" // 已过期（未主动结束，查询时根据 expires_at 计算）
+)
+
+// ImpersonationSession 模拟登录会话：平台管理员以目标用户的身份查看系统，用于排查问题
+type ImpersonationSession struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`                                        // MongoDB主键ID
+	SessionID        string             `bson:"session_id" json:"session_id"`                                   // 会话唯一标识
+	ActorID          string             `bson:"actor_id" json:"actor_id"`                                       // 实际操作人（平台管理员）用户ID
+	ActorName        string             `bson:"actor_name" json:"actor_name"`                                   // 实际操作人用户名
+	TargetUserID     string             `bson:"target_user_id" json:"target_user_id"`                           // 被模拟的用户ID
+	TargetUsername   string             `bson:"target_username" json:"target_username"`                         // 被模拟的用户名
+	TargetCompanyID  string             `bson:"target_company_id" json:"target_company_id"`                     // 被模拟用户所属公司ID
+	Reason           string             `bson:"reason" json:"reason"`                                           // 原因，如工单号
+	ReadOnly         bool               `bson:"read_only" json:"read_only"`                                     // 是否禁止写操作
+	Status           string             `bson:"status" json:"status"`                                           // 状态：active/ended，expired 为查询时计算
+	IPAddress        string             `bson:"ip_address" json:"ip_address"`                                   // 发起时的客户端IP
+	UserAgent        string             `bson:"user_agent" json:"user_agent"`                                   // 发起时的浏览器信息
+	StartedAt        time.Time          `bson:"started_at" json:"started_at"`                                   // 开始时间
+	ExpiresAt        time.Time          `bson:"expires_at" json:"expires_at"`                                   // 令牌过期时间
+	EndedAt          *time.Time         `bson:"ended_at,omitempty" json:"ended_at"`                             // 结束时间
+	EndedBy          string             `bson:"ended_by,omitempty" json:"ended_by,omitempty"`                   // 结束操作人
+	BlockedWriteHits int                `bson:"blocked_write_hits" json:"blocked_write_hits"`                   // 被拦截的写操作次数
+	LastBlockedPath  string             `bson:"last_blocked_path,omitempty" json:"last_blocked_path,omitempty"` // 最近被拦截的写操作
+}
+
+// ImpersonationStartRequest 开始模拟登录请求
+type ImpersonationStartRequest struct {
+	UserID   string `json:"user_id" binding:"required" label:"用户ID"`
+	Reason   string `json:"reason" binding:"required,max=500" label:"原因"`
+	ReadOnly *bool  `json:"read_only" label:"是否只读"` // 不传时为只读；配置禁止写操作时始终只读
+}
+
+// ImpersonationStartResponse 开始模拟登录响应，前端使用该令牌代替管理员令牌访问接口，结束后恢复管理员令牌
+type ImpersonationStartResponse struct {
+	Token     string                `json:"token"`      // 模拟登录访问令牌，不签发刷新令牌
+	ExpiresAt time.Time             `json:"expires_at"` // 令牌过期时间
+	Session   *ImpersonationSession `json:"session"`    // 会话信息
+	User      *UserInfo             `json:"user"`       // 被模拟的用户
+}
+
+// ImpersonationInfo 当前令牌的模拟登录信息，在用户信息中返回，前端据此显示模拟登录提示条
+type ImpersonationInfo struct {
+	SessionID string    `json:"session_id"` // 会话ID
+	ActorID   string    `json:"actor_id"`   // 实际操作人用户ID
+	ActorName string    `json:"actor_name"` // 实际操作人用户名
+	ReadOnly  bool      `json:"read_only"`  // 是否禁止写操作
+	ExpiresAt time.Time `json:"expires_at"` // 令牌过期时间
+}
+
+// ImpersonationQueryRequest 查询模拟登录会话请求
+type ImpersonationQueryRequest struct {
+	Page         int    `form:"page" label:"页码"`
+	PageSize     int    `form:"page_size" binding:"omitempty,max=100" label:"每页数量"`
+	Status       string `form:"status" binding:"omitempty,oneof=active ended expired" label:"状态"`
+	ActorID      string `form:"actor_id" label:"操作人ID"`
+	TargetUserID string `form:"target_user_id" label:"被模拟用户ID"`
+	CompanyID    string `form:"company_id" label:"公司ID"`
+}
+
+// ImpersonationListResponse 模拟登录会话列表响应
+type ImpersonationListResponse struct {
+	List     []ImpersonationSession `json:"list"`
+	Total    int64                  `json:"total"`
+	Page     int                    `json:"page"`
+	PageSize int                    `json:"page_size"`
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 邀请状态
+const (
+	InvitationStatusPending  = "pending"  // 待接受
+	InvitationStatusAccepted = "accepted" // 已接受
+	InvitationStatusRevoked  = "revoked"  // 已撤销
+	InvitationStatusExpired  = "expired"  // 已过期（不落库，查询时根据有效期计算）
+)
+
+// Invitation 用户邀请，受邀人通过一次性链接设置用户名和密码后加入公司
+type Invitation struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`                                      // MongoDB主键ID
+	InvitationID   string             `bson:"invitation_id" json:"invitation_id"`                           // 邀请唯一标识，业务主键
+	CompanyID      string             `bson:"company_id" json:"company_id"`                                 // 受邀加入的公司ID
+	Email          string             `bson:"email" json:"email"`                                           // 受邀邮箱（小写）
+	DisplayName    string             `bson:"display_name" json:"display_name"`                             // 预填的显示名称
+	RoleIDs        []string           `bson:"role_ids" json:"role_ids"`                                     // 预分配的角色
+	TokenHash      string             `bson:"token_hash" json:"-"`                                          // 邀请令牌的SHA-256摘要，不保存明文
+	Status         string             `bson:"status" json:"status"`                                         // 状态：pending/accepted/revoked
+	ExpiresAt      time.Time          `bson:"expires_at" json:"expires_at"`                                 // 链接过期时间
+	SendCount      int                `bson:"send_count" json:"send_count"`                                 // 邀请邮件发送次数
+	LastSentAt     time.Time          `bson:"last_sent_at" json:"last_sent_at"`                             // 最后一次发送时间
+	InvitedBy      string             `bson:"invited_by" json:"invited_by"`                                 // 邀请人用户ID
+	AcceptedAt     *time.Time         `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`           // 接受时间
+	AcceptedUserID string             `bson:"accepted_user_id,omitempty" json:"accepted_user_id,omitempty"` // 接受后创建的用户ID
+	RevokedAt      *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`             // 撤销时间
+	RevokedBy      string             `bson:"revoked_by,omitempty" json:"revoked_by,omitempty"`             // 撤销人用户ID
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`                                 // 创建时间
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`                                 // 更新时间
+}
+
+// InvitationCreateRequest 邀请用户请求
+type InvitationCreateRequest struct {
+	Email       string   `json:"email" binding:"required,email" label:"邮箱"`
+	DisplayName string   `json:"display_name" binding:"omitempty,max=100" label:"显示名称"`
+	CompanyID   string   `json:"company_id" label:"公司ID"` // 平台管理员可指定公司，其他用户固定为本公司
+	RoleIDs     []string `json:"role_ids" binding:"required,min=1" label:"角色"`
+}
+
+// InvitationQueryRequest 查询邀请请求
+type InvitationQueryRequest struct {
+	Page      int    `form:"page" label:"页码"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" label:"每页数量"`
+	Status    string `form:"status" binding:"omitempty,oneof=pending accepted revoked expired" label:"状态"`
+	Email     string `form:"email" label:"邮箱"`
+	CompanyID string `form:"company_id" label:"公司ID"`
+}
+
+// InvitationListResponse 邀请列表响应
+type InvitationListResponse struct {
+	List     []Invitation `json:"list"`
+	Total    int64        `json:"total"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+}
+
+// InvitationPreview 受邀人打开链接时展示的邀请信息
+type InvitationPreview struct {
+	Email       string    `json:"email"`
+	DisplayName string    `json:"display_name"`
+	CompanyName string    `json:"company_name"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// AcceptInvitationRequest 接受邀请请求，邮箱、公司和角色以邀请为准
+type AcceptInvitationRequest struct {
+	Token       string `json:"token" binding:"required" label:"邀请令牌"`
+	Username    string `json:"username" binding:"required,min=3,max=50" label:"用户名"`
+	DisplayName string `json:"display_name" binding:"required,min=2,max=100" label:"显示名称"`
+	Password    string `json:"password" binding:"required,min=8" label:"密码"`
+	Phone       string `json:"phone" binding:"omitempty" label:"手机号码"`
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 邮件状态
+const (
+	MailStatusPending = "pending" // 待发送（含等待重试）
+	MailStatusSending = "sending" // 发送中
+	MailStatusSent    = "sent"    // 已发送
+	MailStatusFailed  = "failed"  // 发送失败（已达最大次数或不可重试）
+)
+
+// 邮件模板
+const (
+	MailTemplatePasswordReset   = "password_reset"   // 重置密码
+	MailTemplateAccountLocked   = "account_locked"   // 账户锁定提醒
+	MailTemplateInvitation      = "invitation"       // 邀请注册
+	MailTemplateReminder        = "reminder"         // 业务提醒
+	MailTemplateScheduledReport = "scheduled_report" // 定时报表
+	MailTemplateTest            = "test"             // 测试邮件
+)
+
+// MailMessage 发件箱中的邮件，入队时即完成模板渲染
+type MailMessage struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`                // MongoDB主键ID
+	MessageID     string             `bson:"message_id" json:"message_id"`           // 邮件唯一标识，业务主键
+	CompanyID     string             `bson:"company_id" json:"company_id"`           // 所属公司ID，系统邮件为空
+	Template      string             `bson:"template" json:"template"`               // 模板名称
+	Language      string             `bson:"language" json:"language"`               // 模板语言：zh/en
+	To            []string           `bson:"to" json:"to"`                           // 收件人
+	Subject       string             `bson:"subject" json:"subject"`                 // 主题
+	TextBody      string             `bson:"text_body,omitempty" json:"text_body"`   // 纯文本正文
+	HTMLBody      string             `bson:"html_body,omitempty" json:"html_body"`   // HTML正文
+	Sensitive     bool               `bson:"sensitive" json:"sensitive"`             // 是否包含敏感内容（如重置链接），管理端不展示正文，发送成功后清除正文
+	Status        string             `bson:"status" json:"status"`                   // 状态：pending/sending/sent/failed
+	Attempts      int                `bson:"attempts" json:"attempts"`               // 已尝试发送次数
+	MaxAttempts   int                `bson:"max_attempts" json:"max_attempts"`       // 最大发送次数
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"next_attempt_at"` // 下次发送时间
+	LockedUntil   *time.Time         `bson:"locked_until,omitempty" json:"-"`        // 发送中的租约到期时间，到期未完成时重新发送
+	LastError     string             `bson:"last_error" json:"last_error"`           // 最后一次发送错误
+	SentAt        *time.Time         `bson:"sent_at,omitempty" json:"sent_at"`       // 发送成功时间
+	CreatedBy     string             `bson:"created_by" json:"created_by"`           // 创建人，系统任务为空
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`           // 创建时间
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`           // 更新时间
+}
+
+// MailSendRequest 邮件入队请求（供业务模块调用）
+type MailSendRequest struct {
+	To        []string               // 收件人
+	Template  string                 // 模板名称
+	Language  string                 // 模板语言，为空时使用默认语言
+	Data      map[string]interface{} // 模板数据
+	Sensitive bool                   // 是否包含敏感内容
+	CompanyID string                 // 所属公司ID
+	CreatedBy string                 // 创建人
+}
+
+// MailQueryRequest 查询发件箱请求
+type MailQueryRequest struct {
+	Page      int    `form:"page" label:"页码"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" label:"每页数量"`
+	Status    string `form:"status" binding:"omitempty,oneof=pending sending sent failed" label:"状态"`
+	Template  string `form:"template" label:"模板名称"`
+	To        string `form:"to" label:"收件人"`
+	CompanyID string `form:"company_id" label:"公司ID"`
+	StartDate string `form:"start_date" label:"开始日期"` // 格式：2006-01-02
+	EndDate   string `form:"end_date" label:"结束日期"`   // 格式：2006-01-02
+}
+
+// MailListResponse 发件箱列表响应，列表不包含正文
+type MailListResponse struct {
+	List         []MailMessage    `json:"list"`
+	Total        int64            `json:"total"`
+	Page         int              `json:"page"`
+	PageSize     int              `json:"page_size"`
+	StatusCounts map[string]int64 `json:"status_counts"` // 各状态邮件数量
+}
+
+// MailTestRequest 发送测试邮件请求
+type MailTestRequest struct {
+	To       string `json:"to" binding:"required,email" label:"收件人"`
+	Language string `json:"language" binding:"omitempty,oneof=zh en" label:"语言"`
+}
+
+// MailProcessResult 发件箱处理结果
+type MailProcessResult struct {
+	Processed int `json:"processed"` // 处理的邮件数量
+	Sent      int `json:"sent"`      // 发送成功数量
+	Retrying  int `json:"retrying"`  // 等待重试数量
+	Failed    int `json:"failed"`    // 发送失败数量
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 通知类型
+const (
+	NotificationTypePremiumDue        = "premium_due"        // 续期保费到期
+	NotificationTypeCoolingOffEnd     = "cooling_off_end"    // 冷静期即将结束
+	NotificationTypePolicyAnniversary = "policy_anniversary" // 保单周年日
+	NotificationTypeCompanyExpiry     = "company_expiry"     // 公司有效期即将结束
+	NotificationTypeAccountLocked     = "account_locked"     // 账户被锁定
+	NotificationTypeNewLogin          = "new_login"          // 新IP或新设备登录
+)
+
+// NotificationTypes 全部通知类型，按显示顺序排列
+var NotificationTypes = []string{
+	NotificationTypePremiumDue,
+	NotificationTypeCoolingOffEnd,
+	NotificationTypePolicyAnniversary,
+	NotificationTypeCompanyExpiry,
+	NotificationTypeAccountLocked,
+	NotificationTypeNewLogin,
+}
+
+// NotificationTypeLabels 通知类型显示名称
+var NotificationTypeLabels = map[string]string{
+	NotificationTypePremiumDue:        "续期保费到期",
+	NotificationTypeCoolingOffEnd:     "冷静期即将结束",
+	NotificationTypePolicyAnniversary: "保单周年日",
+	NotificationTypeCompanyExpiry:     "公司有效期即将结束",
+	NotificationTypeAccountLocked:     "账户被锁定",
+	NotificationTypeNewLogin:          "新IP或新设备登录",
+}
+
+// Notification 站内通知，每条通知属于一个用户
+type Notification struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`                // MongoDB主键ID
+	NotificationID string             `bson:"notification_id" json:"notification_id"` // 通知唯一标识，业务主键
+	UserID         string             `bson:"user_id" json:"user_id"`                 // 接收用户ID
+	CompanyID      string             `bson:"company_id" json:"company_id"`           // 所属公司ID（多租户隔离）
+	Type           string             `bson:"type" json:"type"`                       // 通知类型
+	Title          string             `bson:"title" json:"title"`                     // 标题
+	Content        string             `bson:"content" json:"content"`                 // 内容
+	RelatedType    string             `bson:"related_type" json:"related_type"`       // 关联对象类型：policy/company/user
+	RelatedID      string             `bson:"related_id" json:"related_id"`           // 关联对象ID
+	DedupKey       string             `bson:"dedup_key" json:"-"`                     // 去重键，同一用户同一去重键只生成一条通知
+	IsRead         bool               `bson:"is_read" json:"is_read"`                 // 是否已读
+	ReadAt         *time.Time         `bson:"read_at" json:"read_at"`                 // 阅读时间
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`           // 创建时间
+}
+
+// NotificationQueryRequest 查询通知请求
+type NotificationQueryRequest struct {
+	Page     int    `form:"page" label:"页码"`
+	PageSize int    `form:"page_size" binding:"omitempty,max=100" label:"每页数量"`
+	Type     string `form:"type" label:"通知类型"`
+	IsRead   *bool  `form:"is_read" label:"是否已读"`
+}
+
+// NotificationListResponse 通知列表响应
+type NotificationListResponse struct {
+	List        []Notification `json:"list"`         // 通知列表，按创建时间倒序
+	Total       int64          `json:"total"`        // 总数
+	UnreadCount int64          `json:"unread_count"` // 未读数量
+	Page        int            `json:"page"`         // 当前页
+	PageSize    int            `json:"page_size"`    // 每页数量
+}
+
+// NotificationMarkReadRequest 标记已读请求，不传通知ID时将全部未读通知标记为已读
+type NotificationMarkReadRequest struct {
+	NotificationIDs []string `json:"notification_ids" label:"通知ID"`
+}
+
+// NotificationPreference 用户通知偏好，未设置的通知类型默认接收
+type NotificationPreference struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	UserID    string             `bson:"user_id" json:"user_id"`       // 用户ID
+	CompanyID string             `bson:"company_id" json:"company_id"` // 所属公司ID
+	Enabled   map[string]bool    `bson:"enabled" json:"enabled"`       // 通知类型 -> 是否接收
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"` // 更新时间
+}
+
+// NotificationPreferenceItem 通知偏好项
+type NotificationPreferenceItem struct {
+	Type    string `json:"type"`    // 通知类型
+	Label   string `json:"label"`   // 通知类型显示名称
+	Enabled bool   `json:"enabled"` // 是否接收
+}
+
+// NotificationPreferenceUpdateRequest 更新通知偏好请求
+type NotificationPreferenceUpdateRequest struct {
+	Enabled map[string]bool `json:"enabled" binding:"required" label:"通知偏好"` // 通知类型 -> 是否接收，只更新传入的类型
+}
+
+// NotificationRunResult 提醒规则执行结果
+type NotificationRunResult struct {
+	Companies     int            `json:"companies"`       // 处理的公司数量
+	Created       int            `json:"created"`         // 新生成的通知数量
+	CreatedByType map[string]int `json:"created_by_type"` // 按通知类型统计新生成的通知数量
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PasswordPolicy 密码策略；CompanyID为空表示平台策略，否则为公司策略。
+// 公司策略只能在平台策略基础上加强，实际生效的是两者中更严格的设置。
+type PasswordPolicy struct {
+	ID                  primitive.ObjectID `bson:"_id,omitempty" json:"id"`                            // MongoDB主键ID
+	CompanyID           string             `bson:"company_id" json:"company_id"`                       // 所属公司ID，平台策略为空
+	MinLength           int                `bson:"min_length" json:"min_length"`                       // 最小长度
+	RequireDigit        bool               `bson:"require_digit" json:"require_digit"`                 // 必须包含数字
+	RequireLowercase    bool               `bson:"require_lowercase" json:"require_lowercase"`         // 必须包含小写字母
+	RequireUppercase    bool               `bson:"require_uppercase" json:"require_uppercase"`         // 必须包含大写字母
+	RequireSpecial      bool               `bson:"require_special" json:"require_special"`             // 必须包含特殊字符
+	MinCharClasses      int                `bson:"min_char_classes" json:"min_char_classes"`           // 数字、小写、大写、特殊字符中至少包含的种类数
+	DisallowUsername    bool               `bson:"disallow_username" json:"disallow_username"`         // 不允许包含用户名
+	DisallowCompanyName bool               `bson:"disallow_company_name" json:"disallow_company_name"` // 不允许包含公司名称或公司代码
+	HistoryCount        int                `bson:"history_count" json:"history_count"`                 // 不允许与最近N次使用过的密码相同，0表示不限制
+	MaxAgeDays          int                `bson:"max_age_days" json:"max_age_days"`                   // 密码最长使用天数，到期后须修改，0表示不过期
+	UpdatedBy           string             `bson:"updated_by,omitempty" json:"updated_by,omitempty"`   // 最后修改人
+	CreatedAt           time.Time          `bson:"created_at,omitempty" json:"created_at,omitempty"`   // 创建时间
+	UpdatedAt           time.Time          `bson:"updated_at,omitempty" json:"updated_at,omitempty"`   // 更新时间
+}
+
+// PasswordPolicyRequest 设置密码策略请求
+type PasswordPolicyRequest struct {
+	MinLength           int  `json:"min_length" binding:"required,min=6,max=128" label:"最小长度"`
+	RequireDigit        bool `json:"require_digit" label:"必须包含数字"`
+	RequireLowercase    bool `json:"require_lowercase" label:"必须包含小写字母"`
+	RequireUppercase    bool `json:"require_uppercase" label:"必须包含大写字母"`
+	RequireSpecial      bool `json:"require_special" label:"必须包含特殊字符"`
+	MinCharClasses      int  `json:"min_char_classes" binding:"min=0,max=4" label:"字符种类数"`
+	DisallowUsername    bool `json:"disallow_username" label:"不允许包含用户名"`
+	DisallowCompanyName bool `json:"disallow_company_name" label:"不允许包含公司名称"`
+	HistoryCount        int  `json:"history_count" binding:"min=0,max=24" label:"历史密码数量"`
+	MaxAgeDays          int  `json:"max_age_days" binding:"min=0,max=3650" label:"密码最长使用天数"`
+}
+
+// PasswordPolicyDetail 公司密码策略详情：平台策略、公司策略（未设置时为空）及实际生效的策略
+type PasswordPolicyDetail struct {
+	Platform  *PasswordPolicy `json:"platform"`
+	Company   *PasswordPolicy `json:"company"`
+	Effective *PasswordPolicy `json:"effective"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PasswordResetToken 密码重置令牌，只保存令牌的SHA-256摘要，令牌原文仅通过邮件发送给用户
+type PasswordResetToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`          // MongoDB主键ID
+	TokenHash string             `bson:"token_hash" json:"-"`              // 令牌摘要
+	UserID    string             `bson:"user_id" json:"user_id"`           // 用户ID
+	RequestIP string             `bson:"request_ip" json:"request_ip"`     // 申请重置的IP
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`     // 过期时间
+	UsedAt    *time.Time         `bson:"used_at,omitempty" json:"used_at"` // 使用（或作废）时间，非空即失效
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`     // 创建时间
+}
+
+// PasswordResetRequestLog 忘记密码请求记录，用于按账户和IP限流
+type PasswordResetRequestLog struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`      // MongoDB主键ID
+	Account   string             `bson:"account" json:"account"`       // 提交的用户名或邮箱（小写）
+	IP        string             `bson:"ip" json:"ip"`                 // 请求IP
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"` // 请求时间
+}
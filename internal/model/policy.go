@@ -14,6 +14,7 @@ type Policy struct {
 	// 基本信息
 	SerialNumber   int    `bson:"serial_number" json:"serial_number"`       // 序号
 	AccountNumber  string `bson:"account_number" json:"account_number"`     // 账户号
+	CustomerID     string `bson:"customer_id" json:"customer_id"`           // 关联的客户档案ID，客户号及姓名以客户档案为准
 	CustomerNumber string `bson:"customer_number" json:"customer_number"`   // 客户号
 	CustomerNameCN string `bson:"customer_name_cn" json:"customer_name_cn"` // 客户中文名
 	CustomerNameEN string `bson:"customer_name_en" json:"customer_name_en"` // 客户英文名
@@ -52,10 +53,21 @@ type Policy struct {
 	ExpectedFee    float64    `bson:"expected_fee" json:"expected_fee"`         // 预计转介费
 	PaymentPayDate *time.Time `bson:"payment_pay_date" json:"payment_pay_date"` // 支付日期
 
+	// 转介费规则
+	FeeRuleID          string `bson:"fee_rule_id" json:"fee_rule_id"`                   // 命中的转介费规则ID，为空表示未命中规则
+	ManualReferralRate bool   `bson:"manual_referral_rate" json:"manual_referral_rate"` // 转介费率是否为手工填写（与规则计算值不同）
+	ManualExpectedFee  bool   `bson:"manual_expected_fee" json:"manual_expected_fee"`   // 预计转介费是否为手工填写（与规则计算值不同）
+
 	// 产品信息
-	InsuranceCompany string `bson:"insurance_company" json:"insurance_company"` // 承保公司
-	ProductName      string `bson:"product_name" json:"product_name"`           // 保险产品名称
-	ProductType      string `bson:"product_type" json:"product_type"`           // 产品类型
+	ProductID        string `bson:"product_id" json:"product_id"`               // 产品目录中的产品ID，为空表示未关联产品目录
+	InsurerID        string `bson:"insurer_id" json:"insurer_id"`               // 产品目录中的承保公司ID
+	InsuranceCompany string `bson:"insurance_company" json:"insurance_company"` // 承保公司（关联产品时冗余自产品目录）
+	ProductName      string `bson:"product_name" json:"product_name"`           // 保险产品名称（关联产品时冗余自产品目录）
+	ProductType      string `bson:"product_type" json:"product_type"`           // 产品类型（关联产品时冗余自产品目录）
+
+	// 自定义字段
+	CustomFields  map[string]interface{} `bson:"custom_fields,omitempty" json:"custom_fields,omitempty"`   // 公司自定义字段（字段名 -> 值），由 policies 表结构的字段定义约束
+	SchemaVersion int                    `bson:"schema_version,omitempty" json:"schema_version,omitempty"` // 写入自定义字段时 policies 表结构的版本号
 
 	// 其他信息
 	Remark    string `bson:"remark" json:"remark"`         // 备注说明
@@ -70,71 +82,77 @@ type Policy struct {
 
 // PolicyCreateRequest 创建保单请求
 type PolicyCreateRequest struct {
-	AccountNumber     string     `json:"account_number" label:"账户号"` // 改为非必填
-	CustomerNumber    string     `json:"customer_number" binding:"required" label:"客户号"`
-	CustomerNameCN    string     `json:"customer_name_cn" binding:"required" label:"客户中文名"`
-	CustomerNameEN    string     `json:"customer_name_en" label:"客户英文名"`
-	ProposalNumber    string     `json:"proposal_number" binding:"required" label:"投保单号"`
-	PolicyCurrency    string     `json:"policy_currency" binding:"required,oneof=USD HKD CNY" label:"保单币种"`
-	Partner           string     `json:"partner" label:"合作伙伴"`
-	ReferralCode      string     `json:"referral_code" label:"转介编号"`
-	HKManager         string     `json:"hk_manager" label:"港分客户经理"`
-	ReferralPM        string     `json:"referral_pm" label:"转介理财经理"`
-	ReferralBranch    string     `json:"referral_branch" label:"转介分行"`
-	ReferralSubBranch string     `json:"referral_sub_branch" label:"转介支行"`
-	ReferralDate      *time.Time `json:"referral_date" label:"转介日期"`
-	IsSurrendered     bool       `json:"is_surrendered" label:"签单后是否退保"`
-	PaymentDate       *time.Time `json:"payment_date" label:"缴费日期"`
-	EffectiveDate     *time.Time `json:"effective_date" label:"生效日期"`
-	PaymentMethod     string     `json:"payment_method" binding:"omitempty,oneof=期缴 趸缴 预缴" label:"缴费方式"`
-	PaymentYears      int        `json:"payment_years" label:"缴费年期"`
-	PaymentPeriods    int        `json:"payment_periods" label:"期缴期数"`
-	ActualPremium     float64    `json:"actual_premium" binding:"min=0" label:"实际缴纳保费"`
-	AUM               float64    `json:"aum" binding:"min=0" label:"AUM"`
-	PastCoolingPeriod bool       `json:"past_cooling_period" label:"是否已过冷静期"`
-	IsPaidCommission  bool       `json:"is_paid_commission" label:"是否支付佣金"`
-	IsEmployee        bool       `json:"is_employee" label:"是否员工"`
-	ReferralRate      float64    `json:"referral_rate" binding:"min=0,max=100" label:"转介费率"`
-	ExchangeRate      float64    `json:"exchange_rate" binding:"min=0" label:"汇率"` // 汇率字段，保留4位小数
-	ExpectedFee       float64    `json:"expected_fee" binding:"min=0" label:"预计转介费"`
-	PaymentPayDate    *time.Time `json:"payment_pay_date" label:"支付日期"`
-	InsuranceCompany  string     `json:"insurance_company" binding:"required" label:"承保公司"`
-	ProductName       string     `json:"product_name" binding:"required" label:"保险产品名称"`
-	ProductType       string     `json:"product_type" binding:"required" label:"产品类型"`
-	Remark            string     `json:"remark" label:"备注说明"`
+	AccountNumber     string                 `json:"account_number" rule:"max=50" label:"账户号"` // 改为非必填
+	CustomerNumber    string                 `json:"customer_number" rule:"required,max=50" label:"客户号"`
+	CustomerNameCN    string                 `json:"customer_name_cn" rule:"required,max=100" label:"客户中文名"`
+	CustomerNameEN    string                 `json:"customer_name_en" rule:"max=100" label:"客户英文名"`
+	ProposalNumber    string                 `json:"proposal_number" rule:"required,max=50" label:"投保单号"`
+	PolicyCurrency    string                 `json:"policy_currency" rule:"required,oneof=USD HKD CNY" label:"保单币种"`
+	Partner           string                 `json:"partner" label:"合作伙伴"`
+	ReferralCode      string                 `json:"referral_code" label:"转介编号"`
+	HKManager         string                 `json:"hk_manager" label:"港分客户经理"`
+	ReferralPM        string                 `json:"referral_pm" label:"转介理财经理"`
+	ReferralBranch    string                 `json:"referral_branch" label:"转介分行"`
+	ReferralSubBranch string                 `json:"referral_sub_branch" label:"转介支行"`
+	ReferralDate      *time.Time             `json:"referral_date" label:"转介日期"`
+	IsSurrendered     bool                   `json:"is_surrendered" label:"签单后是否退保"`
+	PaymentDate       *time.Time             `json:"payment_date" label:"缴费日期"`
+	EffectiveDate     *time.Time             `json:"effective_date" label:"生效日期"`
+	PaymentMethod     string                 `json:"payment_method" rule:"oneof=期缴 趸缴 预缴" label:"缴费方式"`
+	PaymentYears      int                    `json:"payment_years" rule:"required_if=payment_method 期缴" label:"缴费年期"`
+	PaymentPeriods    int                    `json:"payment_periods" label:"期缴期数"`
+	ActualPremium     float64                `json:"actual_premium" rule:"min=0" label:"实际缴纳保费"`
+	AUM               float64                `json:"aum" rule:"min=0" label:"AUM"`
+	PastCoolingPeriod bool                   `json:"past_cooling_period" label:"是否已过冷静期"`
+	IsPaidCommission  bool                   `json:"is_paid_commission" label:"是否支付佣金"`
+	IsEmployee        bool                   `json:"is_employee" label:"是否员工"`
+	ReferralRate      float64                `json:"referral_rate" rule:"min=0,max=100" label:"转介费率"`
+	ExchangeRate      float64                `json:"exchange_rate" rule:"min=0" label:"汇率"` // 汇率字段，保留4位小数
+	ExpectedFee       float64                `json:"expected_fee" rule:"min=0" label:"预计转介费"`
+	ManualRate        bool                   `json:"manual_referral_rate" label:"手工填写转介费率"` // 为true时以填写的转介费率为准，否则按规则计算
+	ManualFee         bool                   `json:"manual_expected_fee" label:"手工填写预计转介费"` // 为true时以填写的预计转介费为准（可为0），否则按规则计算
+	PaymentPayDate    *time.Time             `json:"payment_pay_date" label:"支付日期"`
+	ProductID         string                 `json:"product_id" label:"产品"` // 关联产品目录时承保公司、产品名称及类型以产品为准
+	InsuranceCompany  string                 `json:"insurance_company" rule:"required_without=product_id,max=100" label:"承保公司"`
+	ProductName       string                 `json:"product_name" rule:"required_without=product_id,max=200" label:"保险产品名称"`
+	ProductType       string                 `json:"product_type" rule:"required_without=product_id,max=100" label:"产品类型"`
+	Remark            string                 `json:"remark" label:"备注说明"`
+	CustomFields      map[string]interface{} `json:"custom_fields" label:"自定义字段"` // 字段名 -> 值，按字段定义校验
 }
 
 // PolicyUpdateRequest 更新保单请求
 type PolicyUpdateRequest struct {
-	CustomerNameCN    string     `json:"customer_name_cn" label:"客户中文名"`
-	CustomerNameEN    string     `json:"customer_name_en" label:"客户英文名"`
-	PolicyCurrency    string     `json:"policy_currency" binding:"omitempty,oneof=USD HKD CNY" label:"保单币种"`
-	Partner           string     `json:"partner" label:"合作伙伴"`
-	ReferralCode      string     `json:"referral_code" label:"转介编号"`
-	HKManager         string     `json:"hk_manager" label:"港分客户经理"`
-	ReferralPM        string     `json:"referral_pm" label:"转介理财经理"`
-	ReferralBranch    string     `json:"referral_branch" label:"转介分行"`
-	ReferralSubBranch string     `json:"referral_sub_branch" label:"转介支行"`
-	ReferralDate      *time.Time `json:"referral_date" label:"转介日期"`
-	IsSurrendered     *bool      `json:"is_surrendered" label:"签单后是否退保"`
-	PaymentDate       *time.Time `json:"payment_date" label:"缴费日期"`
-	EffectiveDate     *time.Time `json:"effective_date" label:"生效日期"`
-	PaymentMethod     string     `json:"payment_method" binding:"omitempty,oneof=期缴 趸缴 预缴" label:"缴费方式"`
-	PaymentYears      *int       `json:"payment_years" label:"缴费年期"`
-	PaymentPeriods    *int       `json:"payment_periods" label:"期缴期数"`
-	ActualPremium     *float64   `json:"actual_premium" binding:"omitempty,min=0" label:"实际缴纳保费"`
-	AUM               *float64   `json:"aum" binding:"omitempty,min=0" label:"AUM"`
-	PastCoolingPeriod *bool      `json:"past_cooling_period" label:"是否已过冷静期"`
-	IsPaidCommission  *bool      `json:"is_paid_commission" label:"是否支付佣金"`
-	IsEmployee        *bool      `json:"is_employee" label:"是否员工"`
-	ReferralRate      *float64   `json:"referral_rate" binding:"omitempty,min=0,max=100" label:"转介费率"`
-	ExchangeRate      *float64   `json:"exchange_rate" binding:"omitempty,min=0" label:"汇率"`
-	ExpectedFee       *float64   `json:"expected_fee" binding:"omitempty,min=0" label:"预计转介费"`
-	PaymentPayDate    *time.Time `json:"payment_pay_date" label:"支付日期"`
-	InsuranceCompany  string     `json:"insurance_company" label:"承保公司"`
-	ProductName       string     `json:"product_name" label:"保险产品名称"`
-	ProductType       string     `json:"product_type" label:"产品类型"`
-	Remark            string     `json:"remark" label:"备注说明"`
+	CustomerNameCN    string                 `json:"customer_name_cn" rule:"max=100" label:"客户中文名"`
+	CustomerNameEN    string                 `json:"customer_name_en" rule:"max=100" label:"客户英文名"`
+	PolicyCurrency    string                 `json:"policy_currency" rule:"oneof=USD HKD CNY" label:"保单币种"`
+	Partner           string                 `json:"partner" label:"合作伙伴"`
+	ReferralCode      string                 `json:"referral_code" label:"转介编号"`
+	HKManager         string                 `json:"hk_manager" label:"港分客户经理"`
+	ReferralPM        string                 `json:"referral_pm" label:"转介理财经理"`
+	ReferralBranch    string                 `json:"referral_branch" label:"转介分行"`
+	ReferralSubBranch string                 `json:"referral_sub_branch" label:"转介支行"`
+	ReferralDate      *time.Time             `json:"referral_date" label:"转介日期"`
+	IsSurrendered     *bool                  `json:"is_surrendered" label:"签单后是否退保"`
+	PaymentDate       *time.Time             `json:"payment_date" label:"缴费日期"`
+	EffectiveDate     *time.Time             `json:"effective_date" label:"生效日期"`
+	PaymentMethod     string                 `json:"payment_method" rule:"oneof=期缴 趸缴 预缴" label:"缴费方式"`
+	PaymentYears      *int                   `json:"payment_years" rule:"required_if=payment_method 期缴" label:"缴费年期"`
+	PaymentPeriods    *int                   `json:"payment_periods" label:"期缴期数"`
+	ActualPremium     *float64               `json:"actual_premium" rule:"min=0" label:"实际缴纳保费"`
+	AUM               *float64               `json:"aum" rule:"min=0" label:"AUM"`
+	PastCoolingPeriod *bool                  `json:"past_cooling_period" label:"是否已过冷静期"`
+	IsPaidCommission  *bool                  `json:"is_paid_commission" label:"是否支付佣金"`
+	IsEmployee        *bool                  `json:"is_employee" label:"是否员工"`
+	ReferralRate      *float64               `json:"referral_rate" rule:"min=0,max=100" label:"转介费率"`
+	ExchangeRate      *float64               `json:"exchange_rate" rule:"min=0" label:"汇率"`
+	ExpectedFee       *float64               `json:"expected_fee" rule:"min=0" label:"预计转介费"`
+	PaymentPayDate    *time.Time             `json:"payment_pay_date" label:"支付日期"`
+	ProductID         string                 `json:"product_id" label:"产品"`
+	InsuranceCompany  string                 `json:"insurance_company" rule:"max=100" label:"承保公司"`
+	ProductName       string                 `json:"product_name" rule:"max=200" label:"保险产品名称"`
+	ProductType       string                 `json:"product_type" rule:"max=100" label:"产品类型"`
+	Remark            string                 `json:"remark" label:"备注说明"`
+	CustomFields      map[string]interface{} `json:"custom_fields" label:"自定义字段"` // 仅更新传入的字段，值为空表示清除
 }
 
 // PolicyQueryRequest 查询保单请求
@@ -142,6 +160,7 @@ type PolicyQueryRequest struct {
 	Page               int        `form:"page" binding:"min=1" label:"页码"`
 	PageSize           int        `form:"page_size" binding:"min=1,max=100" label:"每页数量"`
 	AccountNumber      string     `form:"account_number" label:"账户号"`
+	CustomerID         string     `form:"customer_id" label:"客户"`
 	CustomerNumber     string     `form:"customer_number" label:"客户号"`
 	CustomerNameCN     string     `form:"customer_name_cn" label:"客户中文名"`
 	CustomerNameEN     string     `form:"customer_name_en" label:"客户英文名"`
@@ -154,6 +173,7 @@ type PolicyQueryRequest struct {
 	ReferralBranch     string     `form:"referral_branch" label:"转介分行"`
 	ReferralSubBranch  string     `form:"referral_sub_branch" label:"转介支行"`
 	PaymentMethod      string     `form:"payment_method" binding:"omitempty,oneof=期缴 趸缴 预缴" label:"缴费方式"`
+	ProductID          string     `form:"product_id" label:"产品"`
 	InsuranceCompany   string     `form:"insurance_company" label:"承保公司"`
 	ProductName        string     `form:"product_name" label:"保险产品名称"`
 	ProductType        string     `form:"product_type" label:"产品类型"`
@@ -169,11 +189,19 @@ type PolicyQueryRequest struct {
 	EffectiveDateEnd   *time.Time `form:"effective_date_end" label:"生效日期结束"`
 	SortBy             string     `form:"sort_by" label:"排序字段"`
 	SortOrder          string     `form:"sort_order" binding:"omitempty,oneof=asc desc" label:"排序方向"`
+
+	// 自定义字段筛选，查询参数形如 custom_fields[channel]=银行
+	CustomFields       map[string]string      `form:"-" label:"自定义字段"`
+	CustomFieldFilters map[string]interface{} `form:"-"` // 由服务层按字段定义转换后的查询条件（字段名 -> 条件）
 }
 
 // PolicyResponse 保单响应
 type PolicyResponse struct {
 	*Policy
+	Warnings        []string `json:"warnings,omitempty"` // 字典校验提示（warn模式下返回）
+	AttachmentCount int64    `json:"attachment_count"`   // 附件数量（列表及详情返回）
+
+	MaskedFields []string `json:"masked_fields,omitempty"` // 按查看人权限脱敏的字段
 }
 
 // PolicyListResponse 保单列表响应
@@ -226,11 +254,12 @@ type PolicyImportFileRequest struct {
 
 // PolicyImportResponse 保单导入响应
 type PolicyImportResponse struct {
-	SuccessCount int                   `json:"success_count"` // 成功导入数量
-	ErrorCount   int                   `json:"error_count"`   // 错误数量
-	TotalCount   int                   `json:"total_count"`   // 总数量
-	Errors       []PolicyImportError   `json:"errors"`        // 错误详情
-	Preview      []PolicyCreateRequest `json:"preview"`       // 预览数据（仅预览时返回）
+	SuccessCount int                   `json:"success_count"`      // 成功导入数量
+	ErrorCount   int                   `json:"error_count"`        // 错误数量
+	TotalCount   int                   `json:"total_count"`        // 总数量
+	Errors       []PolicyImportError   `json:"errors"`             // 错误详情
+	Warnings     []PolicyImportError   `json:"warnings,omitempty"` // 提示信息（字典校验warn模式）
+	Preview      []PolicyCreateRequest `json:"preview"`            // 预览数据（仅预览时返回）
 }
 
 // PolicyImportError 保单导入错误
@@ -239,3 +268,33 @@ type PolicyImportError struct {
 	Errors []string `json:"errors"` // 错误信息列表
 	Data   any      `json:"data"`   // 错误数据
 }
+
+// PolicyDictionaryFields 受系统配置字典约束的保单字段（配置类型 -> 保单字段）
+var PolicyDictionaryFields = map[string]string{
+	"hk_manager":      "hk_manager",
+	"referral_branch": "referral_branch",
+	"partner":         "partner",
+}
+
+// PolicyDictionaryRemapRequest 保单字典值批量重映射请求
+type PolicyDictionaryRemapRequest struct {
+	ConfigType string            `json:"config_type" binding:"required,oneof=hk_manager referral_branch partner" label:"配置类型"`
+	Mappings   map[string]string `json:"mappings" label:"手工映射"` // 旧值 -> 配置键，优先于自动解析
+	DryRun     bool              `json:"dry_run" label:"仅预览"`
+}
+
+// PolicyDictionaryRemapItem 字典值重映射明细
+type PolicyDictionaryRemapItem struct {
+	FromValue string `json:"from_value"` // 原值
+	ToValue   string `json:"to_value"`   // 目标配置键，未解析时为空
+	Count     int64  `json:"count"`      // 涉及保单数量
+}
+
+// PolicyDictionaryRemapResponse 保单字典值批量重映射响应
+type PolicyDictionaryRemapResponse struct {
+	ConfigType   string                      `json:"config_type"`   // 配置类型
+	DryRun       bool                        `json:"dry_run"`       // 是否仅预览
+	Remapped     []PolicyDictionaryRemapItem `json:"remapped"`      // 已（将）重映射的值
+	Unresolved   []PolicyDictionaryRemapItem `json:"unresolved"`    // 无法解析的值
+	UpdatedCount int64                       `json:"updated_count"` // 实际更新的保单数量
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PolicyAttachmentTableName 保单附件在变更记录中使用的表名
+const PolicyAttachmentTableName = "policy_attachments"
+
+// 附件类别
+const (
+	AttachmentCategoryApplicationForm = "application_form" // 投保单
+	AttachmentCategoryIDCopy          = "id_copy"          // 证件复印件
+	AttachmentCategoryReceipt         = "receipt"          // 缴费凭证
+	AttachmentCategoryOther           = "other"            // 其他
+)
+
+// AttachmentCategoryLabels 附件类别显示名称
+var AttachmentCategoryLabels = map[string]string{
+	AttachmentCategoryApplicationForm: "投保单",
+	AttachmentCategoryIDCopy:          "证件复印件",
+	AttachmentCategoryReceipt:         "缴费凭证",
+	AttachmentCategoryOther:           "其他",
+}
+
+// PolicyAttachment 保单附件元数据，文件内容保存在文件存储中
+type PolicyAttachment struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`            // MongoDB主键ID
+	AttachmentID string             `bson:"attachment_id" json:"attachment_id"` // 附件唯一标识，业务主键
+	PolicyID     string             `bson:"policy_id" json:"policy_id"`         // 所属保单ID
+	CompanyID    string             `bson:"company_id" json:"company_id"`       // 所属公司ID（多租户隔离）
+	Category     string             `bson:"category" json:"category"`           // 附件类别：application_form/id_copy/receipt/other
+	FileName     string             `bson:"file_name" json:"file_name"`         // 原始文件名
+	Extension    string             `bson:"extension" json:"extension"`         // 文件扩展名（小写，不含点）
+	ContentType  string             `bson:"content_type" json:"content_type"`   // 按文件内容识别的MIME类型
+	Size         int64              `bson:"size" json:"size"`                   // 文件大小（字节）
+	Checksum     string             `bson:"checksum" json:"checksum"`           // 文件内容SHA-256校验值（十六进制）
+	StorageKey   string             `bson:"storage_key" json:"-"`               // 文件存储路径
+	Remark       string             `bson:"remark" json:"remark"`               // 备注
+	UploadedBy   string             `bson:"uploaded_by" json:"uploaded_by"`     // 上传人ID
+	UploaderName string             `bson:"uploader_name" json:"uploader_name"` // 上传人用户名
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`       // 上传时间
+}
+
+// PolicyAttachmentUploadRequest 上传保单附件请求（multipart/form-data，文件字段为 file）
+type PolicyAttachmentUploadRequest struct {
+	Category string `form:"category" binding:"required,oneof=application_form id_copy receipt other" label:"附件类别"`
+	Remark   string `form:"remark" binding:"max=200" label:"备注"`
+}
+
+// PolicyAttachmentFieldLabels 保单附件字段显示名称，用于变更记录
+var PolicyAttachmentFieldLabels = map[string]string{
+	"policy_id":     "保单",
+	"category":      "附件类别",
+	"file_name":     "文件名",
+	"extension":     "扩展名",
+	"content_type":  "文件类型",
+	"size":          "文件大小",
+	"checksum":      "校验值",
+	"remark":        "备注",
+	"uploaded_by":   "上传人",
+	"uploader_name": "上传人用户名",
+}
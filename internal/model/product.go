@@ -0,0 +1,142 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Insurer 承保公司表模型
+type Insurer struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`          // MongoDB主键ID
+	InsurerID   string             `bson:"insurer_id" json:"insurer_id"`     // 承保公司唯一标识，业务主键
+	InsurerCode string             `bson:"insurer_code" json:"insurer_code"` // 承保公司编码
+	InsurerName string             `bson:"insurer_name" json:"insurer_name"` // 承保公司名称
+	NameEN      string             `bson:"name_en" json:"name_en"`           // 英文名称
+	CompanyID   string             `bson:"company_id" json:"company_id"`     // 所属公司ID（多租户隔离）
+	Status      string             `bson:"status" json:"status"`             // 状态：enable/disable
+	Remark      string             `bson:"remark" json:"remark"`             // 备注
+	CreatedBy   string             `bson:"created_by" json:"created_by"`     // 创建人
+	UpdatedBy   string             `bson:"updated_by" json:"updated_by"`     // 更新人
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`     // 创建时间
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`     // 更新时间
+}
+
+// Product 保险产品表模型
+type Product struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`          // MongoDB主键ID
+	ProductID   string             `bson:"product_id" json:"product_id"`     // 产品唯一标识，业务主键
+	ProductCode string             `bson:"product_code" json:"product_code"` // 产品编码
+	ProductName string             `bson:"product_name" json:"product_name"` // 产品名称
+	ProductType string             `bson:"product_type" json:"product_type"` // 产品类型
+	InsurerID   string             `bson:"insurer_id" json:"insurer_id"`     // 承保公司ID
+	InsurerName string             `bson:"insurer_name" json:"insurer_name"` // 承保公司名称（冗余）
+
+	// 产品规则
+	Currencies          []string   `bson:"currencies" json:"currencies"`                       // 可选币种，为空表示不限
+	PaymentMethods      []string   `bson:"payment_methods" json:"payment_methods"`             // 允许的缴费方式，为空表示不限
+	PaymentYears        []int      `bson:"payment_years" json:"payment_years"`                 // 允许的缴费年期，为空表示不限
+	DefaultReferralRate float64    `bson:"default_referral_rate" json:"default_referral_rate"` // 默认转介费率
+	CoolingOffDays      int        `bson:"cooling_off_days" json:"cooling_off_days"`           // 冷静期天数
+	EffectiveFrom       *time.Time `bson:"effective_from" json:"effective_from"`               // 上架日期
+	EffectiveTo         *time.Time `bson:"effective_to" json:"effective_to"`                   // 下架日期
+
+	CompanyID string    `bson:"company_id" json:"company_id"` // 所属公司ID（多租户隔离）
+	Status    string    `bson:"status" json:"status"`         // 状态：enable/disable
+	Remark    string    `bson:"remark" json:"remark"`         // 备注
+	CreatedBy string    `bson:"created_by" json:"created_by"` // 创建人
+	UpdatedBy string    `bson:"updated_by" json:"updated_by"` // 更新人
+	CreatedAt time.Time `bson:"created_at" json:"created_at"` // 创建时间
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"` // 更新时间
+}
+
+// InsurerCreateRequest 创建承保公司请求
+type InsurerCreateRequest struct {
+	InsurerCode string `json:"insurer_code" label:"承保公司编码"`
+	InsurerName string `json:"insurer_name" binding:"required,max=100" label:"承保公司名称"`
+	NameEN      string `json:"name_en" label:"英文名称"`
+	Status      string `json:"status" binding:"omitempty,oneof=enable disable" label:"状态"`
+	Remark      string `json:"remark" label:"备注"`
+}
+
+// InsurerUpdateRequest 更新承保公司请求
+type InsurerUpdateRequest struct {
+	InsurerCode string `json:"insurer_code" label:"承保公司编码"`
+	InsurerName string `json:"insurer_name" binding:"omitempty,max=100" label:"承保公司名称"`
+	NameEN      string `json:"name_en" label:"英文名称"`
+	Status      string `json:"status" binding:"omitempty,oneof=enable disable" label:"状态"`
+	Remark      string `json:"remark" label:"备注"`
+}
+
+// ProductCreateRequest 创建保险产品请求
+type ProductCreateRequest struct {
+	ProductCode         string     `json:"product_code" label:"产品编码"`
+	ProductName         string     `json:"product_name" binding:"required,max=100" label:"产品名称"`
+	ProductType         string     `json:"product_type" binding:"required" label:"产品类型"`
+	InsurerID           string     `json:"insurer_id" binding:"required" label:"承保公司"`
+	Currencies          []string   `json:"currencies" binding:"omitempty,dive,oneof=USD HKD CNY" label:"可选币种"`
+	PaymentMethods      []string   `json:"payment_methods" binding:"omitempty,dive,oneof=期缴 趸缴 预缴" label:"缴费方式"`
+	PaymentYears        []int      `json:"payment_years" binding:"omitempty,dive,min=1" label:"缴费年期"`
+	DefaultReferralRate float64    `json:"default_referral_rate" binding:"min=0,max=100" label:"默认转介费率"`
+	CoolingOffDays      int        `json:"cooling_off_days" binding:"min=0" label:"冷静期天数"`
+	EffectiveFrom       *time.Time `json:"effective_from" label:"上架日期"`
+	EffectiveTo         *time.Time `json:"effective_to" label:"下架日期"`
+	Status              string     `json:"status" binding:"omitempty,oneof=enable disable" label:"状态"`
+	Remark              string     `json:"remark" label:"备注"`
+}
+
+// ProductUpdateRequest 更新保险产品请求
+type ProductUpdateRequest struct {
+	ProductCode         string     `json:"product_code" label:"产品编码"`
+	ProductName         string     `json:"product_name" binding:"omitempty,max=100" label:"产品名称"`
+	ProductType         string     `json:"product_type" label:"产品类型"`
+	InsurerID           string     `json:"insurer_id" label:"承保公司"`
+	Currencies          []string   `json:"currencies" binding:"omitempty,dive,oneof=USD HKD CNY" label:"可选币种"`
+	PaymentMethods      []string   `json:"payment_methods" binding:"omitempty,dive,oneof=期缴 趸缴 预缴" label:"缴费方式"`
+	PaymentYears        []int      `json:"payment_years" binding:"omitempty,dive,min=1" label:"缴费年期"`
+	DefaultReferralRate *float64   `json:"default_referral_rate" binding:"omitempty,min=0,max=100" label:"默认转介费率"`
+	CoolingOffDays      *int       `json:"cooling_off_days" binding:"omitempty,min=0" label:"冷静期天数"`
+	EffectiveFrom       *time.Time `json:"effective_from" label:"上架日期"`
+	EffectiveTo         *time.Time `json:"effective_to" label:"下架日期"`
+	Status              string     `json:"status" binding:"omitempty,oneof=enable disable" label:"状态"`
+	Remark              string     `json:"remark" label:"备注"`
+}
+
+// ProductQueryRequest 查询保险产品请求
+type ProductQueryRequest struct {
+	Page        int    `form:"page" label:"页码"`
+	PageSize    int    `form:"page_size" label:"每页数量"`
+	InsurerID   string `form:"insurer_id" label:"承保公司"`
+	ProductType string `form:"product_type" label:"产品类型"`
+	Status      string `form:"status" label:"状态"`
+	Keyword     string `form:"keyword" label:"关键词"`
+}
+
+// ProductListResponse 保险产品列表响应
+type ProductListResponse struct {
+	List     []Product `json:"list"`      // 产品列表
+	Total    int64     `json:"total"`     // 总数
+	Page     int       `json:"page"`      // 当前页
+	PageSize int       `json:"page_size"` // 每页数量
+}
+
+// ProductImportFileRequest 保险产品文件导入请求
+type ProductImportFileRequest struct {
+	SkipHeader     bool `form:"skip_header"`     // 是否跳过表头行
+	UpdateExisting bool `form:"update_existing"` // 同一承保公司下产品名称已存在时是否更新
+}
+
+// ProductImportResponse 保险产品导入响应
+type ProductImportResponse struct {
+	SuccessCount int                  `json:"success_count"` // 成功导入数量
+	ErrorCount   int                  `json:"error_count"`   // 错误数量
+	TotalCount   int                  `json:"total_count"`   // 总数量
+	Errors       []ProductImportError `json:"errors"`        // 错误详情
+}
+
+// ProductImportError 保险产品导入错误
+type ProductImportError struct {
+	Row    int      `json:"row"`    // 错误行号
+	Errors []string `json:"errors"` // 错误信息列表
+	Data   any      `json:"data"`   // 错误数据
+}
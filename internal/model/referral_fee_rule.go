@@ -0,0 +1,99 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 转介费分档方式
+const (
+	FeeTierModeFlat        = "flat"        // 全额分档：保费达到某档后全部按该档费率计算
+	FeeTierModeProgressive = "progressive" // 超额累进：各档区间内的保费分别按对应费率计算
+)
+
+// ReferralFeeRule 转介费计算规则表模型
+type ReferralFeeRule struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`      // MongoDB主键ID
+	RuleID    string             `bson:"rule_id" json:"rule_id"`       // 规则唯一标识，业务主键
+	RuleName  string             `bson:"rule_name" json:"rule_name"`   // 规则名称
+	CompanyID string             `bson:"company_id" json:"company_id"` // 所属公司ID（多租户隔离）
+
+	// 匹配条件，为空表示不限
+	Partner       string     `bson:"partner" json:"partner"`               // 合作伙伴（系统配置键）
+	ProductID     string     `bson:"product_id" json:"product_id"`         // 产品ID
+	PaymentMethod string     `bson:"payment_method" json:"payment_method"` // 缴费方式
+	PaymentYears  int        `bson:"payment_years" json:"payment_years"`   // 缴费年期，0表示不限
+	EffectiveFrom *time.Time `bson:"effective_from" json:"effective_from"` // 适用的保单生效日期起
+	EffectiveTo   *time.Time `bson:"effective_to" json:"effective_to"`     // 适用的保单生效日期止
+
+	// 计算方式
+	TierMode string            `bson:"tier_mode" json:"tier_mode"` // 分档方式：flat/progressive
+	Tiers    []ReferralFeeTier `bson:"tiers" json:"tiers"`         // 费率档位，按起始保费升序
+	MaxFee   float64           `bson:"max_fee" json:"max_fee"`     // 单张保单转介费上限，0表示不封顶
+	Priority int               `bson:"priority" json:"priority"`   // 优先级，数值越大越优先
+
+	Status    string    `bson:"status" json:"status"`         // 状态：enable/disable
+	Remark    string    `bson:"remark" json:"remark"`         // 备注
+	CreatedBy string    `bson:"created_by" json:"created_by"` // 创建人
+	UpdatedBy string    `bson:"updated_by" json:"updated_by"` // 更新人
+	CreatedAt time.Time `bson:"created_at" json:"created_at"` // 创建时间
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"` // 更新时间
+}
+
+// ReferralFeeTier 转介费率档位
+type ReferralFeeTier struct {
+	MinPremium float64 `bson:"min_premium" json:"min_premium" binding:"min=0" label:"起始保费"` // 档位起始保费（含）
+	Rate       float64 `bson:"rate" json:"rate" binding:"min=0,max=100" label:"转介费率"`       // 档位转介费率（%）
+}
+
+// ReferralFeeRuleRequest 创建/更新转介费规则请求（更新时整体替换匹配条件与档位）
+type ReferralFeeRuleRequest struct {
+	RuleName      string            `json:"rule_name" binding:"required,max=100" label:"规则名称"`
+	Partner       string            `json:"partner" label:"合作伙伴"`
+	ProductID     string            `json:"product_id" label:"产品"`
+	PaymentMethod string            `json:"payment_method" binding:"omitempty,oneof=期缴 趸缴 预缴" label:"缴费方式"`
+	PaymentYears  int               `json:"payment_years" binding:"min=0" label:"缴费年期"`
+	EffectiveFrom *time.Time        `json:"effective_from" label:"生效日期起"`
+	EffectiveTo   *time.Time        `json:"effective_to" label:"生效日期止"`
+	TierMode      string            `json:"tier_mode" binding:"omitempty,oneof=flat progressive" label:"分档方式"`
+	Tiers         []ReferralFeeTier `json:"tiers" binding:"required,min=1,dive" label:"费率档位"`
+	MaxFee        float64           `json:"max_fee" binding:"min=0" label:"转介费上限"`
+	Priority      int               `json:"priority" label:"优先级"`
+	Status        string            `json:"status" binding:"omitempty,oneof=enable disable" label:"状态"`
+	Remark        string            `json:"remark" label:"备注"`
+}
+
+// ReferralFeeRecalculateRequest 转介费重算请求
+type ReferralFeeRecalculateRequest struct {
+	PolicyIDs          []string   `json:"policy_ids" label:"保单ID数组"` // 指定保单，为空时按筛选条件
+	Partner            string     `json:"partner" label:"合作伙伴"`
+	ProductID          string     `json:"product_id" label:"产品"`
+	PaymentMethod      string     `json:"payment_method" binding:"omitempty,oneof=期缴 趸缴 预缴" label:"缴费方式"`
+	EffectiveDateStart *time.Time `json:"effective_date_start" label:"生效日期开始"`
+	EffectiveDateEnd   *time.Time `json:"effective_date_end" label:"生效日期结束"`
+	OverrideManual     bool       `json:"override_manual" label:"覆盖手工值"` // 是否覆盖手工填写的费率及转介费
+	DryRun             bool       `json:"dry_run" label:"仅预览"`           // 仅返回差异，不写入
+}
+
+// ReferralFeeRecalculateResponse 转介费重算响应
+type ReferralFeeRecalculateResponse struct {
+	TotalCount   int                          `json:"total_count"`   // 参与重算的保单数
+	ChangedCount int                          `json:"changed_count"` // 结果有变化的保单数
+	ManualCount  int                          `json:"manual_count"`  // 因手工填写而保留原值的保单数
+	UpdatedCount int                          `json:"updated_count"` // 实际更新的保单数
+	Items        []ReferralFeeRecalculateItem `json:"items"`         // 变化明细
+}
+
+// ReferralFeeRecalculateItem 转介费重算差异明细
+type ReferralFeeRecalculateItem struct {
+	PolicyID        string  `json:"policy_id"`         // 保单ID
+	ProposalNumber  string  `json:"proposal_number"`   // 投保单号
+	CustomerNameCN  string  `json:"customer_name_cn"`  // 客户中文名
+	OldRuleID       string  `json:"old_rule_id"`       // 原规则ID
+	NewRuleID       string  `json:"new_rule_id"`       // 新规则ID
+	OldReferralRate float64 `json:"old_referral_rate"` // 原转介费率
+	NewReferralRate float64 `json:"new_referral_rate"` // 新转介费率
+	OldExpectedFee  float64 `json:"old_expected_fee"`  // 原预计转介费
+	NewExpectedFee  float64 `json:"new_expected_fee"`  // 新预计转介费
+}
@@ -3,20 +3,24 @@ package model
 // 响应状态码
 const (
 	// 通用状态码
-	CodeSuccess       = 200 // 成功
-	CodeInvalidParams = 400 // 请求参数错误
-	CodeUnauthorized  = 401 // 未授权
-	CodeForbidden     = 403 // 禁止访问
-	CodeNotFound      = 404 // 资源不存在
-	CodeConflict      = 409 // 资源冲突
-	CodeAccountLocked = 423 // 账户被锁定
-	CodeServerError   = 500 // 服务器内部错误
+	CodeSuccess         = 200 // 成功
+	CodeInvalidParams   = 400 // 请求参数错误
+	CodeUnauthorized    = 401 // 未授权
+	CodeForbidden       = 403 // 禁止访问
+	CodeNotFound        = 404 // 资源不存在
+	CodeConflict        = 409 // 资源冲突
+	CodeAccountLocked   = 423 // 账户被锁定
+	CodeTooManyRequests = 429 // 请求过于频繁
+	CodeServerError     = 500 // 服务器内部错误
 
 	// 认证相关错误码
 	CodeAuthFailed      = 1001 // 认证失败
 	CodeTokenInvalid    = 1002 // 令牌无效
 	CodeTokenExpired    = 1003 // 令牌过期
 	CodeAccountDisabled = 1004 // 账户被禁用
+	CodePasswordChange  = 1005 // 须修改密码后才能继续使用
+	CodePasswordPolicy  = 1006 // 密码不符合安全策略
+	CodeIPNotAllowed    = 1007 // 当前网络不在公司IP白名单内
 
 	// 用户相关错误码
 	CodeUserExists    = 2001 // 用户已存在
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 安全事件类型
+const (
+	SecurityEventIPDenied = "ip_denied" // IP不在公司白名单内被拒绝
+	SecurityEventNewLogin = "new_login" // 新IP或新设备登录
+)
+
+// 被拒绝访问的来源
+const (
+	IPAccessSourceLogin   = "login"   // 登录
+	IPAccessSourceRequest = "request" // 已登录后访问接口
+)
+
+// IPAccessAttempt 一次需要按公司IP白名单校验的访问
+type IPAccessAttempt struct {
+	UserID    string
+	Username  string
+	CompanyID string
+	IPAddress string
+	UserAgent string
+	Path      string
+	Source    string // login/request
+}
+
+// SecurityEvent 安全事件。同一用户同一IP的拒绝访问按小时合并为一条，Count 记录次数
+type SecurityEvent struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`              // MongoDB主键ID
+	EventID     string             `bson:"event_id" json:"event_id"`             // 事件唯一标识
+	Type        string             `bson:"type" json:"type"`                     // 事件类型：ip_denied/new_login
+	Source      string             `bson:"source,omitempty" json:"source"`       // 来源：login/request
+	UserID      string             `bson:"user_id" json:"user_id"`               // 用户ID
+	Username    string             `bson:"username" json:"username"`             // 用户名
+	CompanyID   string             `bson:"company_id" json:"company_id"`         // 所属公司ID
+	IPAddress   string             `bson:"ip_address" json:"ip_address"`         // 客户端IP
+	UserAgent   string             `bson:"user_agent" json:"user_agent"`         // 浏览器信息
+	Path        string             `bson:"path,omitempty" json:"path,omitempty"` // 访问的接口
+	Detail      string             `bson:"detail" json:"detail"`                 // 说明
+	Count       int                `bson:"count" json:"count"`                   // 合并的次数
+	DedupKey    string             `bson:"dedup_key" json:"-"`                   // 合并键
+	FirstSeenAt time.Time          `bson:"first_seen_at" json:"first_seen_at"`   // 首次发生时间
+	LastSeenAt  time.Time          `bson:"last_seen_at" json:"last_seen_at"`     // 最近发生时间
+}
+
+// UserLoginLocation 用户登录过的IP和设备，用于识别新IP或新设备登录
+type UserLoginLocation struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      string             `bson:"user_id" json:"user_id"`             // 用户ID
+	CompanyID   string             `bson:"company_id" json:"company_id"`       // 所属公司ID
+	IPAddress   string             `bson:"ip_address" json:"ip_address"`       // 登录IP
+	Device      string             `bson:"device" json:"device"`               // 设备（浏览器及操作系统），由 User-Agent 归纳
+	UserAgent   string             `bson:"user_agent" json:"user_agent"`       // 最近一次登录的 User-Agent
+	LoginCount  int                `bson:"login_count" json:"login_count"`     // 登录次数
+	FirstSeenAt time.Time          `bson:"first_seen_at" json:"first_seen_at"` // 首次登录时间
+	LastSeenAt  time.Time          `bson:"last_seen_at" json:"last_seen_at"`   // 最近登录时间
+}
+
+// CompanyIPAllowlistRequest 设置公司IP白名单请求
+type CompanyIPAllowlistRequest struct {
+	AllowedIPs []string `json:"allowed_ips" label:"IP白名单"` // IP或CIDR，传空数组表示取消限制
+}
+
+// CompanyIPAllowlistResponse 公司IP白名单
+type CompanyIPAllowlistResponse struct {
+	CompanyID  string   `json:"company_id"`  // 公司ID
+	AllowedIPs []string `json:"allowed_ips"` // IP或CIDR，为空表示不限制
+	CurrentIP  string   `json:"current_ip"`  // 当前请求的客户端IP，便于确认白名单是否包含自己
+}
+
+// SecurityEventQueryRequest 查询安全事件请求
+type SecurityEventQueryRequest struct {
+	Page      int    `form:"page" label:"页码"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" label:"每页数量"`
+	Type      string `form:"type" binding:"omitempty,oneof=ip_denied new_login" label:"事件类型"`
+	CompanyID string `form:"company_id" label:"公司ID"`
+	UserID    string `form:"user_id" label:"用户ID"`
+	IPAddress string `form:"ip_address" label:"IP"`
+	StartTime string `form:"start_time" label:"开始时间"` // 格式：2006-01-02
+	EndTime   string `form:"end_time" label:"结束时间"`   // 格式：2006-01-02，包含当天
+}
+
+// SecurityEventListResponse 安全事件列表响应
+type SecurityEventListResponse struct {
+	List     []SecurityEvent `json:"list"`
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+}
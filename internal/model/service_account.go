@@ -0,0 +1,151 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 服务账号状态
+const (
+	ServiceAccountStatusActive   = "active"   // 启用
+	ServiceAccountStatusDisabled = "disabled" // 停用
+)
+
+// 操作人类型，用于活动记录和变更记录区分用户与服务账号
+const (
+	ActorTypeUser           = "user"            // 用户
+	ActorTypeServiceAccount = "service_account" // 服务账号
+)
+
+// APIKeyHeader 服务账号调用接口时携带API密钥的请求头
+const APIKeyHeader = "X-API-Key"
+
+// API密钥可授予的权限范围，取值为对应接口的权限标识
+const (
+	ScopeCustomerCreate = "customer:create" // 推送客户（转介）
+	ScopeCustomerView   = "customer:view"   // 查询客户
+	ScopePolicyCreate   = "policy:create"   // 推送保单
+	ScopePolicyView     = "policy:view"     // 查询保单及状态
+)
+
+// APIKeyScopes 可授予服务账号的权限范围及说明
+var APIKeyScopes = map[string]string{
+	ScopeCustomerCreate: "推送客户",
+	ScopeCustomerView:   "查询客户",
+	ScopePolicyCreate:   "推送保单",
+	ScopePolicyView:     "查询保单及状态",
+}
+
+// ServiceAccount 服务账号，供合作机构系统通过API密钥调用接口，归属于公司且只能访问本公司数据
+type ServiceAccount struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`                      // MongoDB主键ID
+	ServiceAccountID string             `bson:"service_account_id" json:"service_account_id"` // 服务账号唯一标识，业务主键
+	CompanyID        string             `bson:"company_id" json:"company_id"`                 // 所属公司ID
+	Name             string             `bson:"name" json:"name"`                             // 名称，如合作银行名称
+	Description      string             `bson:"description" json:"description"`               // 说明
+	Scopes           []string           `bson:"scopes" json:"scopes"`                         // 权限范围
+	AllowedIPs       []string           `bson:"allowed_ips" json:"allowed_ips"`               // 允许调用的IP或CIDR，为空表示不限制
+	Status           string             `bson:"status" json:"status"`                         // 状态：active/disabled
+	LastUsedAt       *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at"`   // 最后调用时间
+	CreatedBy        string             `bson:"created_by" json:"created_by"`                 // 创建人
+	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`                 // 创建时间
+	UpdatedAt        time.Time          `bson:"updated_at" json:"updated_at"`                 // 更新时间
+	Keys             []APIKey           `bson:"-" json:"keys,omitempty"`                      // API密钥（详情接口返回，不含密钥明文）
+}
+
+// APIKey 服务账号的API密钥，只保存摘要；前缀用于识别和查找密钥
+type APIKey struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`                          // MongoDB主键ID
+	KeyID            string             `bson:"key_id" json:"key_id"`                             // 密钥唯一标识
+	ServiceAccountID string             `bson:"service_account_id" json:"service_account_id"`     // 所属服务账号ID
+	CompanyID        string             `bson:"company_id" json:"company_id"`                     // 所属公司ID
+	Prefix           string             `bson:"prefix" json:"prefix"`                             // 密钥前缀，可公开展示，用于识别密钥
+	KeyHash          string             `bson:"key_hash" json:"-"`                                // 完整密钥的SHA-256摘要
+	ExpiresAt        *time.Time         `bson:"expires_at,omitempty" json:"expires_at"`           // 过期时间，为空表示不过期
+	RevokedAt        *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at"`           // 吊销时间
+	RevokedBy        string             `bson:"revoked_by,omitempty" json:"revoked_by,omitempty"` // 吊销人
+	LastUsedAt       *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at"`       // 最后使用时间
+	LastUsedIP       string             `bson:"last_used_ip,omitempty" json:"last_used_ip"`       // 最后使用IP
+	CreatedBy        string             `bson:"created_by" json:"created_by"`                     // 创建人
+	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`                     // 创建时间
+}
+
+// Active 密钥是否可用（未吊销且未过期）
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || k.ExpiresAt.After(now))
+}
+
+// ServiceIdentity 通过API密钥认证的服务账号身份
+type ServiceIdentity struct {
+	ServiceAccountID string
+	Name             string
+	CompanyID        string
+	Scopes           []string
+	KeyPrefix        string
+}
+
+// HasScope 是否拥有指定权限范围
+func (i *ServiceIdentity) HasScope(scope string) bool {
+	for _, s := range i.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceAccountCreateRequest 创建服务账号请求，创建时同时签发第一个API密钥
+type ServiceAccountCreateRequest struct {
+	Name          string   `json:"name" binding:"required,max=100" label:"名称"`
+	Description   string   `json:"description" binding:"omitempty,max=500" label:"说明"`
+	CompanyID     string   `json:"company_id" label:"公司ID"` // 平台管理员可指定公司，其他用户固定为本公司
+	Scopes        []string `json:"scopes" binding:"required,min=1" label:"权限范围"`
+	AllowedIPs    []string `json:"allowed_ips" label:"IP白名单"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=3650" label:"密钥有效天数"` // 为空表示不过期
+}
+
+// ServiceAccountUpdateRequest 更新服务账号请求
+type ServiceAccountUpdateRequest struct {
+	Name        string   `json:"name" binding:"omitempty,max=100" label:"名称"`
+	Description *string  `json:"description" binding:"omitempty,max=500" label:"说明"`
+	Scopes      []string `json:"scopes" binding:"omitempty,min=1" label:"权限范围"`
+	AllowedIPs  []string `json:"allowed_ips" label:"IP白名单"` // 传空数组表示取消限制，不传表示不修改
+	Status      string   `json:"status" binding:"omitempty,oneof=active disabled" label:"状态"`
+}
+
+// APIKeyCreateRequest 签发（轮换）API密钥请求
+type APIKeyCreateRequest struct {
+	ExpiresInDays    int  `json:"expires_in_days" binding:"omitempty,min=1,max=3650" label:"密钥有效天数"` // 为空表示不过期
+	GracePeriodHours int  `json:"grace_period_hours" binding:"omitempty,min=0,max=720" label:"旧密钥保留小时数"`
+	RevokeExisting   bool `json:"revoke_existing" label:"是否使旧密钥失效"` // 为true时现有密钥在保留时间后失效，用于轮换
+}
+
+// APIKeyCreateResponse 签发API密钥响应，密钥明文只在此返回一次
+type APIKeyCreateResponse struct {
+	Key    string  `json:"key"`     // 密钥明文，请妥善保存
+	APIKey *APIKey `json:"api_key"` // 密钥信息
+}
+
+// ServiceAccountCreateResponse 创建服务账号响应
+type ServiceAccountCreateResponse struct {
+	ServiceAccount *ServiceAccount `json:"service_account"`
+	Key            string          `json:"key"` // 密钥明文，请妥善保存
+}
+
+// ServiceAccountQueryRequest 查询服务账号请求
+type ServiceAccountQueryRequest struct {
+	Page      int    `form:"page" label:"页码"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" label:"每页数量"`
+	CompanyID string `form:"company_id" label:"公司ID"`
+	Status    string `form:"status" binding:"omitempty,oneof=active disabled" label:"状态"`
+	Name      string `form:"name" label:"名称"`
+}
+
+// ServiceAccountListResponse 服务账号列表响应
+type ServiceAccountListResponse struct {
+	List     []ServiceAccount `json:"list"`
+	Total    int64            `json:"total"`
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
+}
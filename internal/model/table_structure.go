@@ -0,0 +1,220 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 表类型
+const (
+	TableTypeSystem = "system" // 系统表
+	TableTypeCustom = "custom" // 自定义表
+)
+
+// 表状态
+const (
+	TableStatusActive   = "active"   // 启用
+	TableStatusInactive = "inactive" // 禁用
+)
+
+// 字段类型
+const (
+	FieldTypeString  = "string"  // 文本
+	FieldTypeNumber  = "number"  // 数字
+	FieldTypeDate    = "date"    // 日期
+	FieldTypeBoolean = "boolean" // 布尔
+	FieldTypeEnum    = "enum"    // 枚举
+	FieldTypeFile    = "file"    // 文件
+)
+
+// CustomFieldsKey 动态字段数据在记录中的存放位置，如 policies 集合中的 custom_fields.<field_name>
+const CustomFieldsKey = "custom_fields"
+
+// PolicyTableName 保单自定义字段所绑定的表结构名称（与保单集合同名）
+const PolicyTableName = "policies"
+
+// 字段验证规则（FieldDefinition.ValidationRules 的键）
+const (
+	RuleMinLength = "min_length" // 最小长度（string）
+	RuleMaxLength = "max_length" // 最大长度（string）
+	RulePattern   = "pattern"    // 正则表达式（string）
+	RuleMin       = "min"        // 最小值（number）
+	RuleMax       = "max"        // 最大值（number）
+	RuleMinDate   = "min_date"   // 最早日期 yyyy-mm-dd（date）
+	RuleMaxDate   = "max_date"   // 最晚日期 yyyy-mm-dd（date）
+
+	// 条件必填（所有类型）：{"field": "payment_method", "values": ["期缴"]}，
+	// field 为记录的固定字段名，引用其他自定义字段时写作 custom_fields.<field_name>
+	RuleRequiredIf = "required_if"
+)
+
+// TableStructureCreateRequest 创建表结构请求
+type TableStructureCreateRequest struct {
+	TableName   string `json:"table_name" binding:"required,max=50" label:"表名"`
+	DisplayName string `json:"display_name" binding:"required,max=100" label:"显示名称"`
+	TableType   string `json:"table_type" binding:"omitempty,oneof=system custom" label:"表类型"`
+	Description string `json:"description" label:"表描述"`
+	Status      string `json:"status" binding:"omitempty,oneof=active inactive" label:"状态"`
+}
+
+// TableStructureUpdateRequest 更新表结构请求（表名创建后不可修改）
+type TableStructureUpdateRequest struct {
+	DisplayName string `json:"display_name" binding:"omitempty,max=100" label:"显示名称"`
+	Description string `json:"description" label:"表描述"`
+	Status      string `json:"status" binding:"omitempty,oneof=active inactive" label:"状态"`
+}
+
+// TableStructureQueryRequest 查询表结构请求
+type TableStructureQueryRequest struct {
+	TableType string `form:"table_type" binding:"omitempty,oneof=system custom" label:"表类型"`
+	Status    string `form:"status" binding:"omitempty,oneof=active inactive" label:"状态"`
+	Keyword   string `form:"keyword" label:"关键词"`
+}
+
+// TableStructureResponse 表结构响应（含字段定义）
+type TableStructureResponse struct {
+	TableStructure
+	Fields []FieldDefinition `json:"fields"` // 字段定义，按排序号升序
+}
+
+// FieldDefinitionCreateRequest 创建字段定义请求
+type FieldDefinitionCreateRequest struct {
+	FieldName       string                 `json:"field_name" binding:"required,max=50" label:"字段名"`
+	DisplayName     string                 `json:"display_name" binding:"required,max=100" label:"显示名称"`
+	FieldType       string                 `json:"field_type" binding:"required,oneof=string number date boolean enum file" label:"字段类型"`
+	FieldLength     int                    `json:"field_length" binding:"min=0" label:"字段长度"`
+	Required        bool                   `json:"required" label:"是否必填"`
+	DefaultValue    string                 `json:"default_value" label:"默认值"`
+	EnumOptions     []string               `json:"enum_options" label:"枚举选项"`
+	ValidationRules map[string]interface{} `json:"validation_rules" label:"验证规则"`
+	SortOrder       int                    `json:"sort_order" label:"排序号"` // 为0时排在最后
+	Visible         *bool                  `json:"visible" label:"是否显示"`   // 未传时默认显示
+}
+
+// FieldDefinitionUpdateRequest 更新字段定义请求（字段名和字段类型通过字段迁移修改）
+type FieldDefinitionUpdateRequest struct {
+	DisplayName     string                 `json:"display_name" binding:"omitempty,max=100" label:"显示名称"`
+	FieldLength     *int                   `json:"field_length" binding:"omitempty,min=0" label:"字段长度"`
+	Required        *bool                  `json:"required" label:"是否必填"`
+	DefaultValue    *string                `json:"default_value" label:"默认值"`
+	EnumOptions     []string               `json:"enum_options" label:"枚举选项"`
+	ValidationRules map[string]interface{} `json:"validation_rules" label:"验证规则"`
+	SortOrder       *int                   `json:"sort_order" label:"排序号"`
+	Visible         *bool                  `json:"visible" label:"是否显示"`
+}
+
+// FieldSortRequest 字段排序请求
+type FieldSortRequest struct {
+	FieldIDs []string `json:"field_ids" binding:"required,min=1" label:"字段ID数组"` // 按新顺序排列的字段ID
+}
+
+// FieldVisibilityRequest 字段显示设置请求
+type FieldVisibilityRequest struct {
+	Visible *bool `json:"visible" binding:"required" label:"是否显示"`
+}
+
+// TableStructureVersion 表结构版本（字段定义快照），发布后不可修改
+type TableStructureVersion struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`      // MongoDB主键ID
+	VersionID string             `bson:"version_id" json:"version_id"` // 版本唯一标识，业务主键
+	TableID   string             `bson:"table_id" json:"table_id"`     // 所属表结构ID
+	CompanyID string             `bson:"company_id" json:"company_id"` // 所属公司ID，空表示平台级表
+	Version   int                `bson:"version" json:"version"`       // 版本号，从1开始递增
+	Fields    []FieldDefinition  `bson:"fields" json:"fields"`         // 发布时的字段定义，按排序号升序
+	Remark    string             `bson:"remark" json:"remark"`         // 变更说明
+	CreatedBy string             `bson:"created_by" json:"created_by"` // 发布人
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"` // 发布时间
+}
+
+// TableStructureVersionSummary 表结构版本摘要（列表展示，不含字段快照）
+type TableStructureVersionSummary struct {
+	VersionID  string    `json:"version_id"`  // 版本ID
+	Version    int       `json:"version"`     // 版本号
+	FieldCount int       `json:"field_count"` // 字段数量
+	Remark     string    `json:"remark"`      // 变更说明
+	CreatedBy  string    `json:"created_by"`  // 发布人
+	CreatedAt  time.Time `json:"created_at"`  // 发布时间
+}
+
+// TableStructureVersionDiffRequest 表结构版本对比请求
+type TableStructureVersionDiffRequest struct {
+	From int `form:"from" binding:"min=0" label:"起始版本"` // 0表示空结构
+	To   int `form:"to" binding:"required,min=1" label:"目标版本"`
+}
+
+// TableStructureVersionDiff 表结构版本对比结果
+type TableStructureVersionDiff struct {
+	From    int                `json:"from"`    // 起始版本
+	To      int                `json:"to"`      // 目标版本
+	Added   []FieldDefinition  `json:"added"`   // 新增的字段
+	Removed []FieldDefinition  `json:"removed"` // 删除的字段
+	Changed []FieldVersionDiff `json:"changed"` // 修改的字段
+}
+
+// FieldVersionDiff 字段在两个版本间的差异
+type FieldVersionDiff struct {
+	FieldID   string               `json:"field_id"`   // 字段ID
+	FieldName string               `json:"field_name"` // 目标版本中的字段名
+	Changes   []FieldAttributeDiff `json:"changes"`    // 属性变化
+}
+
+// FieldAttributeDiff 字段属性变化
+type FieldAttributeDiff struct {
+	Attribute string      `json:"attribute"` // 属性名，如 field_name、field_type
+	OldValue  interface{} `json:"old_value"` // 起始版本的值
+	NewValue  interface{} `json:"new_value"` // 目标版本的值
+}
+
+// FieldMigrationRequest 字段迁移请求（重命名字段或修改字段类型，并转换已有数据）
+type FieldMigrationRequest struct {
+	FieldName       string                 `json:"field_name" binding:"omitempty,max=50" label:"新字段名"`                                      // 为空表示不重命名
+	FieldType       string                 `json:"field_type" binding:"omitempty,oneof=string number date boolean enum file" label:"新字段类型"` // 为空表示不修改类型
+	FieldLength     *int                   `json:"field_length" binding:"omitempty,min=0" label:"字段长度"`
+	EnumOptions     []string               `json:"enum_options" label:"枚举选项"`
+	ValidationRules map[string]interface{} `json:"validation_rules" label:"验证规则"`
+	DefaultValue    *string                `json:"default_value" label:"默认值"`
+	ClearInvalid    bool                   `json:"clear_invalid" label:"清除无法转换的值"` // 为false时存在无法转换的值则不执行迁移
+	DryRun          bool                   `json:"dry_run" label:"仅预览"`
+	Remark          string                 `json:"remark" label:"变更说明"`
+}
+
+// FieldMigrationFailure 无法转换的字段值
+type FieldMigrationFailure struct {
+	RecordID string      `json:"record_id"` // 记录ID
+	Value    interface{} `json:"value"`     // 原值
+	Error    string      `json:"error"`     // 失败原因
+}
+
+// FieldMigrationResponse 字段迁移结果
+type FieldMigrationResponse struct {
+	DryRun         bool                    `json:"dry_run"`         // 是否仅预览
+	Applied        bool                    `json:"applied"`         // 是否已执行迁移
+	TotalCount     int                     `json:"total_count"`     // 有值的记录数
+	ConvertedCount int                     `json:"converted_count"` // 可转换的记录数
+	FailedCount    int                     `json:"failed_count"`    // 无法转换的记录数
+	Failures       []FieldMigrationFailure `json:"failures"`        // 无法转换的值
+	Field          *FieldDefinition        `json:"field,omitempty"` // 迁移后的字段定义
+	Version        int                     `json:"version"`         // 迁移后的表结构版本
+}
+
+// FieldDataRecord 记录中的动态字段值
+type FieldDataRecord struct {
+	ID       primitive.ObjectID // 记录主键
+	RecordID string             // 业务主键（无业务主键时为主键的十六进制）
+	Value    interface{}        // 字段值
+}
+
+// FieldDataUpdate 动态字段值迁移写入
+type FieldDataUpdate struct {
+	ID    primitive.ObjectID // 记录主键
+	Value interface{}        // 新值，为nil表示清除
+}
+
+// TableRecordKeys 动态表对应集合的业务主键字段
+var TableRecordKeys = map[string]string{
+	PolicyTableName: "policy_id",
+}
+
+// SchemaVersionKey 记录写入时所依据的表结构版本在记录中的字段名
+const SchemaVersionKey = "schema_version"
@@ -8,22 +8,26 @@ import (
 
 // User 用户表模型
 type User struct {
-	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`                // MongoDB主键ID
-	UserID        string             `bson:"user_id" json:"user_id"`                 // 用户唯一标识，业务主键
-	Username      string             `bson:"username" json:"username"`               // 登录用户名，唯一
-	DisplayName   string             `bson:"display_name" json:"display_name"`       // 用户显示名称（中文名等）
-	CompanyID     string             `bson:"company_id" json:"company_id"`           // 所属保险经纪公司ID
-	RoleIDs       []string           `bson:"role_ids" json:"role_ids"`               // 用户角色ID数组，支持多角色
-	Status        string             `bson:"status" json:"status"`                   // 用户状态：active=激活, inactive=禁用, locked=锁定
-	LastLoginTime *time.Time         `bson:"last_login_time" json:"last_login_time"` // 最后登录时间，可为空
-	PasswordHash  string             `bson:"password_hash" json:"-"`                 // 密码哈希值，不返回给前端
-	Email         string             `bson:"email" json:"email"`                     // 邮箱地址，可选
-	Phone         string             `bson:"phone" json:"phone"`                     // 手机号码，可选
-	Remark        string             `bson:"remark" json:"remark"`                   // 备注信息
-	LoginAttempts int                `bson:"login_attempts" json:"login_attempts"`   // 登录失败次数，用于防暴力破解
-	LockedUntil   *time.Time         `bson:"locked_until" json:"locked_until"`       // 账户锁定截止时间，可为空
-	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`           // 创建时间
-	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`           // 更新时间
+	ID                 primitive.ObjectID `bson:"_id,omitempty" json:"id"`                                  // MongoDB主键ID
+	UserID             string             `bson:"user_id" json:"user_id"`                                   // 用户唯一标识，业务主键
+	Username           string             `bson:"username" json:"username"`                                 // 登录用户名，唯一
+	DisplayName        string             `bson:"display_name" json:"display_name"`                         // 用户显示名称（中文名等）
+	CompanyID          string             `bson:"company_id" json:"company_id"`                             // 所属保险经纪公司ID
+	RoleIDs            []string           `bson:"role_ids" json:"role_ids"`                                 // 用户角色ID数组，支持多角色
+	Status             string             `bson:"status" json:"status"`                                     // 用户状态：active=激活, inactive=禁用, locked=锁定
+	LastLoginTime      *time.Time         `bson:"last_login_time" json:"last_login_time"`                   // 最后登录时间，可为空
+	PasswordHash       string             `bson:"password_hash" json:"-"`                                   // 密码哈希值，不返回给前端
+	Email              string             `bson:"email" json:"email"`                                       // 邮箱地址，可选
+	Phone              string             `bson:"phone" json:"phone"`                                       // 手机号码，可选
+	Remark             string             `bson:"remark" json:"remark"`                                     // 备注信息
+	LoginAttempts      int                `bson:"login_attempts" json:"login_attempts"`                     // 登录失败次数，用于防暴力破解
+	LockedUntil        *time.Time         `bson:"locked_until" json:"locked_until"`                         // 账户锁定截止时间，可为空
+	TokensValidAfter   *time.Time         `bson:"tokens_valid_after,omitempty" json:"-"`                    // 会话吊销时间，早于该时间签发的令牌失效（如重置密码后）
+	PasswordChangedAt  *time.Time         `bson:"password_changed_at,omitempty" json:"password_changed_at"` // 最后修改密码时间，用于计算密码是否过期
+	PasswordHistory    []string           `bson:"password_history,omitempty" json:"-"`                      // 最近使用过的密码哈希（不含当前密码），用于禁止重复使用
+	MustChangePassword bool               `bson:"must_change_password" json:"must_change_password"`         // 是否须修改密码后才能使用系统（新建账户、管理员重置密码后）
+	CreatedAt          time.Time          `bson:"created_at" json:"created_at"`                             // 创建时间
+	UpdatedAt          time.Time          `bson:"updated_at" json:"updated_at"`                             // 更新时间
 }
 
 // Company 保险经纪公司表模型
@@ -69,6 +73,8 @@ type Company struct {
 	ValidEndDate     time.Time `bson:"valid_end_date" json:"valid_end_date"`         // 有效期结束日期
 	UserQuota        int       `bson:"user_quota" json:"user_quota"`                 // 允许创建的用户数量配额
 	CurrentUserCount int       `bson:"current_user_count" json:"current_user_count"` // 当前已创建的用户数量
+	DictionaryMode   string    `bson:"dictionary_mode" json:"dictionary_mode"`       // 保单字典校验模式：off=关闭, warn=警告, reject=拒绝
+	AllowedIPs       []string  `bson:"allowed_ips,omitempty" json:"allowed_ips"`     // 允许访问的IP或CIDR，为空表示不限制（平台管理员不受限制）
 	Status           string    `bson:"status" json:"status"`                         // 状态：active=有效, inactive=停用, expired=过期
 	Remark           string    `bson:"remark" json:"remark"`                         // 备注信息（保留兼容）
 	SubmittedBy      string    `bson:"submitted_by" json:"submitted_by"`             // 提交人
@@ -92,6 +98,23 @@ type Role struct {
 	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"` // 更新时间
 }
 
+// platformAdminRoleIDs 平台管理员（超级管理员）角色ID
+var platformAdminRoleIDs = map[string]bool{
+	"ADMIN":          true,
+	"SUPER_ADMIN":    true,
+	"platform_admin": true,
+}
+
+// IsPlatformAdminRoles 角色列表中是否包含平台管理员角色
+func IsPlatformAdminRoles(roleIDs []string) bool {
+	for _, roleID := range roleIDs {
+		if platformAdminRoleIDs[roleID] {
+			return true
+		}
+	}
+	return false
+}
+
 // Menu 菜单表模型
 type Menu struct {
 	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`                // MongoDB主键ID
@@ -120,6 +143,7 @@ type TableStructure struct {
 	CompanyID   string             `bson:"company_id" json:"company_id"`     // 所属公司ID，空表示平台级表
 	Description string             `bson:"description" json:"description"`   // 表描述
 	Status      string             `bson:"status" json:"status"`             // 状态：active=启用, inactive=禁用
+	Version     int                `bson:"version" json:"version"`           // 当前结构版本号，每次字段变更发布新版本，0表示尚未发布
 	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`     // 创建时间
 	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`     // 更新时间
 }
@@ -229,12 +253,24 @@ type ResetPasswordRequest struct {
 	NewPassword string `json:"new_password" binding:"required,min=8"` // 新密码
 }
 
+// ForgotPasswordRequest 忘记密码请求
+type ForgotPasswordRequest struct {
+	Account string `json:"account" binding:"required,max=100" label:"用户名或邮箱"` // 用户名或邮箱
+}
+
+// ResetPasswordWithTokenRequest 使用重置令牌设置新密码请求
+type ResetPasswordWithTokenRequest struct {
+	Token       string `json:"token" binding:"required,max=200" label:"重置令牌"`     // 邮件中的重置令牌
+	NewPassword string `json:"new_password" binding:"required,min=8" label:"新密码"` // 新密码
+}
+
 // LoginResponse 登录响应
 type LoginResponse struct {
-	Token        string    `json:"token"`         // 访问令牌
-	RefreshToken string    `json:"refresh_token"` // 刷新令牌
-	ExpiresAt    time.Time `json:"expires_at"`    // 令牌过期时间
-	User         UserInfo  `json:"user"`          // 用户信息
+	Token                string              `json:"token"`                           // 访问令牌
+	RefreshToken         string              `json:"refresh_token"`                   // 刷新令牌
+	ExpiresAt            time.Time           `json:"expires_at"`                      // 令牌过期时间
+	User                 UserInfo            `json:"user"`                            // 用户信息
+	PendingAnnouncements []AnnouncementBrief `json:"pending_announcements,omitempty"` // 待确认公告（仅登录时返回）
 }
 
 // UserInfo 用户信息
@@ -249,6 +285,13 @@ type UserInfo struct {
 	Email       string     `json:"email"`        // 邮箱地址
 	Phone       string     `json:"phone"`        // 手机号码
 	LastLogin   *time.Time `json:"last_login"`   // 最后登录时间
+
+	MustChangePassword bool       `json:"must_change_password"`          // 是否须修改密码（管理员设置或密码已过期）
+	PasswordExpiresAt  *time.Time `json:"password_expires_at,omitempty"` // 密码过期时间，策略不限制时为空
+
+	Impersonation *ImpersonationInfo `json:"impersonation,omitempty"` // 模拟登录信息，当前令牌为平台管理员模拟登录时返回
+
+	MaskedFields []string `json:"masked_fields,omitempty"` // 按查看人权限脱敏的字段
 }
 
 // UserListResponse 用户列表响应
@@ -349,11 +392,12 @@ type UpdateCompanyRequest struct {
 	Password string `json:"password" binding:"omitempty,min=8"`        // 密码
 
 	// 系统字段
-	ValidStartDate string `json:"valid_start_date"`                                 // 有效期开始日期
-	ValidEndDate   string `json:"valid_end_date"`                                   // 有效期结束日期
-	UserQuota      int    `json:"user_quota" binding:"omitempty,min=1,max=10000"`   // 用户配额
-	Status         string `json:"status" binding:"omitempty,oneof=active inactive"` // 状态
-	Remark         string `json:"remark" binding:"omitempty,max=500"`               // 备注信息（保留兼容）
+	ValidStartDate string `json:"valid_start_date"`                                          // 有效期开始日期
+	ValidEndDate   string `json:"valid_end_date"`                                            // 有效期结束日期
+	UserQuota      int    `json:"user_quota" binding:"omitempty,min=1,max=10000"`            // 用户配额
+	DictionaryMode string `json:"dictionary_mode" binding:"omitempty,oneof=off warn reject"` // 保单字典校验模式
+	Status         string `json:"status" binding:"omitempty,oneof=active inactive"`          // 状态
+	Remark         string `json:"remark" binding:"omitempty,max=500"`                        // 备注信息（保留兼容）
 }
 
 // CompanyQueryRequest 公司查询请求
@@ -406,6 +450,7 @@ type CompanyInfo struct {
 	ValidEndDate     string `json:"valid_end_date"`     // 有效期结束日期
 	UserQuota        int    `json:"user_quota"`         // 用户配额
 	CurrentUserCount int    `json:"current_user_count"` // 当前用户数量
+	DictionaryMode   string `json:"dictionary_mode"`    // 保单字典校验模式
 	Status           string `json:"status"`             // 状态
 	StatusText       string `json:"status_text"`        // 状态文本
 	Remark           string `json:"remark"`             // 备注信息（保留兼容）
@@ -736,11 +781,12 @@ type RolePermission struct {
 type SystemConfig struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	ConfigID    string             `bson:"config_id" json:"config_id"`       // 配置项ID
-	ConfigType  string             `bson:"config_type" json:"config_type"`   // 配置类型：hk_manager, referral_branch, partner
+	ConfigType  string             `bson:"config_type" json:"config_type"`   // 配置类型，对应字典类型注册表中的类型编码
 	ConfigKey   string             `bson:"config_key" json:"config_key"`     // 配置键
+	ParentKey   string             `bson:"parent_key" json:"parent_key"`     // 上级配置键，所属类型存在上级类型时必填
 	ConfigValue string             `bson:"config_value" json:"config_value"` // 配置值
 	DisplayName string             `bson:"display_name" json:"display_name"` // 显示名称
-	CompanyID   string             `bson:"company_id" json:"company_id"`     // 所属公司ID
+	CompanyID   string             `bson:"company_id" json:"company_id"`     // 所属公司ID，空表示平台级配置项，所有公司继承
 	SortOrder   int                `bson:"sort_order" json:"sort_order"`     // 排序
 	Status      string             `bson:"status" json:"status"`             // 状态：enable/disable
 	Remark      string             `bson:"remark" json:"remark"`             // 备注
@@ -750,10 +796,25 @@ type SystemConfig struct {
 	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`     // 更新时间
 }
 
+// 保单字典校验模式
+const (
+	DictionaryModeOff    = "off"    // 不校验，原样保存
+	DictionaryModeWarn   = "warn"   // 规范化已知值，未知值仅提示
+	DictionaryModeReject = "reject" // 规范化已知值，拒绝未知值
+)
+
+// 系统配置项来源
+const (
+	ConfigSourcePlatform = "platform" // 平台级配置项（公司继承）
+	ConfigSourceCompany  = "company"  // 公司自有配置项
+)
+
 // SystemConfigCreateRequest 创建系统配置请求
 type SystemConfigCreateRequest struct {
-	ConfigType  string `json:"config_type" binding:"required,oneof=hk_manager referral_branch partner" label:"配置类型"`
+	CompanyID   string `json:"company_id" label:"所属公司"` // 空表示平台级配置项
+	ConfigType  string `json:"config_type" binding:"required" label:"配置类型"`
 	ConfigKey   string `json:"config_key" binding:"required" label:"配置键"`
+	ParentKey   string `json:"parent_key" label:"上级配置键"`
 	ConfigValue string `json:"config_value" binding:"required" label:"配置值"`
 	DisplayName string `json:"display_name" binding:"required" label:"显示名称"`
 	SortOrder   int    `json:"sort_order" label:"排序"`
@@ -763,6 +824,7 @@ type SystemConfigCreateRequest struct {
 
 // SystemConfigUpdateRequest 更新系统配置请求
 type SystemConfigUpdateRequest struct {
+	ParentKey   string `json:"parent_key" label:"上级配置键"`
 	ConfigValue string `json:"config_value" label:"配置值"`
 	DisplayName string `json:"display_name" label:"显示名称"`
 	SortOrder   int    `json:"sort_order" label:"排序"`
@@ -772,6 +834,7 @@ type SystemConfigUpdateRequest struct {
 
 // SystemConfigQueryRequest 查询系统配置请求
 type SystemConfigQueryRequest struct {
+	CompanyID  string `json:"company_id" form:"company_id"`
 	ConfigType string `json:"config_type" form:"config_type"`
 	Status     string `json:"status" form:"status"`
 	Keyword    string `json:"keyword" form:"keyword"`
@@ -791,15 +854,101 @@ type SystemConfigResponse struct {
 	ConfigID    string `json:"config_id"`
 	ConfigType  string `json:"config_type"`
 	ConfigKey   string `json:"config_key"`
+	ParentKey   string `json:"parent_key"`
 	ConfigValue string `json:"config_value"`
 	DisplayName string `json:"display_name"`
 	CompanyID   string `json:"company_id"`
 	SortOrder   int    `json:"sort_order"`
 	Status      string `json:"status"`
 	StatusText  string `json:"status_text"`
+	Source      string `json:"source"`               // 来源：platform=平台继承, company=公司自有
+	Overridden  bool   `json:"overridden,omitempty"` // 是否被公司覆盖了显示名称或排序
+	Hidden      bool   `json:"hidden,omitempty"`     // 是否被公司隐藏
 	Remark      string `json:"remark"`
 	CreatedBy   string `json:"created_by"`
 	UpdatedBy   string `json:"updated_by"`
 	CreatedAt   string `json:"created_at"`
 	UpdatedAt   string `json:"updated_at"`
 }
+
+// SystemConfigOptionsRequest 获取配置选项请求
+type SystemConfigOptionsRequest struct {
+	ParentKey     string `json:"parent_key" form:"parent_key"`         // 上级配置键，用于级联选择时获取下级选项
+	Tree          bool   `json:"tree" form:"tree"`                     // 是否以树形结构返回（包含所有下级类型的配置项）
+	IncludeHidden bool   `json:"include_hidden" form:"include_hidden"` // 是否包含已被公司隐藏的平台配置项
+}
+
+// SystemConfigOverride 公司对平台级配置项的覆盖设置
+type SystemConfigOverride struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CompanyID   string             `bson:"company_id" json:"company_id"`     // 公司ID
+	ConfigID    string             `bson:"config_id" json:"config_id"`       // 被覆盖的平台配置项ID
+	ConfigType  string             `bson:"config_type" json:"config_type"`   // 配置类型
+	Hidden      bool               `bson:"hidden" json:"hidden"`             // 是否隐藏
+	DisplayName string             `bson:"display_name" json:"display_name"` // 覆盖的显示名称，空表示沿用平台值
+	SortOrder   *int               `bson:"sort_order" json:"sort_order"`     // 覆盖的排序，空表示沿用平台值
+	UpdatedBy   string             `bson:"updated_by" json:"updated_by"`     // 更新人
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`     // 更新时间
+}
+
+// SystemConfigOverrideRequest 设置平台配置项覆盖请求
+type SystemConfigOverrideRequest struct {
+	Hidden      bool   `json:"hidden" label:"是否隐藏"`
+	DisplayName string `json:"display_name" binding:"omitempty,max=100" label:"显示名称"`
+	SortOrder   *int   `json:"sort_order" label:"排序"`
+}
+
+// SystemConfigTreeNode 配置选项树节点
+type SystemConfigTreeNode struct {
+	SystemConfigResponse
+	Children []SystemConfigTreeNode `json:"children,omitempty"`
+}
+
+// ==========================
+// 字典类型注册表相关模型
+// ==========================
+
+// SystemConfigType 字典类型注册表模型
+type SystemConfigType struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TypeID     string             `bson:"type_id" json:"type_id"`         // 类型ID
+	TypeCode   string             `bson:"type_code" json:"type_code"`     // 类型编码，对应系统配置的config_type
+	TypeName   string             `bson:"type_name" json:"type_name"`     // 类型名称
+	ParentType string             `bson:"parent_type" json:"parent_type"` // 上级类型编码，为空表示顶级类型
+	CompanyID  string             `bson:"company_id" json:"company_id"`   // 所属公司ID，空表示平台级类型
+	SortOrder  int                `bson:"sort_order" json:"sort_order"`   // 排序
+	Status     string             `bson:"status" json:"status"`           // 状态：enable/disable
+	Remark     string             `bson:"remark" json:"remark"`           // 备注
+	Builtin    bool               `bson:"-" json:"builtin"`               // 是否内置类型（未写入注册表）
+	CreatedBy  string             `bson:"created_by" json:"created_by"`   // 创建人
+	UpdatedBy  string             `bson:"updated_by" json:"updated_by"`   // 更新人
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`   // 创建时间
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`   // 更新时间
+}
+
+// BuiltinSystemConfigTypes 内置的平台级字典类型，注册表中存在同编码类型时以注册表为准
+var BuiltinSystemConfigTypes = []SystemConfigType{
+	{TypeCode: "hk_manager", TypeName: "港分客户经理", SortOrder: 1, Status: "enable"},
+	{TypeCode: "referral_branch", TypeName: "转介分行", SortOrder: 2, Status: "enable"},
+	{TypeCode: "referral_sub_branch", TypeName: "转介支行", ParentType: "referral_branch", SortOrder: 3, Status: "enable"},
+	{TypeCode: "partner", TypeName: "合作伙伴", SortOrder: 4, Status: "enable"},
+}
+
+// SystemConfigTypeCreateRequest 创建字典类型请求
+type SystemConfigTypeCreateRequest struct {
+	TypeCode   string `json:"type_code" binding:"required,min=2,max=50" label:"类型编码"`
+	TypeName   string `json:"type_name" binding:"required,max=50" label:"类型名称"`
+	ParentType string `json:"parent_type" label:"上级类型"`
+	CompanyID  string `json:"company_id" label:"所属公司"` // 空表示平台级类型
+	SortOrder  int    `json:"sort_order" label:"排序"`
+	Status     string `json:"status" binding:"omitempty,oneof=enable disable" label:"状态"`
+	Remark     string `json:"remark" label:"备注"`
+}
+
+// SystemConfigTypeUpdateRequest 更新字典类型请求
+type SystemConfigTypeUpdateRequest struct {
+	TypeName  string `json:"type_name" binding:"omitempty,max=50" label:"类型名称"`
+	SortOrder int    `json:"sort_order" label:"排序"`
+	Status    string `json:"status" binding:"omitempty,oneof=enable disable" label:"状态"`
+	Remark    string `json:"remark" label:"备注"`
+}
@@ -29,6 +29,21 @@ func (r *ActivityLogRepository) Create(ctx context.Context, log *model.ActivityL
 	return err
 }
 
+// CreateMany 批量创建活动记录
+func (r *ActivityLogRepository) CreateMany(ctx context.Context, logs []*model.ActivityLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	docs := make([]interface{}, 0, len(logs))
+	for _, log := range logs {
+		log.OperationTime = now
+		docs = append(docs, log)
+	}
+	_, err := r.collection.InsertMany(ctx, docs)
+	return err
+}
+
 // GetList 获取活动记录列表
 func (r *ActivityLogRepository) GetList(ctx context.Context, query *model.ActivityLogQuery) (*model.ActivityLogResponse, error) {
 	filter := bson.M{}
@@ -0,0 +1,366 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"YufungProject/internal/model"
+	"YufungProject/pkg/utils"
+)
+
+const (
+	AnnouncementCollection     = "announcements"
+	AnnouncementReadCollection = "announcement_reads"
+)
+
+type AnnouncementRepository struct {
+	db *mongo.Database
+}
+
+func NewAnnouncementRepository(db *mongo.Database) *AnnouncementRepository {
+	return &AnnouncementRepository{db: db}
+}
+
+// ==========================
+// 公告管理
+// ==========================
+
+// CreateAnnouncement 创建公告
+func (r *AnnouncementRepository) CreateAnnouncement(ctx context.Context, announcement *model.Announcement) error {
+	collection := r.db.Collection(AnnouncementCollection)
+
+	announcement.AnnouncementID = utils.GenerateID("ANN")
+	announcement.CreatedAt = time.Now()
+	announcement.UpdatedAt = announcement.CreatedAt
+
+	_, err := collection.InsertOne(ctx, announcement)
+	return err
+}
+
+// GetAnnouncementByID 根据ID获取公告
+func (r *AnnouncementRepository) GetAnnouncementByID(ctx context.Context, announcementID string) (*model.Announcement, error) {
+	collection := r.db.Collection(AnnouncementCollection)
+
+	var announcement model.Announcement
+	err := collection.FindOne(ctx, bson.M{"announcement_id": announcementID}).Decode(&announcement)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &announcement, nil
+}
+
+// UpdateAnnouncement 更新公告
+func (r *AnnouncementRepository) UpdateAnnouncement(ctx context.Context, announcementID string, set bson.M, unset bson.M) error {
+	collection := r.db.Collection(AnnouncementCollection)
+
+	set["updated_at"] = time.Now()
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	_, err := collection.UpdateOne(ctx, bson.M{"announcement_id": announcementID}, update)
+	return err
+}
+
+// DeleteAnnouncement 删除公告及其阅读回执
+func (r *AnnouncementRepository) DeleteAnnouncement(ctx context.Context, announcementID string) error {
+	if _, err := r.db.Collection(AnnouncementReadCollection).DeleteMany(ctx, bson.M{"announcement_id": announcementID}); err != nil {
+		return err
+	}
+
+	_, err := r.db.Collection(AnnouncementCollection).DeleteOne(ctx, bson.M{"announcement_id": announcementID})
+	return err
+}
+
+// ListManagedAnnouncements 分页查询发布方的公告，companyID为空时查询平台公告
+func (r *AnnouncementRepository) ListManagedAnnouncements(ctx context.Context, req *model.AnnouncementManageQueryRequest, companyID string) ([]model.Announcement, int64, error) {
+	collection := r.db.Collection(AnnouncementCollection)
+
+	filter := bson.M{"company_id": companyID}
+	if req.Keyword != "" {
+		filter["title"] = bson.M{"$regex": regexp.QuoteMeta(req.Keyword), "$options": "i"}
+	}
+	if req.Status != "" {
+		filter["status"] = req.Status
+	}
+	if req.Priority != "" {
+		filter["priority"] = req.Priority
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((req.Page - 1) * req.PageSize)).
+		SetLimit(int64(req.PageSize))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	announcements := []model.Announcement{}
+	if err = cursor.All(ctx, &announcements); err != nil {
+		return nil, 0, err
+	}
+
+	return announcements, total, nil
+}
+
+// ==========================
+// 公告查看
+// ==========================
+
+// visibleFilter 用户可见公告的查询条件：已发布、处于生效期内且用户属于受众
+func visibleFilter(companyID string, roleIDs []string, now time.Time) bson.M {
+	if roleIDs == nil {
+		roleIDs = []string{}
+	}
+	return bson.M{
+		"status":     model.AnnouncementStatusPublished,
+		"publish_at": bson.M{"$lte": now},
+		"$and": []bson.M{
+			{"$or": []bson.M{
+				{"expire_at": nil},
+				{"expire_at": bson.M{"$gt": now}},
+			}},
+			{"$or": []bson.M{
+				{"company_id": bson.M{"$in": []string{"", companyID}}, "audience_type": model.AnnouncementAudienceAll},
+				{"company_id": "", "audience_type": model.AnnouncementAudienceCompanies, "target_companies": companyID},
+				{"company_id": bson.M{"$in": []string{"", companyID}}, "audience_type": model.AnnouncementAudienceRoles, "target_roles": bson.M{"$in": roleIDs}},
+			}},
+		},
+	}
+}
+
+// ListVisibleAnnouncements 分页查询用户可见的公告，按生效时间倒序；excludeIDs用于排除已读公告
+func (r *AnnouncementRepository) ListVisibleAnnouncements(ctx context.Context, req *model.AnnouncementQueryRequest, companyID string, roleIDs []string, excludeIDs []string, now time.Time) ([]model.Announcement, int64, error) {
+	collection := r.db.Collection(AnnouncementCollection)
+
+	filter := visibleFilter(companyID, roleIDs, now)
+	if req.Priority != "" {
+		filter["priority"] = req.Priority
+	}
+	if len(excludeIDs) > 0 {
+		filter["announcement_id"] = bson.M{"$nin": excludeIDs}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "publish_at", Value: -1}}).
+		SetSkip(int64((req.Page - 1) * req.PageSize)).
+		SetLimit(int64(req.PageSize))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	announcements := []model.Announcement{}
+	if err = cursor.All(ctx, &announcements); err != nil {
+		return nil, 0, err
+	}
+
+	return announcements, total, nil
+}
+
+// GetVisibleAnnouncement 获取用户可见的公告，不可见时返回nil
+func (r *AnnouncementRepository) GetVisibleAnnouncement(ctx context.Context, announcementID, companyID string, roleIDs []string, now time.Time) (*model.Announcement, error) {
+	collection := r.db.Collection(AnnouncementCollection)
+
+	filter := visibleFilter(companyID, roleIDs, now)
+	filter["announcement_id"] = announcementID
+
+	var announcement model.Announcement
+	err := collection.FindOne(ctx, filter).Decode(&announcement)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &announcement, nil
+}
+
+// ListVisibleRequireAck 获取用户可见且需要确认的公告，按生效时间倒序；excludeIDs用于排除已确认公告
+func (r *AnnouncementRepository) ListVisibleRequireAck(ctx context.Context, companyID string, roleIDs []string, excludeIDs []string, now time.Time) ([]model.Announcement, error) {
+	collection := r.db.Collection(AnnouncementCollection)
+
+	filter := visibleFilter(companyID, roleIDs, now)
+	filter["require_ack"] = true
+	if len(excludeIDs) > 0 {
+		filter["announcement_id"] = bson.M{"$nin": excludeIDs}
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "publish_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	announcements := []model.Announcement{}
+	if err = cursor.All(ctx, &announcements); err != nil {
+		return nil, err
+	}
+
+	return announcements, nil
+}
+
+// ==========================
+// 阅读回执
+// ==========================
+
+// ListUserReads 获取用户的阅读回执（公告ID -> 回执）；announcementIDs为空时返回全部
+func (r *AnnouncementRepository) ListUserReads(ctx context.Context, userID string, announcementIDs []string) (map[string]*model.AnnouncementRead, error) {
+	collection := r.db.Collection(AnnouncementReadCollection)
+
+	filter := bson.M{"user_id": userID}
+	if announcementIDs != nil {
+		filter["announcement_id"] = bson.M{"$in": announcementIDs}
+	}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reads []model.AnnouncementRead
+	if err = cursor.All(ctx, &reads); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*model.AnnouncementRead, len(reads))
+	for i := range reads {
+		result[reads[i].AnnouncementID] = &reads[i]
+	}
+	return result, nil
+}
+
+// ListAnnouncementReads 获取公告的全部阅读回执
+func (r *AnnouncementRepository) ListAnnouncementReads(ctx context.Context, announcementID string) ([]model.AnnouncementRead, error) {
+	collection := r.db.Collection(AnnouncementReadCollection)
+
+	cursor, err := collection.Find(ctx, bson.M{"announcement_id": announcementID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reads []model.AnnouncementRead
+	if err = cursor.All(ctx, &reads); err != nil {
+		return nil, err
+	}
+
+	return reads, nil
+}
+
+// CountReceipts 批量统计公告的已读人数和已确认人数（公告ID -> [已读, 已确认]）
+func (r *AnnouncementRepository) CountReceipts(ctx context.Context, announcementIDs []string) (map[string][2]int64, error) {
+	collection := r.db.Collection(AnnouncementReadCollection)
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"announcement_id": bson.M{"$in": announcementIDs}}},
+		{"$group": bson.M{
+			"_id":  "$announcement_id",
+			"read": bson.M{"$sum": 1},
+			"ack": bson.M{"$sum": bson.M{"$cond": []interface{}{
+				bson.M{"$ifNull": []interface{}{"$acknowledged_at", false}}, 1, 0,
+			}}},
+		}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID   string `bson:"_id"`
+		Read int64  `bson:"read"`
+		Ack  int64  `bson:"ack"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][2]int64, len(rows))
+	for _, row := range rows {
+		result[row.ID] = [2]int64{row.Read, row.Ack}
+	}
+	return result, nil
+}
+
+// MarkRead 记录用户已读公告，已存在回执时保留首次阅读时间
+func (r *AnnouncementRepository) MarkRead(ctx context.Context, announcementID, userID, companyID string, now time.Time) error {
+	collection := r.db.Collection(AnnouncementReadCollection)
+
+	filter := bson.M{"announcement_id": announcementID, "user_id": userID}
+	update := bson.M{"$setOnInsert": bson.M{"company_id": companyID, "read_at": now, "acknowledged_at": nil}}
+	_, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil && mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// Acknowledge 记录用户确认公告，已确认时保留首次确认时间；返回是否本次新确认
+func (r *AnnouncementRepository) Acknowledge(ctx context.Context, announcementID, userID, companyID string, now time.Time) (bool, error) {
+	collection := r.db.Collection(AnnouncementReadCollection)
+
+	filter := bson.M{"announcement_id": announcementID, "user_id": userID, "acknowledged_at": nil}
+	update := bson.M{
+		"$set":         bson.M{"acknowledged_at": now},
+		"$setOnInsert": bson.M{"company_id": companyID, "read_at": now},
+	}
+	result, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		// 已确认过的回执不匹配过滤条件，插入时唯一索引冲突
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return result.ModifiedCount > 0 || result.UpsertedCount > 0, nil
+}
+
+// ResetAcknowledgements 清除公告的全部确认记录（公告内容变更后需要重新确认）
+func (r *AnnouncementRepository) ResetAcknowledgements(ctx context.Context, announcementID string) error {
+	collection := r.db.Collection(AnnouncementReadCollection)
+
+	_, err := collection.UpdateMany(ctx, bson.M{"announcement_id": announcementID}, bson.M{"$set": bson.M{"acknowledged_at": nil}})
+	return err
+}
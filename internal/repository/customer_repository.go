@@ -0,0 +1,531 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"YufungProject/internal/model"
+	"YufungProject/pkg/utils"
+)
+
+const (
+	CustomerCollection          = "customers"
+	CustomerDuplicateCollection = "customer_duplicates"
+)
+
+type CustomerRepository struct {
+	db *mongo.Database
+}
+
+func NewCustomerRepository(db *mongo.Database) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+// CreateCustomer 创建客户
+func (r *CustomerRepository) CreateCustomer(ctx context.Context, customer *model.Customer) error {
+	collection := r.db.Collection(CustomerCollection)
+
+	customer.CustomerID = utils.GenerateID("CUS")
+	customer.CreatedAt = time.Now()
+	customer.UpdatedAt = time.Now()
+
+	stored, err := sealCustomer(ctx, customer)
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.InsertOne(ctx, stored)
+	return err
+}
+
+// GetCustomerByID 根据ID获取客户
+func (r *CustomerRepository) GetCustomerByID(ctx context.Context, customerID string) (*model.Customer, error) {
+	return r.findOne(ctx, bson.M{"customer_id": customerID})
+}
+
+// GetCustomerByNumber 根据客户号获取公司内的客户（客户号可能已加密，按盲索引匹配）
+func (r *CustomerRepository) GetCustomerByNumber(ctx context.Context, customerNumber, companyID string) (*model.Customer, error) {
+	filter := encryptedFieldMatch("customer_number", customerNumber)
+	filter["company_id"] = companyID
+	return r.findOne(ctx, filter)
+}
+
+// GetCustomerByAlias 根据曾用客户号获取公司内的客户
+func (r *CustomerRepository) GetCustomerByAlias(ctx context.Context, customerNumber, companyID string) (*model.Customer, error) {
+	return r.findOne(ctx, bson.M{"company_id": companyID, "aliases": customerNumber})
+}
+
+// GetCustomersByIDs 根据ID批量获取客户
+func (r *CustomerRepository) GetCustomersByIDs(ctx context.Context, customerIDs []string) (map[string]*model.Customer, error) {
+	collection := r.db.Collection(CustomerCollection)
+
+	cursor, err := collection.Find(ctx, bson.M{"customer_id": bson.M{"$in": customerIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	customers, err := decodeCustomers(ctx, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*model.Customer, len(customers))
+	for i := range customers {
+		result[customers[i].CustomerID] = &customers[i]
+	}
+	return result, nil
+}
+
+// ListAllCustomers 获取公司的全部客户
+func (r *CustomerRepository) ListAllCustomers(ctx context.Context, companyID string) ([]model.Customer, error) {
+	collection := r.db.Collection(CustomerCollection)
+
+	cursor, err := collection.Find(ctx, bson.M{"company_id": companyID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	return decodeCustomers(ctx, cursor)
+}
+
+func (r *CustomerRepository) findOne(ctx context.Context, filter bson.M) (*model.Customer, error) {
+	collection := r.db.Collection(CustomerCollection)
+
+	customer, err := decodeCustomer(ctx, collection.FindOne(ctx, filter))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return customer, nil
+}
+
+// UpdateCustomer 更新客户
+func (r *CustomerRepository) UpdateCustomer(ctx context.Context, customerID string, updates bson.M) error {
+	collection := r.db.Collection(CustomerCollection)
+
+	updates["updated_at"] = time.Now()
+
+	filter := bson.M{"customer_id": customerID}
+	updates, err := sealUpdates(ctx, collection, filter, updates, CustomerEncryptedFields)
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.UpdateOne(ctx, filter, bson.M{"$set": updates})
+	return err
+}
+
+// DeleteCustomer 删除客户
+func (r *CustomerRepository) DeleteCustomer(ctx context.Context, customerID string) error {
+	collection := r.db.Collection(CustomerCollection)
+
+	_, err := collection.DeleteOne(ctx, bson.M{"customer_id": customerID})
+	return err
+}
+
+// ListCustomers 分页查询客户列表；启用加密后关键字只能精确匹配客户号、姓名、证件号及电话
+func (r *CustomerRepository) ListCustomers(ctx context.Context, req *model.CustomerQueryRequest, companyID string) (*model.CustomerListResponse, error) {
+	collection := r.db.Collection(CustomerCollection)
+
+	filter := bson.M{"company_id": companyID}
+	if req.RiskLevel != "" {
+		filter["risk_level"] = req.RiskLevel
+	}
+	if req.Source != "" {
+		filter["source"] = req.Source
+	}
+	if req.Keyword != "" {
+		keyword := regexp.QuoteMeta(req.Keyword)
+		filter["$or"] = []bson.M{
+			encryptedFieldSearch("customer_number", req.Keyword),
+			{"aliases": bson.M{"$regex": keyword, "$options": "i"}},
+			encryptedFieldSearch("customer_name_cn", req.Keyword),
+			encryptedFieldSearch("customer_name_en", req.Keyword),
+			encryptedFieldSearch("id_number", req.Keyword),
+			encryptedFieldSearch("phone", req.Keyword),
+		}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+	// 客户号加密后密文无序，改按创建时间排序
+	sortBy := bson.D{{Key: "customer_number", Value: 1}}
+	if FieldEncryptionEnabled() {
+		sortBy = bson.D{{Key: "created_at", Value: 1}}
+	}
+	opts := options.Find().
+		SetSort(sortBy).
+		SetSkip(int64((req.Page - 1) * req.PageSize)).
+		SetLimit(int64(req.PageSize))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	customers, err := decodeCustomers(ctx, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.CustomerListResponse{
+		List:     customers,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// CheckCustomerNumberExists 检查客户号在公司内是否已存在（含其他客户的曾用客户号）
+func (r *CustomerRepository) CheckCustomerNumberExists(ctx context.Context, customerNumber, companyID, excludeID string) (bool, error) {
+	collection := r.db.Collection(CustomerCollection)
+
+	filter := bson.M{
+		"company_id": companyID,
+		"$or": []bson.M{
+			encryptedFieldMatch("customer_number", customerNumber),
+			{"aliases": customerNumber},
+		},
+	}
+	if excludeID != "" {
+		filter["customer_id"] = bson.M{"$ne": excludeID}
+	}
+
+	count, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// ==========================
+// 关联保单
+// ==========================
+
+// ListCustomerPolicies 获取客户关联的保单，按创建时间倒序
+func (r *CustomerRepository) ListCustomerPolicies(ctx context.Context, customerID string) ([]model.Policy, error) {
+	collection := r.db.Collection(PolicyCollection)
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.M{"customer_id": customerID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	return decodePolicies(ctx, cursor)
+}
+
+// CountPoliciesByCustomer 统计客户关联的保单数量
+func (r *CustomerRepository) CountPoliciesByCustomer(ctx context.Context, customerID string) (int64, error) {
+	collection := r.db.Collection(PolicyCollection)
+
+	return collection.CountDocuments(ctx, bson.M{"customer_id": customerID})
+}
+
+// GetCustomerPolicySummary 按保单币种汇总客户的保单数量、保费及AUM
+func (r *CustomerRepository) GetCustomerPolicySummary(ctx context.Context, customerID string) (*model.CustomerPolicySummary, error) {
+	collection := r.db.Collection(PolicyCollection)
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"customer_id": customerID}},
+		{
+			"$group": bson.M{
+				"_id":               "$policy_currency",
+				"policy_count":      bson.M{"$sum": 1},
+				"total_premium":     bson.M{"$sum": "$actual_premium"},
+				"total_aum":         bson.M{"$sum": "$aum"},
+				"surrendered_count": bson.M{"$sum": bson.M{"$cond": []interface{}{"$is_surrendered", 1, 0}}},
+			},
+		},
+		{"$sort": bson.M{"_id": 1}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []bson.M
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	summary := &model.CustomerPolicySummary{ByCurrency: []model.CustomerCurrencySummary{}}
+	for _, result := range results {
+		currency, _ := result["_id"].(string)
+		item := model.CustomerCurrencySummary{
+			Currency:     currency,
+			PolicyCount:  getInt64FromInterface(result["policy_count"]),
+			TotalPremium: getFloat64FromInterface(result["total_premium"]),
+			TotalAUM:     getFloat64FromInterface(result["total_aum"]),
+		}
+		summary.ByCurrency = append(summary.ByCurrency, item)
+		summary.PolicyCount += item.PolicyCount
+		summary.TotalPremium += item.TotalPremium
+		summary.TotalAUM += item.TotalAUM
+		summary.SurrenderedCount += getInt64FromInterface(result["surrendered_count"])
+	}
+
+	return summary, nil
+}
+
+// ListUnlinkedPolicyCustomers 按客户号汇总公司中尚未关联客户档案的保单（取最近一张保单的客户姓名）；
+// 客户号及姓名可能已加密，解密后再汇总
+func (r *CustomerRepository) ListUnlinkedPolicyCustomers(ctx context.Context, companyID string) ([]model.Customer, error) {
+	collection := r.db.Collection(PolicyCollection)
+
+	filter := bson.M{
+		"company_id":      companyID,
+		"customer_id":     bson.M{"$in": []interface{}{nil, ""}},
+		"customer_number": bson.M{"$nin": []interface{}{nil, ""}},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetProjection(bson.M{"customer_number": 1, "customer_name_cn": 1, "customer_name_en": 1, "encryption": 1})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	policies, err := decodePolicies(ctx, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(policies))
+	customers := make([]model.Customer, 0, len(policies))
+	for _, policy := range policies {
+		if seen[policy.CustomerNumber] {
+			continue
+		}
+		seen[policy.CustomerNumber] = true
+		customers = append(customers, model.Customer{
+			CustomerNumber: policy.CustomerNumber,
+			CustomerNameCN: policy.CustomerNameCN,
+			CustomerNameEN: policy.CustomerNameEN,
+			CompanyID:      companyID,
+		})
+	}
+	sort.Slice(customers, func(i, j int) bool {
+		return customers[i].CustomerNumber < customers[j].CustomerNumber
+	})
+
+	return customers, nil
+}
+
+// FindUnlinkedPoliciesByNumbers 获取公司中这些客户号下尚未关联客户档案的保单
+func (r *CustomerRepository) FindUnlinkedPoliciesByNumbers(ctx context.Context, customerNumbers []string, companyID string) ([]model.Policy, error) {
+	collection := r.db.Collection(PolicyCollection)
+
+	filter := encryptedFieldIn("customer_number", customerNumbers)
+	filter["company_id"] = companyID
+	filter["customer_id"] = bson.M{"$in": []interface{}{nil, ""}}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	return decodePolicies(ctx, cursor)
+}
+
+// ListCustomerAccountNumbers 获取公司中各客户关联保单的账户号（客户ID -> 去重后的账户号）；
+// 账户号可能已加密，解密后再去重
+func (r *CustomerRepository) ListCustomerAccountNumbers(ctx context.Context, companyID string) (map[string][]string, error) {
+	collection := r.db.Collection(PolicyCollection)
+
+	filter := bson.M{
+		"company_id":     companyID,
+		"customer_id":    bson.M{"$nin": []interface{}{nil, ""}},
+		"account_number": bson.M{"$nin": []interface{}{nil, ""}},
+	}
+	opts := options.Find().SetProjection(bson.M{"customer_id": 1, "account_number": 1, "encryption": 1})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	policies, err := decodePolicies(ctx, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make(map[string][]string)
+	seen := make(map[string]bool, len(policies))
+	for _, policy := range policies {
+		key := policy.CustomerID + "\x00" + policy.AccountNumber
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		accounts[policy.CustomerID] = append(accounts[policy.CustomerID], policy.AccountNumber)
+	}
+	return accounts, nil
+}
+
+// ==========================
+// 疑似重复客户
+// ==========================
+
+// ListCustomerDuplicates 获取公司的全部疑似重复记录
+func (r *CustomerRepository) ListCustomerDuplicates(ctx context.Context, companyID string) ([]model.CustomerDuplicate, error) {
+	collection := r.db.Collection(CustomerDuplicateCollection)
+
+	cursor, err := collection.Find(ctx, bson.M{"company_id": companyID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	duplicates := []model.CustomerDuplicate{}
+	if err = cursor.All(ctx, &duplicates); err != nil {
+		return nil, err
+	}
+
+	return duplicates, nil
+}
+
+// GetCustomerDuplicateByID 根据ID获取疑似重复记录
+func (r *CustomerRepository) GetCustomerDuplicateByID(ctx context.Context, duplicateID string) (*model.CustomerDuplicate, error) {
+	collection := r.db.Collection(CustomerDuplicateCollection)
+
+	var duplicate model.CustomerDuplicate
+	err := collection.FindOne(ctx, bson.M{"duplicate_id": duplicateID}).Decode(&duplicate)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &duplicate, nil
+}
+
+// CreateCustomerDuplicate 创建疑似重复记录
+func (r *CustomerRepository) CreateCustomerDuplicate(ctx context.Context, duplicate *model.CustomerDuplicate) error {
+	collection := r.db.Collection(CustomerDuplicateCollection)
+
+	duplicate.DuplicateID = utils.GenerateID("DUP")
+	duplicate.CreatedAt = time.Now()
+	duplicate.UpdatedAt = time.Now()
+
+	_, err := collection.InsertOne(ctx, duplicate)
+	return err
+}
+
+// UpdateCustomerDuplicate 更新疑似重复记录
+func (r *CustomerRepository) UpdateCustomerDuplicate(ctx context.Context, duplicateID string, updates bson.M) error {
+	collection := r.db.Collection(CustomerDuplicateCollection)
+
+	updates["updated_at"] = time.Now()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"duplicate_id": duplicateID}, bson.M{"$set": updates})
+	return err
+}
+
+// DeletePendingCustomerDuplicates 删除公司中不在保留列表内的待审核记录（重新扫描后已不再相似的客户对）
+func (r *CustomerRepository) DeletePendingCustomerDuplicates(ctx context.Context, companyID string, keepIDs []string) (int64, error) {
+	collection := r.db.Collection(CustomerDuplicateCollection)
+
+	filter := bson.M{
+		"company_id":   companyID,
+		"status":       model.CustomerDuplicatePending,
+		"duplicate_id": bson.M{"$nin": keepIDs},
+	}
+	result, err := collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// ListCustomerDuplicatesPage 分页查询疑似重复记录，按得分倒序
+func (r *CustomerRepository) ListCustomerDuplicatesPage(ctx context.Context, req *model.CustomerDuplicateQueryRequest, companyID string) ([]model.CustomerDuplicate, int64, error) {
+	collection := r.db.Collection(CustomerDuplicateCollection)
+
+	filter := bson.M{"company_id": companyID, "status": req.Status}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "score", Value: -1}, {Key: "created_at", Value: 1}}).
+		SetSkip(int64((req.Page - 1) * req.PageSize)).
+		SetLimit(int64(req.PageSize))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	duplicates := []model.CustomerDuplicate{}
+	if err = cursor.All(ctx, &duplicates); err != nil {
+		return nil, 0, err
+	}
+
+	return duplicates, total, nil
+}
+
+// ResolveMergedCustomerDuplicates 合并客户后更新疑似重复记录：参与合并的客户之间的记录标记为已合并，
+// 被合并客户与其他客户之间的待审核记录删除（重新扫描时将以保留客户重新比对）
+func (r *CustomerRepository) ResolveMergedCustomerDuplicates(ctx context.Context, companyID string, survivorID string, mergedIDs []string, userID string) error {
+	collection := r.db.Collection(CustomerDuplicateCollection)
+
+	involved := append([]string{survivorID}, mergedIDs...)
+	now := time.Now()
+	_, err := collection.UpdateMany(ctx, bson.M{
+		"company_id":    companyID,
+		"customer_a_id": bson.M{"$in": involved},
+		"customer_b_id": bson.M{"$in": involved},
+	}, bson.M{"$set": bson.M{
+		"status":      model.CustomerDuplicateMerged,
+		"reviewed_by": userID,
+		"reviewed_at": now,
+		"updated_at":  now,
+	}})
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.DeleteMany(ctx, bson.M{
+		"company_id": companyID,
+		"status":     model.CustomerDuplicatePending,
+		"$or": []bson.M{
+			{"customer_a_id": bson.M{"$in": mergedIDs}},
+			{"customer_b_id": bson.M{"$in": mergedIDs}},
+		},
+	})
+	return err
+}
@@ -0,0 +1,537 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"YufungProject/internal/model"
+	"YufungProject/pkg/fieldcrypt"
+)
+
+// fieldCodec 字段加密编解码器，为nil时不加密，敏感字段按明文读写
+var fieldCodec *fieldcrypt.Codec
+
+// SetFieldCodec 设置字段加密编解码器，须在创建仓库前调用
+func SetFieldCodec(codec *fieldcrypt.Codec) {
+	fieldCodec = codec
+}
+
+// FieldEncryptionEnabled 是否启用了字段加密
+func FieldEncryptionEnabled() bool {
+	return fieldCodec != nil
+}
+
+// 加密字段（数据库字段名），启用加密后这些字段只能按盲索引精确查询
+var (
+	PolicyEncryptedFields   = []string{"account_number", "customer_number", "customer_name_cn", "customer_name_en"}
+	UserEncryptedFields     = []string{"email", "phone"}
+	CustomerEncryptedFields = []string{"customer_number", "customer_name_cn", "customer_name_en", "id_number", "phone", "email", "address"}
+)
+
+// errFieldEncryptionDisabled 读取到已加密的文档但未启用字段加密
+var errFieldEncryptionDisabled = errors.New("文档字段已加密，但未启用字段加密")
+
+// encryptionEnvelope 文档的加密信封：由主密钥包装的数据密钥
+type encryptionEnvelope struct {
+	KeyID      string `bson:"key_id"`      // 包装数据密钥的主密钥ID
+	WrappedKey []byte `bson:"wrapped_key"` // 包装后的数据密钥
+}
+
+// storedPolicy 保单的存储格式，在模型之外附带加密信封和盲索引
+type storedPolicy struct {
+	model.Policy `bson:",inline"`
+	Encryption   *encryptionEnvelope `bson:"encryption,omitempty"`  // 加密信封，未加密的旧文档为空
+	BlindIndex   map[string]string   `bson:"blind_index,omitempty"` // 加密字段的盲索引（字段名 -> HMAC）
+}
+
+// storedUser 用户的存储格式，在模型之外附带加密信封和盲索引
+type storedUser struct {
+	model.User `bson:",inline"`
+	Encryption *encryptionEnvelope `bson:"encryption,omitempty"`  // 加密信封，未加密的旧文档为空
+	BlindIndex map[string]string   `bson:"blind_index,omitempty"` // 加密字段的盲索引（字段名 -> HMAC）
+}
+
+// storedCustomer 客户档案的存储格式，在模型之外附带加密信封和盲索引
+type storedCustomer struct {
+	model.Customer `bson:",inline"`
+	Encryption     *encryptionEnvelope `bson:"encryption,omitempty"`  // 加密信封，未加密的旧文档为空
+	BlindIndex     map[string]string   `bson:"blind_index,omitempty"` // 加密字段的盲索引（字段名 -> HMAC）
+}
+
+// encryptedField 加密字段：数据库字段名及模型中对应的值
+type encryptedField struct {
+	name  string
+	value *string
+}
+
+// policyFields 保单的加密字段
+func policyFields(policy *model.Policy) []encryptedField {
+	return []encryptedField{
+		{name: "account_number", value: &policy.AccountNumber},
+		{name: "customer_number", value: &policy.CustomerNumber},
+		{name: "customer_name_cn", value: &policy.CustomerNameCN},
+		{name: "customer_name_en", value: &policy.CustomerNameEN},
+	}
+}
+
+// userFields 用户的加密字段
+func userFields(user *model.User) []encryptedField {
+	return []encryptedField{
+		{name: "email", value: &user.Email},
+		{name: "phone", value: &user.Phone},
+	}
+}
+
+// customerFields 客户档案的加密字段
+func customerFields(customer *model.Customer) []encryptedField {
+	return []encryptedField{
+		{name: "customer_number", value: &customer.CustomerNumber},
+		{name: "customer_name_cn", value: &customer.CustomerNameCN},
+		{name: "customer_name_en", value: &customer.CustomerNameEN},
+		{name: "id_number", value: &customer.IDNumber},
+		{name: "phone", value: &customer.Phone},
+		{name: "email", value: &customer.Email},
+		{name: "address", value: &customer.Address},
+	}
+}
+
+// sealFields 为新文档生成数据密钥，原地加密字段并计算盲索引；未启用加密时不做处理
+func sealFields(ctx context.Context, fields []encryptedField) (*encryptionEnvelope, map[string]string, error) {
+	if fieldCodec == nil {
+		return nil, nil, nil
+	}
+
+	dataKey, keyID, wrappedKey, err := fieldCodec.NewDataKey(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blindIndex := make(map[string]string, len(fields))
+	for _, field := range fields {
+		if index := fieldCodec.BlindIndex(field.name, *field.value); index != "" {
+			blindIndex[field.name] = index
+		}
+		encrypted, err := fieldcrypt.Encrypt(dataKey, field.name, *field.value)
+		if err != nil {
+			return nil, nil, err
+		}
+		*field.value = encrypted
+	}
+
+	return &encryptionEnvelope{KeyID: keyID, WrappedKey: wrappedKey}, blindIndex, nil
+}
+
+// openFields 原地解密文档的字段，没有加密信封的文档原样返回
+func openFields(ctx context.Context, envelope *encryptionEnvelope, fields []encryptedField) error {
+	if envelope == nil {
+		return nil
+	}
+	if fieldCodec == nil {
+		return errFieldEncryptionDisabled
+	}
+
+	dataKey, err := fieldCodec.UnwrapDataKey(ctx, envelope.KeyID, envelope.WrappedKey)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		plaintext, err := fieldcrypt.Decrypt(dataKey, field.name, *field.value)
+		if err != nil {
+			return err
+		}
+		*field.value = plaintext
+	}
+	return nil
+}
+
+// sealPolicy 返回保单的加密存储格式，不修改传入的保单
+func sealPolicy(ctx context.Context, policy *model.Policy) (*storedPolicy, error) {
+	stored := &storedPolicy{Policy: *policy}
+	envelope, blindIndex, err := sealFields(ctx, policyFields(&stored.Policy))
+	if err != nil {
+		return nil, err
+	}
+	stored.Encryption = envelope
+	stored.BlindIndex = blindIndex
+	return stored, nil
+}
+
+// sealUser 返回用户的加密存储格式，不修改传入的用户
+func sealUser(ctx context.Context, user *model.User) (*storedUser, error) {
+	stored := &storedUser{User: *user}
+	envelope, blindIndex, err := sealFields(ctx, userFields(&stored.User))
+	if err != nil {
+		return nil, err
+	}
+	stored.Encryption = envelope
+	stored.BlindIndex = blindIndex
+	return stored, nil
+}
+
+// sealCustomer 返回客户档案的加密存储格式，不修改传入的客户档案
+func sealCustomer(ctx context.Context, customer *model.Customer) (*storedCustomer, error) {
+	stored := &storedCustomer{Customer: *customer}
+	envelope, blindIndex, err := sealFields(ctx, customerFields(&stored.Customer))
+	if err != nil {
+		return nil, err
+	}
+	stored.Encryption = envelope
+	stored.BlindIndex = blindIndex
+	return stored, nil
+}
+
+// decodePolicies 读取游标中的保单并解密
+func decodePolicies(ctx context.Context, cursor *mongo.Cursor) ([]model.Policy, error) {
+	var stored []storedPolicy
+	if err := cursor.All(ctx, &stored); err != nil {
+		return nil, err
+	}
+
+	policies := make([]model.Policy, 0, len(stored))
+	for i := range stored {
+		if err := openFields(ctx, stored[i].Encryption, policyFields(&stored[i].Policy)); err != nil {
+			return nil, err
+		}
+		policies = append(policies, stored[i].Policy)
+	}
+	return policies, nil
+}
+
+// decodePolicy 读取单个保单并解密
+func decodePolicy(ctx context.Context, result *mongo.SingleResult) (*model.Policy, error) {
+	var stored storedPolicy
+	if err := result.Decode(&stored); err != nil {
+		return nil, err
+	}
+	if err := openFields(ctx, stored.Encryption, policyFields(&stored.Policy)); err != nil {
+		return nil, err
+	}
+	return &stored.Policy, nil
+}
+
+// decodeUsers 读取游标中的用户并解密
+func decodeUsers(ctx context.Context, cursor *mongo.Cursor) ([]*model.User, error) {
+	var stored []storedUser
+	if err := cursor.All(ctx, &stored); err != nil {
+		return nil, err
+	}
+
+	users := make([]*model.User, 0, len(stored))
+	for i := range stored {
+		if err := openFields(ctx, stored[i].Encryption, userFields(&stored[i].User)); err != nil {
+			return nil, err
+		}
+		users = append(users, &stored[i].User)
+	}
+	return users, nil
+}
+
+// decodeUser 读取单个用户并解密
+func decodeUser(ctx context.Context, result *mongo.SingleResult) (*model.User, error) {
+	var stored storedUser
+	if err := result.Decode(&stored); err != nil {
+		return nil, err
+	}
+	if err := openFields(ctx, stored.Encryption, userFields(&stored.User)); err != nil {
+		return nil, err
+	}
+	return &stored.User, nil
+}
+
+// decodeCustomers 读取游标中的客户档案并解密
+func decodeCustomers(ctx context.Context, cursor *mongo.Cursor) ([]model.Customer, error) {
+	var stored []storedCustomer
+	if err := cursor.All(ctx, &stored); err != nil {
+		return nil, err
+	}
+
+	customers := make([]model.Customer, 0, len(stored))
+	for i := range stored {
+		if err := openFields(ctx, stored[i].Encryption, customerFields(&stored[i].Customer)); err != nil {
+			return nil, err
+		}
+		customers = append(customers, stored[i].Customer)
+	}
+	return customers, nil
+}
+
+// decodeCustomer 读取单个客户档案并解密
+func decodeCustomer(ctx context.Context, result *mongo.SingleResult) (*model.Customer, error) {
+	var stored storedCustomer
+	if err := result.Decode(&stored); err != nil {
+		return nil, err
+	}
+	if err := openFields(ctx, stored.Encryption, customerFields(&stored.Customer)); err != nil {
+		return nil, err
+	}
+	return &stored.Customer, nil
+}
+
+// sealUpdates 加密更新内容中的加密字段并同步更新盲索引，返回新的更新内容，不修改传入的 updates；
+// 文档还没有加密信封时先为其生成数据密钥
+func sealUpdates(ctx context.Context, collection *mongo.Collection, filter bson.M, updates bson.M, fields []string) (bson.M, error) {
+	if fieldCodec == nil || !containsAnyField(updates, fields) {
+		return updates, nil
+	}
+
+	dataKey, err := documentDataKey(ctx, collection, filter)
+	if err != nil || dataKey == nil {
+		return updates, err
+	}
+
+	sealed := make(bson.M, len(updates)+len(fields))
+	for key, value := range updates {
+		sealed[key] = value
+	}
+	for _, field := range fields {
+		value, ok := updates[field].(string)
+		if !ok {
+			continue
+		}
+		encrypted, err := fieldcrypt.Encrypt(dataKey, field, value)
+		if err != nil {
+			return nil, err
+		}
+		sealed[field] = encrypted
+		if index := fieldCodec.BlindIndex(field, value); index != "" {
+			sealed["blind_index."+field] = index
+		} else {
+			sealed["blind_index."+field] = nil
+		}
+	}
+	return sealed, nil
+}
+
+// documentDataKey 获取文档的数据密钥，文档没有加密信封时为其生成；文档不存在时返回nil
+func documentDataKey(ctx context.Context, collection *mongo.Collection, filter bson.M) ([]byte, error) {
+	envelope, found, err := findEnvelope(ctx, collection, filter)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	if envelope == nil {
+		_, keyID, wrappedKey, err := fieldCodec.NewDataKey(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// 只为仍没有信封的文档写入，并发写入时以先写入的信封为准
+		conditional := bson.M{"encryption": bson.M{"$exists": false}}
+		for key, value := range filter {
+			conditional[key] = value
+		}
+		_, err = collection.UpdateOne(ctx, conditional, bson.M{"$set": bson.M{
+			"encryption": encryptionEnvelope{KeyID: keyID, WrappedKey: wrappedKey},
+		}})
+		if err != nil {
+			return nil, err
+		}
+		if envelope, found, err = findEnvelope(ctx, collection, filter); err != nil || !found {
+			return nil, err
+		}
+		if envelope == nil {
+			return nil, errors.New("写入加密信封失败")
+		}
+	}
+
+	return fieldCodec.UnwrapDataKey(ctx, envelope.KeyID, envelope.WrappedKey)
+}
+
+// findEnvelope 读取文档的加密信封
+func findEnvelope(ctx context.Context, collection *mongo.Collection, filter bson.M) (*encryptionEnvelope, bool, error) {
+	var doc struct {
+		Encryption *encryptionEnvelope `bson:"encryption"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"encryption": 1})
+	if err := collection.FindOne(ctx, filter, opts).Decode(&doc); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return doc.Encryption, true, nil
+}
+
+// containsAnyField 更新内容是否包含任一加密字段
+func containsAnyField(updates bson.M, fields []string) bool {
+	for _, field := range fields {
+		if _, ok := updates[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// encryptedFieldMatch 加密字段的精确查询条件：启用加密时按盲索引匹配，否则按原值匹配
+func encryptedFieldMatch(field, value string) bson.M {
+	if fieldCodec == nil {
+		return bson.M{field: value}
+	}
+	return bson.M{"blind_index." + field: fieldCodec.BlindIndex(field, value)}
+}
+
+// encryptedFieldIn 加密字段取值在列表中的查询条件
+func encryptedFieldIn(field string, values []string) bson.M {
+	if fieldCodec == nil {
+		return bson.M{field: bson.M{"$in": values}}
+	}
+	indexes := make([]string, 0, len(values))
+	for _, value := range values {
+		indexes = append(indexes, fieldCodec.BlindIndex(field, value))
+	}
+	return bson.M{"blind_index." + field: bson.M{"$in": indexes}}
+}
+
+// encryptedFieldSearch 加密字段的关键字搜索条件：未启用加密时模糊匹配，启用后只能精确匹配
+func encryptedFieldSearch(field, keyword string) bson.M {
+	if fieldCodec == nil {
+		return bson.M{field: bson.M{"$regex": regexp.QuoteMeta(keyword), "$options": "i"}}
+	}
+	return bson.M{"blind_index." + field: fieldCodec.BlindIndex(field, keyword)}
+}
+
+// setEncryptedFieldSearch 为查询条件添加加密字段的搜索：未启用加密时模糊匹配，启用后只能精确匹配
+func setEncryptedFieldSearch(filter bson.M, field, value string) {
+	for key, condition := range encryptedFieldSearch(field, value) {
+		filter[key] = condition
+	}
+}
+
+// ReencryptResult 重新加密的统计结果
+type ReencryptResult struct {
+	Scanned   int64 // 扫描的文档数
+	Encrypted int64 // 加密了明文字段的文档数
+	Rewrapped int64 // 改用当前主密钥包装数据密钥的文档数
+	Reindexed int64 // 更新了盲索引的文档数
+	Skipped   int64 // 处理期间被其他请求修改而跳过的文档数，重新执行即可
+}
+
+// ReencryptCollection 重新加密集合中的文档：加密尚为明文的字段，将非当前主密钥包装的数据密钥改用当前主密钥包装，
+// 并重建与当前盲索引密钥不一致的盲索引；字段密文不变，dryRun 时只统计不写入
+func ReencryptCollection(ctx context.Context, db *mongo.Database, collectionName string, fields []string, dryRun bool) (*ReencryptResult, error) {
+	if fieldCodec == nil {
+		return nil, errors.New("未启用字段加密")
+	}
+	collection := db.Collection(collectionName)
+
+	projection := bson.M{"encryption": 1, "blind_index": 1}
+	for _, field := range fields {
+		projection[field] = 1
+	}
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	result := &ReencryptResult{}
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID         interface{}         `bson:"_id"`
+			Encryption *encryptionEnvelope `bson:"encryption"`
+			BlindIndex map[string]string   `bson:"blind_index"`
+			Values     bson.M              `bson:",inline"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return result, err
+		}
+		result.Scanned++
+
+		updates, changes, err := reencryptDocument(ctx, doc.Encryption, doc.BlindIndex, doc.Values, fields)
+		if err != nil {
+			return result, fmt.Errorf("重新加密文档 %v 失败: %w", doc.ID, err)
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		if !dryRun {
+			// 以读取时的信封和字段值为条件，期间被修改过的文档跳过，避免覆盖新数据
+			filter := bson.M{"_id": doc.ID}
+			if doc.Encryption != nil {
+				filter["encryption.wrapped_key"] = doc.Encryption.WrappedKey
+			} else {
+				filter["encryption"] = bson.M{"$exists": false}
+			}
+			for _, field := range fields {
+				filter[field] = doc.Values[field]
+			}
+			updated, err := collection.UpdateOne(ctx, filter, bson.M{"$set": updates})
+			if err != nil {
+				return result, err
+			}
+			if updated.MatchedCount == 0 {
+				result.Skipped++
+				continue
+			}
+		}
+		result.Encrypted += changes.Encrypted
+		result.Rewrapped += changes.Rewrapped
+		result.Reindexed += changes.Reindexed
+	}
+
+	return result, cursor.Err()
+}
+
+// reencryptDocument 计算单个文档重新加密需要写入的内容，changes 中各项为0或1
+func reencryptDocument(ctx context.Context, envelope *encryptionEnvelope, blindIndex map[string]string, values bson.M, fields []string) (bson.M, ReencryptResult, error) {
+	var changes ReencryptResult
+	updates := bson.M{}
+
+	var dataKey []byte
+	var err error
+	if envelope == nil {
+		var keyID string
+		var wrappedKey []byte
+		if dataKey, keyID, wrappedKey, err = fieldCodec.NewDataKey(ctx); err != nil {
+			return nil, changes, err
+		}
+		updates["encryption"] = encryptionEnvelope{KeyID: keyID, WrappedKey: wrappedKey}
+	} else {
+		if dataKey, err = fieldCodec.UnwrapDataKey(ctx, envelope.KeyID, envelope.WrappedKey); err != nil {
+			return nil, changes, err
+		}
+		if envelope.KeyID != fieldCodec.ActiveKeyID() {
+			keyID, wrappedKey, err := fieldCodec.RewrapDataKey(ctx, dataKey)
+			if err != nil {
+				return nil, changes, err
+			}
+			updates["encryption"] = encryptionEnvelope{KeyID: keyID, WrappedKey: wrappedKey}
+			changes.Rewrapped = 1
+		}
+	}
+
+	for _, field := range fields {
+		value, _ := values[field].(string)
+		plaintext, err := fieldcrypt.Decrypt(dataKey, field, value)
+		if err != nil {
+			return nil, changes, err
+		}
+		if plaintext != "" && !fieldcrypt.IsEncrypted(value) {
+			encrypted, err := fieldcrypt.Encrypt(dataKey, field, plaintext)
+			if err != nil {
+				return nil, changes, err
+			}
+			updates[field] = encrypted
+			changes.Encrypted = 1
+		}
+		if index := fieldCodec.BlindIndex(field, plaintext); index != blindIndex[field] {
+			if index != "" {
+				updates["blind_index."+field] = index
+			} else {
+				updates["blind_index."+field] = nil
+			}
+			changes.Reindexed = 1
+		}
+	}
+
+	// 没有需要加密或重建索引的字段时，新生成的信封无需写入
+	if envelope == nil && changes.Encrypted == 0 && changes.Reindexed == 0 {
+		return nil, changes, nil
+	}
+	return updates, changes, nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"YufungProject/internal/model"
+)
+
+const ImpersonationSessionCollection = "impersonation_sessions"
+
+type ImpersonationRepository struct {
+	db *mongo.Database
+}
+
+func NewImpersonationRepository(db *mongo.Database) *ImpersonationRepository {
+	return &ImpersonationRepository{db: db}
+}
+
+// Create 保存模拟登录会话
+func (r *ImpersonationRepository) Create(ctx context.Context, session *model.ImpersonationSession) error {
+	collection := r.db.Collection(ImpersonationSessionCollection)
+	_, err := collection.InsertOne(ctx, session)
+	return err
+}
+
+// GetBySessionID 根据会话ID获取模拟登录会话；不存在时返回nil
+func (r *ImpersonationRepository) GetBySessionID(ctx context.Context, sessionID string) (*model.ImpersonationSession, error) {
+	collection := r.db.Collection(ImpersonationSessionCollection)
+
+	var session model.ImpersonationSession
+	err := collection.FindOne(ctx, bson.M{"session_id": sessionID}).Decode(&session)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// End 结束进行中的模拟登录会话；会话不存在或已结束时返回nil
+func (r *ImpersonationRepository) End(ctx context.Context, sessionID, endedBy string, now time.Time) (*model.ImpersonationSession, error) {
+	collection := r.db.Collection(ImpersonationSessionCollection)
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var session model.ImpersonationSession
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"session_id": sessionID, "status": model.ImpersonationStatusActive},
+		bson.M{"$set": bson.M{"status": model.ImpersonationStatusEnded, "ended_at": now, "ended_by": endedBy}},
+		opts,
+	).Decode(&session)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// RecordBlockedWrite 记录模拟登录期间被拦截的写操作
+func (r *ImpersonationRepository) RecordBlockedWrite(ctx context.Context, sessionID, path string) error {
+	collection := r.db.Collection(ImpersonationSessionCollection)
+
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"session_id": sessionID},
+		bson.M{
+			"$inc": bson.M{"blocked_write_hits": 1},
+			"$set": bson.M{"last_blocked_path": path},
+		},
+	)
+	return err
+}
+
+// List 分页查询模拟登录会话，状态为expired时查询未主动结束且已过期的会话
+func (r *ImpersonationRepository) List(ctx context.Context, req *model.ImpersonationQueryRequest, now time.Time) ([]model.ImpersonationSession, int64, error) {
+	collection := r.db.Collection(ImpersonationSessionCollection)
+
+	filter := bson.M{}
+	switch req.Status {
+	case "":
+	case model.ImpersonationStatusActive:
+		filter["status"] = model.ImpersonationStatusActive
+		filter["expires_at"] = bson.M{"$gt": now}
+	case model.ImpersonationStatusExpired:
+		filter["status"] = model.ImpersonationStatusActive
+		filter["expires_at"] = bson.M{"$lte": now}
+	default:
+		filter["status"] = req.Status
+	}
+	if req.ActorID != "" {
+		filter["actor_id"] = req.ActorID
+	}
+	if req.TargetUserID != "" {
+		filter["target_user_id"] = req.TargetUserID
+	}
+	if req.CompanyID != "" {
+		filter["target_company_id"] = req.CompanyID
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "started_at", Value: -1}}).
+		SetSkip(int64((req.Page - 1) * req.PageSize)).
+		SetLimit(int64(req.PageSize))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	sessions := make([]model.ImpersonationSession, 0)
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, 0, err
+	}
+
+	return sessions, total, nil
+}
@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"YufungProject/internal/model"
+	"YufungProject/pkg/logger"
+)
+
+const (
+	InvitationCollection     = "invitations"
+	InvitationLockCollection = "invitation_locks"
+)
+
+type InvitationRepository struct {
+	db *mongo.Database
+}
+
+func NewInvitationRepository(db *mongo.Database) *InvitationRepository {
+	repo := &InvitationRepository{db: db}
+
+	// 接受邀请锁依赖 company_id 唯一索引实现互斥，启动时确保索引存在
+	repo.createIndexes()
+
+	return repo
+}
+
+// createIndexes 创建接受邀请锁索引，其他索引见 scripts/init-invitation-indexes.js
+func (r *InvitationRepository) createIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.db.Collection(InvitationLockCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "company_id", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("idx_company_id"),
+	})
+	if err != nil {
+		logger.Errorf("创建接受邀请锁索引失败: %v", err)
+	}
+}
+
+// Create 保存邀请
+func (r *InvitationRepository) Create(ctx context.Context, invitation *model.Invitation) error {
+	collection := r.db.Collection(InvitationCollection)
+	_, err := collection.InsertOne(ctx, invitation)
+	return err
+}
+
+// GetByInvitationID 根据邀请ID获取邀请；不存在时返回nil
+func (r *InvitationRepository) GetByInvitationID(ctx context.Context, invitationID string) (*model.Invitation, error) {
+	collection := r.db.Collection(InvitationCollection)
+
+	var invitation model.Invitation
+	err := collection.FindOne(ctx, bson.M{"invitation_id": invitationID}).Decode(&invitation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// GetValidByToken 根据令牌摘要获取待接受且未过期的邀请；没有时返回nil
+func (r *InvitationRepository) GetValidByToken(ctx context.Context, tokenHash string, now time.Time) (*model.Invitation, error) {
+	collection := r.db.Collection(InvitationCollection)
+
+	var invitation model.Invitation
+	err := collection.FindOne(ctx, validInvitationFilter(tokenHash, now)).Decode(&invitation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// ExistsPending 公司是否已有发给该邮箱且未过期的待接受邀请
+func (r *InvitationRepository) ExistsPending(ctx context.Context, companyID, email string, now time.Time) (bool, error) {
+	collection := r.db.Collection(InvitationCollection)
+
+	count, err := collection.CountDocuments(ctx, bson.M{
+		"company_id": companyID,
+		"email":      email,
+		"status":     model.InvitationStatusPending,
+		"expires_at": bson.M{"$gt": now},
+	})
+	return count > 0, err
+}
+
+// CountPending 统计公司未过期的待接受邀请数量，用于预占用户配额
+func (r *InvitationRepository) CountPending(ctx context.Context, companyID string, now time.Time) (int64, error) {
+	collection := r.db.Collection(InvitationCollection)
+	return collection.CountDocuments(ctx, bson.M{
+		"company_id": companyID,
+		"status":     model.InvitationStatusPending,
+		"expires_at": bson.M{"$gt": now},
+	})
+}
+
+// List 分页查询邀请，状态为expired时查询已过期的待接受邀请
+func (r *InvitationRepository) List(ctx context.Context, req *model.InvitationQueryRequest, now time.Time) ([]model.Invitation, int64, error) {
+	collection := r.db.Collection(InvitationCollection)
+
+	filter := bson.M{}
+	switch req.Status {
+	case "":
+	case model.InvitationStatusPending:
+		filter["status"] = model.InvitationStatusPending
+		filter["expires_at"] = bson.M{"$gt": now}
+	case model.InvitationStatusExpired:
+		filter["status"] = model.InvitationStatusPending
+		filter["expires_at"] = bson.M{"$lte": now}
+	default:
+		filter["status"] = req.Status
+	}
+	if req.Email != "" {
+		filter["email"] = bson.M{"$regex": regexp.QuoteMeta(req.Email), "$options": "i"}
+	}
+	if req.CompanyID != "" {
+		filter["company_id"] = req.CompanyID
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((req.Page - 1) * req.PageSize)).
+		SetLimit(int64(req.PageSize))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	invitations := make([]model.Invitation, 0)
+	if err := cursor.All(ctx, &invitations); err != nil {
+		return nil, 0, err
+	}
+
+	return invitations, total, nil
+}
+
+// Renew 为待接受的邀请更换令牌并延长有效期（重新发送），原链接随之失效；邀请已接受或已撤销时返回nil
+func (r *InvitationRepository) Renew(ctx context.Context, invitationID, tokenHash string, expiresAt, now time.Time) (*model.Invitation, error) {
+	collection := r.db.Collection(InvitationCollection)
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var invitation model.Invitation
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"invitation_id": invitationID, "status": model.InvitationStatusPending},
+		bson.M{
+			"$set": bson.M{"token_hash": tokenHash, "expires_at": expiresAt, "last_sent_at": now, "updated_at": now},
+			"$inc": bson.M{"send_count": 1},
+		},
+		opts,
+	).Decode(&invitation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// Revoke 撤销待接受的邀请；邀请已接受或已撤销时返回false
+func (r *InvitationRepository) Revoke(ctx context.Context, invitationID, revokedBy string, now time.Time) (bool, error) {
+	collection := r.db.Collection(InvitationCollection)
+
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"invitation_id": invitationID, "status": model.InvitationStatusPending},
+		bson.M{"$set": bson.M{
+			"status":     model.InvitationStatusRevoked,
+			"revoked_at": now,
+			"revoked_by": revokedBy,
+			"updated_at": now,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// Consume 原子地将邀请标记为已接受，并发提交时只有一个请求能成功；邀请无效时返回nil
+func (r *InvitationRepository) Consume(ctx context.Context, tokenHash, userID string, now time.Time) (*model.Invitation, error) {
+	collection := r.db.Collection(InvitationCollection)
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var invitation model.Invitation
+	err := collection.FindOneAndUpdate(ctx,
+		validInvitationFilter(tokenHash, now),
+		bson.M{"$set": bson.M{
+			"status":           model.InvitationStatusAccepted,
+			"accepted_at":      now,
+			"accepted_user_id": userID,
+			"updated_at":       now,
+		}},
+		opts,
+	).Decode(&invitation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// Restore 创建用户失败时将已接受的邀请恢复为待接受
+func (r *InvitationRepository) Restore(ctx context.Context, invitationID string, now time.Time) error {
+	collection := r.db.Collection(InvitationCollection)
+
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"invitation_id": invitationID, "status": model.InvitationStatusAccepted},
+		bson.M{
+			"$set":   bson.M{"status": model.InvitationStatusPending, "updated_at": now},
+			"$unset": bson.M{"accepted_at": "", "accepted_user_id": ""},
+		},
+	)
+	return err
+}
+
+// AcquireCompanyLock 获取公司级的接受邀请锁，使同一公司的配额检查与创建用户串行执行；
+// 锁已被占用时返回false，锁在 ttl 后自动失效，防止进程异常退出后无法释放
+func (r *InvitationRepository) AcquireCompanyLock(ctx context.Context, companyID string, now time.Time, ttl time.Duration) (bool, error) {
+	collection := r.db.Collection(InvitationLockCollection)
+
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"company_id": companyID, "locked_until": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"locked_until": now.Add(ttl)}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		// 锁未过期时条件不匹配，upsert 插入会与 company_id 唯一索引冲突
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ReleaseCompanyLock 释放公司级的接受邀请锁
+func (r *InvitationRepository) ReleaseCompanyLock(ctx context.Context, companyID string) error {
+	collection := r.db.Collection(InvitationLockCollection)
+
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"company_id": companyID},
+		bson.M{"$set": bson.M{"locked_until": time.Time{}}},
+	)
+	return err
+}
+
+// validInvitationFilter 待接受且未过期的邀请
+func validInvitationFilter(tokenHash string, now time.Time) bson.M {
+	return bson.M{
+		"token_hash": tokenHash,
+		"status":     model.InvitationStatusPending,
+		"expires_at": bson.M{"$gt": now},
+	}
+}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"YufungProject/internal/model"
+	"YufungProject/pkg/utils"
+)
+
+const MailOutboxCollection = "mail_outbox"
+
+type MailRepository struct {
+	db *mongo.Database
+}
+
+func NewMailRepository(db *mongo.Database) *MailRepository {
+	return &MailRepository{db: db}
+}
+
+// CreateMessage 邮件入队
+func (r *MailRepository) CreateMessage(ctx context.Context, message *model.MailMessage) error {
+	collection := r.db.Collection(MailOutboxCollection)
+
+	message.MessageID = utils.GenerateID("MAIL")
+	message.CreatedAt = time.Now()
+	message.UpdatedAt = message.CreatedAt
+
+	_, err := collection.InsertOne(ctx, message)
+	return err
+}
+
+// GetMessageByID 根据ID获取邮件
+func (r *MailRepository) GetMessageByID(ctx context.Context, messageID string) (*model.MailMessage, error) {
+	collection := r.db.Collection(MailOutboxCollection)
+
+	var message model.MailMessage
+	err := collection.FindOne(ctx, bson.M{"message_id": messageID}).Decode(&message)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// ClaimDueMessage 领取一封到期待发送的邮件并标记为发送中，发送中但租约已过期的邮件（进程中断）也会被重新领取；没有时返回nil
+func (r *MailRepository) ClaimDueMessage(ctx context.Context, now time.Time, lease time.Duration) (*model.MailMessage, error) {
+	collection := r.db.Collection(MailOutboxCollection)
+
+	filter := bson.M{"$or": []bson.M{
+		{"status": model.MailStatusPending, "next_attempt_at": bson.M{"$lte": now}},
+		{"status": model.MailStatusSending, "locked_until": bson.M{"$lt": now}},
+	}}
+	update := bson.M{
+		"$set": bson.M{"status": model.MailStatusSending, "locked_until": now.Add(lease), "updated_at": now},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var message model.MailMessage
+	err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&message)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// MarkSent 标记邮件发送成功，purgeBody为true时清除正文
+func (r *MailRepository) MarkSent(ctx context.Context, messageID string, sentAt time.Time, purgeBody bool) error {
+	collection := r.db.Collection(MailOutboxCollection)
+
+	update := bson.M{
+		"$set":   bson.M{"status": model.MailStatusSent, "sent_at": sentAt, "last_error": "", "updated_at": time.Now()},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	if purgeBody {
+		update["$unset"] = bson.M{"locked_until": "", "text_body": "", "html_body": ""}
+	}
+
+	_, err := collection.UpdateOne(ctx, bson.M{"message_id": messageID}, update)
+	return err
+}
+
+// MarkRetry 发送失败，等待下次重试
+func (r *MailRepository) MarkRetry(ctx context.Context, messageID string, nextAttemptAt time.Time, lastError string) error {
+	collection := r.db.Collection(MailOutboxCollection)
+
+	update := bson.M{
+		"$set":   bson.M{"status": model.MailStatusPending, "next_attempt_at": nextAttemptAt, "last_error": lastError, "updated_at": time.Now()},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	_, err := collection.UpdateOne(ctx, bson.M{"message_id": messageID}, update)
+	return err
+}
+
+// MarkFailed 标记邮件最终发送失败
+func (r *MailRepository) MarkFailed(ctx context.Context, messageID string, lastError string) error {
+	collection := r.db.Collection(MailOutboxCollection)
+
+	update := bson.M{
+		"$set":   bson.M{"status": model.MailStatusFailed, "last_error": lastError, "updated_at": time.Now()},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	_, err := collection.UpdateOne(ctx, bson.M{"message_id": messageID}, update)
+	return err
+}
+
+// RequeueFailedMessage 将发送失败的邮件重新放入发件箱并重置发送次数；邮件不是失败状态时返回false
+func (r *MailRepository) RequeueFailedMessage(ctx context.Context, messageID string, now time.Time) (bool, error) {
+	collection := r.db.Collection(MailOutboxCollection)
+
+	filter := bson.M{"message_id": messageID, "status": model.MailStatusFailed}
+	update := bson.M{"$set": bson.M{
+		"status":          model.MailStatusPending,
+		"attempts":        0,
+		"next_attempt_at": now,
+		"updated_at":      now,
+	}}
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// ListMessages 分页查询发件箱，按创建时间倒序，不返回正文
+func (r *MailRepository) ListMessages(ctx context.Context, req *model.MailQueryRequest) ([]model.MailMessage, int64, error) {
+	collection := r.db.Collection(MailOutboxCollection)
+
+	filter := bson.M{}
+	if req.Status != "" {
+		filter["status"] = req.Status
+	}
+	if req.Template != "" {
+		filter["template"] = req.Template
+	}
+	if req.To != "" {
+		filter["to"] = bson.M{"$regex": regexp.QuoteMeta(req.To), "$options": "i"}
+	}
+	if req.CompanyID != "" {
+		filter["company_id"] = req.CompanyID
+	}
+	createdAt := bson.M{}
+	if startDate, err := time.ParseInLocation("2006-01-02", req.StartDate, time.Local); err == nil {
+		createdAt["$gte"] = startDate
+	}
+	if endDate, err := time.ParseInLocation("2006-01-02", req.EndDate, time.Local); err == nil {
+		createdAt["$lt"] = endDate.AddDate(0, 0, 1)
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((req.Page - 1) * req.PageSize)).
+		SetLimit(int64(req.PageSize)).
+		SetProjection(bson.M{"text_body": 0, "html_body": 0})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	messages := []model.MailMessage{}
+	if err = cursor.All(ctx, &messages); err != nil {
+		return nil, 0, err
+	}
+
+	return messages, total, nil
+}
+
+// CountByStatus 统计各状态的邮件数量
+func (r *MailRepository) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	collection := r.db.Collection(MailOutboxCollection)
+
+	cursor, err := collection.Aggregate(ctx, []bson.M{
+		{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Status string `bson:"_id"`
+		Count  int64  `bson:"count"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int64{
+		model.MailStatusPending: 0,
+		model.MailStatusSending: 0,
+		model.MailStatusSent:    0,
+		model.MailStatusFailed:  0,
+	}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
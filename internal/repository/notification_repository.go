@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"YufungProject/internal/model"
+	"YufungProject/pkg/utils"
+)
+
+const (
+	NotificationCollection           = "notifications"
+	NotificationPreferenceCollection = "notification_preferences"
+)
+
+type NotificationRepository struct {
+	db *mongo.Database
+}
+
+func NewNotificationRepository(db *mongo.Database) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// ==========================
+// 通知
+// ==========================
+
+// CreateNotificationIfAbsent 创建通知，同一用户已存在相同去重键的通知时不重复创建；返回是否新建
+func (r *NotificationRepository) CreateNotificationIfAbsent(ctx context.Context, notification *model.Notification) (bool, error) {
+	collection := r.db.Collection(NotificationCollection)
+
+	notification.NotificationID = utils.GenerateID("NTF")
+	notification.CreatedAt = time.Now()
+
+	filter := bson.M{"user_id": notification.UserID, "dedup_key": notification.DedupKey}
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$setOnInsert": notification}, options.Update().SetUpsert(true))
+	if err != nil {
+		// 并发插入同一去重键时唯一索引冲突，视为已存在
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return result.UpsertedCount > 0, nil
+}
+
+// CreateNotificationsIfAbsent 批量创建通知，同一用户已存在相同去重键的通知时跳过；返回新建通知在参数中的下标
+func (r *NotificationRepository) CreateNotificationsIfAbsent(ctx context.Context, notifications []*model.Notification) ([]int, error) {
+	if len(notifications) == 0 {
+		return nil, nil
+	}
+	collection := r.db.Collection(NotificationCollection)
+
+	now := time.Now()
+	writes := make([]mongo.WriteModel, 0, len(notifications))
+	for _, notification := range notifications {
+		notification.NotificationID = utils.GenerateID("NTF")
+		notification.CreatedAt = now
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"user_id": notification.UserID, "dedup_key": notification.DedupKey}).
+			SetUpdate(bson.M{"$setOnInsert": notification}).
+			SetUpsert(true))
+	}
+
+	result, err := collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil && !onlyDuplicateKeyErrors(err) {
+		return nil, err
+	}
+	if result == nil {
+		return nil, nil
+	}
+
+	created := make([]int, 0, len(result.UpsertedIDs))
+	for index := range result.UpsertedIDs {
+		created = append(created, int(index))
+	}
+	return created, nil
+}
+
+// onlyDuplicateKeyErrors 批量写入的错误是否全部为唯一索引冲突（并发插入同一去重键），此时视为已存在
+func onlyDuplicateKeyErrors(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil || len(bulkErr.WriteErrors) == 0 {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if !mongo.IsDuplicateKeyError(writeErr) {
+			return false
+		}
+	}
+	return true
+}
+
+// ListNotifications 分页查询用户的通知，按创建时间倒序
+func (r *NotificationRepository) ListNotifications(ctx context.Context, req *model.NotificationQueryRequest, userID string) (*model.NotificationListResponse, error) {
+	collection := r.db.Collection(NotificationCollection)
+
+	filter := bson.M{"user_id": userID}
+	if req.Type != "" {
+		filter["type"] = req.Type
+	}
+	if req.IsRead != nil {
+		filter["is_read"] = *req.IsRead
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := collection.CountDocuments(ctx, bson.M{"user_id": userID, "is_read": false})
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((req.Page - 1) * req.PageSize)).
+		SetLimit(int64(req.PageSize))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	notifications := []model.Notification{}
+	if err = cursor.All(ctx, &notifications); err != nil {
+		return nil, err
+	}
+
+	return &model.NotificationListResponse{
+		List:        notifications,
+		Total:       total,
+		UnreadCount: unread,
+		Page:        req.Page,
+		PageSize:    req.PageSize,
+	}, nil
+}
+
+// CountUnreadNotifications 统计用户的未读通知数量
+func (r *NotificationRepository) CountUnreadNotifications(ctx context.Context, userID string) (int64, error) {
+	collection := r.db.Collection(NotificationCollection)
+
+	return collection.CountDocuments(ctx, bson.M{"user_id": userID, "is_read": false})
+}
+
+// MarkNotificationsRead 将用户的通知标记为已读，通知ID为空时标记全部未读通知；返回更新数量
+func (r *NotificationRepository) MarkNotificationsRead(ctx context.Context, userID string, notificationIDs []string) (int64, error) {
+	collection := r.db.Collection(NotificationCollection)
+
+	filter := bson.M{"user_id": userID, "is_read": false}
+	if len(notificationIDs) > 0 {
+		filter["notification_id"] = bson.M{"$in": notificationIDs}
+	}
+
+	result, err := collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"is_read": true, "read_at": time.Now()}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// ==========================
+// 通知偏好
+// ==========================
+
+// GetPreference 获取用户的通知偏好，未设置时返回nil
+func (r *NotificationRepository) GetPreference(ctx context.Context, userID string) (*model.NotificationPreference, error) {
+	collection := r.db.Collection(NotificationPreferenceCollection)
+
+	var preference model.NotificationPreference
+	err := collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&preference)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &preference, nil
+}
+
+// ListPreferences 获取公司中已设置通知偏好的用户（用户ID -> 偏好）
+func (r *NotificationRepository) ListPreferences(ctx context.Context, companyID string) (map[string]*model.NotificationPreference, error) {
+	collection := r.db.Collection(NotificationPreferenceCollection)
+
+	cursor, err := collection.Find(ctx, bson.M{"company_id": companyID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var preferences []model.NotificationPreference
+	if err = cursor.All(ctx, &preferences); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*model.NotificationPreference, len(preferences))
+	for i := range preferences {
+		result[preferences[i].UserID] = &preferences[i]
+	}
+	return result, nil
+}
+
+// UpsertPreference 更新用户的通知偏好，只更新传入的通知类型
+func (r *NotificationRepository) UpsertPreference(ctx context.Context, userID, companyID string, enabled map[string]bool) error {
+	collection := r.db.Collection(NotificationPreferenceCollection)
+
+	set := bson.M{"company_id": companyID, "updated_at": time.Now()}
+	for notificationType, value := range enabled {
+		set["enabled."+notificationType] = value
+	}
+
+	_, err := collection.UpdateOne(ctx, bson.M{"user_id": userID}, bson.M{"$set": set}, options.Update().SetUpsert(true))
+	return err
+}
+
+// ==========================
+// 提醒规则数据
+// ==========================
+
+// ListReminderPolicies 获取公司中未退保且填写了缴费日期或生效日期的保单
+func (r *NotificationRepository) ListReminderPolicies(ctx context.Context, companyID string) ([]model.Policy, error) {
+	collection := r.db.Collection(PolicyCollection)
+
+	filter := bson.M{
+		"company_id":     companyID,
+		"is_surrendered": bson.M{"$ne": true},
+		"$or": []bson.M{
+			{"payment_date": bson.M{"$ne": nil}},
+			{"effective_date": bson.M{"$ne": nil}},
+		},
+	}
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	return decodePolicies(ctx, cursor)
+}
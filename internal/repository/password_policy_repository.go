@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"YufungProject/internal/model"
+)
+
+const PasswordPolicyCollection = "password_policies"
+
+type PasswordPolicyRepository struct {
+	db *mongo.Database
+}
+
+func NewPasswordPolicyRepository(db *mongo.Database) *PasswordPolicyRepository {
+	return &PasswordPolicyRepository{db: db}
+}
+
+// GetPolicy 获取密码策略，companyID为空时获取平台策略；未设置时返回nil
+func (r *PasswordPolicyRepository) GetPolicy(ctx context.Context, companyID string) (*model.PasswordPolicy, error) {
+	collection := r.db.Collection(PasswordPolicyCollection)
+
+	var policy model.PasswordPolicy
+	err := collection.FindOne(ctx, bson.M{"company_id": companyID}).Decode(&policy)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// SavePolicy 保存密码策略（按公司ID覆盖）
+func (r *PasswordPolicyRepository) SavePolicy(ctx context.Context, policy *model.PasswordPolicy) error {
+	collection := r.db.Collection(PasswordPolicyCollection)
+
+	now := time.Now()
+	policy.UpdatedAt = now
+	update := bson.M{
+		"$set": bson.M{
+			"min_length":            policy.MinLength,
+			"require_digit":         policy.RequireDigit,
+			"require_lowercase":     policy.RequireLowercase,
+			"require_uppercase":     policy.RequireUppercase,
+			"require_special":       policy.RequireSpecial,
+			"min_char_classes":      policy.MinCharClasses,
+			"disallow_username":     policy.DisallowUsername,
+			"disallow_company_name": policy.DisallowCompanyName,
+			"history_count":         policy.HistoryCount,
+			"max_age_days":          policy.MaxAgeDays,
+			"updated_by":            policy.UpdatedBy,
+			"updated_at":            now,
+		},
+		"$setOnInsert": bson.M{"company_id": policy.CompanyID, "created_at": now},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	return collection.FindOneAndUpdate(ctx, bson.M{"company_id": policy.CompanyID}, update, opts).Decode(policy)
+}
+
+// DeletePolicy 删除公司密码策略，恢复使用平台策略；策略不存在时返回false
+func (r *PasswordPolicyRepository) DeletePolicy(ctx context.Context, companyID string) (bool, error) {
+	collection := r.db.Collection(PasswordPolicyCollection)
+
+	result, err := collection.DeleteOne(ctx, bson.M{"company_id": companyID})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"YufungProject/internal/model"
+)
+
+const (
+	PasswordResetTokenCollection   = "password_reset_tokens"
+	PasswordResetRequestCollection = "password_reset_requests"
+)
+
+type PasswordResetRepository struct {
+	db *mongo.Database
+}
+
+func NewPasswordResetRepository(db *mongo.Database) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+// RecordRequest 记录一次忘记密码请求
+func (r *PasswordResetRepository) RecordRequest(ctx context.Context, account, ip string, now time.Time) error {
+	collection := r.db.Collection(PasswordResetRequestCollection)
+
+	_, err := collection.InsertOne(ctx, &model.PasswordResetRequestLog{
+		Account:   account,
+		IP:        ip,
+		CreatedAt: now,
+	})
+	return err
+}
+
+// CountRequestsByIP 统计某IP自since以来的忘记密码请求数量
+func (r *PasswordResetRepository) CountRequestsByIP(ctx context.Context, ip string, since time.Time) (int64, error) {
+	collection := r.db.Collection(PasswordResetRequestCollection)
+	return collection.CountDocuments(ctx, bson.M{"ip": ip, "created_at": bson.M{"$gte": since}})
+}
+
+// CountTokensByUser 统计某用户自since以来签发的重置令牌数量
+func (r *PasswordResetRepository) CountTokensByUser(ctx context.Context, userID string, since time.Time) (int64, error) {
+	collection := r.db.Collection(PasswordResetTokenCollection)
+	return collection.CountDocuments(ctx, bson.M{"user_id": userID, "created_at": bson.M{"$gte": since}})
+}
+
+// CreateToken 保存新的重置令牌，同时作废该用户之前未使用的令牌，保证同一时间只有最新的链接有效
+func (r *PasswordResetRepository) CreateToken(ctx context.Context, token *model.PasswordResetToken) error {
+	if err := r.InvalidateUserTokens(ctx, token.UserID, token.CreatedAt); err != nil {
+		return err
+	}
+
+	collection := r.db.Collection(PasswordResetTokenCollection)
+	_, err := collection.InsertOne(ctx, token)
+	return err
+}
+
+// GetValidToken 根据摘要获取未使用且未过期的令牌；没有时返回nil
+func (r *PasswordResetRepository) GetValidToken(ctx context.Context, tokenHash string, now time.Time) (*model.PasswordResetToken, error) {
+	collection := r.db.Collection(PasswordResetTokenCollection)
+
+	var token model.PasswordResetToken
+	err := collection.FindOne(ctx, validTokenFilter(tokenHash, now)).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// ConsumeToken 原子地将令牌标记为已使用，并发提交时只有一个请求能成功；令牌无效时返回nil
+func (r *PasswordResetRepository) ConsumeToken(ctx context.Context, tokenHash string, now time.Time) (*model.PasswordResetToken, error) {
+	collection := r.db.Collection(PasswordResetTokenCollection)
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var token model.PasswordResetToken
+	err := collection.FindOneAndUpdate(ctx, validTokenFilter(tokenHash, now), bson.M{"$set": bson.M{"used_at": now}}, opts).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// InvalidateUserTokens 作废用户所有未使用的重置令牌
+func (r *PasswordResetRepository) InvalidateUserTokens(ctx context.Context, userID string, now time.Time) error {
+	collection := r.db.Collection(PasswordResetTokenCollection)
+
+	_, err := collection.UpdateMany(ctx,
+		bson.M{"user_id": userID, "used_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"used_at": now}},
+	)
+	return err
+}
+
+// validTokenFilter 未使用且未过期的令牌
+func validTokenFilter(tokenHash string, now time.Time) bson.M {
+	return bson.M{
+		"token_hash": tokenHash,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"YufungProject/internal/model"
+	"YufungProject/pkg/utils"
+)
+
+const PolicyAttachmentCollection = "policy_attachments"
+
+type PolicyAttachmentRepository struct {
+	db *mongo.Database
+}
+
+func NewPolicyAttachmentRepository(db *mongo.Database) *PolicyAttachmentRepository {
+	return &PolicyAttachmentRepository{db: db}
+}
+
+// CreateAttachment 创建附件元数据，附件ID为空时自动生成
+func (r *PolicyAttachmentRepository) CreateAttachment(ctx context.Context, attachment *model.PolicyAttachment) error {
+	collection := r.db.Collection(PolicyAttachmentCollection)
+
+	if attachment.AttachmentID == "" {
+		attachment.AttachmentID = utils.GenerateID("ATT")
+	}
+	attachment.CreatedAt = time.Now()
+
+	_, err := collection.InsertOne(ctx, attachment)
+	return err
+}
+
+// GetAttachmentByID 根据ID获取附件
+func (r *PolicyAttachmentRepository) GetAttachmentByID(ctx context.Context, attachmentID string) (*model.PolicyAttachment, error) {
+	collection := r.db.Collection(PolicyAttachmentCollection)
+
+	var attachment model.PolicyAttachment
+	err := collection.FindOne(ctx, bson.M{"attachment_id": attachmentID}).Decode(&attachment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// ListAttachmentsByPolicy 获取保单的附件，按上传时间倒序
+func (r *PolicyAttachmentRepository) ListAttachmentsByPolicy(ctx context.Context, policyID string) ([]model.PolicyAttachment, error) {
+	collection := r.db.Collection(PolicyAttachmentCollection)
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.M{"policy_id": policyID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	attachments := []model.PolicyAttachment{}
+	if err = cursor.All(ctx, &attachments); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// DeleteAttachment 删除附件元数据
+func (r *PolicyAttachmentRepository) DeleteAttachment(ctx context.Context, attachmentID string) error {
+	collection := r.db.Collection(PolicyAttachmentCollection)
+
+	_, err := collection.DeleteOne(ctx, bson.M{"attachment_id": attachmentID})
+	return err
+}
+
+// CountAttachmentsByPolicies 统计各保单的附件数量（保单ID -> 数量）
+func (r *PolicyAttachmentRepository) CountAttachmentsByPolicies(ctx context.Context, policyIDs []string) (map[string]int64, error) {
+	collection := r.db.Collection(PolicyAttachmentCollection)
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"policy_id": bson.M{"$in": policyIDs}}},
+		{"$group": bson.M{"_id": "$policy_id", "count": bson.M{"$sum": 1}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []bson.M
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(results))
+	for _, result := range results {
+		policyID, _ := result["_id"].(string)
+		counts[policyID] = getInt64FromInterface(result["count"])
+	}
+	return counts, nil
+}
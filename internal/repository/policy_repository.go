@@ -41,7 +41,12 @@ func (r *PolicyRepository) CreatePolicy(ctx context.Context, policy *model.Polic
 	}
 	policy.SerialNumber = serialNumber
 
-	_, err = collection.InsertOne(ctx, policy)
+	stored, err := sealPolicy(ctx, policy)
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.InsertOne(ctx, stored)
 	return err
 }
 
@@ -49,8 +54,7 @@ func (r *PolicyRepository) CreatePolicy(ctx context.Context, policy *model.Polic
 func (r *PolicyRepository) GetPolicyByID(ctx context.Context, policyID string) (*model.Policy, error) {
 	collection := r.db.Collection(PolicyCollection)
 
-	var policy model.Policy
-	err := collection.FindOne(ctx, bson.M{"policy_id": policyID}).Decode(&policy)
+	policy, err := decodePolicy(ctx, collection.FindOne(ctx, bson.M{"policy_id": policyID}))
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -58,7 +62,7 @@ func (r *PolicyRepository) GetPolicyByID(ctx context.Context, policyID string) (
 		return nil, err
 	}
 
-	return &policy, nil
+	return policy, nil
 }
 
 // UpdatePolicy 更新保单
@@ -67,9 +71,15 @@ func (r *PolicyRepository) UpdatePolicy(ctx context.Context, policyID string, up
 
 	updates["updated_at"] = time.Now()
 
-	_, err := collection.UpdateOne(
+	filter := bson.M{"policy_id": policyID}
+	updates, err := sealUpdates(ctx, collection, filter, updates, PolicyEncryptedFields)
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.UpdateOne(
 		ctx,
-		bson.M{"policy_id": policyID},
+		filter,
 		bson.M{"$set": updates},
 	)
 	return err
@@ -88,20 +98,92 @@ func (r *PolicyRepository) ListPolicies(ctx context.Context, req *model.PolicyQu
 	collection := r.db.Collection(PolicyCollection)
 
 	// 构建查询条件
+	filter := buildPolicyFilter(req, companyID)
+
+	// 计算总数
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// 设置默认分页
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 20
+	}
+
+	// 计算跳过数量
+	skip := (req.Page - 1) * req.PageSize
+
+	// 构建排序
+	sort := bson.D{}
+	if req.SortBy != "" {
+		sortOrder := 1
+		if req.SortOrder == "desc" {
+			sortOrder = -1
+		}
+		sort = append(sort, bson.E{Key: req.SortBy, Value: sortOrder})
+	} else {
+		sort = append(sort, bson.E{Key: "created_at", Value: -1}) // 默认按创建时间倒序
+	}
+
+	// 查询数据
+	opts := options.Find().
+		SetSkip(int64(skip)).
+		SetLimit(int64(req.PageSize)).
+		SetSort(sort)
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	policies, err := decodePolicies(ctx, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// 转换为响应格式
+	var policyResponses []model.PolicyResponse
+	for _, policy := range policies {
+		policyResponses = append(policyResponses, model.PolicyResponse{Policy: &policy})
+	}
+
+	// 计算总页数
+	totalPages := int(math.Ceil(float64(total) / float64(req.PageSize)))
+
+	return &model.PolicyListResponse{
+		List:       policyResponses,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// buildPolicyFilter 根据查询请求构建保单查询条件
+func buildPolicyFilter(req *model.PolicyQueryRequest, companyID string) bson.M {
 	filter := bson.M{"company_id": companyID}
 
 	// 添加搜索条件
+	// 加密字段启用加密后按盲索引精确匹配
 	if req.AccountNumber != "" {
-		filter["account_number"] = bson.M{"$regex": req.AccountNumber, "$options": "i"}
+		setEncryptedFieldSearch(filter, "account_number", req.AccountNumber)
+	}
+	if req.CustomerID != "" {
+		filter["customer_id"] = req.CustomerID
 	}
 	if req.CustomerNumber != "" {
-		filter["customer_number"] = bson.M{"$regex": req.CustomerNumber, "$options": "i"}
+		setEncryptedFieldSearch(filter, "customer_number", req.CustomerNumber)
 	}
 	if req.CustomerNameCN != "" {
-		filter["customer_name_cn"] = bson.M{"$regex": req.CustomerNameCN, "$options": "i"}
+		setEncryptedFieldSearch(filter, "customer_name_cn", req.CustomerNameCN)
 	}
 	if req.CustomerNameEN != "" {
-		filter["customer_name_en"] = bson.M{"$regex": req.CustomerNameEN, "$options": "i"}
+		setEncryptedFieldSearch(filter, "customer_name_en", req.CustomerNameEN)
 	}
 	if req.ProposalNumber != "" {
 		filter["proposal_number"] = bson.M{"$regex": req.ProposalNumber, "$options": "i"}
@@ -130,6 +212,9 @@ func (r *PolicyRepository) ListPolicies(ctx context.Context, req *model.PolicyQu
 	if req.PaymentMethod != "" {
 		filter["payment_method"] = req.PaymentMethod
 	}
+	if req.ProductID != "" {
+		filter["product_id"] = req.ProductID
+	}
 	if req.InsuranceCompany != "" {
 		filter["insurance_company"] = bson.M{"$regex": req.InsuranceCompany, "$options": "i"}
 	}
@@ -188,68 +273,25 @@ func (r *PolicyRepository) ListPolicies(ctx context.Context, req *model.PolicyQu
 		filter["effective_date"] = dateFilter
 	}
 
-	// 计算总数
-	total, err := collection.CountDocuments(ctx, filter)
-	if err != nil {
-		return nil, err
+	// 自定义字段筛选
+	for name, condition := range req.CustomFieldFilters {
+		filter[model.CustomFieldsKey+"."+name] = condition
 	}
 
-	// 设置默认分页
-	if req.Page == 0 {
-		req.Page = 1
-	}
-	if req.PageSize == 0 {
-		req.PageSize = 20
-	}
-
-	// 计算跳过数量
-	skip := (req.Page - 1) * req.PageSize
-
-	// 构建排序
-	sort := bson.D{}
-	if req.SortBy != "" {
-		sortOrder := 1
-		if req.SortOrder == "desc" {
-			sortOrder = -1
-		}
-		sort = append(sort, bson.E{Key: req.SortBy, Value: sortOrder})
-	} else {
-		sort = append(sort, bson.E{Key: "created_at", Value: -1}) // 默认按创建时间倒序
-	}
+	return filter
+}
 
-	// 查询数据
-	opts := options.Find().
-		SetSkip(int64(skip)).
-		SetLimit(int64(req.PageSize)).
-		SetSort(sort)
+// FindPolicies 按查询条件获取全部保单（不分页）
+func (r *PolicyRepository) FindPolicies(ctx context.Context, req *model.PolicyQueryRequest, companyID string) ([]model.Policy, error) {
+	collection := r.db.Collection(PolicyCollection)
 
-	cursor, err := collection.Find(ctx, filter, opts)
+	cursor, err := collection.Find(ctx, buildPolicyFilter(req, companyID), options.Find().SetSort(bson.D{{Key: "serial_number", Value: 1}}))
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	var policies []model.Policy
-	if err = cursor.All(ctx, &policies); err != nil {
-		return nil, err
-	}
-
-	// 转换为响应格式
-	var policyResponses []model.PolicyResponse
-	for _, policy := range policies {
-		policyResponses = append(policyResponses, model.PolicyResponse{Policy: &policy})
-	}
-
-	// 计算总页数
-	totalPages := int(math.Ceil(float64(total) / float64(req.PageSize)))
-
-	return &model.PolicyListResponse{
-		List:       policyResponses,
-		Total:      total,
-		Page:       req.Page,
-		PageSize:   req.PageSize,
-		TotalPages: totalPages,
-	}, nil
+	return decodePolicies(ctx, cursor)
 }
 
 // CheckDuplicatePolicy 检查重复保单
@@ -263,7 +305,7 @@ func (r *PolicyRepository) CheckDuplicatePolicy(ctx context.Context, accountNumb
 
 	// 只有当账户号不为空时才检查账户号唯一性
 	if strings.TrimSpace(accountNumber) != "" {
-		conditions = append(conditions, bson.M{"account_number": accountNumber})
+		conditions = append(conditions, encryptedFieldMatch("account_number", accountNumber))
 	}
 
 	filter := bson.M{
@@ -361,12 +403,58 @@ func (r *PolicyRepository) GetPoliciesByIDs(ctx context.Context, policyIDs []str
 	}
 	defer cursor.Close(ctx)
 
-	var policies []model.Policy
-	if err = cursor.All(ctx, &policies); err != nil {
+	return decodePolicies(ctx, cursor)
+}
+
+// CountDistinctFieldValues 统计公司保单中某字段的不同取值及数量
+func (r *PolicyRepository) CountDistinctFieldValues(ctx context.Context, companyID, field string) ([]model.PolicyDictionaryRemapItem, error) {
+	collection := r.db.Collection(PolicyCollection)
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"company_id": companyID}},
+		{"$group": bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}},
+		{"$sort": bson.M{"count": -1}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
 		return nil, err
 	}
+	defer cursor.Close(ctx)
+
+	var results []bson.M
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	items := make([]model.PolicyDictionaryRemapItem, 0, len(results))
+	for _, result := range results {
+		value, _ := result["_id"].(string)
+		items = append(items, model.PolicyDictionaryRemapItem{
+			FromValue: value,
+			Count:     getInt64FromInterface(result["count"]),
+		})
+	}
+
+	return items, nil
+}
+
+// FindPoliciesByFieldValue 获取公司中某字段为指定值的保单
+func (r *PolicyRepository) FindPoliciesByFieldValue(ctx context.Context, companyID, field, value string) ([]model.Policy, error) {
+	collection := r.db.Collection(PolicyCollection)
+
+	filter := bson.M{
+		"company_id": companyID,
+		field:        value,
+	}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
 
-	return policies, nil
+	return decodePolicies(ctx, cursor)
 }
 
 // getNextSerialNumber 获取下一个序号
@@ -0,0 +1,359 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"YufungProject/internal/model"
+	"YufungProject/pkg/utils"
+)
+
+const (
+	InsurerCollection = "insurers"
+	ProductCollection = "products"
+)
+
+type ProductRepository struct {
+	db *mongo.Database
+}
+
+func NewProductRepository(db *mongo.Database) *ProductRepository {
+	return &ProductRepository{db: db}
+}
+
+// ==========================
+// 承保公司
+// ==========================
+
+// CreateInsurer 创建承保公司
+func (r *ProductRepository) CreateInsurer(ctx context.Context, insurer *model.Insurer) error {
+	collection := r.db.Collection(InsurerCollection)
+
+	insurer.InsurerID = utils.GenerateID("INS")
+	insurer.CreatedAt = time.Now()
+	insurer.UpdatedAt = time.Now()
+
+	_, err := collection.InsertOne(ctx, insurer)
+	return err
+}
+
+// GetInsurerByID 根据ID获取承保公司
+func (r *ProductRepository) GetInsurerByID(ctx context.Context, insurerID string) (*model.Insurer, error) {
+	collection := r.db.Collection(InsurerCollection)
+
+	var insurer model.Insurer
+	err := collection.FindOne(ctx, bson.M{"insurer_id": insurerID}).Decode(&insurer)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &insurer, nil
+}
+
+// GetInsurerByName 根据名称获取承保公司（忽略大小写）
+func (r *ProductRepository) GetInsurerByName(ctx context.Context, insurerName, companyID string) (*model.Insurer, error) {
+	collection := r.db.Collection(InsurerCollection)
+
+	filter := bson.M{
+		"company_id": companyID,
+		"$or": []bson.M{
+			{"insurer_name": insurerName},
+			{"name_en": insurerName},
+			{"insurer_code": insurerName},
+		},
+	}
+	opts := options.FindOne().SetCollation(&options.Collation{Locale: "en", Strength: 2})
+
+	var insurer model.Insurer
+	err := collection.FindOne(ctx, filter, opts).Decode(&insurer)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &insurer, nil
+}
+
+// UpdateInsurer 更新承保公司
+func (r *ProductRepository) UpdateInsurer(ctx context.Context, insurerID string, updates bson.M) error {
+	collection := r.db.Collection(InsurerCollection)
+
+	updates["updated_at"] = time.Now()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"insurer_id": insurerID}, bson.M{"$set": updates})
+	return err
+}
+
+// DeleteInsurer 删除承保公司
+func (r *ProductRepository) DeleteInsurer(ctx context.Context, insurerID string) error {
+	collection := r.db.Collection(InsurerCollection)
+
+	_, err := collection.DeleteOne(ctx, bson.M{"insurer_id": insurerID})
+	return err
+}
+
+// ListInsurers 获取公司的承保公司列表
+func (r *ProductRepository) ListInsurers(ctx context.Context, companyID, status string) ([]model.Insurer, error) {
+	collection := r.db.Collection(InsurerCollection)
+
+	filter := bson.M{"company_id": companyID}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "insurer_name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var insurers []model.Insurer
+	if err = cursor.All(ctx, &insurers); err != nil {
+		return nil, err
+	}
+
+	return insurers, nil
+}
+
+// CheckInsurerNameExists 检查承保公司名称是否已存在
+func (r *ProductRepository) CheckInsurerNameExists(ctx context.Context, insurerName, companyID, excludeID string) (bool, error) {
+	collection := r.db.Collection(InsurerCollection)
+
+	filter := bson.M{
+		"insurer_name": insurerName,
+		"company_id":   companyID,
+	}
+	if excludeID != "" {
+		filter["insurer_id"] = bson.M{"$ne": excludeID}
+	}
+
+	count, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// ==========================
+// 保险产品
+// ==========================
+
+// CreateProduct 创建保险产品
+func (r *ProductRepository) CreateProduct(ctx context.Context, product *model.Product) error {
+	collection := r.db.Collection(ProductCollection)
+
+	product.ProductID = utils.GenerateID("PRD")
+	product.CreatedAt = time.Now()
+	product.UpdatedAt = time.Now()
+
+	_, err := collection.InsertOne(ctx, product)
+	return err
+}
+
+// GetProductByID 根据ID获取保险产品
+func (r *ProductRepository) GetProductByID(ctx context.Context, productID string) (*model.Product, error) {
+	collection := r.db.Collection(ProductCollection)
+
+	var product model.Product
+	err := collection.FindOne(ctx, bson.M{"product_id": productID}).Decode(&product)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+// GetProductByName 根据承保公司和产品名称获取保险产品（忽略大小写）
+func (r *ProductRepository) GetProductByName(ctx context.Context, insurerID, productName, companyID string) (*model.Product, error) {
+	collection := r.db.Collection(ProductCollection)
+
+	filter := bson.M{
+		"company_id": companyID,
+		"insurer_id": insurerID,
+		"$or": []bson.M{
+			{"product_name": productName},
+			{"product_code": productName},
+		},
+	}
+	opts := options.FindOne().SetCollation(&options.Collation{Locale: "en", Strength: 2})
+
+	var product model.Product
+	err := collection.FindOne(ctx, filter, opts).Decode(&product)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+// UpdateProduct 更新保险产品
+func (r *ProductRepository) UpdateProduct(ctx context.Context, productID string, updates bson.M) error {
+	collection := r.db.Collection(ProductCollection)
+
+	updates["updated_at"] = time.Now()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"product_id": productID}, bson.M{"$set": updates})
+	return err
+}
+
+// UpdateInsurerNameOnProducts 承保公司改名后同步产品中冗余的承保公司名称
+func (r *ProductRepository) UpdateInsurerNameOnProducts(ctx context.Context, insurerID, insurerName string) error {
+	collection := r.db.Collection(ProductCollection)
+
+	_, err := collection.UpdateMany(
+		ctx,
+		bson.M{"insurer_id": insurerID},
+		bson.M{"$set": bson.M{"insurer_name": insurerName, "updated_at": time.Now()}},
+	)
+	return err
+}
+
+// DeleteProduct 删除保险产品
+func (r *ProductRepository) DeleteProduct(ctx context.Context, productID string) error {
+	collection := r.db.Collection(ProductCollection)
+
+	_, err := collection.DeleteOne(ctx, bson.M{"product_id": productID})
+	return err
+}
+
+// ListProducts 查询保险产品列表，PageSize为0时返回全部
+func (r *ProductRepository) ListProducts(ctx context.Context, req *model.ProductQueryRequest, companyID string) (*model.ProductListResponse, error) {
+	collection := r.db.Collection(ProductCollection)
+
+	filter := bson.M{"company_id": companyID}
+	if req.InsurerID != "" {
+		filter["insurer_id"] = req.InsurerID
+	}
+	if req.ProductType != "" {
+		filter["product_type"] = req.ProductType
+	}
+	if req.Status != "" {
+		filter["status"] = req.Status
+	}
+	if req.Keyword != "" {
+		filter["$or"] = []bson.M{
+			{"product_name": bson.M{"$regex": req.Keyword, "$options": "i"}},
+			{"product_code": bson.M{"$regex": req.Keyword, "$options": "i"}},
+			{"insurer_name": bson.M{"$regex": req.Keyword, "$options": "i"}},
+		}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.D{
+		{Key: "insurer_name", Value: 1},
+		{Key: "product_name", Value: 1},
+	})
+	if req.PageSize > 0 {
+		if req.Page <= 0 {
+			req.Page = 1
+		}
+		opts.SetSkip(int64((req.Page - 1) * req.PageSize)).SetLimit(int64(req.PageSize))
+	}
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var products []model.Product
+	if err = cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+
+	return &model.ProductListResponse{
+		List:     products,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// CheckProductNameExists 检查同一承保公司下产品名称是否已存在
+func (r *ProductRepository) CheckProductNameExists(ctx context.Context, insurerID, productName, companyID, excludeID string) (bool, error) {
+	collection := r.db.Collection(ProductCollection)
+
+	filter := bson.M{
+		"company_id":   companyID,
+		"insurer_id":   insurerID,
+		"product_name": productName,
+	}
+	if excludeID != "" {
+		filter["product_id"] = bson.M{"$ne": excludeID}
+	}
+
+	count, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// CountProductsByInsurer 统计承保公司下的产品数量
+func (r *ProductRepository) CountProductsByInsurer(ctx context.Context, insurerID string) (int64, error) {
+	collection := r.db.Collection(ProductCollection)
+
+	return collection.CountDocuments(ctx, bson.M{"insurer_id": insurerID})
+}
+
+// CountPoliciesByProduct 统计引用该产品的保单数量
+func (r *ProductRepository) CountPoliciesByProduct(ctx context.Context, productID string) (int64, error) {
+	collection := r.db.Collection(PolicyCollection)
+
+	return collection.CountDocuments(ctx, bson.M{"product_id": productID})
+}
+
+// SyncPolicyProductNames 同步保单中冗余的承保公司及产品名称
+func (r *ProductRepository) SyncPolicyProductNames(ctx context.Context, product *model.Product) (int64, error) {
+	collection := r.db.Collection(PolicyCollection)
+
+	result, err := collection.UpdateMany(
+		ctx,
+		bson.M{"product_id": product.ProductID},
+		bson.M{"$set": bson.M{
+			"insurer_id":        product.InsurerID,
+			"insurance_company": product.InsurerName,
+			"product_name":      product.ProductName,
+			"product_type":      product.ProductType,
+			"updated_at":        time.Now(),
+		}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// SyncPolicyInsurerName 承保公司改名后同步保单中冗余的承保公司名称
+func (r *ProductRepository) SyncPolicyInsurerName(ctx context.Context, insurerID, insurerName string) error {
+	collection := r.db.Collection(PolicyCollection)
+
+	_, err := collection.UpdateMany(
+		ctx,
+		bson.M{"insurer_id": insurerID},
+		bson.M{"$set": bson.M{"insurance_company": insurerName, "updated_at": time.Now()}},
+	)
+	return err
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"YufungProject/internal/model"
+	"YufungProject/pkg/utils"
+)
+
+const ReferralFeeRuleCollection = "referral_fee_rules"
+
+type ReferralFeeRuleRepository struct {
+	db *mongo.Database
+}
+
+func NewReferralFeeRuleRepository(db *mongo.Database) *ReferralFeeRuleRepository {
+	return &ReferralFeeRuleRepository{db: db}
+}
+
+// CreateRule 创建转介费规则
+func (r *ReferralFeeRuleRepository) CreateRule(ctx context.Context, rule *model.ReferralFeeRule) error {
+	collection := r.db.Collection(ReferralFeeRuleCollection)
+
+	rule.RuleID = utils.GenerateID("FEE")
+	rule.CreatedAt = time.Now()
+	rule.UpdatedAt = time.Now()
+
+	_, err := collection.InsertOne(ctx, rule)
+	return err
+}
+
+// GetRuleByID 根据ID获取转介费规则
+func (r *ReferralFeeRuleRepository) GetRuleByID(ctx context.Context, ruleID string) (*model.ReferralFeeRule, error) {
+	collection := r.db.Collection(ReferralFeeRuleCollection)
+
+	var rule model.ReferralFeeRule
+	err := collection.FindOne(ctx, bson.M{"rule_id": ruleID}).Decode(&rule)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// UpdateRule 更新转介费规则
+func (r *ReferralFeeRuleRepository) UpdateRule(ctx context.Context, ruleID string, updates bson.M) error {
+	collection := r.db.Collection(ReferralFeeRuleCollection)
+
+	updates["updated_at"] = time.Now()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"rule_id": ruleID}, bson.M{"$set": updates})
+	return err
+}
+
+// DeleteRule 删除转介费规则
+func (r *ReferralFeeRuleRepository) DeleteRule(ctx context.Context, ruleID string) error {
+	collection := r.db.Collection(ReferralFeeRuleCollection)
+
+	_, err := collection.DeleteOne(ctx, bson.M{"rule_id": ruleID})
+	return err
+}
+
+// ListRules 获取公司的转介费规则，按优先级降序
+func (r *ReferralFeeRuleRepository) ListRules(ctx context.Context, companyID, status string) ([]model.ReferralFeeRule, error) {
+	collection := r.db.Collection(ReferralFeeRuleCollection)
+
+	filter := bson.M{"company_id": companyID}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	opts := options.Find().SetSort(bson.D{
+		{Key: "priority", Value: -1},
+		{Key: "created_at", Value: 1},
+	})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rules []model.ReferralFeeRule
+	if err = cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// CountPoliciesByRule 统计命中该规则的保单数量
+func (r *ReferralFeeRuleRepository) CountPoliciesByRule(ctx context.Context, ruleID string) (int64, error) {
+	collection := r.db.Collection(PolicyCollection)
+
+	return collection.CountDocuments(ctx, bson.M{"fee_rule_id": ruleID})
+}
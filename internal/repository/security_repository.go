@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"YufungProject/internal/model"
+	"YufungProject/pkg/utils"
+)
+
+const (
+	SecurityEventCollection     = "security_events"
+	UserLoginLocationCollection = "user_login_locations"
+)
+
+type SecurityRepository struct {
+	db *mongo.Database
+}
+
+func NewSecurityRepository(db *mongo.Database) *SecurityRepository {
+	return &SecurityRepository{db: db}
+}
+
+// RecordEvent 记录安全事件；合并键相同的事件只保存一条并累加次数
+func (r *SecurityRepository) RecordEvent(ctx context.Context, event *model.SecurityEvent) error {
+	collection := r.db.Collection(SecurityEventCollection)
+
+	filter := bson.M{"dedup_key": event.DedupKey}
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			"event_id":      utils.GenerateID("SEC"),
+			"type":          event.Type,
+			"source":        event.Source,
+			"user_id":       event.UserID,
+			"username":      event.Username,
+			"company_id":    event.CompanyID,
+			"ip_address":    event.IPAddress,
+			"path":          event.Path,
+			"detail":        event.Detail,
+			"first_seen_at": event.LastSeenAt,
+		},
+		"$set": bson.M{"last_seen_at": event.LastSeenAt, "user_agent": event.UserAgent},
+		"$inc": bson.M{"count": 1},
+	}
+	_, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// 并发插入同一合并键时唯一索引冲突，此时记录已存在，累加次数即可
+		_, err = collection.UpdateOne(ctx, filter, update)
+	}
+	return err
+}
+
+// ListEvents 分页查询安全事件，按最近发生时间倒序
+func (r *SecurityRepository) ListEvents(ctx context.Context, req *model.SecurityEventQueryRequest) ([]model.SecurityEvent, int64, error) {
+	collection := r.db.Collection(SecurityEventCollection)
+
+	filter := bson.M{}
+	if req.Type != "" {
+		filter["type"] = req.Type
+	}
+	if req.CompanyID != "" {
+		filter["company_id"] = req.CompanyID
+	}
+	if req.UserID != "" {
+		filter["user_id"] = req.UserID
+	}
+	if req.IPAddress != "" {
+		filter["ip_address"] = req.IPAddress
+	}
+
+	// 时间范围查询
+	if req.StartTime != "" || req.EndTime != "" {
+		timeFilter := bson.M{}
+		if req.StartTime != "" {
+			if startTime, err := time.ParseInLocation("2006-01-02", req.StartTime, time.Local); err == nil {
+				timeFilter["$gte"] = startTime
+			}
+		}
+		if req.EndTime != "" {
+			if endTime, err := time.ParseInLocation("2006-01-02", req.EndTime, time.Local); err == nil {
+				timeFilter["$lt"] = endTime.AddDate(0, 0, 1)
+			}
+		}
+		if len(timeFilter) > 0 {
+			filter["last_seen_at"] = timeFilter
+		}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "last_seen_at", Value: -1}}).
+		SetSkip(int64((req.Page - 1) * req.PageSize)).
+		SetLimit(int64(req.PageSize))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	events := make([]model.SecurityEvent, 0)
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// LoginLocationSeen 用户是否有过登录记录，以及是否曾从该IP、该设备登录
+func (r *SecurityRepository) LoginLocationSeen(ctx context.Context, userID, ipAddress, device string) (hasHistory, ipSeen, deviceSeen bool, err error) {
+	collection := r.db.Collection(UserLoginLocationCollection)
+
+	count, err := collection.CountDocuments(ctx, bson.M{"user_id": userID}, options.Count().SetLimit(1))
+	if err != nil || count == 0 {
+		return false, false, false, err
+	}
+	count, err = collection.CountDocuments(ctx, bson.M{"user_id": userID, "ip_address": ipAddress}, options.Count().SetLimit(1))
+	if err != nil {
+		return true, false, false, err
+	}
+	ipSeen = count > 0
+	count, err = collection.CountDocuments(ctx, bson.M{"user_id": userID, "device": device}, options.Count().SetLimit(1))
+	if err != nil {
+		return true, ipSeen, false, err
+	}
+	return true, ipSeen, count > 0, nil
+}
+
+// TouchLoginLocation 记录用户从该IP、该设备登录
+func (r *SecurityRepository) TouchLoginLocation(ctx context.Context, location *model.UserLoginLocation) error {
+	collection := r.db.Collection(UserLoginLocationCollection)
+
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"user_id": location.UserID, "ip_address": location.IPAddress, "device": location.Device},
+		bson.M{
+			"$setOnInsert": bson.M{"company_id": location.CompanyID, "first_seen_at": location.LastSeenAt},
+			"$set":         bson.M{"user_agent": location.UserAgent, "last_seen_at": location.LastSeenAt},
+			"$inc":         bson.M{"login_count": 1},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"YufungProject/internal/model"
+)
+
+const (
+	ServiceAccountCollection = "service_accounts"
+	APIKeyCollection         = "api_keys"
+)
+
+type ServiceAccountRepository struct {
+	db *mongo.Database
+}
+
+func NewServiceAccountRepository(db *mongo.Database) *ServiceAccountRepository {
+	return &ServiceAccountRepository{db: db}
+}
+
+// CreateAccount 保存服务账号
+func (r *ServiceAccountRepository) CreateAccount(ctx context.Context, account *model.ServiceAccount) error {
+	collection := r.db.Collection(ServiceAccountCollection)
+	_, err := collection.InsertOne(ctx, account)
+	return err
+}
+
+// GetAccount 根据服务账号ID获取服务账号；不存在时返回nil
+func (r *ServiceAccountRepository) GetAccount(ctx context.Context, serviceAccountID string) (*model.ServiceAccount, error) {
+	collection := r.db.Collection(ServiceAccountCollection)
+
+	var account model.ServiceAccount
+	err := collection.FindOne(ctx, bson.M{"service_account_id": serviceAccountID}).Decode(&account)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// ExistsAccountName 公司下是否已有同名服务账号
+func (r *ServiceAccountRepository) ExistsAccountName(ctx context.Context, companyID, name, excludeID string) (bool, error) {
+	collection := r.db.Collection(ServiceAccountCollection)
+
+	filter := bson.M{"company_id": companyID, "name": name}
+	if excludeID != "" {
+		filter["service_account_id"] = bson.M{"$ne": excludeID}
+	}
+	count, err := collection.CountDocuments(ctx, filter)
+	return count > 0, err
+}
+
+// ListAccounts 分页查询服务账号
+func (r *ServiceAccountRepository) ListAccounts(ctx context.Context, req *model.ServiceAccountQueryRequest) ([]model.ServiceAccount, int64, error) {
+	collection := r.db.Collection(ServiceAccountCollection)
+
+	filter := bson.M{}
+	if req.CompanyID != "" {
+		filter["company_id"] = req.CompanyID
+	}
+	if req.Status != "" {
+		filter["status"] = req.Status
+	}
+	if req.Name != "" {
+		filter["name"] = bson.M{"$regex": regexp.QuoteMeta(req.Name), "$options": "i"}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((req.Page - 1) * req.PageSize)).
+		SetLimit(int64(req.PageSize))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	accounts := make([]model.ServiceAccount, 0)
+	if err := cursor.All(ctx, &accounts); err != nil {
+		return nil, 0, err
+	}
+
+	return accounts, total, nil
+}
+
+// UpdateAccount 更新服务账号
+func (r *ServiceAccountRepository) UpdateAccount(ctx context.Context, serviceAccountID string, updates bson.M) error {
+	collection := r.db.Collection(ServiceAccountCollection)
+
+	updates["updated_at"] = time.Now()
+	_, err := collection.UpdateOne(ctx, bson.M{"service_account_id": serviceAccountID}, bson.M{"$set": updates})
+	return err
+}
+
+// DeleteAccount 删除服务账号及其全部密钥
+func (r *ServiceAccountRepository) DeleteAccount(ctx context.Context, serviceAccountID string) error {
+	if _, err := r.db.Collection(APIKeyCollection).DeleteMany(ctx, bson.M{"service_account_id": serviceAccountID}); err != nil {
+		return err
+	}
+	_, err := r.db.Collection(ServiceAccountCollection).DeleteOne(ctx, bson.M{"service_account_id": serviceAccountID})
+	return err
+}
+
+// CreateKey 保存API密钥
+func (r *ServiceAccountRepository) CreateKey(ctx context.Context, key *model.APIKey) error {
+	collection := r.db.Collection(APIKeyCollection)
+	_, err := collection.InsertOne(ctx, key)
+	return err
+}
+
+// GetKeyByPrefix 根据前缀获取密钥；不存在时返回nil
+func (r *ServiceAccountRepository) GetKeyByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	collection := r.db.Collection(APIKeyCollection)
+
+	var key model.APIKey
+	err := collection.FindOne(ctx, bson.M{"prefix": prefix}).Decode(&key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// ListKeys 获取服务账号的全部密钥，按创建时间倒序
+func (r *ServiceAccountRepository) ListKeys(ctx context.Context, serviceAccountID string) ([]model.APIKey, error) {
+	collection := r.db.Collection(APIKeyCollection)
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.M{"service_account_id": serviceAccountID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := make([]model.APIKey, 0)
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// ExpireKeys 将服务账号当前有效的密钥（新签发的密钥除外）的过期时间提前到 expiresAt，用于轮换
+func (r *ServiceAccountRepository) ExpireKeys(ctx context.Context, serviceAccountID, exceptKeyID string, expiresAt time.Time) error {
+	collection := r.db.Collection(APIKeyCollection)
+
+	_, err := collection.UpdateMany(ctx,
+		bson.M{
+			"service_account_id": serviceAccountID,
+			"key_id":             bson.M{"$ne": exceptKeyID},
+			"revoked_at":         bson.M{"$exists": false},
+			"$or": bson.A{
+				bson.M{"expires_at": bson.M{"$exists": false}},
+				bson.M{"expires_at": bson.M{"$gt": expiresAt}},
+			},
+		},
+		bson.M{"$set": bson.M{"expires_at": expiresAt}},
+	)
+	return err
+}
+
+// RevokeKey 吊销密钥；密钥不存在或已吊销时返回false
+func (r *ServiceAccountRepository) RevokeKey(ctx context.Context, serviceAccountID, keyID, revokedBy string, now time.Time) (bool, error) {
+	collection := r.db.Collection(APIKeyCollection)
+
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"service_account_id": serviceAccountID, "key_id": keyID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": now, "revoked_by": revokedBy}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// TouchKey 记录密钥及服务账号的最后使用时间；距上次记录不足 interval 时跳过，避免每次请求都写库
+func (r *ServiceAccountRepository) TouchKey(ctx context.Context, key *model.APIKey, clientIP string, now time.Time, interval time.Duration) error {
+	if key.LastUsedAt != nil && now.Sub(*key.LastUsedAt) < interval && key.LastUsedIP == clientIP {
+		return nil
+	}
+
+	if _, err := r.db.Collection(APIKeyCollection).UpdateOne(ctx,
+		bson.M{"key_id": key.KeyID},
+		bson.M{"$set": bson.M{"last_used_at": now, "last_used_ip": clientIP}},
+	); err != nil {
+		return err
+	}
+	_, err := r.db.Collection(ServiceAccountCollection).UpdateOne(ctx,
+		bson.M{"service_account_id": key.ServiceAccountID},
+		bson.M{"$set": bson.M{"last_used_at": now}},
+	)
+	return err
+}
@@ -20,9 +20,17 @@ type SystemConfigRepository interface {
 	GetByID(ctx context.Context, configID string) (*model.SystemConfig, error)
 	Update(ctx context.Context, configID string, config *model.SystemConfig) error
 	Delete(ctx context.Context, configID string) error
-	List(ctx context.Context, req *model.SystemConfigQueryRequest, companyID string) (*model.SystemConfigListResponse, error)
+	List(ctx context.Context, req *model.SystemConfigQueryRequest, companyIDs []string) (*model.SystemConfigListResponse, error)
 	GetByType(ctx context.Context, configType, companyID string) ([]model.SystemConfig, error)
 	CheckKeyExists(ctx context.Context, configType, configKey, companyID, excludeID string) (bool, error)
+	GetByKey(ctx context.Context, configType, configKey, companyID string) (*model.SystemConfig, error)
+	CountByType(ctx context.Context, configType, companyID string) (int64, error)
+	CountByParent(ctx context.Context, configTypes []string, parentKey, companyID string) (int64, error)
+
+	// 公司对平台配置项的覆盖设置
+	GetOverrides(ctx context.Context, companyID, configType string) ([]model.SystemConfigOverride, error)
+	UpsertOverride(ctx context.Context, override *model.SystemConfigOverride) error
+	DeleteOverride(ctx context.Context, companyID, configID string) error
 }
 
 type systemConfigRepository struct {
@@ -74,6 +82,7 @@ func (r *systemConfigRepository) Update(ctx context.Context, configID string, co
 	filter := bson.M{"config_id": configID}
 	update := bson.M{
 		"$set": bson.M{
+			"parent_key":   config.ParentKey,
 			"config_value": config.ConfigValue,
 			"display_name": config.DisplayName,
 			"sort_order":   config.SortOrder,
@@ -110,19 +119,21 @@ func (r *systemConfigRepository) Delete(ctx context.Context, configID string) er
 		return fmt.Errorf("系统配置不存在")
 	}
 
-	return nil
+	// 同时清理各公司对该配置项的覆盖设置
+	_, err = r.db.Collection("system_config_overrides").DeleteMany(ctx, filter)
+	return err
 }
 
 // List 获取系统配置列表
-func (r *systemConfigRepository) List(ctx context.Context, req *model.SystemConfigQueryRequest, companyID string) (*model.SystemConfigListResponse, error) {
+func (r *systemConfigRepository) List(ctx context.Context, req *model.SystemConfigQueryRequest, companyIDs []string) (*model.SystemConfigListResponse, error) {
 	collection := r.db.Collection("system_configs")
 
 	// 构建查询条件
 	filter := bson.M{}
 
-	// 只有当companyID不为空时才添加公司过滤条件
-	if companyID != "" {
-		filter["company_id"] = companyID
+	// 只有当companyIDs不为空时才添加公司过滤条件，空字符串表示平台级配置项
+	if len(companyIDs) > 0 {
+		filter["company_id"] = bson.M{"$in": companyIDs}
 	}
 
 	if req.ConfigType != "" {
@@ -186,18 +197,14 @@ func (r *systemConfigRepository) List(ctx context.Context, req *model.SystemConf
 	}, nil
 }
 
-// GetByType 根据配置类型获取配置列表
+// GetByType 根据配置类型获取公司可见的启用配置列表（平台级 + 公司级），companyID为空时只返回平台级配置项
 func (r *systemConfigRepository) GetByType(ctx context.Context, configType, companyID string) ([]model.SystemConfig, error) {
 	collection := r.db.Collection("system_configs")
 
 	filter := bson.M{
 		"config_type": configType,
 		"status":      "enable",
-	}
-
-	// 只有当companyID不为空时才添加公司过滤条件
-	if companyID != "" {
-		filter["company_id"] = companyID
+		"company_id":  bson.M{"$in": []string{"", companyID}},
 	}
 
 	findOptions := options.Find().SetSort(bson.D{
@@ -220,13 +227,16 @@ func (r *systemConfigRepository) GetByType(ctx context.Context, configType, comp
 }
 
 // CheckKeyExists 检查配置键是否存在
+// 公司级配置键不能与平台级或本公司已有配置键重复；平台级配置键在所有公司中唯一
 func (r *systemConfigRepository) CheckKeyExists(ctx context.Context, configType, configKey, companyID, excludeID string) (bool, error) {
 	collection := r.db.Collection("system_configs")
 
 	filter := bson.M{
 		"config_type": configType,
 		"config_key":  configKey,
-		"company_id":  companyID,
+	}
+	if companyID != "" {
+		filter["company_id"] = bson.M{"$in": []string{"", companyID}}
 	}
 
 	if excludeID != "" {
@@ -240,3 +250,115 @@ func (r *systemConfigRepository) CheckKeyExists(ctx context.Context, configType,
 
 	return count > 0, nil
 }
+
+// GetByKey 根据配置键获取公司可见的配置项（平台级或公司级），不存在时返回nil
+func (r *systemConfigRepository) GetByKey(ctx context.Context, configType, configKey, companyID string) (*model.SystemConfig, error) {
+	collection := r.db.Collection("system_configs")
+
+	filter := bson.M{
+		"config_type": configType,
+		"config_key":  configKey,
+		"company_id":  bson.M{"$in": []string{"", companyID}},
+	}
+
+	var config model.SystemConfig
+	err := collection.FindOne(ctx, filter).Decode(&config)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// CountByType 统计指定类型下公司可见的配置项数量（平台级 + 公司级），companyID为空时统计所有公司
+func (r *systemConfigRepository) CountByType(ctx context.Context, configType, companyID string) (int64, error) {
+	collection := r.db.Collection("system_configs")
+
+	filter := bson.M{"config_type": configType}
+	if companyID != "" {
+		filter["company_id"] = bson.M{"$in": []string{"", companyID}}
+	}
+
+	return collection.CountDocuments(ctx, filter)
+}
+
+// CountByParent 统计指定下级类型中挂在某个上级配置键下、公司可见的配置项数量，companyID为空时统计所有公司
+func (r *systemConfigRepository) CountByParent(ctx context.Context, configTypes []string, parentKey, companyID string) (int64, error) {
+	if len(configTypes) == 0 {
+		return 0, nil
+	}
+
+	collection := r.db.Collection("system_configs")
+
+	filter := bson.M{
+		"config_type": bson.M{"$in": configTypes},
+		"parent_key":  parentKey,
+	}
+	if companyID != "" {
+		filter["company_id"] = bson.M{"$in": []string{"", companyID}}
+	}
+
+	return collection.CountDocuments(ctx, filter)
+}
+
+// GetOverrides 获取公司对指定类型平台配置项的覆盖设置
+func (r *systemConfigRepository) GetOverrides(ctx context.Context, companyID, configType string) ([]model.SystemConfigOverride, error) {
+	collection := r.db.Collection("system_config_overrides")
+
+	filter := bson.M{
+		"company_id":  companyID,
+		"config_type": configType,
+	}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var overrides []model.SystemConfigOverride
+	if err = cursor.All(ctx, &overrides); err != nil {
+		return nil, err
+	}
+
+	return overrides, nil
+}
+
+// UpsertOverride 创建或更新公司对平台配置项的覆盖设置
+func (r *systemConfigRepository) UpsertOverride(ctx context.Context, override *model.SystemConfigOverride) error {
+	collection := r.db.Collection("system_config_overrides")
+
+	filter := bson.M{
+		"company_id": override.CompanyID,
+		"config_id":  override.ConfigID,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"config_type":  override.ConfigType,
+			"hidden":       override.Hidden,
+			"display_name": override.DisplayName,
+			"sort_order":   override.SortOrder,
+			"updated_by":   override.UpdatedBy,
+			"updated_at":   time.Now(),
+		},
+	}
+
+	_, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// DeleteOverride 删除公司对平台配置项的覆盖设置，恢复为平台值
+func (r *systemConfigRepository) DeleteOverride(ctx context.Context, companyID, configID string) error {
+	collection := r.db.Collection("system_config_overrides")
+
+	filter := bson.M{
+		"company_id": companyID,
+		"config_id":  configID,
+	}
+
+	_, err := collection.DeleteOne(ctx, filter)
+	return err
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"YufungProject/internal/model"
+	"YufungProject/pkg/utils"
+)
+
+// SystemConfigTypeRepository 字典类型注册表数据访问层接口
+type SystemConfigTypeRepository interface {
+	Create(ctx context.Context, configType *model.SystemConfigType) error
+	GetByID(ctx context.Context, typeID string) (*model.SystemConfigType, error)
+	GetByCode(ctx context.Context, typeCode, companyID string) (*model.SystemConfigType, error)
+	Update(ctx context.Context, typeID string, configType *model.SystemConfigType) error
+	Delete(ctx context.Context, typeID string) error
+	List(ctx context.Context, companyID string) ([]model.SystemConfigType, error)
+	CheckCodeExists(ctx context.Context, typeCode, companyID string) (bool, error)
+	CountByParentType(ctx context.Context, parentType, companyID string) (int64, error)
+}
+
+type systemConfigTypeRepository struct {
+	db *mongo.Database
+}
+
+// NewSystemConfigTypeRepository 创建字典类型注册表数据访问层实例
+func NewSystemConfigTypeRepository(db *mongo.Database) SystemConfigTypeRepository {
+	return &systemConfigTypeRepository{
+		db: db,
+	}
+}
+
+// Create 创建字典类型
+func (r *systemConfigTypeRepository) Create(ctx context.Context, configType *model.SystemConfigType) error {
+	collection := r.db.Collection("system_config_types")
+
+	configType.TypeID = utils.GenerateID("CFGTYPE")
+	configType.CreatedAt = time.Now()
+	configType.UpdatedAt = time.Now()
+
+	_, err := collection.InsertOne(ctx, configType)
+	return err
+}
+
+// GetByID 根据ID获取字典类型
+func (r *systemConfigTypeRepository) GetByID(ctx context.Context, typeID string) (*model.SystemConfigType, error) {
+	collection := r.db.Collection("system_config_types")
+
+	var configType model.SystemConfigType
+	err := collection.FindOne(ctx, bson.M{"type_id": typeID}).Decode(&configType)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("字典类型不存在")
+		}
+		return nil, err
+	}
+
+	return &configType, nil
+}
+
+// GetByCode 根据类型编码获取公司可见的字典类型，公司级类型优先于平台级类型，不存在时返回nil
+func (r *systemConfigTypeRepository) GetByCode(ctx context.Context, typeCode, companyID string) (*model.SystemConfigType, error) {
+	collection := r.db.Collection("system_config_types")
+
+	filter := bson.M{
+		"type_code":  typeCode,
+		"company_id": bson.M{"$in": []string{"", companyID}},
+	}
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "company_id", Value: -1}})
+
+	var configType model.SystemConfigType
+	err := collection.FindOne(ctx, filter, findOptions).Decode(&configType)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &configType, nil
+}
+
+// Update 更新字典类型
+func (r *systemConfigTypeRepository) Update(ctx context.Context, typeID string, configType *model.SystemConfigType) error {
+	collection := r.db.Collection("system_config_types")
+
+	update := bson.M{
+		"$set": bson.M{
+			"type_name":  configType.TypeName,
+			"sort_order": configType.SortOrder,
+			"status":     configType.Status,
+			"remark":     configType.Remark,
+			"updated_by": configType.UpdatedBy,
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.M{"type_id": typeID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("字典类型不存在")
+	}
+
+	return nil
+}
+
+// Delete 删除字典类型
+func (r *systemConfigTypeRepository) Delete(ctx context.Context, typeID string) error {
+	collection := r.db.Collection("system_config_types")
+
+	result, err := collection.DeleteOne(ctx, bson.M{"type_id": typeID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("字典类型不存在")
+	}
+
+	return nil
+}
+
+// List 获取公司可见的字典类型列表（平台级 + 公司级），companyID为空时只返回平台级类型
+func (r *systemConfigTypeRepository) List(ctx context.Context, companyID string) ([]model.SystemConfigType, error) {
+	collection := r.db.Collection("system_config_types")
+
+	filter := bson.M{"company_id": bson.M{"$in": []string{"", companyID}}}
+	findOptions := options.Find().SetSort(bson.D{
+		{Key: "sort_order", Value: 1},
+		{Key: "created_at", Value: 1},
+	})
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var configTypes []model.SystemConfigType
+	if err = cursor.All(ctx, &configTypes); err != nil {
+		return nil, err
+	}
+
+	return configTypes, nil
+}
+
+// CheckCodeExists 检查类型编码是否已被占用
+// 平台级类型编码在所有公司中唯一；公司级类型编码不能与平台级或本公司已有类型重复
+func (r *systemConfigTypeRepository) CheckCodeExists(ctx context.Context, typeCode, companyID string) (bool, error) {
+	collection := r.db.Collection("system_config_types")
+
+	filter := bson.M{"type_code": typeCode}
+	if companyID != "" {
+		filter["company_id"] = bson.M{"$in": []string{"", companyID}}
+	}
+
+	count, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// CountByParentType 统计以指定类型为上级类型、公司可见的字典类型数量，companyID为空时统计所有公司
+func (r *systemConfigTypeRepository) CountByParentType(ctx context.Context, parentType, companyID string) (int64, error) {
+	collection := r.db.Collection("system_config_types")
+
+	filter := bson.M{"parent_type": parentType}
+	if companyID != "" {
+		filter["company_id"] = bson.M{"$in": []string{"", companyID}}
+	}
+
+	return collection.CountDocuments(ctx, filter)
+}
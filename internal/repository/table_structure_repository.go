@@ -0,0 +1,499 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"YufungProject/internal/model"
+	"YufungProject/pkg/utils"
+)
+
+// TableStructureRepository 动态表结构数据访问层接口
+type TableStructureRepository interface {
+	// 表结构
+	CreateTable(ctx context.Context, table *model.TableStructure) error
+	GetTableByID(ctx context.Context, tableID string) (*model.TableStructure, error)
+	GetTableByName(ctx context.Context, tableName, companyID string) (*model.TableStructure, error)
+	UpdateTable(ctx context.Context, tableID string, updates bson.M) error
+	DeleteTable(ctx context.Context, tableID string) error
+	ListTables(ctx context.Context, req *model.TableStructureQueryRequest, companyID string) ([]model.TableStructure, error)
+	CheckTableNameExists(ctx context.Context, tableName, companyID string) (bool, error)
+
+	// 字段定义
+	CreateField(ctx context.Context, field *model.FieldDefinition) error
+	GetFieldByID(ctx context.Context, fieldID string) (*model.FieldDefinition, error)
+	UpdateField(ctx context.Context, fieldID string, updates bson.M) error
+	DeleteField(ctx context.Context, fieldID string) error
+	ListFields(ctx context.Context, tableID string) ([]model.FieldDefinition, error)
+	CheckFieldNameExists(ctx context.Context, tableID, fieldName string) (bool, error)
+	GetMaxSortOrder(ctx context.Context, tableID string) (int, error)
+	UpdateFieldSortOrders(ctx context.Context, tableID string, fieldIDs []string) error
+
+	// 结构版本
+	IncrementTableVersion(ctx context.Context, tableID string) (int, error)
+	CreateVersion(ctx context.Context, version *model.TableStructureVersion) error
+	GetVersion(ctx context.Context, tableID string, version int) (*model.TableStructureVersion, error)
+	ListVersions(ctx context.Context, tableID string) ([]model.TableStructureVersion, error)
+
+	// 字段数据
+	CountFieldData(ctx context.Context, tableName, fieldName, companyID string) (int64, error)
+	FindFieldData(ctx context.Context, tableName, fieldName, companyID string) ([]model.FieldDataRecord, error)
+	MigrateFieldData(ctx context.Context, tableName, oldFieldName, newFieldName string, updates []model.FieldDataUpdate, version int) error
+}
+
+type tableStructureRepository struct {
+	db *mongo.Database
+}
+
+// NewTableStructureRepository 创建动态表结构数据访问层实例
+func NewTableStructureRepository(db *mongo.Database) TableStructureRepository {
+	return &tableStructureRepository{
+		db: db,
+	}
+}
+
+// CreateTable 创建表结构
+func (r *tableStructureRepository) CreateTable(ctx context.Context, table *model.TableStructure) error {
+	collection := r.db.Collection("table_structures")
+
+	table.TableID = utils.GenerateID("TABLE")
+	table.CreatedAt = time.Now()
+	table.UpdatedAt = time.Now()
+
+	_, err := collection.InsertOne(ctx, table)
+	return err
+}
+
+// GetTableByID 根据ID获取表结构
+func (r *tableStructureRepository) GetTableByID(ctx context.Context, tableID string) (*model.TableStructure, error) {
+	collection := r.db.Collection("table_structures")
+
+	var table model.TableStructure
+	err := collection.FindOne(ctx, bson.M{"table_id": tableID}).Decode(&table)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("表结构不存在")
+		}
+		return nil, err
+	}
+
+	return &table, nil
+}
+
+// GetTableByName 根据表名获取公司可见的表结构，公司表优先于平台表，不存在时返回nil
+func (r *tableStructureRepository) GetTableByName(ctx context.Context, tableName, companyID string) (*model.TableStructure, error) {
+	collection := r.db.Collection("table_structures")
+
+	filter := bson.M{
+		"table_name": tableName,
+		"company_id": bson.M{"$in": []string{"", companyID}},
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "company_id", Value: -1}})
+
+	var table model.TableStructure
+	err := collection.FindOne(ctx, filter, opts).Decode(&table)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &table, nil
+}
+
+// UpdateTable 更新表结构
+func (r *tableStructureRepository) UpdateTable(ctx context.Context, tableID string, updates bson.M) error {
+	collection := r.db.Collection("table_structures")
+
+	updates["updated_at"] = time.Now()
+
+	result, err := collection.UpdateOne(ctx, bson.M{"table_id": tableID}, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("表结构不存在")
+	}
+
+	return nil
+}
+
+// DeleteTable 删除表结构及其字段定义和版本
+func (r *tableStructureRepository) DeleteTable(ctx context.Context, tableID string) error {
+	if _, err := r.db.Collection("field_definitions").DeleteMany(ctx, bson.M{"table_id": tableID}); err != nil {
+		return err
+	}
+	if _, err := r.db.Collection("table_structure_versions").DeleteMany(ctx, bson.M{"table_id": tableID}); err != nil {
+		return err
+	}
+
+	result, err := r.db.Collection("table_structures").DeleteOne(ctx, bson.M{"table_id": tableID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("表结构不存在")
+	}
+
+	return nil
+}
+
+// ListTables 获取公司可见的表结构（平台表 + 本公司表）
+func (r *tableStructureRepository) ListTables(ctx context.Context, req *model.TableStructureQueryRequest, companyID string) ([]model.TableStructure, error) {
+	collection := r.db.Collection("table_structures")
+
+	filter := bson.M{"company_id": bson.M{"$in": []string{"", companyID}}}
+	if req.TableType != "" {
+		filter["table_type"] = req.TableType
+	}
+	if req.Status != "" {
+		filter["status"] = req.Status
+	}
+	if req.Keyword != "" {
+		filter["$or"] = []bson.M{
+			{"table_name": bson.M{"$regex": req.Keyword, "$options": "i"}},
+			{"display_name": bson.M{"$regex": req.Keyword, "$options": "i"}},
+		}
+	}
+
+	opts := options.Find().SetSort(bson.D{
+		{Key: "table_type", Value: -1},
+		{Key: "table_name", Value: 1},
+	})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tables []model.TableStructure
+	if err = cursor.All(ctx, &tables); err != nil {
+		return nil, err
+	}
+
+	return tables, nil
+}
+
+// CheckTableNameExists 检查表名是否已存在（本公司表与平台表不能重名）
+func (r *tableStructureRepository) CheckTableNameExists(ctx context.Context, tableName, companyID string) (bool, error) {
+	collection := r.db.Collection("table_structures")
+
+	filter := bson.M{
+		"table_name": tableName,
+		"company_id": bson.M{"$in": []string{"", companyID}},
+	}
+	// 平台表名需在所有公司中唯一
+	if companyID == "" {
+		delete(filter, "company_id")
+	}
+
+	count, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// CreateField 创建字段定义
+func (r *tableStructureRepository) CreateField(ctx context.Context, field *model.FieldDefinition) error {
+	collection := r.db.Collection("field_definitions")
+
+	field.FieldID = utils.GenerateID("FIELD")
+	field.CreatedAt = time.Now()
+	field.UpdatedAt = time.Now()
+
+	_, err := collection.InsertOne(ctx, field)
+	return err
+}
+
+// GetFieldByID 根据ID获取字段定义
+func (r *tableStructureRepository) GetFieldByID(ctx context.Context, fieldID string) (*model.FieldDefinition, error) {
+	collection := r.db.Collection("field_definitions")
+
+	var field model.FieldDefinition
+	err := collection.FindOne(ctx, bson.M{"field_id": fieldID}).Decode(&field)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("字段定义不存在")
+		}
+		return nil, err
+	}
+
+	return &field, nil
+}
+
+// UpdateField 更新字段定义
+func (r *tableStructureRepository) UpdateField(ctx context.Context, fieldID string, updates bson.M) error {
+	collection := r.db.Collection("field_definitions")
+
+	updates["updated_at"] = time.Now()
+
+	result, err := collection.UpdateOne(ctx, bson.M{"field_id": fieldID}, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("字段定义不存在")
+	}
+
+	return nil
+}
+
+// DeleteField 删除字段定义
+func (r *tableStructureRepository) DeleteField(ctx context.Context, fieldID string) error {
+	collection := r.db.Collection("field_definitions")
+
+	result, err := collection.DeleteOne(ctx, bson.M{"field_id": fieldID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("字段定义不存在")
+	}
+
+	return nil
+}
+
+// ListFields 获取表结构的字段定义，按排序号升序
+func (r *tableStructureRepository) ListFields(ctx context.Context, tableID string) ([]model.FieldDefinition, error) {
+	collection := r.db.Collection("field_definitions")
+
+	opts := options.Find().SetSort(bson.D{
+		{Key: "sort_order", Value: 1},
+		{Key: "created_at", Value: 1},
+	})
+
+	cursor, err := collection.Find(ctx, bson.M{"table_id": tableID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	fields := []model.FieldDefinition{}
+	if err = cursor.All(ctx, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+// CheckFieldNameExists 检查同一表结构下字段名是否已存在
+func (r *tableStructureRepository) CheckFieldNameExists(ctx context.Context, tableID, fieldName string) (bool, error) {
+	collection := r.db.Collection("field_definitions")
+
+	count, err := collection.CountDocuments(ctx, bson.M{"table_id": tableID, "field_name": fieldName})
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// GetMaxSortOrder 获取表结构下字段的最大排序号
+func (r *tableStructureRepository) GetMaxSortOrder(ctx context.Context, tableID string) (int, error) {
+	collection := r.db.Collection("field_definitions")
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "sort_order", Value: -1}})
+
+	var field model.FieldDefinition
+	err := collection.FindOne(ctx, bson.M{"table_id": tableID}, opts).Decode(&field)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return field.SortOrder, nil
+}
+
+// UpdateFieldSortOrders 按给定顺序重写字段排序号（从1开始）
+func (r *tableStructureRepository) UpdateFieldSortOrders(ctx context.Context, tableID string, fieldIDs []string) error {
+	collection := r.db.Collection("field_definitions")
+
+	models := make([]mongo.WriteModel, 0, len(fieldIDs))
+	for i, fieldID := range fieldIDs {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"table_id": tableID, "field_id": fieldID}).
+			SetUpdate(bson.M{"$set": bson.M{"sort_order": i + 1, "updated_at": time.Now()}}))
+	}
+
+	_, err := collection.BulkWrite(ctx, models)
+	return err
+}
+
+// IncrementTableVersion 递增表结构版本号，返回新版本号
+func (r *tableStructureRepository) IncrementTableVersion(ctx context.Context, tableID string) (int, error) {
+	collection := r.db.Collection("table_structures")
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	update := bson.M{
+		"$inc": bson.M{"version": 1},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+
+	var table model.TableStructure
+	err := collection.FindOneAndUpdate(ctx, bson.M{"table_id": tableID}, update, opts).Decode(&table)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, fmt.Errorf("表结构不存在")
+		}
+		return 0, err
+	}
+
+	return table.Version, nil
+}
+
+// CreateVersion 保存表结构版本快照
+func (r *tableStructureRepository) CreateVersion(ctx context.Context, version *model.TableStructureVersion) error {
+	collection := r.db.Collection("table_structure_versions")
+
+	version.VersionID = utils.GenerateID("TSV")
+	version.CreatedAt = time.Now()
+
+	_, err := collection.InsertOne(ctx, version)
+	return err
+}
+
+// GetVersion 获取表结构的指定版本
+func (r *tableStructureRepository) GetVersion(ctx context.Context, tableID string, version int) (*model.TableStructureVersion, error) {
+	collection := r.db.Collection("table_structure_versions")
+
+	var result model.TableStructureVersion
+	err := collection.FindOne(ctx, bson.M{"table_id": tableID, "version": version}).Decode(&result)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("表结构版本不存在")
+		}
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// ListVersions 获取表结构的全部版本，按版本号降序
+func (r *tableStructureRepository) ListVersions(ctx context.Context, tableID string) ([]model.TableStructureVersion, error) {
+	collection := r.db.Collection("table_structure_versions")
+
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+
+	cursor, err := collection.Find(ctx, bson.M{"table_id": tableID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	versions := []model.TableStructureVersion{}
+	if err = cursor.All(ctx, &versions); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// CountFieldData 统计表名对应集合中该动态字段有值的记录数，平台表统计所有公司
+func (r *tableStructureRepository) CountFieldData(ctx context.Context, tableName, fieldName, companyID string) (int64, error) {
+	collection := r.db.Collection(tableName)
+
+	key := model.CustomFieldsKey + "." + fieldName
+	filter := bson.M{key: bson.M{"$exists": true, "$nin": []interface{}{nil, ""}}}
+	if companyID != "" {
+		filter["company_id"] = companyID
+	}
+
+	return collection.CountDocuments(ctx, filter)
+}
+
+// FindFieldData 获取表名对应集合中该动态字段有值的记录，平台表查询所有公司
+func (r *tableStructureRepository) FindFieldData(ctx context.Context, tableName, fieldName, companyID string) ([]model.FieldDataRecord, error) {
+	collection := r.db.Collection(tableName)
+
+	key := model.CustomFieldsKey + "." + fieldName
+	filter := bson.M{key: bson.M{"$exists": true, "$nin": []interface{}{nil, ""}}}
+	if companyID != "" {
+		filter["company_id"] = companyID
+	}
+
+	projection := bson.M{"_id": 1, key: 1}
+	recordKey := model.TableRecordKeys[tableName]
+	if recordKey != "" {
+		projection[recordKey] = 1
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []model.FieldDataRecord
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID           primitive.ObjectID `bson:"_id"`
+			CustomFields bson.M             `bson:"custom_fields"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+
+		record := model.FieldDataRecord{
+			ID:       doc.ID,
+			RecordID: doc.ID.Hex(),
+			Value:    doc.CustomFields[fieldName],
+		}
+		if recordKey != "" {
+			if id, ok := cursor.Current.Lookup(recordKey).StringValueOK(); ok && id != "" {
+				record.RecordID = id
+			}
+		}
+		records = append(records, record)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// MigrateFieldData 将动态字段的新值写回记录（字段重命名时移除旧字段），并标记记录的表结构版本
+func (r *tableStructureRepository) MigrateFieldData(ctx context.Context, tableName, oldFieldName, newFieldName string, updates []model.FieldDataUpdate, version int) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	collection := r.db.Collection(tableName)
+	oldKey := model.CustomFieldsKey + "." + oldFieldName
+	newKey := model.CustomFieldsKey + "." + newFieldName
+
+	models := make([]mongo.WriteModel, 0, len(updates))
+	for _, item := range updates {
+		set := bson.M{model.SchemaVersionKey: version, "updated_at": time.Now()}
+		unset := bson.M{}
+		if item.Value == nil {
+			unset[newKey] = ""
+		} else {
+			set[newKey] = item.Value
+		}
+		if oldKey != newKey {
+			unset[oldKey] = ""
+		}
+
+		update := bson.M{"$set": set}
+		if len(unset) > 0 {
+			update["$unset"] = unset
+		}
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(bson.M{"_id": item.ID}).SetUpdate(update))
+	}
+
+	_, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
@@ -67,6 +67,10 @@ func (r *userRepository) createIndexes() {
 			Keys:    bson.D{{Key: "email", Value: 1}},
 			Options: options.Index().SetSparse(true).SetName("idx_email"),
 		},
+		{
+			Keys:    bson.D{{Key: "blind_index.email", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("idx_blind_index_email"),
+		},
 		{
 			Keys:    bson.D{{Key: "status", Value: 1}},
 			Options: options.Index().SetName("idx_status"),
@@ -98,7 +102,12 @@ func (r *userRepository) createIndexes() {
 // Create 创建用户
 func (r *userRepository) Create(ctx context.Context, user *model.User) error {
 	start := time.Now()
-	_, err := r.collection.InsertOne(ctx, user)
+	stored, err := sealUser(ctx, user)
+	if err != nil {
+		logger.Errorf("加密用户字段失败: %v, UserID: %s", err, user.UserID)
+		return err
+	}
+	_, err = r.collection.InsertOne(ctx, stored)
 	duration := time.Since(start)
 
 	if err != nil {
@@ -117,8 +126,7 @@ func (r *userRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*m
 	start := time.Now()
 	filter := bson.M{"_id": id}
 
-	var user model.User
-	err := r.collection.FindOne(ctx, filter).Decode(&user)
+	user, err := decodeUser(ctx, r.collection.FindOne(ctx, filter))
 	duration := time.Since(start)
 
 	if err != nil {
@@ -132,7 +140,7 @@ func (r *userRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*m
 	}
 
 	logger.DBLog("FIND", "users", filter, duration)
-	return &user, nil
+	return user, nil
 }
 
 // GetByUserID 根据用户ID获取用户
@@ -140,8 +148,7 @@ func (r *userRepository) GetByUserID(ctx context.Context, userID string) (*model
 	start := time.Now()
 	filter := bson.M{"user_id": userID}
 
-	var user model.User
-	err := r.collection.FindOne(ctx, filter).Decode(&user)
+	user, err := decodeUser(ctx, r.collection.FindOne(ctx, filter))
 	duration := time.Since(start)
 
 	if err != nil {
@@ -155,7 +162,7 @@ func (r *userRepository) GetByUserID(ctx context.Context, userID string) (*model
 	}
 
 	logger.DBLog("FIND", "users", filter, duration)
-	return &user, nil
+	return user, nil
 }
 
 // GetByUsername 根据用户名获取用户
@@ -163,8 +170,7 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*m
 	start := time.Now()
 	filter := bson.M{"username": username}
 
-	var user model.User
-	err := r.collection.FindOne(ctx, filter).Decode(&user)
+	user, err := decodeUser(ctx, r.collection.FindOne(ctx, filter))
 	duration := time.Since(start)
 
 	if err != nil {
@@ -178,16 +184,15 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*m
 	}
 
 	logger.DBLog("FIND", "users", filter, duration)
-	return &user, nil
+	return user, nil
 }
 
 // GetByEmail 根据邮箱获取用户
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	start := time.Now()
-	filter := bson.M{"email": email}
+	filter := encryptedFieldMatch("email", email)
 
-	var user model.User
-	err := r.collection.FindOne(ctx, filter).Decode(&user)
+	user, err := decodeUser(ctx, r.collection.FindOne(ctx, filter))
 	duration := time.Since(start)
 
 	if err != nil {
@@ -201,7 +206,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.U
 	}
 
 	logger.DBLog("FIND", "users", filter, duration)
-	return &user, nil
+	return user, nil
 }
 
 // Update 更新用户信息
@@ -210,6 +215,12 @@ func (r *userRepository) Update(ctx context.Context, userID string, update bson.
 	filter := bson.M{"user_id": userID}
 	update["updated_at"] = time.Now()
 
+	update, err := sealUpdates(ctx, r.collection, filter, update, UserEncryptedFields)
+	if err != nil {
+		logger.Errorf("加密用户字段失败: %v, UserID: %s", err, userID)
+		return err
+	}
+
 	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": update})
 	duration := time.Since(start)
 
@@ -323,8 +334,8 @@ func (r *userRepository) List(ctx context.Context, filter bson.M, page, pageSize
 	}
 	defer cursor.Close(ctx)
 
-	var users []*model.User
-	if err = cursor.All(ctx, &users); err != nil {
+	users, err := decodeUsers(ctx, cursor)
+	if err != nil {
 		duration := time.Since(start)
 		logger.DBLog("DECODE_LIST_ERROR", "users", filter, duration)
 		logger.Errorf("解析用户列表失败: %v", err)
@@ -385,7 +396,7 @@ func (r *userRepository) ExistsByUsername(ctx context.Context, username string)
 // ExistsByEmail 检查邮箱是否存在
 func (r *userRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	start := time.Now()
-	filter := bson.M{"email": email}
+	filter := encryptedFieldMatch("email", email)
 
 	count, err := r.collection.CountDocuments(ctx, filter)
 	duration := time.Since(start)
@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"YufungProject/configs"
+	"YufungProject/internal/controller"
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/repository"
+	"YufungProject/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 公告管理权限标识（平台管理员不受限制）
+const (
+	announcementListPermission   = "announcement:list"
+	announcementCreatePermission = "announcement:create"
+	announcementEditPermission   = "announcement:edit"
+	announcementDeletePermission = "announcement:delete"
+)
+
+// SetupAnnouncementRoutes 设置通知公告相关路由
+func SetupAnnouncementRoutes(router *gin.Engine, announcementController *controller.AnnouncementController, rbacRepo repository.RBACRepository, config *configs.Config) {
+	// 初始化活动记录服务
+	activityLogService := service.NewActivityLogService()
+
+	// 公告管理：平台管理员管理平台公告，公司用户按权限管理本公司公告
+	manageGroup := router.Group("/api/announcements/manage")
+	manageGroup.Use(middleware.AuthMiddleware(config))
+	manageGroup.Use(middleware.ActivityLogMiddleware(activityLogService))
+	{
+		manageGroup.POST("", middleware.PermissionRequiredMiddleware(rbacRepo, announcementCreatePermission), announcementController.CreateAnnouncement)       // 创建公告
+		manageGroup.GET("", middleware.PermissionRequiredMiddleware(rbacRepo, announcementListPermission), announcementController.ListManagedAnnouncements)    // 获取公告管理列表
+		manageGroup.GET("/:id", middleware.PermissionRequiredMiddleware(rbacRepo, announcementListPermission), announcementController.GetManagedAnnouncement)  // 获取公告管理详情
+		manageGroup.PUT("/:id", middleware.PermissionRequiredMiddleware(rbacRepo, announcementEditPermission), announcementController.UpdateAnnouncement)      // 更新公告（含发布、撤回）
+		manageGroup.DELETE("/:id", middleware.PermissionRequiredMiddleware(rbacRepo, announcementDeletePermission), announcementController.DeleteAnnouncement) // 删除公告
+		manageGroup.GET("/:id/receipts", middleware.PermissionRequiredMiddleware(rbacRepo, announcementListPermission), announcementController.GetReceipts)    // 获取公告回执
+	}
+
+	// 公告查看：所有登录用户查看自己可见的公告
+	announcementGroup := router.Group("/api/announcements")
+	announcementGroup.Use(middleware.AuthMiddleware(config))
+	announcementGroup.Use(middleware.ActivityLogMiddleware(activityLogService))
+	{
+		announcementGroup.GET("", announcementController.ListAnnouncements)                                   // 获取我的公告
+		announcementGroup.GET("/pending-acknowledgements", announcementController.GetPendingAcknowledgements) // 获取待确认公告
+		announcementGroup.GET("/:id", announcementController.GetAnnouncement)                                 // 查看公告（记录已读）
+		announcementGroup.POST("/:id/acknowledge", announcementController.AcknowledgeAnnouncement)            // 确认公告
+	}
+}
@@ -10,12 +10,15 @@ import (
 
 // SetupAuthRoutes 设置认证相关路由
 func SetupAuthRoutes(router *gin.Engine, authController *controller.AuthController, config *configs.Config) {
-	// 公开路由（不需要认证）
+	// 公开路由（不需要认证），按IP限流；登录、忘记密码另按用户名限流
 	authGroup := router.Group("/api/auth")
+	authGroup.Use(middleware.AuthIPRateLimitMiddleware())
 	{
-		authGroup.POST("/login", authController.Login)
+		authGroup.POST("/login", middleware.LoginRateLimitMiddleware(), authController.Login)
 		authGroup.POST("/register", authController.Register)
 		authGroup.POST("/refresh", authController.RefreshToken)
+		authGroup.POST("/forgot-password", middleware.PasswordResetRateLimitMiddleware(), authController.ForgotPassword)
+		authGroup.POST("/reset-password", authController.ResetPasswordWithToken)
 	}
 
 	// 需要认证的路由
@@ -23,7 +26,10 @@ func SetupAuthRoutes(router *gin.Engine, authController *controller.AuthControll
 	authProtectedGroup.Use(middleware.AuthMiddleware(config))
 	{
 		authProtectedGroup.POST("/logout", authController.Logout)
-		authProtectedGroup.POST("/change-password", authController.ChangePassword)
+		authProtectedGroup.POST("/change-password", middleware.AuthIPRateLimitMiddleware(), authController.ChangePassword)
 		authProtectedGroup.GET("/user-info", authController.GetUserInfo)
 	}
+
+	// 公钥集合，供其他内部服务校验令牌
+	router.GET("/.well-known/jwks.json", authController.GetJWKS)
 }
@@ -23,10 +23,10 @@ func SetupCompanyRoutes(router *gin.Engine, companyController *controller.Compan
 		companyGroup.GET("/stats", companyController.GetCompanyStats)
 
 		// 导入导出功能
-		companyGroup.POST("/export", companyController.ExportCompany)         // 导出公司数据
-		companyGroup.GET("/template", companyController.DownloadTemplate)     // 下载导入模板
-		companyGroup.POST("/import/preview", companyController.PreviewImport) // 预览导入数据
-		companyGroup.POST("/import", companyController.ImportCompany)         // 导入公司数据
+		companyGroup.POST("/export", middleware.UserRateLimitMiddleware("export"), companyController.ExportCompany) // 导出公司数据
+		companyGroup.GET("/template", companyController.DownloadTemplate)                                           // 下载导入模板
+		companyGroup.POST("/import/preview", companyController.PreviewImport)                                       // 预览导入数据
+		companyGroup.POST("/import", middleware.UserRateLimitMiddleware("import"), companyController.ImportCompany) // 导入公司数据
 
 		// 公司基本操作
 		companyGroup.POST("", companyController.CreateCompany)       // 创建公司
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"YufungProject/configs"
+	"YufungProject/internal/controller"
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupCustomerRoutes 设置客户管理相关路由
+func SetupCustomerRoutes(router *gin.Engine, customerController *controller.CustomerController, config *configs.Config) {
+	// 初始化活动记录服务
+	activityLogService := service.NewActivityLogService()
+
+	customerGroup := router.Group("/api/customers")
+	customerGroup.Use(middleware.AuthMiddleware(config))
+	customerGroup.Use(middleware.ActivityLogMiddleware(activityLogService))
+	{
+		customerGroup.POST("/link-policies", customerController.LinkExistingPolicies)            // 关联历史保单
+		customerGroup.POST("/duplicates/scan", customerController.ScanDuplicateCustomers)        // 扫描疑似重复客户
+		customerGroup.GET("/duplicates", customerController.ListDuplicateCustomers)              // 疑似重复客户审核队列
+		customerGroup.POST("/duplicates/:id/ignore", customerController.IgnoreDuplicateCustomer) // 忽略疑似重复客户
+		customerGroup.POST("/merge", customerController.MergeCustomers)                          // 合并客户
+
+		customerGroup.POST("", customerController.CreateCustomer)       // 创建客户
+		customerGroup.GET("", customerController.ListCustomers)         // 获取客户列表
+		customerGroup.GET("/:id", customerController.GetCustomer)       // 获取客户详情（含保单及汇总）
+		customerGroup.PUT("/:id", customerController.UpdateCustomer)    // 更新客户
+		customerGroup.DELETE("/:id", customerController.DeleteCustomer) // 删除客户
+	}
+}
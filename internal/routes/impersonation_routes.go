@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"YufungProject/configs"
+	"YufungProject/internal/controller"
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupImpersonationRoutes 设置模拟登录相关路由
+func SetupImpersonationRoutes(router *gin.Engine, impersonationController *controller.ImpersonationController, config *configs.Config) {
+	// 初始化活动记录服务
+	activityLogService := service.NewActivityLogService()
+
+	// 模拟登录：开始和会话管理仅平台管理员，结束使用模拟登录令牌调用
+	impersonationGroup := router.Group("/api/impersonation")
+	impersonationGroup.Use(middleware.AuthMiddleware(config))
+	impersonationGroup.Use(middleware.ActivityLogMiddleware(activityLogService))
+	{
+		impersonationGroup.POST("", middleware.AdminRequiredMiddleware(), impersonationController.StartImpersonation)                       // 开始模拟登录（平台管理员）
+		impersonationGroup.POST("/stop", impersonationController.StopImpersonation)                                                         // 结束当前模拟登录
+		impersonationGroup.GET("/sessions", middleware.AdminRequiredMiddleware(), impersonationController.ListImpersonationSessions)        // 模拟登录会话列表（平台管理员）
+		impersonationGroup.POST("/sessions/:id/end", middleware.AdminRequiredMiddleware(), impersonationController.EndImpersonationSession) // 强制结束模拟登录会话（平台管理员）
+	}
+}
@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"YufungProject/configs"
+	"YufungProject/internal/controller"
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/repository"
+	"YufungProject/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 邀请用户权限标识（平台管理员不受限制）
+const userInvitePermission = "user:invite"
+
+// SetupInvitationRoutes 设置邀请注册相关路由
+func SetupInvitationRoutes(router *gin.Engine, invitationController *controller.InvitationController, rbacRepo repository.RBACRepository, config *configs.Config) {
+	// 初始化活动记录服务
+	activityLogService := service.NewActivityLogService()
+
+	// 受邀人查看和接受邀请（不需要认证），按IP限流
+	authGroup := router.Group("/api/auth")
+	authGroup.Use(middleware.AuthIPRateLimitMiddleware())
+	{
+		authGroup.GET("/invitation", invitationController.PreviewInvitation)        // 查看邀请信息
+		authGroup.POST("/accept-invitation", invitationController.AcceptInvitation) // 接受邀请
+	}
+
+	// 邀请管理：平台管理员管理所有公司，公司用户按权限管理本公司
+	invitationGroup := router.Group("/api/invitations")
+	invitationGroup.Use(middleware.AuthMiddleware(config))
+	invitationGroup.Use(middleware.ActivityLogMiddleware(activityLogService))
+	invitationGroup.Use(middleware.PermissionRequiredMiddleware(rbacRepo, userInvitePermission))
+	{
+		invitationGroup.POST("", invitationController.CreateInvitation)            // 邀请用户
+		invitationGroup.GET("", invitationController.ListInvitations)              // 获取邀请列表
+		invitationGroup.POST("/:id/resend", invitationController.ResendInvitation) // 重新发送邀请
+		invitationGroup.POST("/:id/revoke", invitationController.RevokeInvitation) // 撤销邀请
+	}
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"YufungProject/configs"
+	"YufungProject/internal/controller"
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupMailRoutes 设置邮件管理相关路由（仅平台管理员）
+func SetupMailRoutes(router *gin.Engine, mailController *controller.MailController, config *configs.Config) {
+	// 初始化活动记录服务
+	activityLogService := service.NewActivityLogService()
+
+	mailGroup := router.Group("/api/mail")
+	mailGroup.Use(middleware.AuthMiddleware(config))
+	mailGroup.Use(middleware.AdminRequiredMiddleware())
+	mailGroup.Use(middleware.ActivityLogMiddleware(activityLogService))
+	{
+		mailGroup.GET("/messages", mailController.ListMessages)            // 获取发件箱列表
+		mailGroup.GET("/messages/:id", mailController.GetMessage)          // 获取邮件详情
+		mailGroup.POST("/messages/:id/retry", mailController.RetryMessage) // 重新发送失败的邮件
+		mailGroup.POST("/test", mailController.SendTestMail)               // 发送测试邮件
+		mailGroup.POST("/process", mailController.ProcessOutbox)           // 立即处理发件箱
+	}
+}
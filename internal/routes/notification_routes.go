@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"YufungProject/configs"
+	"YufungProject/internal/controller"
+	"YufungProject/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupNotificationRoutes 设置通知中心相关路由
+func SetupNotificationRoutes(router *gin.Engine, notificationController *controller.NotificationController, config *configs.Config) {
+	// 通知列表和未读数量会被前端轮询，不记录活动日志
+	notificationGroup := router.Group("/api/notifications")
+	notificationGroup.Use(middleware.AuthMiddleware(config))
+	{
+		notificationGroup.GET("", notificationController.ListNotifications)                                           // 获取通知列表
+		notificationGroup.GET("/unread-count", notificationController.GetUnreadCount)                                 // 获取未读数量
+		notificationGroup.POST("/read", notificationController.MarkRead)                                              // 标记已读
+		notificationGroup.GET("/preferences", notificationController.GetPreferences)                                  // 获取通知偏好
+		notificationGroup.PUT("/preferences", notificationController.UpdatePreferences)                               // 更新通知偏好
+		notificationGroup.POST("/run", middleware.AdminRequiredMiddleware(), notificationController.RunReminderRules) // 立即执行提醒规则（管理员）
+	}
+}
@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"YufungProject/configs"
+	"YufungProject/internal/controller"
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/repository"
+	"YufungProject/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 公司密码策略管理权限标识（平台管理员不受限制）
+const passwordPolicyManagePermission = "password_policy:manage"
+
+// SetupPasswordPolicyRoutes 设置密码策略相关路由
+func SetupPasswordPolicyRoutes(router *gin.Engine, policyController *controller.PasswordPolicyController, rbacRepo repository.RBACRepository, config *configs.Config) {
+	// 初始化活动记录服务
+	activityLogService := service.NewActivityLogService()
+
+	// 当前用户适用的密码策略（须修改密码的用户也可访问）
+	authGroup := router.Group("/api/auth")
+	authGroup.Use(middleware.AuthMiddleware(config))
+	{
+		authGroup.GET("/password-policy", policyController.GetMyPolicy) // 获取当前用户适用的密码策略
+	}
+
+	policyGroup := router.Group("/api/password-policies")
+	policyGroup.Use(middleware.AuthMiddleware(config))
+	policyGroup.Use(middleware.ActivityLogMiddleware(activityLogService))
+	{
+		// 平台策略：仅平台管理员
+		policyGroup.GET("/platform", middleware.AdminRequiredMiddleware(), policyController.GetPlatformPolicy)    // 获取平台密码策略
+		policyGroup.PUT("/platform", middleware.AdminRequiredMiddleware(), policyController.UpdatePlatformPolicy) // 设置平台密码策略
+
+		// 公司策略：平台管理员管理所有公司，公司用户按权限管理本公司
+		policyGroup.GET("/companies/:company_id", middleware.PermissionRequiredMiddleware(rbacRepo, passwordPolicyManagePermission), policyController.GetCompanyPolicy)       // 获取公司密码策略
+		policyGroup.PUT("/companies/:company_id", middleware.PermissionRequiredMiddleware(rbacRepo, passwordPolicyManagePermission), policyController.UpdateCompanyPolicy)    // 设置公司密码策略
+		policyGroup.DELETE("/companies/:company_id", middleware.PermissionRequiredMiddleware(rbacRepo, passwordPolicyManagePermission), policyController.DeleteCompanyPolicy) // 删除公司密码策略
+	}
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"YufungProject/configs"
+	"YufungProject/internal/controller"
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupPolicyAttachmentRoutes 设置保单附件相关路由
+func SetupPolicyAttachmentRoutes(router *gin.Engine, attachmentController *controller.PolicyAttachmentController, config *configs.Config) {
+	// 初始化活动记录服务
+	activityLogService := service.NewActivityLogService()
+
+	attachmentGroup := router.Group("/api/policies/:id/attachments")
+	attachmentGroup.Use(middleware.AuthMiddleware(config))
+	attachmentGroup.Use(middleware.ActivityLogMiddleware(activityLogService))
+	{
+		attachmentGroup.POST("", attachmentController.UploadAttachment)                          // 上传附件
+		attachmentGroup.GET("", attachmentController.ListAttachments)                            // 获取附件列表
+		attachmentGroup.GET("/:attachment_id/download", attachmentController.DownloadAttachment) // 下载附件
+		attachmentGroup.DELETE("/:attachment_id", attachmentController.DeleteAttachment)         // 删除附件
+	}
+}
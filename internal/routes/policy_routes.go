@@ -4,13 +4,17 @@ import (
 	"YufungProject/configs"
 	"YufungProject/internal/controller"
 	"YufungProject/internal/middleware"
+	"YufungProject/internal/repository"
 	"YufungProject/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
+// 批量重映射字典值会改写公司全部保单，需要系统配置维护权限（平台管理员不受限制）
+const policyDictionaryRemapPermission = "system_config:manage"
+
 // SetupPolicyRoutes 设置保单管理相关路由
-func SetupPolicyRoutes(router *gin.Engine, policyController *controller.PolicyController, changeRecordController *controller.ChangeRecordController, config *configs.Config) {
+func SetupPolicyRoutes(router *gin.Engine, policyController *controller.PolicyController, changeRecordController *controller.ChangeRecordController, rbacRepo repository.RBACRepository, config *configs.Config) {
 	// 初始化活动记录服务
 	activityLogService := service.NewActivityLogService()
 
@@ -23,10 +27,10 @@ func SetupPolicyRoutes(router *gin.Engine, policyController *controller.PolicyCo
 		policyGroup.GET("/statistics", policyController.GetPolicyStatistics)
 
 		// 导入导出功能
-		policyGroup.POST("/export", policyController.ExportPolicies)              // 导出保单数据
-		policyGroup.GET("/template", policyController.DownloadPolicyTemplate)     // 下载导入模板
-		policyGroup.POST("/import/preview", policyController.PreviewPolicyImport) // 预览导入数据
-		policyGroup.POST("/import", policyController.ImportPoliciesFromFile)      // 导入保单数据
+		policyGroup.POST("/export", middleware.UserRateLimitMiddleware("export"), policyController.ExportPolicies)         // 导出保单数据
+		policyGroup.GET("/template", policyController.DownloadPolicyTemplate)                                              // 下载导入模板
+		policyGroup.POST("/import/preview", policyController.PreviewPolicyImport)                                          // 预览导入数据
+		policyGroup.POST("/import", middleware.UserRateLimitMiddleware("import"), policyController.ImportPoliciesFromFile) // 导入保单数据
 
 		// 获取字段验证规则
 		policyGroup.GET("/validation-rules", policyController.GetPolicyValidationRules)
@@ -43,5 +47,11 @@ func SetupPolicyRoutes(router *gin.Engine, policyController *controller.PolicyCo
 
 		// 批量操作
 		policyGroup.POST("/batch-update", policyController.BatchUpdatePolicyStatus) // 批量更新状态
+
+		// 字典维护
+		policyGroup.POST("/dictionary-remap", middleware.PermissionRequiredMiddleware(rbacRepo, policyDictionaryRemapPermission), policyController.RemapDictionaryValues) // 批量重映射字典值
+
+		// 转介费
+		policyGroup.POST("/recalculate-fees", policyController.RecalculateReferralFees) // 按规则重算转介费
 	}
 }
@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"YufungProject/configs"
+	"YufungProject/internal/controller"
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupProductRoutes 设置产品目录相关路由
+func SetupProductRoutes(router *gin.Engine, productController *controller.ProductController, config *configs.Config) {
+	// 初始化活动记录服务
+	activityLogService := service.NewActivityLogService()
+
+	// 承保公司
+	insurerGroup := router.Group("/api/insurers")
+	insurerGroup.Use(middleware.AuthMiddleware(config))
+	insurerGroup.Use(middleware.ActivityLogMiddleware(activityLogService))
+	{
+		insurerGroup.GET("", productController.ListInsurers)         // 获取承保公司列表
+		insurerGroup.POST("", productController.CreateInsurer)       // 创建承保公司
+		insurerGroup.PUT("/:id", productController.UpdateInsurer)    // 更新承保公司
+		insurerGroup.DELETE("/:id", productController.DeleteInsurer) // 删除承保公司
+	}
+
+	// 保险产品
+	productGroup := router.Group("/api/products")
+	productGroup.Use(middleware.AuthMiddleware(config))
+	productGroup.Use(middleware.ActivityLogMiddleware(activityLogService))
+	{
+		// 导入导出（放在参数路由前面，避免被 :id 匹配）
+		productGroup.GET("/template", productController.DownloadProductTemplate)                                             // 下载导入模板
+		productGroup.GET("/export", middleware.UserRateLimitMiddleware("export"), productController.ExportProducts)          // 导出产品目录
+		productGroup.POST("/import", middleware.UserRateLimitMiddleware("import"), productController.ImportProductsFromFile) // 导入产品目录
+
+		// 产品基本操作
+		productGroup.POST("", productController.CreateProduct)       // 创建产品
+		productGroup.GET("", productController.ListProducts)         // 获取产品列表
+		productGroup.GET("/:id", productController.GetProduct)       // 获取产品详情
+		productGroup.PUT("/:id", productController.UpdateProduct)    // 更新产品
+		productGroup.DELETE("/:id", productController.DeleteProduct) // 删除产品
+	}
+}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"YufungProject/configs"
+	"YufungProject/internal/controller"
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupReferralFeeRoutes 设置转介费规则相关路由
+func SetupReferralFeeRoutes(router *gin.Engine, referralFeeController *controller.ReferralFeeController, config *configs.Config) {
+	// 初始化活动记录服务
+	activityLogService := service.NewActivityLogService()
+
+	ruleGroup := router.Group("/api/referral-fee-rules")
+	ruleGroup.Use(middleware.AuthMiddleware(config))
+	ruleGroup.Use(middleware.ActivityLogMiddleware(activityLogService))
+	{
+		ruleGroup.GET("", referralFeeController.ListRules)         // 获取规则列表
+		ruleGroup.POST("", referralFeeController.CreateRule)       // 创建规则
+		ruleGroup.GET("/:id", referralFeeController.GetRule)       // 获取规则详情
+		ruleGroup.PUT("/:id", referralFeeController.UpdateRule)    // 更新规则
+		ruleGroup.DELETE("/:id", referralFeeController.DeleteRule) // 删除规则
+	}
+}
@@ -1,12 +1,18 @@
 package routes
 
 import (
+	"context"
+	"time"
+
 	"YufungProject/configs"
 	"YufungProject/internal/controller"
 	"YufungProject/internal/middleware"
 	"YufungProject/internal/repository"
 	"YufungProject/internal/service"
 	"YufungProject/pkg/logger"
+	"YufungProject/pkg/mailer"
+	"YufungProject/pkg/ratelimit"
+	"YufungProject/pkg/storage"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -22,6 +28,11 @@ func SetupRoutes(db *mongo.Database, config *configs.Config) *gin.Engine {
 	// 创建Gin实例
 	router := gin.New()
 
+	// 只信任配置的反向代理转发的客户端IP，避免伪造 X-Forwarded-For 绕过IP白名单
+	if err := router.SetTrustedProxies(config.Server.TrustedProxies); err != nil {
+		logger.Fatalf("可信代理配置错误: %v", err)
+	}
+
 	// 添加日志中间件
 	router.Use(LoggerMiddleware())
 
@@ -56,36 +67,138 @@ func SetupRoutes(db *mongo.Database, config *configs.Config) *gin.Engine {
 		logger.Info("Swagger文档已启用: /swagger/index.html")
 	}
 
+	// 加载敏感字段加密密钥，须在初始化仓库层之前设置
+	fieldCodec, err := service.NewFieldCodec(config.Security.Encryption)
+	if err != nil {
+		logger.Fatalf("加载字段加密密钥失败: %v", err)
+	}
+	repository.SetFieldCodec(fieldCodec)
+
 	// 初始化仓库层
 	userRepo := repository.NewUserRepository(db)
 	companyRepo := repository.NewCompanyRepository(db, userRepo)
 	roleRepo := repository.NewRoleRepository(db)
 	menuRepo := repository.NewMenuRepository(db)
-	rbacRepo := repository.NewRBACRepository(db)                 // 启用RBAC仓库
-	policyRepo := repository.NewPolicyRepository(db)             // 添加保单仓库
-	systemConfigRepo := repository.NewSystemConfigRepository(db) // 添加系统配置仓库
-	changeRecordRepo := repository.NewChangeRecordRepository(db) // 添加变更记录仓库
+	rbacRepo := repository.NewRBACRepository(db)                         // 启用RBAC仓库
+	policyRepo := repository.NewPolicyRepository(db)                     // 添加保单仓库
+	systemConfigRepo := repository.NewSystemConfigRepository(db)         // 添加系统配置仓库
+	systemConfigTypeRepo := repository.NewSystemConfigTypeRepository(db) // 字典类型注册表仓库
+	changeRecordRepo := repository.NewChangeRecordRepository(db)         // 添加变更记录仓库
+	productRepo := repository.NewProductRepository(db)                   // 产品目录仓库
+	referralFeeRuleRepo := repository.NewReferralFeeRuleRepository(db)   // 转介费规则仓库
+	tableStructureRepo := repository.NewTableStructureRepository(db)     // 动态表结构仓库
+	customerRepo := repository.NewCustomerRepository(db)                 // 客户档案仓库
+	attachmentRepo := repository.NewPolicyAttachmentRepository(db)       // 保单附件仓库
+	notificationRepo := repository.NewNotificationRepository(db)         // 站内通知仓库
+	announcementRepo := repository.NewAnnouncementRepository(db)         // 通知公告仓库
+	mailRepo := repository.NewMailRepository(db)                         // 邮件发件箱仓库
+	passwordResetRepo := repository.NewPasswordResetRepository(db)       // 重置密码令牌仓库
+	passwordPolicyRepo := repository.NewPasswordPolicyRepository(db)     // 密码策略仓库
+	invitationRepo := repository.NewInvitationRepository(db)             // 用户邀请仓库
+	serviceAccountRepo := repository.NewServiceAccountRepository(db)     // 服务账号仓库
+	securityRepo := repository.NewSecurityRepository(db)                 // 安全事件与登录地点仓库
+	impersonationRepo := repository.NewImpersonationRepository(db)       // 模拟登录会话仓库
+
+	// 初始化文件存储
+	uploadStorage, err := storage.NewLocalStorage(config.Upload.Path)
+	if err != nil {
+		logger.Fatalf("初始化文件存储失败: %v", err)
+	}
+	maxUploadSize, err := config.Upload.MaxSizeBytes()
+	if err != nil {
+		logger.Fatalf("上传配置错误: %v", err)
+	}
+
+	// 加载JWT签名密钥，认证服务和认证中间件共用
+	jwtUtil, err := service.NewJWTUtil(config.JWT)
+	if err != nil {
+		logger.Fatalf("加载JWT签名密钥失败: %v", err)
+	}
+
+	// 初始化邮件发送器和模板
+	mailSender, err := service.NewMailSender(config.Mail)
+	if err != nil {
+		logger.Fatalf("初始化邮件发送器失败: %v", err)
+	}
+	mailRenderer, err := mailer.NewRenderer(config.Mail.DefaultLanguage)
+	if err != nil {
+		logger.Fatalf("加载邮件模板失败: %v", err)
+	}
 
 	// 初始化服务层
-	authService := service.NewAuthService(userRepo, config)
 	companyService := service.NewCompanyService(companyRepo, userRepo)
-	userService := service.NewUserService(userRepo, companyRepo)
 	roleService := service.NewRoleService(roleRepo, companyRepo, rbacRepo)
 	menuService := service.NewMenuService(menuRepo)
-	changeRecordService := service.NewChangeRecordService(changeRecordRepo, userRepo) // 添加变更记录服务
-	policyService := service.NewPolicyService(policyRepo, changeRecordService)        // 修改保单服务注入变更记录服务
-	systemConfigService := service.NewSystemConfigService(systemConfigRepo)           // 添加系统配置服务
+	changeRecordService := service.NewChangeRecordService(changeRecordRepo, userRepo, tableStructureRepo)                                                                                          // 添加变更记录服务
+	systemConfigService := service.NewSystemConfigService(systemConfigRepo, systemConfigTypeRepo, companyRepo)                                                                                     // 添加系统配置服务
+	productService := service.NewProductService(productRepo)                                                                                                                                       // 产品目录服务
+	referralFeeService := service.NewReferralFeeService(referralFeeRuleRepo, productRepo)                                                                                                          // 转介费规则服务
+	tableStructureService := service.NewTableStructureService(tableStructureRepo)                                                                                                                  // 动态表结构服务
+	customerService := service.NewCustomerService(customerRepo, policyRepo, changeRecordService)                                                                                                   // 客户档案服务
+	notificationService := service.NewNotificationService(notificationRepo, userRepo, companyRepo, rbacRepo, config.Notification)                                                                  // 站内通知服务
+	mailService := service.NewMailService(mailRepo, mailSender, mailRenderer, config.Mail)                                                                                                         // 邮件发送服务
+	passwordPolicyService := service.NewPasswordPolicyService(passwordPolicyRepo, userRepo, companyRepo, config.Security)                                                                          // 密码策略服务
+	securityService := service.NewSecurityService(securityRepo, companyRepo, notificationRepo)                                                                                                     // 公司IP白名单与登录地点提醒
+	sessionService := service.NewSessionService(userRepo, passwordPolicyService)                                                                                                                   // 会话吊销与强制修改密码校验
+	userService := service.NewUserService(userRepo, companyRepo, passwordPolicyService, sessionService)                                                                                            // 用户服务，密码按策略校验
+	authService := service.NewAuthService(userRepo, passwordResetRepo, sessionService, passwordPolicyService, securityService, service.NewMailPasswordResetNotifier(mailService), jwtUtil, config) // 认证服务，重置密码链接通过邮件发送
+	invitationService := service.NewInvitationService(invitationRepo, userRepo, companyRepo, roleRepo, passwordPolicyService, mailService, config.Security.Invitation)                             // 邀请注册服务
+	serviceAccountService := service.NewServiceAccountService(serviceAccountRepo, companyRepo, rbacRepo)                                                                                           // 服务账号服务
+	impersonationService := service.NewImpersonationService(impersonationRepo, userRepo, sessionService, jwtUtil, config)                                                                          // 平台管理员模拟登录服务
+	announcementService := service.NewAnnouncementService(announcementRepo, userRepo, companyRepo, roleRepo)                                                                                       // 通知公告服务
+	attachmentService := service.NewPolicyAttachmentService(attachmentRepo, policyRepo, userRepo, changeRecordService, uploadStorage, maxUploadSize, config.Upload.AllowedTypes)                   // 保单附件服务
+	policyService := service.NewPolicyService(policyRepo, changeRecordService, systemConfigService, productService, referralFeeService, tableStructureService, customerService, attachmentService) // 保单服务注入变更记录、字典校验、产品目录、转介费规则、自定义字段、客户档案与附件
+
+	// 敏感字段脱敏服务：无查看权限时脱敏，完整查看记录为查看活动
+	fieldMaskingService, err := service.NewFieldMaskingService(rbacRepo, service.NewActivityLogService(), config.Security.Masking)
+	if err != nil {
+		logger.Fatalf("脱敏规则配置错误: %v", err)
+	}
 
 	// 初始化控制器层
-	authController := controller.NewAuthController(authService)
+	authController := controller.NewAuthController(authService, announcementService)
 	companyController := controller.NewCompanyController(companyService)
 	userController := controller.NewUserController(userService, companyService)
 	roleController := controller.NewRoleController(roleService)
 	menuController := controller.NewMenuController(menuService)
-	policyController := controller.NewPolicyController(policyService)                   // 添加保单控制器
-	systemConfigController := controller.NewSystemConfigController(systemConfigService) // 添加系统配置控制器
-	changeRecordController := controller.NewChangeRecordController(changeRecordService) // 添加变更记录控制器
-	activityLogController := controller.NewActivityLogController()                      // 添加活动记录控制器
+	policyController := controller.NewPolicyController(policyService)                         // 添加保单控制器
+	systemConfigController := controller.NewSystemConfigController(systemConfigService)       // 添加系统配置控制器
+	changeRecordController := controller.NewChangeRecordController(changeRecordService)       // 添加变更记录控制器
+	activityLogController := controller.NewActivityLogController()                            // 添加活动记录控制器
+	productController := controller.NewProductController(productService)                      // 产品目录控制器
+	referralFeeController := controller.NewReferralFeeController(referralFeeService)          // 转介费规则控制器
+	tableStructureController := controller.NewTableStructureController(tableStructureService) // 动态表结构控制器
+	customerController := controller.NewCustomerController(customerService)                   // 客户档案控制器
+	attachmentController := controller.NewPolicyAttachmentController(attachmentService)       // 保单附件控制器
+	notificationController := controller.NewNotificationController(notificationService)       // 站内通知控制器
+	announcementController := controller.NewAnnouncementController(announcementService)       // 通知公告控制器
+	mailController := controller.NewMailController(mailService)                               // 邮件管理控制器
+	passwordPolicyController := controller.NewPasswordPolicyController(passwordPolicyService) // 密码策略控制器
+	invitationController := controller.NewInvitationController(invitationService)             // 邀请管理控制器
+	serviceAccountController := controller.NewServiceAccountController(serviceAccountService) // 服务账号控制器
+	securityController := controller.NewSecurityController(securityService)                   // 安全管理控制器
+	impersonationController := controller.NewImpersonationController(impersonationService)    // 模拟登录控制器
+
+	// 认证中间件按令牌头的 kid 选择校验密钥
+	middleware.SetJWTUtil(jwtUtil)
+
+	// 认证中间件校验会话是否已被吊销（如重置密码后）、是否须先修改密码
+	middleware.SetSessionChecker(sessionService)
+
+	// 认证中间件接受服务账号的API密钥
+	middleware.SetAPIKeyAuthenticator(serviceAccountService)
+
+	// 认证中间件按公司IP白名单限制用户访问
+	middleware.SetIPAccessChecker(securityService)
+
+	// 认证中间件校验模拟登录会话，只读模拟登录拦截写操作
+	middleware.SetImpersonationChecker(impersonationService)
+
+	// 认证中间件按查看人权限为请求创建敏感字段脱敏器
+	middleware.SetFieldMasking(fieldMaskingService)
+
+	// 认证接口及导出、导入等耗资源接口限流
+	middleware.SetRateLimiter(newRateLimiter(config), config.RateLimit)
 
 	// 设置认证相关路由
 	SetupAuthRoutes(router, authController, config)
@@ -103,7 +216,49 @@ func SetupRoutes(db *mongo.Database, config *configs.Config) *gin.Engine {
 	SetupMenuRoutes(router, menuController, config)
 
 	// 设置保单管理相关路由
-	SetupPolicyRoutes(router, policyController, changeRecordController, config)
+	SetupPolicyRoutes(router, policyController, changeRecordController, rbacRepo, config)
+
+	// 设置产品目录相关路由
+	SetupProductRoutes(router, productController, config)
+
+	// 设置转介费规则相关路由
+	SetupReferralFeeRoutes(router, referralFeeController, config)
+
+	// 设置客户管理相关路由
+	SetupCustomerRoutes(router, customerController, config)
+
+	// 设置保单附件相关路由
+	SetupPolicyAttachmentRoutes(router, attachmentController, config)
+
+	// 设置通知中心相关路由
+	SetupNotificationRoutes(router, notificationController, config)
+
+	// 设置通知公告相关路由
+	SetupAnnouncementRoutes(router, announcementController, rbacRepo, config)
+
+	// 设置邮件管理相关路由
+	SetupMailRoutes(router, mailController, config)
+
+	// 设置密码策略相关路由
+	SetupPasswordPolicyRoutes(router, passwordPolicyController, rbacRepo, config)
+
+	// 设置邀请注册相关路由
+	SetupInvitationRoutes(router, invitationController, rbacRepo, config)
+
+	// 设置服务账号相关路由
+	SetupServiceAccountRoutes(router, serviceAccountController, rbacRepo, config)
+
+	// 设置安全管理相关路由
+	SetupSecurityRoutes(router, securityController, rbacRepo, config)
+
+	// 设置模拟登录相关路由
+	SetupImpersonationRoutes(router, impersonationController, config)
+
+	// 启动通知提醒定时任务
+	notificationService.StartScheduler(context.Background())
+
+	// 启动发件箱定时发送任务
+	mailService.StartWorker(context.Background())
 
 	// 设置变更记录相关路由
 	SetupChangeRecordRoutes(router, changeRecordController, config)
@@ -113,7 +268,10 @@ func SetupRoutes(db *mongo.Database, config *configs.Config) *gin.Engine {
 
 	// 设置系统配置相关路由
 	api := router.Group("/api")
-	RegisterSystemConfigRoutes(api, systemConfigController, config)
+	RegisterSystemConfigRoutes(api, systemConfigController, rbacRepo, config)
+
+	// 设置动态表结构相关路由
+	RegisterTableStructureRoutes(api, tableStructureController, config)
 
 	logger.Info("所有路由设置完成")
 	return router
@@ -134,3 +292,30 @@ func LoggerMiddleware() gin.HandlerFunc {
 		return ""
 	})
 }
+
+// newRateLimiter 创建限流器：配置为redis时优先使用Redis，连接失败或运行中出错时退回进程内存限流
+func newRateLimiter(config *configs.Config) ratelimit.Limiter {
+	memoryLimiter := ratelimit.NewMemoryLimiter()
+	if !config.RateLimit.Enabled || config.RateLimit.Backend != "redis" {
+		return memoryLimiter
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	redisLimiter, err := ratelimit.NewRedisLimiter(ctx, ratelimit.RedisOptions{
+		Addr:     config.Redis.Addr,
+		Password: config.Redis.Password,
+		DB:       config.Redis.DB,
+		PoolSize: config.Redis.PoolSize,
+		Prefix:   config.RateLimit.KeyPrefix,
+	})
+	if err != nil {
+		logger.Warnf("Redis限流不可用，使用进程内存限流: %v", err)
+		return memoryLimiter
+	}
+
+	logger.Infof("限流使用Redis: %s", config.Redis.Addr)
+	return ratelimit.WithFallback(redisLimiter, memoryLimiter, func(err error) {
+		logger.Errorf("Redis限流失败，使用进程内存限流: %v", err)
+	})
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"YufungProject/configs"
+	"YufungProject/internal/controller"
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/repository"
+	"YufungProject/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 安全管理权限标识（平台管理员不受限制）
+const securityManagePermission = "security:manage"
+
+// SetupSecurityRoutes 设置安全管理相关路由
+func SetupSecurityRoutes(router *gin.Engine, securityController *controller.SecurityController, rbacRepo repository.RBACRepository, config *configs.Config) {
+	// 初始化活动记录服务
+	activityLogService := service.NewActivityLogService()
+
+	// 安全管理：平台管理员管理所有公司，公司用户按权限管理本公司
+	securityGroup := router.Group("/api/security")
+	securityGroup.Use(middleware.AuthMiddleware(config))
+	securityGroup.Use(middleware.ActivityLogMiddleware(activityLogService))
+	securityGroup.Use(middleware.PermissionRequiredMiddleware(rbacRepo, securityManagePermission))
+	{
+		securityGroup.GET("/companies/:id/ip-allowlist", securityController.GetCompanyIPAllowlist)    // 获取公司IP白名单
+		securityGroup.PUT("/companies/:id/ip-allowlist", securityController.UpdateCompanyIPAllowlist) // 设置公司IP白名单
+		securityGroup.GET("/events", securityController.ListSecurityEvents)                           // 安全事件（被拒绝的访问、新IP或新设备登录）
+	}
+}
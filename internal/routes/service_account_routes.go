@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"net/http"
+
+	"YufungProject/configs"
+	"YufungProject/internal/controller"
+	"YufungProject/internal/middleware"
+	"YufungProject/internal/model"
+	"YufungProject/internal/repository"
+	"YufungProject/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 服务账号管理权限标识（平台管理员不受限制）
+const serviceAccountManagePermission = "service_account:manage"
+
+// SetupServiceAccountRoutes 设置服务账号相关路由，并登记允许API密钥访问的接口
+func SetupServiceAccountRoutes(router *gin.Engine, serviceAccountController *controller.ServiceAccountController, rbacRepo repository.RBACRepository, config *configs.Config) {
+	// 初始化活动记录服务
+	activityLogService := service.NewActivityLogService()
+
+	// 合作机构系统可通过API密钥推送客户、保单及查询状态，其他接口拒绝API密钥访问
+	middleware.RegisterAPIKeyRoutes(
+		middleware.APIKeyRoute{Method: http.MethodPost, Path: "/api/customers", Scope: model.ScopeCustomerCreate},
+		middleware.APIKeyRoute{Method: http.MethodGet, Path: "/api/customers", Scope: model.ScopeCustomerView},
+		middleware.APIKeyRoute{Method: http.MethodGet, Path: "/api/customers/:id", Scope: model.ScopeCustomerView},
+		middleware.APIKeyRoute{Method: http.MethodPost, Path: "/api/policies", Scope: model.ScopePolicyCreate},
+		middleware.APIKeyRoute{Method: http.MethodGet, Path: "/api/policies", Scope: model.ScopePolicyView},
+		middleware.APIKeyRoute{Method: http.MethodGet, Path: "/api/policies/:id", Scope: model.ScopePolicyView},
+	)
+
+	// 服务账号管理：平台管理员管理所有公司，公司用户按权限管理本公司
+	serviceAccountGroup := router.Group("/api/service-accounts")
+	serviceAccountGroup.Use(middleware.AuthMiddleware(config))
+	serviceAccountGroup.Use(middleware.ActivityLogMiddleware(activityLogService))
+	serviceAccountGroup.Use(middleware.PermissionRequiredMiddleware(rbacRepo, serviceAccountManagePermission))
+	{
+		serviceAccountGroup.POST("", serviceAccountController.CreateServiceAccount)            // 创建服务账号
+		serviceAccountGroup.GET("", serviceAccountController.ListServiceAccounts)              // 获取服务账号列表
+		serviceAccountGroup.GET("/:id", serviceAccountController.GetServiceAccount)            // 获取服务账号详情
+		serviceAccountGroup.PUT("/:id", serviceAccountController.UpdateServiceAccount)         // 更新服务账号
+		serviceAccountGroup.DELETE("/:id", serviceAccountController.DeleteServiceAccount)      // 删除服务账号
+		serviceAccountGroup.POST("/:id/keys", serviceAccountController.CreateAPIKey)           // 签发（轮换）API密钥
+		serviceAccountGroup.DELETE("/:id/keys/:key_id", serviceAccountController.RevokeAPIKey) // 吊销API密钥
+	}
+}
@@ -6,22 +6,43 @@ import (
 	"YufungProject/configs"
 	"YufungProject/internal/controller"
 	"YufungProject/internal/middleware"
+	"YufungProject/internal/repository"
 )
 
+// 系统配置维护权限标识（平台管理员不受限制）
+const systemConfigManagePermission = "system_config:manage"
+
 // RegisterSystemConfigRoutes 注册系统配置相关路由
-func RegisterSystemConfigRoutes(r *gin.RouterGroup, systemConfigController *controller.SystemConfigController, config *configs.Config) {
+func RegisterSystemConfigRoutes(r *gin.RouterGroup, systemConfigController *controller.SystemConfigController, rbacRepo repository.RBACRepository, config *configs.Config) {
 	// 系统配置管理路由组
 	systemConfigGroup := r.Group("/system-configs")
 	systemConfigGroup.Use(middleware.AuthMiddleware(config)) // 需要认证
 
+	manageConfig := middleware.PermissionRequiredMiddleware(rbacRepo, systemConfigManagePermission)
+
 	{
-		systemConfigGroup.GET("", systemConfigController.ListSystemConfigs)         // 获取系统配置列表
-		systemConfigGroup.POST("", systemConfigController.CreateSystemConfig)       // 创建系统配置
-		systemConfigGroup.GET("/:id", systemConfigController.GetSystemConfig)       // 获取系统配置详情
-		systemConfigGroup.PUT("/:id", systemConfigController.UpdateSystemConfig)    // 更新系统配置
-		systemConfigGroup.DELETE("/:id", systemConfigController.DeleteSystemConfig) // 删除系统配置
+		systemConfigGroup.GET("", systemConfigController.ListSystemConfigs)                       // 获取系统配置列表
+		systemConfigGroup.POST("", manageConfig, systemConfigController.CreateSystemConfig)       // 创建系统配置
+		systemConfigGroup.GET("/:id", systemConfigController.GetSystemConfig)                     // 获取系统配置详情
+		systemConfigGroup.PUT("/:id", manageConfig, systemConfigController.UpdateSystemConfig)    // 更新系统配置
+		systemConfigGroup.DELETE("/:id", manageConfig, systemConfigController.DeleteSystemConfig) // 删除系统配置
+
+		// 公司对平台配置项的覆盖
+		systemConfigGroup.PUT("/:id/override", manageConfig, systemConfigController.SetConfigOverride)       // 隐藏或覆盖平台配置项
+		systemConfigGroup.DELETE("/:id/override", manageConfig, systemConfigController.RemoveConfigOverride) // 恢复为平台配置
 
 		// 获取配置选项
 		systemConfigGroup.GET("/options/:type", systemConfigController.GetConfigOptions) // 根据类型获取配置选项
 	}
+
+	// 字典类型注册表路由组
+	configTypeGroup := r.Group("/system-config-types")
+	configTypeGroup.Use(middleware.AuthMiddleware(config)) // 需要认证
+
+	{
+		configTypeGroup.GET("", systemConfigController.ListConfigTypes)                       // 获取字典类型列表
+		configTypeGroup.POST("", manageConfig, systemConfigController.CreateConfigType)       // 注册字典类型
+		configTypeGroup.PUT("/:id", manageConfig, systemConfigController.UpdateConfigType)    // 更新字典类型
+		configTypeGroup.DELETE("/:id", manageConfig, systemConfigController.DeleteConfigType) // 删除字典类型
+	}
 }
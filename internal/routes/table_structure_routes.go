@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"YufungProject/configs"
+	"YufungProject/internal/controller"
+	"YufungProject/internal/middleware"
+)
+
+// RegisterTableStructureRoutes 注册动态表结构相关路由
+func RegisterTableStructureRoutes(r *gin.RouterGroup, tableStructureController *controller.TableStructureController, config *configs.Config) {
+	// 动态表结构路由组
+	tableGroup := r.Group("/table-structures")
+	tableGroup.Use(middleware.AuthMiddleware(config)) // 需要认证
+
+	{
+		tableGroup.GET("", tableStructureController.ListTables)         // 获取表结构列表
+		tableGroup.POST("", tableStructureController.CreateTable)       // 创建表结构
+		tableGroup.GET("/:id", tableStructureController.GetTable)       // 获取表结构详情（含字段）
+		tableGroup.PUT("/:id", tableStructureController.UpdateTable)    // 更新表结构
+		tableGroup.DELETE("/:id", tableStructureController.DeleteTable) // 删除表结构
+
+		// 字段定义
+		tableGroup.GET("/:id/fields", tableStructureController.ListFields)                             // 获取字段列表
+		tableGroup.POST("/:id/fields", tableStructureController.CreateField)                           // 新增字段
+		tableGroup.PUT("/:id/fields/sort", tableStructureController.SortFields)                        // 字段排序
+		tableGroup.PUT("/:id/fields/:fieldId", tableStructureController.UpdateField)                   // 更新字段
+		tableGroup.DELETE("/:id/fields/:fieldId", tableStructureController.DeleteField)                // 删除字段
+		tableGroup.PUT("/:id/fields/:fieldId/visibility", tableStructureController.SetFieldVisibility) // 设置字段显示
+		tableGroup.POST("/:id/fields/:fieldId/migrate", tableStructureController.MigrateField)         // 字段重命名/改类型并迁移数据
+
+		// 结构版本
+		tableGroup.GET("/:id/versions", tableStructureController.ListVersions)        // 获取版本列表
+		tableGroup.GET("/:id/versions/diff", tableStructureController.DiffVersions)   // 对比版本
+		tableGroup.GET("/:id/versions/:version", tableStructureController.GetVersion) // 获取版本详情
+	}
+}
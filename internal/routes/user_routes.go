@@ -41,12 +41,12 @@ func SetupUserRoutes(router *gin.Engine, userController *controller.UserControll
 		userGroup.PUT("/:id/quick-disable", userController.QuickDisableUser) // 快捷停用用户
 
 		// 数据导出
-		userGroup.GET("/export", userController.ExportUsers) // 导出用户数据
+		userGroup.GET("/export", middleware.UserRateLimitMiddleware("export"), userController.ExportUsers) // 导出用户数据
 
 		// 高级导入导出功能
-		userGroup.POST("/export-advanced", userController.ExportUsersAdvanced) // 高级导出
-		userGroup.GET("/template", userController.DownloadUserTemplate)        // 下载模板
-		userGroup.POST("/import/preview", userController.PreviewUserImport)    // 预览导入
-		userGroup.POST("/import", userController.ImportUsers)                  // 导入用户
+		userGroup.POST("/export-advanced", middleware.UserRateLimitMiddleware("export"), userController.ExportUsersAdvanced) // 高级导出
+		userGroup.GET("/template", userController.DownloadUserTemplate)                                                      // 下载模板
+		userGroup.POST("/import/preview", userController.PreviewUserImport)                                                  // 预览导入
+		userGroup.POST("/import", middleware.UserRateLimitMiddleware("import"), userController.ImportUsers)                  // 导入用户
 	}
 }
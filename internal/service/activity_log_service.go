@@ -21,6 +21,11 @@ func (s *ActivityLogService) CreateActivityLog(ctx context.Context, log *model.A
 	return s.repo.Create(ctx, log)
 }
 
+// CreateActivityLogs 批量创建活动记录
+func (s *ActivityLogService) CreateActivityLogs(ctx context.Context, logs []*model.ActivityLog) error {
+	return s.repo.CreateMany(ctx, logs)
+}
+
 // GetActivityLogList 获取活动记录列表
 func (s *ActivityLogService) GetActivityLogList(ctx context.Context, query *model.ActivityLogQuery) (*model.ActivityLogResponse, error) {
 	return s.repo.GetList(ctx, query)
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+)
+
+// Actor 发起请求的操作人，由认证中间件写入请求上下文，用于变更记录等区分用户与服务账号
+type Actor struct {
+	ID   string // 用户ID或服务账号ID
+	Name string // 用户名或服务账号名称
+	Type string // model.ActorTypeUser / model.ActorTypeServiceAccount
+
+	ImpersonatorID   string // 模拟登录时的实际操作人（平台管理员）用户ID
+	ImpersonatorName string // 模拟登录时的实际操作人用户名
+}
+
+type actorContextKey struct{}
+
+// WithActor 将操作人写入上下文
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext 从上下文获取操作人
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorContextKey{}).(Actor)
+	return actor, ok
+}
@@ -0,0 +1,520 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"YufungProject/internal/model"
+	"YufungProject/internal/repository"
+	"YufungProject/pkg/logger"
+	"YufungProject/pkg/utils"
+)
+
+// announcementPriorityOrder 公告优先级排序权重，数值越小越靠前
+var announcementPriorityOrder = map[string]int{
+	model.AnnouncementPriorityUrgent: 0,
+	model.AnnouncementPriorityHigh:   1,
+	model.AnnouncementPriorityNormal: 2,
+	model.AnnouncementPriorityLow:    3,
+}
+
+type AnnouncementService struct {
+	announcementRepo *repository.AnnouncementRepository
+	userRepo         repository.UserRepository
+	companyRepo      repository.CompanyRepository
+	roleRepo         repository.RoleRepository
+}
+
+func NewAnnouncementService(announcementRepo *repository.AnnouncementRepository, userRepo repository.UserRepository, companyRepo repository.CompanyRepository, roleRepo repository.RoleRepository) *AnnouncementService {
+	return &AnnouncementService{
+		announcementRepo: announcementRepo,
+		userRepo:         userRepo,
+		companyRepo:      companyRepo,
+		roleRepo:         roleRepo,
+	}
+}
+
+// ==========================
+// 公告管理
+// ==========================
+
+// CreateAnnouncement 创建公告，ownerCompanyID为空表示平台公告
+func (s *AnnouncementService) CreateAnnouncement(ctx context.Context, req *model.AnnouncementCreateRequest, userID, ownerCompanyID string) (*model.Announcement, error) {
+	now := time.Now()
+	announcement := &model.Announcement{
+		CompanyID:       ownerCompanyID,
+		Title:           strings.TrimSpace(req.Title),
+		Content:         utils.SanitizeRichText(req.Content),
+		Priority:        req.Priority,
+		Status:          model.AnnouncementStatusDraft,
+		RequireAck:      req.RequireAck,
+		AudienceType:    req.AudienceType,
+		TargetCompanies: uniqueStrings(req.TargetCompanies),
+		TargetRoles:     uniqueStrings(req.TargetRoles),
+		PublishAt:       now,
+		ExpireAt:        req.ExpireAt,
+		CreatedBy:       userID,
+		UpdatedBy:       userID,
+	}
+	if announcement.Priority == "" {
+		announcement.Priority = model.AnnouncementPriorityNormal
+	}
+	if req.PublishAt != nil {
+		announcement.PublishAt = *req.PublishAt
+	}
+	if req.Publish {
+		announcement.Status = model.AnnouncementStatusPublished
+		announcement.PublishedAt = &now
+	}
+
+	if err := s.validateAnnouncement(ctx, announcement); err != nil {
+		return nil, err
+	}
+
+	if user, err := s.userRepo.GetByUserID(ctx, userID); err == nil && user != nil {
+		announcement.CreatedByName = user.DisplayName
+	}
+
+	if err := s.announcementRepo.CreateAnnouncement(ctx, announcement); err != nil {
+		logger.Errorf("创建公告失败: %v", err)
+		return nil, fmt.Errorf("创建公告失败: %w", err)
+	}
+
+	logger.BusinessLog("通知公告", "创建公告", userID, fmt.Sprintf("AnnouncementID=%s, Company=%s, Status=%s", announcement.AnnouncementID, ownerCompanyID, announcement.Status))
+	return announcement, nil
+}
+
+// UpdateAnnouncement 更新公告；已发布且需确认的公告修改标题或内容后，受众需重新确认
+func (s *AnnouncementService) UpdateAnnouncement(ctx context.Context, announcementID string, req *model.AnnouncementUpdateRequest, userID, ownerCompanyID string) (*model.Announcement, error) {
+	announcement, err := s.getManagedAnnouncement(ctx, announcementID, ownerCompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	contentChanged := false
+	if req.Title != nil && strings.TrimSpace(*req.Title) != announcement.Title {
+		announcement.Title = strings.TrimSpace(*req.Title)
+		contentChanged = true
+	}
+	if req.Content != nil {
+		content := utils.SanitizeRichText(*req.Content)
+		if content != announcement.Content {
+			announcement.Content = content
+			contentChanged = true
+		}
+	}
+	if req.Priority != nil {
+		announcement.Priority = *req.Priority
+	}
+	if req.RequireAck != nil {
+		announcement.RequireAck = *req.RequireAck
+	}
+	if req.AudienceType != nil {
+		announcement.AudienceType = *req.AudienceType
+	}
+	if req.TargetCompanies != nil {
+		announcement.TargetCompanies = uniqueStrings(req.TargetCompanies)
+	}
+	if req.TargetRoles != nil {
+		announcement.TargetRoles = uniqueStrings(req.TargetRoles)
+	}
+	if req.PublishAt != nil {
+		announcement.PublishAt = *req.PublishAt
+	}
+	if req.ClearExpireAt {
+		announcement.ExpireAt = nil
+	} else if req.ExpireAt != nil {
+		announcement.ExpireAt = req.ExpireAt
+	}
+	if req.Status != nil && *req.Status != announcement.Status {
+		announcement.Status = *req.Status
+		if announcement.Status == model.AnnouncementStatusPublished && announcement.PublishedAt == nil {
+			now := time.Now()
+			announcement.PublishedAt = &now
+		}
+	}
+
+	if err := s.validateAnnouncement(ctx, announcement); err != nil {
+		return nil, err
+	}
+
+	set := bson.M{
+		"title":            announcement.Title,
+		"content":          announcement.Content,
+		"priority":         announcement.Priority,
+		"status":           announcement.Status,
+		"require_ack":      announcement.RequireAck,
+		"audience_type":    announcement.AudienceType,
+		"target_companies": announcement.TargetCompanies,
+		"target_roles":     announcement.TargetRoles,
+		"publish_at":       announcement.PublishAt,
+		"updated_by":       userID,
+	}
+	unset := bson.M{}
+	if announcement.ExpireAt != nil {
+		set["expire_at"] = announcement.ExpireAt
+	} else {
+		unset["expire_at"] = ""
+	}
+	if announcement.PublishedAt != nil {
+		set["published_at"] = announcement.PublishedAt
+	}
+
+	if err := s.announcementRepo.UpdateAnnouncement(ctx, announcementID, set, unset); err != nil {
+		logger.Errorf("更新公告失败: %v", err)
+		return nil, fmt.Errorf("更新公告失败: %w", err)
+	}
+
+	if contentChanged && announcement.RequireAck && announcement.PublishedAt != nil {
+		if err := s.announcementRepo.ResetAcknowledgements(ctx, announcementID); err != nil {
+			logger.Errorf("清除公告确认记录失败: AnnouncementID=%s, Error=%v", announcementID, err)
+		}
+	}
+
+	logger.BusinessLog("通知公告", "更新公告", userID, fmt.Sprintf("AnnouncementID=%s, Status=%s, ContentChanged=%v", announcementID, announcement.Status, contentChanged))
+	return s.announcementRepo.GetAnnouncementByID(ctx, announcementID)
+}
+
+// DeleteAnnouncement 删除公告及其阅读回执
+func (s *AnnouncementService) DeleteAnnouncement(ctx context.Context, announcementID, userID, ownerCompanyID string) error {
+	if _, err := s.getManagedAnnouncement(ctx, announcementID, ownerCompanyID); err != nil {
+		return err
+	}
+
+	if err := s.announcementRepo.DeleteAnnouncement(ctx, announcementID); err != nil {
+		logger.Errorf("删除公告失败: %v", err)
+		return fmt.Errorf("删除公告失败: %w", err)
+	}
+
+	logger.BusinessLog("通知公告", "删除公告", userID, fmt.Sprintf("AnnouncementID=%s", announcementID))
+	return nil
+}
+
+// GetManagedAnnouncement 获取发布方的公告详情
+func (s *AnnouncementService) GetManagedAnnouncement(ctx context.Context, announcementID, ownerCompanyID string) (*model.Announcement, error) {
+	return s.getManagedAnnouncement(ctx, announcementID, ownerCompanyID)
+}
+
+// ListManagedAnnouncements 分页查询发布方的公告，附带阅读统计
+func (s *AnnouncementService) ListManagedAnnouncements(ctx context.Context, req *model.AnnouncementManageQueryRequest, ownerCompanyID string) (*model.AnnouncementManageListResponse, error) {
+	announcements, total, err := s.announcementRepo.ListManagedAnnouncements(ctx, req, ownerCompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("查询公告列表失败: %w", err)
+	}
+
+	ids := make([]string, 0, len(announcements))
+	for _, announcement := range announcements {
+		ids = append(ids, announcement.AnnouncementID)
+	}
+	counts := map[string][2]int64{}
+	if len(ids) > 0 {
+		if counts, err = s.announcementRepo.CountReceipts(ctx, ids); err != nil {
+			return nil, fmt.Errorf("统计公告回执失败: %w", err)
+		}
+	}
+
+	items := make([]model.AnnouncementManageItem, 0, len(announcements))
+	for _, announcement := range announcements {
+		count := counts[announcement.AnnouncementID]
+		items = append(items, model.AnnouncementManageItem{
+			Announcement: announcement,
+			ReadCount:    count[0],
+			AckCount:     count[1],
+		})
+	}
+
+	return &model.AnnouncementManageListResponse{
+		List:     items,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// GetReceipts 获取公告的受众回执明细，未读的排在前面
+func (s *AnnouncementService) GetReceipts(ctx context.Context, announcementID, ownerCompanyID string) (*model.AnnouncementReceiptResponse, error) {
+	announcement, err := s.getManagedAnnouncement(ctx, announcementID, ownerCompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	users, _, err := s.userRepo.List(ctx, audienceUserFilter(announcement), 1, 0)
+	if err != nil {
+		return nil, fmt.Errorf("查询公告受众失败: %w", err)
+	}
+	reads, err := s.announcementRepo.ListAnnouncementReads(ctx, announcementID)
+	if err != nil {
+		return nil, fmt.Errorf("查询公告回执失败: %w", err)
+	}
+	readByUser := make(map[string]*model.AnnouncementRead, len(reads))
+	for i := range reads {
+		readByUser[reads[i].UserID] = &reads[i]
+	}
+
+	result := &model.AnnouncementReceiptResponse{
+		AnnouncementID: announcementID,
+		AudienceCount:  len(users),
+		List:           make([]model.AnnouncementReceiptItem, 0, len(users)),
+	}
+	for _, user := range users {
+		item := model.AnnouncementReceiptItem{
+			UserID:      user.UserID,
+			Username:    user.Username,
+			DisplayName: user.DisplayName,
+			CompanyID:   user.CompanyID,
+		}
+		if read := readByUser[user.UserID]; read != nil {
+			readAt := read.ReadAt
+			item.ReadAt = &readAt
+			item.AcknowledgedAt = read.AcknowledgedAt
+			result.ReadCount++
+			if read.AcknowledgedAt != nil {
+				result.AckCount++
+			}
+		}
+		result.List = append(result.List, item)
+	}
+	sort.SliceStable(result.List, func(i, j int) bool {
+		return result.List[i].ReadAt == nil && result.List[j].ReadAt != nil
+	})
+
+	return result, nil
+}
+
+// getManagedAnnouncement 获取属于发布方的公告，不属于时视为不存在
+func (s *AnnouncementService) getManagedAnnouncement(ctx context.Context, announcementID, ownerCompanyID string) (*model.Announcement, error) {
+	announcement, err := s.announcementRepo.GetAnnouncementByID(ctx, announcementID)
+	if err != nil {
+		return nil, fmt.Errorf("查询公告失败: %w", err)
+	}
+	if announcement == nil || announcement.CompanyID != ownerCompanyID {
+		return nil, errors.New("公告不存在")
+	}
+	return announcement, nil
+}
+
+// validateAnnouncement 校验公告内容、有效期和受众
+func (s *AnnouncementService) validateAnnouncement(ctx context.Context, announcement *model.Announcement) error {
+	if announcement.Title == "" {
+		return errors.New("公告标题不能为空")
+	}
+	if strings.TrimSpace(utils.StripHTML(announcement.Content)) == "" && !strings.Contains(announcement.Content, "<img") {
+		return errors.New("公告内容不能为空")
+	}
+	if announcement.ExpireAt != nil && !announcement.ExpireAt.After(announcement.PublishAt) {
+		return errors.New("失效时间必须晚于生效时间")
+	}
+
+	switch announcement.AudienceType {
+	case model.AnnouncementAudienceAll:
+		announcement.TargetCompanies = []string{}
+		announcement.TargetRoles = []string{}
+	case model.AnnouncementAudienceCompanies:
+		if announcement.CompanyID != "" {
+			return errors.New("公司公告不能指定其他公司为受众")
+		}
+		if len(announcement.TargetCompanies) == 0 {
+			return errors.New("请选择受众公司")
+		}
+		for _, companyID := range announcement.TargetCompanies {
+			company, err := s.companyRepo.GetCompanyByID(ctx, companyID)
+			if err != nil {
+				return fmt.Errorf("查询公司失败: %w", err)
+			}
+			if company == nil {
+				return fmt.Errorf("受众公司不存在: %s", companyID)
+			}
+		}
+		announcement.TargetRoles = []string{}
+	case model.AnnouncementAudienceRoles:
+		if len(announcement.TargetRoles) == 0 {
+			return errors.New("请选择受众角色")
+		}
+		roles, err := s.roleRepo.GetRolesByIDs(ctx, announcement.TargetRoles)
+		if err != nil {
+			return err
+		}
+		found := make(map[string]bool, len(roles))
+		for _, role := range roles {
+			// 公司公告只能选择平台级角色或本公司角色
+			if announcement.CompanyID == "" || role.CompanyID == "" || role.CompanyID == announcement.CompanyID {
+				found[role.RoleID] = true
+			}
+		}
+		for _, roleID := range announcement.TargetRoles {
+			if !found[roleID] {
+				return fmt.Errorf("受众角色不存在: %s", roleID)
+			}
+		}
+		announcement.TargetCompanies = []string{}
+	default:
+		return errors.New("受众类型无效")
+	}
+
+	return nil
+}
+
+// audienceUserFilter 公告受众用户的查询条件
+func audienceUserFilter(announcement *model.Announcement) bson.M {
+	filter := bson.M{"status": bson.M{"$ne": "inactive"}}
+	if announcement.CompanyID != "" {
+		filter["company_id"] = announcement.CompanyID
+	}
+	switch announcement.AudienceType {
+	case model.AnnouncementAudienceCompanies:
+		filter["company_id"] = bson.M{"$in": announcement.TargetCompanies}
+	case model.AnnouncementAudienceRoles:
+		filter["role_ids"] = bson.M{"$in": announcement.TargetRoles}
+	}
+	return filter
+}
+
+// ==========================
+// 公告查看
+// ==========================
+
+// ListAnnouncements 分页查询当前用户可见的公告，附带本人阅读状态
+func (s *AnnouncementService) ListAnnouncements(ctx context.Context, req *model.AnnouncementQueryRequest, userID, companyID string, roleIDs []string) (*model.AnnouncementListResponse, error) {
+	now := time.Now()
+
+	var excludeIDs []string
+	if req.UnreadOnly {
+		reads, err := s.announcementRepo.ListUserReads(ctx, userID, nil)
+		if err != nil {
+			return nil, fmt.Errorf("查询阅读记录失败: %w", err)
+		}
+		for announcementID := range reads {
+			excludeIDs = append(excludeIDs, announcementID)
+		}
+	}
+
+	announcements, total, err := s.announcementRepo.ListVisibleAnnouncements(ctx, req, companyID, roleIDs, excludeIDs, now)
+	if err != nil {
+		return nil, fmt.Errorf("查询公告列表失败: %w", err)
+	}
+
+	ids := make([]string, 0, len(announcements))
+	for _, announcement := range announcements {
+		ids = append(ids, announcement.AnnouncementID)
+	}
+	reads, err := s.announcementRepo.ListUserReads(ctx, userID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("查询阅读记录失败: %w", err)
+	}
+
+	items := make([]model.AnnouncementItem, 0, len(announcements))
+	for _, announcement := range announcements {
+		items = append(items, newAnnouncementItem(announcement, reads[announcement.AnnouncementID]))
+	}
+
+	return &model.AnnouncementListResponse{
+		List:     items,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// GetAnnouncement 查看公告详情并记录已读
+func (s *AnnouncementService) GetAnnouncement(ctx context.Context, announcementID, userID, companyID string, roleIDs []string) (*model.AnnouncementItem, error) {
+	now := time.Now()
+
+	announcement, err := s.announcementRepo.GetVisibleAnnouncement(ctx, announcementID, companyID, roleIDs, now)
+	if err != nil {
+		return nil, fmt.Errorf("查询公告失败: %w", err)
+	}
+	if announcement == nil {
+		return nil, errors.New("公告不存在")
+	}
+
+	if err := s.announcementRepo.MarkRead(ctx, announcementID, userID, companyID, now); err != nil {
+		logger.Errorf("记录公告已读失败: AnnouncementID=%s, UserID=%s, Error=%v", announcementID, userID, err)
+	}
+
+	reads, err := s.announcementRepo.ListUserReads(ctx, userID, []string{announcementID})
+	if err != nil {
+		return nil, fmt.Errorf("查询阅读记录失败: %w", err)
+	}
+
+	item := newAnnouncementItem(*announcement, reads[announcementID])
+	return &item, nil
+}
+
+// AcknowledgeAnnouncement 确认公告（同时记录已读）
+func (s *AnnouncementService) AcknowledgeAnnouncement(ctx context.Context, announcementID, userID, companyID string, roleIDs []string) error {
+	now := time.Now()
+
+	announcement, err := s.announcementRepo.GetVisibleAnnouncement(ctx, announcementID, companyID, roleIDs, now)
+	if err != nil {
+		return fmt.Errorf("查询公告失败: %w", err)
+	}
+	if announcement == nil {
+		return errors.New("公告不存在")
+	}
+	if !announcement.RequireAck {
+		return errors.New("该公告无需确认")
+	}
+
+	created, err := s.announcementRepo.Acknowledge(ctx, announcementID, userID, companyID, now)
+	if err != nil {
+		logger.Errorf("确认公告失败: AnnouncementID=%s, UserID=%s, Error=%v", announcementID, userID, err)
+		return fmt.Errorf("确认公告失败: %w", err)
+	}
+	if created {
+		logger.BusinessLog("通知公告", "确认公告", userID, fmt.Sprintf("AnnouncementID=%s", announcementID))
+	}
+	return nil
+}
+
+// ListPendingAcknowledgements 获取当前用户待确认的公告，紧急和高优先级排在前面
+func (s *AnnouncementService) ListPendingAcknowledgements(ctx context.Context, userID, companyID string, roleIDs []string) ([]model.AnnouncementBrief, error) {
+	reads, err := s.announcementRepo.ListUserReads(ctx, userID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("查询阅读记录失败: %w", err)
+	}
+	var acknowledged []string
+	for announcementID, read := range reads {
+		if read.AcknowledgedAt != nil {
+			acknowledged = append(acknowledged, announcementID)
+		}
+	}
+
+	announcements, err := s.announcementRepo.ListVisibleRequireAck(ctx, companyID, roleIDs, acknowledged, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("查询待确认公告失败: %w", err)
+	}
+
+	sort.SliceStable(announcements, func(i, j int) bool {
+		return announcementPriorityOrder[announcements[i].Priority] < announcementPriorityOrder[announcements[j].Priority]
+	})
+
+	briefs := make([]model.AnnouncementBrief, 0, len(announcements))
+	for _, announcement := range announcements {
+		briefs = append(briefs, model.AnnouncementBrief{
+			AnnouncementID: announcement.AnnouncementID,
+			Title:          announcement.Title,
+			Priority:       announcement.Priority,
+			PublishAt:      announcement.PublishAt,
+		})
+	}
+	return briefs, nil
+}
+
+// newAnnouncementItem 组装带阅读状态的公告
+func newAnnouncementItem(announcement model.Announcement, read *model.AnnouncementRead) model.AnnouncementItem {
+	item := model.AnnouncementItem{Announcement: announcement}
+	if read != nil {
+		readAt := read.ReadAt
+		item.IsRead = true
+		item.ReadAt = &readAt
+		item.IsAcknowledged = read.AcknowledgedAt != nil
+		item.AcknowledgedAt = read.AcknowledgedAt
+	}
+	return item
+}
@@ -3,6 +3,9 @@ package service
 import (
 	"context"
 	"errors"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"YufungProject/configs"
@@ -14,50 +17,74 @@ import (
 
 // AuthService 认证服务接口
 type AuthService interface {
-	Login(ctx context.Context, req *model.LoginRequest) (*model.LoginResponse, error)
+	Login(ctx context.Context, req *model.LoginRequest, clientIP, userAgent string) (*model.LoginResponse, error)
 	Register(ctx context.Context, req *model.RegisterRequest) (*model.UserInfo, error)
 	ChangePassword(ctx context.Context, userID string, req *model.ChangePasswordRequest) error
 	RefreshToken(ctx context.Context, refreshToken string) (*model.LoginResponse, error)
 	Logout(ctx context.Context, userID string, token string) error
 	ResetPassword(ctx context.Context, req *model.ResetPasswordRequest) error
+	ForgotPassword(ctx context.Context, req *model.ForgotPasswordRequest, clientIP string) error
+	ResetPasswordWithToken(ctx context.Context, req *model.ResetPasswordWithTokenRequest, clientIP string) (string, error)
 	GetUserInfo(ctx context.Context, userID string) (*model.UserInfo, error)
+	JWKS() *utils.JWKS
 }
 
+// 自助重置密码默认值（未配置时使用）
+const (
+	defaultPasswordResetTokenTTL     = 30 * time.Minute
+	defaultPasswordResetWindow       = time.Hour
+	defaultPasswordResetAccountLimit = 3
+	defaultPasswordResetIPLimit      = 10
+	passwordResetTokenBytes          = 32
+	passwordResetDeliveryTimeout     = 30 * time.Second
+)
+
 type authService struct {
-	userRepo repository.UserRepository
-	config   *configs.Config
-	jwtUtil  *utils.JWTUtil
+	userRepo          repository.UserRepository
+	passwordResetRepo *repository.PasswordResetRepository
+	sessionService    *SessionService
+	policyService     *PasswordPolicyService
+	securityService   *SecurityService
+	resetNotifier     PasswordResetNotifier
+	config            *configs.Config
+	jwtUtil           *utils.JWTUtil
+	resetTokenTTL     time.Duration
+	resetWindow       time.Duration
 }
 
 // NewAuthService 创建认证服务实例
-func NewAuthService(userRepo repository.UserRepository, config *configs.Config) AuthService {
-	// 解析时间配置
-	expiresIn, _ := time.ParseDuration(config.JWT.ExpiresIn)
-	refreshExpiresIn, _ := time.ParseDuration(config.JWT.RefreshExpiresIn)
-
-	jwtUtil := utils.NewJWTUtil(config.JWT.Secret, expiresIn, refreshExpiresIn)
-
+func NewAuthService(userRepo repository.UserRepository, passwordResetRepo *repository.PasswordResetRepository, sessionService *SessionService, policyService *PasswordPolicyService, securityService *SecurityService, resetNotifier PasswordResetNotifier, jwtUtil *utils.JWTUtil, config *configs.Config) AuthService {
 	return &authService{
-		userRepo: userRepo,
-		config:   config,
-		jwtUtil:  jwtUtil,
+		userRepo:          userRepo,
+		passwordResetRepo: passwordResetRepo,
+		sessionService:    sessionService,
+		policyService:     policyService,
+		securityService:   securityService,
+		resetNotifier:     resetNotifier,
+		config:            config,
+		jwtUtil:           jwtUtil,
+		resetTokenTTL:     parseDurationOr(config.Security.PasswordReset.TokenTTL, defaultPasswordResetTokenTTL),
+		resetWindow:       parseDurationOr(config.Security.PasswordReset.Window, defaultPasswordResetWindow),
 	}
 }
 
-// Login 用户登录
-func (s *authService) Login(ctx context.Context, req *model.LoginRequest) (*model.LoginResponse, error) {
-	// 查找用户
+// JWKS 返回校验令牌的公钥集合，供其他内部服务校验本系统签发的令牌
+func (s *authService) JWKS() *utils.JWKS {
+	return s.jwtUtil.JWKS()
+}
+
+// Login 用户登录，公司设置了IP白名单时只允许从白名单内的IP登录（平台管理员除外）
+func (s *authService) Login(ctx context.Context, req *model.LoginRequest, clientIP, userAgent string) (*model.LoginResponse, error) {
+	// 查找用户；用户不存在时仍做一次密码比对，避免通过响应耗时探测用户名
 	user, err := s.userRepo.GetByUsername(ctx, req.Username)
 	if err != nil {
+		utils.CheckPassword(dummyPasswordHash(), req.Password)
 		logger.Warnf("用户登录失败 - 用户不存在: %s", req.Username)
 		return nil, errors.New("用户不存在")
 	}
 
-	// 检查账户状态
-	if user.Status == "inactive" {
-		logger.Warnf("用户登录失败 - 账户已禁用: %s", req.Username)
-		return nil, errors.New("账户已被禁用")
-	}
+	// 先比对密码再检查锁定，各分支都只做一次密码比对，避免通过响应耗时探测账户是否被锁定
+	passwordMatched := utils.CheckPassword(user.PasswordHash, req.Password)
 
 	// 检查账户是否被锁定
 	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
@@ -66,7 +93,7 @@ func (s *authService) Login(ctx context.Context, req *model.LoginRequest) (*mode
 	}
 
 	// 验证密码
-	if !utils.CheckPassword(user.PasswordHash, req.Password) {
+	if !passwordMatched {
 		// 增加登录失败次数
 		newAttempts := user.LoginAttempts + 1
 		var lockedUntil *time.Time
@@ -86,6 +113,31 @@ func (s *authService) Login(ctx context.Context, req *model.LoginRequest) (*mode
 		return nil, errors.New("密码错误")
 	}
 
+	// 检查账户状态，密码正确后才提示账户已禁用
+	if user.Status == "inactive" {
+		logger.Warnf("用户登录失败 - 账户已禁用: %s", req.Username)
+		return nil, errors.New("账户已被禁用")
+	}
+
+	// 校验公司IP白名单，密码正确后才校验，避免泄露白名单设置
+	if !model.IsPlatformAdminRoles(user.RoleIDs) {
+		err := s.securityService.CheckIPAccess(ctx, &model.IPAccessAttempt{
+			UserID:    user.UserID,
+			Username:  user.Username,
+			CompanyID: user.CompanyID,
+			IPAddress: clientIP,
+			UserAgent: userAgent,
+			Source:    model.IPAccessSourceLogin,
+		})
+		if err != nil {
+			if errors.Is(err, ErrIPNotAllowed) {
+				return nil, err
+			}
+			logger.Errorf("校验公司IP白名单失败: %v, UserID: %s", err, user.UserID)
+			return nil, errors.New("登录失败")
+		}
+	}
+
 	// 登录成功，重置登录尝试次数和更新最后登录时间
 	now := time.Now()
 	s.userRepo.UpdateLoginAttempts(ctx, user.UserID, 0, nil)
@@ -118,9 +170,19 @@ func (s *authService) Login(ctx context.Context, req *model.LoginRequest) (*mode
 		Phone:       user.Phone,
 		LastLogin:   &now,
 	}
+	s.fillPasswordState(ctx, user, userInfo)
 
 	logger.Infof("用户登录成功: %s", req.Username)
 
+	// 记录登录IP和设备，新IP或新设备登录时通知用户；不影响登录结果
+	go func() {
+		recordCtx, cancel := context.WithTimeout(context.Background(), securityRecordTimeout)
+		defer cancel()
+		if err := s.securityService.RecordLogin(recordCtx, user, clientIP, userAgent); err != nil {
+			logger.Errorf("记录登录地点失败: %v, UserID: %s", err, user.UserID)
+		}
+	}()
+
 	return &model.LoginResponse{
 		Token:        token,
 		RefreshToken: refreshToken,
@@ -131,6 +193,12 @@ func (s *authService) Login(ctx context.Context, req *model.LoginRequest) (*mode
 
 // Register 用户注册
 func (s *authService) Register(ctx context.Context, req *model.RegisterRequest) (*model.UserInfo, error) {
+	// 启用邀请注册后关闭公开注册
+	if s.config.Security.Invitation.Enabled {
+		logger.Warnf("注册失败 - 公开注册已关闭: %s", req.Username)
+		return nil, errors.New("公开注册已关闭")
+	}
+
 	// 检查用户名是否已存在
 	exists, err := s.userRepo.ExistsByUsername(ctx, req.Username)
 	if err != nil {
@@ -155,19 +223,6 @@ func (s *authService) Register(ctx context.Context, req *model.RegisterRequest)
 		}
 	}
 
-	// 验证密码强度
-	if len(req.Password) < s.config.Security.PasswordMinLength || !utils.ValidatePassword(req.Password) {
-		logger.Warnf("注册失败 - 密码强度不足: %s", req.Username)
-		return nil, errors.New("密码强度不足")
-	}
-
-	// 加密密码
-	passwordHash, err := utils.HashPassword(req.Password)
-	if err != nil {
-		logger.Errorf("密码加密失败: %v", err)
-		return nil, errors.New("注册失败")
-	}
-
 	// 生成用户ID
 	userID := utils.GenerateUserID()
 
@@ -180,7 +235,6 @@ func (s *authService) Register(ctx context.Context, req *model.RegisterRequest)
 		CompanyID:     "CMP_PLATFORM_001",          // 默认平台公司
 		RoleIDs:       []string{"ROL_NORMAL_USER"}, // 默认普通用户角色
 		Status:        "active",
-		PasswordHash:  passwordHash,
 		Email:         req.Email,
 		Phone:         req.Phone,
 		LoginAttempts: 0,
@@ -188,6 +242,12 @@ func (s *authService) Register(ctx context.Context, req *model.RegisterRequest)
 		UpdatedAt:     now,
 	}
 
+	// 按密码策略校验并设置密码
+	if err := s.policyService.PreparePassword(ctx, user, req.Password, false); err != nil {
+		logger.Warnf("注册失败 - 密码不符合策略: %s, %v", req.Username, err)
+		return nil, err
+	}
+
 	// 保存用户
 	err = s.userRepo.Create(ctx, user)
 	if err != nil {
@@ -226,25 +286,16 @@ func (s *authService) ChangePassword(ctx context.Context, userID string, req *mo
 		return errors.New("当前密码错误")
 	}
 
-	// 验证新密码强度
-	if len(req.NewPassword) < s.config.Security.PasswordMinLength || !utils.ValidatePassword(req.NewPassword) {
-		logger.Warnf("修改密码失败 - 新密码强度不足: %s", userID)
-		return errors.New("新密码强度不足")
-	}
-
-	// 加密新密码
-	newPasswordHash, err := utils.HashPassword(req.NewPassword)
-	if err != nil {
-		logger.Errorf("新密码加密失败: %v", err)
-		return errors.New("密码修改失败")
-	}
-
-	// 更新密码
-	err = s.userRepo.UpdatePassword(ctx, userID, newPasswordHash)
-	if err != nil {
+	// 按密码策略校验并保存新密码，同时清除须修改密码标记
+	if err := s.policyService.SetPassword(ctx, user, req.NewPassword, false); err != nil {
+		if IsPasswordPolicyError(err) {
+			logger.Warnf("修改密码失败 - 新密码不符合策略: %s, %v", userID, err)
+			return err
+		}
 		logger.Errorf("更新密码失败: %v, UserID: %s", err, userID)
 		return errors.New("密码修改失败")
 	}
+	s.sessionService.Invalidate(userID)
 
 	logger.Infof("用户密码修改成功: %s", userID)
 	return nil
@@ -253,11 +304,26 @@ func (s *authService) ChangePassword(ctx context.Context, userID string, req *mo
 // RefreshToken 刷新令牌
 func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*model.LoginResponse, error) {
 	// 解析刷新令牌
-	userID, err := s.jwtUtil.ParseRefreshToken(refreshToken)
+	claims, err := s.jwtUtil.ParseRefreshClaims(refreshToken)
 	if err != nil {
 		logger.Warnf("刷新令牌解析失败: %v", err)
 		return nil, errors.New("刷新令牌无效")
 	}
+	userID := claims.Subject
+
+	// 模拟登录令牌不能换取被模拟用户的正式令牌
+	if accessClaims, err := s.jwtUtil.ParseToken(refreshToken); err == nil && accessClaims.Impersonation != nil {
+		logger.Warnf("刷新令牌失败 - 模拟登录令牌不能刷新: SessionID=%s, ActorID=%s", accessClaims.Impersonation.SessionID, accessClaims.Impersonation.ActorID)
+		return nil, errors.New("刷新令牌无效")
+	}
+
+	// 检查会话是否已被吊销（如重置密码后）
+	if claims.IssuedAt != nil {
+		if err := s.sessionService.CheckIssuedAt(ctx, userID, claims.IssuedAt.Time); err != nil {
+			logger.Warnf("刷新令牌失败 - 会话已失效: %s, Error: %v", userID, err)
+			return nil, errors.New("刷新令牌无效")
+		}
+	}
 
 	// 获取用户信息
 	user, err := s.userRepo.GetByUserID(ctx, userID)
@@ -299,6 +365,7 @@ func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*m
 		Phone:       user.Phone,
 		LastLogin:   user.LastLoginTime,
 	}
+	s.fillPasswordState(ctx, user, userInfo)
 
 	logger.Infof("令牌刷新成功: %s", userID)
 
@@ -320,30 +387,192 @@ func (s *authService) Logout(ctx context.Context, userID string, token string) e
 
 // ResetPassword 重置密码（管理员功能）
 func (s *authService) ResetPassword(ctx context.Context, req *model.ResetPasswordRequest) error {
-	// 验证新密码强度
-	if len(req.NewPassword) < s.config.Security.PasswordMinLength || !utils.ValidatePassword(req.NewPassword) {
-		logger.Warnf("重置密码失败 - 新密码强度不足: %s", req.UserID)
-		return errors.New("新密码强度不足")
+	user, err := s.userRepo.GetByUserID(ctx, req.UserID)
+	if err != nil || user == nil {
+		logger.Errorf("获取用户信息失败: %v, UserID: %s", err, req.UserID)
+		return errors.New("用户不存在")
 	}
 
-	// 加密新密码
-	newPasswordHash, err := utils.HashPassword(req.NewPassword)
-	if err != nil {
-		logger.Errorf("新密码加密失败: %v", err)
+	// 管理员设置的密码须由用户登录后修改
+	if err := s.policyService.SetPassword(ctx, user, req.NewPassword, true); err != nil {
+		if IsPasswordPolicyError(err) {
+			logger.Warnf("重置密码失败 - 新密码不符合策略: %s, %v", req.UserID, err)
+			return err
+		}
+		logger.Errorf("重置密码失败: %v, UserID: %s", err, req.UserID)
 		return errors.New("密码重置失败")
 	}
+	if err := s.sessionService.RevokeUserSessions(ctx, req.UserID); err != nil {
+		logger.Errorf("吊销用户会话失败: %v, UserID: %s", err, req.UserID)
+	}
 
-	// 更新密码
-	err = s.userRepo.UpdatePassword(ctx, req.UserID, newPasswordHash)
+	logger.Infof("管理员重置用户密码成功: %s", req.UserID)
+	return nil
+}
+
+// ForgotPassword 忘记密码：向账户绑定的邮箱发送一次性重置链接
+//
+// 无论账户是否存在都返回相同结果，查找账户和发送邮件在后台完成，避免通过响应内容或耗时枚举账户。
+// 同一IP超出请求次数时返回"请求过于频繁"；同一账户超出发送次数时静默丢弃，不暴露账户是否存在。
+func (s *authService) ForgotPassword(ctx context.Context, req *model.ForgotPasswordRequest, clientIP string) error {
+	account := strings.TrimSpace(req.Account)
+	now := time.Now()
+
+	ipLimit := s.config.Security.PasswordReset.IPLimit
+	if ipLimit <= 0 {
+		ipLimit = defaultPasswordResetIPLimit
+	}
+	count, err := s.passwordResetRepo.CountRequestsByIP(ctx, clientIP, now.Add(-s.resetWindow))
 	if err != nil {
-		logger.Errorf("重置密码失败: %v, UserID: %s", err, req.UserID)
-		return errors.New("密码重置失败")
+		logger.Errorf("统计忘记密码请求失败: %v, IP: %s", err, clientIP)
+		return errors.New("请求失败")
+	}
+	if count >= int64(ipLimit) {
+		logger.Warnf("忘记密码请求过于频繁: IP=%s, Count=%d", clientIP, count)
+		return errors.New("请求过于频繁，请稍后再试")
 	}
 
-	logger.Infof("管理员重置用户密码成功: %s", req.UserID)
+	if err := s.passwordResetRepo.RecordRequest(ctx, strings.ToLower(account), clientIP, now); err != nil {
+		logger.Errorf("记录忘记密码请求失败: %v, IP: %s", err, clientIP)
+		return errors.New("请求失败")
+	}
+
+	go s.issueResetToken(account, clientIP)
 	return nil
 }
 
+// issueResetToken 查找账户并签发重置令牌，账户不存在、不可用或超出发送次数时只记录日志
+func (s *authService) issueResetToken(account, clientIP string) {
+	ctx, cancel := context.WithTimeout(context.Background(), passwordResetDeliveryTimeout)
+	defer cancel()
+
+	var user *model.User
+	var err error
+	if strings.Contains(account, "@") {
+		user, err = s.userRepo.GetByEmail(ctx, account)
+	} else {
+		user, err = s.userRepo.GetByUsername(ctx, account)
+	}
+	if err != nil || user == nil {
+		logger.Infof("忘记密码 - 账户不存在: %s, IP: %s", account, clientIP)
+		return
+	}
+	if user.Status != "active" || user.Email == "" {
+		logger.Infof("忘记密码 - 账户不可用或未绑定邮箱: %s, IP: %s", user.UserID, clientIP)
+		return
+	}
+
+	now := time.Now()
+	accountLimit := s.config.Security.PasswordReset.AccountLimit
+	if accountLimit <= 0 {
+		accountLimit = defaultPasswordResetAccountLimit
+	}
+	count, err := s.passwordResetRepo.CountTokensByUser(ctx, user.UserID, now.Add(-s.resetWindow))
+	if err != nil {
+		logger.Errorf("统计重置令牌失败: %v, UserID: %s", err, user.UserID)
+		return
+	}
+	if count >= int64(accountLimit) {
+		logger.Warnf("忘记密码 - 账户发送次数已达上限: UserID=%s, Count=%d, IP=%s", user.UserID, count, clientIP)
+		return
+	}
+
+	token, err := utils.GenerateSecureToken(passwordResetTokenBytes)
+	if err != nil {
+		logger.Errorf("生成重置令牌失败: %v", err)
+		return
+	}
+	if err := s.passwordResetRepo.CreateToken(ctx, &model.PasswordResetToken{
+		TokenHash: utils.HashToken(token),
+		UserID:    user.UserID,
+		RequestIP: clientIP,
+		ExpiresAt: now.Add(s.resetTokenTTL),
+		CreatedAt: now,
+	}); err != nil {
+		logger.Errorf("保存重置令牌失败: %v, UserID: %s", err, user.UserID)
+		return
+	}
+
+	if err := s.resetNotifier.NotifyPasswordReset(ctx, user, s.buildResetURL(token), s.resetTokenTTL); err != nil {
+		logger.Errorf("发送重置密码通知失败: %v, UserID: %s", err, user.UserID)
+		return
+	}
+
+	logger.BusinessLog("认证管理", "申请重置密码", user.UserID, "IP="+clientIP)
+}
+
+// buildResetURL 将令牌作为 token 查询参数附加到前端重置页面地址
+func (s *authService) buildResetURL(token string) string {
+	resetURL := s.config.Security.PasswordReset.ResetURL
+	separator := "?"
+	if strings.Contains(resetURL, "?") {
+		separator = "&"
+	}
+	return resetURL + separator + "token=" + url.QueryEscape(token)
+}
+
+// ResetPasswordWithToken 使用重置令牌设置新密码，成功后令牌失效并吊销用户已有的全部会话，返回用户ID
+func (s *authService) ResetPasswordWithToken(ctx context.Context, req *model.ResetPasswordWithTokenRequest, clientIP string) (string, error) {
+	tokenHash := utils.HashToken(strings.TrimSpace(req.Token))
+	now := time.Now()
+
+	// 先确认令牌有效，再按密码策略校验新密码，避免密码不合格时令牌被消耗
+	token, err := s.passwordResetRepo.GetValidToken(ctx, tokenHash, now)
+	if err != nil {
+		logger.Errorf("查询重置令牌失败: %v", err)
+		return "", errors.New("密码重置失败")
+	}
+	if token == nil {
+		logger.Warnf("重置密码失败 - 令牌无效或已过期: IP=%s", clientIP)
+		return "", errors.New("重置链接无效或已过期")
+	}
+
+	user, err := s.userRepo.GetByUserID(ctx, token.UserID)
+	if err != nil || user == nil || user.Status != "active" {
+		logger.Warnf("重置密码失败 - 用户不存在或不可用: %s", token.UserID)
+		return "", errors.New("重置链接无效或已过期")
+	}
+
+	if err := s.policyService.PreparePassword(ctx, user, req.NewPassword, false); err != nil {
+		if IsPasswordPolicyError(err) {
+			logger.Warnf("重置密码失败 - 新密码不符合策略: %s, %v", token.UserID, err)
+			return "", err
+		}
+		logger.Errorf("新密码处理失败: %v, UserID: %s", err, token.UserID)
+		return "", errors.New("密码重置失败")
+	}
+
+	// 原子消耗令牌，并发提交同一令牌时只有一个请求成功
+	token, err = s.passwordResetRepo.ConsumeToken(ctx, tokenHash, now)
+	if err != nil {
+		logger.Errorf("消耗重置令牌失败: %v", err)
+		return "", errors.New("密码重置失败")
+	}
+	if token == nil {
+		logger.Warnf("重置密码失败 - 令牌已被使用: IP=%s", clientIP)
+		return "", errors.New("重置链接无效或已过期")
+	}
+
+	if err := s.policyService.SavePassword(ctx, user); err != nil {
+		logger.Errorf("重置密码失败: %v, UserID: %s", err, user.UserID)
+		return "", errors.New("密码重置失败")
+	}
+
+	// 重置成功后解除登录锁定，并使已签发的令牌和其他未使用的重置链接失效
+	if err := s.userRepo.UpdateLoginAttempts(ctx, user.UserID, 0, nil); err != nil {
+		logger.Errorf("重置登录尝试次数失败: %v, UserID: %s", err, user.UserID)
+	}
+	if err := s.sessionService.RevokeUserSessions(ctx, user.UserID); err != nil {
+		logger.Errorf("吊销用户会话失败: %v, UserID: %s", err, user.UserID)
+	}
+	if err := s.passwordResetRepo.InvalidateUserTokens(ctx, user.UserID, now); err != nil {
+		logger.Errorf("作废重置令牌失败: %v, UserID: %s", err, user.UserID)
+	}
+
+	logger.Infof("用户通过重置链接重置密码成功: %s, IP: %s", user.UserID, clientIP)
+	return user.UserID, nil
+}
+
 // GetUserInfo 获取用户信息
 func (s *authService) GetUserInfo(ctx context.Context, userID string) (*model.UserInfo, error) {
 	user, err := s.userRepo.GetByUserID(ctx, userID)
@@ -352,7 +581,7 @@ func (s *authService) GetUserInfo(ctx context.Context, userID string) (*model.Us
 		return nil, errors.New("用户不存在")
 	}
 
-	return &model.UserInfo{
+	userInfo := &model.UserInfo{
 		ID:          user.ID.Hex(),
 		UserID:      user.UserID,
 		Username:    user.Username,
@@ -363,5 +592,33 @@ func (s *authService) GetUserInfo(ctx context.Context, userID string) (*model.Us
 		Email:       user.Email,
 		Phone:       user.Phone,
 		LastLogin:   user.LastLoginTime,
-	}, nil
+	}
+	s.fillPasswordState(ctx, user, userInfo)
+
+	return userInfo, nil
+}
+
+// fillPasswordState 填充用户是否须修改密码及密码过期时间，查询策略失败时只记录日志
+func (s *authService) fillPasswordState(ctx context.Context, user *model.User, userInfo *model.UserInfo) {
+	mustChange, expiresAt, err := s.policyService.RequiresPasswordChange(ctx, user)
+	if err != nil {
+		logger.Errorf("查询密码策略失败: %v, UserID: %s", err, user.UserID)
+		userInfo.MustChangePassword = user.MustChangePassword
+		return
+	}
+	userInfo.MustChangePassword = mustChange
+	userInfo.PasswordExpiresAt = expiresAt
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// dummyPasswordHash 用户不存在时用于比对的密码哈希，使响应耗时与密码错误时一致
+func dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = utils.HashPassword("dummy-password-for-timing")
+	})
+	return dummyHash
 }
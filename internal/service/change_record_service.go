@@ -4,38 +4,54 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
 	"YufungProject/internal/model"
 	"YufungProject/internal/repository"
 	"YufungProject/pkg/logger"
 )
 
 type ChangeRecordService struct {
-	changeRecordRepo *repository.ChangeRecordRepository
-	userRepo         repository.UserRepository
+	changeRecordRepo   *repository.ChangeRecordRepository
+	userRepo           repository.UserRepository
+	tableStructureRepo repository.TableStructureRepository
 }
 
-func NewChangeRecordService(changeRecordRepo *repository.ChangeRecordRepository, userRepo repository.UserRepository) *ChangeRecordService {
+func NewChangeRecordService(changeRecordRepo *repository.ChangeRecordRepository, userRepo repository.UserRepository, tableStructureRepo repository.TableStructureRepository) *ChangeRecordService {
 	return &ChangeRecordService{
-		changeRecordRepo: changeRecordRepo,
-		userRepo:         userRepo,
+		changeRecordRepo:   changeRecordRepo,
+		userRepo:           userRepo,
+		tableStructureRepo: tableStructureRepo,
 	}
 }
 
 // RecordChange 记录数据变更
 func (s *ChangeRecordService) RecordChange(ctx context.Context, tableName, recordID, userID, companyID, changeType string, oldData, newData interface{}, changeReason, ipAddress, userAgent string) error {
-	// 获取用户信息
-	user, err := s.userRepo.GetByUserID(ctx, userID)
-	if err != nil {
-		logger.Error("Failed to get user info", "user_id", userID, "error", err)
-		// 即使获取用户信息失败，也要记录变更，使用默认用户名
-	}
-
 	username := "unknown"
-	if user != nil {
-		username = user.Username
+	actorType := model.ActorTypeUser
+	// 上下文中的操作人与记录的操作人一致时才使用其身份信息
+	actor, _ := ActorFromContext(ctx)
+	if actor.ID != userID {
+		actor = Actor{}
+	}
+	if actor.Type == model.ActorTypeServiceAccount {
+		// 服务账号不在用户表中，直接使用认证时写入上下文的名称
+		username = actor.Name
+		actorType = actor.Type
+	} else {
+		// 获取用户信息
+		user, err := s.userRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			logger.Error("Failed to get user info", "user_id", userID, "error", err)
+			// 即使获取用户信息失败，也要记录变更，使用默认用户名
+		}
+		if user != nil {
+			username = user.Username
+		}
 	}
 
 	// 比较数据变更
@@ -51,6 +67,7 @@ func (s *ChangeRecordService) RecordChange(ctx context.Context, tableName, recor
 		RecordID:      recordID,
 		UserID:        userID,
 		Username:      username,
+		ActorType:     actorType,
 		CompanyID:     companyID,
 		ChangeType:    changeType,
 		OldValues:     oldValues,
@@ -60,6 +77,9 @@ func (s *ChangeRecordService) RecordChange(ctx context.Context, tableName, recor
 		IPAddress:     ipAddress,
 		UserAgent:     userAgent,
 	}
+	// 模拟登录期间的变更同时记录实际操作的平台管理员
+	record.ImpersonatorID = actor.ImpersonatorID
+	record.ImpersonatorName = actor.ImpersonatorName
 
 	return s.changeRecordRepo.CreateChangeRecord(ctx, record)
 }
@@ -71,9 +91,10 @@ func (s *ChangeRecordService) GetChangeRecordsByPolicy(ctx context.Context, poli
 		return nil, 0, err
 	}
 
+	labels := s.newCustomFieldLabels(ctx)
 	var responses []*model.ChangeRecordResponse
 	for _, record := range records {
-		response := s.convertToResponse(record)
+		response := s.convertToResponse(record, labels)
 		responses = append(responses, response)
 	}
 
@@ -87,9 +108,10 @@ func (s *ChangeRecordService) GetChangeRecordsList(ctx context.Context, params *
 		return nil, 0, err
 	}
 
+	labels := s.newCustomFieldLabels(ctx)
 	var responses []*model.ChangeRecordResponse
 	for _, record := range records {
-		response := s.convertToResponse(record)
+		response := s.convertToResponse(record, labels)
 		responses = append(responses, response)
 	}
 
@@ -97,7 +119,7 @@ func (s *ChangeRecordService) GetChangeRecordsList(ctx context.Context, params *
 }
 
 // convertToResponse 转换为响应格式
-func (s *ChangeRecordService) convertToResponse(record *model.ChangeRecord) *model.ChangeRecordResponse {
+func (s *ChangeRecordService) convertToResponse(record *model.ChangeRecord, labels *customFieldLabels) *model.ChangeRecordResponse {
 	response := &model.ChangeRecordResponse{
 		ID:                  record.ID.Hex(),
 		ChangeID:            record.ChangeID,
@@ -114,17 +136,19 @@ func (s *ChangeRecordService) convertToResponse(record *model.ChangeRecord) *mod
 		ChangeReason:        record.ChangeReason,
 		IPAddress:           record.IPAddress,
 		UserAgent:           record.UserAgent,
+		ImpersonatorID:      record.ImpersonatorID,
+		ImpersonatorName:    record.ImpersonatorName,
 		ChangeTimeFormatted: record.ChangeTime.Format("2006-01-02 15:04:05"),
 	}
 
 	// 生成变更详情
-	response.ChangeDetails = s.generateChangeDetails(record.TableName, record.ChangedFields, record.OldValues, record.NewValues, record.ChangeType)
+	response.ChangeDetails = s.generateChangeDetails(record.TableName, record.ChangedFields, record.OldValues, record.NewValues, record.ChangeType, labels.get(record.TableName, record.CompanyID))
 
 	return response
 }
 
 // generateChangeDetails 生成变更详情
-func (s *ChangeRecordService) generateChangeDetails(tableName string, changedFields []string, oldValues, newValues map[string]interface{}, changeType string) []model.ChangeDetail {
+func (s *ChangeRecordService) generateChangeDetails(tableName string, changedFields []string, oldValues, newValues map[string]interface{}, changeType string, customLabels map[string]string) []model.ChangeDetail {
 	var details []model.ChangeDetail
 
 	for _, fieldName := range changedFields {
@@ -133,12 +157,21 @@ func (s *ChangeRecordService) generateChangeDetails(tableName string, changedFie
 			FieldLabel: model.GetFieldLabel(tableName, fieldName),
 		}
 
-		// 获取旧值和新值
-		if oldValues != nil {
-			detail.OldValue = oldValues[fieldName]
-		}
-		if newValues != nil {
-			detail.NewValue = newValues[fieldName]
+		// 获取旧值和新值，自定义字段以 custom_fields.<字段名> 记录
+		if customName, ok := strings.CutPrefix(fieldName, model.CustomFieldsKey+"."); ok {
+			detail.FieldLabel = customName
+			if label := customLabels[customName]; label != "" {
+				detail.FieldLabel = label
+			}
+			detail.OldValue = customFieldValue(oldValues, customName)
+			detail.NewValue = customFieldValue(newValues, customName)
+		} else {
+			if oldValues != nil {
+				detail.OldValue = oldValues[fieldName]
+			}
+			if newValues != nil {
+				detail.NewValue = newValues[fieldName]
+			}
 		}
 
 		// 格式化显示文本
@@ -199,6 +232,10 @@ func (s *ChangeRecordService) compareData(oldData, newData interface{}, changeTy
 		if newData != nil {
 			newMap := s.structToMap(newData)
 			for k, v := range newMap {
+				if k == model.CustomFieldsKey {
+					changedFields = s.compareCustomFields(changedFields, oldValues, newValues, nil, v)
+					continue
+				}
 				if s.shouldTrackField(k) {
 					changedFields = append(changedFields, k)
 					newValues[k] = v
@@ -210,6 +247,10 @@ func (s *ChangeRecordService) compareData(oldData, newData interface{}, changeTy
 		if oldData != nil {
 			oldMap := s.structToMap(oldData)
 			for k, v := range oldMap {
+				if k == model.CustomFieldsKey {
+					changedFields = s.compareCustomFields(changedFields, oldValues, newValues, v, nil)
+					continue
+				}
 				if s.shouldTrackField(k) {
 					changedFields = append(changedFields, k)
 					oldValues[k] = v
@@ -223,6 +264,10 @@ func (s *ChangeRecordService) compareData(oldData, newData interface{}, changeTy
 			newMap := s.structToMap(newData)
 
 			for k, newVal := range newMap {
+				if k == model.CustomFieldsKey {
+					changedFields = s.compareCustomFields(changedFields, oldValues, newValues, oldMap[k], newVal)
+					continue
+				}
 				if !s.shouldTrackField(k) {
 					continue
 				}
@@ -242,6 +287,113 @@ func (s *ChangeRecordService) compareData(oldData, newData interface{}, changeTy
 	return changedFields, oldValues, newValues
 }
 
+// compareCustomFields 逐个比较自定义字段，变更字段记为 custom_fields.<字段名>，新旧值按原结构存放在 custom_fields 下
+func (s *ChangeRecordService) compareCustomFields(changedFields []string, oldValues, newValues map[string]interface{}, oldData, newData interface{}) []string {
+	oldFields := customFieldMap(oldData)
+	newFields := customFieldMap(newData)
+
+	names := make([]string, 0, len(oldFields)+len(newFields))
+	for name := range oldFields {
+		names = append(names, name)
+	}
+	for name := range newFields {
+		if _, exists := oldFields[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changedOld := make(map[string]interface{})
+	changedNew := make(map[string]interface{})
+	for _, name := range names {
+		oldVal, oldExists := oldFields[name]
+		newVal, newExists := newFields[name]
+		if oldExists == newExists && s.valuesEqual(oldVal, newVal) {
+			continue
+		}
+
+		changedFields = append(changedFields, model.CustomFieldsKey+"."+name)
+		if oldExists {
+			changedOld[name] = oldVal
+		}
+		if newExists {
+			changedNew[name] = newVal
+		}
+	}
+
+	if len(changedOld) > 0 {
+		oldValues[model.CustomFieldsKey] = changedOld
+	}
+	if len(changedNew) > 0 {
+		newValues[model.CustomFieldsKey] = changedNew
+	}
+	return changedFields
+}
+
+// customFieldMap 将自定义字段值转换为map（兼容从数据库读出的文档类型）
+func customFieldMap(data interface{}) map[string]interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		return v
+	case primitive.M:
+		return v
+	case primitive.D:
+		result := make(map[string]interface{}, len(v))
+		for _, elem := range v {
+			result[elem.Key] = elem.Value
+		}
+		return result
+	}
+	return nil
+}
+
+// customFieldValue 从变更值中读取自定义字段的值
+func customFieldValue(values map[string]interface{}, name string) interface{} {
+	if values == nil {
+		return nil
+	}
+	return customFieldMap(values[model.CustomFieldsKey])[name]
+}
+
+// customFieldLabels 自定义字段显示名称缓存（表名+公司 -> 字段名 -> 显示名称）
+type customFieldLabels struct {
+	ctx    context.Context
+	repo   repository.TableStructureRepository
+	labels map[string]map[string]string
+}
+
+func (s *ChangeRecordService) newCustomFieldLabels(ctx context.Context) *customFieldLabels {
+	return &customFieldLabels{ctx: ctx, repo: s.tableStructureRepo, labels: map[string]map[string]string{}}
+}
+
+// get 获取表结构的字段显示名称，查询失败时返回空映射（以字段名显示）
+func (l *customFieldLabels) get(tableName, companyID string) map[string]string {
+	key := tableName + "|" + companyID
+	if labels, ok := l.labels[key]; ok {
+		return labels
+	}
+
+	labels := make(map[string]string)
+	l.labels[key] = labels
+	if l.repo == nil {
+		return labels
+	}
+
+	table, err := l.repo.GetTableByName(l.ctx, tableName, companyID)
+	if err != nil || table == nil {
+		return labels
+	}
+	fields, err := l.repo.ListFields(l.ctx, table.TableID)
+	if err != nil {
+		logger.Warnf("加载自定义字段显示名称失败: %v", err)
+		return labels
+	}
+	for _, field := range fields {
+		labels[field.FieldName] = field.DisplayName
+	}
+	return labels
+}
+
 // structToMap 将结构体转换为map
 func (s *ChangeRecordService) structToMap(data interface{}) map[string]interface{} {
 	result := make(map[string]interface{})
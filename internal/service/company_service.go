@@ -293,6 +293,10 @@ func (s *companyService) UpdateCompany(ctx context.Context, companyID string, re
 		updates["user_quota"] = req.UserQuota
 	}
 
+	if req.DictionaryMode != "" {
+		updates["dictionary_mode"] = req.DictionaryMode
+	}
+
 	if req.Status != "" {
 		updates["status"] = req.Status
 	}
@@ -412,6 +416,7 @@ func (s *companyService) convertToCompanyInfo(company *model.Company) *model.Com
 		ValidEndDate:      company.ValidEndDate.Format("2006-01-02"),
 		UserQuota:         company.UserQuota,
 		CurrentUserCount:  company.CurrentUserCount,
+		DictionaryMode:    company.DictionaryMode,
 		Status:            company.Status,
 		Remark:            company.Remark,
 		SubmittedBy:       company.SubmittedBy,
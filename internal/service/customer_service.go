@@ -0,0 +1,814 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"YufungProject/internal/model"
+	"YufungProject/internal/repository"
+	"YufungProject/pkg/logger"
+	"YufungProject/pkg/utils"
+)
+
+// customerSyncReason 客户档案变更同步到保单时的变更原因
+const customerSyncReason = "客户档案同步"
+
+// customerMergeReason 合并客户时的默认变更原因
+const customerMergeReason = "客户合并"
+
+type CustomerService struct {
+	customerRepo        *repository.CustomerRepository
+	policyRepo          *repository.PolicyRepository
+	changeRecordService *ChangeRecordService
+}
+
+func NewCustomerService(customerRepo *repository.CustomerRepository, policyRepo *repository.PolicyRepository, changeRecordService *ChangeRecordService) *CustomerService {
+	return &CustomerService{
+		customerRepo:        customerRepo,
+		policyRepo:          policyRepo,
+		changeRecordService: changeRecordService,
+	}
+}
+
+// CreateCustomer 创建客户，并关联该客户号下已有的保单
+func (s *CustomerService) CreateCustomer(ctx context.Context, req *model.CustomerCreateRequest, userID, companyID, ipAddress, userAgent string) (*model.Customer, error) {
+	customerNumber := strings.TrimSpace(req.CustomerNumber)
+	exists, err := s.customerRepo.CheckCustomerNumberExists(ctx, customerNumber, companyID, "")
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("客户号已存在")
+	}
+
+	customer := &model.Customer{
+		CustomerNumber: customerNumber,
+		CustomerNameCN: strings.TrimSpace(req.CustomerNameCN),
+		CustomerNameEN: strings.TrimSpace(req.CustomerNameEN),
+		Gender:         req.Gender,
+		Birthday:       req.Birthday,
+		IDType:         req.IDType,
+		IDNumber:       strings.TrimSpace(req.IDNumber),
+		Phone:          strings.TrimSpace(req.Phone),
+		Email:          strings.TrimSpace(req.Email),
+		Address:        strings.TrimSpace(req.Address),
+		RiskLevel:      req.RiskLevel,
+		RiskAssessedAt: req.RiskAssessedAt,
+		Source:         model.CustomerSourceManual,
+		CompanyID:      companyID,
+		Remark:         req.Remark,
+		CreatedBy:      userID,
+		UpdatedBy:      userID,
+	}
+
+	if err := s.customerRepo.CreateCustomer(ctx, customer); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.linkUnlinkedPolicies(ctx, customer, userID, ipAddress, userAgent); err != nil {
+		return nil, fmt.Errorf("关联已有保单失败: %v", err)
+	}
+
+	return customer, nil
+}
+
+// GetCustomerDetail 获取客户详情，含关联保单及保费、AUM汇总
+func (s *CustomerService) GetCustomerDetail(ctx context.Context, customerID, companyID string) (*model.CustomerDetailResponse, error) {
+	customer, err := s.getCustomer(ctx, customerID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := s.customerRepo.ListCustomerPolicies(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.customerRepo.GetCustomerPolicySummary(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.CustomerDetailResponse{
+		Customer: customer,
+		Policies: policies,
+		Summary:  *summary,
+	}, nil
+}
+
+// UpdateCustomer 更新客户，客户号或姓名变更时同步到关联保单并记录保单变更
+func (s *CustomerService) UpdateCustomer(ctx context.Context, customerID string, req *model.CustomerUpdateRequest, userID, companyID, ipAddress, userAgent string) (*model.Customer, error) {
+	customer, err := s.getCustomer(ctx, customerID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := bson.M{"updated_by": userID}
+	if number := strings.TrimSpace(req.CustomerNumber); number != "" && number != customer.CustomerNumber {
+		exists, err := s.customerRepo.CheckCustomerNumberExists(ctx, number, companyID, customerID)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, fmt.Errorf("客户号已存在")
+		}
+		updates["customer_number"] = number
+	}
+	setIfNotEmpty(updates, "customer_name_cn", strings.TrimSpace(req.CustomerNameCN))
+	setIfNotEmpty(updates, "customer_name_en", strings.TrimSpace(req.CustomerNameEN))
+	setIfNotEmpty(updates, "gender", req.Gender)
+	setIfNotEmpty(updates, "id_type", req.IDType)
+	setIfNotEmpty(updates, "id_number", strings.TrimSpace(req.IDNumber))
+	setIfNotEmpty(updates, "phone", strings.TrimSpace(req.Phone))
+	setIfNotEmpty(updates, "email", strings.TrimSpace(req.Email))
+	setIfNotEmpty(updates, "address", strings.TrimSpace(req.Address))
+	setIfNotEmpty(updates, "risk_level", req.RiskLevel)
+	setIfNotEmpty(updates, "remark", req.Remark)
+	if req.Birthday != nil {
+		updates["birthday"] = req.Birthday
+	}
+	if req.RiskAssessedAt != nil {
+		updates["risk_assessed_at"] = req.RiskAssessedAt
+	}
+
+	if err := s.customerRepo.UpdateCustomer(ctx, customerID, updates); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.customerRepo.GetCustomerByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.changeRecordService != nil {
+		if err := s.changeRecordService.RecordChange(ctx, "customers", customerID, userID, companyID, "update", customer, updated, "", ipAddress, userAgent); err != nil {
+			logger.Warnf("记录客户变更失败: %v", err)
+		}
+	}
+
+	if updated.CustomerNumber != customer.CustomerNumber ||
+		updated.CustomerNameCN != customer.CustomerNameCN ||
+		updated.CustomerNameEN != customer.CustomerNameEN {
+		count, err := s.syncPolicies(ctx, updated, userID, ipAddress, userAgent)
+		if err != nil {
+			return nil, fmt.Errorf("同步保单客户信息失败: %v", err)
+		}
+		logger.BusinessLog("客户管理", "同步保单客户信息", userID, fmt.Sprintf("客户: %s, 更新保单: %d", customerID, count))
+	}
+
+	return updated, nil
+}
+
+// DeleteCustomer 删除客户，存在关联保单时不允许删除
+func (s *CustomerService) DeleteCustomer(ctx context.Context, customerID, companyID string) error {
+	if _, err := s.getCustomer(ctx, customerID, companyID); err != nil {
+		return err
+	}
+
+	count, err := s.customerRepo.CountPoliciesByCustomer(ctx, customerID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("该客户下存在%d张保单，无法删除", count)
+	}
+
+	return s.customerRepo.DeleteCustomer(ctx, customerID)
+}
+
+// ListCustomers 获取客户列表
+func (s *CustomerService) ListCustomers(ctx context.Context, req *model.CustomerQueryRequest, companyID string) (*model.CustomerListResponse, error) {
+	return s.customerRepo.ListCustomers(ctx, req, companyID)
+}
+
+// LinkPolicyCustomer 按客户号（含曾用客户号）获取保单对应的客户档案，不存在时以保单中的客户信息自动创建，返回档案是否为本次新建；
+// 已存在的档案缺少英文名时以保单中的值补充
+func (s *CustomerService) LinkPolicyCustomer(ctx context.Context, customerNumber, nameCN, nameEN, userID, companyID string) (*model.Customer, bool, error) {
+	customerNumber = strings.TrimSpace(customerNumber)
+	customer, err := s.findCustomerByNumber(ctx, customerNumber, companyID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if customer == nil {
+		customer = &model.Customer{
+			CustomerNumber: customerNumber,
+			CustomerNameCN: strings.TrimSpace(nameCN),
+			CustomerNameEN: strings.TrimSpace(nameEN),
+			Source:         model.CustomerSourcePolicy,
+			CompanyID:      companyID,
+			CreatedBy:      userID,
+			UpdatedBy:      userID,
+		}
+		err := s.customerRepo.CreateCustomer(ctx, customer)
+		if err == nil {
+			return customer, true, nil
+		}
+		// 并发创建同一客户号时以先创建的档案为准
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, false, err
+		}
+		if customer, err = s.customerRepo.GetCustomerByNumber(ctx, customerNumber, companyID); err != nil {
+			return nil, false, err
+		}
+		if customer == nil {
+			return nil, false, fmt.Errorf("客户档案创建失败")
+		}
+	}
+
+	if customer.CustomerNameEN == "" && strings.TrimSpace(nameEN) != "" {
+		customer.CustomerNameEN = strings.TrimSpace(nameEN)
+		if err := s.customerRepo.UpdateCustomer(ctx, customer.CustomerID, bson.M{"customer_name_en": customer.CustomerNameEN, "updated_by": userID}); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return customer, false, nil
+}
+
+// LinkExistingPolicies 为尚未关联客户档案的历史保单按客户号创建或关联客户档案
+func (s *CustomerService) LinkExistingPolicies(ctx context.Context, userID, companyID, ipAddress, userAgent string) (*model.CustomerLinkPoliciesResponse, error) {
+	candidates, err := s.customerRepo.ListUnlinkedPolicyCustomers(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.CustomerLinkPoliciesResponse{}
+	for _, candidate := range candidates {
+		customer, created, err := s.LinkPolicyCustomer(ctx, candidate.CustomerNumber, candidate.CustomerNameCN, candidate.CustomerNameEN, userID, companyID)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			response.CreatedCustomers++
+		}
+
+		count, err := s.linkUnlinkedPolicies(ctx, customer, userID, ipAddress, userAgent)
+		if err != nil {
+			return nil, err
+		}
+		response.LinkedPolicies += count
+	}
+
+	logger.BusinessLog("客户管理", "关联历史保单", userID, fmt.Sprintf("公司: %s, 新建客户: %d, 关联保单: %d", companyID, response.CreatedCustomers, response.LinkedPolicies))
+
+	return response, nil
+}
+
+// ==========================
+// 疑似重复客户与合并
+// ==========================
+
+// 疑似重复客户评分
+const (
+	duplicateDefaultMinScore = 50  // 默认最低得分
+	duplicateScoreNameCN     = 50  // 规范化后中文名一致
+	duplicateScoreNameEN     = 30  // 英文名（拼音）词重合，按重合比例计分
+	duplicateScoreAccount    = 40  // 共用账户号
+	duplicateMaxTokenBlock   = 200 // 单个英文名词对应的客户数超过该值时不按该词比对（如常见姓氏）
+)
+
+// duplicateProfile 参与重复比对的客户特征
+type duplicateProfile struct {
+	customer    *model.Customer
+	nameCN      string          // 规范化后的中文名
+	nameEN      string          // 参与比对的英文名
+	tokens      []string        // 英文名（拼音）规范化后的词
+	compactKeys []string        // 英文名去除分隔后的正序及倒序拼接，如 "Zhang San" -> zhangsan、sanzhang
+	accounts    map[string]bool // 关联保单的账户号
+}
+
+// newDuplicateProfile 构建客户的比对特征；未填写英文名且中文名为拼音时以中文名作为英文名比对
+func newDuplicateProfile(customer *model.Customer, accounts []string) *duplicateProfile {
+	profile := &duplicateProfile{
+		customer: customer,
+		nameCN:   utils.NormalizeName(customer.CustomerNameCN),
+		accounts: make(map[string]bool, len(accounts)),
+	}
+
+	profile.nameEN = customer.CustomerNameEN
+	if strings.TrimSpace(profile.nameEN) == "" && isLatinName(customer.CustomerNameCN) {
+		profile.nameEN = customer.CustomerNameCN
+	}
+	profile.tokens = uniqueStrings(utils.NameTokens(profile.nameEN))
+	if len(profile.tokens) > 0 {
+		reversed := make([]string, len(profile.tokens))
+		for i, token := range profile.tokens {
+			reversed[len(profile.tokens)-1-i] = token
+		}
+		profile.compactKeys = uniqueStrings([]string{strings.Join(profile.tokens, ""), strings.Join(reversed, "")})
+	}
+
+	for _, account := range accounts {
+		if account = strings.TrimSpace(account); account != "" {
+			profile.accounts[account] = true
+		}
+	}
+	return profile
+}
+
+// scoreDuplicate 计算两个客户为同一人的得分及依据
+func scoreDuplicate(a, b *duplicateProfile) (int, []string) {
+	score := 0
+	var reasons []string
+
+	if a.nameCN != "" && a.nameCN == b.nameCN {
+		score += duplicateScoreNameCN
+		reasons = append(reasons, fmt.Sprintf("中文名一致：%s / %s", a.customer.CustomerNameCN, b.customer.CustomerNameCN))
+	}
+
+	if similarity := englishNameSimilarity(a, b); similarity > 0 {
+		score += int(float64(duplicateScoreNameEN)*similarity + 0.5)
+		reasons = append(reasons, fmt.Sprintf("英文名相似度%d%%：%s / %s", int(similarity*100+0.5), a.nameEN, b.nameEN))
+	}
+
+	var shared []string
+	for account := range a.accounts {
+		if b.accounts[account] {
+			shared = append(shared, account)
+		}
+	}
+	if len(shared) > 0 {
+		sort.Strings(shared)
+		score += duplicateScoreAccount
+		reasons = append(reasons, fmt.Sprintf("共用账户号：%s", strings.Join(shared, "、")))
+	}
+
+	if score > 100 {
+		score = 100
+	}
+	return score, reasons
+}
+
+// englishNameSimilarity 英文名相似度：去除分隔后正序或倒序一致视为相同，否则取词的重合比例（Jaccard）
+func englishNameSimilarity(a, b *duplicateProfile) float64 {
+	if len(a.tokens) == 0 || len(b.tokens) == 0 {
+		return 0
+	}
+	for _, keyA := range a.compactKeys {
+		for _, keyB := range b.compactKeys {
+			if keyA == keyB {
+				return 1
+			}
+		}
+	}
+
+	common := 0
+	for _, tokenA := range a.tokens {
+		for _, tokenB := range b.tokens {
+			if tokenA == tokenB {
+				common++
+				break
+			}
+		}
+	}
+	return float64(common) / float64(len(a.tokens)+len(b.tokens)-common)
+}
+
+// findDuplicateCandidates 找出得分不低于最低得分的客户对；只比对中文名、英文名或账户号有交集的客户
+func findDuplicateCandidates(profiles []*duplicateProfile, minScore int) []model.CustomerDuplicate {
+	blocks := make(map[string][]int)
+	addBlock := func(key string, index int) {
+		blocks[key] = append(blocks[key], index)
+	}
+	for i, profile := range profiles {
+		if profile.nameCN != "" {
+			addBlock("cn:"+profile.nameCN, i)
+		}
+		for _, key := range profile.compactKeys {
+			addBlock("en:"+key, i)
+		}
+		for _, token := range profile.tokens {
+			addBlock("token:"+token, i)
+		}
+		for account := range profile.accounts {
+			addBlock("account:"+account, i)
+		}
+	}
+
+	seen := make(map[[2]int]bool)
+	var candidates []model.CustomerDuplicate
+	for key, indexes := range blocks {
+		if strings.HasPrefix(key, "token:") && len(indexes) > duplicateMaxTokenBlock {
+			continue
+		}
+		for i := 0; i < len(indexes); i++ {
+			for j := i + 1; j < len(indexes); j++ {
+				pair := [2]int{indexes[i], indexes[j]}
+				if seen[pair] {
+					continue
+				}
+				seen[pair] = true
+
+				a, b := profiles[pair[0]], profiles[pair[1]]
+				score, reasons := scoreDuplicate(a, b)
+				if score < minScore {
+					continue
+				}
+				idA, idB := a.customer.CustomerID, b.customer.CustomerID
+				if idA > idB {
+					idA, idB = idB, idA
+				}
+				candidates = append(candidates, model.CustomerDuplicate{
+					CustomerAID: idA,
+					CustomerBID: idB,
+					Score:       score,
+					Reasons:     reasons,
+				})
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].CustomerAID+candidates[i].CustomerBID < candidates[j].CustomerAID+candidates[j].CustomerBID
+	})
+	return candidates
+}
+
+// ScanDuplicateCustomers 扫描公司内的疑似重复客户并更新审核队列：新发现的客户对加入待审核，
+// 已忽略或已合并的不再重复提示，不再达到最低得分的待审核记录移除
+func (s *CustomerService) ScanDuplicateCustomers(ctx context.Context, req *model.CustomerDuplicateScanRequest, userID, companyID string) (*model.CustomerDuplicateScanResponse, error) {
+	minScore := req.MinScore
+	if minScore <= 0 {
+		minScore = duplicateDefaultMinScore
+	}
+
+	customers, err := s.customerRepo.ListAllCustomers(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := s.customerRepo.ListCustomerAccountNumbers(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make([]*duplicateProfile, 0, len(customers))
+	for i := range customers {
+		profiles = append(profiles, newDuplicateProfile(&customers[i], accounts[customers[i].CustomerID]))
+	}
+	candidates := findDuplicateCandidates(profiles, minScore)
+
+	existing, err := s.customerRepo.ListCustomerDuplicates(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	existingByPair := make(map[string]*model.CustomerDuplicate, len(existing))
+	for i := range existing {
+		existingByPair[existing[i].CustomerAID+"|"+existing[i].CustomerBID] = &existing[i]
+	}
+
+	response := &model.CustomerDuplicateScanResponse{
+		ScannedCustomers: len(customers),
+		Candidates:       len(candidates),
+	}
+	keepIDs := make([]string, 0, len(candidates))
+	for i := range candidates {
+		candidate := &candidates[i]
+		if found, ok := existingByPair[candidate.CustomerAID+"|"+candidate.CustomerBID]; ok {
+			keepIDs = append(keepIDs, found.DuplicateID)
+			if found.Status != model.CustomerDuplicatePending {
+				continue
+			}
+			if err := s.customerRepo.UpdateCustomerDuplicate(ctx, found.DuplicateID, bson.M{"score": candidate.Score, "reasons": candidate.Reasons}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		candidate.CompanyID = companyID
+		candidate.Status = model.CustomerDuplicatePending
+		if err := s.customerRepo.CreateCustomerDuplicate(ctx, candidate); err != nil {
+			return nil, err
+		}
+		keepIDs = append(keepIDs, candidate.DuplicateID)
+		response.NewCandidates++
+	}
+
+	if _, err := s.customerRepo.DeletePendingCustomerDuplicates(ctx, companyID, keepIDs); err != nil {
+		return nil, err
+	}
+
+	logger.BusinessLog("客户管理", "扫描疑似重复客户", userID, fmt.Sprintf("公司: %s, 客户: %d, 疑似重复: %d, 新增: %d", companyID, response.ScannedCustomers, response.Candidates, response.NewCandidates))
+
+	return response, nil
+}
+
+// ListDuplicateCustomers 获取疑似重复客户审核队列，默认返回待审核记录
+func (s *CustomerService) ListDuplicateCustomers(ctx context.Context, req *model.CustomerDuplicateQueryRequest, companyID string) (*model.CustomerDuplicateListResponse, error) {
+	if req.Status == "" {
+		req.Status = model.CustomerDuplicatePending
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	duplicates, total, err := s.customerRepo.ListCustomerDuplicatesPage(ctx, req, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	customerIDs := make([]string, 0, len(duplicates)*2)
+	for _, duplicate := range duplicates {
+		customerIDs = append(customerIDs, duplicate.CustomerAID, duplicate.CustomerBID)
+	}
+	customers, err := s.customerRepo.GetCustomersByIDs(ctx, customerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]model.CustomerDuplicateItem, 0, len(duplicates))
+	for _, duplicate := range duplicates {
+		items = append(items, model.CustomerDuplicateItem{
+			CustomerDuplicate: duplicate,
+			CustomerA:         customers[duplicate.CustomerAID],
+			CustomerB:         customers[duplicate.CustomerBID],
+		})
+	}
+
+	return &model.CustomerDuplicateListResponse{
+		List:     items,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// IgnoreDuplicateCustomer 确认疑似重复的两个客户并非同一人，重新扫描时不再提示
+func (s *CustomerService) IgnoreDuplicateCustomer(ctx context.Context, duplicateID, userID, companyID string) error {
+	duplicate, err := s.customerRepo.GetCustomerDuplicateByID(ctx, duplicateID)
+	if err != nil {
+		return err
+	}
+	if duplicate == nil || duplicate.CompanyID != companyID {
+		return fmt.Errorf("疑似重复记录不存在")
+	}
+	if duplicate.Status != model.CustomerDuplicatePending {
+		return fmt.Errorf("该记录已处理")
+	}
+
+	now := time.Now()
+	return s.customerRepo.UpdateCustomerDuplicate(ctx, duplicateID, bson.M{
+		"status":      model.CustomerDuplicateIgnored,
+		"reviewed_by": userID,
+		"reviewed_at": now,
+	})
+}
+
+// MergeCustomers 将被合并客户并入保留客户：保单改为关联保留客户并记录变更，被合并客户的客户号记为保留客户的
+// 曾用客户号（之后录入或导入使用旧客户号的保单自动关联到保留客户），保留客户为空的资料以被合并客户补充，被合并客户删除
+func (s *CustomerService) MergeCustomers(ctx context.Context, req *model.CustomerMergeRequest, userID, companyID, ipAddress, userAgent string) (*model.CustomerMergeResponse, error) {
+	survivor, err := s.getCustomer(ctx, req.SurvivorID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		reason = customerMergeReason
+	}
+
+	var merged []*model.Customer
+	var mergedIDs []string
+	seen := map[string]bool{survivor.CustomerID: true}
+	for _, id := range req.MergedIDs {
+		if id == survivor.CustomerID {
+			return nil, fmt.Errorf("不能将客户与自身合并")
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		customer, err := s.getCustomer(ctx, id, companyID)
+		if err != nil {
+			return nil, err
+		}
+		merged = append(merged, customer)
+		mergedIDs = append(mergedIDs, id)
+	}
+
+	// 合并曾用客户号并补充保留客户为空的资料，先合并的客户优先
+	aliases := append([]string{}, survivor.Aliases...)
+	filled := *survivor
+	for _, customer := range merged {
+		for _, number := range append([]string{customer.CustomerNumber}, customer.Aliases...) {
+			if number != survivor.CustomerNumber && !containsString(aliases, number) {
+				aliases = append(aliases, number)
+			}
+		}
+		fillEmptyCustomerFields(&filled, customer)
+	}
+
+	updates := bson.M{
+		"aliases":          aliases,
+		"customer_name_en": filled.CustomerNameEN,
+		"gender":           filled.Gender,
+		"birthday":         filled.Birthday,
+		"id_type":          filled.IDType,
+		"id_number":        filled.IDNumber,
+		"phone":            filled.Phone,
+		"email":            filled.Email,
+		"address":          filled.Address,
+		"risk_level":       filled.RiskLevel,
+		"risk_assessed_at": filled.RiskAssessedAt,
+		"updated_by":       userID,
+	}
+	if err := s.customerRepo.UpdateCustomer(ctx, survivor.CustomerID, updates); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.customerRepo.GetCustomerByID(ctx, survivor.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.CustomerMergeResponse{Customer: updated}
+	for _, customer := range merged {
+		policies, err := s.customerRepo.ListCustomerPolicies(ctx, customer.CustomerID)
+		if err != nil {
+			return nil, err
+		}
+		count, err := s.applyCustomerToPolicies(ctx, policies, updated, userID, reason, ipAddress, userAgent)
+		if err != nil {
+			return nil, fmt.Errorf("转移客户%s的保单失败: %v", customer.CustomerNumber, err)
+		}
+		response.MovedPolicies += count
+
+		if err := s.customerRepo.DeleteCustomer(ctx, customer.CustomerID); err != nil {
+			return nil, err
+		}
+		if s.changeRecordService != nil {
+			if err := s.changeRecordService.RecordChange(ctx, "customers", customer.CustomerID, userID, companyID, "delete", customer, nil, fmt.Sprintf("%s：并入客户%s", reason, updated.CustomerNumber), ipAddress, userAgent); err != nil {
+				logger.Warnf("记录客户合并变更失败: %v", err)
+			}
+		}
+	}
+
+	// 被合并客户号下尚未关联客户档案的保单一并关联
+	count, err := s.linkUnlinkedPolicies(ctx, updated, userID, ipAddress, userAgent)
+	if err != nil {
+		return nil, fmt.Errorf("关联曾用客户号下的保单失败: %v", err)
+	}
+	response.MovedPolicies += count
+
+	if s.changeRecordService != nil {
+		if err := s.changeRecordService.RecordChange(ctx, "customers", survivor.CustomerID, userID, companyID, "update", survivor, updated, reason, ipAddress, userAgent); err != nil {
+			logger.Warnf("记录客户合并变更失败: %v", err)
+		}
+	}
+
+	if err := s.customerRepo.ResolveMergedCustomerDuplicates(ctx, companyID, survivor.CustomerID, mergedIDs, userID); err != nil {
+		logger.Warnf("更新疑似重复客户记录失败: %v", err)
+	}
+
+	logger.BusinessLog("客户管理", "合并客户", userID, fmt.Sprintf("保留客户: %s, 被合并客户: %s, 转移保单: %d", survivor.CustomerID, strings.Join(mergedIDs, ","), response.MovedPolicies))
+
+	return response, nil
+}
+
+// fillEmptyCustomerFields 以来源客户的资料补充目标客户为空的字段
+func fillEmptyCustomerFields(target, source *model.Customer) {
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&target.CustomerNameEN, source.CustomerNameEN)
+	fill(&target.Gender, source.Gender)
+	fill(&target.IDType, source.IDType)
+	fill(&target.IDNumber, source.IDNumber)
+	fill(&target.Phone, source.Phone)
+	fill(&target.Email, source.Email)
+	fill(&target.Address, source.Address)
+	if target.Birthday == nil {
+		target.Birthday = source.Birthday
+	}
+	if target.RiskLevel == "" {
+		target.RiskLevel = source.RiskLevel
+		target.RiskAssessedAt = source.RiskAssessedAt
+	}
+}
+
+// isLatinName 姓名是否只包含拉丁字母（如拼音）及分隔符
+func isLatinName(name string) bool {
+	hasLetter := false
+	for _, r := range name {
+		if unicode.IsLetter(r) {
+			if !unicode.Is(unicode.Latin, r) {
+				return false
+			}
+			hasLetter = true
+		}
+	}
+	return hasLetter
+}
+
+// uniqueStrings 去除重复值，保持原有顺序
+func uniqueStrings(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if !containsString(result, value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// linkUnlinkedPolicies 将客户号及曾用客户号下尚未关联的保单关联到客户档案，姓名以档案为准
+func (s *CustomerService) linkUnlinkedPolicies(ctx context.Context, customer *model.Customer, userID, ipAddress, userAgent string) (int64, error) {
+	numbers := append([]string{customer.CustomerNumber}, customer.Aliases...)
+	policies, err := s.customerRepo.FindUnlinkedPoliciesByNumbers(ctx, numbers, customer.CompanyID)
+	if err != nil {
+		return 0, err
+	}
+	return s.applyCustomerToPolicies(ctx, policies, customer, userID, customerSyncReason, ipAddress, userAgent)
+}
+
+// syncPolicies 将客户档案的客户号及姓名同步到已关联的保单
+func (s *CustomerService) syncPolicies(ctx context.Context, customer *model.Customer, userID, ipAddress, userAgent string) (int64, error) {
+	policies, err := s.customerRepo.ListCustomerPolicies(ctx, customer.CustomerID)
+	if err != nil {
+		return 0, err
+	}
+	return s.applyCustomerToPolicies(ctx, policies, customer, userID, customerSyncReason, ipAddress, userAgent)
+}
+
+// applyCustomerToPolicies 逐张更新保单的客户信息并记录变更，信息一致的保单跳过
+func (s *CustomerService) applyCustomerToPolicies(ctx context.Context, policies []model.Policy, customer *model.Customer, userID, reason, ipAddress, userAgent string) (int64, error) {
+	var count int64
+	for i := range policies {
+		policy := &policies[i]
+		if policy.CustomerID == customer.CustomerID &&
+			policy.CustomerNumber == customer.CustomerNumber &&
+			policy.CustomerNameCN == customer.CustomerNameCN &&
+			policy.CustomerNameEN == customer.CustomerNameEN {
+			continue
+		}
+
+		updates := bson.M{
+			"customer_id":      customer.CustomerID,
+			"customer_number":  customer.CustomerNumber,
+			"customer_name_cn": customer.CustomerNameCN,
+			"customer_name_en": customer.CustomerNameEN,
+			"updated_by":       userID,
+		}
+		if err := s.policyRepo.UpdatePolicy(ctx, policy.PolicyID, updates); err != nil {
+			return count, err
+		}
+		count++
+
+		if s.changeRecordService != nil {
+			updated := *policy
+			updated.CustomerID = customer.CustomerID
+			updated.CustomerNumber = customer.CustomerNumber
+			updated.CustomerNameCN = customer.CustomerNameCN
+			updated.CustomerNameEN = customer.CustomerNameEN
+			if err := s.changeRecordService.RecordChange(ctx, "policies", policy.PolicyID, userID, policy.CompanyID, "update", policy, &updated, reason, ipAddress, userAgent); err != nil {
+				logger.Warnf("记录保单客户信息同步变更失败: %v", err)
+			}
+		}
+	}
+
+	return count, nil
+}
+
+// findCustomerByNumber 按客户号获取公司内的客户，未找到时按曾用客户号查找
+func (s *CustomerService) findCustomerByNumber(ctx context.Context, customerNumber, companyID string) (*model.Customer, error) {
+	customer, err := s.customerRepo.GetCustomerByNumber(ctx, customerNumber, companyID)
+	if err != nil || customer != nil {
+		return customer, err
+	}
+	return s.customerRepo.GetCustomerByAlias(ctx, customerNumber, companyID)
+}
+
+// getCustomer 获取客户并校验所属公司
+func (s *CustomerService) getCustomer(ctx context.Context, customerID, companyID string) (*model.Customer, error) {
+	customer, err := s.customerRepo.GetCustomerByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil || customer.CompanyID != companyID {
+		return nil, fmt.Errorf("客户不存在")
+	}
+	return customer, nil
+}
+
+// setIfNotEmpty 值非空时写入更新字段
+func setIfNotEmpty(updates bson.M, key, value string) {
+	if value != "" {
+		updates[key] = value
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"fmt"
+
+	"YufungProject/configs"
+	"YufungProject/pkg/fieldcrypt"
+)
+
+// 主密钥来源
+const (
+	EncryptionProviderKeyFile = "keyfile" // 本地密钥文件
+)
+
+// NewFieldCodec 按加密配置加载主密钥，创建敏感字段的加密编解码器；未启用加密时返回nil
+func NewFieldCodec(config configs.EncryptionConfig) (*fieldcrypt.Codec, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+
+	var provider fieldcrypt.KeyProvider
+	switch config.Provider {
+	case "", EncryptionProviderKeyFile:
+		keyFile, err := fieldcrypt.LoadKeyFile(config.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		provider = keyFile
+	default:
+		// 接入KMS时在此创建实现 fieldcrypt.KeyProvider 的客户端
+		return nil, fmt.Errorf("不支持的主密钥来源: %s", config.Provider)
+	}
+
+	return fieldcrypt.NewCodec(provider)
+}
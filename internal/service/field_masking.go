@@ -0,0 +1,530 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"YufungProject/configs"
+	"YufungProject/internal/model"
+	"YufungProject/internal/repository"
+	"YufungProject/pkg/logger"
+)
+
+// 查看敏感字段完整值的权限标识
+const (
+	PolicyViewSensitivePermission = "policy:view_sensitive"
+	UserViewSensitivePermission   = "user:view_sensitive"
+)
+
+// 脱敏方式
+const (
+	MaskStrategyLast4  = "last4"  // 只显示后4位：****1234
+	MaskStrategyName   = "name"   // 只显示姓名每个词的首字：张*、J*** S****
+	MaskStrategyEmail  = "email"  // 只显示邮箱用户名首字及域名：a***@example.com
+	MaskStrategyPhone  = "phone"  // 只显示前3位和后4位：138****5678
+	MaskStrategyHidden = "hidden" // 完全隐藏，金额置为0并在 masked_fields 中列出
+)
+
+// maskedPlaceholder 隐藏的文本字段替换为该值
+const maskedPlaceholder = "****"
+
+// 脱敏规则字段的数据类型前缀，规则字段格式为 数据类型.字段名
+const (
+	maskEntityPolicy = "policy"
+	maskEntityUser   = "user"
+)
+
+// DefaultMaskingRules 默认脱敏规则：保单账户号、客户号、客户姓名、保费及AUM，用户邮箱和手机号
+func DefaultMaskingRules() []configs.MaskingRuleConfig {
+	return []configs.MaskingRuleConfig{
+		{Field: "policy.account_number", Strategy: MaskStrategyLast4, Permission: PolicyViewSensitivePermission},
+		{Field: "policy.customer_number", Strategy: MaskStrategyLast4, Permission: PolicyViewSensitivePermission},
+		{Field: "policy.customer_name_cn", Strategy: MaskStrategyName, Permission: PolicyViewSensitivePermission},
+		{Field: "policy.customer_name_en", Strategy: MaskStrategyName, Permission: PolicyViewSensitivePermission},
+		{Field: "policy.actual_premium", Strategy: MaskStrategyHidden, Permission: PolicyViewSensitivePermission},
+		{Field: "policy.aum", Strategy: MaskStrategyHidden, Permission: PolicyViewSensitivePermission},
+		{Field: "user.email", Strategy: MaskStrategyEmail, Permission: UserViewSensitivePermission},
+		{Field: "user.phone", Strategy: MaskStrategyPhone, Permission: UserViewSensitivePermission},
+	}
+}
+
+// maskFields 一条记录中可脱敏的字段（字段名 -> 值）
+type maskFields struct {
+	texts   map[string]*string
+	amounts map[string]*float64
+}
+
+// policyMaskFields 保单可脱敏的字段
+func policyMaskFields(policy *model.Policy) maskFields {
+	return maskFields{
+		texts: map[string]*string{
+			"account_number":   &policy.AccountNumber,
+			"customer_number":  &policy.CustomerNumber,
+			"customer_name_cn": &policy.CustomerNameCN,
+			"customer_name_en": &policy.CustomerNameEN,
+			"proposal_number":  &policy.ProposalNumber,
+		},
+		amounts: map[string]*float64{
+			"actual_premium": &policy.ActualPremium,
+			"aum":            &policy.AUM,
+			"expected_fee":   &policy.ExpectedFee,
+		},
+	}
+}
+
+// userMaskFields 用户可脱敏的字段
+func userMaskFields(email, phone *string) maskFields {
+	return maskFields{
+		texts: map[string]*string{
+			"email": email,
+			"phone": phone,
+		},
+	}
+}
+
+// maskingRule 已校验的脱敏规则
+type maskingRule struct {
+	entity     string
+	field      string
+	strategy   string
+	permission string
+	roles      []string
+}
+
+// FieldMaskingService 敏感字段脱敏服务：按查看人的权限或角色决定字段是否脱敏，并记录未脱敏的查看
+type FieldMaskingService struct {
+	rbacRepo           repository.RBACRepository
+	activityLogService *ActivityLogService
+	rules              []maskingRule
+}
+
+// NewFieldMaskingService 创建敏感字段脱敏服务，未启用脱敏时返回nil
+func NewFieldMaskingService(rbacRepo repository.RBACRepository, activityLogService *ActivityLogService, config configs.MaskingConfig) (*FieldMaskingService, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+
+	ruleConfigs := config.Rules
+	if len(ruleConfigs) == 0 {
+		ruleConfigs = DefaultMaskingRules()
+	}
+
+	var policy model.Policy
+	var email, phone string
+	fieldsByEntity := map[string]maskFields{
+		maskEntityPolicy: policyMaskFields(&policy),
+		maskEntityUser:   userMaskFields(&email, &phone),
+	}
+
+	rules := make([]maskingRule, 0, len(ruleConfigs))
+	for _, rc := range ruleConfigs {
+		entity, field, ok := strings.Cut(rc.Field, ".")
+		fields, known := fieldsByEntity[entity]
+		if !ok || !known {
+			return nil, fmt.Errorf("不支持脱敏的字段: %s", rc.Field)
+		}
+		if _, isText := fields.texts[field]; isText {
+			switch rc.Strategy {
+			case MaskStrategyLast4, MaskStrategyName, MaskStrategyEmail, MaskStrategyPhone, MaskStrategyHidden:
+			default:
+				return nil, fmt.Errorf("字段 %s 不支持脱敏方式: %s", rc.Field, rc.Strategy)
+			}
+		} else if _, isAmount := fields.amounts[field]; isAmount {
+			if rc.Strategy != MaskStrategyHidden {
+				return nil, fmt.Errorf("金额字段 %s 只支持脱敏方式 hidden", rc.Field)
+			}
+		} else {
+			return nil, fmt.Errorf("不支持脱敏的字段: %s", rc.Field)
+		}
+		if rc.Permission == "" && len(rc.Roles) == 0 {
+			return nil, fmt.Errorf("字段 %s 须配置可查看完整值的权限标识或角色", rc.Field)
+		}
+		rules = append(rules, maskingRule{
+			entity:     entity,
+			field:      field,
+			strategy:   rc.Strategy,
+			permission: rc.Permission,
+			roles:      rc.Roles,
+		})
+	}
+
+	return &FieldMaskingService{
+		rbacRepo:           rbacRepo,
+		activityLogService: activityLogService,
+		rules:              rules,
+	}, nil
+}
+
+// MaskViewer 查看数据的用户，由认证中间件按请求填写
+type MaskViewer struct {
+	UserID    string
+	Username  string
+	CompanyID string
+	RoleIDs   []string
+	ActorType string   // model.ActorTypeUser / model.ActorTypeServiceAccount
+	Scopes    []string // 服务账号API密钥的权限范围
+	KeyPrefix string   // 服务账号调用时使用的API密钥前缀
+
+	RequestURL    string
+	RequestMethod string
+	IPAddress     string
+	UserAgent     string
+	Impersonation *model.ImpersonationInfo // 模拟登录时的实际操作人
+}
+
+// NewMasker 为一次请求创建脱敏器，查看人的权限在首次脱敏时查询
+func (s *FieldMaskingService) NewMasker(viewer MaskViewer) *FieldMasker {
+	return &FieldMasker{service: s, viewer: viewer}
+}
+
+// FieldMasker 按查看人的权限对一次请求的响应数据脱敏；为nil时不脱敏
+type FieldMasker struct {
+	service *FieldMaskingService
+	viewer  MaskViewer
+
+	once     sync.Once
+	unmasked map[*maskingRule]bool // 查看人可查看完整值的规则
+}
+
+type fieldMaskerContextKey struct{}
+
+// WithFieldMasker 将脱敏器写入上下文
+func WithFieldMasker(ctx context.Context, masker *FieldMasker) context.Context {
+	return context.WithValue(ctx, fieldMaskerContextKey{}, masker)
+}
+
+// FieldMaskerFromContext 从上下文获取脱敏器，未启用脱敏或非用户请求时返回nil
+func FieldMaskerFromContext(ctx context.Context) *FieldMasker {
+	masker, _ := ctx.Value(fieldMaskerContextKey{}).(*FieldMasker)
+	return masker
+}
+
+// resolve 查询查看人对各规则的权限：平台管理员全部可见，服务账号按API密钥的权限范围，用户按角色及角色菜单的权限标识；
+// 查询权限出错时按无权限脱敏
+func (m *FieldMasker) resolve(ctx context.Context) {
+	m.once.Do(func() {
+		m.unmasked = make(map[*maskingRule]bool, len(m.service.rules))
+		isAdmin := model.IsPlatformAdminRoles(m.viewer.RoleIDs)
+		permissions := make(map[string]bool)
+
+		for i := range m.service.rules {
+			rule := &m.service.rules[i]
+			if isAdmin || containsAny(m.viewer.RoleIDs, rule.roles) {
+				m.unmasked[rule] = true
+				continue
+			}
+			if rule.permission == "" {
+				continue
+			}
+			allowed, checked := permissions[rule.permission]
+			if !checked {
+				allowed = m.hasPermission(ctx, rule.permission)
+				permissions[rule.permission] = allowed
+			}
+			m.unmasked[rule] = allowed
+		}
+	})
+}
+
+// hasPermission 查看人是否拥有权限标识
+func (m *FieldMasker) hasPermission(ctx context.Context, permission string) bool {
+	if m.viewer.ActorType == model.ActorTypeServiceAccount {
+		return containsAny(m.viewer.Scopes, []string{permission})
+	}
+	allowed, err := m.service.rbacRepo.CheckUserPermission(ctx, m.viewer.UserID, permission)
+	if err != nil {
+		logger.Errorf("查询敏感字段查看权限失败，按无权限脱敏: UserID=%s, Permission=%s, Error=%v", m.viewer.UserID, permission, err)
+		return false
+	}
+	return allowed
+}
+
+// MaskPolicies 按上下文中的脱敏器原地脱敏保单，返回被脱敏的字段；查看人可见的敏感字段记录为查看活动
+func MaskPolicies(ctx context.Context, policies ...*model.Policy) []string {
+	return FieldMaskerFromContext(ctx).maskPolicies(ctx, policies...)
+}
+
+// MaskPolicyResponses 按上下文中的脱敏器原地脱敏保单响应，并填写被脱敏的字段
+func MaskPolicyResponses(ctx context.Context, responses ...*model.PolicyResponse) {
+	FieldMaskerFromContext(ctx).maskPolicyResponses(ctx, responses...)
+}
+
+// MaskCustomerDetail 按上下文中的脱敏器原地脱敏客户详情的关联保单，保费或AUM对查看人隐藏时汇总金额一并隐藏
+func MaskCustomerDetail(ctx context.Context, detail *model.CustomerDetailResponse) {
+	masker := FieldMaskerFromContext(ctx)
+	if masker == nil {
+		return
+	}
+	policies := make([]*model.Policy, len(detail.Policies))
+	for i := range detail.Policies {
+		policies[i] = &detail.Policies[i]
+	}
+	masker.maskPolicies(ctx, policies...)
+
+	hidden := masker.hiddenFields(ctx, maskEntityPolicy)
+	summary := &detail.Summary
+	if hidden["actual_premium"] {
+		summary.TotalPremium = 0
+		for i := range summary.ByCurrency {
+			summary.ByCurrency[i].TotalPremium = 0
+		}
+	}
+	if hidden["aum"] {
+		summary.TotalAUM = 0
+		for i := range summary.ByCurrency {
+			summary.ByCurrency[i].TotalAUM = 0
+		}
+	}
+}
+
+// MaskUserInfos 按上下文中的脱敏器原地脱敏用户信息，查看本人的信息不脱敏也不记录
+func MaskUserInfos(ctx context.Context, users ...*model.UserInfo) {
+	FieldMaskerFromContext(ctx).maskUserInfos(ctx, users...)
+}
+
+// MaskUsers 按上下文中的脱敏器原地脱敏用户（用于导出），查看本人的信息不脱敏也不记录
+func MaskUsers(ctx context.Context, users ...*model.User) {
+	FieldMaskerFromContext(ctx).maskUsers(ctx, users...)
+}
+
+// HiddenPolicyFields 对查看人完全隐藏的保单字段（字段名 -> true），导出文件中以占位符代替
+func HiddenPolicyFields(ctx context.Context) map[string]bool {
+	return FieldMaskerFromContext(ctx).hiddenFields(ctx, maskEntityPolicy)
+}
+
+// hiddenFields 对查看人完全隐藏的字段
+func (m *FieldMasker) hiddenFields(ctx context.Context, entity string) map[string]bool {
+	hidden := make(map[string]bool)
+	if m == nil {
+		return hidden
+	}
+	m.resolve(ctx)
+	for i := range m.service.rules {
+		rule := &m.service.rules[i]
+		if rule.entity == entity && rule.strategy == MaskStrategyHidden && !m.unmasked[rule] {
+			hidden[rule.field] = true
+		}
+	}
+	return hidden
+}
+
+// maskPolicies 原地脱敏保单
+func (m *FieldMasker) maskPolicies(ctx context.Context, policies ...*model.Policy) []string {
+	if m == nil {
+		return nil
+	}
+	var masked []string
+	var views []*model.ActivityLog
+	for _, policy := range policies {
+		if policy == nil {
+			continue
+		}
+		fields, revealed := m.apply(ctx, maskEntityPolicy, policyMaskFields(policy))
+		if len(fields) > len(masked) {
+			masked = fields
+		}
+		if revealed {
+			views = append(views, m.viewLog(model.ModulePolicy, policy.PolicyID, policy.ProposalNumber, policy.CompanyID))
+		}
+	}
+	m.recordViews(views)
+	return masked
+}
+
+// maskPolicyResponses 原地脱敏保单响应
+func (m *FieldMasker) maskPolicyResponses(ctx context.Context, responses ...*model.PolicyResponse) {
+	if m == nil {
+		return
+	}
+	policies := make([]*model.Policy, 0, len(responses))
+	for _, response := range responses {
+		policies = append(policies, response.Policy)
+	}
+	masked := m.maskPolicies(ctx, policies...)
+	for _, response := range responses {
+		response.MaskedFields = masked
+	}
+}
+
+// maskUserInfos 原地脱敏用户信息
+func (m *FieldMasker) maskUserInfos(ctx context.Context, users ...*model.UserInfo) {
+	if m == nil {
+		return
+	}
+	var views []*model.ActivityLog
+	for _, user := range users {
+		if user == nil || user.UserID == m.viewer.UserID {
+			continue
+		}
+		fields, revealed := m.apply(ctx, maskEntityUser, userMaskFields(&user.Email, &user.Phone))
+		user.MaskedFields = fields
+		if revealed {
+			views = append(views, m.viewLog(model.ModuleUser, user.UserID, user.Username, user.CompanyID))
+		}
+	}
+	m.recordViews(views)
+}
+
+// maskUsers 原地脱敏用户
+func (m *FieldMasker) maskUsers(ctx context.Context, users ...*model.User) {
+	if m == nil {
+		return
+	}
+	var views []*model.ActivityLog
+	for _, user := range users {
+		if user == nil || user.UserID == m.viewer.UserID {
+			continue
+		}
+		if _, revealed := m.apply(ctx, maskEntityUser, userMaskFields(&user.Email, &user.Phone)); revealed {
+			views = append(views, m.viewLog(model.ModuleUser, user.UserID, user.Username, user.CompanyID))
+		}
+	}
+	m.recordViews(views)
+}
+
+// apply 按规则脱敏一条记录，返回被脱敏的字段，以及是否有非空的敏感字段以完整值展示给查看人
+func (m *FieldMasker) apply(ctx context.Context, entity string, fields maskFields) ([]string, bool) {
+	m.resolve(ctx)
+
+	var masked []string
+	revealed := false
+	for i := range m.service.rules {
+		rule := &m.service.rules[i]
+		if rule.entity != entity {
+			continue
+		}
+		if text, ok := fields.texts[rule.field]; ok {
+			if m.unmasked[rule] {
+				revealed = revealed || *text != ""
+				continue
+			}
+			*text = maskText(rule.strategy, *text)
+		} else if amount, ok := fields.amounts[rule.field]; ok {
+			if m.unmasked[rule] {
+				revealed = revealed || *amount != 0
+				continue
+			}
+			*amount = 0
+		}
+		masked = append(masked, rule.field)
+	}
+	return masked, revealed
+}
+
+// viewLog 未脱敏查看敏感记录的活动记录
+func (m *FieldMasker) viewLog(moduleName, targetID, targetName, companyID string) *model.ActivityLog {
+	log := &model.ActivityLog{
+		UserID:        m.viewer.UserID,
+		Username:      m.viewer.Username,
+		CompanyID:     m.viewer.CompanyID,
+		CompanyName:   "未知公司",
+		OperationType: model.OperationTypeView,
+		ModuleName:    moduleName,
+		OperationDesc: "查看未脱敏的敏感信息",
+		RequestURL:    m.viewer.RequestURL,
+		RequestMethod: m.viewer.RequestMethod,
+		RequestParams: map[string]string{"record_company_id": companyID},
+		IPAddress:     m.viewer.IPAddress,
+		UserAgent:     m.viewer.UserAgent,
+		ResultStatus:  "success",
+		TargetID:      targetID,
+		TargetName:    targetName,
+		ActorType:     m.viewer.ActorType,
+		APIKeyPrefix:  m.viewer.KeyPrefix,
+	}
+	if impersonation := m.viewer.Impersonation; impersonation != nil {
+		log.ImpersonatorID = impersonation.ActorID
+		log.ImpersonatorName = impersonation.ActorName
+		log.ImpersonationID = impersonation.SessionID
+	}
+	return log
+}
+
+// recordViews 异步记录未脱敏查看的活动，失败不影响响应
+func (m *FieldMasker) recordViews(views []*model.ActivityLog) {
+	if len(views) == 0 || m.service.activityLogService == nil {
+		return
+	}
+	go func() {
+		if err := m.service.activityLogService.CreateActivityLogs(context.Background(), views); err != nil {
+			logger.Errorf("记录敏感信息查看活动失败: %v", err)
+		}
+	}()
+}
+
+// exportAmountCell 导出Excel的金额，对查看人隐藏的金额以占位符代替
+func exportAmountCell(hidden map[string]bool, field string, value float64) interface{} {
+	if hidden[field] {
+		return maskedPlaceholder
+	}
+	return value
+}
+
+// exportAmountText 导出CSV的金额，对查看人隐藏的金额以占位符代替
+func exportAmountText(hidden map[string]bool, field string, value float64) string {
+	if hidden[field] {
+		return maskedPlaceholder
+	}
+	return fmt.Sprintf("%.2f", value)
+}
+
+// maskText 按脱敏方式处理文本，空值不处理
+func maskText(strategy, value string) string {
+	if value == "" {
+		return value
+	}
+	switch strategy {
+	case MaskStrategyLast4:
+		runes := []rune(value)
+		if len(runes) <= 4 {
+			return maskedPlaceholder
+		}
+		return maskedPlaceholder + string(runes[len(runes)-4:])
+	case MaskStrategyName:
+		words := strings.Fields(value)
+		for i, word := range words {
+			words[i] = maskKeepEdges(word, 1, 0)
+		}
+		return strings.Join(words, " ")
+	case MaskStrategyEmail:
+		local, domain, ok := strings.Cut(value, "@")
+		if !ok {
+			return maskKeepEdges(value, 1, 0)
+		}
+		return maskKeepEdges(local, 1, 0) + "@" + domain
+	case MaskStrategyPhone:
+		if utf8.RuneCountInString(value) >= 8 {
+			return maskKeepEdges(value, 3, 4)
+		}
+		return maskKeepEdges(value, 0, 4)
+	default:
+		return maskedPlaceholder
+	}
+}
+
+// maskKeepEdges 保留开头 head 个、结尾 tail 个字符，其余替换为*；字符数不多于保留数时全部替换
+func maskKeepEdges(value string, head, tail int) string {
+	runes := []rune(value)
+	if len(runes) <= head+tail {
+		if head > 0 && len(runes) > 1 {
+			return string(runes[:1]) + strings.Repeat("*", len(runes)-1)
+		}
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:head]) + strings.Repeat("*", len(runes)-head-tail) + string(runes[len(runes)-tail:])
+}
+
+// containsAny 两个列表是否有相同元素
+func containsAny(values, targets []string) bool {
+	for _, value := range values {
+		for _, target := range targets {
+			if value == target {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"YufungProject/configs"
+	"YufungProject/internal/model"
+	"YufungProject/internal/repository"
+	"YufungProject/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	// 查询权限出错等分支会写错误日志
+	if err := logger.InitLogger(logger.LogConfig{Level: "fatal", Output: "stdout"}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeRBACRepository 按用户ID返回权限标识的RBAC仓库，只实现 CheckUserPermission
+type fakeRBACRepository struct {
+	repository.RBACRepository
+	permissions map[string][]string // 用户ID -> 权限标识
+	err         error
+	calls       int
+}
+
+func (r *fakeRBACRepository) CheckUserPermission(ctx context.Context, userID string, permissionCode string) (bool, error) {
+	r.calls++
+	if r.err != nil {
+		return false, r.err
+	}
+	return containsAny(r.permissions[userID], []string{permissionCode}), nil
+}
+
+// samplePolicy 包含全部默认敏感字段的保单
+func samplePolicy() *model.Policy {
+	return &model.Policy{
+		PolicyID:       "P001",
+		ProposalNumber: "PN001",
+		AccountNumber:  "6222001234",
+		CustomerNumber: "C99887766",
+		CustomerNameCN: "张三",
+		CustomerNameEN: "San Zhang",
+		ActualPremium:  12000,
+		AUM:            50000,
+		ExpectedFee:    300,
+	}
+}
+
+func TestMaskText(t *testing.T) {
+	tests := []struct {
+		strategy string
+		value    string
+		want     string
+	}{
+		{MaskStrategyLast4, "6222001234", "****1234"},
+		{MaskStrategyLast4, "12345", "****2345"},
+		{MaskStrategyLast4, "1234", "****"},
+		{MaskStrategyLast4, "账户号码一二三四", "****一二三四"},
+		{MaskStrategyName, "张三", "张*"},
+		{MaskStrategyName, "欧阳娜娜", "欧***"},
+		{MaskStrategyName, "John  Smith", "J*** S****"},
+		{MaskStrategyName, "李", "*"},
+		{MaskStrategyEmail, "alice@example.com", "a****@example.com"},
+		{MaskStrategyEmail, "a@example.com", "*@example.com"},
+		{MaskStrategyEmail, "not-an-email", "n***********"},
+		{MaskStrategyPhone, "13812345678", "138****5678"},
+		{MaskStrategyPhone, "85291234", "852*1234"},
+		{MaskStrategyPhone, "12345", "*2345"},
+		{MaskStrategyHidden, "secret", "****"},
+		{MaskStrategyLast4, "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := maskText(tt.strategy, tt.value); got != tt.want {
+			t.Errorf("maskText(%q, %q) = %q, want %q", tt.strategy, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMaskPolicies(t *testing.T) {
+	maskedPolicy := samplePolicy()
+	maskedPolicy.AccountNumber = "****1234"
+	maskedPolicy.CustomerNumber = "****7766"
+	maskedPolicy.CustomerNameCN = "张*"
+	maskedPolicy.CustomerNameEN = "S** Z****"
+	maskedPolicy.ActualPremium = 0
+	maskedPolicy.AUM = 0
+	allMasked := []string{"account_number", "customer_number", "customer_name_cn", "customer_name_en", "actual_premium", "aum"}
+
+	tests := []struct {
+		name       string
+		viewer     MaskViewer
+		rbacErr    error
+		want       *model.Policy
+		wantMasked []string
+		wantCalls  int
+	}{
+		{
+			name:       "无查看权限的用户",
+			viewer:     MaskViewer{UserID: "u1", RoleIDs: []string{"sales"}, ActorType: model.ActorTypeUser},
+			want:       maskedPolicy,
+			wantMasked: allMasked,
+			wantCalls:  2,
+		},
+		{
+			name:      "拥有查看权限的用户",
+			viewer:    MaskViewer{UserID: "viewer", RoleIDs: []string{"sales"}, ActorType: model.ActorTypeUser},
+			want:      samplePolicy(),
+			wantCalls: 2,
+		},
+		{
+			name:      "平台管理员不查询权限",
+			viewer:    MaskViewer{UserID: "u1", RoleIDs: []string{"sales", "SUPER_ADMIN"}, ActorType: model.ActorTypeUser},
+			want:      samplePolicy(),
+			wantCalls: 0,
+		},
+		{
+			name:       "查询权限出错时按无权限脱敏",
+			viewer:     MaskViewer{UserID: "viewer", ActorType: model.ActorTypeUser},
+			rbacErr:    errors.New("connection refused"),
+			want:       maskedPolicy,
+			wantMasked: allMasked,
+			wantCalls:  2,
+		},
+		{
+			name:      "服务账号拥有权限范围",
+			viewer:    MaskViewer{UserID: "sa1", ActorType: model.ActorTypeServiceAccount, Scopes: []string{"policy:read", PolicyViewSensitivePermission}},
+			want:      samplePolicy(),
+			wantCalls: 0,
+		},
+		{
+			name:       "服务账号没有权限范围",
+			viewer:     MaskViewer{UserID: "viewer", ActorType: model.ActorTypeServiceAccount, Scopes: []string{"policy:read"}},
+			want:       maskedPolicy,
+			wantMasked: allMasked,
+			wantCalls:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rbacRepo := &fakeRBACRepository{
+				permissions: map[string][]string{"viewer": {PolicyViewSensitivePermission}},
+				err:         tt.rbacErr,
+			}
+			service, err := NewFieldMaskingService(rbacRepo, nil, configs.MaskingConfig{Enabled: true})
+			if err != nil {
+				t.Fatalf("NewFieldMaskingService() error = %v", err)
+			}
+			ctx := WithFieldMasker(context.Background(), service.NewMasker(tt.viewer))
+
+			first, second := samplePolicy(), samplePolicy()
+			masked := MaskPolicies(ctx, first, nil, second)
+
+			if !reflect.DeepEqual(first, tt.want) || !reflect.DeepEqual(second, tt.want) {
+				t.Errorf("MaskPolicies() = %+v, want %+v", first, tt.want)
+			}
+			if !reflect.DeepEqual(masked, tt.wantMasked) {
+				t.Errorf("masked fields = %v, want %v", masked, tt.wantMasked)
+			}
+			if rbacRepo.calls != tt.wantCalls {
+				t.Errorf("CheckUserPermission 调用 %d 次, want %d（默认规则有两个权限标识，每个只查询一次）", rbacRepo.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestMaskPoliciesWithoutMasker(t *testing.T) {
+	policy := samplePolicy()
+	if masked := MaskPolicies(context.Background(), policy); masked != nil {
+		t.Errorf("masked fields = %v, want nil", masked)
+	}
+	if !reflect.DeepEqual(policy, samplePolicy()) {
+		t.Errorf("上下文中没有脱敏器时不应脱敏")
+	}
+	if hidden := HiddenPolicyFields(context.Background()); len(hidden) != 0 {
+		t.Errorf("HiddenPolicyFields() = %v, want empty", hidden)
+	}
+}
+
+func TestMaskingRuleRoles(t *testing.T) {
+	service, err := NewFieldMaskingService(&fakeRBACRepository{}, nil, configs.MaskingConfig{
+		Enabled: true,
+		Rules: []configs.MaskingRuleConfig{
+			{Field: "policy.account_number", Strategy: MaskStrategyLast4, Roles: []string{"finance"}},
+			{Field: "policy.expected_fee", Strategy: MaskStrategyHidden, Roles: []string{"finance"}, Permission: "policy:view_fee"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewFieldMaskingService() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		roleIDs     []string
+		wantAccount string
+		wantFee     float64
+		wantHidden  map[string]bool
+	}{
+		{name: "配置的角色可查看", roleIDs: []string{"finance"}, wantAccount: "6222001234", wantFee: 300, wantHidden: map[string]bool{}},
+		{name: "其他角色脱敏", roleIDs: []string{"sales"}, wantAccount: "****1234", wantFee: 0, wantHidden: map[string]bool{"expected_fee": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithFieldMasker(context.Background(), service.NewMasker(MaskViewer{UserID: "u1", RoleIDs: tt.roleIDs, ActorType: model.ActorTypeUser}))
+			policy := samplePolicy()
+			MaskPolicies(ctx, policy)
+
+			if policy.AccountNumber != tt.wantAccount || policy.ExpectedFee != tt.wantFee {
+				t.Errorf("account_number = %q, expected_fee = %v, want %q, %v", policy.AccountNumber, policy.ExpectedFee, tt.wantAccount, tt.wantFee)
+			}
+			if policy.CustomerNameCN != "张三" {
+				t.Errorf("未配置规则的字段不应脱敏, customer_name_cn = %q", policy.CustomerNameCN)
+			}
+			if hidden := HiddenPolicyFields(ctx); !reflect.DeepEqual(hidden, tt.wantHidden) {
+				t.Errorf("HiddenPolicyFields() = %v, want %v", hidden, tt.wantHidden)
+			}
+		})
+	}
+}
+
+func TestMaskUserInfos(t *testing.T) {
+	service, err := NewFieldMaskingService(&fakeRBACRepository{}, nil, configs.MaskingConfig{Enabled: true})
+	if err != nil {
+		t.Fatalf("NewFieldMaskingService() error = %v", err)
+	}
+	ctx := WithFieldMasker(context.Background(), service.NewMasker(MaskViewer{UserID: "self", ActorType: model.ActorTypeUser}))
+
+	self := &model.UserInfo{UserID: "self", Email: "self@example.com", Phone: "13800001111"}
+	other := &model.UserInfo{UserID: "other", Email: "bob@example.com", Phone: "13912345678"}
+	MaskUserInfos(ctx, self, other)
+
+	if self.Email != "self@example.com" || self.Phone != "13800001111" || self.MaskedFields != nil {
+		t.Errorf("查看本人信息不应脱敏: %+v", self)
+	}
+	if other.Email != "b**@example.com" || other.Phone != "139****5678" {
+		t.Errorf("其他用户 email = %q, phone = %q", other.Email, other.Phone)
+	}
+	if want := []string{"email", "phone"}; !reflect.DeepEqual(other.MaskedFields, want) {
+		t.Errorf("MaskedFields = %v, want %v", other.MaskedFields, want)
+	}
+}
+
+func TestNewFieldMaskingService(t *testing.T) {
+	disabled, err := NewFieldMaskingService(&fakeRBACRepository{}, nil, configs.MaskingConfig{Enabled: false})
+	if err != nil || disabled != nil {
+		t.Errorf("未启用脱敏时应返回nil, got %v, %v", disabled, err)
+	}
+
+	tests := []struct {
+		name string
+		rule configs.MaskingRuleConfig
+	}{
+		{name: "缺少数据类型", rule: configs.MaskingRuleConfig{Field: "account_number", Strategy: MaskStrategyLast4, Permission: PolicyViewSensitivePermission}},
+		{name: "未知数据类型", rule: configs.MaskingRuleConfig{Field: "customer.phone", Strategy: MaskStrategyPhone, Permission: PolicyViewSensitivePermission}},
+		{name: "不支持的字段", rule: configs.MaskingRuleConfig{Field: "policy.product_name", Strategy: MaskStrategyLast4, Permission: PolicyViewSensitivePermission}},
+		{name: "不支持的脱敏方式", rule: configs.MaskingRuleConfig{Field: "policy.account_number", Strategy: "first4", Permission: PolicyViewSensitivePermission}},
+		{name: "金额只能隐藏", rule: configs.MaskingRuleConfig{Field: "policy.aum", Strategy: MaskStrategyLast4, Permission: PolicyViewSensitivePermission}},
+		{name: "未配置权限或角色", rule: configs.MaskingRuleConfig{Field: "user.email", Strategy: MaskStrategyEmail}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configs.MaskingConfig{Enabled: true, Rules: []configs.MaskingRuleConfig{tt.rule}}
+			if _, err := NewFieldMaskingService(&fakeRBACRepository{}, nil, config); err == nil {
+				t.Errorf("NewFieldMaskingService() error = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"YufungProject/configs"
+	"YufungProject/internal/model"
+	"YufungProject/internal/repository"
+	"YufungProject/pkg/logger"
+	"YufungProject/pkg/utils"
+)
+
+// defaultImpersonationTTL 模拟登录令牌默认有效期（未配置时使用）
+const defaultImpersonationTTL = 30 * time.Minute
+
+// ErrImpersonationEnded 模拟登录会话已结束、已过期或发起人的会话已被吊销
+var ErrImpersonationEnded = errors.New("模拟登录已结束，请重新登录")
+
+// ImpersonationService 平台管理员模拟登录：签发携带实际操作人的短期令牌，记录会话的开始和结束
+type ImpersonationService struct {
+	impersonationRepo *repository.ImpersonationRepository
+	userRepo          repository.UserRepository
+	sessionService    *SessionService
+	config            configs.ImpersonationConfig
+	jwtUtil           *utils.JWTUtil
+	tokenTTL          time.Duration
+}
+
+func NewImpersonationService(impersonationRepo *repository.ImpersonationRepository, userRepo repository.UserRepository, sessionService *SessionService, jwtUtil *utils.JWTUtil, config *configs.Config) *ImpersonationService {
+	return &ImpersonationService{
+		impersonationRepo: impersonationRepo,
+		userRepo:          userRepo,
+		sessionService:    sessionService,
+		config:            config.Security.Impersonation,
+		jwtUtil:           jwtUtil,
+		tokenTTL:          parseDurationOr(config.Security.Impersonation.TokenTTL, defaultImpersonationTTL),
+	}
+}
+
+// StartImpersonation 平台管理员开始模拟目标用户登录，返回模拟登录令牌；不能模拟自己、平台管理员或已停用的用户
+func (s *ImpersonationService) StartImpersonation(ctx context.Context, req *model.ImpersonationStartRequest, actorID, actorName, clientIP, userAgent string) (*model.ImpersonationStartResponse, error) {
+	if !s.config.Enabled {
+		return nil, errors.New("模拟登录未启用")
+	}
+	if req.UserID == actorID {
+		return nil, errors.New("不能模拟自己登录")
+	}
+
+	target, err := s.userRepo.GetByUserID(ctx, req.UserID)
+	if err != nil || target == nil {
+		return nil, errors.New("用户不存在")
+	}
+	if target.Status != "active" {
+		return nil, errors.New("只能模拟状态正常的用户")
+	}
+	if model.IsPlatformAdminRoles(target.RoleIDs) {
+		return nil, errors.New("不能模拟平台管理员")
+	}
+
+	// 配置禁止写操作时始终只读，否则由发起人选择，默认只读
+	readOnly := s.config.BlockWrites || req.ReadOnly == nil || *req.ReadOnly
+
+	now := time.Now()
+	session := &model.ImpersonationSession{
+		SessionID:       utils.GenerateID("IMP"),
+		ActorID:         actorID,
+		ActorName:       actorName,
+		TargetUserID:    target.UserID,
+		TargetUsername:  target.Username,
+		TargetCompanyID: target.CompanyID,
+		Reason:          req.Reason,
+		ReadOnly:        readOnly,
+		Status:          model.ImpersonationStatusActive,
+		IPAddress:       clientIP,
+		UserAgent:       userAgent,
+		StartedAt:       now,
+		ExpiresAt:       now.Add(s.tokenTTL),
+	}
+
+	token, expiresAt, err := s.jwtUtil.GenerateImpersonationToken(target.UserID, target.Username, target.CompanyID, target.RoleIDs, &utils.ImpersonationClaims{
+		SessionID: session.SessionID,
+		ActorID:   actorID,
+		ActorName: actorName,
+		ReadOnly:  readOnly,
+	}, s.tokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("生成模拟登录令牌失败: %w", err)
+	}
+	session.ExpiresAt = expiresAt
+
+	if err := s.impersonationRepo.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("保存模拟登录会话失败: %w", err)
+	}
+
+	logger.Warnf("开始模拟登录: SessionID=%s, Actor=%s(%s), Target=%s(%s), ReadOnly=%v, Reason=%s, IP=%s", session.SessionID, actorName, actorID, target.Username, target.UserID, readOnly, req.Reason, clientIP)
+	logger.AuthLog("impersonation_start", actorName, clientIP, true, fmt.Sprintf("模拟用户 %s 登录，会话 %s，原因：%s", target.Username, session.SessionID, req.Reason))
+
+	return &model.ImpersonationStartResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		Session:   session,
+		User: &model.UserInfo{
+			ID:          target.ID.Hex(),
+			UserID:      target.UserID,
+			Username:    target.Username,
+			DisplayName: target.DisplayName,
+			CompanyID:   target.CompanyID,
+			RoleIDs:     target.RoleIDs,
+			Status:      target.Status,
+			Email:       target.Email,
+			Phone:       target.Phone,
+			LastLogin:   target.LastLoginTime,
+			Impersonation: &model.ImpersonationInfo{
+				SessionID: session.SessionID,
+				ActorID:   actorID,
+				ActorName: actorName,
+				ReadOnly:  readOnly,
+				ExpiresAt: expiresAt,
+			},
+		},
+	}, nil
+}
+
+// StopImpersonation 结束模拟登录会话，会话的模拟登录令牌随即失效
+func (s *ImpersonationService) StopImpersonation(ctx context.Context, sessionID, operatorID, clientIP string) error {
+	session, err := s.impersonationRepo.End(ctx, sessionID, operatorID, time.Now())
+	if err != nil {
+		return fmt.Errorf("结束模拟登录失败: %w", err)
+	}
+	if session == nil {
+		return errors.New("模拟登录会话不存在或已结束")
+	}
+
+	logger.Warnf("结束模拟登录: SessionID=%s, Actor=%s(%s), Target=%s(%s), Operator=%s, BlockedWrites=%d", session.SessionID, session.ActorName, session.ActorID, session.TargetUsername, session.TargetUserID, operatorID, session.BlockedWriteHits)
+	logger.AuthLog("impersonation_stop", session.ActorName, clientIP, true, fmt.Sprintf("结束模拟用户 %s 登录，会话 %s", session.TargetUsername, session.SessionID))
+	return nil
+}
+
+// CheckImpersonation 校验模拟登录令牌对应的会话仍在进行中，且发起人的会话未被吊销
+func (s *ImpersonationService) CheckImpersonation(ctx context.Context, claims *utils.Claims) error {
+	session, err := s.impersonationRepo.GetBySessionID(ctx, claims.Impersonation.SessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.Status != model.ImpersonationStatusActive || !time.Now().Before(session.ExpiresAt) ||
+		session.ActorID != claims.Impersonation.ActorID || session.TargetUserID != claims.UserID {
+		return ErrImpersonationEnded
+	}
+
+	// 发起人重置密码或被吊销会话后，其发起的模拟登录一并失效
+	if claims.IssuedAt != nil {
+		if err := s.sessionService.CheckIssuedAt(ctx, session.ActorID, claims.IssuedAt.Time); err != nil {
+			if errors.Is(err, ErrSessionRevoked) {
+				return ErrImpersonationEnded
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordBlockedWrite 记录模拟登录期间被拦截的写操作
+func (s *ImpersonationService) RecordBlockedWrite(ctx context.Context, sessionID, path string) {
+	if err := s.impersonationRepo.RecordBlockedWrite(ctx, sessionID, path); err != nil {
+		logger.Errorf("记录模拟登录拦截的写操作失败: %v, SessionID: %s", err, sessionID)
+	}
+}
+
+// ListSessions 分页查询模拟登录会话，未主动结束且已过期的会话状态显示为expired
+func (s *ImpersonationService) ListSessions(ctx context.Context, req *model.ImpersonationQueryRequest) (*model.ImpersonationListResponse, error) {
+	now := time.Now()
+	sessions, total, err := s.impersonationRepo.List(ctx, req, now)
+	if err != nil {
+		return nil, fmt.Errorf("查询模拟登录会话失败: %w", err)
+	}
+	for i := range sessions {
+		if sessions[i].Status == model.ImpersonationStatusActive && !now.Before(sessions[i].ExpiresAt) {
+			sessions[i].Status = model.ImpersonationStatusExpired
+		}
+	}
+
+	return &model.ImpersonationListResponse{
+		List:     sessions,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
@@ -0,0 +1,456 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"YufungProject/configs"
+	"YufungProject/internal/model"
+	"YufungProject/internal/repository"
+	"YufungProject/pkg/logger"
+	"YufungProject/pkg/utils"
+)
+
+// 邀请注册默认值（未配置时使用）
+const (
+	defaultInvitationTokenTTL  = 72 * time.Hour
+	invitationTokenBytes       = 32
+	invitationLockTTL          = 30 * time.Second
+	invitationLockRetries      = 10
+	invitationLockRetryBackoff = 100 * time.Millisecond
+)
+
+// InvitationService 邀请注册：公司管理员邀请邮箱并预分配角色，受邀人通过一次性链接设置密码后加入公司
+type InvitationService struct {
+	invitationRepo *repository.InvitationRepository
+	userRepo       repository.UserRepository
+	companyRepo    repository.CompanyRepository
+	roleRepo       repository.RoleRepository
+	policyService  *PasswordPolicyService
+	mailService    *MailService
+	config         configs.InvitationConfig
+	tokenTTL       time.Duration
+}
+
+func NewInvitationService(invitationRepo *repository.InvitationRepository, userRepo repository.UserRepository, companyRepo repository.CompanyRepository, roleRepo repository.RoleRepository, policyService *PasswordPolicyService, mailService *MailService, config configs.InvitationConfig) *InvitationService {
+	return &InvitationService{
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		companyRepo:    companyRepo,
+		roleRepo:       roleRepo,
+		policyService:  policyService,
+		mailService:    mailService,
+		config:         config,
+		tokenTTL:       parseDurationOr(config.TokenTTL, defaultInvitationTokenTTL),
+	}
+}
+
+// CreateInvitation 邀请用户加入公司；已有用户和未过期的待接受邀请合计不能超过公司用户配额
+func (s *InvitationService) CreateInvitation(ctx context.Context, req *model.InvitationCreateRequest, invitedBy string, isPlatformAdmin bool) (*model.Invitation, error) {
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	company, err := s.companyRepo.GetCompanyByID(ctx, req.CompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("查询公司失败: %w", err)
+	}
+	if company == nil {
+		return nil, errors.New("公司不存在")
+	}
+	if company.Status != "active" {
+		return nil, errors.New("公司已停用，不能邀请用户")
+	}
+
+	if err := s.validateRoles(ctx, company.CompanyID, req.RoleIDs, isPlatformAdmin); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.userRepo.ExistsByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("检查邮箱失败: %w", err)
+	}
+	if exists {
+		return nil, errors.New("邮箱已存在")
+	}
+
+	now := time.Now()
+	pending, err := s.invitationRepo.ExistsPending(ctx, company.CompanyID, email, now)
+	if err != nil {
+		return nil, fmt.Errorf("查询邀请失败: %w", err)
+	}
+	if pending {
+		return nil, errors.New("该邮箱已有待接受的邀请")
+	}
+
+	if err := s.checkQuota(ctx, company, true); err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateSecureToken(invitationTokenBytes)
+	if err != nil {
+		return nil, fmt.Errorf("生成邀请令牌失败: %w", err)
+	}
+
+	invitation := &model.Invitation{
+		InvitationID: utils.GenerateID("INV"),
+		CompanyID:    company.CompanyID,
+		Email:        email,
+		DisplayName:  strings.TrimSpace(req.DisplayName),
+		RoleIDs:      req.RoleIDs,
+		TokenHash:    utils.HashToken(token),
+		Status:       model.InvitationStatusPending,
+		ExpiresAt:    now.Add(s.tokenTTL),
+		SendCount:    1,
+		LastSentAt:   now,
+		InvitedBy:    invitedBy,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.invitationRepo.Create(ctx, invitation); err != nil {
+		return nil, fmt.Errorf("保存邀请失败: %w", err)
+	}
+
+	if err := s.sendInvitation(ctx, invitation, company, token, invitedBy); err != nil {
+		logger.Errorf("发送邀请邮件失败: %v, InvitationID: %s", err, invitation.InvitationID)
+	}
+
+	logger.Infof("邀请用户: InvitationID=%s, CompanyID=%s, Email=%s, InvitedBy=%s", invitation.InvitationID, invitation.CompanyID, invitation.Email, invitedBy)
+	return invitation, nil
+}
+
+// GetInvitation 获取邀请详情
+func (s *InvitationService) GetInvitation(ctx context.Context, invitationID string) (*model.Invitation, error) {
+	invitation, err := s.invitationRepo.GetByInvitationID(ctx, invitationID)
+	if err != nil {
+		return nil, fmt.Errorf("查询邀请失败: %w", err)
+	}
+	if invitation == nil {
+		return nil, errors.New("邀请不存在")
+	}
+	markExpired(invitation, time.Now())
+	return invitation, nil
+}
+
+// ListInvitations 分页查询邀请
+func (s *InvitationService) ListInvitations(ctx context.Context, req *model.InvitationQueryRequest) (*model.InvitationListResponse, error) {
+	now := time.Now()
+	invitations, total, err := s.invitationRepo.List(ctx, req, now)
+	if err != nil {
+		return nil, fmt.Errorf("查询邀请列表失败: %w", err)
+	}
+	for i := range invitations {
+		markExpired(&invitations[i], now)
+	}
+
+	return &model.InvitationListResponse{
+		List:     invitations,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// ResendInvitation 重新发送邀请：更换令牌并重新计算有效期，之前发出的链接失效；已过期的邀请也可重新发送
+func (s *InvitationService) ResendInvitation(ctx context.Context, invitationID, operatorID string) (*model.Invitation, error) {
+	invitation, err := s.GetInvitation(ctx, invitationID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.Status != model.InvitationStatusPending && invitation.Status != model.InvitationStatusExpired {
+		return nil, errors.New("只能重新发送待接受的邀请")
+	}
+
+	company, err := s.companyRepo.GetCompanyByID(ctx, invitation.CompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("查询公司失败: %w", err)
+	}
+	if company == nil || company.Status != "active" {
+		return nil, errors.New("公司已停用，不能邀请用户")
+	}
+	// 已过期的邀请不再占用配额，重新发送前需确认仍有余量
+	if invitation.Status == model.InvitationStatusExpired {
+		if err := s.checkQuota(ctx, company, true); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := utils.GenerateSecureToken(invitationTokenBytes)
+	if err != nil {
+		return nil, fmt.Errorf("生成邀请令牌失败: %w", err)
+	}
+	now := time.Now()
+	renewed, err := s.invitationRepo.Renew(ctx, invitationID, utils.HashToken(token), now.Add(s.tokenTTL), now)
+	if err != nil {
+		return nil, fmt.Errorf("更新邀请失败: %w", err)
+	}
+	if renewed == nil {
+		return nil, errors.New("只能重新发送待接受的邀请")
+	}
+
+	if err := s.sendInvitation(ctx, renewed, company, token, operatorID); err != nil {
+		return nil, fmt.Errorf("发送邀请邮件失败: %w", err)
+	}
+
+	logger.Infof("重新发送邀请: InvitationID=%s, Email=%s, SendCount=%d, Operator=%s", renewed.InvitationID, renewed.Email, renewed.SendCount, operatorID)
+	return renewed, nil
+}
+
+// RevokeInvitation 撤销待接受的邀请，撤销后链接立即失效
+func (s *InvitationService) RevokeInvitation(ctx context.Context, invitationID, operatorID string) error {
+	invitation, err := s.GetInvitation(ctx, invitationID)
+	if err != nil {
+		return err
+	}
+	if invitation.Status != model.InvitationStatusPending && invitation.Status != model.InvitationStatusExpired {
+		return errors.New("只能撤销待接受的邀请")
+	}
+
+	revoked, err := s.invitationRepo.Revoke(ctx, invitationID, operatorID, time.Now())
+	if err != nil {
+		return fmt.Errorf("撤销邀请失败: %w", err)
+	}
+	if !revoked {
+		return errors.New("只能撤销待接受的邀请")
+	}
+
+	logger.Infof("撤销邀请: InvitationID=%s, Email=%s, Operator=%s", invitationID, invitation.Email, operatorID)
+	return nil
+}
+
+// PreviewInvitation 受邀人打开链接时查看邀请信息
+func (s *InvitationService) PreviewInvitation(ctx context.Context, token string) (*model.InvitationPreview, error) {
+	invitation, err := s.invitationRepo.GetValidByToken(ctx, utils.HashToken(strings.TrimSpace(token)), time.Now())
+	if err != nil {
+		logger.Errorf("查询邀请失败: %v", err)
+		return nil, errors.New("查询邀请失败")
+	}
+	if invitation == nil {
+		return nil, errors.New("邀请链接无效或已过期")
+	}
+
+	preview := &model.InvitationPreview{
+		Email:       invitation.Email,
+		DisplayName: invitation.DisplayName,
+		ExpiresAt:   invitation.ExpiresAt,
+	}
+	if company, err := s.companyRepo.GetCompanyByID(ctx, invitation.CompanyID); err == nil && company != nil {
+		preview.CompanyName = company.CompanyName
+	}
+	return preview, nil
+}
+
+// AcceptInvitation 接受邀请并创建用户。同一公司的接受操作串行执行：在锁内检查配额并原子地消耗邀请，
+// 并发提交同一链接时只有一个请求成功，并发接受不同邀请时也不会超出公司用户配额
+func (s *InvitationService) AcceptInvitation(ctx context.Context, req *model.AcceptInvitationRequest, clientIP string) (*model.UserInfo, error) {
+	tokenHash := utils.HashToken(strings.TrimSpace(req.Token))
+
+	// 先确认邀请有效，再校验用户名和密码，避免输入有误时邀请被消耗
+	invitation, err := s.invitationRepo.GetValidByToken(ctx, tokenHash, time.Now())
+	if err != nil {
+		logger.Errorf("查询邀请失败: %v", err)
+		return nil, errors.New("接受邀请失败")
+	}
+	if invitation == nil {
+		logger.Warnf("接受邀请失败 - 链接无效或已过期: IP=%s", clientIP)
+		return nil, errors.New("邀请链接无效或已过期")
+	}
+
+	exists, err := s.userRepo.ExistsByUsername(ctx, req.Username)
+	if err != nil {
+		return nil, fmt.Errorf("检查用户名失败: %w", err)
+	}
+	if exists {
+		return nil, errors.New("用户名已存在")
+	}
+	exists, err = s.userRepo.ExistsByEmail(ctx, invitation.Email)
+	if err != nil {
+		return nil, fmt.Errorf("检查邮箱失败: %w", err)
+	}
+	if exists {
+		return nil, errors.New("邮箱已存在")
+	}
+
+	now := time.Now()
+	user := &model.User{
+		UserID:      utils.GenerateID("user"),
+		Username:    req.Username,
+		DisplayName: req.DisplayName,
+		CompanyID:   invitation.CompanyID,
+		RoleIDs:     invitation.RoleIDs,
+		Status:      "active",
+		Email:       invitation.Email,
+		Phone:       req.Phone,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	// 密码由受邀人本人设置，无须首次登录后修改
+	if err := s.policyService.PreparePassword(ctx, user, req.Password, false); err != nil {
+		return nil, err
+	}
+
+	if err := s.lockCompany(ctx, invitation.CompanyID); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := s.invitationRepo.ReleaseCompanyLock(context.Background(), invitation.CompanyID); err != nil {
+			logger.Errorf("释放邀请锁失败: %v, CompanyID: %s", err, invitation.CompanyID)
+		}
+	}()
+
+	company, err := s.companyRepo.GetCompanyByID(ctx, invitation.CompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("查询公司失败: %w", err)
+	}
+	if company == nil || company.Status != "active" {
+		return nil, errors.New("公司已停用，不能加入")
+	}
+	if err := s.checkQuota(ctx, company, false); err != nil {
+		return nil, err
+	}
+
+	consumed, err := s.invitationRepo.Consume(ctx, tokenHash, user.UserID, time.Now())
+	if err != nil {
+		logger.Errorf("消耗邀请失败: %v", err)
+		return nil, errors.New("接受邀请失败")
+	}
+	if consumed == nil {
+		logger.Warnf("接受邀请失败 - 邀请已被使用或撤销: IP=%s", clientIP)
+		return nil, errors.New("邀请链接无效或已过期")
+	}
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		if restoreErr := s.invitationRepo.Restore(context.Background(), consumed.InvitationID, time.Now()); restoreErr != nil {
+			logger.Errorf("恢复邀请失败: %v, InvitationID: %s", restoreErr, consumed.InvitationID)
+		}
+		return nil, fmt.Errorf("创建用户失败: %w", err)
+	}
+
+	logger.Infof("接受邀请: InvitationID=%s, UserID=%s, CompanyID=%s, IP=%s", consumed.InvitationID, user.UserID, user.CompanyID, clientIP)
+
+	return &model.UserInfo{
+		ID:          user.ID.Hex(),
+		UserID:      user.UserID,
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		CompanyID:   user.CompanyID,
+		RoleIDs:     user.RoleIDs,
+		Status:      user.Status,
+		Email:       user.Email,
+		Phone:       user.Phone,
+	}, nil
+}
+
+// validateRoles 预分配的角色须存在、已启用，且为本公司角色或平台级角色；非平台管理员不能预分配平台管理员角色
+func (s *InvitationService) validateRoles(ctx context.Context, companyID string, roleIDs []string, isPlatformAdmin bool) error {
+	roles, err := s.roleRepo.GetRolesByIDs(ctx, roleIDs)
+	if err != nil {
+		return fmt.Errorf("查询角色失败: %w", err)
+	}
+
+	found := make(map[string]model.Role, len(roles))
+	for _, role := range roles {
+		found[role.RoleID] = role
+	}
+	for _, roleID := range roleIDs {
+		if !isPlatformAdmin && model.IsPlatformAdminRoles([]string{roleID}) {
+			return fmt.Errorf("角色无权分配: %s", roleID)
+		}
+		role, ok := found[roleID]
+		if !ok || role.Status != "enable" {
+			return fmt.Errorf("角色不存在或已停用: %s", roleID)
+		}
+		if role.CompanyID != "" && role.CompanyID != companyID {
+			return fmt.Errorf("角色不属于该公司: %s", roleID)
+		}
+	}
+	return nil
+}
+
+// checkQuota 检查公司用户配额，includePending 为true时未过期的待接受邀请也计入已用配额；配额未设置时不限制
+func (s *InvitationService) checkQuota(ctx context.Context, company *model.Company, includePending bool) error {
+	if company.UserQuota <= 0 {
+		return nil
+	}
+
+	_, used, err := s.userRepo.List(ctx, bson.M{"company_id": company.CompanyID}, 1, 1)
+	if err != nil {
+		return fmt.Errorf("统计公司用户失败: %w", err)
+	}
+	if includePending {
+		pending, err := s.invitationRepo.CountPending(ctx, company.CompanyID, time.Now())
+		if err != nil {
+			return fmt.Errorf("统计待接受邀请失败: %w", err)
+		}
+		used += pending
+	}
+
+	if used >= int64(company.UserQuota) {
+		return errors.New("公司用户数量已达配额上限")
+	}
+	return nil
+}
+
+// lockCompany 获取公司级的接受邀请锁，锁被占用时短暂等待后重试
+func (s *InvitationService) lockCompany(ctx context.Context, companyID string) error {
+	for i := 0; i < invitationLockRetries; i++ {
+		acquired, err := s.invitationRepo.AcquireCompanyLock(ctx, companyID, time.Now(), invitationLockTTL)
+		if err != nil {
+			logger.Errorf("获取邀请锁失败: %v, CompanyID: %s", err, companyID)
+			return errors.New("接受邀请失败")
+		}
+		if acquired {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(invitationLockRetryBackoff):
+		}
+	}
+	return errors.New("系统繁忙，请稍后再试")
+}
+
+// sendInvitation 发送邀请邮件；邮件包含一次性链接，标记为敏感内容
+func (s *InvitationService) sendInvitation(ctx context.Context, invitation *model.Invitation, company *model.Company, token, operatorID string) error {
+	displayName := invitation.DisplayName
+	if displayName == "" {
+		displayName = invitation.Email
+	}
+
+	_, err := s.mailService.Enqueue(ctx, &model.MailSendRequest{
+		To:       []string{invitation.Email},
+		Template: model.MailTemplateInvitation,
+		Data: map[string]interface{}{
+			"DisplayName":  displayName,
+			"CompanyName":  company.CompanyName,
+			"AcceptURL":    s.buildAcceptURL(token),
+			"ExpiresHours": int(s.tokenTTL.Hours()),
+		},
+		Sensitive: true,
+		CompanyID: invitation.CompanyID,
+		CreatedBy: operatorID,
+	})
+	return err
+}
+
+func (s *InvitationService) buildAcceptURL(token string) string {
+	acceptURL := s.config.AcceptURL
+	separator := "?"
+	if strings.Contains(acceptURL, "?") {
+		separator = "&"
+	}
+	return acceptURL + separator + "token=" + url.QueryEscape(token)
+}
+
+// markExpired 待接受但已超过有效期的邀请展示为已过期
+func markExpired(invitation *model.Invitation, now time.Time) {
+	if invitation.Status == model.InvitationStatusPending && !invitation.ExpiresAt.After(now) {
+		invitation.Status = model.InvitationStatusExpired
+	}
+}
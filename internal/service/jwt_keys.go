@@ -0,0 +1,31 @@
+package service
+
+import (
+	"time"
+
+	"YufungProject/configs"
+	"YufungProject/pkg/utils"
+)
+
+// NewJWTUtil 按JWT配置加载签名密钥，创建签发和校验令牌的工具实例，启动时创建一次并注入各处使用
+func NewJWTUtil(config configs.JWTConfig) (*utils.JWTUtil, error) {
+	specs := make([]utils.JWTKeySpec, 0, len(config.Keys))
+	for _, key := range config.Keys {
+		specs = append(specs, utils.JWTKeySpec{
+			ID:             key.KID,
+			Algorithm:      key.Algorithm,
+			Secret:         key.Secret,
+			PrivateKeyFile: key.PrivateKeyFile,
+			PublicKeyFile:  key.PublicKeyFile,
+		})
+	}
+
+	keySet, err := utils.NewJWTKeySet(config.Secret, specs)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresIn, _ := time.ParseDuration(config.ExpiresIn)
+	refreshExpiresIn, _ := time.ParseDuration(config.RefreshExpiresIn)
+	return utils.NewJWTUtilWithKeySet(keySet, expiresIn, refreshExpiresIn), nil
+}
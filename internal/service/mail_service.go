@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"YufungProject/configs"
+	"YufungProject/internal/model"
+	"YufungProject/internal/repository"
+	"YufungProject/pkg/logger"
+	"YufungProject/pkg/mailer"
+)
+
+// 邮件发送默认值（未配置时使用）
+const (
+	defaultMailWorkerInterval = 30 * time.Second
+	defaultMailBatchSize      = 50
+	defaultMailMaxAttempts    = 5
+	defaultMailRetryBaseDelay = time.Minute
+	defaultMailRetryMaxDelay  = time.Hour
+	defaultMailSendTimeout    = 30 * time.Second
+)
+
+// mailErrorMaxLength 保存的发送错误信息最大长度
+const mailErrorMaxLength = 500
+
+type MailService struct {
+	mailRepo       *repository.MailRepository
+	sender         mailer.Mailer
+	renderer       *mailer.Renderer
+	config         configs.MailConfig
+	workerInterval time.Duration
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+	sendTimeout    time.Duration
+	running        int32 // 发件箱是否正在处理，避免定时任务与手动处理重叠
+}
+
+// NewMailSender 根据配置创建邮件发送器：smtp 通过SMTP服务器发送，其他情况写入本地 .eml 文件
+func NewMailSender(config configs.MailConfig) (mailer.Mailer, error) {
+	if config.Driver != "smtp" {
+		return mailer.NewFileMailer(config.OutboxDir)
+	}
+
+	timeout, _ := time.ParseDuration(config.SMTP.Timeout)
+	return mailer.NewSMTPMailer(mailer.SMTPOptions{
+		Host:       config.SMTP.Host,
+		Port:       config.SMTP.Port,
+		Username:   config.SMTP.Username,
+		Password:   config.SMTP.Password,
+		Encryption: config.SMTP.Encryption,
+		Timeout:    timeout,
+	})
+}
+
+func NewMailService(mailRepo *repository.MailRepository, sender mailer.Mailer, renderer *mailer.Renderer, config configs.MailConfig) *MailService {
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultMailBatchSize
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultMailMaxAttempts
+	}
+	if config.DefaultLanguage == "" {
+		config.DefaultLanguage = mailer.LanguageZh
+	}
+
+	s := &MailService{
+		mailRepo:       mailRepo,
+		sender:         sender,
+		renderer:       renderer,
+		config:         config,
+		workerInterval: parseDurationOr(config.WorkerInterval, defaultMailWorkerInterval),
+		retryBaseDelay: parseDurationOr(config.RetryBaseDelay, defaultMailRetryBaseDelay),
+		retryMaxDelay:  parseDurationOr(config.RetryMaxDelay, defaultMailRetryMaxDelay),
+		sendTimeout:    parseDurationOr(config.SMTP.Timeout, defaultMailSendTimeout),
+	}
+	if s.retryMaxDelay < s.retryBaseDelay {
+		s.retryMaxDelay = s.retryBaseDelay
+	}
+	return s
+}
+
+// Enqueue 渲染模板并放入发件箱，由发件箱任务异步发送
+func (s *MailService) Enqueue(ctx context.Context, req *model.MailSendRequest) (*model.MailMessage, error) {
+	if len(req.To) == 0 {
+		return nil, errors.New("收件人不能为空")
+	}
+	for _, to := range req.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return nil, fmt.Errorf("收件人地址无效: %s", to)
+		}
+	}
+
+	language := req.Language
+	if language == "" {
+		language = s.config.DefaultLanguage
+	}
+	rendered, err := s.renderer.Render(req.Template, language, req.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &model.MailMessage{
+		CompanyID:     req.CompanyID,
+		Template:      req.Template,
+		Language:      rendered.Language,
+		To:            req.To,
+		Subject:       rendered.Subject,
+		TextBody:      rendered.Text,
+		HTMLBody:      rendered.HTML,
+		Sensitive:     req.Sensitive,
+		Status:        model.MailStatusPending,
+		MaxAttempts:   s.config.MaxAttempts,
+		NextAttemptAt: time.Now(),
+		CreatedBy:     req.CreatedBy,
+	}
+	if err := s.mailRepo.CreateMessage(ctx, message); err != nil {
+		logger.Errorf("邮件入队失败: Template=%s, Error=%v", req.Template, err)
+		return nil, fmt.Errorf("邮件入队失败: %w", err)
+	}
+
+	logger.Infof("邮件已入队: MessageID=%s, Template=%s, To=%d", message.MessageID, message.Template, len(message.To))
+	return message, nil
+}
+
+// StartWorker 启动发件箱定时发送任务
+func (s *MailService) StartWorker(ctx context.Context) {
+	if !s.config.WorkerEnabled {
+		logger.Info("发件箱定时发送任务未启用")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.workerInterval)
+		defer ticker.Stop()
+
+		for {
+			if result, err := s.ProcessOutbox(ctx, time.Now()); err != nil {
+				logger.Errorf("处理发件箱失败: %v", err)
+			} else if result != nil && result.Processed > 0 {
+				logger.Infof("发件箱处理完成: 处理=%d, 成功=%d, 重试=%d, 失败=%d", result.Processed, result.Sent, result.Retrying, result.Failed)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	logger.Infof("发件箱定时发送任务已启动，处理间隔: %v", s.workerInterval)
+}
+
+// ProcessOutbox 发送到期的邮件，每次最多处理 batch_size 封；失败的邮件按指数退避重试，
+// 达到最大次数或遇到不可重试错误时标记为失败。上一次处理尚未结束时跳过并返回nil
+func (s *MailService) ProcessOutbox(ctx context.Context, now time.Time) (*model.MailProcessResult, error) {
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		return nil, nil
+	}
+	defer atomic.StoreInt32(&s.running, 0)
+
+	// 租约需覆盖一次发送的超时时间，超时未完成的邮件由下次处理重新领取
+	lease := 2 * s.sendTimeout
+	result := &model.MailProcessResult{}
+	for result.Processed < s.config.BatchSize {
+		message, err := s.mailRepo.ClaimDueMessage(ctx, now, lease)
+		if err != nil {
+			return result, err
+		}
+		if message == nil {
+			break
+		}
+		result.Processed++
+
+		switch s.deliver(ctx, message, now) {
+		case model.MailStatusSent:
+			result.Sent++
+		case model.MailStatusPending:
+			result.Retrying++
+		default:
+			result.Failed++
+		}
+	}
+
+	return result, nil
+}
+
+// deliver 发送一封已领取的邮件并记录结果，返回邮件的新状态
+func (s *MailService) deliver(ctx context.Context, message *model.MailMessage, now time.Time) string {
+	sendCtx, cancel := context.WithTimeout(ctx, s.sendTimeout)
+	defer cancel()
+
+	err := s.sender.Send(sendCtx, &mailer.Message{
+		ID:       message.MessageID,
+		From:     s.config.From,
+		FromName: s.config.FromName,
+		To:       message.To,
+		Subject:  message.Subject,
+		TextBody: message.TextBody,
+		HTMLBody: message.HTMLBody,
+	})
+	if err == nil {
+		if err := s.mailRepo.MarkSent(ctx, message.MessageID, time.Now(), message.Sensitive); err != nil {
+			logger.Errorf("更新邮件状态失败: MessageID=%s, Error=%v", message.MessageID, err)
+		}
+		return model.MailStatusSent
+	}
+
+	lastError := err.Error()
+	if len(lastError) > mailErrorMaxLength {
+		lastError = lastError[:mailErrorMaxLength]
+	}
+
+	if mailer.IsPermanent(err) || message.Attempts >= message.MaxAttempts {
+		logger.Errorf("邮件发送失败: MessageID=%s, Attempts=%d, Error=%v", message.MessageID, message.Attempts, err)
+		if err := s.mailRepo.MarkFailed(ctx, message.MessageID, lastError); err != nil {
+			logger.Errorf("更新邮件状态失败: MessageID=%s, Error=%v", message.MessageID, err)
+		}
+		return model.MailStatusFailed
+	}
+
+	nextAttemptAt := now.Add(s.retryDelay(message.Attempts))
+	logger.Warnf("邮件发送失败，等待重试: MessageID=%s, Attempts=%d, NextAttemptAt=%v, Error=%v", message.MessageID, message.Attempts, nextAttemptAt, err)
+	if err := s.mailRepo.MarkRetry(ctx, message.MessageID, nextAttemptAt, lastError); err != nil {
+		logger.Errorf("更新邮件状态失败: MessageID=%s, Error=%v", message.MessageID, err)
+	}
+	return model.MailStatusPending
+}
+
+// retryDelay 第n次发送失败后的重试间隔：首次为基础间隔，之后每次翻倍，不超过上限
+func (s *MailService) retryDelay(attempts int) time.Duration {
+	delay := s.retryBaseDelay
+	for i := 1; i < attempts && delay < s.retryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.retryMaxDelay {
+		delay = s.retryMaxDelay
+	}
+	return delay
+}
+
+// ==========================
+// 发件箱管理
+// ==========================
+
+// ListMessages 分页查询发件箱，附带各状态数量
+func (s *MailService) ListMessages(ctx context.Context, req *model.MailQueryRequest) (*model.MailListResponse, error) {
+	messages, total, err := s.mailRepo.ListMessages(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询发件箱失败: %w", err)
+	}
+	counts, err := s.mailRepo.CountByStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("统计发件箱失败: %w", err)
+	}
+
+	return &model.MailListResponse{
+		List:         messages,
+		Total:        total,
+		Page:         req.Page,
+		PageSize:     req.PageSize,
+		StatusCounts: counts,
+	}, nil
+}
+
+// GetMessage 获取邮件详情，敏感邮件不返回正文
+func (s *MailService) GetMessage(ctx context.Context, messageID string) (*model.MailMessage, error) {
+	message, err := s.mailRepo.GetMessageByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("查询邮件失败: %w", err)
+	}
+	if message == nil {
+		return nil, errors.New("邮件不存在")
+	}
+	if message.Sensitive {
+		message.TextBody = ""
+		message.HTMLBody = ""
+	}
+	return message, nil
+}
+
+// RetryMessage 将发送失败的邮件重新放入发件箱
+func (s *MailService) RetryMessage(ctx context.Context, messageID, userID string) error {
+	message, err := s.mailRepo.GetMessageByID(ctx, messageID)
+	if err != nil {
+		return fmt.Errorf("查询邮件失败: %w", err)
+	}
+	if message == nil {
+		return errors.New("邮件不存在")
+	}
+
+	requeued, err := s.mailRepo.RequeueFailedMessage(ctx, messageID, time.Now())
+	if err != nil {
+		return fmt.Errorf("重新发送邮件失败: %w", err)
+	}
+	if !requeued {
+		return errors.New("只能重新发送失败的邮件")
+	}
+
+	logger.BusinessLog("邮件管理", "重新发送邮件", userID, fmt.Sprintf("MessageID=%s", messageID))
+	return nil
+}
+
+// SendTestMail 发送测试邮件（入队后由发件箱任务发送）
+func (s *MailService) SendTestMail(ctx context.Context, req *model.MailTestRequest, userID string) (*model.MailMessage, error) {
+	return s.Enqueue(ctx, &model.MailSendRequest{
+		To:        []string{req.To},
+		Template:  model.MailTemplateTest,
+		Language:  req.Language,
+		Data:      map[string]interface{}{"SentAt": time.Now().Format("2006-01-02 15:04:05")},
+		CreatedBy: userID,
+	})
+}
+
+// parseDurationOr 解析时间间隔配置，为空或无效时使用默认值
+func parseDurationOr(value string, fallback time.Duration) time.Duration {
+	duration, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || duration <= 0 {
+		return fallback
+	}
+	return duration
+}
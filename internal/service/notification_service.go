@@ -0,0 +1,425 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"YufungProject/configs"
+	"YufungProject/internal/model"
+	"YufungProject/internal/repository"
+	"YufungProject/pkg/logger"
+)
+
+// 提醒规则默认值（未配置时使用）
+const (
+	defaultSchedulerInterval = time.Hour
+	defaultPremiumDueDays    = 7
+	defaultAnniversaryDays   = 7
+	defaultCoolingOffDays    = 21
+	defaultCompanyExpiryDays = 30
+)
+
+// paymentMethodRegular 期缴，按缴费日期周年提醒续期保费
+const paymentMethodRegular = "期缴"
+
+// lockoutAdminPermission 接收其他用户账户锁定通知所需的权限
+const lockoutAdminPermission = "user:edit"
+
+type NotificationService struct {
+	notificationRepo *repository.NotificationRepository
+	userRepo         repository.UserRepository
+	companyRepo      repository.CompanyRepository
+	rbacRepo         repository.RBACRepository
+	config           configs.NotificationConfig
+	running          int32 // 提醒规则是否正在执行，避免定时任务与手动执行重叠
+}
+
+func NewNotificationService(notificationRepo *repository.NotificationRepository, userRepo repository.UserRepository, companyRepo repository.CompanyRepository, rbacRepo repository.RBACRepository, config configs.NotificationConfig) *NotificationService {
+	if config.PremiumDueDays <= 0 {
+		config.PremiumDueDays = defaultPremiumDueDays
+	}
+	if config.AnniversaryDays <= 0 {
+		config.AnniversaryDays = defaultAnniversaryDays
+	}
+	if config.CoolingOffDays <= 0 {
+		config.CoolingOffDays = defaultCoolingOffDays
+	}
+	if config.CompanyExpiryDays <= 0 {
+		config.CompanyExpiryDays = defaultCompanyExpiryDays
+	}
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		companyRepo:      companyRepo,
+		rbacRepo:         rbacRepo,
+		config:           config,
+	}
+}
+
+// ListNotifications 获取当前用户的通知列表
+func (s *NotificationService) ListNotifications(ctx context.Context, req *model.NotificationQueryRequest, userID string) (*model.NotificationListResponse, error) {
+	return s.notificationRepo.ListNotifications(ctx, req, userID)
+}
+
+// CountUnread 获取当前用户的未读通知数量
+func (s *NotificationService) CountUnread(ctx context.Context, userID string) (int64, error) {
+	return s.notificationRepo.CountUnreadNotifications(ctx, userID)
+}
+
+// MarkRead 将当前用户的通知标记为已读，不传通知ID时标记全部
+func (s *NotificationService) MarkRead(ctx context.Context, req *model.NotificationMarkReadRequest, userID string) (int64, error) {
+	return s.notificationRepo.MarkNotificationsRead(ctx, userID, req.NotificationIDs)
+}
+
+// GetPreferences 获取当前用户的通知偏好，未设置的类型默认接收
+func (s *NotificationService) GetPreferences(ctx context.Context, userID string) ([]model.NotificationPreferenceItem, error) {
+	preference, err := s.notificationRepo.GetPreference(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]model.NotificationPreferenceItem, 0, len(model.NotificationTypes))
+	for _, notificationType := range model.NotificationTypes {
+		items = append(items, model.NotificationPreferenceItem{
+			Type:    notificationType,
+			Label:   model.NotificationTypeLabels[notificationType],
+			Enabled: preferenceEnabled(preference, notificationType),
+		})
+	}
+	return items, nil
+}
+
+// UpdatePreferences 更新当前用户的通知偏好
+func (s *NotificationService) UpdatePreferences(ctx context.Context, req *model.NotificationPreferenceUpdateRequest, userID, companyID string) ([]model.NotificationPreferenceItem, error) {
+	for notificationType := range req.Enabled {
+		if _, ok := model.NotificationTypeLabels[notificationType]; !ok {
+			return nil, fmt.Errorf("未知的通知类型：%s", notificationType)
+		}
+	}
+
+	if err := s.notificationRepo.UpsertPreference(ctx, userID, companyID, req.Enabled); err != nil {
+		return nil, err
+	}
+	return s.GetPreferences(ctx, userID)
+}
+
+// StartScheduler 启动提醒规则定时任务：启动后立即执行一次，之后按配置的间隔执行，ctx 取消时停止
+func (s *NotificationService) StartScheduler(ctx context.Context) {
+	if !s.config.SchedulerEnabled {
+		logger.Info("通知提醒定时任务未启用")
+		return
+	}
+
+	interval, err := time.ParseDuration(s.config.SchedulerInterval)
+	if err != nil || interval <= 0 {
+		interval = defaultSchedulerInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if result, err := s.RunReminderRules(ctx, time.Now()); err != nil {
+				logger.Errorf("执行通知提醒规则失败: %v", err)
+			} else if result != nil && result.Created > 0 {
+				logger.Infof("通知提醒规则执行完成: 公司=%d, 新通知=%d", result.Companies, result.Created)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	logger.Infof("通知提醒定时任务已启动，执行间隔: %v", interval)
+}
+
+// RunReminderRules 为全部有效公司执行提醒规则，生成的通知按去重键去重，重复执行不会重复提醒；
+// 上一次执行尚未结束时跳过并返回nil
+func (s *NotificationService) RunReminderRules(ctx context.Context, now time.Time) (*model.NotificationRunResult, error) {
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		logger.Warnf("通知提醒规则正在执行，跳过本次执行")
+		return nil, nil
+	}
+	defer atomic.StoreInt32(&s.running, 0)
+
+	companies, _, err := s.companyRepo.GetCompanyList(ctx, 1, 0, "active")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.NotificationRunResult{CreatedByType: map[string]int{}}
+	for _, company := range companies {
+		if err := s.runCompanyRules(ctx, company, now, result); err != nil {
+			// 单个公司失败不影响其他公司
+			logger.Errorf("执行公司%s的通知提醒规则失败: %v", company.CompanyID, err)
+			continue
+		}
+		result.Companies++
+	}
+
+	return result, nil
+}
+
+// reminder 提醒规则生成的待发送提醒
+type reminder struct {
+	notificationType string
+	title            string
+	content          string
+	relatedType      string
+	relatedID        string
+	dedupKey         string
+	recipients       []string // 接收用户ID，为空时发送给公司全部有效用户
+}
+
+// runCompanyRules 执行单个公司的提醒规则并发送通知
+func (s *NotificationService) runCompanyRules(ctx context.Context, company *model.Company, now time.Time, result *model.NotificationRunResult) error {
+	users, _, err := s.userRepo.List(ctx, bson.M{"company_id": company.CompanyID, "status": "active"}, 1, 0)
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		return nil
+	}
+
+	preferences, err := s.notificationRepo.ListPreferences(ctx, company.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	policies, err := s.notificationRepo.ListReminderPolicies(ctx, company.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	today := dateOnly(now)
+	var reminders []reminder
+	for i := range policies {
+		reminders = append(reminders, s.policyReminders(&policies[i], today)...)
+	}
+	if r := s.companyExpiryReminder(company, today); r != nil {
+		reminders = append(reminders, *r)
+	}
+	lockReminders, err := s.accountLockReminders(ctx, company.CompanyID, users, now)
+	if err != nil {
+		return err
+	}
+	reminders = append(reminders, lockReminders...)
+
+	allUserIDs := make([]string, 0, len(users))
+	for _, user := range users {
+		allUserIDs = append(allUserIDs, user.UserID)
+	}
+
+	var notifications []*model.Notification
+	for _, r := range reminders {
+		recipients := r.recipients
+		if len(recipients) == 0 {
+			recipients = allUserIDs
+		}
+		for _, userID := range recipients {
+			if !preferenceEnabled(preferences[userID], r.notificationType) {
+				continue
+			}
+			notifications = append(notifications, &model.Notification{
+				UserID:      userID,
+				CompanyID:   company.CompanyID,
+				Type:        r.notificationType,
+				Title:       r.title,
+				Content:     r.content,
+				RelatedType: r.relatedType,
+				RelatedID:   r.relatedID,
+				DedupKey:    r.dedupKey,
+			})
+		}
+	}
+
+	// 同一公司的提醒一次批量写入，避免按接收人逐条访问数据库
+	created, err := s.notificationRepo.CreateNotificationsIfAbsent(ctx, notifications)
+	if err != nil {
+		return err
+	}
+	for _, index := range created {
+		result.Created++
+		result.CreatedByType[notifications[index].Type]++
+	}
+
+	return nil
+}
+
+// policyReminders 根据保单日期生成提醒：期缴保单的缴费日期周年（续期保费）、冷静期结束前一天、生效日期周年
+func (s *NotificationService) policyReminders(policy *model.Policy, today time.Time) []reminder {
+	var reminders []reminder
+	subject := policySubject(policy)
+
+	if policy.PaymentMethod == paymentMethodRegular && policy.PaymentDate != nil {
+		due, period := nextAnniversary(*policy.PaymentDate, today)
+		// 首期保费在缴费日期已缴纳，续期保费为第2期至第N期（N为缴费年期，未填写时不限）
+		if daysBetween(today, due) <= s.config.PremiumDueDays && (policy.PaymentYears <= 0 || period < policy.PaymentYears) {
+			reminders = append(reminders, reminder{
+				notificationType: model.NotificationTypePremiumDue,
+				title:            "续期保费即将到期",
+				content:          fmt.Sprintf("%s第%d期保费将于%s到期", subject, period+1, due.Format("2006-01-02")),
+				relatedType:      "policy",
+				relatedID:        policy.PolicyID,
+				dedupKey:         fmt.Sprintf("%s:%s:%s", model.NotificationTypePremiumDue, policy.PolicyID, due.Format("2006-01-02")),
+			})
+		}
+	}
+
+	if policy.EffectiveDate != nil {
+		effective := dateOnly(*policy.EffectiveDate)
+
+		if !policy.PastCoolingPeriod {
+			end := effective.AddDate(0, 0, s.config.CoolingOffDays)
+			if days := daysBetween(today, end); days >= 0 && days <= 1 {
+				reminders = append(reminders, reminder{
+					notificationType: model.NotificationTypeCoolingOffEnd,
+					title:            "冷静期即将结束",
+					content:          fmt.Sprintf("%s的冷静期将于%s结束", subject, end.Format("2006-01-02")),
+					relatedType:      "policy",
+					relatedID:        policy.PolicyID,
+					dedupKey:         fmt.Sprintf("%s:%s:%s", model.NotificationTypeCoolingOffEnd, policy.PolicyID, end.Format("2006-01-02")),
+				})
+			}
+		}
+
+		anniversary, years := nextAnniversary(effective, today)
+		if daysBetween(today, anniversary) <= s.config.AnniversaryDays {
+			reminders = append(reminders, reminder{
+				notificationType: model.NotificationTypePolicyAnniversary,
+				title:            "保单周年日临近",
+				content:          fmt.Sprintf("%s将于%s迎来第%d个保单周年日", subject, anniversary.Format("2006-01-02"), years),
+				relatedType:      "policy",
+				relatedID:        policy.PolicyID,
+				dedupKey:         fmt.Sprintf("%s:%s:%s", model.NotificationTypePolicyAnniversary, policy.PolicyID, anniversary.Format("2006-01-02")),
+			})
+		}
+	}
+
+	return reminders
+}
+
+// companyExpiryReminder 公司有效期结束前提醒公司全部用户
+func (s *NotificationService) companyExpiryReminder(company *model.Company, today time.Time) *reminder {
+	if company.ValidEndDate.IsZero() {
+		return nil
+	}
+	end := dateOnly(company.ValidEndDate)
+	days := daysBetween(today, end)
+	if days < 0 || days > s.config.CompanyExpiryDays {
+		return nil
+	}
+
+	return &reminder{
+		notificationType: model.NotificationTypeCompanyExpiry,
+		title:            "公司有效期即将结束",
+		content:          fmt.Sprintf("%s的有效期将于%s结束（剩余%d天），请及时联系平台续期", company.CompanyName, end.Format("2006-01-02"), days),
+		relatedType:      "company",
+		relatedID:        company.CompanyID,
+		dedupKey:         fmt.Sprintf("%s:%s:%s", model.NotificationTypeCompanyExpiry, company.CompanyID, end.Format("2006-01-02")),
+	}
+}
+
+// accountLockReminders 为当前处于锁定状态的账户生成提醒，发送给被锁定用户及公司中有用户管理权限的用户
+func (s *NotificationService) accountLockReminders(ctx context.Context, companyID string, users []*model.User, now time.Time) ([]reminder, error) {
+	var locked []*model.User
+	for _, user := range users {
+		if user.LockedUntil != nil && user.LockedUntil.After(now) {
+			locked = append(locked, user)
+		}
+	}
+	if len(locked) == 0 {
+		return nil, nil
+	}
+
+	var admins []string
+	for _, user := range users {
+		allowed, err := s.rbacRepo.CheckUserPermission(ctx, user.UserID, lockoutAdminPermission)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			admins = append(admins, user.UserID)
+		}
+	}
+
+	reminders := make([]reminder, 0, len(locked))
+	for _, user := range locked {
+		recipients := []string{user.UserID}
+		for _, adminID := range admins {
+			if adminID != user.UserID {
+				recipients = append(recipients, adminID)
+			}
+		}
+		reminders = append(reminders, reminder{
+			notificationType: model.NotificationTypeAccountLocked,
+			title:            "账户已被锁定",
+			content:          fmt.Sprintf("用户%s因多次登录失败已被锁定至%s", user.Username, user.LockedUntil.In(time.Local).Format("2006-01-02 15:04")),
+			relatedType:      "user",
+			relatedID:        user.UserID,
+			dedupKey:         fmt.Sprintf("%s:%s:%d", model.NotificationTypeAccountLocked, user.UserID, user.LockedUntil.Unix()),
+			recipients:       recipients,
+		})
+	}
+	return reminders, nil
+}
+
+// preferenceEnabled 用户是否接收该类型的通知，未设置时默认接收
+func preferenceEnabled(preference *model.NotificationPreference, notificationType string) bool {
+	if preference == nil {
+		return true
+	}
+	enabled, ok := preference.Enabled[notificationType]
+	return !ok || enabled
+}
+
+// policySubject 通知内容中保单的描述，如「保单（投保单号 P001，客户 张三）」
+func policySubject(policy *model.Policy) string {
+	var parts []string
+	if policy.ProposalNumber != "" {
+		parts = append(parts, "投保单号 "+policy.ProposalNumber)
+	}
+	if name := policy.CustomerNameCN; name != "" {
+		parts = append(parts, "客户 "+name)
+	} else if policy.CustomerNameEN != "" {
+		parts = append(parts, "客户 "+policy.CustomerNameEN)
+	}
+	if len(parts) == 0 {
+		return "保单"
+	}
+	return fmt.Sprintf("保单（%s）", strings.Join(parts, "，"))
+}
+
+// nextAnniversary 返回基准日期在今天或之后的第一个周年日（至少为第1个）及其周年数
+func nextAnniversary(base, today time.Time) (time.Time, int) {
+	base = dateOnly(base)
+	years := today.Year() - base.Year()
+	if years < 1 {
+		years = 1
+	}
+	anniversary := base.AddDate(years, 0, 0)
+	for anniversary.Before(today) {
+		years++
+		anniversary = base.AddDate(years, 0, 0)
+	}
+	return anniversary, years
+}
+
+// dateOnly 取本地时区的日期部分
+func dateOnly(t time.Time) time.Time {
+	local := t.In(time.Local)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// daysBetween 两个日期相差的天数（to - from）
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours()/24 + 0.5)
+}
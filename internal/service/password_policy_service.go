@@ -0,0 +1,445 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"YufungProject/configs"
+	"YufungProject/internal/model"
+	"YufungProject/internal/repository"
+	"YufungProject/pkg/logger"
+	"YufungProject/pkg/utils"
+)
+
+// passwordPolicyCacheTTL 生效策略的本地缓存时长
+const passwordPolicyCacheTTL = 30 * time.Second
+
+// 平台默认最小长度（未配置 password_min_length 时使用）
+const defaultPasswordMinLength = 8
+
+// PasswordPolicyError 密码不符合安全策略，Violations 为不满足的各项要求
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "密码不符合安全策略：" + strings.Join(e.Violations, "；")
+}
+
+// IsPasswordPolicyError 判断错误是否为密码不符合安全策略
+func IsPasswordPolicyError(err error) bool {
+	var policyErr *PasswordPolicyError
+	return errors.As(err, &policyErr)
+}
+
+type passwordPolicyCacheEntry struct {
+	policy   *model.PasswordPolicy
+	loadedAt time.Time
+}
+
+// PasswordPolicyService 密码策略：平台策略加公司策略，负责密码校验、历史密码和过期判断
+type PasswordPolicyService struct {
+	policyRepo  *repository.PasswordPolicyRepository
+	userRepo    repository.UserRepository
+	companyRepo repository.CompanyRepository
+	security    configs.SecurityConfig
+	mu          sync.RWMutex
+	cache       map[string]passwordPolicyCacheEntry
+}
+
+func NewPasswordPolicyService(policyRepo *repository.PasswordPolicyRepository, userRepo repository.UserRepository, companyRepo repository.CompanyRepository, security configs.SecurityConfig) *PasswordPolicyService {
+	return &PasswordPolicyService{
+		policyRepo:  policyRepo,
+		userRepo:    userRepo,
+		companyRepo: companyRepo,
+		security:    security,
+		cache:       make(map[string]passwordPolicyCacheEntry),
+	}
+}
+
+// ==========================
+// 策略管理
+// ==========================
+
+// GetPlatformPolicy 获取平台策略，未在系统中保存时使用配置文件中的默认策略
+func (s *PasswordPolicyService) GetPlatformPolicy(ctx context.Context) (*model.PasswordPolicy, error) {
+	policy, err := s.policyRepo.GetPolicy(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("查询平台密码策略失败: %w", err)
+	}
+	if policy != nil {
+		return policy, nil
+	}
+
+	defaults := s.security.PasswordPolicy
+	minLength := s.security.PasswordMinLength
+	if minLength <= 0 {
+		minLength = defaultPasswordMinLength
+	}
+	return &model.PasswordPolicy{
+		MinLength:           minLength,
+		RequireDigit:        defaults.RequireDigit,
+		RequireLowercase:    defaults.RequireLowercase,
+		RequireUppercase:    defaults.RequireUppercase,
+		RequireSpecial:      defaults.RequireSpecial,
+		MinCharClasses:      defaults.MinCharClasses,
+		DisallowUsername:    defaults.DisallowUsername,
+		DisallowCompanyName: defaults.DisallowCompanyName,
+		HistoryCount:        defaults.HistoryCount,
+		MaxAgeDays:          defaults.MaxAgeDays,
+	}, nil
+}
+
+// GetCompanyPolicyDetail 获取公司的平台策略、公司策略及实际生效的策略
+func (s *PasswordPolicyService) GetCompanyPolicyDetail(ctx context.Context, companyID string) (*model.PasswordPolicyDetail, error) {
+	company, err := s.companyRepo.GetCompanyByID(ctx, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("查询公司失败: %w", err)
+	}
+	if company == nil {
+		return nil, errors.New("公司不存在")
+	}
+
+	platform, err := s.GetPlatformPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	companyPolicy, err := s.policyRepo.GetPolicy(ctx, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("查询公司密码策略失败: %w", err)
+	}
+
+	return &model.PasswordPolicyDetail{
+		Platform:  platform,
+		Company:   companyPolicy,
+		Effective: mergePasswordPolicy(platform, companyPolicy),
+	}, nil
+}
+
+// UpdatePlatformPolicy 保存平台策略
+func (s *PasswordPolicyService) UpdatePlatformPolicy(ctx context.Context, req *model.PasswordPolicyRequest, userID string) (*model.PasswordPolicy, error) {
+	policy := passwordPolicyFromRequest(req)
+	policy.UpdatedBy = userID
+	if err := s.policyRepo.SavePolicy(ctx, policy); err != nil {
+		return nil, fmt.Errorf("保存平台密码策略失败: %w", err)
+	}
+	s.clearCache()
+
+	logger.BusinessLog("密码策略", "修改平台密码策略", userID, fmt.Sprintf("MinLength=%d, HistoryCount=%d, MaxAgeDays=%d", policy.MinLength, policy.HistoryCount, policy.MaxAgeDays))
+	return policy, nil
+}
+
+// UpdateCompanyPolicy 保存公司策略，低于平台策略的设置不生效
+func (s *PasswordPolicyService) UpdateCompanyPolicy(ctx context.Context, companyID string, req *model.PasswordPolicyRequest, userID string) (*model.PasswordPolicyDetail, error) {
+	company, err := s.companyRepo.GetCompanyByID(ctx, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("查询公司失败: %w", err)
+	}
+	if company == nil {
+		return nil, errors.New("公司不存在")
+	}
+
+	policy := passwordPolicyFromRequest(req)
+	policy.CompanyID = companyID
+	policy.UpdatedBy = userID
+	if err := s.policyRepo.SavePolicy(ctx, policy); err != nil {
+		return nil, fmt.Errorf("保存公司密码策略失败: %w", err)
+	}
+	s.clearCache()
+
+	logger.BusinessLog("密码策略", "修改公司密码策略", userID, fmt.Sprintf("CompanyID=%s, MinLength=%d, HistoryCount=%d, MaxAgeDays=%d", companyID, policy.MinLength, policy.HistoryCount, policy.MaxAgeDays))
+	return s.GetCompanyPolicyDetail(ctx, companyID)
+}
+
+// DeleteCompanyPolicy 删除公司策略，恢复使用平台策略
+func (s *PasswordPolicyService) DeleteCompanyPolicy(ctx context.Context, companyID, userID string) error {
+	deleted, err := s.policyRepo.DeletePolicy(ctx, companyID)
+	if err != nil {
+		return fmt.Errorf("删除公司密码策略失败: %w", err)
+	}
+	if !deleted {
+		return errors.New("公司未设置密码策略")
+	}
+	s.clearCache()
+
+	logger.BusinessLog("密码策略", "删除公司密码策略", userID, fmt.Sprintf("CompanyID=%s", companyID))
+	return nil
+}
+
+// EffectivePolicy 获取公司实际生效的密码策略（平台策略与公司策略中更严格的设置），带短期缓存
+func (s *PasswordPolicyService) EffectivePolicy(ctx context.Context, companyID string) (*model.PasswordPolicy, error) {
+	s.mu.RLock()
+	entry, ok := s.cache[companyID]
+	s.mu.RUnlock()
+	if ok && time.Since(entry.loadedAt) < passwordPolicyCacheTTL {
+		return entry.policy, nil
+	}
+
+	platform, err := s.GetPlatformPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var companyPolicy *model.PasswordPolicy
+	if companyID != "" {
+		companyPolicy, err = s.policyRepo.GetPolicy(ctx, companyID)
+		if err != nil {
+			return nil, fmt.Errorf("查询公司密码策略失败: %w", err)
+		}
+	}
+	policy := mergePasswordPolicy(platform, companyPolicy)
+
+	s.mu.Lock()
+	s.cache[companyID] = passwordPolicyCacheEntry{policy: policy, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return policy, nil
+}
+
+func (s *PasswordPolicyService) clearCache() {
+	s.mu.Lock()
+	s.cache = make(map[string]passwordPolicyCacheEntry)
+	s.mu.Unlock()
+}
+
+// ==========================
+// 密码校验与设置
+// ==========================
+
+// PreparePassword 按用户所属公司的策略校验新密码，通过后设置用户的密码哈希、历史密码、修改时间和是否须修改密码（不保存）
+func (s *PasswordPolicyService) PreparePassword(ctx context.Context, user *model.User, password string, mustChange bool) error {
+	policy, err := s.EffectivePolicy(ctx, user.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	if violations := s.checkRules(ctx, policy, user, password); len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+
+	// 当前密码加上保存的历史密码即最近使用过的密码
+	var recent []string
+	if user.PasswordHash != "" {
+		recent = append(recent, user.PasswordHash)
+	}
+	recent = append(recent, user.PasswordHistory...)
+	if len(recent) > policy.HistoryCount {
+		recent = recent[:policy.HistoryCount]
+	}
+	for _, hash := range recent {
+		if utils.CheckPassword(hash, password) {
+			return &PasswordPolicyError{Violations: []string{fmt.Sprintf("不能与最近%d次使用过的密码相同", policy.HistoryCount)}}
+		}
+	}
+
+	passwordHash, err := utils.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("生成密码哈希失败: %w", err)
+	}
+
+	// 只保留策略要求的历史数量（不含新的当前密码）
+	history := recent
+	if policy.HistoryCount > 0 && len(history) > policy.HistoryCount-1 {
+		history = history[:policy.HistoryCount-1]
+	}
+	now := time.Now()
+	user.PasswordHash = passwordHash
+	user.PasswordHistory = history
+	user.PasswordChangedAt = &now
+	user.MustChangePassword = mustChange
+	return nil
+}
+
+// SetPassword 校验并保存用户的新密码
+func (s *PasswordPolicyService) SetPassword(ctx context.Context, user *model.User, password string, mustChange bool) error {
+	if err := s.PreparePassword(ctx, user, password, mustChange); err != nil {
+		return err
+	}
+	return s.SavePassword(ctx, user)
+}
+
+// SavePassword 保存已通过 PreparePassword 设置的密码字段
+func (s *PasswordPolicyService) SavePassword(ctx context.Context, user *model.User) error {
+	history := user.PasswordHistory
+	if history == nil {
+		history = []string{}
+	}
+	return s.userRepo.Update(ctx, user.UserID, bson.M{
+		"password_hash":        user.PasswordHash,
+		"password_history":     history,
+		"password_changed_at":  user.PasswordChangedAt,
+		"must_change_password": user.MustChangePassword,
+	})
+}
+
+// GenerateTemporaryPassword 生成符合公司策略的随机临时密码（用于批量导入等无法由用户设置密码的场景）
+func (s *PasswordPolicyService) GenerateTemporaryPassword(ctx context.Context, companyID string) (string, error) {
+	policy, err := s.EffectivePolicy(ctx, companyID)
+	if err != nil {
+		return "", err
+	}
+	size := policy.MinLength
+	if size < 16 {
+		size = 16
+	}
+	token, err := utils.GenerateSecureToken(size)
+	if err != nil {
+		return "", err
+	}
+	// 随机部分为URL安全的base64字符，补充各类字符以满足字符种类要求
+	return token + "Aa1!", nil
+}
+
+// PasswordExpiresAt 计算密码过期时间，策略不限制使用天数时返回nil
+func (s *PasswordPolicyService) PasswordExpiresAt(ctx context.Context, user *model.User) (*time.Time, error) {
+	policy, err := s.EffectivePolicy(ctx, user.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	if policy.MaxAgeDays <= 0 {
+		return nil, nil
+	}
+
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+	expiresAt := changedAt.AddDate(0, 0, policy.MaxAgeDays)
+	return &expiresAt, nil
+}
+
+// RequiresPasswordChange 判断用户是否须先修改密码：被标记为须修改或密码已过期
+func (s *PasswordPolicyService) RequiresPasswordChange(ctx context.Context, user *model.User) (bool, *time.Time, error) {
+	expiresAt, err := s.PasswordExpiresAt(ctx, user)
+	if err != nil {
+		return false, nil, err
+	}
+	if user.MustChangePassword {
+		return true, expiresAt, nil
+	}
+	return expiresAt != nil && time.Now().After(*expiresAt), expiresAt, nil
+}
+
+// checkRules 校验长度、字符种类及是否包含用户名、公司名称，返回不满足的要求
+func (s *PasswordPolicyService) checkRules(ctx context.Context, policy *model.PasswordPolicy, user *model.User, password string) []string {
+	var violations []string
+
+	if len([]rune(password)) < policy.MinLength {
+		violations = append(violations, fmt.Sprintf("长度至少为%d位", policy.MinLength))
+	}
+
+	var hasDigit, hasLower, hasUpper, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		case r >= 'a' && r <= 'z':
+			hasLower = true
+		case r >= 'A' && r <= 'Z':
+			hasUpper = true
+		case !unicode.IsSpace(r):
+			hasSpecial = true
+		}
+	}
+	if policy.RequireDigit && !hasDigit {
+		violations = append(violations, "须包含数字")
+	}
+	if policy.RequireLowercase && !hasLower {
+		violations = append(violations, "须包含小写字母")
+	}
+	if policy.RequireUppercase && !hasUpper {
+		violations = append(violations, "须包含大写字母")
+	}
+	if policy.RequireSpecial && !hasSpecial {
+		violations = append(violations, "须包含特殊字符")
+	}
+	classes := 0
+	for _, has := range []bool{hasDigit, hasLower, hasUpper, hasSpecial} {
+		if has {
+			classes++
+		}
+	}
+	if classes < policy.MinCharClasses {
+		violations = append(violations, fmt.Sprintf("须包含数字、小写字母、大写字母、特殊字符中的至少%d种", policy.MinCharClasses))
+	}
+
+	lowerPassword := strings.ToLower(password)
+	if policy.DisallowUsername && containsFold(lowerPassword, user.Username) {
+		violations = append(violations, "不能包含用户名")
+	}
+	if policy.DisallowCompanyName && user.CompanyID != "" {
+		company, err := s.companyRepo.GetCompanyByID(ctx, user.CompanyID)
+		if err != nil {
+			logger.Errorf("校验密码策略时查询公司失败: %v, CompanyID: %s", err, user.CompanyID)
+		} else if company != nil && (containsFold(lowerPassword, company.CompanyName) || containsFold(lowerPassword, company.CompanyCode)) {
+			violations = append(violations, "不能包含公司名称或公司代码")
+		}
+	}
+
+	return violations
+}
+
+// containsFold 判断密码（已转小写）是否包含指定词，过短的词不参与判断
+func containsFold(lowerPassword, word string) bool {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if len([]rune(word)) < 3 {
+		return false
+	}
+	return strings.Contains(lowerPassword, word)
+}
+
+// passwordPolicyFromRequest 请求转换为策略
+func passwordPolicyFromRequest(req *model.PasswordPolicyRequest) *model.PasswordPolicy {
+	return &model.PasswordPolicy{
+		MinLength:           req.MinLength,
+		RequireDigit:        req.RequireDigit,
+		RequireLowercase:    req.RequireLowercase,
+		RequireUppercase:    req.RequireUppercase,
+		RequireSpecial:      req.RequireSpecial,
+		MinCharClasses:      req.MinCharClasses,
+		DisallowUsername:    req.DisallowUsername,
+		DisallowCompanyName: req.DisallowCompanyName,
+		HistoryCount:        req.HistoryCount,
+		MaxAgeDays:          req.MaxAgeDays,
+	}
+}
+
+// mergePasswordPolicy 合并平台策略和公司策略，每一项取更严格的设置
+func mergePasswordPolicy(platform, company *model.PasswordPolicy) *model.PasswordPolicy {
+	merged := *platform
+	merged.ID = primitive.NilObjectID
+	merged.UpdatedBy = ""
+	merged.CreatedAt = time.Time{}
+	merged.UpdatedAt = time.Time{}
+	if company == nil {
+		return &merged
+	}
+
+	merged.CompanyID = company.CompanyID
+	if company.MinLength > merged.MinLength {
+		merged.MinLength = company.MinLength
+	}
+	merged.RequireDigit = merged.RequireDigit || company.RequireDigit
+	merged.RequireLowercase = merged.RequireLowercase || company.RequireLowercase
+	merged.RequireUppercase = merged.RequireUppercase || company.RequireUppercase
+	merged.RequireSpecial = merged.RequireSpecial || company.RequireSpecial
+	if company.MinCharClasses > merged.MinCharClasses {
+		merged.MinCharClasses = company.MinCharClasses
+	}
+	merged.DisallowUsername = merged.DisallowUsername || company.DisallowUsername
+	merged.DisallowCompanyName = merged.DisallowCompanyName || company.DisallowCompanyName
+	if company.HistoryCount > merged.HistoryCount {
+		merged.HistoryCount = company.HistoryCount
+	}
+	// 使用天数：0表示不过期，取两者中较短的非零值
+	if company.MaxAgeDays > 0 && (merged.MaxAgeDays == 0 || company.MaxAgeDays < merged.MaxAgeDays) {
+		merged.MaxAgeDays = company.MaxAgeDays
+	}
+	return &merged
+}
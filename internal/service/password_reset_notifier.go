@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"YufungProject/internal/model"
+)
+
+// PasswordResetNotifier 重置密码链接的投递方式，默认通过邮件发送，可替换为短信等其他渠道
+type PasswordResetNotifier interface {
+	NotifyPasswordReset(ctx context.Context, user *model.User, resetURL string, expiresIn time.Duration) error
+}
+
+// mailPasswordResetNotifier 通过发件箱发送重置密码邮件
+type mailPasswordResetNotifier struct {
+	mailService *MailService
+}
+
+// NewMailPasswordResetNotifier 创建基于邮件的重置密码通知
+func NewMailPasswordResetNotifier(mailService *MailService) PasswordResetNotifier {
+	return &mailPasswordResetNotifier{mailService: mailService}
+}
+
+// NotifyPasswordReset 发送重置密码邮件；邮件包含重置链接，标记为敏感内容
+func (n *mailPasswordResetNotifier) NotifyPasswordReset(ctx context.Context, user *model.User, resetURL string, expiresIn time.Duration) error {
+	displayName := user.DisplayName
+	if displayName == "" {
+		displayName = user.Username
+	}
+
+	_, err := n.mailService.Enqueue(ctx, &model.MailSendRequest{
+		To:       []string{user.Email},
+		Template: model.MailTemplatePasswordReset,
+		Data: map[string]interface{}{
+			"DisplayName":    displayName,
+			"ResetURL":       resetURL,
+			"ExpiresMinutes": int(expiresIn.Minutes()),
+		},
+		Sensitive: true,
+		CompanyID: user.CompanyID,
+	})
+	return err
+}
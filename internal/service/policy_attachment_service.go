@@ -0,0 +1,302 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"YufungProject/internal/model"
+	"YufungProject/internal/repository"
+	"YufungProject/pkg/logger"
+	"YufungProject/pkg/storage"
+	"YufungProject/pkg/utils"
+)
+
+// oleSignature Office 97-2003 文档（doc/xls/ppt）的复合文档文件头
+var oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// officeTypes Office 文档扩展名对应的MIME类型及 OOXML 压缩包中必须包含的目录
+var officeTypes = map[string]struct {
+	contentType string
+	ooxmlDir    string
+}{
+	"doc":  {"application/msword", ""},
+	"xls":  {"application/vnd.ms-excel", ""},
+	"ppt":  {"application/vnd.ms-powerpoint", ""},
+	"docx": {"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "word/"},
+	"xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xl/"},
+	"pptx": {"application/vnd.openxmlformats-officedocument.presentationml.presentation", "ppt/"},
+}
+
+type PolicyAttachmentService struct {
+	attachmentRepo      *repository.PolicyAttachmentRepository
+	policyRepo          *repository.PolicyRepository
+	userRepo            repository.UserRepository
+	changeRecordService *ChangeRecordService
+	storage             storage.Storage
+	maxSize             int64           // 单个文件大小上限（字节）
+	allowedTypes        map[string]bool // 允许的扩展名（小写，不含点）
+}
+
+func NewPolicyAttachmentService(attachmentRepo *repository.PolicyAttachmentRepository, policyRepo *repository.PolicyRepository, userRepo repository.UserRepository, changeRecordService *ChangeRecordService, fileStorage storage.Storage, maxSize int64, allowedTypes []string) *PolicyAttachmentService {
+	allowed := make(map[string]bool, len(allowedTypes))
+	for _, ext := range allowedTypes {
+		allowed[strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))] = true
+	}
+	return &PolicyAttachmentService{
+		attachmentRepo:      attachmentRepo,
+		policyRepo:          policyRepo,
+		userRepo:            userRepo,
+		changeRecordService: changeRecordService,
+		storage:             fileStorage,
+		maxSize:             maxSize,
+		allowedTypes:        allowed,
+	}
+}
+
+// UploadAttachment 上传保单附件：校验扩展名、大小，并按文件内容识别类型，与扩展名不符的文件拒绝上传
+func (s *PolicyAttachmentService) UploadAttachment(ctx context.Context, policyID string, req *model.PolicyAttachmentUploadRequest, file multipart.File, header *multipart.FileHeader, userID, companyID, ipAddress, userAgent string) (*model.PolicyAttachment, error) {
+	if _, err := s.getPolicy(ctx, policyID, companyID); err != nil {
+		return nil, err
+	}
+
+	fileName := filepath.Base(strings.ReplaceAll(header.Filename, "\\", "/"))
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(fileName), "."))
+	if !s.allowedTypes[ext] {
+		return nil, fmt.Errorf("不支持的文件类型：%s，允许的类型：%s", ext, strings.Join(s.allowedTypeList(), "、"))
+	}
+	if header.Size > s.maxSize {
+		return nil, fmt.Errorf("文件大小不能超过%s", formatFileSize(s.maxSize))
+	}
+
+	content, err := io.ReadAll(io.LimitReader(file, s.maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取上传文件失败: %v", err)
+	}
+	if int64(len(content)) > s.maxSize {
+		return nil, fmt.Errorf("文件大小不能超过%s", formatFileSize(s.maxSize))
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("文件内容为空")
+	}
+
+	contentType, ok := detectAttachmentType(ext, content)
+	if !ok {
+		return nil, fmt.Errorf("文件内容与扩展名不符")
+	}
+
+	checksum := sha256.Sum256(content)
+	attachment := &model.PolicyAttachment{
+		AttachmentID: utils.GenerateID("ATT"),
+		PolicyID:     policyID,
+		CompanyID:    companyID,
+		Category:     req.Category,
+		FileName:     fileName,
+		Extension:    ext,
+		ContentType:  contentType,
+		Size:         int64(len(content)),
+		Checksum:     hex.EncodeToString(checksum[:]),
+		Remark:       strings.TrimSpace(req.Remark),
+		UploadedBy:   userID,
+	}
+	attachment.StorageKey = fmt.Sprintf("%s/%s/%s.%s", companyID, policyID, attachment.AttachmentID, ext)
+	if user, err := s.userRepo.GetByUserID(ctx, userID); err == nil && user != nil {
+		attachment.UploaderName = user.Username
+	}
+
+	if _, err := s.storage.Save(ctx, attachment.StorageKey, bytes.NewReader(content)); err != nil {
+		return nil, fmt.Errorf("保存文件失败: %v", err)
+	}
+	if err := s.attachmentRepo.CreateAttachment(ctx, attachment); err != nil {
+		if delErr := s.storage.Delete(ctx, attachment.StorageKey); delErr != nil {
+			logger.Warnf("清理附件文件失败: %v", delErr)
+		}
+		return nil, err
+	}
+
+	if s.changeRecordService != nil {
+		if err := s.changeRecordService.RecordChange(ctx, model.PolicyAttachmentTableName, attachment.AttachmentID, userID, companyID, "insert", nil, attachment, "", ipAddress, userAgent); err != nil {
+			logger.Warnf("记录附件上传失败: %v", err)
+		}
+	}
+	logger.BusinessLog("保单管理", "上传附件", userID, fmt.Sprintf("保单: %s, 附件: %s, 文件名: %s", policyID, attachment.AttachmentID, fileName))
+
+	return attachment, nil
+}
+
+// ListAttachments 获取保单附件列表
+func (s *PolicyAttachmentService) ListAttachments(ctx context.Context, policyID, companyID string) ([]model.PolicyAttachment, error) {
+	if _, err := s.getPolicy(ctx, policyID, companyID); err != nil {
+		return nil, err
+	}
+	return s.attachmentRepo.ListAttachmentsByPolicy(ctx, policyID)
+}
+
+// OpenAttachment 打开附件用于下载，只能下载本公司保单的附件；调用方负责关闭返回的文件
+func (s *PolicyAttachmentService) OpenAttachment(ctx context.Context, policyID, attachmentID, userID, companyID string) (*model.PolicyAttachment, io.ReadCloser, error) {
+	attachment, err := s.getAttachment(ctx, policyID, attachmentID, companyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := s.storage.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			logger.Warnf("附件文件丢失: %s (%s)", attachment.AttachmentID, attachment.StorageKey)
+			return nil, nil, fmt.Errorf("附件不存在")
+		}
+		return nil, nil, err
+	}
+
+	logger.BusinessLog("保单管理", "下载附件", userID, fmt.Sprintf("保单: %s, 附件: %s", policyID, attachmentID))
+	return attachment, reader, nil
+}
+
+// DeleteAttachment 删除附件并记录变更
+func (s *PolicyAttachmentService) DeleteAttachment(ctx context.Context, policyID, attachmentID, userID, companyID, ipAddress, userAgent string) error {
+	attachment, err := s.getAttachment(ctx, policyID, attachmentID, companyID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.attachmentRepo.DeleteAttachment(ctx, attachmentID); err != nil {
+		return err
+	}
+	if err := s.storage.Delete(ctx, attachment.StorageKey); err != nil {
+		logger.Warnf("删除附件文件失败: %v", err)
+	}
+
+	if s.changeRecordService != nil {
+		if err := s.changeRecordService.RecordChange(ctx, model.PolicyAttachmentTableName, attachmentID, userID, companyID, "delete", attachment, nil, "", ipAddress, userAgent); err != nil {
+			logger.Warnf("记录附件删除失败: %v", err)
+		}
+	}
+	logger.BusinessLog("保单管理", "删除附件", userID, fmt.Sprintf("保单: %s, 附件: %s, 文件名: %s", policyID, attachmentID, attachment.FileName))
+
+	return nil
+}
+
+// DeletePolicyAttachments 删除保单的全部附件（删除保单时调用）
+func (s *PolicyAttachmentService) DeletePolicyAttachments(ctx context.Context, policyID string) error {
+	attachments, err := s.attachmentRepo.ListAttachmentsByPolicy(ctx, policyID)
+	if err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		if err := s.attachmentRepo.DeleteAttachment(ctx, attachment.AttachmentID); err != nil {
+			return err
+		}
+		if err := s.storage.Delete(ctx, attachment.StorageKey); err != nil {
+			logger.Warnf("删除附件文件失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// CountAttachments 统计各保单的附件数量
+func (s *PolicyAttachmentService) CountAttachments(ctx context.Context, policyIDs []string) (map[string]int64, error) {
+	if len(policyIDs) == 0 {
+		return map[string]int64{}, nil
+	}
+	return s.attachmentRepo.CountAttachmentsByPolicies(ctx, policyIDs)
+}
+
+// getPolicy 获取保单并校验所属公司，其他公司的保单视为不存在
+func (s *PolicyAttachmentService) getPolicy(ctx context.Context, policyID, companyID string) (*model.Policy, error) {
+	policy, err := s.policyRepo.GetPolicyByID(ctx, policyID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil || policy.CompanyID != companyID {
+		return nil, fmt.Errorf("保单不存在")
+	}
+	return policy, nil
+}
+
+// getAttachment 获取附件并校验所属保单及公司
+func (s *PolicyAttachmentService) getAttachment(ctx context.Context, policyID, attachmentID, companyID string) (*model.PolicyAttachment, error) {
+	attachment, err := s.attachmentRepo.GetAttachmentByID(ctx, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if attachment == nil || attachment.CompanyID != companyID || attachment.PolicyID != policyID {
+		return nil, fmt.Errorf("附件不存在")
+	}
+	return attachment, nil
+}
+
+// allowedTypeList 允许的扩展名列表（排序后）
+func (s *PolicyAttachmentService) allowedTypeList() []string {
+	list := make([]string, 0, len(s.allowedTypes))
+	for ext := range s.allowedTypes {
+		list = append(list, ext)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// detectAttachmentType 按文件内容识别MIME类型，并检查是否与扩展名一致
+func detectAttachmentType(ext string, content []byte) (string, bool) {
+	if office, ok := officeTypes[ext]; ok {
+		if office.ooxmlDir == "" {
+			return office.contentType, bytes.HasPrefix(content, oleSignature)
+		}
+		return office.contentType, zipContainsDir(content, office.ooxmlDir)
+	}
+
+	sniffed := http.DetectContentType(content)
+	expected := mime.TypeByExtension("." + ext)
+	if ext == "jpg" || ext == "jpeg" {
+		expected = "image/jpeg"
+	}
+	if expected == "" {
+		return "", false
+	}
+
+	sniffedType, _, _ := mime.ParseMediaType(sniffed)
+	expectedType, _, _ := mime.ParseMediaType(expected)
+	if sniffedType == expectedType {
+		return sniffed, true
+	}
+	// 文本类文件（如 txt、csv）内容识别结果均为 text/plain
+	if strings.HasPrefix(expectedType, "text/") && sniffedType == "text/plain" {
+		return expected, true
+	}
+	return "", false
+}
+
+// zipContainsDir 内容是否为包含指定目录的 zip 压缩包（OOXML 文档）
+func zipContainsDir(content []byte, dir string) bool {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return false
+	}
+	for _, file := range reader.File {
+		if strings.HasPrefix(file.Name, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatFileSize 格式化文件大小
+func formatFileSize(size int64) string {
+	switch {
+	case size >= 1<<20 && size%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", size>>20)
+	case size >= 1<<10 && size%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", size>>10)
+	default:
+		return fmt.Sprintf("%dB", size)
+	}
+}
@@ -4,11 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"mime/multipart"
 	"reflect"
-	"strconv"
+	"regexp"
 	"strings"
 	"time"
 
@@ -17,23 +18,96 @@ import (
 
 	"YufungProject/internal/model"
 	"YufungProject/internal/repository"
+	"YufungProject/pkg/logger"
+	"YufungProject/pkg/validator"
 	"math"
 )
 
+// 由请求结构体标签生成的保单字段规则，API、导入及前端规则接口共用
+var (
+	policyCreateRules = validator.MustParseStruct(model.PolicyCreateRequest{})
+	policyUpdateRules = validator.MustParseStruct(model.PolicyUpdateRequest{})
+)
+
+// policyImportColumns 导入文件固定列对应的字段名（第1列为序号）
+var policyImportColumns = []string{
+	"", "account_number", "customer_number", "customer_name_cn", "customer_name_en", "proposal_number",
+	"policy_currency", "partner", "referral_code", "hk_manager", "referral_pm", "referral_branch",
+	"referral_sub_branch", "referral_date", "is_surrendered", "payment_date", "effective_date",
+	"payment_method", "payment_years", "payment_periods", "actual_premium", "aum", "past_cooling_period",
+	"is_paid_commission", "referral_rate", "exchange_rate", "expected_fee", "payment_pay_date",
+	"is_employee", "insurance_company", "product_name", "product_type", "remark", "product_id",
+}
+
 type PolicyService struct {
-	policyRepo          *repository.PolicyRepository
-	changeRecordService *ChangeRecordService
+	policyRepo            *repository.PolicyRepository
+	changeRecordService   *ChangeRecordService
+	systemConfigService   SystemConfigService
+	productService        *ProductService
+	referralFeeService    *ReferralFeeService
+	tableStructureService TableStructureService
+	customerService       *CustomerService
+	attachmentService     *PolicyAttachmentService
 }
 
-func NewPolicyService(policyRepo *repository.PolicyRepository, changeRecordService *ChangeRecordService) *PolicyService {
+func NewPolicyService(policyRepo *repository.PolicyRepository, changeRecordService *ChangeRecordService, systemConfigService SystemConfigService, productService *ProductService, referralFeeService *ReferralFeeService, tableStructureService TableStructureService, customerService *CustomerService, attachmentService *PolicyAttachmentService) *PolicyService {
 	return &PolicyService{
-		policyRepo:          policyRepo,
-		changeRecordService: changeRecordService,
+		policyRepo:            policyRepo,
+		changeRecordService:   changeRecordService,
+		systemConfigService:   systemConfigService,
+		productService:        productService,
+		referralFeeService:    referralFeeService,
+		tableStructureService: tableStructureService,
+		customerService:       customerService,
+		attachmentService:     attachmentService,
 	}
 }
 
 // CreatePolicy 创建保单
 func (s *PolicyService) CreatePolicy(ctx context.Context, req *model.PolicyCreateRequest, userID, companyID string) (*model.PolicyResponse, error) {
+	resolver, err := s.systemConfigService.NewDictionaryResolver(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	calculator, err := s.referralFeeService.NewFeeCalculator(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := s.tableStructureService.NewCustomFieldSchema(ctx, model.PolicyTableName, companyID)
+	if err != nil {
+		return nil, err
+	}
+	return s.createPolicy(ctx, req, userID, companyID, resolver, calculator, schema)
+}
+
+// createPolicy 创建保单（复用已加载的字典解析器、转介费计算器与自定义字段模式，供导入等批量场景使用）
+func (s *PolicyService) createPolicy(ctx context.Context, req *model.PolicyCreateRequest, userID, companyID string, resolver *DictionaryResolver, calculator *ReferralFeeCalculator, schema *CustomFieldSchema) (*model.PolicyResponse, error) {
+	// 字典字段校验与规范化
+	warnings, err := resolver.Normalize(policyDictionaryValues(&req.HKManager, &req.ReferralBranch, &req.Partner))
+	if err != nil {
+		return nil, err
+	}
+
+	// 自定义字段转换
+	customFields, err := schema.Normalize(req.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+
+	// 字段规则校验
+	if err := validatePolicyCreate(req, customFields, schema); err != nil {
+		return nil, err
+	}
+
+	// 关联产品目录
+	product, err := s.applyPolicyProduct(ctx, req, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按规则计算转介费率及预计转介费
+	fee := applyReferralFee(calculator, req)
+
 	// 检查重复保单
 	isDuplicate, err := s.policyRepo.CheckDuplicatePolicy(ctx, req.AccountNumber, req.ProposalNumber, companyID, "")
 	if err != nil {
@@ -48,52 +122,263 @@ func (s *PolicyService) CreatePolicy(ctx context.Context, req *model.PolicyCreat
 		}
 	}
 
+	// 关联客户档案（不存在时自动创建），客户姓名以档案为准
+	customer, customerCreated, err := s.customerService.LinkPolicyCustomer(ctx, req.CustomerNumber, req.CustomerNameCN, req.CustomerNameEN, userID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if customer.CustomerNameCN != req.CustomerNameCN || (req.CustomerNameEN != "" && customer.CustomerNameEN != req.CustomerNameEN) {
+		warnings = append(warnings, fmt.Sprintf("客户号%s的姓名与客户档案不一致，已使用档案中的姓名：%s", customer.CustomerNumber, customerDisplayName(customer)))
+	}
+	req.CustomerNumber = customer.CustomerNumber
+	req.CustomerNameCN = customer.CustomerNameCN
+	req.CustomerNameEN = customer.CustomerNameEN
+
 	// 构建保单模型
 	policy := &model.Policy{
-		AccountNumber:     req.AccountNumber,
-		CustomerNumber:    req.CustomerNumber,
-		CustomerNameCN:    req.CustomerNameCN,
-		CustomerNameEN:    req.CustomerNameEN,
-		ProposalNumber:    req.ProposalNumber,
-		PolicyCurrency:    req.PolicyCurrency,
-		Partner:           req.Partner,
-		ReferralCode:      req.ReferralCode,
-		HKManager:         req.HKManager,
-		ReferralPM:        req.ReferralPM,
-		ReferralBranch:    req.ReferralBranch,
-		ReferralSubBranch: req.ReferralSubBranch,
-		ReferralDate:      req.ReferralDate,
-		IsSurrendered:     req.IsSurrendered,
-		PaymentDate:       req.PaymentDate,
-		EffectiveDate:     req.EffectiveDate,
-		PaymentMethod:     req.PaymentMethod,
-		PaymentYears:      req.PaymentYears,
-		PaymentPeriods:    req.PaymentPeriods,
-		ActualPremium:     req.ActualPremium,
-		AUM:               req.AUM,
-		PastCoolingPeriod: req.PastCoolingPeriod,
-		IsPaidCommission:  req.IsPaidCommission,
-		IsEmployee:        req.IsEmployee,
-		ReferralRate:      req.ReferralRate,
-		ExchangeRate:      req.ExchangeRate,
-		ExpectedFee:       req.ExpectedFee,
-		PaymentPayDate:    req.PaymentPayDate,
-		InsuranceCompany:  req.InsuranceCompany,
-		ProductName:       req.ProductName,
-		ProductType:       req.ProductType,
-		Remark:            req.Remark,
-		CompanyID:         companyID,
-		CreatedBy:         userID,
-		UpdatedBy:         userID,
-	}
-
-	// 创建保单
+		AccountNumber:      req.AccountNumber,
+		CustomerID:         customer.CustomerID,
+		CustomerNumber:     req.CustomerNumber,
+		CustomerNameCN:     req.CustomerNameCN,
+		CustomerNameEN:     req.CustomerNameEN,
+		ProposalNumber:     req.ProposalNumber,
+		PolicyCurrency:     req.PolicyCurrency,
+		Partner:            req.Partner,
+		ReferralCode:       req.ReferralCode,
+		HKManager:          req.HKManager,
+		ReferralPM:         req.ReferralPM,
+		ReferralBranch:     req.ReferralBranch,
+		ReferralSubBranch:  req.ReferralSubBranch,
+		ReferralDate:       req.ReferralDate,
+		IsSurrendered:      req.IsSurrendered,
+		PaymentDate:        req.PaymentDate,
+		EffectiveDate:      req.EffectiveDate,
+		PaymentMethod:      req.PaymentMethod,
+		PaymentYears:       req.PaymentYears,
+		PaymentPeriods:     req.PaymentPeriods,
+		ActualPremium:      req.ActualPremium,
+		AUM:                req.AUM,
+		PastCoolingPeriod:  req.PastCoolingPeriod,
+		IsPaidCommission:   req.IsPaidCommission,
+		IsEmployee:         req.IsEmployee,
+		ReferralRate:       req.ReferralRate,
+		ExchangeRate:       req.ExchangeRate,
+		ExpectedFee:        req.ExpectedFee,
+		PaymentPayDate:     req.PaymentPayDate,
+		FeeRuleID:          fee.RuleID,
+		ManualReferralRate: fee.ManualReferralRate,
+		ManualExpectedFee:  fee.ManualExpectedFee,
+		ProductID:          req.ProductID,
+		InsuranceCompany:   req.InsuranceCompany,
+		ProductName:        req.ProductName,
+		ProductType:        req.ProductType,
+		CustomFields:       customFields,
+		SchemaVersion:      schema.Version(),
+		Remark:             req.Remark,
+		CompanyID:          companyID,
+		CreatedBy:          userID,
+		UpdatedBy:          userID,
+	}
+	if product != nil {
+		policy.InsurerID = product.InsurerID
+	}
+
+	// 创建保单，失败时删除本次自动创建的客户档案
 	err = s.policyRepo.CreatePolicy(ctx, policy)
 	if err != nil {
+		if customerCreated {
+			if deleteErr := s.customerService.DeleteCustomer(ctx, customer.CustomerID, companyID); deleteErr != nil {
+				logger.Warnf("删除未关联保单的客户档案失败: CustomerID=%s, Error=%v", customer.CustomerID, deleteErr)
+			}
+		}
 		return nil, err
 	}
 
-	return &model.PolicyResponse{Policy: policy}, nil
+	return &model.PolicyResponse{Policy: policy, Warnings: warnings}, nil
+}
+
+// customerDisplayName 客户姓名显示文本（中文名及英文名）
+func customerDisplayName(customer *model.Customer) string {
+	if customer.CustomerNameEN == "" {
+		return customer.CustomerNameCN
+	}
+	return fmt.Sprintf("%s（%s）", customer.CustomerNameCN, customer.CustomerNameEN)
+}
+
+// validatePolicyCreate 按保单字段规则及自定义字段规则校验新建保单
+func validatePolicyCreate(req *model.PolicyCreateRequest, customFields map[string]interface{}, schema *CustomFieldSchema) error {
+	rules, err := policyCreateRules.With(schema.Rules()...)
+	if err != nil {
+		return err
+	}
+
+	values := validator.StructValues(req)
+	for name, value := range customFields {
+		values[customFieldPath(name)] = value
+	}
+	return rules.Validate(values)
+}
+
+// validationMessages 将校验错误展开为提示列表
+func validationMessages(err error) []string {
+	var errs validator.Errors
+	if errors.As(err, &errs) {
+		return errs.Messages()
+	}
+	return []string{err.Error()}
+}
+
+// applyPolicyProduct 按产品ID关联产品目录：校验缴费条款，并以产品信息覆盖冗余的承保公司、产品名称及类型
+func (s *PolicyService) applyPolicyProduct(ctx context.Context, req *model.PolicyCreateRequest, companyID string) (*model.Product, error) {
+	if req.ProductID == "" {
+		return nil, nil
+	}
+
+	product, err := s.productService.GetPolicyProduct(ctx, req.ProductID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.productService.CheckPolicyTerms(product, req.PolicyCurrency, req.PaymentMethod, req.PaymentYears, req.EffectiveDate); err != nil {
+		return nil, err
+	}
+
+	req.InsuranceCompany = product.InsurerName
+	req.ProductName = product.ProductName
+	req.ProductType = product.ProductType
+
+	return product, nil
+}
+
+// applyReferralFee 计算新保单的转介费率及预计转介费，标记为手工填写的值以请求为准
+func applyReferralFee(calculator *ReferralFeeCalculator, req *model.PolicyCreateRequest) ReferralFeeResult {
+	var rate, fee *float64
+	if req.ManualRate {
+		rate = &req.ReferralRate
+	}
+	if req.ManualFee {
+		fee = &req.ExpectedFee
+	}
+
+	result := calculator.Calculate(ReferralFeeInput{
+		Partner:       req.Partner,
+		ProductID:     req.ProductID,
+		PaymentMethod: req.PaymentMethod,
+		PaymentYears:  req.PaymentYears,
+		EffectiveDate: req.EffectiveDate,
+		ActualPremium: req.ActualPremium,
+		ExchangeRate:  req.ExchangeRate,
+	}, rate, fee)
+
+	req.ReferralRate = result.ReferralRate
+	req.ExpectedFee = result.ExpectedFee
+	return result
+}
+
+// policyFeeInput 组装保单的转介费计算入参
+func policyFeeInput(policy *model.Policy) ReferralFeeInput {
+	return ReferralFeeInput{
+		Partner:       policy.Partner,
+		ProductID:     policy.ProductID,
+		PaymentMethod: policy.PaymentMethod,
+		PaymentYears:  policy.PaymentYears,
+		EffectiveDate: policy.EffectiveDate,
+		ActualPremium: policy.ActualPremium,
+		ExchangeRate:  policy.ExchangeRate,
+	}
+}
+
+// policyFeeInputsChanged 判断更新请求是否涉及转介费计算相关字段
+func policyFeeInputsChanged(req *model.PolicyUpdateRequest) bool {
+	return req.Partner != "" || req.ProductID != "" || req.PaymentMethod != "" ||
+		req.PaymentYears != nil || req.EffectiveDate != nil || req.ActualPremium != nil ||
+		req.ExchangeRate != nil || req.ReferralRate != nil || req.ExpectedFee != nil
+}
+
+// mergePolicyUpdates 将更新字段应用到保单副本上，用于计算派生字段
+func mergePolicyUpdates(policy *model.Policy, updates bson.M) (*model.Policy, error) {
+	data, err := bson.Marshal(policy)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	for key, value := range updates {
+		doc[key] = value
+	}
+
+	if data, err = bson.Marshal(doc); err != nil {
+		return nil, err
+	}
+	var merged model.Policy
+	if err := bson.Unmarshal(data, &merged); err != nil {
+		return nil, err
+	}
+	return &merged, nil
+}
+
+// applyPolicyProductUpdate 更新保单时校验产品及缴费条款，仅在产品或条款相关字段变更时校验
+func (s *PolicyService) applyPolicyProductUpdate(ctx context.Context, policy *model.Policy, req *model.PolicyUpdateRequest, companyID string) (*model.Product, error) {
+	productID := policy.ProductID
+	if req.ProductID != "" {
+		productID = req.ProductID
+	}
+	if productID == "" {
+		return nil, nil
+	}
+
+	termsChanged := req.ProductID != "" && req.ProductID != policy.ProductID
+	if !termsChanged && req.PolicyCurrency == "" && req.PaymentMethod == "" && req.PaymentYears == nil && req.EffectiveDate == nil {
+		// 未涉及产品条款，仅保持冗余名称与产品一致
+		req.InsuranceCompany, req.ProductName, req.ProductType = "", "", ""
+		return nil, nil
+	}
+
+	var product *model.Product
+	var err error
+	if termsChanged {
+		// 更换产品时新产品须处于启用状态
+		product, err = s.productService.GetPolicyProduct(ctx, productID, companyID)
+	} else {
+		product, err = s.productService.GetProduct(ctx, productID, companyID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 以更新后的值校验产品条款
+	currency, paymentMethod, paymentYears, effectiveDate := policy.PolicyCurrency, policy.PaymentMethod, policy.PaymentYears, policy.EffectiveDate
+	if req.PolicyCurrency != "" {
+		currency = req.PolicyCurrency
+	}
+	if req.PaymentMethod != "" {
+		paymentMethod = req.PaymentMethod
+	}
+	if req.PaymentYears != nil {
+		paymentYears = *req.PaymentYears
+	}
+	if req.EffectiveDate != nil {
+		effectiveDate = req.EffectiveDate
+	}
+	if err := s.productService.CheckPolicyTerms(product, currency, paymentMethod, paymentYears, effectiveDate); err != nil {
+		return nil, err
+	}
+
+	req.InsuranceCompany = product.InsurerName
+	req.ProductName = product.ProductName
+	req.ProductType = product.ProductType
+
+	return product, nil
+}
+
+// policyDictionaryValues 组装保单字典字段（配置类型 -> 字段值指针）
+func policyDictionaryValues(hkManager, referralBranch, partner *string) map[string]*string {
+	return map[string]*string{
+		"hk_manager":      hkManager,
+		"referral_branch": referralBranch,
+		"partner":         partner,
+	}
 }
 
 // GetPolicyByID 获取保单详情
@@ -111,7 +396,12 @@ func (s *PolicyService) GetPolicyByID(ctx context.Context, policyID, companyID s
 		return nil, fmt.Errorf("无权访问该保单")
 	}
 
-	return &model.PolicyResponse{Policy: policy}, nil
+	counts, err := s.attachmentService.CountAttachments(ctx, []string{policyID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.PolicyResponse{Policy: policy, AttachmentCount: counts[policyID]}, nil
 }
 
 // UpdatePolicy 更新保单
@@ -130,6 +420,61 @@ func (s *PolicyService) UpdatePolicy(ctx context.Context, policyID string, req *
 		return nil, fmt.Errorf("无权修改该保单")
 	}
 
+	// 已关联客户档案的保单，客户姓名由客户管理统一维护
+	if policy.CustomerID != "" &&
+		((req.CustomerNameCN != "" && req.CustomerNameCN != policy.CustomerNameCN) ||
+			(req.CustomerNameEN != "" && req.CustomerNameEN != policy.CustomerNameEN)) {
+		return nil, fmt.Errorf("保单已关联客户档案，客户姓名请在客户管理中修改")
+	}
+
+	// 字典字段校验与规范化
+	resolver, err := s.systemConfigService.NewDictionaryResolver(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	warnings, err := resolver.Normalize(policyDictionaryValues(&req.HKManager, &req.ReferralBranch, &req.Partner))
+	if err != nil {
+		return nil, err
+	}
+
+	// 关联产品目录
+	product, err := s.applyPolicyProductUpdate(ctx, policy, req, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 自定义字段转换，与已有值合并
+	rules := policyUpdateRules
+	values := validator.StructValues(req)
+	base := validator.StructValues(policy)
+	var customFields map[string]interface{}
+	var schemaVersion int
+	if req.CustomFields != nil {
+		schema, err := s.tableStructureService.NewCustomFieldSchema(ctx, model.PolicyTableName, companyID)
+		if err != nil {
+			return nil, err
+		}
+		schemaVersion = schema.Version()
+		customFields, err = schema.NormalizeUpdate(policy.CustomFields, req.CustomFields)
+		if err != nil {
+			return nil, err
+		}
+		if rules, err = rules.With(schema.Rules()...); err != nil {
+			return nil, err
+		}
+		for name := range req.CustomFields {
+			values[customFieldPath(name)] = customFields[name]
+		}
+		for name, value := range policy.CustomFields {
+			base[customFieldPath(name)] = value
+		}
+	}
+
+	// 字段规则校验：只校验本次传入的字段，条件必填按合并后的数据检查
+	if err := rules.ValidatePartial(values, base); err != nil {
+		return nil, err
+	}
+
 	// 保存原始数据用于变更记录
 	oldPolicy := *policy
 
@@ -176,6 +521,41 @@ func (s *PolicyService) UpdatePolicy(ctx context.Context, policyID string, req *
 		}
 	}
 
+	if product != nil {
+		updates["insurer_id"] = product.InsurerID
+	}
+	if req.CustomFields != nil {
+		updates[model.CustomFieldsKey] = customFields
+		updates[model.SchemaVersionKey] = schemaVersion
+	}
+
+	// 转介费相关字段变更时按规则重新计算，未重新填写的手工值保持不变
+	if policyFeeInputsChanged(req) {
+		merged, err := mergePolicyUpdates(policy, updates)
+		if err != nil {
+			return nil, err
+		}
+		calculator, err := s.referralFeeService.NewFeeCalculator(ctx, companyID)
+		if err != nil {
+			return nil, err
+		}
+
+		rate, fee := req.ReferralRate, req.ExpectedFee
+		if rate == nil && policy.ManualReferralRate {
+			rate = &policy.ReferralRate
+		}
+		if fee == nil && policy.ManualExpectedFee {
+			fee = &policy.ExpectedFee
+		}
+
+		result := calculator.Calculate(policyFeeInput(merged), rate, fee)
+		updates["referral_rate"] = result.ReferralRate
+		updates["expected_fee"] = result.ExpectedFee
+		updates["fee_rule_id"] = result.RuleID
+		updates["manual_referral_rate"] = result.ManualReferralRate
+		updates["manual_expected_fee"] = result.ManualExpectedFee
+	}
+
 	// 执行更新
 	err = s.policyRepo.UpdatePolicy(ctx, policyID, updates)
 	if err != nil {
@@ -191,8 +571,8 @@ func (s *PolicyService) UpdatePolicy(ctx context.Context, policyID string, req *
 	// 记录变更日志（异步处理，不影响主流程）
 	go func() {
 		if s.changeRecordService != nil {
-			// 创建独立的上下文以避免原上下文被取消
-			changeCtx := context.Background()
+			// 创建不随请求取消的上下文，保留操作人等上下文信息
+			changeCtx := context.WithoutCancel(ctx)
 			err := s.changeRecordService.RecordChange(
 				changeCtx,
 				"policies",
@@ -214,7 +594,7 @@ func (s *PolicyService) UpdatePolicy(ctx context.Context, policyID string, req *
 	}()
 
 	// 返回更新后的保单
-	return &model.PolicyResponse{Policy: updatedPolicy}, nil
+	return &model.PolicyResponse{Policy: updatedPolicy, Warnings: warnings}, nil
 }
 
 // DeletePolicy 删除保单
@@ -233,12 +613,99 @@ func (s *PolicyService) DeletePolicy(ctx context.Context, policyID, companyID st
 		return fmt.Errorf("无权删除该保单")
 	}
 
-	return s.policyRepo.DeletePolicy(ctx, policyID)
+	if err := s.policyRepo.DeletePolicy(ctx, policyID); err != nil {
+		return err
+	}
+
+	// 删除保单附件
+	if err := s.attachmentService.DeletePolicyAttachments(ctx, policyID); err != nil {
+		logger.Warnf("删除保单附件失败: %v", err)
+	}
+	return nil
 }
 
 // ListPolicies 获取保单列表
 func (s *PolicyService) ListPolicies(ctx context.Context, req *model.PolicyQueryRequest, companyID string) (*model.PolicyListResponse, error) {
-	return s.policyRepo.ListPolicies(ctx, req, companyID)
+	if err := s.applyCustomFieldFilters(ctx, req, companyID); err != nil {
+		return nil, err
+	}
+	result, err := s.policyRepo.ListPolicies(ctx, req, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 填充附件数量
+	policyIDs := make([]string, 0, len(result.List))
+	for _, item := range result.List {
+		policyIDs = append(policyIDs, item.PolicyID)
+	}
+	counts, err := s.attachmentService.CountAttachments(ctx, policyIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result.List {
+		result.List[i].AttachmentCount = counts[result.List[i].PolicyID]
+	}
+
+	return result, nil
+}
+
+// applyCustomFieldFilters 按字段定义将自定义字段筛选值转换为查询条件：文本模糊匹配，其他类型精确匹配
+func (s *PolicyService) applyCustomFieldFilters(ctx context.Context, req *model.PolicyQueryRequest, companyID string) error {
+	if len(req.CustomFields) == 0 {
+		return nil
+	}
+
+	schema, err := s.tableStructureService.NewCustomFieldSchema(ctx, model.PolicyTableName, companyID)
+	if err != nil {
+		return err
+	}
+
+	req.CustomFieldFilters = make(map[string]interface{}, len(req.CustomFields))
+	for name, raw := range req.CustomFields {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		field := schema.Field(name)
+		if field == nil {
+			return fmt.Errorf("未定义的自定义字段「%s」", name)
+		}
+
+		switch field.FieldType {
+		case model.FieldTypeString, model.FieldTypeFile:
+			req.CustomFieldFilters[name] = bson.M{"$regex": regexp.QuoteMeta(strings.TrimSpace(raw)), "$options": "i"}
+		default:
+			// 筛选值只做类型转换，不执行长度、范围等验证规则
+			filterField := *field
+			filterField.ValidationRules = nil
+			filterField.FieldLength = 0
+			value, err := ParseFieldValue(&filterField, raw)
+			if err != nil {
+				return err
+			}
+			req.CustomFieldFilters[name] = value
+		}
+	}
+
+	return nil
+}
+
+// GetPolicyValidationRules 获取保单字段验证规则（含公司自定义字段），与保存及导入时使用的规则一致
+func (s *PolicyService) GetPolicyValidationRules(ctx context.Context, companyID string) (map[string]validator.Rule, error) {
+	schema, err := s.tableStructureService.NewCustomFieldSchema(ctx, model.PolicyTableName, companyID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := policyCreateRules.With(schema.Rules()...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]validator.Rule)
+	for _, rule := range rules.Rules() {
+		result[rule.Field] = rule
+	}
+	return result, nil
 }
 
 // GetPolicyStatistics 获取保单统计
@@ -278,6 +745,227 @@ func (s *PolicyService) BatchUpdatePolicyStatus(ctx context.Context, req *model.
 	return s.policyRepo.BatchUpdatePolicyStatus(ctx, req.PolicyIDs, updates)
 }
 
+// ErrDictionaryRemapInvalid 字典值重映射请求无效：配置类型不支持或映射目标不在系统配置中
+var ErrDictionaryRemapInvalid = errors.New("字典值重映射参数错误")
+
+// RemapDictionaryValues 将保单中的历史字典值批量重映射为规范的配置键
+func (s *PolicyService) RemapDictionaryValues(ctx context.Context, req *model.PolicyDictionaryRemapRequest, userID, companyID, ipAddress, userAgent string) (*model.PolicyDictionaryRemapResponse, error) {
+	field, ok := model.PolicyDictionaryFields[req.ConfigType]
+	if !ok {
+		return nil, fmt.Errorf("%w，不支持的配置类型: %s", ErrDictionaryRemapInvalid, req.ConfigType)
+	}
+
+	resolver, err := s.systemConfigService.NewDictionaryResolver(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := s.policyRepo.CountDistinctFieldValues(ctx, companyID, field)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.PolicyDictionaryRemapResponse{
+		ConfigType: req.ConfigType,
+		DryRun:     req.DryRun,
+		Remapped:   []model.PolicyDictionaryRemapItem{},
+		Unresolved: []model.PolicyDictionaryRemapItem{},
+	}
+
+	for _, value := range values {
+		if strings.TrimSpace(value.FromValue) == "" {
+			continue
+		}
+
+		// 手工映射优先，目标必须是有效的配置项
+		target, resolved := "", false
+		if mapped, exists := req.Mappings[value.FromValue]; exists {
+			target, resolved = resolver.Resolve(req.ConfigType, mapped)
+			if !resolved {
+				return nil, fmt.Errorf("%w，映射目标「%s」不在系统配置中", ErrDictionaryRemapInvalid, mapped)
+			}
+		} else {
+			target, resolved = resolver.Resolve(req.ConfigType, value.FromValue)
+		}
+
+		if !resolved {
+			response.Unresolved = append(response.Unresolved, value)
+			continue
+		}
+		if target == value.FromValue {
+			continue
+		}
+
+		value.ToValue = target
+		response.Remapped = append(response.Remapped, value)
+
+		if !req.DryRun {
+			updated, err := s.remapPolicyFieldValue(ctx, companyID, field, value.FromValue, target, userID, ipAddress, userAgent)
+			if err != nil {
+				return nil, err
+			}
+			response.UpdatedCount += updated
+		}
+	}
+
+	if !req.DryRun {
+		logger.BusinessLog("保单管理", "字典值重映射", userID, fmt.Sprintf("公司: %s, 类型: %s, 更新保单: %d", companyID, req.ConfigType, response.UpdatedCount))
+	}
+
+	return response, nil
+}
+
+// remapPolicyFieldValue 逐张将保单字段的旧值替换为新值并记录变更
+func (s *PolicyService) remapPolicyFieldValue(ctx context.Context, companyID, field, fromValue, toValue, userID, ipAddress, userAgent string) (int64, error) {
+	policies, err := s.policyRepo.FindPoliciesByFieldValue(ctx, companyID, field, fromValue)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for i := range policies {
+		policy := &policies[i]
+		updates := bson.M{
+			field:        toValue,
+			"updated_by": userID,
+		}
+		if err := s.policyRepo.UpdatePolicy(ctx, policy.PolicyID, updates); err != nil {
+			return count, err
+		}
+		count++
+
+		if s.changeRecordService != nil {
+			updated := *policy
+			switch field {
+			case "hk_manager":
+				updated.HKManager = toValue
+			case "referral_branch":
+				updated.ReferralBranch = toValue
+			case "partner":
+				updated.Partner = toValue
+			}
+			if err := s.changeRecordService.RecordChange(ctx, "policies", policy.PolicyID, userID, companyID, "update", policy, &updated, "字典值重映射", ipAddress, userAgent); err != nil {
+				logger.Warnf("记录字典值重映射变更失败: %v", err)
+			}
+		}
+	}
+
+	return count, nil
+}
+
+// RecalculateReferralFees 按当前转介费规则重算保单的转介费率及预计转介费，返回变更前后差异
+func (s *PolicyService) RecalculateReferralFees(ctx context.Context, req *model.ReferralFeeRecalculateRequest, userID, companyID, ipAddress, userAgent string) (*model.ReferralFeeRecalculateResponse, error) {
+	var policies []model.Policy
+	var err error
+	if len(req.PolicyIDs) > 0 {
+		policies, err = s.policyRepo.GetPoliciesByIDs(ctx, req.PolicyIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, policy := range policies {
+			if policy.CompanyID != companyID {
+				return nil, fmt.Errorf("保单 %s 不属于当前公司", policy.PolicyID)
+			}
+		}
+	} else {
+		policies, err = s.policyRepo.FindPolicies(ctx, &model.PolicyQueryRequest{
+			Partner:            req.Partner,
+			ProductID:          req.ProductID,
+			PaymentMethod:      req.PaymentMethod,
+			EffectiveDateStart: req.EffectiveDateStart,
+			EffectiveDateEnd:   req.EffectiveDateEnd,
+		}, companyID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	calculator, err := s.referralFeeService.NewFeeCalculator(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.ReferralFeeRecalculateResponse{
+		TotalCount: len(policies),
+		Items:      []model.ReferralFeeRecalculateItem{},
+	}
+
+	for i := range policies {
+		policy := &policies[i]
+
+		// 未要求覆盖时保留手工填写的值
+		var rate, fee *float64
+		if !req.OverrideManual {
+			if policy.ManualReferralRate {
+				rate = &policy.ReferralRate
+			}
+			if policy.ManualExpectedFee {
+				fee = &policy.ExpectedFee
+			}
+			if rate != nil || fee != nil {
+				response.ManualCount++
+			}
+		}
+
+		result := calculator.Calculate(policyFeeInput(policy), rate, fee)
+		if result.RuleID == policy.FeeRuleID &&
+			result.ReferralRate == policy.ReferralRate &&
+			result.ExpectedFee == policy.ExpectedFee &&
+			result.ManualReferralRate == policy.ManualReferralRate &&
+			result.ManualExpectedFee == policy.ManualExpectedFee {
+			continue
+		}
+
+		response.ChangedCount++
+		response.Items = append(response.Items, model.ReferralFeeRecalculateItem{
+			PolicyID:        policy.PolicyID,
+			ProposalNumber:  policy.ProposalNumber,
+			CustomerNameCN:  policy.CustomerNameCN,
+			OldRuleID:       policy.FeeRuleID,
+			NewRuleID:       result.RuleID,
+			OldReferralRate: policy.ReferralRate,
+			NewReferralRate: result.ReferralRate,
+			OldExpectedFee:  policy.ExpectedFee,
+			NewExpectedFee:  result.ExpectedFee,
+		})
+
+		if req.DryRun {
+			continue
+		}
+
+		updates := bson.M{
+			"referral_rate":        result.ReferralRate,
+			"expected_fee":         result.ExpectedFee,
+			"fee_rule_id":          result.RuleID,
+			"manual_referral_rate": result.ManualReferralRate,
+			"manual_expected_fee":  result.ManualExpectedFee,
+			"updated_by":           userID,
+		}
+		if err := s.policyRepo.UpdatePolicy(ctx, policy.PolicyID, updates); err != nil {
+			return nil, err
+		}
+		response.UpdatedCount++
+
+		if s.changeRecordService != nil {
+			updated := *policy
+			updated.ReferralRate = result.ReferralRate
+			updated.ExpectedFee = result.ExpectedFee
+			updated.FeeRuleID = result.RuleID
+			updated.ManualReferralRate = result.ManualReferralRate
+			updated.ManualExpectedFee = result.ManualExpectedFee
+			if err := s.changeRecordService.RecordChange(ctx, "policies", policy.PolicyID, userID, companyID, "update", policy, &updated, "转介费重算", ipAddress, userAgent); err != nil {
+				logger.Warnf("记录转介费重算变更失败: %v", err)
+			}
+		}
+	}
+
+	if !req.DryRun {
+		logger.BusinessLog("保单管理", "转介费重算", userID, fmt.Sprintf("公司: %s, 重算保单: %d, 更新保单: %d", companyID, response.TotalCount, response.UpdatedCount))
+	}
+
+	return response, nil
+}
+
 // ImportPolicies 批量导入保单
 func (s *PolicyService) ImportPolicies(ctx context.Context, req *model.PolicyImportRequest, userID, companyID string) ([]string, []string, error) {
 	var successIDs []string
@@ -308,8 +996,23 @@ func (s *PolicyService) ImportPolicies(ctx context.Context, req *model.PolicyImp
 	return successIDs, errors, nil
 }
 
-// ExportPolicies 导出保单
+// ExportPolicies 导出保单，按查看人权限脱敏
 func (s *PolicyService) ExportPolicies(ctx context.Context, req *model.PolicyExportRequest, companyID string) ([]model.Policy, error) {
+	policies, err := s.loadExportPolicies(ctx, req, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]*model.Policy, len(policies))
+	for i := range policies {
+		refs[i] = &policies[i]
+	}
+	MaskPolicies(ctx, refs...)
+	return policies, nil
+}
+
+// loadExportPolicies 查询待导出的保单
+func (s *PolicyService) loadExportPolicies(ctx context.Context, req *model.PolicyExportRequest, companyID string) ([]model.Policy, error) {
 	if len(req.PolicyIDs) > 0 {
 		// 导出指定保单
 		policies, err := s.policyRepo.GetPoliciesByIDs(ctx, req.PolicyIDs)
@@ -346,27 +1049,33 @@ func (s *PolicyService) ExportPolicies(ctx context.Context, req *model.PolicyExp
 	}
 }
 
-// GeneratePolicyTemplate 生成保单导入模板
-func (s *PolicyService) GeneratePolicyTemplate(ctx context.Context, format string) ([]byte, string, error) {
+// GeneratePolicyTemplate 生成保单导入模板，公司自定义字段按排序号追加在固定列之后
+func (s *PolicyService) GeneratePolicyTemplate(ctx context.Context, format, companyID string) ([]byte, string, error) {
+	schema, err := s.tableStructureService.NewCustomFieldSchema(ctx, model.PolicyTableName, companyID)
+	if err != nil {
+		return nil, "", err
+	}
+	customFields := schema.VisibleFields()
+
 	headers := []string{
 		"序号", "账户号", "客户号", "客户中文名", "客户英文名", "投保单号",
 		"保单币种（USD/HKD/CNY）", "合作伙伴", "转介编号", "港分客户经理", "转介理财经理",
 		"转介分行", "转介支行", "转介日期", "签单后是否退保", "缴费日期", "生效日期",
 		"缴费方式（期缴、趸缴、预缴）", "缴费年期", "期缴期数", "实际缴纳保费", "AUM",
 		"是否已过冷静期", "是否支付佣金", "转介费率", "汇率", "预计转介费", "支付日期",
-		"是否员工", "承保公司", "保险产品名称", "产品类型", "备注说明",
+		"是否员工", "承保公司", "保险产品名称", "产品类型", "备注说明", "产品编号",
 	}
+	headers = append(headers, customFieldHeaders(customFields)...)
 
 	var fileData []byte
 	var fileName string
-	var err error
 
 	switch format {
 	case "xlsx":
-		fileData, err = s.generatePolicyExcelTemplate(headers)
+		fileData, err = s.generatePolicyExcelTemplate(headers, customFields)
 		fileName = fmt.Sprintf("policy_template_%s.xlsx", time.Now().Format("20060102150405"))
 	case "csv":
-		fileData, err = s.generatePolicyCSVTemplate(headers)
+		fileData, err = s.generatePolicyCSVTemplate(headers, customFields)
 		fileName = fmt.Sprintf("policy_template_%s.csv", time.Now().Format("20060102150405"))
 	default:
 		return nil, "", errors.New("不支持的文件格式")
@@ -377,21 +1086,27 @@ func (s *PolicyService) GeneratePolicyTemplate(ctx context.Context, format strin
 
 // ExportPoliciesToFile 导出保单为文件
 func (s *PolicyService) ExportPoliciesToFile(ctx context.Context, req *model.PolicyExportRequest, companyID, format string) ([]byte, string, error) {
-	// 获取保单数据
+	// 获取保单数据，对查看人隐藏的金额以占位符导出
 	policies, err := s.ExportPolicies(ctx, req, companyID)
 	if err != nil {
 		return nil, "", err
 	}
+	hidden := HiddenPolicyFields(ctx)
+	schema, err := s.tableStructureService.NewCustomFieldSchema(ctx, model.PolicyTableName, companyID)
+	if err != nil {
+		return nil, "", err
+	}
+	customFields := schema.VisibleFields()
 
 	var fileData []byte
 	var fileName string
 
 	switch format {
 	case "xlsx":
-		fileData, err = s.generatePolicyExcelData(policies)
+		fileData, err = s.generatePolicyExcelData(policies, customFields, hidden)
 		fileName = fmt.Sprintf("policies_export_%s.xlsx", time.Now().Format("20060102150405"))
 	case "csv":
-		fileData, err = s.generatePolicyCSVData(policies)
+		fileData, err = s.generatePolicyCSVData(policies, customFields, hidden)
 		fileName = fmt.Sprintf("policies_export_%s.csv", time.Now().Format("20060102150405"))
 	default:
 		return nil, "", errors.New("不支持的文件格式")
@@ -441,6 +1156,20 @@ func (s *PolicyService) processPolicyImport(ctx context.Context, file multipart.
 		Errors:     []model.PolicyImportError{},
 	}
 
+	// 整个文件共用一个字典解析器和转介费计算器，避免逐行查询配置
+	resolver, err := s.systemConfigService.NewDictionaryResolver(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	calculator, err := s.referralFeeService.NewFeeCalculator(ctx, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := s.tableStructureService.NewCustomFieldSchema(ctx, model.PolicyTableName, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
 	var policies []model.PolicyCreateRequest
 	successCount := 0
 
@@ -450,7 +1179,7 @@ func (s *PolicyService) processPolicyImport(ctx context.Context, file multipart.
 			rowNum = i + 2 // 考虑表头行
 		}
 
-		policy, errors := s.validateAndConvertPolicyRecord(record, rowNum)
+		policy, errors := s.validateAndConvertPolicyRecord(record, rowNum, schema)
 		if len(errors) > 0 {
 			response.Errors = append(response.Errors, model.PolicyImportError{
 				Row:    rowNum,
@@ -460,7 +1189,48 @@ func (s *PolicyService) processPolicyImport(ctx context.Context, file multipart.
 			continue
 		}
 
-		if !preview {
+		// 未填写产品编号时按承保公司和产品名称关联产品目录
+		if err := s.linkImportProduct(ctx, policy, req.CompanyID); err != nil {
+			response.Errors = append(response.Errors, model.PolicyImportError{
+				Row:    rowNum,
+				Errors: []string{err.Error()},
+				Data:   record,
+			})
+			continue
+		}
+
+		if preview {
+			// 预览时同样校验产品条款
+			if _, err := s.applyPolicyProduct(ctx, policy, req.CompanyID); err != nil {
+				response.Errors = append(response.Errors, model.PolicyImportError{
+					Row:    rowNum,
+					Errors: []string{err.Error()},
+					Data:   record,
+				})
+				continue
+			}
+
+			// 预览时同样执行字典校验，提前暴露未知值
+			warnings, err := resolver.Normalize(policyDictionaryValues(&policy.HKManager, &policy.ReferralBranch, &policy.Partner))
+			if err != nil {
+				response.Errors = append(response.Errors, model.PolicyImportError{
+					Row:    rowNum,
+					Errors: []string{err.Error()},
+					Data:   record,
+				})
+				continue
+			}
+			if len(warnings) > 0 {
+				response.Warnings = append(response.Warnings, model.PolicyImportError{
+					Row:    rowNum,
+					Errors: warnings,
+					Data:   record,
+				})
+			}
+
+			// 预览数据展示按规则计算后的转介费
+			applyReferralFee(calculator, policy)
+		} else {
 			// 实际导入 - 检查重复
 			isDuplicate, err := s.policyRepo.CheckDuplicatePolicy(ctx, policy.AccountNumber, policy.ProposalNumber, req.CompanyID, "")
 			if err != nil {
@@ -486,7 +1256,7 @@ func (s *PolicyService) processPolicyImport(ctx context.Context, file multipart.
 			}
 
 			// 创建保单
-			_, err = s.CreatePolicy(ctx, policy, req.UserID, req.CompanyID)
+			created, err := s.createPolicy(ctx, policy, req.UserID, req.CompanyID, resolver, calculator, schema)
 			if err != nil {
 				response.Errors = append(response.Errors, model.PolicyImportError{
 					Row:    rowNum,
@@ -495,6 +1265,13 @@ func (s *PolicyService) processPolicyImport(ctx context.Context, file multipart.
 				})
 				continue
 			}
+			if len(created.Warnings) > 0 {
+				response.Warnings = append(response.Warnings, model.PolicyImportError{
+					Row:    rowNum,
+					Errors: created.Warnings,
+					Data:   record,
+				})
+			}
 		}
 
 		policies = append(policies, *policy)
@@ -516,9 +1293,27 @@ func (s *PolicyService) processPolicyImport(ctx context.Context, file multipart.
 	return response, nil
 }
 
+// linkImportProduct 导入行未填写产品编号时，按承保公司和产品名称匹配启用中的目录产品
+func (s *PolicyService) linkImportProduct(ctx context.Context, policy *model.PolicyCreateRequest, companyID string) error {
+	if policy.ProductID != "" {
+		return nil
+	}
+
+	product, err := s.productService.FindPolicyProduct(ctx, policy.InsuranceCompany, policy.ProductName, companyID)
+	if err != nil {
+		return err
+	}
+	// 未收录或已禁用的产品保留原有名称，不强制关联
+	if product != nil && product.Status != "disable" {
+		policy.ProductID = product.ProductID
+	}
+
+	return nil
+}
+
 // 辅助方法实现
 
-func (s *PolicyService) generatePolicyExcelTemplate(headers []string) ([]byte, error) {
+func (s *PolicyService) generatePolicyExcelTemplate(headers []string, customFields []model.FieldDefinition) ([]byte, error) {
 	f := excelize.NewFile()
 	sheetName := "Sheet1"
 
@@ -541,8 +1336,9 @@ func (s *PolicyService) generatePolicyExcelTemplate(headers []string) ([]byte, e
 		"分行A", "支行A", "2024-01-15", "否", "2024-01-20", "2024-02-01",
 		"期缴", "10", "12", "10000.00", "50000.00",
 		"是", "是", "2.50", "6.9000", "2500.00", "2024-02-15",
-		"否", "保险公司A", "产品A", "寿险", "备注信息",
+		"否", "保险公司A", "产品A", "寿险", "备注信息", "",
 	}
+	exampleData = append(exampleData, customFieldExamples(customFields)...)
 
 	for i, data := range exampleData {
 		if i < len(headers) {
@@ -559,7 +1355,7 @@ func (s *PolicyService) generatePolicyExcelTemplate(headers []string) ([]byte, e
 	return buffer.Bytes(), nil
 }
 
-func (s *PolicyService) generatePolicyCSVTemplate(headers []string) ([]byte, error) {
+func (s *PolicyService) generatePolicyCSVTemplate(headers []string, customFields []model.FieldDefinition) ([]byte, error) {
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
 
@@ -575,8 +1371,9 @@ func (s *PolicyService) generatePolicyCSVTemplate(headers []string) ([]byte, err
 		"分行A", "支行A", "2024-01-15", "否", "2024-01-20", "2024-02-01",
 		"期缴", "10", "12", "10000.00", "50000.00",
 		"是", "是", "2.50", "6.9000", "2500.00", "2024-02-15",
-		"否", "保险公司A", "产品A", "寿险", "备注信息",
+		"否", "保险公司A", "产品A", "寿险", "备注信息", "",
 	}
+	exampleData = append(exampleData, customFieldExamples(customFields)...)
 
 	if err := writer.Write(exampleData); err != nil {
 		return nil, err
@@ -586,7 +1383,7 @@ func (s *PolicyService) generatePolicyCSVTemplate(headers []string) ([]byte, err
 	return buf.Bytes(), nil
 }
 
-func (s *PolicyService) generatePolicyExcelData(policies []model.Policy) ([]byte, error) {
+func (s *PolicyService) generatePolicyExcelData(policies []model.Policy, customFields []model.FieldDefinition, hidden map[string]bool) ([]byte, error) {
 	f := excelize.NewFile()
 	sheetName := "Sheet1"
 
@@ -597,8 +1394,9 @@ func (s *PolicyService) generatePolicyExcelData(policies []model.Policy) ([]byte
 		"转介分行", "转介支行", "转介日期", "签单后是否退保", "缴费日期", "生效日期",
 		"缴费方式（期缴、趸缴、预缴）", "缴费年期", "期缴期数", "实际缴纳保费", "AUM",
 		"是否已过冷静期", "是否支付佣金", "转介费率", "汇率", "预计转介费", "支付日期",
-		"是否员工", "承保公司", "保险产品名称", "产品类型", "备注说明",
+		"是否员工", "承保公司", "保险产品名称", "产品类型", "备注说明", "产品编号",
 	}
+	headers = append(headers, customFieldHeaders(customFields)...)
 
 	// 写入表头
 	for i, header := range headers {
@@ -630,19 +1428,23 @@ func (s *PolicyService) generatePolicyExcelData(policies []model.Policy) ([]byte
 			policy.PaymentMethod,
 			policy.PaymentYears,
 			policy.PaymentPeriods,
-			policy.ActualPremium,
-			policy.AUM,
+			exportAmountCell(hidden, "actual_premium", policy.ActualPremium),
+			exportAmountCell(hidden, "aum", policy.AUM),
 			s.formatBool(policy.PastCoolingPeriod),
 			s.formatBool(policy.IsPaidCommission),
 			policy.ReferralRate,
 			policy.ExchangeRate,
-			policy.ExpectedFee,
+			exportAmountCell(hidden, "expected_fee", policy.ExpectedFee),
 			s.formatDate(policy.PaymentPayDate),
 			s.formatBool(policy.IsEmployee),
 			policy.InsuranceCompany,
 			policy.ProductName,
 			policy.ProductType,
 			policy.Remark,
+			policy.ProductID,
+		}
+		for _, value := range customFieldValues(policy, customFields) {
+			data = append(data, value)
 		}
 
 		for j, value := range data {
@@ -665,7 +1467,7 @@ func (s *PolicyService) generatePolicyExcelData(policies []model.Policy) ([]byte
 	return buffer.Bytes(), nil
 }
 
-func (s *PolicyService) generatePolicyCSVData(policies []model.Policy) ([]byte, error) {
+func (s *PolicyService) generatePolicyCSVData(policies []model.Policy, customFields []model.FieldDefinition, hidden map[string]bool) ([]byte, error) {
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
 
@@ -676,8 +1478,9 @@ func (s *PolicyService) generatePolicyCSVData(policies []model.Policy) ([]byte,
 		"转介分行", "转介支行", "转介日期", "签单后是否退保", "缴费日期", "生效日期",
 		"缴费方式（期缴、趸缴、预缴）", "缴费年期", "期缴期数", "实际缴纳保费", "AUM",
 		"是否已过冷静期", "是否支付佣金", "转介费率", "汇率", "预计转介费", "支付日期",
-		"是否员工", "承保公司", "保险产品名称", "产品类型", "备注说明",
+		"是否员工", "承保公司", "保险产品名称", "产品类型", "备注说明", "产品编号",
 	}
+	headers = append(headers, customFieldHeaders(customFields)...)
 
 	if err := writer.Write(headers); err != nil {
 		return nil, err
@@ -706,20 +1509,22 @@ func (s *PolicyService) generatePolicyCSVData(policies []model.Policy) ([]byte,
 			policy.PaymentMethod,
 			fmt.Sprintf("%d", policy.PaymentYears),
 			fmt.Sprintf("%d", policy.PaymentPeriods),
-			fmt.Sprintf("%.2f", policy.ActualPremium),
-			fmt.Sprintf("%.2f", policy.AUM),
+			exportAmountText(hidden, "actual_premium", policy.ActualPremium),
+			exportAmountText(hidden, "aum", policy.AUM),
 			s.formatBool(policy.PastCoolingPeriod),
 			s.formatBool(policy.IsPaidCommission),
 			fmt.Sprintf("%.2f", policy.ReferralRate),
 			fmt.Sprintf("%.4f", policy.ExchangeRate),
-			fmt.Sprintf("%.2f", policy.ExpectedFee),
+			exportAmountText(hidden, "expected_fee", policy.ExpectedFee),
 			s.formatDate(policy.PaymentPayDate),
 			s.formatBool(policy.IsEmployee),
 			policy.InsuranceCompany,
 			policy.ProductName,
 			policy.ProductType,
 			policy.Remark,
+			policy.ProductID,
 		}
+		record = append(record, customFieldValues(policy, customFields)...)
 
 		if err := writer.Write(record); err != nil {
 			return nil, err
@@ -750,142 +1555,99 @@ func (s *PolicyService) parsePolicyCSVFile(file multipart.File) ([][]string, err
 	return reader.ReadAll()
 }
 
-func (s *PolicyService) validateAndConvertPolicyRecord(record []string, rowNum int) (*model.PolicyCreateRequest, []string) {
-	var errors []string
-
-	// 自动补充缺失的列，确保至少有33列
-	for len(record) < 33 {
+// validateAndConvertPolicyRecord 按保单字段规则转换并校验导入行，与API使用相同的规则和提示
+func (s *PolicyService) validateAndConvertPolicyRecord(record []string, rowNum int, schema *CustomFieldSchema) (*model.PolicyCreateRequest, []string) {
+	// 自动补充缺失的列，确保至少包含固定列及自定义字段列
+	customFields := schema.VisibleFields()
+	for len(record) < len(policyImportColumns)+len(customFields) {
 		record = append(record, "")
 	}
 
-	// 只验证必填字段：投保单号
-	if strings.TrimSpace(record[5]) == "" { // 投保单号
-		errors = append(errors, "投保单号不能为空")
-	}
-
-	// 转换数据类型（只转换有值的字段）
-	paymentYears := 0
-	if strings.TrimSpace(record[18]) != "" {
-		if val, err := strconv.Atoi(strings.TrimSpace(record[18])); err != nil {
-			errors = append(errors, "缴费年期格式错误")
-		} else {
-			paymentYears = val
+	// 固定字段按规则转换类型
+	var messages []string
+	raw := make(map[string]string, len(policyImportColumns))
+	for i, name := range policyImportColumns {
+		if name != "" {
+			raw[name] = record[i]
 		}
 	}
-
-	paymentPeriods := 0
-	if strings.TrimSpace(record[19]) != "" {
-		if val, err := strconv.Atoi(strings.TrimSpace(record[19])); err != nil {
-			errors = append(errors, "期缴期数格式错误")
-		} else {
-			paymentPeriods = val
-		}
+	values, err := policyCreateRules.Parse(raw)
+	if err != nil {
+		messages = append(messages, validationMessages(err)...)
 	}
 
-	actualPremium := 0.0
-	if strings.TrimSpace(record[20]) != "" {
-		if val, err := strconv.ParseFloat(strings.TrimSpace(record[20]), 64); err != nil {
-			errors = append(errors, "实际缴纳保费格式错误")
-		} else {
-			actualPremium = val
+	// 自定义字段（固定列之后，按排序号排列）
+	customRaw := make(map[string]interface{})
+	for i, field := range customFields {
+		if value := strings.TrimSpace(record[len(policyImportColumns)+i]); value != "" {
+			customRaw[field.FieldName] = value
 		}
 	}
-
-	aum := 0.0
-	if strings.TrimSpace(record[21]) != "" {
-		if val, err := strconv.ParseFloat(strings.TrimSpace(record[21]), 64); err != nil {
-			errors = append(errors, "AUM格式错误")
-		} else {
-			aum = val
-		}
+	customValues, err := schema.Normalize(customRaw)
+	if err != nil {
+		messages = append(messages, validationMessages(err)...)
 	}
 
-	referralRate := 0.0
-	if strings.TrimSpace(record[24]) != "" {
-		if val, err := strconv.ParseFloat(strings.TrimSpace(record[24]), 64); err != nil {
-			errors = append(errors, "转介费率格式错误")
-		} else {
-			referralRate = val
-		}
+	if len(messages) > 0 {
+		return nil, messages
 	}
 
-	exchangeRate := 0.0
-	if strings.TrimSpace(record[25]) != "" {
-		if val, err := strconv.ParseFloat(strings.TrimSpace(record[25]), 64); err != nil {
-			errors = append(errors, "汇率格式错误")
-		} else {
-			exchangeRate = val
-		}
+	// 创建保单请求对象
+	policy := &model.PolicyCreateRequest{}
+	data, err := json.Marshal(values)
+	if err == nil {
+		err = json.Unmarshal(data, policy)
+	}
+	if err != nil {
+		return nil, []string{fmt.Sprintf("第%d行数据转换失败: %v", rowNum, err)}
 	}
+	policy.ExchangeRate = math.Round(policy.ExchangeRate*10000) / 10000 // 保留4位小数
+	policy.CustomFields = customValues
 
-	expectedFee := 0.0
-	if strings.TrimSpace(record[26]) != "" {
-		if val, err := strconv.ParseFloat(strings.TrimSpace(record[26]), 64); err != nil {
-			errors = append(errors, "预计转介费格式错误")
-		} else {
-			expectedFee = val
-		}
+	// 导入文件中填写了转介费率或预计转介费的视为手工值
+	policy.ManualRate = strings.TrimSpace(raw["referral_rate"]) != ""
+	policy.ManualFee = strings.TrimSpace(raw["expected_fee"]) != ""
+
+	if err := validatePolicyCreate(policy, customValues, schema); err != nil {
+		return nil, validationMessages(err)
 	}
 
-	if len(errors) > 0 {
-		return nil, errors
+	return policy, nil
+}
+
+// customFieldHeaders 自定义字段列表头（显示名称）
+func customFieldHeaders(fields []model.FieldDefinition) []string {
+	headers := make([]string, 0, len(fields))
+	for _, field := range fields {
+		header := field.DisplayName
+		if field.FieldType == model.FieldTypeEnum {
+			header = fmt.Sprintf("%s（%s）", header, strings.Join(field.EnumOptions, "/"))
+		}
+		headers = append(headers, header)
 	}
+	return headers
+}
 
-	// 创建保单请求对象
-	policy := &model.PolicyCreateRequest{
-		AccountNumber:     strings.TrimSpace(record[1]),
-		CustomerNumber:    strings.TrimSpace(record[2]),
-		CustomerNameCN:    strings.TrimSpace(record[3]),
-		CustomerNameEN:    strings.TrimSpace(record[4]),
-		ProposalNumber:    strings.TrimSpace(record[5]),
-		PolicyCurrency:    strings.TrimSpace(record[6]),
-		Partner:           strings.TrimSpace(record[7]),
-		ReferralCode:      strings.TrimSpace(record[8]),
-		HKManager:         strings.TrimSpace(record[9]),
-		ReferralPM:        strings.TrimSpace(record[10]),
-		ReferralBranch:    strings.TrimSpace(record[11]),
-		ReferralSubBranch: strings.TrimSpace(record[12]),
-		PaymentMethod:     strings.TrimSpace(record[17]),
-		PaymentYears:      paymentYears,
-		PaymentPeriods:    paymentPeriods,
-		ActualPremium:     actualPremium,
-		AUM:               aum,
-		ReferralRate:      referralRate,
-		ExchangeRate:      math.Round(exchangeRate*10000) / 10000, // 保留4位小数
-		ExpectedFee:       expectedFee,
-		IsSurrendered:     strings.TrimSpace(record[14]) == "是" || strings.TrimSpace(record[14]) == "true",
-		PastCoolingPeriod: strings.TrimSpace(record[22]) == "是" || strings.TrimSpace(record[22]) == "true",
-		IsPaidCommission:  strings.TrimSpace(record[23]) == "是" || strings.TrimSpace(record[23]) == "true",
-		IsEmployee:        strings.TrimSpace(record[28]) == "是" || strings.TrimSpace(record[28]) == "true",
-		InsuranceCompany:  strings.TrimSpace(record[29]),
-		ProductName:       strings.TrimSpace(record[30]),
-		ProductType:       strings.TrimSpace(record[31]),
-		Remark:            strings.TrimSpace(record[32]),
-	}
-
-	// 处理日期字段（只处理有值的字段）
-	if strings.TrimSpace(record[13]) != "" {
-		if date, err := time.Parse("2006-01-02", strings.TrimSpace(record[13])); err == nil {
-			policy.ReferralDate = &date
-		}
-	}
-	if strings.TrimSpace(record[15]) != "" {
-		if date, err := time.Parse("2006-01-02", strings.TrimSpace(record[15])); err == nil {
-			policy.PaymentDate = &date
-		}
-	}
-	if strings.TrimSpace(record[16]) != "" {
-		if date, err := time.Parse("2006-01-02", strings.TrimSpace(record[16])); err == nil {
-			policy.EffectiveDate = &date
-		}
-	}
-	if strings.TrimSpace(record[27]) != "" {
-		if date, err := time.Parse("2006-01-02", strings.TrimSpace(record[27])); err == nil {
-			policy.PaymentPayDate = &date
+// customFieldExamples 自定义字段示例值，优先使用默认值
+func customFieldExamples(fields []model.FieldDefinition) []string {
+	examples := make([]string, 0, len(fields))
+	for _, field := range fields {
+		example := field.DefaultValue
+		if example == "" && field.FieldType == model.FieldTypeEnum && len(field.EnumOptions) > 0 {
+			example = field.EnumOptions[0]
 		}
+		examples = append(examples, example)
 	}
+	return examples
+}
 
-	return policy, nil
+// customFieldValues 保单的自定义字段导出值
+func customFieldValues(policy model.Policy, fields []model.FieldDefinition) []string {
+	values := make([]string, 0, len(fields))
+	for _, field := range fields {
+		values = append(values, FormatFieldValue(policy.CustomFields[field.FieldName]))
+	}
+	return values
 }
 
 // 辅助函数
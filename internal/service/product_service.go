@@ -0,0 +1,757 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"YufungProject/internal/model"
+	"YufungProject/internal/repository"
+	"YufungProject/pkg/logger"
+)
+
+// productImportHeaders 产品导入导出表头
+var productImportHeaders = []string{
+	"产品编码", "承保公司", "产品名称", "产品类型", "可选币种（USD/HKD/CNY）",
+	"缴费方式（期缴/趸缴/预缴）", "缴费年期（如5/10/20）", "默认转介费率", "冷静期天数",
+	"上架日期", "下架日期", "状态（启用/禁用）", "备注",
+}
+
+type ProductService struct {
+	productRepo *repository.ProductRepository
+}
+
+func NewProductService(productRepo *repository.ProductRepository) *ProductService {
+	return &ProductService{
+		productRepo: productRepo,
+	}
+}
+
+// ==========================
+// 承保公司
+// ==========================
+
+// CreateInsurer 创建承保公司
+func (s *ProductService) CreateInsurer(ctx context.Context, req *model.InsurerCreateRequest, userID, companyID string) (*model.Insurer, error) {
+	insurerName := strings.TrimSpace(req.InsurerName)
+	exists, err := s.productRepo.CheckInsurerNameExists(ctx, insurerName, companyID, "")
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("承保公司名称已存在")
+	}
+
+	if req.Status == "" {
+		req.Status = "enable"
+	}
+
+	insurer := &model.Insurer{
+		InsurerCode: strings.TrimSpace(req.InsurerCode),
+		InsurerName: insurerName,
+		NameEN:      strings.TrimSpace(req.NameEN),
+		CompanyID:   companyID,
+		Status:      req.Status,
+		Remark:      req.Remark,
+		CreatedBy:   userID,
+		UpdatedBy:   userID,
+	}
+
+	if err := s.productRepo.CreateInsurer(ctx, insurer); err != nil {
+		return nil, err
+	}
+
+	return insurer, nil
+}
+
+// UpdateInsurer 更新承保公司，改名时同步产品和保单中的冗余名称
+func (s *ProductService) UpdateInsurer(ctx context.Context, insurerID string, req *model.InsurerUpdateRequest, userID, companyID string) (*model.Insurer, error) {
+	insurer, err := s.getInsurer(ctx, insurerID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := bson.M{"updated_by": userID}
+	renamed := false
+	if name := strings.TrimSpace(req.InsurerName); name != "" && name != insurer.InsurerName {
+		exists, err := s.productRepo.CheckInsurerNameExists(ctx, name, companyID, insurerID)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, fmt.Errorf("承保公司名称已存在")
+		}
+		updates["insurer_name"] = name
+		renamed = true
+	}
+	if req.InsurerCode != "" {
+		updates["insurer_code"] = strings.TrimSpace(req.InsurerCode)
+	}
+	if req.NameEN != "" {
+		updates["name_en"] = strings.TrimSpace(req.NameEN)
+	}
+	if req.Status != "" {
+		updates["status"] = req.Status
+	}
+	if req.Remark != "" {
+		updates["remark"] = req.Remark
+	}
+
+	if err := s.productRepo.UpdateInsurer(ctx, insurerID, updates); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.productRepo.GetInsurerByID(ctx, insurerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if renamed {
+		if err := s.productRepo.UpdateInsurerNameOnProducts(ctx, insurerID, updated.InsurerName); err != nil {
+			return nil, fmt.Errorf("同步产品承保公司名称失败: %v", err)
+		}
+		if err := s.productRepo.SyncPolicyInsurerName(ctx, insurerID, updated.InsurerName); err != nil {
+			return nil, fmt.Errorf("同步保单承保公司名称失败: %v", err)
+		}
+	}
+
+	return updated, nil
+}
+
+// DeleteInsurer 删除承保公司，存在产品时不允许删除
+func (s *ProductService) DeleteInsurer(ctx context.Context, insurerID, companyID string) error {
+	if _, err := s.getInsurer(ctx, insurerID, companyID); err != nil {
+		return err
+	}
+
+	count, err := s.productRepo.CountProductsByInsurer(ctx, insurerID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("该承保公司下存在%d个产品，无法删除", count)
+	}
+
+	return s.productRepo.DeleteInsurer(ctx, insurerID)
+}
+
+// ListInsurers 获取承保公司列表
+func (s *ProductService) ListInsurers(ctx context.Context, companyID, status string) ([]model.Insurer, error) {
+	return s.productRepo.ListInsurers(ctx, companyID, status)
+}
+
+// getInsurer 获取承保公司并校验所属公司
+func (s *ProductService) getInsurer(ctx context.Context, insurerID, companyID string) (*model.Insurer, error) {
+	insurer, err := s.productRepo.GetInsurerByID(ctx, insurerID)
+	if err != nil {
+		return nil, err
+	}
+	if insurer == nil || insurer.CompanyID != companyID {
+		return nil, fmt.Errorf("承保公司不存在")
+	}
+	return insurer, nil
+}
+
+// ==========================
+// 保险产品
+// ==========================
+
+// CreateProduct 创建保险产品
+func (s *ProductService) CreateProduct(ctx context.Context, req *model.ProductCreateRequest, userID, companyID string) (*model.Product, error) {
+	insurer, err := s.getInsurer(ctx, req.InsurerID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkProductEffectiveRange(req.EffectiveFrom, req.EffectiveTo); err != nil {
+		return nil, err
+	}
+
+	productName := strings.TrimSpace(req.ProductName)
+	exists, err := s.productRepo.CheckProductNameExists(ctx, insurer.InsurerID, productName, companyID, "")
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("该承保公司下产品名称已存在")
+	}
+
+	if req.Status == "" {
+		req.Status = "enable"
+	}
+
+	product := &model.Product{
+		ProductCode:         strings.TrimSpace(req.ProductCode),
+		ProductName:         productName,
+		ProductType:         strings.TrimSpace(req.ProductType),
+		InsurerID:           insurer.InsurerID,
+		InsurerName:         insurer.InsurerName,
+		Currencies:          req.Currencies,
+		PaymentMethods:      req.PaymentMethods,
+		PaymentYears:        req.PaymentYears,
+		DefaultReferralRate: req.DefaultReferralRate,
+		CoolingOffDays:      req.CoolingOffDays,
+		EffectiveFrom:       req.EffectiveFrom,
+		EffectiveTo:         req.EffectiveTo,
+		CompanyID:           companyID,
+		Status:              req.Status,
+		Remark:              req.Remark,
+		CreatedBy:           userID,
+		UpdatedBy:           userID,
+	}
+
+	if err := s.productRepo.CreateProduct(ctx, product); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// GetProduct 获取保险产品详情
+func (s *ProductService) GetProduct(ctx context.Context, productID, companyID string) (*model.Product, error) {
+	product, err := s.productRepo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil || product.CompanyID != companyID {
+		return nil, fmt.Errorf("产品不存在")
+	}
+	return product, nil
+}
+
+// UpdateProduct 更新保险产品，名称、类型或承保公司变更时同步保单中的冗余名称
+func (s *ProductService) UpdateProduct(ctx context.Context, productID string, req *model.ProductUpdateRequest, userID, companyID string) (*model.Product, error) {
+	product, err := s.GetProduct(ctx, productID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := bson.M{"updated_by": userID}
+	namesChanged := false
+
+	insurerID := product.InsurerID
+	if req.InsurerID != "" && req.InsurerID != product.InsurerID {
+		insurer, err := s.getInsurer(ctx, req.InsurerID, companyID)
+		if err != nil {
+			return nil, err
+		}
+		insurerID = insurer.InsurerID
+		updates["insurer_id"] = insurer.InsurerID
+		updates["insurer_name"] = insurer.InsurerName
+		namesChanged = true
+	}
+
+	productName := product.ProductName
+	if name := strings.TrimSpace(req.ProductName); name != "" && name != product.ProductName {
+		productName = name
+		updates["product_name"] = name
+		namesChanged = true
+	}
+	if namesChanged {
+		exists, err := s.productRepo.CheckProductNameExists(ctx, insurerID, productName, companyID, productID)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, fmt.Errorf("该承保公司下产品名称已存在")
+		}
+	}
+
+	if productType := strings.TrimSpace(req.ProductType); productType != "" && productType != product.ProductType {
+		updates["product_type"] = productType
+		namesChanged = true
+	}
+	if req.ProductCode != "" {
+		updates["product_code"] = strings.TrimSpace(req.ProductCode)
+	}
+	if req.Currencies != nil {
+		updates["currencies"] = req.Currencies
+	}
+	if req.PaymentMethods != nil {
+		updates["payment_methods"] = req.PaymentMethods
+	}
+	if req.PaymentYears != nil {
+		updates["payment_years"] = req.PaymentYears
+	}
+	if req.DefaultReferralRate != nil {
+		updates["default_referral_rate"] = *req.DefaultReferralRate
+	}
+	if req.CoolingOffDays != nil {
+		updates["cooling_off_days"] = *req.CoolingOffDays
+	}
+
+	effectiveFrom, effectiveTo := product.EffectiveFrom, product.EffectiveTo
+	if req.EffectiveFrom != nil {
+		effectiveFrom = req.EffectiveFrom
+		updates["effective_from"] = req.EffectiveFrom
+	}
+	if req.EffectiveTo != nil {
+		effectiveTo = req.EffectiveTo
+		updates["effective_to"] = req.EffectiveTo
+	}
+	if err := checkProductEffectiveRange(effectiveFrom, effectiveTo); err != nil {
+		return nil, err
+	}
+
+	if req.Status != "" {
+		updates["status"] = req.Status
+	}
+	if req.Remark != "" {
+		updates["remark"] = req.Remark
+	}
+
+	if err := s.productRepo.UpdateProduct(ctx, productID, updates); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.productRepo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if namesChanged {
+		count, err := s.productRepo.SyncPolicyProductNames(ctx, updated)
+		if err != nil {
+			return nil, fmt.Errorf("同步保单产品名称失败: %v", err)
+		}
+		logger.BusinessLog("产品管理", "同步保单产品名称", userID, fmt.Sprintf("产品: %s, 更新保单: %d", productID, count))
+	}
+
+	return updated, nil
+}
+
+// DeleteProduct 删除保险产品，已被保单引用的产品不允许删除（可改为禁用）
+func (s *ProductService) DeleteProduct(ctx context.Context, productID, companyID string) error {
+	if _, err := s.GetProduct(ctx, productID, companyID); err != nil {
+		return err
+	}
+
+	count, err := s.productRepo.CountPoliciesByProduct(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("该产品已被%d张保单引用，无法删除，请改为禁用", count)
+	}
+
+	return s.productRepo.DeleteProduct(ctx, productID)
+}
+
+// ListProducts 获取保险产品列表
+func (s *ProductService) ListProducts(ctx context.Context, req *model.ProductQueryRequest, companyID string) (*model.ProductListResponse, error) {
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	return s.productRepo.ListProducts(ctx, req, companyID)
+}
+
+// ==========================
+// 保单引用产品
+// ==========================
+
+// GetPolicyProduct 获取保单引用的产品，产品须属于当前公司且处于启用状态
+func (s *ProductService) GetPolicyProduct(ctx context.Context, productID, companyID string) (*model.Product, error) {
+	product, err := s.GetProduct(ctx, productID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if product.Status == "disable" {
+		return nil, fmt.Errorf("产品「%s」已禁用", product.ProductName)
+	}
+	return product, nil
+}
+
+// FindPolicyProduct 按承保公司和产品名称在产品目录中查找产品，未找到时返回nil
+func (s *ProductService) FindPolicyProduct(ctx context.Context, insurerName, productName, companyID string) (*model.Product, error) {
+	insurerName = strings.TrimSpace(insurerName)
+	productName = strings.TrimSpace(productName)
+	if insurerName == "" || productName == "" {
+		return nil, nil
+	}
+
+	insurer, err := s.productRepo.GetInsurerByName(ctx, insurerName, companyID)
+	if err != nil || insurer == nil {
+		return nil, err
+	}
+
+	return s.productRepo.GetProductByName(ctx, insurer.InsurerID, productName, companyID)
+}
+
+// CheckPolicyTerms 校验保单的币种、缴费方式、缴费年期及生效日期是否符合产品规则
+func (s *ProductService) CheckPolicyTerms(product *model.Product, currency, paymentMethod string, paymentYears int, effectiveDate *time.Time) error {
+	if currency != "" && len(product.Currencies) > 0 && !containsString(product.Currencies, currency) {
+		return fmt.Errorf("产品「%s」不支持币种%s，可选币种：%s", product.ProductName, currency, strings.Join(product.Currencies, "/"))
+	}
+
+	if paymentMethod != "" && len(product.PaymentMethods) > 0 && !containsString(product.PaymentMethods, paymentMethod) {
+		return fmt.Errorf("产品「%s」不支持缴费方式%s，允许的缴费方式：%s", product.ProductName, paymentMethod, strings.Join(product.PaymentMethods, "/"))
+	}
+
+	if paymentYears > 0 && len(product.PaymentYears) > 0 && !containsInt(product.PaymentYears, paymentYears) {
+		return fmt.Errorf("产品「%s」不支持%d年缴费年期，允许的缴费年期：%s", product.ProductName, paymentYears, joinInts(product.PaymentYears, "/"))
+	}
+
+	if effectiveDate != nil {
+		if product.EffectiveFrom != nil && effectiveDate.Before(*product.EffectiveFrom) {
+			return fmt.Errorf("保单生效日期早于产品「%s」上架日期%s", product.ProductName, product.EffectiveFrom.Format("2006-01-02"))
+		}
+		if product.EffectiveTo != nil && effectiveDate.After(*product.EffectiveTo) {
+			return fmt.Errorf("保单生效日期晚于产品「%s」下架日期%s", product.ProductName, product.EffectiveTo.Format("2006-01-02"))
+		}
+	}
+
+	return nil
+}
+
+// ==========================
+// 导入导出
+// ==========================
+
+// GenerateProductTemplate 生成产品导入模板
+func (s *ProductService) GenerateProductTemplate(format string) ([]byte, string, error) {
+	example := []string{
+		"PRD001", "保险公司A", "产品A", "寿险", "USD/HKD",
+		"期缴/趸缴", "5/10", "2.50", "21",
+		"2024-01-01", "", "启用", "备注信息",
+	}
+
+	fileData, err := writeProductRows(format, [][]string{example})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return fileData, fmt.Sprintf("product_template_%s.%s", time.Now().Format("20060102150405"), format), nil
+}
+
+// ExportProductsToFile 导出产品目录
+func (s *ProductService) ExportProductsToFile(ctx context.Context, req *model.ProductQueryRequest, companyID, format string) ([]byte, string, error) {
+	req.Page, req.PageSize = 0, 0
+	result, err := s.productRepo.ListProducts(ctx, req, companyID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows := make([][]string, 0, len(result.List))
+	for _, product := range result.List {
+		status := "启用"
+		if product.Status == "disable" {
+			status = "禁用"
+		}
+		rows = append(rows, []string{
+			product.ProductCode,
+			product.InsurerName,
+			product.ProductName,
+			product.ProductType,
+			strings.Join(product.Currencies, "/"),
+			strings.Join(product.PaymentMethods, "/"),
+			joinInts(product.PaymentYears, "/"),
+			strconv.FormatFloat(product.DefaultReferralRate, 'f', 2, 64),
+			strconv.Itoa(product.CoolingOffDays),
+			formatOptionalDate(product.EffectiveFrom),
+			formatOptionalDate(product.EffectiveTo),
+			status,
+			product.Remark,
+		})
+	}
+
+	fileData, err := writeProductRows(format, rows)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return fileData, fmt.Sprintf("products_export_%s.%s", time.Now().Format("20060102150405"), format), nil
+}
+
+// ImportProductsFromFile 从文件导入产品目录，承保公司不存在时自动创建
+func (s *ProductService) ImportProductsFromFile(ctx context.Context, file multipart.File, header *multipart.FileHeader, req *model.ProductImportFileRequest, userID, companyID string) (*model.ProductImportResponse, error) {
+	var records [][]string
+	var err error
+
+	fileName := strings.ToLower(header.Filename)
+	if strings.HasSuffix(fileName, ".xlsx") || strings.HasSuffix(fileName, ".xls") {
+		var f *excelize.File
+		f, err = excelize.OpenReader(file)
+		if err == nil {
+			defer f.Close()
+			records, err = f.GetRows(f.GetSheetName(0))
+		}
+	} else if strings.HasSuffix(fileName, ".csv") {
+		records, err = csv.NewReader(file).ReadAll()
+	} else {
+		return nil, errors.New("不支持的文件格式")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if req.SkipHeader && len(records) > 0 {
+		records = records[1:]
+	}
+
+	response := &model.ProductImportResponse{
+		TotalCount: len(records),
+		Errors:     []model.ProductImportError{},
+	}
+
+	for i, record := range records {
+		rowNum := i + 1
+		if req.SkipHeader {
+			rowNum = i + 2
+		}
+
+		if err := s.importProductRecord(ctx, record, req.UpdateExisting, userID, companyID); err != nil {
+			response.Errors = append(response.Errors, model.ProductImportError{
+				Row:    rowNum,
+				Errors: []string{err.Error()},
+				Data:   record,
+			})
+			continue
+		}
+		response.SuccessCount++
+	}
+
+	response.ErrorCount = len(response.Errors)
+	return response, nil
+}
+
+// importProductRecord 导入单行产品数据
+func (s *ProductService) importProductRecord(ctx context.Context, record []string, updateExisting bool, userID, companyID string) error {
+	for len(record) < len(productImportHeaders) {
+		record = append(record, "")
+	}
+	for i := range record {
+		record[i] = strings.TrimSpace(record[i])
+	}
+
+	insurerName, productName := record[1], record[2]
+	if insurerName == "" || productName == "" || record[3] == "" {
+		return fmt.Errorf("承保公司、产品名称和产品类型不能为空")
+	}
+
+	var parseErrors []string
+	currencies := splitList(record[4])
+	for _, currency := range currencies {
+		if !containsString([]string{"USD", "HKD", "CNY"}, currency) {
+			parseErrors = append(parseErrors, fmt.Sprintf("币种%s无效", currency))
+		}
+	}
+	paymentMethods := splitList(record[5])
+	for _, method := range paymentMethods {
+		if !containsString([]string{"期缴", "趸缴", "预缴"}, method) {
+			parseErrors = append(parseErrors, fmt.Sprintf("缴费方式%s无效", method))
+		}
+	}
+	var paymentYears []int
+	for _, item := range splitList(record[6]) {
+		years, err := strconv.Atoi(item)
+		if err != nil || years <= 0 {
+			parseErrors = append(parseErrors, fmt.Sprintf("缴费年期%s格式错误", item))
+			continue
+		}
+		paymentYears = append(paymentYears, years)
+	}
+	referralRate := 0.0
+	if record[7] != "" {
+		rate, err := strconv.ParseFloat(record[7], 64)
+		if err != nil || rate < 0 || rate > 100 {
+			parseErrors = append(parseErrors, "默认转介费率格式错误")
+		}
+		referralRate = rate
+	}
+	coolingOffDays := 0
+	if record[8] != "" {
+		days, err := strconv.Atoi(record[8])
+		if err != nil || days < 0 {
+			parseErrors = append(parseErrors, "冷静期天数格式错误")
+		}
+		coolingOffDays = days
+	}
+	effectiveFrom, err := parseOptionalDate(record[9])
+	if err != nil {
+		parseErrors = append(parseErrors, "上架日期格式错误")
+	}
+	effectiveTo, err := parseOptionalDate(record[10])
+	if err != nil {
+		parseErrors = append(parseErrors, "下架日期格式错误")
+	}
+	status := "enable"
+	if record[11] == "禁用" || record[11] == "disable" {
+		status = "disable"
+	}
+	if len(parseErrors) > 0 {
+		return errors.New(strings.Join(parseErrors, "；"))
+	}
+
+	// 查找或创建承保公司
+	insurer, err := s.productRepo.GetInsurerByName(ctx, insurerName, companyID)
+	if err != nil {
+		return err
+	}
+	if insurer == nil {
+		insurer, err = s.CreateInsurer(ctx, &model.InsurerCreateRequest{InsurerName: insurerName}, userID, companyID)
+		if err != nil {
+			return err
+		}
+	}
+
+	existing, err := s.productRepo.GetProductByName(ctx, insurer.InsurerID, productName, companyID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if !updateExisting {
+			return fmt.Errorf("该承保公司下产品名称已存在")
+		}
+		_, err = s.UpdateProduct(ctx, existing.ProductID, &model.ProductUpdateRequest{
+			ProductCode:         record[0],
+			ProductType:         record[3],
+			Currencies:          currencies,
+			PaymentMethods:      paymentMethods,
+			PaymentYears:        paymentYears,
+			DefaultReferralRate: &referralRate,
+			CoolingOffDays:      &coolingOffDays,
+			EffectiveFrom:       effectiveFrom,
+			EffectiveTo:         effectiveTo,
+			Status:              status,
+			Remark:              record[12],
+		}, userID, companyID)
+		return err
+	}
+
+	_, err = s.CreateProduct(ctx, &model.ProductCreateRequest{
+		ProductCode:         record[0],
+		ProductName:         productName,
+		ProductType:         record[3],
+		InsurerID:           insurer.InsurerID,
+		Currencies:          currencies,
+		PaymentMethods:      paymentMethods,
+		PaymentYears:        paymentYears,
+		DefaultReferralRate: referralRate,
+		CoolingOffDays:      coolingOffDays,
+		EffectiveFrom:       effectiveFrom,
+		EffectiveTo:         effectiveTo,
+		Status:              status,
+		Remark:              record[12],
+	}, userID, companyID)
+	return err
+}
+
+// writeProductRows 按格式写出产品表头及数据行
+func writeProductRows(format string, rows [][]string) ([]byte, error) {
+	switch format {
+	case "xlsx":
+		f := excelize.NewFile()
+		defer f.Close()
+		sheetName := "Sheet1"
+
+		for i, row := range append([][]string{productImportHeaders}, rows...) {
+			for j, value := range row {
+				cell, err := excelize.CoordinatesToCellName(j+1, i+1)
+				if err != nil {
+					return nil, err
+				}
+				f.SetCellValue(sheetName, cell, value)
+			}
+		}
+		lastCol, _ := excelize.ColumnNumberToName(len(productImportHeaders))
+		f.SetColWidth(sheetName, "A", lastCol, 18)
+
+		buffer, err := f.WriteToBuffer()
+		if err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+	case "csv":
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		if err := writer.Write(productImportHeaders); err != nil {
+			return nil, err
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, errors.New("不支持的文件格式")
+	}
+}
+
+// checkProductEffectiveRange 校验产品上架日期不晚于下架日期
+func checkProductEffectiveRange(from, to *time.Time) error {
+	if from != nil && to != nil && to.Before(*from) {
+		return fmt.Errorf("下架日期不能早于上架日期")
+	}
+	return nil
+}
+
+// splitList 拆分以 / 、 , 分隔的列表单元格
+func splitList(value string) []string {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == '/' || r == ',' || r == '、' || r == '，'
+	})
+
+	var result []string
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			result = append(result, field)
+		}
+	}
+	return result
+}
+
+func parseOptionalDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+func formatOptionalDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format("2006-01-02")
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(list []int, value int) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func joinInts(list []int, sep string) string {
+	sorted := append([]int(nil), list...)
+	sort.Ints(sorted)
+
+	items := make([]string, len(sorted))
+	for i, item := range sorted {
+		items[i] = strconv.Itoa(item)
+	}
+	return strings.Join(items, sep)
+}
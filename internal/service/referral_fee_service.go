@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"YufungProject/internal/model"
+	"YufungProject/internal/repository"
+)
+
+type ReferralFeeService struct {
+	ruleRepo    *repository.ReferralFeeRuleRepository
+	productRepo *repository.ProductRepository
+}
+
+func NewReferralFeeService(ruleRepo *repository.ReferralFeeRuleRepository, productRepo *repository.ProductRepository) *ReferralFeeService {
+	return &ReferralFeeService{
+		ruleRepo:    ruleRepo,
+		productRepo: productRepo,
+	}
+}
+
+// ==========================
+// 规则维护
+// ==========================
+
+// CreateRule 创建转介费规则
+func (s *ReferralFeeService) CreateRule(ctx context.Context, req *model.ReferralFeeRuleRequest, userID, companyID string) (*model.ReferralFeeRule, error) {
+	if err := s.checkRuleRequest(ctx, req, companyID); err != nil {
+		return nil, err
+	}
+
+	if req.TierMode == "" {
+		req.TierMode = model.FeeTierModeFlat
+	}
+	if req.Status == "" {
+		req.Status = "enable"
+	}
+
+	rule := &model.ReferralFeeRule{
+		RuleName:      strings.TrimSpace(req.RuleName),
+		CompanyID:     companyID,
+		Partner:       strings.TrimSpace(req.Partner),
+		ProductID:     req.ProductID,
+		PaymentMethod: req.PaymentMethod,
+		PaymentYears:  req.PaymentYears,
+		EffectiveFrom: req.EffectiveFrom,
+		EffectiveTo:   req.EffectiveTo,
+		TierMode:      req.TierMode,
+		Tiers:         req.Tiers,
+		MaxFee:        req.MaxFee,
+		Priority:      req.Priority,
+		Status:        req.Status,
+		Remark:        req.Remark,
+		CreatedBy:     userID,
+		UpdatedBy:     userID,
+	}
+
+	if err := s.ruleRepo.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// GetRule 获取转介费规则详情
+func (s *ReferralFeeService) GetRule(ctx context.Context, ruleID, companyID string) (*model.ReferralFeeRule, error) {
+	rule, err := s.ruleRepo.GetRuleByID(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil || rule.CompanyID != companyID {
+		return nil, fmt.Errorf("转介费规则不存在")
+	}
+	return rule, nil
+}
+
+// UpdateRule 更新转介费规则，匹配条件与档位整体替换
+func (s *ReferralFeeService) UpdateRule(ctx context.Context, ruleID string, req *model.ReferralFeeRuleRequest, userID, companyID string) (*model.ReferralFeeRule, error) {
+	rule, err := s.GetRule(ctx, ruleID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkRuleRequest(ctx, req, companyID); err != nil {
+		return nil, err
+	}
+
+	if req.TierMode == "" {
+		req.TierMode = rule.TierMode
+	}
+	if req.Status == "" {
+		req.Status = rule.Status
+	}
+
+	updates := bson.M{
+		"rule_name":      strings.TrimSpace(req.RuleName),
+		"partner":        strings.TrimSpace(req.Partner),
+		"product_id":     req.ProductID,
+		"payment_method": req.PaymentMethod,
+		"payment_years":  req.PaymentYears,
+		"effective_from": req.EffectiveFrom,
+		"effective_to":   req.EffectiveTo,
+		"tier_mode":      req.TierMode,
+		"tiers":          req.Tiers,
+		"max_fee":        req.MaxFee,
+		"priority":       req.Priority,
+		"status":         req.Status,
+		"remark":         req.Remark,
+		"updated_by":     userID,
+	}
+
+	if err := s.ruleRepo.UpdateRule(ctx, ruleID, updates); err != nil {
+		return nil, err
+	}
+
+	return s.ruleRepo.GetRuleByID(ctx, ruleID)
+}
+
+// DeleteRule 删除转介费规则，已被保单命中的规则不允许删除（可改为禁用）
+func (s *ReferralFeeService) DeleteRule(ctx context.Context, ruleID, companyID string) error {
+	if _, err := s.GetRule(ctx, ruleID, companyID); err != nil {
+		return err
+	}
+
+	count, err := s.ruleRepo.CountPoliciesByRule(ctx, ruleID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("该规则已被%d张保单使用，无法删除，请改为禁用", count)
+	}
+
+	return s.ruleRepo.DeleteRule(ctx, ruleID)
+}
+
+// ListRules 获取转介费规则列表
+func (s *ReferralFeeService) ListRules(ctx context.Context, companyID, status string) ([]model.ReferralFeeRule, error) {
+	return s.ruleRepo.ListRules(ctx, companyID, status)
+}
+
+// checkRuleRequest 校验规则请求，并将档位按起始保费升序排列
+func (s *ReferralFeeService) checkRuleRequest(ctx context.Context, req *model.ReferralFeeRuleRequest, companyID string) error {
+	if req.EffectiveFrom != nil && req.EffectiveTo != nil && req.EffectiveTo.Before(*req.EffectiveFrom) {
+		return fmt.Errorf("生效日期止不能早于生效日期起")
+	}
+
+	if req.ProductID != "" {
+		product, err := s.productRepo.GetProductByID(ctx, req.ProductID)
+		if err != nil {
+			return err
+		}
+		if product == nil || product.CompanyID != companyID {
+			return fmt.Errorf("产品不存在")
+		}
+	}
+
+	sort.Slice(req.Tiers, func(i, j int) bool {
+		return req.Tiers[i].MinPremium < req.Tiers[j].MinPremium
+	})
+	if req.Tiers[0].MinPremium != 0 {
+		return fmt.Errorf("首档起始保费须为0")
+	}
+	for i := 1; i < len(req.Tiers); i++ {
+		if req.Tiers[i].MinPremium == req.Tiers[i-1].MinPremium {
+			return fmt.Errorf("费率档位起始保费%.2f重复", req.Tiers[i].MinPremium)
+		}
+	}
+
+	return nil
+}
+
+// ==========================
+// 转介费计算
+// ==========================
+
+// ReferralFeeInput 转介费计算所需的保单字段
+type ReferralFeeInput struct {
+	Partner       string
+	ProductID     string
+	PaymentMethod string
+	PaymentYears  int
+	EffectiveDate *time.Time
+	ActualPremium float64
+	ExchangeRate  float64
+}
+
+// ReferralFeeResult 转介费计算结果
+type ReferralFeeResult struct {
+	RuleID             string  // 命中的规则ID
+	ReferralRate       float64 // 转介费率
+	ExpectedFee        float64 // 预计转介费
+	ManualReferralRate bool    // 转介费率为手工值（与规则值不同）
+	ManualExpectedFee  bool    // 预计转介费为手工值（与计算值不同）
+}
+
+// ReferralFeeCalculator 转介费计算器，一次加载公司的启用规则，供批量计算复用
+type ReferralFeeCalculator struct {
+	rules        []model.ReferralFeeRule // 已按优先级、匹配精确度排序
+	defaultRates map[string]float64      // 产品ID -> 产品默认转介费率
+}
+
+// NewFeeCalculator 加载公司的启用规则及产品默认费率，创建转介费计算器
+func (s *ReferralFeeService) NewFeeCalculator(ctx context.Context, companyID string) (*ReferralFeeCalculator, error) {
+	rules, err := s.ruleRepo.ListRules(ctx, companyID, "enable")
+	if err != nil {
+		return nil, err
+	}
+
+	// 优先级相同时，匹配条件越多越优先，再按生效日期起越晚越优先
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority > rules[j].Priority
+		}
+		if si, sj := ruleSpecificity(&rules[i]), ruleSpecificity(&rules[j]); si != sj {
+			return si > sj
+		}
+		fi, fj := rules[i].EffectiveFrom, rules[j].EffectiveFrom
+		return fi != nil && (fj == nil || fi.After(*fj))
+	})
+
+	products, err := s.productRepo.ListProducts(ctx, &model.ProductQueryRequest{}, companyID)
+	if err != nil {
+		return nil, err
+	}
+	defaultRates := make(map[string]float64, len(products.List))
+	for _, product := range products.List {
+		defaultRates[product.ProductID] = product.DefaultReferralRate
+	}
+
+	return &ReferralFeeCalculator{rules: rules, defaultRates: defaultRates}, nil
+}
+
+// Match 返回保单命中的第一条规则，未命中返回nil
+func (c *ReferralFeeCalculator) Match(input ReferralFeeInput) *model.ReferralFeeRule {
+	for i := range c.rules {
+		rule := &c.rules[i]
+		if rule.Partner != "" && !strings.EqualFold(rule.Partner, input.Partner) {
+			continue
+		}
+		if rule.ProductID != "" && rule.ProductID != input.ProductID {
+			continue
+		}
+		if rule.PaymentMethod != "" && rule.PaymentMethod != input.PaymentMethod {
+			continue
+		}
+		if rule.PaymentYears > 0 && rule.PaymentYears != input.PaymentYears {
+			continue
+		}
+		if rule.EffectiveFrom != nil || rule.EffectiveTo != nil {
+			if input.EffectiveDate == nil {
+				continue
+			}
+			if rule.EffectiveFrom != nil && input.EffectiveDate.Before(*rule.EffectiveFrom) {
+				continue
+			}
+			if rule.EffectiveTo != nil && input.EffectiveDate.After(*rule.EffectiveTo) {
+				continue
+			}
+		}
+		return rule
+	}
+	return nil
+}
+
+// Calculate 计算转介费率及预计转介费
+// rate、fee 为手工填写的值（nil 表示未填写），与规则计算值不同时标记为手工覆盖。
+// 未命中规则时以产品默认费率作为默认转介费率。
+func (c *ReferralFeeCalculator) Calculate(input ReferralFeeInput, rate, fee *float64) ReferralFeeResult {
+	var result ReferralFeeResult
+
+	rule := c.Match(input)
+	defaultRate := c.defaultRates[input.ProductID]
+	if rule != nil {
+		result.RuleID = rule.RuleID
+		defaultRate = ruleRate(rule, input.ActualPremium)
+	}
+
+	result.ReferralRate = defaultRate
+	if rate != nil {
+		result.ReferralRate = *rate
+		result.ManualReferralRate = math.Abs(*rate-defaultRate) >= 0.00005
+	}
+
+	exchangeRate := input.ExchangeRate
+	if exchangeRate <= 0 {
+		exchangeRate = 1
+	}
+	computed := input.ActualPremium * result.ReferralRate / 100 * exchangeRate
+	if rule != nil && rule.MaxFee > 0 && computed > rule.MaxFee {
+		computed = rule.MaxFee
+	}
+	computed = math.Round(computed*100) / 100
+
+	result.ExpectedFee = computed
+	if fee != nil {
+		result.ExpectedFee = *fee
+		result.ManualExpectedFee = math.Abs(*fee-computed) >= 0.005
+	}
+
+	return result
+}
+
+// ruleRate 按规则档位计算保费对应的转介费率，累进模式返回综合费率
+func ruleRate(rule *model.ReferralFeeRule, premium float64) float64 {
+	if len(rule.Tiers) == 0 {
+		return 0
+	}
+
+	if rule.TierMode != model.FeeTierModeProgressive {
+		rate := rule.Tiers[0].Rate
+		for _, tier := range rule.Tiers {
+			if premium >= tier.MinPremium {
+				rate = tier.Rate
+			}
+		}
+		return rate
+	}
+
+	if premium <= 0 {
+		return rule.Tiers[0].Rate
+	}
+
+	fee := 0.0
+	for i, tier := range rule.Tiers {
+		if premium <= tier.MinPremium {
+			break
+		}
+		upper := premium
+		if i+1 < len(rule.Tiers) && rule.Tiers[i+1].MinPremium < premium {
+			upper = rule.Tiers[i+1].MinPremium
+		}
+		fee += (upper - tier.MinPremium) * tier.Rate / 100
+	}
+	return math.Round(fee/premium*100*10000) / 10000
+}
+
+// ruleSpecificity 统计规则的匹配条件数量
+func ruleSpecificity(rule *model.ReferralFeeRule) int {
+	count := 0
+	if rule.Partner != "" {
+		count++
+	}
+	if rule.ProductID != "" {
+		count++
+	}
+	if rule.PaymentMethod != "" {
+		count++
+	}
+	if rule.PaymentYears > 0 {
+		count++
+	}
+	if rule.EffectiveFrom != nil || rule.EffectiveTo != nil {
+		count++
+	}
+	return count
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"YufungProject/internal/model"
+	"YufungProject/internal/repository"
+	"YufungProject/pkg/logger"
+)
+
+// allowlistCacheTTL 公司IP白名单的本地缓存时长，避免每个请求都查询公司表
+const allowlistCacheTTL = 30 * time.Second
+
+// securityRecordTimeout 异步记录安全事件、登录地点的超时时间
+const securityRecordTimeout = 5 * time.Second
+
+// ErrIPNotAllowed 客户端IP不在公司IP白名单内
+var ErrIPNotAllowed = errors.New("当前网络不允许访问系统，请在公司网络内登录")
+
+type allowlistCacheEntry struct {
+	allowedIPs []string
+	loadedAt   time.Time
+}
+
+// SecurityService 公司IP白名单校验及安全事件记录：拒绝白名单外的登录和访问，
+// 识别用户从新IP或新设备登录并发送站内通知
+type SecurityService struct {
+	securityRepo     *repository.SecurityRepository
+	companyRepo      repository.CompanyRepository
+	notificationRepo *repository.NotificationRepository
+	mu               sync.RWMutex
+	cache            map[string]allowlistCacheEntry
+}
+
+func NewSecurityService(securityRepo *repository.SecurityRepository, companyRepo repository.CompanyRepository, notificationRepo *repository.NotificationRepository) *SecurityService {
+	return &SecurityService{
+		securityRepo:     securityRepo,
+		companyRepo:      companyRepo,
+		notificationRepo: notificationRepo,
+		cache:            make(map[string]allowlistCacheEntry),
+	}
+}
+
+// CheckIPAccess 校验客户端IP是否在公司IP白名单内，实现 middleware.IPAccessChecker；
+// 不在白名单内时记录安全事件并返回 ErrIPNotAllowed
+func (s *SecurityService) CheckIPAccess(ctx context.Context, attempt *model.IPAccessAttempt) error {
+	if attempt.CompanyID == "" {
+		return nil
+	}
+
+	allowedIPs, err := s.allowlist(ctx, attempt.CompanyID)
+	if err != nil {
+		return err
+	}
+	if ipAllowed(allowedIPs, attempt.IPAddress) {
+		return nil
+	}
+
+	logger.Warnf("访问被拒绝 - IP不在公司白名单内: UserID=%s, CompanyID=%s, IP=%s, Source=%s, Path=%s", attempt.UserID, attempt.CompanyID, attempt.IPAddress, attempt.Source, attempt.Path)
+
+	now := time.Now()
+	event := &model.SecurityEvent{
+		Type:       model.SecurityEventIPDenied,
+		Source:     attempt.Source,
+		UserID:     attempt.UserID,
+		Username:   attempt.Username,
+		CompanyID:  attempt.CompanyID,
+		IPAddress:  attempt.IPAddress,
+		UserAgent:  attempt.UserAgent,
+		Path:       attempt.Path,
+		Detail:     "IP不在公司白名单内",
+		LastSeenAt: now,
+		// 同一用户同一IP的拒绝按小时合并，避免被拒绝的客户端反复请求时大量写库
+		DedupKey: fmt.Sprintf("%s:%s:%s:%s:%s", model.SecurityEventIPDenied, attempt.Source, attempt.UserID, attempt.IPAddress, now.Format("2006010215")),
+	}
+	s.recordEventAsync(event)
+
+	return ErrIPNotAllowed
+}
+
+// RecordLogin 记录用户登录的IP和设备；用户曾经登录过且本次IP或设备是首次出现时，记录安全事件并通知用户
+func (s *SecurityService) RecordLogin(ctx context.Context, user *model.User, clientIP, userAgent string) error {
+	now := time.Now()
+	device := loginDevice(userAgent)
+
+	hasHistory, ipSeen, deviceSeen, err := s.securityRepo.LoginLocationSeen(ctx, user.UserID, clientIP, device)
+	if err != nil {
+		return fmt.Errorf("查询登录记录失败: %w", err)
+	}
+	if err := s.securityRepo.TouchLoginLocation(ctx, &model.UserLoginLocation{
+		UserID:     user.UserID,
+		CompanyID:  user.CompanyID,
+		IPAddress:  clientIP,
+		Device:     device,
+		UserAgent:  userAgent,
+		LastSeenAt: now,
+	}); err != nil {
+		return fmt.Errorf("保存登录记录失败: %w", err)
+	}
+
+	// 首次登录没有可比较的历史记录，不提醒
+	if !hasHistory || (ipSeen && deviceSeen) {
+		return nil
+	}
+
+	var changes []string
+	if !ipSeen {
+		changes = append(changes, "新IP "+clientIP)
+	}
+	if !deviceSeen {
+		changes = append(changes, "新设备 "+device)
+	}
+	detail := strings.Join(changes, "，")
+
+	logger.Warnf("用户从新IP或新设备登录: UserID=%s, IP=%s, Device=%s", user.UserID, clientIP, device)
+	if err := s.securityRepo.RecordEvent(ctx, &model.SecurityEvent{
+		Type:       model.SecurityEventNewLogin,
+		Source:     model.IPAccessSourceLogin,
+		UserID:     user.UserID,
+		Username:   user.Username,
+		CompanyID:  user.CompanyID,
+		IPAddress:  clientIP,
+		UserAgent:  userAgent,
+		Detail:     detail,
+		LastSeenAt: now,
+		DedupKey:   fmt.Sprintf("%s:%s:%s:%s", model.SecurityEventNewLogin, user.UserID, clientIP, device),
+	}); err != nil {
+		return fmt.Errorf("记录安全事件失败: %w", err)
+	}
+
+	preference, err := s.notificationRepo.GetPreference(ctx, user.UserID)
+	if err != nil {
+		return fmt.Errorf("查询通知偏好失败: %w", err)
+	}
+	if !preferenceEnabled(preference, model.NotificationTypeNewLogin) {
+		return nil
+	}
+	_, err = s.notificationRepo.CreateNotificationIfAbsent(ctx, &model.Notification{
+		UserID:      user.UserID,
+		CompanyID:   user.CompanyID,
+		Type:        model.NotificationTypeNewLogin,
+		Title:       "账户在新IP或新设备登录",
+		Content:     fmt.Sprintf("您的账户于%s从%s登录。如非本人操作，请立即修改密码并联系管理员。", now.In(time.Local).Format("2006-01-02 15:04"), detail),
+		RelatedType: "user",
+		RelatedID:   user.UserID,
+		DedupKey:    fmt.Sprintf("%s:%s:%s:%d", model.NotificationTypeNewLogin, clientIP, device, now.Unix()),
+	})
+	return err
+}
+
+// GetCompanyAllowlist 获取公司IP白名单
+func (s *SecurityService) GetCompanyAllowlist(ctx context.Context, companyID string) ([]string, error) {
+	company, err := s.companyRepo.GetCompanyByID(ctx, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("查询公司失败: %w", err)
+	}
+	if company == nil {
+		return nil, errors.New("公司不存在")
+	}
+	if company.AllowedIPs == nil {
+		return []string{}, nil
+	}
+	return company.AllowedIPs, nil
+}
+
+// UpdateCompanyAllowlist 设置公司IP白名单，传空表示取消限制；
+// 公司用户（非平台管理员）不能保存不包含自己当前IP的白名单，避免把自己挡在系统外
+func (s *SecurityService) UpdateCompanyAllowlist(ctx context.Context, companyID string, entries []string, operatorID, operatorIP string, isPlatformAdmin bool) ([]string, error) {
+	company, err := s.companyRepo.GetCompanyByID(ctx, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("查询公司失败: %w", err)
+	}
+	if company == nil {
+		return nil, errors.New("公司不存在")
+	}
+
+	allowedIPs, err := normalizeAllowedIPs(entries)
+	if err != nil {
+		return nil, err
+	}
+	if !isPlatformAdmin && !ipAllowed(allowedIPs, operatorIP) {
+		return nil, fmt.Errorf("IP白名单未包含当前IP %s，保存后将无法访问系统", operatorIP)
+	}
+
+	if err := s.companyRepo.UpdateCompany(ctx, companyID, bson.M{"allowed_ips": allowedIPs}); err != nil {
+		return nil, fmt.Errorf("更新IP白名单失败: %w", err)
+	}
+	s.invalidate(companyID)
+
+	logger.Infof("更新公司IP白名单: CompanyID=%s, AllowedIPs=%v, Operator=%s", companyID, allowedIPs, operatorID)
+	return allowedIPs, nil
+}
+
+// ListEvents 分页查询安全事件
+func (s *SecurityService) ListEvents(ctx context.Context, req *model.SecurityEventQueryRequest) (*model.SecurityEventListResponse, error) {
+	events, total, err := s.securityRepo.ListEvents(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询安全事件失败: %w", err)
+	}
+
+	return &model.SecurityEventListResponse{
+		List:     events,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// allowlist 获取公司IP白名单，带短期缓存；公司不存在时视为不限制
+func (s *SecurityService) allowlist(ctx context.Context, companyID string) ([]string, error) {
+	s.mu.RLock()
+	entry, ok := s.cache[companyID]
+	s.mu.RUnlock()
+	if ok && time.Since(entry.loadedAt) < allowlistCacheTTL {
+		return entry.allowedIPs, nil
+	}
+
+	company, err := s.companyRepo.GetCompanyByID(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	entry = allowlistCacheEntry{loadedAt: time.Now()}
+	if company != nil {
+		entry.allowedIPs = company.AllowedIPs
+	}
+
+	s.mu.Lock()
+	s.cache[companyID] = entry
+	s.mu.Unlock()
+	return entry.allowedIPs, nil
+}
+
+// invalidate 清除公司IP白名单缓存
+func (s *SecurityService) invalidate(companyID string) {
+	s.mu.Lock()
+	delete(s.cache, companyID)
+	s.mu.Unlock()
+}
+
+// recordEventAsync 异步记录安全事件，不影响请求的响应
+func (s *SecurityService) recordEventAsync(event *model.SecurityEvent) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), securityRecordTimeout)
+		defer cancel()
+		if err := s.securityRepo.RecordEvent(ctx, event); err != nil {
+			logger.Errorf("记录安全事件失败: %v, Type: %s, UserID: %s", err, event.Type, event.UserID)
+		}
+	}()
+}
+
+// loginDevice 由 User-Agent 归纳出浏览器和操作系统，浏览器小版本升级不视为新设备
+func loginDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	browser := "其他浏览器"
+	switch {
+	case strings.Contains(ua, "micromessenger"):
+		browser = "微信"
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/"), strings.Contains(ua, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	}
+
+	platform := "其他系统"
+	switch {
+	case strings.Contains(ua, "windows"):
+		platform = "Windows"
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"):
+		platform = "iOS"
+	case strings.Contains(ua, "mac os"):
+		platform = "macOS"
+	case strings.Contains(ua, "android"):
+		platform = "Android"
+	case strings.Contains(ua, "linux"):
+		platform = "Linux"
+	}
+
+	return browser + " / " + platform
+}
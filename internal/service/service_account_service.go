@@ -0,0 +1,421 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"YufungProject/internal/model"
+	"YufungProject/internal/repository"
+	"YufungProject/pkg/logger"
+	"YufungProject/pkg/utils"
+)
+
+// API密钥格式：yfk_<8位随机前缀>.<随机串>，前缀用于查找密钥，可在列表中展示
+const (
+	apiKeyPrefixTag      = "yfk_"
+	apiKeyPrefixBytes    = 6
+	apiKeySecretBytes    = 32
+	apiKeyTouchInterval  = time.Minute
+	apiKeyAuthTimeout    = 5 * time.Second
+	apiKeyPrefixSplitter = "."
+)
+
+var (
+	// ErrInvalidAPIKey 密钥不存在、已吊销、已过期或服务账号已停用
+	ErrInvalidAPIKey = errors.New("API密钥无效或已过期")
+	// ErrAPIKeyIPNotAllowed 调用IP不在服务账号的白名单内
+	ErrAPIKeyIPNotAllowed = errors.New("调用IP不在服务账号白名单内")
+)
+
+// ServiceAccountService 服务账号与API密钥管理，以及API密钥认证
+type ServiceAccountService struct {
+	accountRepo *repository.ServiceAccountRepository
+	companyRepo repository.CompanyRepository
+	rbacRepo    repository.RBACRepository
+}
+
+func NewServiceAccountService(accountRepo *repository.ServiceAccountRepository, companyRepo repository.CompanyRepository, rbacRepo repository.RBACRepository) *ServiceAccountService {
+	return &ServiceAccountService{
+		accountRepo: accountRepo,
+		companyRepo: companyRepo,
+		rbacRepo:    rbacRepo,
+	}
+}
+
+// CreateServiceAccount 创建服务账号并签发第一个API密钥；非平台管理员只能授予自己拥有的权限
+func (s *ServiceAccountService) CreateServiceAccount(ctx context.Context, req *model.ServiceAccountCreateRequest, operatorID string, isPlatformAdmin bool) (*model.ServiceAccountCreateResponse, error) {
+	company, err := s.companyRepo.GetCompanyByID(ctx, req.CompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("查询公司失败: %w", err)
+	}
+	if company == nil {
+		return nil, errors.New("公司不存在")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	exists, err := s.accountRepo.ExistsAccountName(ctx, company.CompanyID, name, "")
+	if err != nil {
+		return nil, fmt.Errorf("检查服务账号名称失败: %w", err)
+	}
+	if exists {
+		return nil, errors.New("服务账号名称已存在")
+	}
+
+	if err := s.validateScopes(ctx, req.Scopes, operatorID, isPlatformAdmin); err != nil {
+		return nil, err
+	}
+	allowedIPs, err := normalizeAllowedIPs(req.AllowedIPs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	account := &model.ServiceAccount{
+		ServiceAccountID: utils.GenerateID("SVC"),
+		CompanyID:        company.CompanyID,
+		Name:             name,
+		Description:      req.Description,
+		Scopes:           req.Scopes,
+		AllowedIPs:       allowedIPs,
+		Status:           model.ServiceAccountStatusActive,
+		CreatedBy:        operatorID,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if err := s.accountRepo.CreateAccount(ctx, account); err != nil {
+		return nil, fmt.Errorf("创建服务账号失败: %w", err)
+	}
+
+	key, apiKey, err := s.issueKey(ctx, account, req.ExpiresInDays, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	account.Keys = []model.APIKey{*apiKey}
+
+	logger.Infof("创建服务账号: ServiceAccountID=%s, CompanyID=%s, Scopes=%v, Operator=%s", account.ServiceAccountID, account.CompanyID, account.Scopes, operatorID)
+	return &model.ServiceAccountCreateResponse{ServiceAccount: account, Key: key}, nil
+}
+
+// GetServiceAccount 获取服务账号详情（含密钥列表）
+func (s *ServiceAccountService) GetServiceAccount(ctx context.Context, serviceAccountID string) (*model.ServiceAccount, error) {
+	account, err := s.accountRepo.GetAccount(ctx, serviceAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("查询服务账号失败: %w", err)
+	}
+	if account == nil {
+		return nil, errors.New("服务账号不存在")
+	}
+
+	keys, err := s.accountRepo.ListKeys(ctx, serviceAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("查询API密钥失败: %w", err)
+	}
+	account.Keys = keys
+	return account, nil
+}
+
+// ListServiceAccounts 分页查询服务账号
+func (s *ServiceAccountService) ListServiceAccounts(ctx context.Context, req *model.ServiceAccountQueryRequest) (*model.ServiceAccountListResponse, error) {
+	accounts, total, err := s.accountRepo.ListAccounts(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("查询服务账号列表失败: %w", err)
+	}
+
+	return &model.ServiceAccountListResponse{
+		List:     accounts,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// UpdateServiceAccount 更新服务账号名称、权限范围、IP白名单或状态，停用后其全部密钥立即不可用
+func (s *ServiceAccountService) UpdateServiceAccount(ctx context.Context, serviceAccountID string, req *model.ServiceAccountUpdateRequest, operatorID string, isPlatformAdmin bool) error {
+	account, err := s.accountRepo.GetAccount(ctx, serviceAccountID)
+	if err != nil {
+		return fmt.Errorf("查询服务账号失败: %w", err)
+	}
+	if account == nil {
+		return errors.New("服务账号不存在")
+	}
+
+	updates := bson.M{}
+	if name := strings.TrimSpace(req.Name); name != "" && name != account.Name {
+		exists, err := s.accountRepo.ExistsAccountName(ctx, account.CompanyID, name, serviceAccountID)
+		if err != nil {
+			return fmt.Errorf("检查服务账号名称失败: %w", err)
+		}
+		if exists {
+			return errors.New("服务账号名称已存在")
+		}
+		updates["name"] = name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if len(req.Scopes) > 0 {
+		if err := s.validateScopes(ctx, req.Scopes, operatorID, isPlatformAdmin); err != nil {
+			return err
+		}
+		updates["scopes"] = req.Scopes
+	}
+	if req.AllowedIPs != nil {
+		allowedIPs, err := normalizeAllowedIPs(req.AllowedIPs)
+		if err != nil {
+			return err
+		}
+		updates["allowed_ips"] = allowedIPs
+	}
+	if req.Status != "" {
+		updates["status"] = req.Status
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	if err := s.accountRepo.UpdateAccount(ctx, serviceAccountID, updates); err != nil {
+		return fmt.Errorf("更新服务账号失败: %w", err)
+	}
+
+	logger.Infof("更新服务账号: ServiceAccountID=%s, Fields=%v, Operator=%s", serviceAccountID, mapKeys(updates), operatorID)
+	return nil
+}
+
+// DeleteServiceAccount 删除服务账号及其全部密钥
+func (s *ServiceAccountService) DeleteServiceAccount(ctx context.Context, serviceAccountID, operatorID string) error {
+	account, err := s.accountRepo.GetAccount(ctx, serviceAccountID)
+	if err != nil {
+		return fmt.Errorf("查询服务账号失败: %w", err)
+	}
+	if account == nil {
+		return errors.New("服务账号不存在")
+	}
+
+	if err := s.accountRepo.DeleteAccount(ctx, serviceAccountID); err != nil {
+		return fmt.Errorf("删除服务账号失败: %w", err)
+	}
+
+	logger.Infof("删除服务账号: ServiceAccountID=%s, Name=%s, Operator=%s", serviceAccountID, account.Name, operatorID)
+	return nil
+}
+
+// CreateKey 签发新的API密钥。RevokeExisting 为true时用于轮换：现有密钥在保留时间后失效，
+// 便于合作方在保留期内切换到新密钥
+func (s *ServiceAccountService) CreateKey(ctx context.Context, serviceAccountID string, req *model.APIKeyCreateRequest, operatorID string) (*model.APIKeyCreateResponse, error) {
+	account, err := s.accountRepo.GetAccount(ctx, serviceAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("查询服务账号失败: %w", err)
+	}
+	if account == nil {
+		return nil, errors.New("服务账号不存在")
+	}
+
+	key, apiKey, err := s.issueKey(ctx, account, req.ExpiresInDays, operatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.RevokeExisting {
+		expiresAt := time.Now().Add(time.Duration(req.GracePeriodHours) * time.Hour)
+		if err := s.accountRepo.ExpireKeys(ctx, serviceAccountID, apiKey.KeyID, expiresAt); err != nil {
+			return nil, fmt.Errorf("更新旧密钥失败: %w", err)
+		}
+		logger.Infof("轮换API密钥: ServiceAccountID=%s, NewPrefix=%s, OldKeysExpireAt=%v, Operator=%s", serviceAccountID, apiKey.Prefix, expiresAt, operatorID)
+	}
+
+	return &model.APIKeyCreateResponse{Key: key, APIKey: apiKey}, nil
+}
+
+// RevokeKey 立即吊销API密钥
+func (s *ServiceAccountService) RevokeKey(ctx context.Context, serviceAccountID, keyID, operatorID string) error {
+	revoked, err := s.accountRepo.RevokeKey(ctx, serviceAccountID, keyID, operatorID, time.Now())
+	if err != nil {
+		return fmt.Errorf("吊销API密钥失败: %w", err)
+	}
+	if !revoked {
+		return errors.New("API密钥不存在或已吊销")
+	}
+
+	logger.Infof("吊销API密钥: ServiceAccountID=%s, KeyID=%s, Operator=%s", serviceAccountID, keyID, operatorID)
+	return nil
+}
+
+// AuthenticateAPIKey 校验API密钥，返回服务账号身份；失败时返回 ErrInvalidAPIKey 或 ErrAPIKeyIPNotAllowed
+func (s *ServiceAccountService) AuthenticateAPIKey(ctx context.Context, rawKey, clientIP string) (*model.ServiceIdentity, error) {
+	prefix, ok := parseAPIKeyPrefix(rawKey)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.accountRepo.GetKeyByPrefix(ctx, prefix)
+	if err != nil {
+		logger.Errorf("查询API密钥失败: %v, Prefix: %s", err, prefix)
+		return nil, ErrInvalidAPIKey
+	}
+	if key == nil || subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(utils.HashToken(rawKey))) != 1 {
+		logger.Warnf("API密钥认证失败 - 密钥不存在或不匹配: Prefix=%s, IP=%s", prefix, clientIP)
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if !key.Active(now) {
+		logger.Warnf("API密钥认证失败 - 密钥已吊销或过期: Prefix=%s, IP=%s", prefix, clientIP)
+		return nil, ErrInvalidAPIKey
+	}
+
+	account, err := s.accountRepo.GetAccount(ctx, key.ServiceAccountID)
+	if err != nil {
+		logger.Errorf("查询服务账号失败: %v, ServiceAccountID: %s", err, key.ServiceAccountID)
+		return nil, ErrInvalidAPIKey
+	}
+	if account == nil || account.Status != model.ServiceAccountStatusActive {
+		logger.Warnf("API密钥认证失败 - 服务账号不存在或已停用: ServiceAccountID=%s, IP=%s", key.ServiceAccountID, clientIP)
+		return nil, ErrInvalidAPIKey
+	}
+	if !ipAllowed(account.AllowedIPs, clientIP) {
+		logger.Warnf("API密钥认证失败 - IP不在白名单内: ServiceAccountID=%s, IP=%s", account.ServiceAccountID, clientIP)
+		return nil, ErrAPIKeyIPNotAllowed
+	}
+
+	// 最后使用时间不影响认证结果，使用独立的上下文以免请求取消导致写入失败
+	go func() {
+		touchCtx, cancel := context.WithTimeout(context.Background(), apiKeyAuthTimeout)
+		defer cancel()
+		if err := s.accountRepo.TouchKey(touchCtx, key, clientIP, now, apiKeyTouchInterval); err != nil {
+			logger.Errorf("记录API密钥使用时间失败: %v, Prefix: %s", err, key.Prefix)
+		}
+	}()
+
+	return &model.ServiceIdentity{
+		ServiceAccountID: account.ServiceAccountID,
+		Name:             account.Name,
+		CompanyID:        account.CompanyID,
+		Scopes:           account.Scopes,
+		KeyPrefix:        key.Prefix,
+	}, nil
+}
+
+// issueKey 生成并保存新密钥，返回密钥明文
+func (s *ServiceAccountService) issueKey(ctx context.Context, account *model.ServiceAccount, expiresInDays int, operatorID string) (string, *model.APIKey, error) {
+	prefixPart, err := utils.GenerateSecureToken(apiKeyPrefixBytes)
+	if err != nil {
+		return "", nil, fmt.Errorf("生成API密钥失败: %w", err)
+	}
+	secret, err := utils.GenerateSecureToken(apiKeySecretBytes)
+	if err != nil {
+		return "", nil, fmt.Errorf("生成API密钥失败: %w", err)
+	}
+	// 前缀只使用字母数字，便于在日志和界面中识别
+	prefix := apiKeyPrefixTag + strings.NewReplacer("-", "x", "_", "y").Replace(prefixPart)
+	rawKey := prefix + apiKeyPrefixSplitter + secret
+
+	now := time.Now()
+	apiKey := &model.APIKey{
+		KeyID:            utils.GenerateID("KEY"),
+		ServiceAccountID: account.ServiceAccountID,
+		CompanyID:        account.CompanyID,
+		Prefix:           prefix,
+		KeyHash:          utils.HashToken(rawKey),
+		CreatedBy:        operatorID,
+		CreatedAt:        now,
+	}
+	if expiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, expiresInDays)
+		apiKey.ExpiresAt = &expiresAt
+	}
+	if err := s.accountRepo.CreateKey(ctx, apiKey); err != nil {
+		return "", nil, fmt.Errorf("保存API密钥失败: %w", err)
+	}
+
+	logger.Infof("签发API密钥: ServiceAccountID=%s, Prefix=%s, ExpiresAt=%v, Operator=%s", account.ServiceAccountID, prefix, apiKey.ExpiresAt, operatorID)
+	return rawKey, apiKey, nil
+}
+
+// validateScopes 权限范围须为可授予服务账号的权限标识，非平台管理员只能授予自己拥有的权限
+func (s *ServiceAccountService) validateScopes(ctx context.Context, scopes []string, operatorID string, isPlatformAdmin bool) error {
+	for _, scope := range scopes {
+		if _, ok := model.APIKeyScopes[scope]; !ok {
+			return fmt.Errorf("权限范围无效: %s", scope)
+		}
+		if isPlatformAdmin {
+			continue
+		}
+		allowed, err := s.rbacRepo.CheckUserPermission(ctx, operatorID, scope)
+		if err != nil {
+			return fmt.Errorf("查询用户权限失败: %w", err)
+		}
+		if !allowed {
+			return fmt.Errorf("权限范围无效: 不能授予自己没有的权限 %s", scope)
+		}
+	}
+	return nil
+}
+
+// normalizeAllowedIPs 校验IP白名单，单个IP统一转换为CIDR
+func normalizeAllowedIPs(entries []string) ([]string, error) {
+	result := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			result = append(result, network.String())
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("IP白名单格式错误: %s", entry)
+		}
+		if ip.To4() != nil {
+			result = append(result, ip.String()+"/32")
+		} else {
+			result = append(result, ip.String()+"/128")
+		}
+	}
+	return result, nil
+}
+
+// ipAllowed 白名单为空时不限制
+func ipAllowed(allowed []string, clientIP string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+	for _, entry := range allowed {
+		if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAPIKeyPrefix 从密钥明文中取出前缀
+func parseAPIKeyPrefix(rawKey string) (string, bool) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefixTag) {
+		return "", false
+	}
+	prefix, _, found := strings.Cut(rawKey, apiKeyPrefixSplitter)
+	if !found || prefix == apiKeyPrefixTag {
+		return "", false
+	}
+	return prefix, true
+}
+
+func mapKeys(m bson.M) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"YufungProject/internal/repository"
+	"YufungProject/pkg/logger"
+	"YufungProject/pkg/utils"
+)
+
+// sessionCacheTTL 会话状态的本地缓存时长，避免每个请求都查询用户表
+const sessionCacheTTL = 30 * time.Second
+
+var (
+	// ErrSessionRevoked 令牌签发于会话吊销之前
+	ErrSessionRevoked = errors.New("登录状态已失效，请重新登录")
+	// ErrPasswordChangeRequired 用户须先修改密码（管理员要求或密码已过期）
+	ErrPasswordChangeRequired = errors.New("密码已过期或须修改，请先修改密码")
+)
+
+type sessionCacheEntry struct {
+	validAfter     time.Time
+	mustChange     bool
+	passwordExpiry *time.Time
+	loadedAt       time.Time
+}
+
+// SessionService 会话管理：记录用户的会话吊销时间，早于该时间签发的访问令牌和刷新令牌一律失效；
+// 同时判断用户是否须先修改密码
+type SessionService struct {
+	userRepo      repository.UserRepository
+	policyService *PasswordPolicyService
+	mu            sync.RWMutex
+	cache         map[string]sessionCacheEntry
+}
+
+func NewSessionService(userRepo repository.UserRepository, policyService *PasswordPolicyService) *SessionService {
+	return &SessionService{
+		userRepo:      userRepo,
+		policyService: policyService,
+		cache:         make(map[string]sessionCacheEntry),
+	}
+}
+
+// CheckSession 校验令牌是否已被吊销、用户是否须先修改密码，实现 middleware.SessionChecker
+func (s *SessionService) CheckSession(ctx context.Context, claims *utils.Claims) error {
+	entry, err := s.state(ctx, claims.UserID)
+	if err != nil {
+		return err
+	}
+	if claims.IssuedAt != nil && !entry.validAfter.IsZero() && claims.IssuedAt.Time.Before(entry.validAfter) {
+		return ErrSessionRevoked
+	}
+	if entry.mustChange || (entry.passwordExpiry != nil && time.Now().After(*entry.passwordExpiry)) {
+		return ErrPasswordChangeRequired
+	}
+	return nil
+}
+
+// CheckIssuedAt 校验签发时间是否晚于用户的会话吊销时间
+func (s *SessionService) CheckIssuedAt(ctx context.Context, userID string, issuedAt time.Time) error {
+	entry, err := s.state(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !entry.validAfter.IsZero() && issuedAt.Before(entry.validAfter) {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// RevokeUserSessions 吊销用户当前所有会话（已签发的访问令牌和刷新令牌）
+func (s *SessionService) RevokeUserSessions(ctx context.Context, userID string) error {
+	// 令牌签发时间只精确到秒，吊销时间取整到秒，吊销后立即重新登录签发的令牌不会被误判为失效
+	now := time.Now().Truncate(time.Second)
+	if err := s.userRepo.Update(ctx, userID, bson.M{"tokens_valid_after": now}); err != nil {
+		return err
+	}
+	s.Invalidate(userID)
+
+	logger.Infof("用户会话已吊销: UserID=%s", userID)
+	return nil
+}
+
+// Invalidate 清除用户的会话状态缓存，用户密码或状态变更后调用
+func (s *SessionService) Invalidate(userID string) {
+	s.mu.Lock()
+	delete(s.cache, userID)
+	s.mu.Unlock()
+}
+
+// state 获取用户的会话状态，带短期缓存
+func (s *SessionService) state(ctx context.Context, userID string) (sessionCacheEntry, error) {
+	s.mu.RLock()
+	entry, ok := s.cache[userID]
+	s.mu.RUnlock()
+	if ok && time.Since(entry.loadedAt) < sessionCacheTTL {
+		return entry, nil
+	}
+
+	user, err := s.userRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return sessionCacheEntry{}, err
+	}
+	entry = sessionCacheEntry{loadedAt: time.Now()}
+	if user != nil {
+		if user.TokensValidAfter != nil {
+			entry.validAfter = *user.TokensValidAfter
+		}
+		entry.mustChange = user.MustChangePassword
+		if entry.passwordExpiry, err = s.policyService.PasswordExpiresAt(ctx, user); err != nil {
+			return sessionCacheEntry{}, err
+		}
+	}
+
+	s.mu.Lock()
+	s.cache[userID] = entry
+	s.mu.Unlock()
+	return entry, nil
+}
@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	"YufungProject/internal/model"
@@ -11,29 +14,99 @@ import (
 
 // SystemConfigService 系统配置服务接口
 type SystemConfigService interface {
-	CreateSystemConfig(ctx context.Context, req *model.SystemConfigCreateRequest, userID string) (*model.SystemConfigResponse, error)
-	GetSystemConfigByID(ctx context.Context, configID string) (*model.SystemConfigResponse, error)
-	UpdateSystemConfig(ctx context.Context, configID string, req *model.SystemConfigUpdateRequest, userID string) (*model.SystemConfigResponse, error)
-	DeleteSystemConfig(ctx context.Context, configID string) error
-	ListSystemConfigs(ctx context.Context, req *model.SystemConfigQueryRequest) (*model.SystemConfigListResponse, error)
-	GetConfigsByType(ctx context.Context, configType string) ([]model.SystemConfigResponse, error)
+	CreateSystemConfig(ctx context.Context, req *model.SystemConfigCreateRequest, userID, companyID string, roleIDs []string) (*model.SystemConfigResponse, error)
+	GetSystemConfigByID(ctx context.Context, configID, companyID string, roleIDs []string) (*model.SystemConfigResponse, error)
+	UpdateSystemConfig(ctx context.Context, configID string, req *model.SystemConfigUpdateRequest, userID, companyID string, roleIDs []string) (*model.SystemConfigResponse, error)
+	DeleteSystemConfig(ctx context.Context, configID, companyID string, roleIDs []string) error
+	ListSystemConfigs(ctx context.Context, req *model.SystemConfigQueryRequest, companyID string, roleIDs []string) (*model.SystemConfigListResponse, error)
+	GetConfigsByType(ctx context.Context, configType, companyID string, req *model.SystemConfigOptionsRequest) ([]model.SystemConfigResponse, error)
+	GetConfigTree(ctx context.Context, configType, companyID string) ([]model.SystemConfigTreeNode, error)
+	NewDictionaryResolver(ctx context.Context, companyID string) (*DictionaryResolver, error)
+
+	// 公司对平台配置项的覆盖
+	SetConfigOverride(ctx context.Context, configID string, req *model.SystemConfigOverrideRequest, userID, companyID string) (*model.SystemConfigResponse, error)
+	RemoveConfigOverride(ctx context.Context, configID, companyID string) error
+
+	// 字典类型注册表
+	CreateConfigType(ctx context.Context, req *model.SystemConfigTypeCreateRequest, userID, companyID string, roleIDs []string) (*model.SystemConfigType, error)
+	UpdateConfigType(ctx context.Context, typeID string, req *model.SystemConfigTypeUpdateRequest, userID, companyID string, roleIDs []string) (*model.SystemConfigType, error)
+	DeleteConfigType(ctx context.Context, typeID, companyID string, roleIDs []string) error
+	ListConfigTypes(ctx context.Context, companyID string) ([]model.SystemConfigType, error)
 }
 
 type systemConfigService struct {
-	systemConfigRepo repository.SystemConfigRepository
+	systemConfigRepo     repository.SystemConfigRepository
+	systemConfigTypeRepo repository.SystemConfigTypeRepository
+	companyRepo          repository.CompanyRepository
 }
 
+// ErrConfigForbidden 无权维护平台级或其他公司的配置
+var ErrConfigForbidden = errors.New("无权限操作")
+
+// ErrConfigInvalid 配置请求参数不合法
+var ErrConfigInvalid = errors.New("参数错误")
+
+// checkConfigScope 平台管理员可维护平台级及各公司的配置，其他用户只能维护本公司的配置
+func checkConfigScope(ownerCompanyID, companyID string, roleIDs []string) error {
+	if model.IsPlatformAdminRoles(roleIDs) {
+		return nil
+	}
+	if ownerCompanyID == "" {
+		return fmt.Errorf("%w，平台级配置只能由平台管理员维护", ErrConfigForbidden)
+	}
+	if ownerCompanyID != companyID {
+		return fmt.Errorf("%w，不能维护其他公司的配置", ErrConfigForbidden)
+	}
+	return nil
+}
+
+// checkConfigVisible 平台管理员可查看所有配置，其他用户只能查看平台级及本公司的配置
+func checkConfigVisible(ownerCompanyID, companyID string, roleIDs []string) error {
+	if model.IsPlatformAdminRoles(roleIDs) || ownerCompanyID == "" || ownerCompanyID == companyID {
+		return nil
+	}
+	return fmt.Errorf("%w，不能查看其他公司的配置", ErrConfigForbidden)
+}
+
+// typeCodePattern 字典类型编码格式：小写字母开头，仅含小写字母、数字和下划线
+var typeCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
 // NewSystemConfigService 创建系统配置服务实例
-func NewSystemConfigService(systemConfigRepo repository.SystemConfigRepository) SystemConfigService {
+func NewSystemConfigService(systemConfigRepo repository.SystemConfigRepository, systemConfigTypeRepo repository.SystemConfigTypeRepository, companyRepo repository.CompanyRepository) SystemConfigService {
 	return &systemConfigService{
-		systemConfigRepo: systemConfigRepo,
+		systemConfigRepo:     systemConfigRepo,
+		systemConfigTypeRepo: systemConfigTypeRepo,
+		companyRepo:          companyRepo,
 	}
 }
 
 // CreateSystemConfig 创建系统配置
-func (s *systemConfigService) CreateSystemConfig(ctx context.Context, req *model.SystemConfigCreateRequest, userID string) (*model.SystemConfigResponse, error) {
-	// 验证配置键是否已存在
-	exists, err := s.systemConfigRepo.CheckKeyExists(ctx, req.ConfigType, req.ConfigKey, "", "")
+func (s *systemConfigService) CreateSystemConfig(ctx context.Context, req *model.SystemConfigCreateRequest, userID, companyID string, roleIDs []string) (*model.SystemConfigResponse, error) {
+	// 公司用户只能创建本公司配置项，平台级配置项（company_id为空）由平台管理员维护
+	if !model.IsPlatformAdminRoles(roleIDs) {
+		req.CompanyID = companyID
+	}
+	if err := checkConfigScope(req.CompanyID, companyID, roleIDs); err != nil {
+		return nil, err
+	}
+
+	// 验证配置类型是否已注册
+	configType, err := s.getConfigType(ctx, req.ConfigType, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	if configType.Status == "disable" {
+		return nil, fmt.Errorf("配置类型已禁用")
+	}
+
+	// 验证上级配置键
+	req.ParentKey = strings.TrimSpace(req.ParentKey)
+	if err := s.checkParentKey(ctx, configType, req.ParentKey, req.CompanyID); err != nil {
+		return nil, err
+	}
+
+	// 验证配置键是否已存在（公司配置键不能与继承的平台配置键重复）
+	exists, err := s.systemConfigRepo.CheckKeyExists(ctx, req.ConfigType, req.ConfigKey, req.CompanyID, "")
 	if err != nil {
 		return nil, fmt.Errorf("检查配置键失败: %v", err)
 	}
@@ -50,9 +123,10 @@ func (s *systemConfigService) CreateSystemConfig(ctx context.Context, req *model
 	config := &model.SystemConfig{
 		ConfigType:  req.ConfigType,
 		ConfigKey:   strings.TrimSpace(req.ConfigKey),
+		ParentKey:   req.ParentKey,
 		ConfigValue: strings.TrimSpace(req.ConfigValue),
 		DisplayName: strings.TrimSpace(req.DisplayName),
-		CompanyID:   "", // 移除公司ID限制
+		CompanyID:   req.CompanyID,
 		SortOrder:   req.SortOrder,
 		Status:      req.Status,
 		Remark:      req.Remark,
@@ -65,36 +139,47 @@ func (s *systemConfigService) CreateSystemConfig(ctx context.Context, req *model
 		return nil, fmt.Errorf("创建系统配置失败: %v", err)
 	}
 
-	return s.GetSystemConfigByID(ctx, config.ConfigID)
+	return s.GetSystemConfigByID(ctx, config.ConfigID, companyID, roleIDs)
 }
 
-// GetSystemConfigByID 根据ID获取系统配置
-func (s *systemConfigService) GetSystemConfigByID(ctx context.Context, configID string) (*model.SystemConfigResponse, error) {
+// GetSystemConfigByID 根据ID获取系统配置，非平台管理员只能获取平台级及本公司的配置
+func (s *systemConfigService) GetSystemConfigByID(ctx context.Context, configID, companyID string, roleIDs []string) (*model.SystemConfigResponse, error) {
 	config, err := s.systemConfigRepo.GetByID(ctx, configID)
 	if err != nil {
 		return nil, err
 	}
 
-	// 移除公司权限检查
-	// if config.CompanyID != companyID {
-	// 	return nil, fmt.Errorf("无权限访问该系统配置")
-	// }
+	if err := checkConfigVisible(config.CompanyID, companyID, roleIDs); err != nil {
+		return nil, err
+	}
 
 	return s.convertToResponse(config), nil
 }
 
 // UpdateSystemConfig 更新系统配置
-func (s *systemConfigService) UpdateSystemConfig(ctx context.Context, configID string, req *model.SystemConfigUpdateRequest, userID string) (*model.SystemConfigResponse, error) {
+func (s *systemConfigService) UpdateSystemConfig(ctx context.Context, configID string, req *model.SystemConfigUpdateRequest, userID, companyID string, roleIDs []string) (*model.SystemConfigResponse, error) {
 	// 获取现有配置
 	existingConfig, err := s.systemConfigRepo.GetByID(ctx, configID)
 	if err != nil {
 		return nil, err
 	}
 
-	// 移除公司权限检查
-	// if existingConfig.CompanyID != companyID {
-	// 	return nil, fmt.Errorf("无权限访问该系统配置")
-	// }
+	// 公司配置项只能由所属公司修改；平台配置项只能由平台管理员修改，公司请通过覆盖设置调整
+	if err := checkConfigScope(existingConfig.CompanyID, companyID, roleIDs); err != nil {
+		return nil, err
+	}
+
+	// 更新上级配置键
+	if parentKey := strings.TrimSpace(req.ParentKey); parentKey != "" && parentKey != existingConfig.ParentKey {
+		configType, err := s.getConfigType(ctx, existingConfig.ConfigType, existingConfig.CompanyID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkParentKey(ctx, configType, parentKey, existingConfig.CompanyID); err != nil {
+			return nil, err
+		}
+		existingConfig.ParentKey = parentKey
+	}
 
 	// 更新字段
 	if req.ConfigValue != "" {
@@ -119,28 +204,54 @@ func (s *systemConfigService) UpdateSystemConfig(ctx context.Context, configID s
 		return nil, fmt.Errorf("更新系统配置失败: %v", err)
 	}
 
-	return s.GetSystemConfigByID(ctx, configID)
+	return s.GetSystemConfigByID(ctx, configID, companyID, roleIDs)
 }
 
 // DeleteSystemConfig 删除系统配置
-func (s *systemConfigService) DeleteSystemConfig(ctx context.Context, configID string) error {
+func (s *systemConfigService) DeleteSystemConfig(ctx context.Context, configID, companyID string, roleIDs []string) error {
 	// 检查配置是否存在
-	_, err := s.systemConfigRepo.GetByID(ctx, configID)
+	config, err := s.systemConfigRepo.GetByID(ctx, configID)
 	if err != nil {
 		return err
 	}
 
-	// 移除公司权限检查
-	// if config.CompanyID != companyID {
-	// 	return fmt.Errorf("无权限删除该系统配置")
-	// }
+	// 平台配置项只能由平台管理员删除，公司请通过覆盖设置隐藏
+	if err := checkConfigScope(config.CompanyID, companyID, roleIDs); err != nil {
+		return err
+	}
+
+	// 存在下级配置项时不允许删除；公司配置项只统计所属公司可见的下级配置项，平台配置项统计所有公司
+	configTypes, err := s.ListConfigTypes(ctx, companyID)
+	if err != nil {
+		return err
+	}
+	count, err := s.systemConfigRepo.CountByParent(ctx, childConfigTypes(configTypes, config.ConfigType), config.ConfigKey, config.CompanyID)
+	if err != nil {
+		return fmt.Errorf("检查下级配置项失败: %v", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("该配置项下存在%d个下级配置项，无法删除", count)
+	}
 
 	return s.systemConfigRepo.Delete(ctx, configID)
 }
 
 // ListSystemConfigs 获取系统配置列表
-func (s *systemConfigService) ListSystemConfigs(ctx context.Context, req *model.SystemConfigQueryRequest) (*model.SystemConfigListResponse, error) {
-	result, err := s.systemConfigRepo.List(ctx, req, "") // 移除公司ID限制
+// 平台管理员可按公司筛选全部配置，其他用户只能查看平台级及本公司的配置
+func (s *systemConfigService) ListSystemConfigs(ctx context.Context, req *model.SystemConfigQueryRequest, companyID string, roleIDs []string) (*model.SystemConfigListResponse, error) {
+	var companyIDs []string
+	switch {
+	case model.IsPlatformAdminRoles(roleIDs):
+		if req.CompanyID != "" {
+			companyIDs = []string{req.CompanyID}
+		}
+	case req.CompanyID != "" && req.CompanyID == companyID:
+		companyIDs = []string{companyID}
+	default:
+		companyIDs = []string{"", companyID}
+	}
+
+	result, err := s.systemConfigRepo.List(ctx, req, companyIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -157,21 +268,216 @@ func (s *systemConfigService) ListSystemConfigs(ctx context.Context, req *model.
 	}, nil
 }
 
-// GetConfigsByType 根据配置类型获取配置选项
-func (s *systemConfigService) GetConfigsByType(ctx context.Context, configType string) ([]model.SystemConfigResponse, error) {
-	configs, err := s.systemConfigRepo.GetByType(ctx, configType, "") // 移除公司ID限制
+// GetConfigsByType 根据配置类型获取公司生效的配置选项（继承的平台配置项 + 公司自有配置项）
+// req.ParentKey不为空时只返回挂在该上级配置键下的配置项，用于级联选择
+func (s *systemConfigService) GetConfigsByType(ctx context.Context, configType, companyID string, req *model.SystemConfigOptionsRequest) ([]model.SystemConfigResponse, error) {
+	configs, err := s.getEffectiveConfigs(ctx, configType, companyID, req.IncludeHidden)
 	if err != nil {
 		return nil, err
 	}
 
+	if req.ParentKey == "" {
+		return configs, nil
+	}
+
 	var responses []model.SystemConfigResponse
 	for _, config := range configs {
-		responses = append(responses, *s.convertToResponse(&config))
+		if config.ParentKey == req.ParentKey {
+			responses = append(responses, config)
+		}
 	}
 
 	return responses, nil
 }
 
+// getEffectiveConfigs 合并平台配置项与公司配置项，应用公司的隐藏和覆盖设置后按排序返回
+func (s *systemConfigService) getEffectiveConfigs(ctx context.Context, configType, companyID string, includeHidden bool) ([]model.SystemConfigResponse, error) {
+	configs, err := s.systemConfigRepo.GetByType(ctx, configType, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]model.SystemConfigOverride)
+	if companyID != "" {
+		items, err := s.systemConfigRepo.GetOverrides(ctx, companyID, configType)
+		if err != nil {
+			return nil, err
+		}
+		for _, override := range items {
+			overrides[override.ConfigID] = override
+		}
+	}
+
+	responses := make([]model.SystemConfigResponse, 0, len(configs))
+	for i := range configs {
+		response := s.convertToResponse(&configs[i])
+		if override, ok := overrides[response.ConfigID]; ok && response.Source == model.ConfigSourcePlatform {
+			if override.Hidden {
+				if !includeHidden {
+					continue
+				}
+				response.Hidden = true
+			}
+			if override.DisplayName != "" {
+				response.DisplayName = override.DisplayName
+				response.Overridden = true
+			}
+			if override.SortOrder != nil {
+				response.SortOrder = *override.SortOrder
+				response.Overridden = true
+			}
+		}
+		responses = append(responses, *response)
+	}
+
+	sort.SliceStable(responses, func(i, j int) bool {
+		return responses[i].SortOrder < responses[j].SortOrder
+	})
+
+	return responses, nil
+}
+
+// SetConfigOverride 设置公司对平台配置项的隐藏、显示名称和排序覆盖
+func (s *systemConfigService) SetConfigOverride(ctx context.Context, configID string, req *model.SystemConfigOverrideRequest, userID, companyID string) (*model.SystemConfigResponse, error) {
+	if companyID == "" {
+		return nil, fmt.Errorf("%w，公司信息缺失", ErrConfigInvalid)
+	}
+
+	config, err := s.systemConfigRepo.GetByID(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+	if config.CompanyID != "" {
+		return nil, fmt.Errorf("%w，只能覆盖平台级配置项，公司配置项请直接修改", ErrConfigInvalid)
+	}
+
+	override := &model.SystemConfigOverride{
+		CompanyID:   companyID,
+		ConfigID:    configID,
+		ConfigType:  config.ConfigType,
+		Hidden:      req.Hidden,
+		DisplayName: strings.TrimSpace(req.DisplayName),
+		SortOrder:   req.SortOrder,
+		UpdatedBy:   userID,
+	}
+	if err := s.systemConfigRepo.UpsertOverride(ctx, override); err != nil {
+		return nil, fmt.Errorf("保存覆盖设置失败: %v", err)
+	}
+
+	configs, err := s.getEffectiveConfigs(ctx, config.ConfigType, companyID, true)
+	if err != nil {
+		return nil, err
+	}
+	for i := range configs {
+		if configs[i].ConfigID == configID {
+			return &configs[i], nil
+		}
+	}
+
+	// 平台配置项已禁用时不在生效列表中，直接返回平台值
+	return s.convertToResponse(config), nil
+}
+
+// RemoveConfigOverride 删除公司对平台配置项的覆盖设置，恢复为平台值
+func (s *systemConfigService) RemoveConfigOverride(ctx context.Context, configID, companyID string) error {
+	if companyID == "" {
+		return fmt.Errorf("%w，公司信息缺失", ErrConfigInvalid)
+	}
+
+	if _, err := s.systemConfigRepo.GetByID(ctx, configID); err != nil {
+		return err
+	}
+
+	return s.systemConfigRepo.DeleteOverride(ctx, companyID, configID)
+}
+
+// GetConfigTree 以树形结构获取配置选项，子节点为所有下级类型中挂在该配置项下的配置项
+func (s *systemConfigService) GetConfigTree(ctx context.Context, configType, companyID string) ([]model.SystemConfigTreeNode, error) {
+	if _, err := s.getConfigType(ctx, configType, companyID); err != nil {
+		return nil, err
+	}
+
+	configTypes, err := s.ListConfigTypes(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按类型缓存已加载的生效配置项
+	entries := make(map[string][]model.SystemConfigResponse)
+	load := func(typeCode string) ([]model.SystemConfigResponse, error) {
+		if configs, ok := entries[typeCode]; ok {
+			return configs, nil
+		}
+		configs, err := s.getEffectiveConfigs(ctx, typeCode, companyID, false)
+		if err != nil {
+			return nil, err
+		}
+		entries[typeCode] = configs
+		return configs, nil
+	}
+
+	// visited 记录当前路径上的类型，防止类型层级配置成环时无限递归
+	var build func(typeCode, parentKey string, root bool, visited map[string]bool) ([]model.SystemConfigTreeNode, error)
+	build = func(typeCode, parentKey string, root bool, visited map[string]bool) ([]model.SystemConfigTreeNode, error) {
+		if visited[typeCode] {
+			return nil, nil
+		}
+		visited[typeCode] = true
+		defer delete(visited, typeCode)
+
+		configs, err := load(typeCode)
+		if err != nil {
+			return nil, err
+		}
+
+		var nodes []model.SystemConfigTreeNode
+		for _, config := range configs {
+			if !root && config.ParentKey != parentKey {
+				continue
+			}
+
+			node := model.SystemConfigTreeNode{SystemConfigResponse: config}
+			for _, childType := range childConfigTypes(configTypes, typeCode) {
+				children, err := build(childType, config.ConfigKey, false, visited)
+				if err != nil {
+					return nil, err
+				}
+				node.Children = append(node.Children, children...)
+			}
+			nodes = append(nodes, node)
+		}
+
+		return nodes, nil
+	}
+
+	return build(configType, "", true, make(map[string]bool))
+}
+
+// checkParentKey 校验配置项的上级配置键与所属类型的层级关系是否一致
+// 公司配置项的上级可以是继承的平台配置项或本公司配置项，平台配置项的上级只能是平台配置项
+func (s *systemConfigService) checkParentKey(ctx context.Context, configType *model.SystemConfigType, parentKey, companyID string) error {
+	if configType.ParentType == "" {
+		if parentKey != "" {
+			return fmt.Errorf("配置类型%s没有上级类型，不能设置上级配置键", configType.TypeName)
+		}
+		return nil
+	}
+
+	if parentKey == "" {
+		return fmt.Errorf("配置类型%s必须指定上级配置键", configType.TypeName)
+	}
+
+	parent, err := s.systemConfigRepo.GetByKey(ctx, configType.ParentType, parentKey, companyID)
+	if err != nil {
+		return fmt.Errorf("检查上级配置键失败: %v", err)
+	}
+	if parent == nil {
+		return fmt.Errorf("上级配置键「%s」不存在", parentKey)
+	}
+
+	return nil
+}
+
 // convertToResponse 转换为响应格式
 func (s *systemConfigService) convertToResponse(config *model.SystemConfig) *model.SystemConfigResponse {
 	statusText := "启用"
@@ -179,17 +485,24 @@ func (s *systemConfigService) convertToResponse(config *model.SystemConfig) *mod
 		statusText = "禁用"
 	}
 
+	source := model.ConfigSourceCompany
+	if config.CompanyID == "" {
+		source = model.ConfigSourcePlatform
+	}
+
 	return &model.SystemConfigResponse{
 		ID:          config.ID.Hex(),
 		ConfigID:    config.ConfigID,
 		ConfigType:  config.ConfigType,
 		ConfigKey:   config.ConfigKey,
+		ParentKey:   config.ParentKey,
 		ConfigValue: config.ConfigValue,
 		DisplayName: config.DisplayName,
 		CompanyID:   config.CompanyID,
 		SortOrder:   config.SortOrder,
 		Status:      config.Status,
 		StatusText:  statusText,
+		Source:      source,
 		Remark:      config.Remark,
 		CreatedBy:   config.CreatedBy,
 		UpdatedBy:   config.UpdatedBy,
@@ -197,3 +510,316 @@ func (s *systemConfigService) convertToResponse(config *model.SystemConfig) *mod
 		UpdatedAt:   config.UpdatedAt.Format("2006-01-02 15:04:05"),
 	}
 }
+
+// NewDictionaryResolver 加载公司的字典校验模式及保单字典配置项
+func (s *systemConfigService) NewDictionaryResolver(ctx context.Context, companyID string) (*DictionaryResolver, error) {
+	resolver := &DictionaryResolver{
+		Mode:    model.DictionaryModeOff,
+		entries: make(map[string][]model.SystemConfigResponse),
+	}
+
+	if companyID != "" {
+		company, err := s.companyRepo.GetCompanyByID(ctx, companyID)
+		if err != nil {
+			return nil, fmt.Errorf("查询公司失败: %v", err)
+		}
+		if company != nil && company.DictionaryMode != "" {
+			resolver.Mode = company.DictionaryMode
+		}
+	}
+
+	for configType := range model.PolicyDictionaryFields {
+		configs, err := s.getEffectiveConfigs(ctx, configType, companyID, false)
+		if err != nil {
+			return nil, fmt.Errorf("加载字典配置失败: %v", err)
+		}
+		resolver.entries[configType] = configs
+	}
+
+	return resolver, nil
+}
+
+// DictionaryResolver 字典值解析器，按配置键或显示名称匹配公司生效的配置项
+type DictionaryResolver struct {
+	Mode    string
+	entries map[string][]model.SystemConfigResponse
+}
+
+// Enabled 是否需要执行字典校验
+func (r *DictionaryResolver) Enabled() bool {
+	return r != nil && (r.Mode == model.DictionaryModeWarn || r.Mode == model.DictionaryModeReject)
+}
+
+// Resolve 将输入值解析为规范的配置键，忽略首尾空格和大小写
+func (r *DictionaryResolver) Resolve(configType, value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if r == nil || value == "" {
+		return value, false
+	}
+
+	for _, config := range r.entries[configType] {
+		if strings.EqualFold(config.ConfigKey, value) || strings.EqualFold(config.DisplayName, value) {
+			return config.ConfigKey, true
+		}
+	}
+
+	return value, false
+}
+
+// Normalize 规范化一组字典字段（配置类型 -> 字段值指针），返回提示信息；reject模式下遇到未知值返回错误
+func (r *DictionaryResolver) Normalize(fields map[string]*string) ([]string, error) {
+	if !r.Enabled() {
+		return nil, nil
+	}
+
+	configTypes := make([]string, 0, len(fields))
+	for configType := range fields {
+		configTypes = append(configTypes, configType)
+	}
+	sort.Strings(configTypes)
+
+	var warnings []string
+	for _, configType := range configTypes {
+		value := fields[configType]
+		if value == nil || strings.TrimSpace(*value) == "" {
+			continue
+		}
+
+		key, ok := r.Resolve(configType, *value)
+		if ok {
+			*value = key
+			continue
+		}
+
+		message := fmt.Sprintf("%s「%s」不在系统配置中", model.GetFieldLabel("policies", model.PolicyDictionaryFields[configType]), *value)
+		if r.Mode == model.DictionaryModeReject {
+			return nil, errors.New(message)
+		}
+		warnings = append(warnings, message)
+	}
+
+	return warnings, nil
+}
+
+// ListConfigTypes 获取公司可见的字典类型（内置 + 平台级 + 公司级），注册表中的同编码类型覆盖内置类型
+func (s *systemConfigService) ListConfigTypes(ctx context.Context, companyID string) ([]model.SystemConfigType, error) {
+	stored, err := s.systemConfigTypeRepo.List(ctx, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("获取字典类型失败: %v", err)
+	}
+
+	registered := make(map[string]bool, len(stored))
+	for _, configType := range stored {
+		registered[configType.TypeCode] = true
+	}
+
+	configTypes := make([]model.SystemConfigType, 0, len(stored)+len(model.BuiltinSystemConfigTypes))
+	for _, builtin := range model.BuiltinSystemConfigTypes {
+		if !registered[builtin.TypeCode] {
+			builtin.Builtin = true
+			configTypes = append(configTypes, builtin)
+		}
+	}
+	configTypes = append(configTypes, stored...)
+
+	sort.SliceStable(configTypes, func(i, j int) bool {
+		return configTypes[i].SortOrder < configTypes[j].SortOrder
+	})
+
+	return configTypes, nil
+}
+
+// CreateConfigType 注册字典类型
+func (s *systemConfigService) CreateConfigType(ctx context.Context, req *model.SystemConfigTypeCreateRequest, userID, companyID string, roleIDs []string) (*model.SystemConfigType, error) {
+	// 非平台管理员未指定公司时注册为本公司类型
+	if req.CompanyID == "" && !model.IsPlatformAdminRoles(roleIDs) {
+		req.CompanyID = companyID
+	}
+	if err := checkConfigScope(req.CompanyID, companyID, roleIDs); err != nil {
+		return nil, err
+	}
+
+	req.TypeCode = strings.TrimSpace(req.TypeCode)
+	if !typeCodePattern.MatchString(req.TypeCode) {
+		return nil, fmt.Errorf("类型编码只能包含小写字母、数字和下划线，且以字母开头")
+	}
+
+	// 验证公司是否存在
+	if req.CompanyID != "" {
+		company, err := s.companyRepo.GetCompanyByID(ctx, req.CompanyID)
+		if err != nil || company == nil {
+			return nil, fmt.Errorf("指定的公司不存在")
+		}
+	}
+
+	// 验证类型编码是否已被占用，公司级类型不能覆盖内置类型
+	exists, err := s.systemConfigTypeRepo.CheckCodeExists(ctx, req.TypeCode, req.CompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("检查类型编码失败: %v", err)
+	}
+	if exists || (req.CompanyID != "" && isBuiltinConfigType(req.TypeCode)) {
+		return nil, fmt.Errorf("类型编码已存在")
+	}
+
+	// 验证上级类型
+	req.ParentType = strings.TrimSpace(req.ParentType)
+	if req.ParentType != "" {
+		configTypes, err := s.ListConfigTypes(ctx, req.CompanyID)
+		if err != nil {
+			return nil, err
+		}
+		if !hasConfigType(configTypes, req.ParentType) {
+			return nil, fmt.Errorf("上级类型「%s」不存在", req.ParentType)
+		}
+		if isAncestorConfigType(configTypes, req.ParentType, req.TypeCode) {
+			return nil, fmt.Errorf("上级类型不能是当前类型或其下级类型")
+		}
+	}
+
+	if req.Status == "" {
+		req.Status = "enable"
+	}
+
+	configType := &model.SystemConfigType{
+		TypeCode:   req.TypeCode,
+		TypeName:   strings.TrimSpace(req.TypeName),
+		ParentType: req.ParentType,
+		CompanyID:  req.CompanyID,
+		SortOrder:  req.SortOrder,
+		Status:     req.Status,
+		Remark:     req.Remark,
+		CreatedBy:  userID,
+		UpdatedBy:  userID,
+	}
+
+	if err := s.systemConfigTypeRepo.Create(ctx, configType); err != nil {
+		return nil, fmt.Errorf("创建字典类型失败: %v", err)
+	}
+
+	return configType, nil
+}
+
+// UpdateConfigType 更新字典类型，类型编码和上级类型创建后不可修改
+func (s *systemConfigService) UpdateConfigType(ctx context.Context, typeID string, req *model.SystemConfigTypeUpdateRequest, userID, companyID string, roleIDs []string) (*model.SystemConfigType, error) {
+	configType, err := s.systemConfigTypeRepo.GetByID(ctx, typeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkConfigScope(configType.CompanyID, companyID, roleIDs); err != nil {
+		return nil, err
+	}
+
+	if req.TypeName != "" {
+		configType.TypeName = strings.TrimSpace(req.TypeName)
+	}
+	if req.SortOrder > 0 {
+		configType.SortOrder = req.SortOrder
+	}
+	if req.Status != "" {
+		configType.Status = req.Status
+	}
+	if req.Remark != "" {
+		configType.Remark = req.Remark
+	}
+	configType.UpdatedBy = userID
+
+	if err := s.systemConfigTypeRepo.Update(ctx, typeID, configType); err != nil {
+		return nil, fmt.Errorf("更新字典类型失败: %v", err)
+	}
+
+	return s.systemConfigTypeRepo.GetByID(ctx, typeID)
+}
+
+// DeleteConfigType 删除字典类型，类型下存在配置项或下级类型时不允许删除
+func (s *systemConfigService) DeleteConfigType(ctx context.Context, typeID, companyID string, roleIDs []string) error {
+	configType, err := s.systemConfigTypeRepo.GetByID(ctx, typeID)
+	if err != nil {
+		return err
+	}
+	if err := checkConfigScope(configType.CompanyID, companyID, roleIDs); err != nil {
+		return err
+	}
+
+	// 公司类型只统计所属公司的配置项和下级类型，平台级类型统计所有公司
+	count, err := s.systemConfigRepo.CountByType(ctx, configType.TypeCode, configType.CompanyID)
+	if err != nil {
+		return fmt.Errorf("检查配置项失败: %v", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("该类型下存在%d个配置项，无法删除", count)
+	}
+
+	childCount, err := s.systemConfigTypeRepo.CountByParentType(ctx, configType.TypeCode, configType.CompanyID)
+	if err != nil {
+		return fmt.Errorf("检查下级类型失败: %v", err)
+	}
+	if childCount > 0 || (configType.CompanyID == "" && hasChildBuiltinConfigType(configType.TypeCode)) {
+		return fmt.Errorf("该类型存在下级类型，无法删除")
+	}
+
+	return s.systemConfigTypeRepo.Delete(ctx, typeID)
+}
+
+// getConfigType 获取公司可见的字典类型
+func (s *systemConfigService) getConfigType(ctx context.Context, typeCode, companyID string) (*model.SystemConfigType, error) {
+	configTypes, err := s.ListConfigTypes(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range configTypes {
+		if configTypes[i].TypeCode == typeCode {
+			return &configTypes[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("配置类型「%s」未注册", typeCode)
+}
+
+// childConfigTypes 返回以指定类型为上级类型的类型编码
+func childConfigTypes(configTypes []model.SystemConfigType, typeCode string) []string {
+	var children []string
+	for _, configType := range configTypes {
+		if configType.ParentType == typeCode {
+			children = append(children, configType.TypeCode)
+		}
+	}
+	return children
+}
+
+// hasConfigType 判断类型列表中是否包含指定编码
+func hasConfigType(configTypes []model.SystemConfigType, typeCode string) bool {
+	for _, configType := range configTypes {
+		if configType.TypeCode == typeCode {
+			return true
+		}
+	}
+	return false
+}
+
+// isAncestorConfigType 沿上级类型链向上查找，判断ancestor是否出现在typeCode的上级链（含自身）中
+func isAncestorConfigType(configTypes []model.SystemConfigType, typeCode, ancestor string) bool {
+	parents := make(map[string]string, len(configTypes))
+	for _, configType := range configTypes {
+		parents[configType.TypeCode] = configType.ParentType
+	}
+
+	visited := make(map[string]bool)
+	for current := typeCode; current != "" && !visited[current]; current = parents[current] {
+		if current == ancestor {
+			return true
+		}
+		visited[current] = true
+	}
+	return false
+}
+
+// isBuiltinConfigType 判断是否为内置类型编码
+func isBuiltinConfigType(typeCode string) bool {
+	return hasConfigType(model.BuiltinSystemConfigTypes, typeCode)
+}
+
+// hasChildBuiltinConfigType 判断内置类型中是否存在以指定类型为上级的类型
+func hasChildBuiltinConfigType(typeCode string) bool {
+	return len(childConfigTypes(model.BuiltinSystemConfigTypes, typeCode)) > 0
+}
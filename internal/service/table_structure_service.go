@@ -0,0 +1,1143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"YufungProject/internal/model"
+	"YufungProject/internal/repository"
+	"YufungProject/pkg/logger"
+	"YufungProject/pkg/validator"
+)
+
+// tableNamePattern 表名与字段名格式：小写字母开头，仅含小写字母、数字和下划线
+var tableNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// fieldRuleKeys 各字段类型支持的验证规则
+var fieldRuleKeys = map[string][]string{
+	model.FieldTypeString: {model.RuleMinLength, model.RuleMaxLength, model.RulePattern},
+	model.FieldTypeNumber: {model.RuleMin, model.RuleMax},
+	model.FieldTypeDate:   {model.RuleMinDate, model.RuleMaxDate},
+}
+
+// commonRuleKeys 所有字段类型均支持的验证规则
+var commonRuleKeys = []string{model.RuleRequiredIf}
+
+// TableStructureService 动态表结构业务逻辑层接口
+type TableStructureService interface {
+	// 表结构
+	CreateTable(ctx context.Context, req *model.TableStructureCreateRequest, companyID string) (*model.TableStructure, error)
+	GetTable(ctx context.Context, tableID, companyID string) (*model.TableStructureResponse, error)
+	UpdateTable(ctx context.Context, tableID string, req *model.TableStructureUpdateRequest, companyID string) (*model.TableStructure, error)
+	DeleteTable(ctx context.Context, tableID, companyID string) error
+	ListTables(ctx context.Context, req *model.TableStructureQueryRequest, companyID string) ([]model.TableStructure, error)
+
+	// 字段定义（每次变更发布新的表结构版本）
+	CreateField(ctx context.Context, tableID string, req *model.FieldDefinitionCreateRequest, userID, companyID string) (*model.FieldDefinition, error)
+	UpdateField(ctx context.Context, tableID, fieldID string, req *model.FieldDefinitionUpdateRequest, userID, companyID string) (*model.FieldDefinition, error)
+	DeleteField(ctx context.Context, tableID, fieldID, userID, companyID string) error
+	SortFields(ctx context.Context, tableID string, req *model.FieldSortRequest, userID, companyID string) ([]model.FieldDefinition, error)
+	SetFieldVisibility(ctx context.Context, tableID, fieldID string, visible bool, userID, companyID string) (*model.FieldDefinition, error)
+	MigrateField(ctx context.Context, tableID, fieldID string, req *model.FieldMigrationRequest, userID, companyID string) (*model.FieldMigrationResponse, error)
+	ListFields(ctx context.Context, tableID, companyID string) ([]model.FieldDefinition, error)
+
+	// 结构版本
+	ListVersions(ctx context.Context, tableID, companyID string) ([]model.TableStructureVersionSummary, error)
+	GetVersion(ctx context.Context, tableID string, version int, companyID string) (*model.TableStructureVersion, error)
+	DiffVersions(ctx context.Context, tableID string, req *model.TableStructureVersionDiffRequest, companyID string) (*model.TableStructureVersionDiff, error)
+
+	// 字段数据
+	NewCustomFieldSchema(ctx context.Context, tableName, companyID string) (*CustomFieldSchema, error)
+}
+
+type tableStructureService struct {
+	tableStructureRepo repository.TableStructureRepository
+}
+
+// NewTableStructureService 创建动态表结构业务逻辑层实例
+func NewTableStructureService(tableStructureRepo repository.TableStructureRepository) TableStructureService {
+	return &tableStructureService{
+		tableStructureRepo: tableStructureRepo,
+	}
+}
+
+// ==========================
+// 表结构
+// ==========================
+
+// CreateTable 创建表结构，公司用户创建本公司表，平台用户创建平台表
+func (s *tableStructureService) CreateTable(ctx context.Context, req *model.TableStructureCreateRequest, companyID string) (*model.TableStructure, error) {
+	tableName := strings.TrimSpace(req.TableName)
+	if !tableNamePattern.MatchString(tableName) {
+		return nil, fmt.Errorf("表名只能包含小写字母、数字和下划线，且以字母开头")
+	}
+
+	exists, err := s.tableStructureRepo.CheckTableNameExists(ctx, tableName, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("表名已存在")
+	}
+
+	if req.TableType == "" {
+		req.TableType = model.TableTypeCustom
+	}
+	if req.Status == "" {
+		req.Status = model.TableStatusActive
+	}
+
+	table := &model.TableStructure{
+		TableName:   tableName,
+		DisplayName: strings.TrimSpace(req.DisplayName),
+		TableType:   req.TableType,
+		CompanyID:   companyID,
+		Description: req.Description,
+		Status:      req.Status,
+	}
+
+	if err := s.tableStructureRepo.CreateTable(ctx, table); err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+// GetTable 获取表结构详情及字段定义
+func (s *tableStructureService) GetTable(ctx context.Context, tableID, companyID string) (*model.TableStructureResponse, error) {
+	table, err := s.getTable(ctx, tableID, companyID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := s.tableStructureRepo.ListFields(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TableStructureResponse{TableStructure: *table, Fields: fields}, nil
+}
+
+// UpdateTable 更新表结构的显示名称、描述和状态
+func (s *tableStructureService) UpdateTable(ctx context.Context, tableID string, req *model.TableStructureUpdateRequest, companyID string) (*model.TableStructure, error) {
+	if _, err := s.getTable(ctx, tableID, companyID, true); err != nil {
+		return nil, err
+	}
+
+	updates := bson.M{}
+	if name := strings.TrimSpace(req.DisplayName); name != "" {
+		updates["display_name"] = name
+	}
+	if req.Description != "" {
+		updates["description"] = req.Description
+	}
+	if req.Status != "" {
+		updates["status"] = req.Status
+	}
+
+	if err := s.tableStructureRepo.UpdateTable(ctx, tableID, updates); err != nil {
+		return nil, err
+	}
+
+	return s.tableStructureRepo.GetTableByID(ctx, tableID)
+}
+
+// DeleteTable 删除表结构，系统表及存在字段数据的表不允许删除
+func (s *tableStructureService) DeleteTable(ctx context.Context, tableID, companyID string) error {
+	table, err := s.getTable(ctx, tableID, companyID, true)
+	if err != nil {
+		return err
+	}
+	if table.TableType == model.TableTypeSystem {
+		return fmt.Errorf("系统表不允许删除")
+	}
+
+	fields, err := s.tableStructureRepo.ListFields(ctx, tableID)
+	if err != nil {
+		return err
+	}
+	for i := range fields {
+		if err := s.checkFieldHasNoData(ctx, table, &fields[i]); err != nil {
+			return err
+		}
+	}
+
+	return s.tableStructureRepo.DeleteTable(ctx, tableID)
+}
+
+// ListTables 获取公司可见的表结构列表
+func (s *tableStructureService) ListTables(ctx context.Context, req *model.TableStructureQueryRequest, companyID string) ([]model.TableStructure, error) {
+	return s.tableStructureRepo.ListTables(ctx, req, companyID)
+}
+
+// getTable 获取表结构并校验访问权限：可读取平台表和本公司表，只能修改本公司表（平台用户修改平台表）
+func (s *tableStructureService) getTable(ctx context.Context, tableID, companyID string, write bool) (*model.TableStructure, error) {
+	table, err := s.tableStructureRepo.GetTableByID(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	if table.CompanyID != "" && table.CompanyID != companyID {
+		return nil, fmt.Errorf("表结构不存在")
+	}
+	if write && table.CompanyID != companyID {
+		return nil, fmt.Errorf("无权限修改平台表结构")
+	}
+
+	return table, nil
+}
+
+// ==========================
+// 字段定义
+// ==========================
+
+// CreateField 为表结构新增字段
+func (s *tableStructureService) CreateField(ctx context.Context, tableID string, req *model.FieldDefinitionCreateRequest, userID, companyID string) (*model.FieldDefinition, error) {
+	if _, err := s.getTable(ctx, tableID, companyID, true); err != nil {
+		return nil, err
+	}
+
+	fieldName := strings.TrimSpace(req.FieldName)
+	if !tableNamePattern.MatchString(fieldName) {
+		return nil, fmt.Errorf("字段名只能包含小写字母、数字和下划线，且以字母开头")
+	}
+
+	exists, err := s.tableStructureRepo.CheckFieldNameExists(ctx, tableID, fieldName)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("字段名已存在")
+	}
+
+	field := &model.FieldDefinition{
+		TableID:         tableID,
+		FieldName:       fieldName,
+		DisplayName:     strings.TrimSpace(req.DisplayName),
+		FieldType:       req.FieldType,
+		FieldLength:     req.FieldLength,
+		Required:        req.Required,
+		DefaultValue:    strings.TrimSpace(req.DefaultValue),
+		EnumOptions:     req.EnumOptions,
+		ValidationRules: req.ValidationRules,
+		SortOrder:       req.SortOrder,
+		Visible:         req.Visible == nil || *req.Visible,
+	}
+	if err := checkFieldDefinition(field); err != nil {
+		return nil, err
+	}
+
+	// 未指定排序号时排在最后
+	if field.SortOrder <= 0 {
+		maxSortOrder, err := s.tableStructureRepo.GetMaxSortOrder(ctx, tableID)
+		if err != nil {
+			return nil, err
+		}
+		field.SortOrder = maxSortOrder + 1
+	}
+
+	if err := s.tableStructureRepo.CreateField(ctx, field); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.publishVersion(ctx, tableID, fmt.Sprintf("新增字段「%s」", field.DisplayName), userID); err != nil {
+		return nil, err
+	}
+
+	return field, nil
+}
+
+// UpdateField 更新字段定义，字段名和字段类型需通过迁移修改
+func (s *tableStructureService) UpdateField(ctx context.Context, tableID, fieldID string, req *model.FieldDefinitionUpdateRequest, userID, companyID string) (*model.FieldDefinition, error) {
+	field, err := s.getField(ctx, tableID, fieldID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	remark := fmt.Sprintf("修改字段「%s」", field.DisplayName)
+
+	updates := bson.M{}
+	if name := strings.TrimSpace(req.DisplayName); name != "" {
+		field.DisplayName = name
+		updates["display_name"] = name
+	}
+	if req.FieldLength != nil {
+		field.FieldLength = *req.FieldLength
+		updates["field_length"] = *req.FieldLength
+	}
+	if req.Required != nil {
+		field.Required = *req.Required
+		updates["required"] = *req.Required
+	}
+	if req.DefaultValue != nil {
+		field.DefaultValue = strings.TrimSpace(*req.DefaultValue)
+		updates["default_value"] = field.DefaultValue
+	}
+	if req.EnumOptions != nil {
+		field.EnumOptions = req.EnumOptions
+		updates["enum_options"] = req.EnumOptions
+	}
+	if req.ValidationRules != nil {
+		field.ValidationRules = req.ValidationRules
+		updates["validation_rules"] = req.ValidationRules
+	}
+	if req.SortOrder != nil {
+		field.SortOrder = *req.SortOrder
+		updates["sort_order"] = *req.SortOrder
+	}
+	if req.Visible != nil {
+		field.Visible = *req.Visible
+		updates["visible"] = *req.Visible
+	}
+
+	if err := checkFieldDefinition(field); err != nil {
+		return nil, err
+	}
+
+	if err := s.tableStructureRepo.UpdateField(ctx, fieldID, updates); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.publishVersion(ctx, tableID, remark, userID); err != nil {
+		return nil, err
+	}
+
+	return s.tableStructureRepo.GetFieldByID(ctx, fieldID)
+}
+
+// DeleteField 删除字段定义，已有数据的字段不允许删除（可改为隐藏）
+func (s *tableStructureService) DeleteField(ctx context.Context, tableID, fieldID, userID, companyID string) error {
+	field, err := s.getField(ctx, tableID, fieldID, companyID)
+	if err != nil {
+		return err
+	}
+
+	table, err := s.tableStructureRepo.GetTableByID(ctx, tableID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkFieldHasNoData(ctx, table, field); err != nil {
+		return err
+	}
+
+	if err := s.tableStructureRepo.DeleteField(ctx, fieldID); err != nil {
+		return err
+	}
+
+	_, err = s.publishVersion(ctx, tableID, fmt.Sprintf("删除字段「%s」", field.DisplayName), userID)
+	return err
+}
+
+// SortFields 按给定顺序重排字段，须包含表结构下的全部字段
+func (s *tableStructureService) SortFields(ctx context.Context, tableID string, req *model.FieldSortRequest, userID, companyID string) ([]model.FieldDefinition, error) {
+	if _, err := s.getTable(ctx, tableID, companyID, true); err != nil {
+		return nil, err
+	}
+
+	fields, err := s.tableStructureRepo.ListFields(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		existing[field.FieldID] = true
+	}
+	seen := make(map[string]bool, len(req.FieldIDs))
+	for _, fieldID := range req.FieldIDs {
+		if !existing[fieldID] {
+			return nil, fmt.Errorf("字段 %s 不属于该表结构", fieldID)
+		}
+		if seen[fieldID] {
+			return nil, fmt.Errorf("字段 %s 重复", fieldID)
+		}
+		seen[fieldID] = true
+	}
+	if len(seen) != len(fields) {
+		return nil, fmt.Errorf("排序须包含表结构的全部%d个字段", len(fields))
+	}
+
+	if err := s.tableStructureRepo.UpdateFieldSortOrders(ctx, tableID, req.FieldIDs); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.publishVersion(ctx, tableID, "调整字段顺序", userID); err != nil {
+		return nil, err
+	}
+
+	return s.tableStructureRepo.ListFields(ctx, tableID)
+}
+
+// SetFieldVisibility 设置字段是否在表单中显示
+func (s *tableStructureService) SetFieldVisibility(ctx context.Context, tableID, fieldID string, visible bool, userID, companyID string) (*model.FieldDefinition, error) {
+	field, err := s.getField(ctx, tableID, fieldID, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tableStructureRepo.UpdateField(ctx, fieldID, bson.M{"visible": visible}); err != nil {
+		return nil, err
+	}
+
+	remark := fmt.Sprintf("隐藏字段「%s」", field.DisplayName)
+	if visible {
+		remark = fmt.Sprintf("显示字段「%s」", field.DisplayName)
+	}
+	if _, err := s.publishVersion(ctx, tableID, remark, userID); err != nil {
+		return nil, err
+	}
+
+	return s.tableStructureRepo.GetFieldByID(ctx, fieldID)
+}
+
+// MigrateField 重命名字段或修改字段类型，并按新定义转换已有数据；存在无法转换的值时默认不执行，可选择清除这些值
+func (s *tableStructureService) MigrateField(ctx context.Context, tableID, fieldID string, req *model.FieldMigrationRequest, userID, companyID string) (*model.FieldMigrationResponse, error) {
+	field, err := s.getField(ctx, tableID, fieldID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	table, err := s.tableStructureRepo.GetTableByID(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 构建迁移后的字段定义
+	target := *field
+	if name := strings.TrimSpace(req.FieldName); name != "" && name != field.FieldName {
+		if !tableNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("字段名只能包含小写字母、数字和下划线，且以字母开头")
+		}
+		exists, err := s.tableStructureRepo.CheckFieldNameExists(ctx, tableID, name)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, fmt.Errorf("字段名已存在")
+		}
+		target.FieldName = name
+	}
+	if req.FieldType != "" && req.FieldType != field.FieldType {
+		// 类型变化时未重新指定的类型相关属性失效
+		target.FieldType = req.FieldType
+		target.FieldLength = 0
+		target.EnumOptions = nil
+		target.ValidationRules = nil
+		target.DefaultValue = ""
+	}
+	if req.FieldLength != nil {
+		target.FieldLength = *req.FieldLength
+	}
+	if req.EnumOptions != nil {
+		target.EnumOptions = req.EnumOptions
+	}
+	if req.ValidationRules != nil {
+		target.ValidationRules = req.ValidationRules
+	}
+	if req.DefaultValue != nil {
+		target.DefaultValue = strings.TrimSpace(*req.DefaultValue)
+	}
+	if reflect.DeepEqual(target, *field) {
+		return nil, fmt.Errorf("未指定需要迁移的字段变更")
+	}
+	if err := checkFieldDefinition(&target); err != nil {
+		return nil, err
+	}
+
+	// 按新定义逐条转换已有数据
+	records, err := s.tableStructureRepo.FindFieldData(ctx, table.TableName, field.FieldName, table.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.FieldMigrationResponse{
+		DryRun:     req.DryRun,
+		TotalCount: len(records),
+		Failures:   []model.FieldMigrationFailure{},
+		Version:    table.Version,
+	}
+	updates := make([]model.FieldDataUpdate, 0, len(records))
+	for _, record := range records {
+		value, err := ParseFieldValue(&target, FormatFieldValue(record.Value))
+		if err != nil {
+			response.Failures = append(response.Failures, model.FieldMigrationFailure{
+				RecordID: record.RecordID,
+				Value:    record.Value,
+				Error:    err.Error(),
+			})
+			updates = append(updates, model.FieldDataUpdate{ID: record.ID})
+			continue
+		}
+		updates = append(updates, model.FieldDataUpdate{ID: record.ID, Value: value})
+	}
+	response.FailedCount = len(response.Failures)
+	response.ConvertedCount = response.TotalCount - response.FailedCount
+
+	if req.DryRun || (response.FailedCount > 0 && !req.ClearInvalid) {
+		return response, nil
+	}
+
+	// 更新字段定义并发布新版本，再写回转换后的数据
+	if err := s.tableStructureRepo.UpdateField(ctx, fieldID, bson.M{
+		"field_name":       target.FieldName,
+		"field_type":       target.FieldType,
+		"field_length":     target.FieldLength,
+		"enum_options":     target.EnumOptions,
+		"validation_rules": target.ValidationRules,
+		"default_value":    target.DefaultValue,
+	}); err != nil {
+		return nil, err
+	}
+
+	remark := strings.TrimSpace(req.Remark)
+	if remark == "" {
+		remark = fmt.Sprintf("迁移字段「%s」", field.DisplayName)
+	}
+	version, err := s.publishVersion(ctx, tableID, remark, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tableStructureRepo.MigrateFieldData(ctx, table.TableName, field.FieldName, target.FieldName, updates, version); err != nil {
+		logger.Errorf("字段数据迁移失败: table=%s, field=%s, version=%d, error=%v", table.TableName, field.FieldName, version, err)
+		return nil, fmt.Errorf("字段定义已更新为版本%d，但数据迁移失败: %v", version, err)
+	}
+	logger.Infof("字段数据迁移完成: table=%s, field=%s -> %s, type=%s -> %s, converted=%d, cleared=%d, version=%d",
+		table.TableName, field.FieldName, target.FieldName, field.FieldType, target.FieldType, response.ConvertedCount, response.FailedCount, version)
+
+	migrated, err := s.tableStructureRepo.GetFieldByID(ctx, fieldID)
+	if err != nil {
+		return nil, err
+	}
+
+	response.Applied = true
+	response.Field = migrated
+	response.Version = version
+	return response, nil
+}
+
+// ListFields 获取表结构的字段定义，按排序号升序
+func (s *tableStructureService) ListFields(ctx context.Context, tableID, companyID string) ([]model.FieldDefinition, error) {
+	if _, err := s.getTable(ctx, tableID, companyID, false); err != nil {
+		return nil, err
+	}
+
+	return s.tableStructureRepo.ListFields(ctx, tableID)
+}
+
+// getField 获取字段定义并校验其所属表结构的修改权限
+func (s *tableStructureService) getField(ctx context.Context, tableID, fieldID, companyID string) (*model.FieldDefinition, error) {
+	if _, err := s.getTable(ctx, tableID, companyID, true); err != nil {
+		return nil, err
+	}
+
+	field, err := s.tableStructureRepo.GetFieldByID(ctx, fieldID)
+	if err != nil {
+		return nil, err
+	}
+	if field.TableID != tableID {
+		return nil, fmt.Errorf("字段定义不存在")
+	}
+
+	return field, nil
+}
+
+// checkFieldHasNoData 检查字段在表名对应集合中是否已有数据
+func (s *tableStructureService) checkFieldHasNoData(ctx context.Context, table *model.TableStructure, field *model.FieldDefinition) error {
+	count, err := s.tableStructureRepo.CountFieldData(ctx, table.TableName, field.FieldName, table.CompanyID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("字段「%s」已有%d条数据，无法删除，请改为隐藏", field.DisplayName, count)
+	}
+	return nil
+}
+
+// ==========================
+// 结构版本
+// ==========================
+
+// publishVersion 以当前字段定义发布新的表结构版本，返回新版本号
+func (s *tableStructureService) publishVersion(ctx context.Context, tableID, remark, userID string) (int, error) {
+	table, err := s.tableStructureRepo.GetTableByID(ctx, tableID)
+	if err != nil {
+		return 0, err
+	}
+	fields, err := s.tableStructureRepo.ListFields(ctx, tableID)
+	if err != nil {
+		return 0, err
+	}
+
+	version, err := s.tableStructureRepo.IncrementTableVersion(ctx, tableID)
+	if err != nil {
+		return 0, err
+	}
+
+	snapshot := &model.TableStructureVersion{
+		TableID:   tableID,
+		CompanyID: table.CompanyID,
+		Version:   version,
+		Fields:    fields,
+		Remark:    remark,
+		CreatedBy: userID,
+	}
+	if err := s.tableStructureRepo.CreateVersion(ctx, snapshot); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// ListVersions 获取表结构的版本列表，按版本号降序
+func (s *tableStructureService) ListVersions(ctx context.Context, tableID, companyID string) ([]model.TableStructureVersionSummary, error) {
+	if _, err := s.getTable(ctx, tableID, companyID, false); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.tableStructureRepo.ListVersions(ctx, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]model.TableStructureVersionSummary, 0, len(versions))
+	for _, version := range versions {
+		summaries = append(summaries, model.TableStructureVersionSummary{
+			VersionID:  version.VersionID,
+			Version:    version.Version,
+			FieldCount: len(version.Fields),
+			Remark:     version.Remark,
+			CreatedBy:  version.CreatedBy,
+			CreatedAt:  version.CreatedAt,
+		})
+	}
+
+	return summaries, nil
+}
+
+// GetVersion 获取表结构指定版本的字段快照
+func (s *tableStructureService) GetVersion(ctx context.Context, tableID string, version int, companyID string) (*model.TableStructureVersion, error) {
+	if _, err := s.getTable(ctx, tableID, companyID, false); err != nil {
+		return nil, err
+	}
+
+	return s.tableStructureRepo.GetVersion(ctx, tableID, version)
+}
+
+// DiffVersions 对比表结构两个版本的字段定义，按字段ID匹配（重命名和改类型的字段视为修改）
+func (s *tableStructureService) DiffVersions(ctx context.Context, tableID string, req *model.TableStructureVersionDiffRequest, companyID string) (*model.TableStructureVersionDiff, error) {
+	if _, err := s.getTable(ctx, tableID, companyID, false); err != nil {
+		return nil, err
+	}
+
+	var fromFields []model.FieldDefinition
+	if req.From > 0 {
+		from, err := s.tableStructureRepo.GetVersion(ctx, tableID, req.From)
+		if err != nil {
+			return nil, err
+		}
+		fromFields = from.Fields
+	}
+	to, err := s.tableStructureRepo.GetVersion(ctx, tableID, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &model.TableStructureVersionDiff{
+		From:    req.From,
+		To:      req.To,
+		Added:   []model.FieldDefinition{},
+		Removed: []model.FieldDefinition{},
+		Changed: []model.FieldVersionDiff{},
+	}
+
+	oldFields := make(map[string]model.FieldDefinition, len(fromFields))
+	for _, field := range fromFields {
+		oldFields[field.FieldID] = field
+	}
+	newFieldIDs := make(map[string]bool, len(to.Fields))
+	for _, field := range to.Fields {
+		newFieldIDs[field.FieldID] = true
+
+		old, exists := oldFields[field.FieldID]
+		if !exists {
+			diff.Added = append(diff.Added, field)
+			continue
+		}
+		if changes := diffFieldAttributes(old, field); len(changes) > 0 {
+			diff.Changed = append(diff.Changed, model.FieldVersionDiff{
+				FieldID:   field.FieldID,
+				FieldName: field.FieldName,
+				Changes:   changes,
+			})
+		}
+	}
+	for _, field := range fromFields {
+		if !newFieldIDs[field.FieldID] {
+			diff.Removed = append(diff.Removed, field)
+		}
+	}
+
+	return diff, nil
+}
+
+// diffFieldAttributes 对比字段定义的各项属性
+func diffFieldAttributes(old, new model.FieldDefinition) []model.FieldAttributeDiff {
+	attributes := []struct {
+		name     string
+		oldValue interface{}
+		newValue interface{}
+	}{
+		{"field_name", old.FieldName, new.FieldName},
+		{"display_name", old.DisplayName, new.DisplayName},
+		{"field_type", old.FieldType, new.FieldType},
+		{"field_length", old.FieldLength, new.FieldLength},
+		{"required", old.Required, new.Required},
+		{"default_value", old.DefaultValue, new.DefaultValue},
+		{"enum_options", old.EnumOptions, new.EnumOptions},
+		{"validation_rules", old.ValidationRules, new.ValidationRules},
+		{"sort_order", old.SortOrder, new.SortOrder},
+		{"visible", old.Visible, new.Visible},
+	}
+
+	var changes []model.FieldAttributeDiff
+	for _, attribute := range attributes {
+		if reflect.DeepEqual(attribute.oldValue, attribute.newValue) {
+			continue
+		}
+		changes = append(changes, model.FieldAttributeDiff{
+			Attribute: attribute.name,
+			OldValue:  attribute.oldValue,
+			NewValue:  attribute.newValue,
+		})
+	}
+	return changes
+}
+
+// ==========================
+// 字段数据
+// ==========================
+
+// NewCustomFieldSchema 加载公司可见的表结构字段定义，用于校验记录中的自定义字段；表结构不存在或已禁用时返回空模式
+func (s *tableStructureService) NewCustomFieldSchema(ctx context.Context, tableName, companyID string) (*CustomFieldSchema, error) {
+	schema := &CustomFieldSchema{byName: map[string]*model.FieldDefinition{}}
+
+	table, err := s.tableStructureRepo.GetTableByName(ctx, tableName, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if table == nil || table.Status != model.TableStatusActive {
+		return schema, nil
+	}
+
+	fields, err := s.tableStructureRepo.ListFields(ctx, table.TableID)
+	if err != nil {
+		return nil, err
+	}
+
+	schema.Table = table
+	schema.Fields = fields
+	for i := range schema.Fields {
+		schema.byName[schema.Fields[i].FieldName] = &schema.Fields[i]
+	}
+
+	return schema, nil
+}
+
+// CustomFieldSchema 自定义字段模式，按字段定义校验和转换记录中的 custom_fields
+type CustomFieldSchema struct {
+	Table  *model.TableStructure   // 表结构，为nil表示未定义自定义字段
+	Fields []model.FieldDefinition // 字段定义，按排序号升序
+	byName map[string]*model.FieldDefinition
+}
+
+// Version 当前表结构版本号，未定义表结构时为0
+func (s *CustomFieldSchema) Version() int {
+	if s == nil || s.Table == nil {
+		return 0
+	}
+	return s.Table.Version
+}
+
+// Field 根据字段名获取字段定义
+func (s *CustomFieldSchema) Field(fieldName string) *model.FieldDefinition {
+	if s == nil {
+		return nil
+	}
+	return s.byName[fieldName]
+}
+
+// VisibleFields 获取显示中的字段，用于导入模板和导出
+func (s *CustomFieldSchema) VisibleFields() []model.FieldDefinition {
+	if s == nil {
+		return nil
+	}
+
+	var fields []model.FieldDefinition
+	for _, field := range s.Fields {
+		if field.Visible {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Labels 获取字段名到显示名称的映射
+func (s *CustomFieldSchema) Labels() map[string]string {
+	labels := make(map[string]string)
+	if s == nil {
+		return labels
+	}
+	for _, field := range s.Fields {
+		labels[field.FieldName] = field.DisplayName
+	}
+	return labels
+}
+
+// Rules 获取自定义字段的验证规则（字段名为 custom_fields.<field_name>），与固定字段规则合并后统一校验
+func (s *CustomFieldSchema) Rules() []validator.Rule {
+	rules := make([]validator.Rule, 0, len(s.fieldsOrEmpty()))
+	for i := range s.fieldsOrEmpty() {
+		rules = append(rules, customFieldRule(&s.Fields[i]))
+	}
+	return rules
+}
+
+// Normalize 转换新建记录的自定义字段并补充默认值；必填、长度、范围等规则由规则引擎统一校验
+func (s *CustomFieldSchema) Normalize(values map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(values))
+	if err := s.apply(result, values); err != nil {
+		return nil, err
+	}
+
+	for _, field := range s.fieldsOrEmpty() {
+		if _, ok := result[field.FieldName]; ok || field.DefaultValue == "" {
+			continue
+		}
+		value, err := ParseFieldValue(&field, field.DefaultValue)
+		if err != nil {
+			return nil, fmt.Errorf("%s默认值无效: %v", field.DisplayName, err)
+		}
+		result[field.FieldName] = value
+	}
+
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+// NormalizeUpdate 将传入的自定义字段合并到当前值：值为空表示清除该字段，未传入的字段保持不变
+func (s *CustomFieldSchema) NormalizeUpdate(current, values map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(current)+len(values))
+	for key, value := range current {
+		result[key] = value
+	}
+
+	if err := s.apply(result, values); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// apply 按字段名顺序转换并写入传入的值，空值表示清除
+func (s *CustomFieldSchema) apply(result, values map[string]interface{}) error {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs validator.Errors
+	for _, name := range names {
+		field := s.Field(name)
+		if field == nil {
+			return fmt.Errorf("未定义的自定义字段「%s」", name)
+		}
+
+		raw := FormatFieldValue(values[name])
+		if strings.TrimSpace(raw) == "" {
+			delete(result, name)
+			continue
+		}
+
+		rule := customFieldRule(field)
+		value, err := rule.Parse(raw)
+		if err != nil {
+			errs = append(errs, err.(validator.FieldError))
+			continue
+		}
+		result[name] = customFieldStoredValue(value)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (s *CustomFieldSchema) fieldsOrEmpty() []model.FieldDefinition {
+	if s == nil {
+		return nil
+	}
+	return s.Fields
+}
+
+// FormatFieldValue 将自定义字段值格式化为文本，用于导出和重新解析
+func FormatFieldValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		if v {
+			return "是"
+		}
+		return "否"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case int:
+		return strconv.Itoa(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// ==========================
+// 字段定义校验
+// ==========================
+
+// checkFieldDefinition 校验字段长度、枚举选项、验证规则及默认值是否与字段类型匹配
+func checkFieldDefinition(field *model.FieldDefinition) error {
+	if field.FieldLength > 0 && field.FieldType != model.FieldTypeString {
+		return fmt.Errorf("只有文本字段可以设置字段长度")
+	}
+
+	if field.FieldType == model.FieldTypeEnum {
+		if len(field.EnumOptions) == 0 {
+			return fmt.Errorf("枚举字段至少需要一个选项")
+		}
+		seen := make(map[string]bool, len(field.EnumOptions))
+		for i, option := range field.EnumOptions {
+			option = strings.TrimSpace(option)
+			if option == "" {
+				return fmt.Errorf("枚举选项不能为空")
+			}
+			if seen[option] {
+				return fmt.Errorf("枚举选项「%s」重复", option)
+			}
+			seen[option] = true
+			field.EnumOptions[i] = option
+		}
+	} else if len(field.EnumOptions) > 0 {
+		return fmt.Errorf("只有枚举字段可以设置枚举选项")
+	}
+
+	if err := checkValidationRules(field.FieldType, field.ValidationRules); err != nil {
+		return err
+	}
+
+	if field.DefaultValue != "" {
+		if _, err := ParseFieldValue(field, field.DefaultValue); err != nil {
+			return fmt.Errorf("默认值无效: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// checkValidationRules 校验验证规则的键和值类型
+func checkValidationRules(fieldType string, rules map[string]interface{}) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	allowed := make(map[string]bool)
+	for _, key := range append(fieldRuleKeys[fieldType], commonRuleKeys...) {
+		allowed[key] = true
+	}
+
+	for key, value := range rules {
+		if !allowed[key] {
+			return fmt.Errorf("%s类型字段不支持验证规则%s", fieldType, key)
+		}
+		switch key {
+		case model.RulePattern:
+			pattern, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("验证规则%s须为字符串", key)
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("正则表达式无效: %v", err)
+			}
+		case model.RuleMinDate, model.RuleMaxDate:
+			date, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("验证规则%s须为日期字符串", key)
+			}
+			if _, err := time.Parse("2006-01-02", date); err != nil {
+				return fmt.Errorf("验证规则%s日期格式应为YYYY-MM-DD", key)
+			}
+		case model.RuleRequiredIf:
+			if _, err := requiredIfCondition(value); err != nil {
+				return err
+			}
+		default:
+			if _, ok := ruleNumber(value); !ok {
+				return fmt.Errorf("验证规则%s须为数字", key)
+			}
+		}
+	}
+
+	for _, pair := range [][2]string{{model.RuleMinLength, model.RuleMaxLength}, {model.RuleMin, model.RuleMax}} {
+		min, hasMin := ruleNumber(rules[pair[0]])
+		max, hasMax := ruleNumber(rules[pair[1]])
+		if hasMin && hasMax && min > max {
+			return fmt.Errorf("验证规则%s不能大于%s", pair[0], pair[1])
+		}
+	}
+	if minDate, ok := rules[model.RuleMinDate].(string); ok {
+		if maxDate, ok := rules[model.RuleMaxDate].(string); ok && minDate > maxDate {
+			return fmt.Errorf("验证规则%s不能晚于%s", model.RuleMinDate, model.RuleMaxDate)
+		}
+	}
+
+	return nil
+}
+
+// ParseFieldValue 按字段定义将文本值转换为对应类型，并执行长度、枚举及验证规则校验（日期以YYYY-MM-DD文本存储）
+func ParseFieldValue(field *model.FieldDefinition, raw string) (interface{}, error) {
+	rule := customFieldRule(field)
+	value, err := rule.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	value = customFieldStoredValue(value)
+	if err := rule.Check(value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// customFieldRule 将字段定义转换为规则引擎的字段规则；仅显示中的字段执行必填和条件必填
+func customFieldRule(field *model.FieldDefinition) validator.Rule {
+	rule := validator.Rule{
+		Field:    customFieldPath(field.FieldName),
+		Label:    field.DisplayName,
+		Type:     validator.TypeString,
+		Required: field.Required && field.Visible,
+	}
+	rules := field.ValidationRules
+
+	switch field.FieldType {
+	case model.FieldTypeNumber:
+		rule.Type = validator.TypeNumber
+		if min, ok := ruleNumber(rules[model.RuleMin]); ok {
+			rule.Min = &min
+		}
+		if max, ok := ruleNumber(rules[model.RuleMax]); ok {
+			rule.Max = &max
+		}
+	case model.FieldTypeDate:
+		rule.Type = validator.TypeDate
+		rule.MinDate, _ = rules[model.RuleMinDate].(string)
+		rule.MaxDate, _ = rules[model.RuleMaxDate].(string)
+	case model.FieldTypeBoolean:
+		rule.Type = validator.TypeBoolean
+	case model.FieldTypeEnum:
+		rule.Enum = field.EnumOptions
+	default:
+		if min, ok := ruleNumber(rules[model.RuleMinLength]); ok {
+			length := int(min)
+			rule.MinLength = &length
+		}
+		maxLength := field.FieldLength
+		if max, ok := ruleNumber(rules[model.RuleMaxLength]); ok && (maxLength == 0 || int(max) < maxLength) {
+			maxLength = int(max)
+		}
+		if maxLength > 0 {
+			rule.MaxLength = &maxLength
+		}
+		rule.Pattern, _ = rules[model.RulePattern].(string)
+	}
+
+	if field.Visible {
+		if cond, err := requiredIfCondition(rules[model.RuleRequiredIf]); err == nil {
+			rule.RequiredIf = cond
+		}
+	}
+
+	return rule
+}
+
+// requiredIfCondition 解析条件必填规则 {"field": "...", "values": [...]}，未设置时返回nil
+func requiredIfCondition(value interface{}) (*validator.Condition, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	m := customFieldMap(value)
+	field, _ := m["field"].(string)
+	if m == nil || strings.TrimSpace(field) == "" {
+		return nil, fmt.Errorf("验证规则%s须包含条件字段field", model.RuleRequiredIf)
+	}
+
+	cond := &validator.Condition{Field: strings.TrimSpace(field)}
+	switch values := m["values"].(type) {
+	case []string:
+		cond.Values = values
+	case []interface{}:
+		for _, v := range values {
+			cond.Values = append(cond.Values, FormatFieldValue(v))
+		}
+	case primitive.A:
+		for _, v := range values {
+			cond.Values = append(cond.Values, FormatFieldValue(v))
+		}
+	}
+	if len(cond.Values) == 0 {
+		return nil, fmt.Errorf("验证规则%s须包含条件值values", model.RuleRequiredIf)
+	}
+
+	return cond, nil
+}
+
+// customFieldStoredValue 将转换后的值调整为存储形式（日期存为YYYY-MM-DD文本）
+func customFieldStoredValue(value interface{}) interface{} {
+	if date, ok := value.(time.Time); ok {
+		return date.Format(validator.DateLayout)
+	}
+	return value
+}
+
+// customFieldPath 自定义字段在记录中的路径，用于规则引擎的字段名和错误定位
+func customFieldPath(fieldName string) string {
+	return model.CustomFieldsKey + "." + fieldName
+}
+
+// ruleNumber 读取数字类型的验证规则值（JSON解码为float64，BSON解码可能为整型）
+func ruleNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
@@ -18,7 +18,6 @@ import (
 
 	"github.com/xuri/excelize/v2"
 	"go.mongodb.org/mongo-driver/bson"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // UserService 用户服务接口
@@ -49,15 +48,19 @@ type UserService interface {
 
 // userService 用户服务实现
 type userService struct {
-	userRepo    repository.UserRepository
-	companyRepo repository.CompanyRepository
+	userRepo       repository.UserRepository
+	companyRepo    repository.CompanyRepository
+	policyService  *PasswordPolicyService
+	sessionService *SessionService
 }
 
 // NewUserService 创建用户服务实例
-func NewUserService(userRepo repository.UserRepository, companyRepo repository.CompanyRepository) UserService {
+func NewUserService(userRepo repository.UserRepository, companyRepo repository.CompanyRepository, policyService *PasswordPolicyService, sessionService *SessionService) UserService {
 	return &userService{
-		userRepo:    userRepo,
-		companyRepo: companyRepo,
+		userRepo:       userRepo,
+		companyRepo:    companyRepo,
+		policyService:  policyService,
+		sessionService: sessionService,
 	}
 }
 
@@ -83,29 +86,27 @@ func (s *userService) CreateUser(ctx context.Context, req *model.UserCreateReque
 		}
 	}
 
-	// 生成密码哈希
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return nil, fmt.Errorf("生成密码哈希失败: %w", err)
-	}
-
 	// 生成用户ID
 	userID := utils.GenerateID("user")
 
 	// 创建用户对象
 	user := &model.User{
-		UserID:       userID,
-		Username:     req.Username,
-		DisplayName:  req.DisplayName,
-		CompanyID:    req.CompanyID,
-		RoleIDs:      req.RoleIDs,
-		Status:       "active",
-		PasswordHash: string(passwordHash),
-		Email:        req.Email,
-		Phone:        req.Phone,
-		Remark:       req.Remark,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		UserID:      userID,
+		Username:    req.Username,
+		DisplayName: req.DisplayName,
+		CompanyID:   req.CompanyID,
+		RoleIDs:     req.RoleIDs,
+		Status:      "active",
+		Email:       req.Email,
+		Phone:       req.Phone,
+		Remark:      req.Remark,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	// 按密码策略校验初始密码，管理员设置的密码须由用户首次登录后修改
+	if err := s.policyService.PreparePassword(ctx, user, req.Password, true); err != nil {
+		return nil, err
 	}
 
 	// 保存到数据库
@@ -242,17 +243,24 @@ func (s *userService) DeleteUser(ctx context.Context, userID string) error {
 
 // ResetPassword 重置用户密码
 func (s *userService) ResetPassword(ctx context.Context, req *model.ResetPasswordRequest) error {
-	// 生成新密码哈希
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
-	if err != nil {
-		return fmt.Errorf("生成密码哈希失败: %w", err)
+	user, err := s.userRepo.GetByUserID(ctx, req.UserID)
+	if err != nil || user == nil {
+		return errors.New("用户不存在")
 	}
 
-	// 更新密码
-	if err := s.userRepo.UpdatePassword(ctx, req.UserID, string(passwordHash)); err != nil {
+	// 按密码策略校验并保存，管理员重置的密码须由用户登录后修改
+	if err := s.policyService.SetPassword(ctx, user, req.NewPassword, true); err != nil {
+		if IsPasswordPolicyError(err) {
+			return err
+		}
 		return fmt.Errorf("重置密码失败: %w", err)
 	}
 
+	// 重置后原有登录会话失效
+	if err := s.sessionService.RevokeUserSessions(ctx, req.UserID); err != nil {
+		logger.Errorf("吊销用户会话失败: %v, UserID: %s", err, req.UserID)
+	}
+
 	return nil
 }
 
@@ -300,6 +308,7 @@ func (s *userService) ExportUsers(ctx context.Context, filter map[string]interfa
 	if err != nil {
 		return nil, "", fmt.Errorf("查询用户数据失败: %w", err)
 	}
+	MaskUsers(ctx, users...)
 
 	// 创建Excel文件
 	f := excelize.NewFile()
@@ -440,7 +449,12 @@ func (s *userService) ExportUsersAdvanced(ctx context.Context, req *model.UserEx
 		// 生成模板文件
 		fileData, fileName, err = s.GenerateUserTemplate(ctx, req.Format)
 	} else {
-		// 生成数据文件
+		// 生成数据文件，按查看人权限脱敏
+		refs := make([]*model.UserInfo, len(users))
+		for i := range users {
+			refs[i] = &users[i]
+		}
+		MaskUserInfos(ctx, refs...)
 		fileData, fileName, err = s.generateUserDataFile(users, req.Format)
 	}
 
@@ -555,11 +569,21 @@ func (s *userService) processUserImport(ctx context.Context, file multipart.File
 				continue
 			}
 
-			// 实际导入
+			// 实际导入：模板中没有密码列，使用符合密码策略的随机临时密码，
+			// 用户通过忘记密码或管理员重置密码设置自己的密码
+			tempPassword, err := s.policyService.GenerateTemporaryPassword(ctx, user.CompanyID)
+			if err != nil {
+				response.Errors = append(response.Errors, model.UserImportError{
+					Row:    rowNum,
+					Errors: []string{"生成临时密码失败"},
+					Data:   record,
+				})
+				continue
+			}
 			createReq := &model.UserCreateRequest{
 				Username:    user.Username,
 				DisplayName: user.DisplayName,
-				Password:    "123456", // 默认密码，实际应该从记录中获取
+				Password:    tempPassword,
 				CompanyID:   user.CompanyID,
 				RoleIDs:     user.RoleIDs,
 				Email:       user.Email,
@@ -567,7 +591,7 @@ func (s *userService) processUserImport(ctx context.Context, file multipart.File
 				Remark:      "",
 			}
 
-			_, err := s.CreateUser(ctx, createReq)
+			_, err = s.CreateUser(ctx, createReq)
 			if err != nil {
 				response.Errors = append(response.Errors, model.UserImportError{
 					Row:    rowNum,
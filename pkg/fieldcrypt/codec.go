@@ -0,0 +1,155 @@
+package fieldcrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// ciphertextPrefix 密文前缀，没有该前缀的值视为尚未加密的明文
+	ciphertextPrefix = "enc:v1:"
+	// dataKeySize 数据密钥长度（AES-256）
+	dataKeySize = 32
+	// minIndexKeySize 盲索引密钥的最小长度
+	minIndexKeySize = 32
+)
+
+// KeyProvider 主密钥提供者：用主密钥加密（包装）和解密文档的数据密钥，主密钥本身不离开提供者，
+// 本地密钥文件见 KeyFileProvider，接入KMS时实现该接口
+type KeyProvider interface {
+	// ActiveKeyID 当前用于包装新数据密钥的主密钥ID
+	ActiveKeyID() string
+	// WrapKey 使用当前主密钥包装数据密钥，返回主密钥ID和包装后的数据密钥
+	WrapKey(ctx context.Context, dataKey []byte) (string, []byte, error)
+	// UnwrapKey 使用指定主密钥解开数据密钥
+	UnwrapKey(ctx context.Context, keyID string, wrappedKey []byte) ([]byte, error)
+	// BlindIndexKey 计算盲索引的HMAC密钥，更换后须重建全部盲索引
+	BlindIndexKey() []byte
+}
+
+// Codec 字段加密编解码器：每个文档使用独立的数据密钥以 AES-256-GCM 加密字段，
+// 数据密钥由主密钥包装后与文档一起保存（信封加密）；字段的盲索引用于精确查询
+type Codec struct {
+	provider KeyProvider
+	indexKey []byte
+}
+
+// NewCodec 创建字段加密编解码器
+func NewCodec(provider KeyProvider) (*Codec, error) {
+	if provider.ActiveKeyID() == "" {
+		return nil, errors.New("未配置当前主密钥")
+	}
+	indexKey := provider.BlindIndexKey()
+	if len(indexKey) < minIndexKeySize {
+		return nil, fmt.Errorf("盲索引密钥不能少于%d字节", minIndexKeySize)
+	}
+	return &Codec{provider: provider, indexKey: indexKey}, nil
+}
+
+// ActiveKeyID 当前主密钥ID
+func (c *Codec) ActiveKeyID() string {
+	return c.provider.ActiveKeyID()
+}
+
+// NewDataKey 生成新的数据密钥，返回明文数据密钥、主密钥ID和包装后的数据密钥
+func (c *Codec) NewDataKey(ctx context.Context) ([]byte, string, []byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, "", nil, err
+	}
+	keyID, wrappedKey, err := c.provider.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return dataKey, keyID, wrappedKey, nil
+}
+
+// UnwrapDataKey 解开文档的数据密钥
+func (c *Codec) UnwrapDataKey(ctx context.Context, keyID string, wrappedKey []byte) ([]byte, error) {
+	return c.provider.UnwrapKey(ctx, keyID, wrappedKey)
+}
+
+// RewrapDataKey 使用当前主密钥重新包装数据密钥，字段密文不变
+func (c *Codec) RewrapDataKey(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	return c.provider.WrapKey(ctx, dataKey)
+}
+
+// BlindIndex 计算字段值的盲索引：规范化后的值以字段名区分后计算HMAC，空值没有盲索引
+func (c *Codec) BlindIndex(field, value string) string {
+	normalized := Normalize(value)
+	if normalized == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, c.indexKey)
+	mac.Write([]byte(field))
+	mac.Write([]byte{0})
+	mac.Write([]byte(normalized))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Normalize 规范化字段值用于盲索引：去除首尾空白、合并连续空白并转为小写
+func Normalize(value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(value), " "))
+}
+
+// IsEncrypted 是否为密文
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, ciphertextPrefix)
+}
+
+// Encrypt 使用数据密钥加密字段值，字段名作为附加数据，密文不能挪用到其他字段；空值不加密
+func Encrypt(dataKey []byte, field, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), []byte(field))
+	return ciphertextPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 使用数据密钥解密字段值，不是密文的值原样返回
+func Decrypt(dataKey []byte, field, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, ciphertextPrefix))
+	if err != nil {
+		return "", fmt.Errorf("字段 %s 密文格式错误: %w", field, err)
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("字段 %s 密文格式错误", field)
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(field))
+	if err != nil {
+		return "", fmt.Errorf("字段 %s 解密失败: %w", field, err)
+	}
+	return string(plaintext), nil
+}
+
+// newGCM 创建 AES-GCM 加密器
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,301 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// randomKey 生成随机密钥
+func randomKey(t *testing.T, size int) []byte {
+	t.Helper()
+	key := make([]byte, size)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// writeKeyFile 写入临时密钥文件，keys 为主密钥ID到密钥的映射
+func writeKeyFile(t *testing.T, activeKeyID string, keys map[string][]byte, indexKey []byte) string {
+	t.Helper()
+	file := keyFile{
+		ActiveKeyID: activeKeyID,
+		Keys:        make(map[string]string, len(keys)),
+		IndexKey:    base64.StdEncoding.EncodeToString(indexKey),
+	}
+	for keyID, key := range keys {
+		file.Keys[keyID] = base64.StdEncoding.EncodeToString(key)
+	}
+	data, err := json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "field-keys.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newTestCodec 使用随机主密钥和盲索引密钥创建编解码器
+func newTestCodec(t *testing.T, indexKey []byte) *Codec {
+	t.Helper()
+	provider, err := LoadKeyFile(writeKeyFile(t, "k1", map[string][]byte{"k1": randomKey(t, masterKeySize)}, indexKey))
+	if err != nil {
+		t.Fatalf("LoadKeyFile() error = %v", err)
+	}
+	codec, err := NewCodec(provider)
+	if err != nil {
+		t.Fatalf("NewCodec() error = %v", err)
+	}
+	return codec
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	dataKey := randomKey(t, dataKeySize)
+
+	tests := []struct {
+		name      string
+		plaintext string
+	}{
+		{name: "手机号", plaintext: "13800138000"},
+		{name: "身份证号", plaintext: "11010519491231002X"},
+		{name: "中文地址", plaintext: "北京市朝阳区建国路 88 号"},
+		{name: "首尾空白保留", plaintext: "  abc  "},
+		{name: "空值", plaintext: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ciphertext, err := Encrypt(dataKey, "phone", tt.plaintext)
+			if err != nil {
+				t.Fatalf("Encrypt() error = %v", err)
+			}
+			if tt.plaintext == "" {
+				if ciphertext != "" {
+					t.Fatalf("空值不应加密, got %q", ciphertext)
+				}
+			} else {
+				if !IsEncrypted(ciphertext) || strings.Contains(ciphertext, tt.plaintext) {
+					t.Fatalf("Encrypt() = %q，应为不含明文的密文", ciphertext)
+				}
+			}
+
+			got, err := Decrypt(dataKey, "phone", ciphertext)
+			if err != nil {
+				t.Fatalf("Decrypt() error = %v", err)
+			}
+			if got != tt.plaintext {
+				t.Errorf("Decrypt() = %q, want %q", got, tt.plaintext)
+			}
+		})
+	}
+}
+
+func TestEncryptUsesRandomNonce(t *testing.T) {
+	dataKey := randomKey(t, dataKeySize)
+	first, _ := Encrypt(dataKey, "phone", "13800138000")
+	second, _ := Encrypt(dataKey, "phone", "13800138000")
+	if first == second {
+		t.Errorf("相同明文两次加密的密文不应相同")
+	}
+}
+
+func TestDecryptFailures(t *testing.T) {
+	dataKey := randomKey(t, dataKeySize)
+	ciphertext, err := Encrypt(dataKey, "phone", "13800138000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 修改密文中间的一个字符，避开只影响 base64 填充位的末尾字符
+	tampered := []byte(ciphertext)
+	mid := len(ciphertextPrefix) + (len(ciphertext)-len(ciphertextPrefix))/2
+	if tampered[mid] == 'A' {
+		tampered[mid] = 'B'
+	} else {
+		tampered[mid] = 'A'
+	}
+
+	tests := []struct {
+		name  string
+		key   []byte
+		field string
+		value string
+	}{
+		{name: "数据密钥错误", key: randomKey(t, dataKeySize), field: "phone", value: ciphertext},
+		{name: "密文挪用到其他字段", key: dataKey, field: "email", value: ciphertext},
+		{name: "密文被篡改", key: dataKey, field: "phone", value: string(tampered)},
+		{name: "密文不是base64", key: dataKey, field: "phone", value: ciphertextPrefix + "!!!"},
+		{name: "密文过短", key: dataKey, field: "phone", value: ciphertextPrefix + "AAAA"},
+		{name: "数据密钥长度无效", key: []byte("short"), field: "phone", value: ciphertext},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Decrypt(tt.key, tt.field, tt.value); err == nil {
+				t.Errorf("Decrypt() = %q, want error", got)
+			}
+		})
+	}
+}
+
+func TestDecryptPlaintextPassThrough(t *testing.T) {
+	got, err := Decrypt(randomKey(t, dataKeySize), "phone", "13800138000")
+	if err != nil || got != "13800138000" {
+		t.Errorf("Decrypt() = %q, %v，尚未加密的明文应原样返回", got, err)
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	indexKey := randomKey(t, minIndexKeySize)
+	codec := newTestCodec(t, indexKey)
+	sameKeyCodec := newTestCodec(t, indexKey) // 主密钥不同，盲索引密钥相同
+	otherCodec := newTestCodec(t, randomKey(t, minIndexKeySize))
+
+	base := codec.BlindIndex("email", "alice@example.com")
+	if base == "" {
+		t.Fatal("BlindIndex() 返回空值")
+	}
+
+	tests := []struct {
+		name  string
+		got   string
+		equal bool
+	}{
+		{name: "重复计算结果稳定", got: codec.BlindIndex("email", "alice@example.com"), equal: true},
+		{name: "与主密钥无关", got: sameKeyCodec.BlindIndex("email", "alice@example.com"), equal: true},
+		{name: "忽略大小写和首尾空白", got: codec.BlindIndex("email", "  Alice@Example.COM "), equal: true},
+		{name: "不同的值", got: codec.BlindIndex("email", "bob@example.com"), equal: false},
+		{name: "不同的字段", got: codec.BlindIndex("contact", "alice@example.com"), equal: false},
+		{name: "不同的盲索引密钥", got: otherCodec.BlindIndex("email", "alice@example.com"), equal: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (tt.got == base) != tt.equal {
+				t.Errorf("BlindIndex() = %q, base = %q, want equal = %v", tt.got, base, tt.equal)
+			}
+		})
+	}
+
+	if got := codec.BlindIndex("email", "   "); got != "" {
+		t.Errorf("空值的盲索引 = %q, want empty", got)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"  Zhang  San ", "zhang san"},
+		{"张\t三", "张 三"},
+		{"ABC", "abc"},
+		{" \n ", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.value); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCodecEnvelopeAndRotation(t *testing.T) {
+	ctx := context.Background()
+	oldKey, newKey := randomKey(t, masterKeySize), randomKey(t, masterKeySize)
+	indexKey := randomKey(t, minIndexKeySize)
+
+	oldProvider, err := LoadKeyFile(writeKeyFile(t, "2026-04", map[string][]byte{"2026-04": oldKey}, indexKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldCodec, err := NewCodec(oldProvider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dataKey, keyID, wrappedKey, err := oldCodec.NewDataKey(ctx)
+	if err != nil {
+		t.Fatalf("NewDataKey() error = %v", err)
+	}
+	if keyID != "2026-04" || bytes.Contains(wrappedKey, dataKey) {
+		t.Fatalf("NewDataKey() keyID = %q，包装后的数据密钥不应包含明文", keyID)
+	}
+	ciphertext, err := Encrypt(dataKey, "phone", "13800138000")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 轮换主密钥：新主密钥签发，旧主密钥保留用于解开旧数据密钥
+	rotated, err := LoadKeyFile(writeKeyFile(t, "2026-10", map[string][]byte{"2026-10": newKey, "2026-04": oldKey}, indexKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	codec, err := NewCodec(rotated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unwrapped, err := codec.UnwrapDataKey(ctx, keyID, wrappedKey)
+	if err != nil {
+		t.Fatalf("UnwrapDataKey() error = %v", err)
+	}
+	newKeyID, rewrapped, err := codec.RewrapDataKey(ctx, unwrapped)
+	if err != nil || newKeyID != "2026-10" {
+		t.Fatalf("RewrapDataKey() = %q, %v", newKeyID, err)
+	}
+	unwrapped, err = codec.UnwrapDataKey(ctx, newKeyID, rewrapped)
+	if err != nil {
+		t.Fatalf("UnwrapDataKey() error = %v", err)
+	}
+	if got, err := Decrypt(unwrapped, "phone", ciphertext); err != nil || got != "13800138000" {
+		t.Errorf("重新包装后解密 = %q, %v", got, err)
+	}
+
+	// 包装时主密钥ID作为附加数据，换用其他主密钥ID解开应失败
+	if _, err := codec.UnwrapDataKey(ctx, "2026-10", wrappedKey); err == nil {
+		t.Errorf("使用错误的主密钥解开数据密钥应失败")
+	}
+	if _, err := oldCodec.UnwrapDataKey(ctx, "2026-10", rewrapped); err == nil {
+		t.Errorf("密钥文件中没有的主密钥应报错")
+	}
+}
+
+func TestLoadKeyFileInvalid(t *testing.T) {
+	masterKey := randomKey(t, masterKeySize)
+	indexKey := randomKey(t, minIndexKeySize)
+
+	tests := []struct {
+		name        string
+		activeKeyID string
+		keys        map[string][]byte
+		indexKey    []byte
+	}{
+		{name: "缺少当前主密钥", activeKeyID: "k2", keys: map[string][]byte{"k1": masterKey}, indexKey: indexKey},
+		{name: "主密钥长度错误", activeKeyID: "k1", keys: map[string][]byte{"k1": randomKey(t, 16)}, indexKey: indexKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadKeyFile(writeKeyFile(t, tt.activeKeyID, tt.keys, tt.indexKey)); err == nil {
+				t.Errorf("LoadKeyFile() error = nil, want error")
+			}
+		})
+	}
+
+	provider, err := LoadKeyFile(writeKeyFile(t, "k1", map[string][]byte{"k1": masterKey}, randomKey(t, 16)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCodec(provider); err == nil {
+		t.Errorf("盲索引密钥过短时 NewCodec() 应报错")
+	}
+}
@@ -0,0 +1,111 @@
+package fieldcrypt
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// masterKeySize 主密钥长度（AES-256）
+const masterKeySize = 32
+
+// keyFile 本地密钥文件格式，密钥均为 base64 编码的32字节随机数
+//
+//	{
+//	  "active_key_id": "2026-10",
+//	  "keys": {"2026-10": "...", "2026-04": "..."},
+//	  "index_key": "..."
+//	}
+type keyFile struct {
+	ActiveKeyID string            `json:"active_key_id"` // 当前主密钥ID，新数据密钥使用该主密钥包装
+	Keys        map[string]string `json:"keys"`          // 全部主密钥，轮换后旧主密钥须保留到重新加密完成
+	IndexKey    string            `json:"index_key"`     // 盲索引密钥
+}
+
+// KeyFileProvider 从本地密钥文件加载主密钥，使用 AES-256-GCM 包装数据密钥
+type KeyFileProvider struct {
+	activeKeyID string
+	keys        map[string][]byte
+	indexKey    []byte
+}
+
+// LoadKeyFile 加载本地密钥文件
+func LoadKeyFile(path string) (*KeyFileProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("密钥文件格式错误: %w", err)
+	}
+
+	provider := &KeyFileProvider{
+		activeKeyID: file.ActiveKeyID,
+		keys:        make(map[string][]byte, len(file.Keys)),
+	}
+	for keyID, encoded := range file.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != masterKeySize {
+			return nil, fmt.Errorf("主密钥 %s 须为 base64 编码的%d字节", keyID, masterKeySize)
+		}
+		provider.keys[keyID] = key
+	}
+	if _, ok := provider.keys[file.ActiveKeyID]; !ok {
+		return nil, fmt.Errorf("密钥文件中没有当前主密钥 %q", file.ActiveKeyID)
+	}
+	provider.indexKey, err = base64.StdEncoding.DecodeString(file.IndexKey)
+	if err != nil {
+		return nil, errors.New("盲索引密钥须为 base64 编码")
+	}
+
+	return provider, nil
+}
+
+// ActiveKeyID 当前主密钥ID
+func (p *KeyFileProvider) ActiveKeyID() string {
+	return p.activeKeyID
+}
+
+// WrapKey 使用当前主密钥包装数据密钥，主密钥ID作为附加数据
+func (p *KeyFileProvider) WrapKey(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	gcm, err := newGCM(p.keys[p.activeKeyID])
+	if err != nil {
+		return "", nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	return p.activeKeyID, gcm.Seal(nonce, nonce, dataKey, []byte(p.activeKeyID)), nil
+}
+
+// UnwrapKey 使用指定主密钥解开数据密钥
+func (p *KeyFileProvider) UnwrapKey(ctx context.Context, keyID string, wrappedKey []byte) ([]byte, error) {
+	masterKey, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("密钥文件中没有主密钥 %q", keyID)
+	}
+	gcm, err := newGCM(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrappedKey) < gcm.NonceSize() {
+		return nil, errors.New("数据密钥格式错误")
+	}
+	dataKey, err := gcm.Open(nil, wrappedKey[:gcm.NonceSize()], wrappedKey[gcm.NonceSize():], []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("解开数据密钥失败: %w", err)
+	}
+	return dataKey, nil
+}
+
+// BlindIndexKey 盲索引密钥
+func (p *KeyFileProvider) BlindIndexKey() []byte {
+	return p.indexKey
+}
@@ -0,0 +1,74 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// unsafeFileChars 文件名中不允许出现的字符
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// FileMailer 将邮件写入本地 .eml 文件而不真正发送，用于开发和测试环境
+type FileMailer struct {
+	dir string
+}
+
+// NewFileMailer 创建文件邮件发送器，目录不存在时自动创建
+func NewFileMailer(dir string) (*FileMailer, error) {
+	if strings.TrimSpace(dir) == "" {
+		dir = "./mail_outbox"
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(absDir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建邮件输出目录失败: %v", err)
+	}
+	return &FileMailer{dir: absDir}, nil
+}
+
+// Send 将邮件写入 <目录>/<日期>/<时间>_<邮件ID>.eml
+func (m *FileMailer) Send(ctx context.Context, msg *Message) error {
+	now := time.Now()
+	data, err := msg.Build(now)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(m.dir, now.Format("20060102"))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	name := unsafeFileChars.ReplaceAllString(msg.ID, "_")
+	if name == "" {
+		name = randomToken(8)
+	}
+	path := filepath.Join(dir, now.Format("150405")+"_"+name+".eml")
+
+	// 先写入临时文件再重命名，避免读取到写了一半的文件
+	tmp, err := os.CreateTemp(dir, ".mail-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
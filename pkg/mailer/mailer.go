@@ -0,0 +1,146 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Message 待发送的邮件
+type Message struct {
+	ID       string   // 邮件唯一标识，用于Message-ID和文件名
+	From     string   // 发件人地址
+	FromName string   // 发件人名称
+	To       []string // 收件人地址
+	Subject  string   // 主题
+	TextBody string   // 纯文本正文
+	HTMLBody string   // HTML正文，为空时只发送纯文本
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	// Send 发送邮件；返回 PermanentError 表示重试也不会成功（如收件人地址被拒收）
+	Send(ctx context.Context, msg *Message) error
+}
+
+// PermanentError 不可重试的发送错误
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanent 判断错误是否不可重试
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// validate 校验邮件的发件人和收件人
+func (m *Message) validate() error {
+	if _, err := mail.ParseAddress(m.From); err != nil {
+		return &PermanentError{Err: fmt.Errorf("发件人地址无效: %s", m.From)}
+	}
+	if len(m.To) == 0 {
+		return &PermanentError{Err: errors.New("收件人不能为空")}
+	}
+	for _, to := range m.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return &PermanentError{Err: fmt.Errorf("收件人地址无效: %s", to)}
+		}
+	}
+	return nil
+}
+
+// Build 生成RFC 5322格式的邮件内容，同时包含纯文本和HTML正文时使用 multipart/alternative
+func (m *Message) Build(now time.Time) ([]byte, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	from := mail.Address{Name: m.FromName, Address: m.From}
+	writeHeader(&buf, "From", from.String())
+	writeHeader(&buf, "To", strings.Join(m.To, ", "))
+	writeHeader(&buf, "Subject", mime.BEncoding.Encode("UTF-8", m.Subject))
+	writeHeader(&buf, "Date", now.Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", "<"+m.messageID()+">")
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	if m.HTMLBody == "" {
+		writeHeader(&buf, "Content-Type", "text/plain; charset=UTF-8")
+		writeHeader(&buf, "Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, m.TextBody); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	boundary := randomToken(12)
+	writeHeader(&buf, "Content-Type", `multipart/alternative; boundary="`+boundary+`"`)
+	buf.WriteString("\r\n")
+	for _, part := range []struct {
+		contentType string
+		body        string
+	}{{"text/plain", m.TextBody}, {"text/html", m.HTMLBody}} {
+		buf.WriteString("--" + boundary + "\r\n")
+		writeHeader(&buf, "Content-Type", part.contentType+"; charset=UTF-8")
+		writeHeader(&buf, "Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, part.body); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\r\n")
+	}
+	buf.WriteString("--" + boundary + "--\r\n")
+
+	return buf.Bytes(), nil
+}
+
+// messageID 生成Message-ID，域名取发件人地址的域名部分
+func (m *Message) messageID() string {
+	id := m.ID
+	if id == "" {
+		id = randomToken(16)
+	}
+	domain := "localhost"
+	if at := strings.LastIndex(m.From, "@"); at >= 0 && at < len(m.From)-1 {
+		domain = m.From[at+1:]
+	}
+	return id + "@" + domain
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	// 去除换行，防止邮件头注入
+	value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+	buf.WriteString(key + ": " + value + "\r\n")
+}
+
+func writeQuotedPrintable(buf *bytes.Buffer, body string) error {
+	writer := quotedprintable.NewWriter(buf)
+	body = strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := writer.Write([]byte(body)); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+func randomToken(size int) string {
+	b := make([]byte, size)
+	rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
@@ -0,0 +1,142 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+// SMTP加密方式
+const (
+	EncryptionNone     = "none"     // 不加密（仅用于内网中继）
+	EncryptionStartTLS = "starttls" // 明文连接后升级为TLS（通常为587端口）
+	EncryptionTLS      = "tls"      // 直接建立TLS连接（通常为465端口）
+)
+
+// SMTPOptions SMTP服务器连接参数
+type SMTPOptions struct {
+	Host       string
+	Port       int
+	Username   string
+	Password   string
+	Encryption string        // none/starttls/tls，为空时按starttls处理
+	Timeout    time.Duration // 连接和发送超时，为0时默认30秒
+}
+
+// SMTPMailer 通过SMTP服务器发送邮件
+type SMTPMailer struct {
+	options SMTPOptions
+}
+
+// NewSMTPMailer 创建SMTP邮件发送器
+func NewSMTPMailer(options SMTPOptions) (*SMTPMailer, error) {
+	if options.Host == "" || options.Port <= 0 {
+		return nil, errors.New("SMTP服务器地址未配置")
+	}
+	switch options.Encryption {
+	case "":
+		options.Encryption = EncryptionStartTLS
+	case EncryptionNone, EncryptionStartTLS, EncryptionTLS:
+	default:
+		return nil, fmt.Errorf("不支持的SMTP加密方式: %s", options.Encryption)
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 30 * time.Second
+	}
+	return &SMTPMailer{options: options}, nil
+}
+
+// Send 发送邮件
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	data, err := msg.Build(time.Now())
+	if err != nil {
+		return err
+	}
+
+	client, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if m.options.Username != "" {
+		auth := smtp.PlainAuth("", m.options.Username, m.options.Password, m.options.Host)
+		if err := client.Auth(auth); err != nil {
+			return classify("SMTP认证失败", err)
+		}
+	}
+	if err := client.Mail(msg.From); err != nil {
+		return classify("SMTP发件人被拒绝", err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return classify("SMTP收件人被拒绝", err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return classify("SMTP发送数据失败", err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		return classify("SMTP发送数据失败", err)
+	}
+	if err := writer.Close(); err != nil {
+		return classify("SMTP发送数据失败", err)
+	}
+
+	return client.Quit()
+}
+
+// dial 连接SMTP服务器，按配置建立TLS连接或升级STARTTLS
+func (m *SMTPMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.options.Host, strconv.Itoa(m.options.Port))
+	dialer := &net.Dialer{Timeout: m.options.Timeout}
+	tlsConfig := &tls.Config{ServerName: m.options.Host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	if m.options.Encryption == EncryptionTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("连接SMTP服务器失败: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(m.options.Timeout))
+
+	client, err := smtp.NewClient(conn, m.options.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("连接SMTP服务器失败: %w", err)
+	}
+
+	if m.options.Encryption == EncryptionStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, &PermanentError{Err: errors.New("SMTP服务器不支持STARTTLS")}
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("SMTP升级TLS失败: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+// classify 将SMTP 5xx应答视为不可重试错误，其余错误可重试
+func classify(message string, err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return &PermanentError{Err: fmt.Errorf("%s: %w", message, err)}
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
@@ -0,0 +1,122 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// 模板语言
+const (
+	LanguageZh = "zh" // 中文
+	LanguageEn = "en" // 英文
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Rendered 渲染后的邮件内容
+type Rendered struct {
+	Language string // 实际使用的模板语言
+	Subject  string // 主题
+	Text     string // 纯文本正文
+	HTML     string // HTML正文
+}
+
+// Renderer 邮件模板渲染器
+//
+// 模板文件位于 templates/<语言>/<模板名>.tmpl，每个文件定义 subject、text、html 三个模板块：
+// subject 和 text 按纯文本渲染，html 按HTML上下文自动转义渲染。
+type Renderer struct {
+	defaultLanguage string
+	text            map[string]*texttemplate.Template
+	html            map[string]*htmltemplate.Template
+}
+
+// NewRenderer 加载内置邮件模板
+func NewRenderer(defaultLanguage string) (*Renderer, error) {
+	if defaultLanguage == "" {
+		defaultLanguage = LanguageZh
+	}
+	renderer := &Renderer{
+		defaultLanguage: defaultLanguage,
+		text:            make(map[string]*texttemplate.Template),
+		html:            make(map[string]*htmltemplate.Template),
+	}
+
+	files, err := fs.Glob(templateFS, "templates/*/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		content, err := templateFS.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		key := path.Base(path.Dir(file)) + "/" + strings.TrimSuffix(path.Base(file), ".tmpl")
+
+		textTemplate, err := texttemplate.New(key).Option("missingkey=zero").Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("解析邮件模板 %s 失败: %w", file, err)
+		}
+		htmlTemplate, err := htmltemplate.New(key).Option("missingkey=zero").Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("解析邮件模板 %s 失败: %w", file, err)
+		}
+		for _, block := range []string{"subject", "text", "html"} {
+			if textTemplate.Lookup(block) == nil {
+				return nil, fmt.Errorf("邮件模板 %s 缺少 %s 模板块", file, block)
+			}
+		}
+		renderer.text[key] = textTemplate
+		renderer.html[key] = htmlTemplate
+	}
+
+	return renderer, nil
+}
+
+// Has 判断模板是否存在（任一语言）
+func (r *Renderer) Has(name string) bool {
+	for key := range r.text {
+		if strings.HasSuffix(key, "/"+name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Render 渲染邮件模板，指定语言的模板不存在时依次回退到默认语言和中文
+func (r *Renderer) Render(name, language string, data interface{}) (*Rendered, error) {
+	for _, lang := range []string{language, r.defaultLanguage, LanguageZh} {
+		key := lang + "/" + name
+		textTemplate, ok := r.text[key]
+		if !ok {
+			continue
+		}
+
+		var subject, text, html bytes.Buffer
+		if err := textTemplate.ExecuteTemplate(&subject, "subject", data); err != nil {
+			return nil, fmt.Errorf("渲染邮件主题失败: %w", err)
+		}
+		if err := textTemplate.ExecuteTemplate(&text, "text", data); err != nil {
+			return nil, fmt.Errorf("渲染邮件正文失败: %w", err)
+		}
+		if err := r.html[key].ExecuteTemplate(&html, "html", data); err != nil {
+			return nil, fmt.Errorf("渲染邮件正文失败: %w", err)
+		}
+
+		return &Rendered{
+			Language: lang,
+			Subject:  strings.TrimSpace(subject.String()),
+			Text:     strings.TrimSpace(text.String()),
+			HTML:     strings.TrimSpace(html.String()),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("邮件模板不存在: %s", name)
+}
@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval 清理长时间未使用的令牌桶的间隔
+const memorySweepInterval = time.Minute
+
+type bucket struct {
+	tokens   float64
+	updated  time.Time
+	idleTime time.Duration // 补满所需时间，空闲超过该时间的桶等同于新桶，可以清理
+}
+
+// MemoryLimiter 进程内存令牌桶，多实例部署时各实例分别计数
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryLimiter 创建进程内存限流器
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow 从 key 对应的令牌桶中取一个令牌
+func (l *MemoryLimiter) Allow(_ context.Context, key string, rule Rule) (Result, error) {
+	if !rule.Enabled() {
+		return Result{Allowed: true}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	capacity := float64(rule.Capacity)
+	rate := capacity / float64(rule.Period) // 每纳秒补充的令牌数
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		l.buckets[key] = b
+	}
+	b.idleTime = rule.Period
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+float64(elapsed)*rate)
+	}
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return Result{Allowed: true, Remaining: int(b.tokens)}, nil
+	}
+	retryAfter := time.Duration(math.Ceil((1 - b.tokens) / rate))
+	return Result{Allowed: false, RetryAfter: retryAfter}, nil
+}
+
+// Refund 向 key 对应的令牌桶归还一个令牌
+func (l *MemoryLimiter) Refund(_ context.Context, key string, rule Rule) error {
+	if !rule.Enabled() {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b, ok := l.buckets[key]; ok {
+		b.tokens = math.Min(float64(rule.Capacity), b.tokens+1)
+	}
+	return nil
+}
+
+// sweep 定期清理已补满的令牌桶，避免大量不同的key占用内存
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < memorySweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= b.idleTime {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock 可手动推进的时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// newTestLimiter 创建使用 fakeClock 的限流器
+func newTestLimiter() (*MemoryLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewMemoryLimiter()
+	l.now = clock.Now
+	l.lastSweep = clock.now
+	return l, clock
+}
+
+func TestMemoryLimiterAllow(t *testing.T) {
+	rule := Rule{Capacity: 3, Period: 3 * time.Second} // 每秒补充1个令牌
+
+	type step struct {
+		advance       time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "突发请求用完容量后拒绝",
+			steps: []step{
+				{wantAllowed: true, wantRemaining: 2},
+				{wantAllowed: true, wantRemaining: 1},
+				{wantAllowed: true, wantRemaining: 0},
+				{wantAllowed: false, wantRetry: time.Second},
+			},
+		},
+		{
+			name: "按时间匀速补充令牌",
+			steps: []step{
+				{wantAllowed: true, wantRemaining: 2},
+				{wantAllowed: true, wantRemaining: 1},
+				{wantAllowed: true, wantRemaining: 0},
+				{advance: 400 * time.Millisecond, wantAllowed: false, wantRetry: 600 * time.Millisecond},
+				{advance: 600 * time.Millisecond, wantAllowed: true, wantRemaining: 0},
+				{wantAllowed: false, wantRetry: time.Second},
+			},
+		},
+		{
+			name: "长时间空闲后补满但不超过容量",
+			steps: []step{
+				{wantAllowed: true, wantRemaining: 2},
+				{advance: time.Hour, wantAllowed: true, wantRemaining: 2},
+				{wantAllowed: true, wantRemaining: 1},
+				{wantAllowed: true, wantRemaining: 0},
+				{wantAllowed: false, wantRetry: time.Second},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, clock := newTestLimiter()
+			for i, s := range tt.steps {
+				clock.Advance(s.advance)
+				got, err := l.Allow(context.Background(), "user", rule)
+				if err != nil {
+					t.Fatalf("step %d: Allow() error = %v", i, err)
+				}
+				want := Result{Allowed: s.wantAllowed, Remaining: s.wantRemaining, RetryAfter: s.wantRetry}
+				if got != want {
+					t.Fatalf("step %d: Allow() = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryLimiterKeysAreIndependent(t *testing.T) {
+	l, _ := newTestLimiter()
+	rule := Rule{Capacity: 1, Period: time.Minute}
+	ctx := context.Background()
+
+	if r, _ := l.Allow(ctx, "auth:account:alice", rule); !r.Allowed {
+		t.Fatalf("alice 第一次请求应允许")
+	}
+	if r, _ := l.Allow(ctx, "auth:account:alice", rule); r.Allowed {
+		t.Fatalf("alice 第二次请求应拒绝")
+	}
+	if r, _ := l.Allow(ctx, "auth:reset:alice", rule); !r.Allowed {
+		t.Errorf("不同作用域的 key 不应共享令牌桶")
+	}
+}
+
+func TestMemoryLimiterRuleDisabled(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{name: "未配置", rule: Rule{}},
+		{name: "容量为0", rule: Rule{Capacity: 0, Period: time.Minute}},
+		{name: "周期为0", rule: Rule{Capacity: 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := newTestLimiter()
+			for i := 0; i < 10; i++ {
+				if r, err := l.Allow(context.Background(), "k", tt.rule); err != nil || !r.Allowed {
+					t.Fatalf("Allow() = %+v, %v, want allowed", r, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryLimiterRefund(t *testing.T) {
+	rule := Rule{Capacity: 2, Period: time.Minute}
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		allows        int
+		refunds       int
+		wantRemaining int // 再取一个令牌后的剩余数，-1 表示应被拒绝
+	}{
+		{name: "归还后可再次请求", allows: 2, refunds: 1, wantRemaining: 0},
+		{name: "归还不超过容量", allows: 1, refunds: 5, wantRemaining: 1},
+		{name: "桶不存在时忽略", allows: 0, refunds: 1, wantRemaining: 1},
+		{name: "未归还时拒绝", allows: 2, refunds: 0, wantRemaining: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := newTestLimiter()
+			for i := 0; i < tt.allows; i++ {
+				l.Allow(ctx, "k", rule)
+			}
+			for i := 0; i < tt.refunds; i++ {
+				if err := l.Refund(ctx, "k", rule); err != nil {
+					t.Fatalf("Refund() error = %v", err)
+				}
+			}
+			r, _ := l.Allow(ctx, "k", rule)
+			if tt.wantRemaining < 0 {
+				if r.Allowed {
+					t.Errorf("Allow() = %+v, want rejected", r)
+				}
+				return
+			}
+			if !r.Allowed || r.Remaining != tt.wantRemaining {
+				t.Errorf("Allow() = %+v, want allowed with %d remaining", r, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestMemoryLimiterSweep(t *testing.T) {
+	l, clock := newTestLimiter()
+	ctx := context.Background()
+
+	l.Allow(ctx, "short", Rule{Capacity: 1, Period: time.Second})
+	l.Allow(ctx, "long", Rule{Capacity: 1, Period: time.Hour})
+	clock.Advance(2 * memorySweepInterval)
+	l.Allow(ctx, "other", Rule{Capacity: 1, Period: time.Second})
+
+	if _, ok := l.buckets["short"]; ok {
+		t.Errorf("已补满的令牌桶应被清理")
+	}
+	if _, ok := l.buckets["long"]; !ok {
+		t.Errorf("未补满的令牌桶不应被清理")
+	}
+}
+
+// errLimiter 总是返回错误的限流器
+type errLimiter struct{}
+
+var errUnavailable = errors.New("unavailable")
+
+func (errLimiter) Allow(context.Context, string, Rule) (Result, error) {
+	return Result{}, errUnavailable
+}
+
+func (errLimiter) Refund(context.Context, string, Rule) error {
+	return errUnavailable
+}
+
+func TestWithFallback(t *testing.T) {
+	rule := Rule{Capacity: 1, Period: time.Minute}
+	ctx := context.Background()
+	fallback, _ := newTestLimiter()
+
+	var logged []error
+	l := WithFallback(errLimiter{}, fallback, func(err error) { logged = append(logged, err) })
+
+	if r, err := l.Allow(ctx, "k", rule); err != nil || !r.Allowed {
+		t.Fatalf("Allow() = %+v, %v, want allowed by fallback", r, err)
+	}
+	if r, _ := l.Allow(ctx, "k", rule); r.Allowed {
+		t.Fatalf("备用限流器应继续计数")
+	}
+	if err := l.Refund(ctx, "k", rule); err != nil {
+		t.Fatalf("Refund() error = %v", err)
+	}
+	if r, _ := l.Allow(ctx, "k", rule); !r.Allowed {
+		t.Errorf("归还应作用于备用限流器")
+	}
+	if len(logged) != 4 {
+		t.Errorf("onError 调用次数 = %d, want 4", len(logged))
+	}
+}
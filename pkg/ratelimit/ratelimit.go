@@ -0,0 +1,66 @@
+// Package ratelimit 令牌桶限流，支持进程内存和Redis两种存储
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Rule 限流规则：桶容量为 Capacity，令牌在 Period 内匀速补满，即平均每 Period 允许 Capacity 次请求
+type Rule struct {
+	Capacity int
+	Period   time.Duration
+}
+
+// Enabled 规则是否有效，容量或周期未配置时不限流
+func (r Rule) Enabled() bool {
+	return r.Capacity > 0 && r.Period > 0
+}
+
+// Result 限流结果
+type Result struct {
+	Allowed    bool          // 是否允许本次请求
+	Remaining  int           // 剩余令牌数
+	RetryAfter time.Duration // 被拒绝时，距离下一个令牌可用的时间
+}
+
+// Limiter 限流器，key 为限流对象（如IP、用户名、用户ID）
+type Limiter interface {
+	Allow(ctx context.Context, key string, rule Rule) (Result, error)
+	// Refund 归还一个令牌（不超过桶容量），用于只对失败请求计数的场景；桶不存在时忽略
+	Refund(ctx context.Context, key string, rule Rule) error
+}
+
+// fallbackLimiter 主限流器出错时使用备用限流器
+type fallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+	onError  func(error)
+}
+
+// WithFallback 主限流器（如Redis）出错时改用备用限流器（如进程内存），onError 用于记录错误，可为nil
+func WithFallback(primary, fallback Limiter, onError func(error)) Limiter {
+	return &fallbackLimiter{primary: primary, fallback: fallback, onError: onError}
+}
+
+func (l *fallbackLimiter) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	result, err := l.primary.Allow(ctx, key, rule)
+	if err == nil {
+		return result, nil
+	}
+	if l.onError != nil {
+		l.onError(err)
+	}
+	return l.fallback.Allow(ctx, key, rule)
+}
+
+func (l *fallbackLimiter) Refund(ctx context.Context, key string, rule Rule) error {
+	err := l.primary.Refund(ctx, key, rule)
+	if err == nil {
+		return nil
+	}
+	if l.onError != nil {
+		l.onError(err)
+	}
+	return l.fallback.Refund(ctx, key, rule)
+}
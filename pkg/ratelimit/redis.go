@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript 在Redis中原子地计算令牌桶：
+// KEYS[1]=桶；ARGV[1]=容量，ARGV[2]=补满周期（毫秒），ARGV[3]=当前时间（毫秒）。
+// 返回 {是否允许, 剩余令牌数, 重试等待毫秒数}
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil or ts == nil then
+  tokens = capacity
+  ts = now
+end
+local rate = capacity / period
+local elapsed = now - ts
+if elapsed > 0 then
+  tokens = math.min(capacity, tokens + elapsed * rate)
+  ts = now
+end
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) / rate)
+end
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', ts)
+redis.call('PEXPIRE', KEYS[1], period)
+return {allowed, math.floor(tokens), retry}
+`)
+
+// refundScript 向令牌桶归还一个令牌，不超过容量；桶不存在（已过期）时忽略。
+// KEYS[1]=桶；ARGV[1]=容量
+var refundScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local tokens = tonumber(redis.call('HGET', KEYS[1], 'tokens'))
+if tokens == nil then
+  return 0
+end
+tokens = math.min(capacity, tokens + 1)
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens))
+return 1
+`)
+
+// RedisOptions Redis连接配置
+type RedisOptions struct {
+	Addr     string
+	Password string
+	DB       int
+	PoolSize int
+	Prefix   string // 键前缀
+}
+
+// RedisLimiter 基于Redis的令牌桶，多实例部署时共享计数
+type RedisLimiter struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisLimiter 连接Redis并创建限流器，连接失败时返回错误
+func NewRedisLimiter(ctx context.Context, opts RedisOptions) (*RedisLimiter, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     opts.Addr,
+		Password: opts.Password,
+		DB:       opts.DB,
+		PoolSize: opts.PoolSize,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("连接Redis失败: %w", err)
+	}
+	return &RedisLimiter{client: client, prefix: opts.Prefix}, nil
+}
+
+// Allow 从 key 对应的令牌桶中取一个令牌
+func (l *RedisLimiter) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	if !rule.Enabled() {
+		return Result{Allowed: true}, nil
+	}
+
+	period := rule.Period.Milliseconds()
+	if period <= 0 {
+		period = 1
+	}
+	values, err := tokenBucketScript.Run(ctx, l.client, []string{l.prefix + key}, rule.Capacity, period, time.Now().UnixMilli()).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(values) != 3 {
+		return Result{}, fmt.Errorf("限流脚本返回值异常: %v", values)
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
+
+// Refund 向 key 对应的令牌桶归还一个令牌
+func (l *RedisLimiter) Refund(ctx context.Context, key string, rule Rule) error {
+	if !rule.Enabled() {
+		return nil
+	}
+	return refundScript.Run(ctx, l.client, []string{l.prefix + key}, rule.Capacity).Err()
+}
+
+// Close 关闭Redis连接
+func (l *RedisLimiter) Close() error {
+	return l.client.Close()
+}
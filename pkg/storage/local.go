@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage 本地磁盘存储，文件保存在根目录下
+type LocalStorage struct {
+	root string
+}
+
+// NewLocalStorage 创建本地磁盘存储，根目录不存在时自动创建
+func NewLocalStorage(root string) (*LocalStorage, error) {
+	if strings.TrimSpace(root) == "" {
+		root = "./uploads"
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(absRoot, 0o755); err != nil {
+		return nil, fmt.Errorf("创建上传目录失败: %v", err)
+	}
+	return &LocalStorage{root: absRoot}, nil
+}
+
+// Save 先写入临时文件再重命名，避免读取到写了一半的文件
+func (s *LocalStorage) Save(ctx context.Context, key string, reader io.Reader) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	written, err := io.Copy(tmp, reader)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+
+	return written, nil
+}
+
+// Open 打开文件
+func (s *LocalStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// Delete 删除文件
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// path 将存储路径转换为根目录下的文件路径，拒绝跳出根目录的路径
+func (s *LocalStorage) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + filepath.FromSlash(key))
+	path := filepath.Join(s.root, cleaned)
+	if path == s.root || !strings.HasPrefix(path, s.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("非法的存储路径: %s", key)
+	}
+	return path, nil
+}
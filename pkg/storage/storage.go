@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound 文件不存在
+var ErrNotFound = errors.New("文件不存在")
+
+// Storage 文件存储接口，key 为存储路径（以 / 分隔），由调用方生成
+type Storage interface {
+	// Save 保存文件，返回写入的字节数
+	Save(ctx context.Context, key string, reader io.Reader) (int64, error)
+	// Open 打开文件，文件不存在时返回 ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除文件，文件不存在时不报错
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// richTextAllowedTags 富文本允许保留的标签
+var richTextAllowedTags = map[string]bool{
+	"p": true, "br": true, "span": true, "div": true, "strong": true, "b": true, "em": true, "i": true,
+	"u": true, "s": true, "del": true, "sub": true, "sup": true, "blockquote": true, "pre": true, "code": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "hr": true,
+	"ul": true, "ol": true, "li": true, "a": true, "img": true,
+	"table": true, "thead": true, "tbody": true, "tr": true, "th": true, "td": true,
+}
+
+// richTextDroppedContent 连同内容一起移除的标签
+var richTextDroppedContent = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true, "noscript": true, "template": true,
+}
+
+// richTextAllowedAttrs 各标签允许保留的属性
+var richTextAllowedAttrs = map[string]map[string]bool{
+	"a":   {"href": true, "title": true, "target": true},
+	"img": {"src": true, "alt": true, "title": true, "width": true, "height": true},
+	"td":  {"colspan": true, "rowspan": true},
+	"th":  {"colspan": true, "rowspan": true},
+}
+
+// SanitizeRichText 清理富文本HTML，只保留白名单内的标签和属性，链接仅允许http/https/mailto协议
+func SanitizeRichText(input string) string {
+	tokenizer := nethtml.NewTokenizer(strings.NewReader(input))
+	var buf bytes.Buffer
+	dropDepth := 0
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == nethtml.ErrorToken {
+			if tokenizer.Err() == io.EOF {
+				return buf.String()
+			}
+			return html.EscapeString(input)
+		}
+
+		token := tokenizer.Token()
+		switch tokenType {
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			if richTextDroppedContent[token.Data] {
+				if tokenType == nethtml.StartTagToken {
+					dropDepth++
+				}
+				continue
+			}
+			if dropDepth > 0 || !richTextAllowedTags[token.Data] {
+				continue
+			}
+			buf.WriteString("<" + token.Data)
+			for _, attr := range token.Attr {
+				if !richTextAllowedAttrs[token.Data][attr.Key] {
+					continue
+				}
+				if (attr.Key == "href" || attr.Key == "src") && !isSafeURL(attr.Val) {
+					continue
+				}
+				buf.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+			}
+			if token.Data == "a" {
+				buf.WriteString(` rel="noopener noreferrer"`)
+			}
+			if tokenType == nethtml.SelfClosingTagToken {
+				buf.WriteString(" /")
+			}
+			buf.WriteString(">")
+		case nethtml.EndTagToken:
+			if richTextDroppedContent[token.Data] {
+				if dropDepth > 0 {
+					dropDepth--
+				}
+				continue
+			}
+			if dropDepth > 0 || !richTextAllowedTags[token.Data] {
+				continue
+			}
+			buf.WriteString("</" + token.Data + ">")
+		case nethtml.TextToken:
+			if dropDepth == 0 {
+				buf.WriteString(html.EscapeString(token.Data))
+			}
+		}
+	}
+}
+
+// StripHTML 去除HTML标签，返回纯文本（用于摘要和邮件正文）
+func StripHTML(input string) string {
+	tokenizer := nethtml.NewTokenizer(strings.NewReader(input))
+	var buf strings.Builder
+	dropDepth := 0
+
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case nethtml.ErrorToken:
+			return strings.TrimSpace(buf.String())
+		case nethtml.StartTagToken:
+			token := tokenizer.Token()
+			if richTextDroppedContent[token.Data] {
+				dropDepth++
+			} else if token.Data == "br" {
+				buf.WriteString("\n")
+			}
+		case nethtml.EndTagToken:
+			token := tokenizer.Token()
+			if richTextDroppedContent[token.Data] && dropDepth > 0 {
+				dropDepth--
+			} else if token.Data == "p" || token.Data == "div" || token.Data == "li" {
+				buf.WriteString("\n")
+			}
+		case nethtml.SelfClosingTagToken:
+			if tokenizer.Token().Data == "br" {
+				buf.WriteString("\n")
+			}
+		case nethtml.TextToken:
+			if dropDepth == 0 {
+				buf.WriteString(tokenizer.Token().Data)
+			}
+		}
+	}
+}
+
+// isSafeURL 判断链接是否为安全协议（http/https/mailto）或相对路径
+func isSafeURL(value string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return false
+	}
+	if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") || strings.HasPrefix(value, "mailto:") {
+		return true
+	}
+	// 相对路径不能包含协议
+	return !strings.Contains(value, ":")
+}
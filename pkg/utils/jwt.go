@@ -9,29 +9,53 @@ import (
 
 // Claims JWT声明
 type Claims struct {
-	UserID    string   `json:"user_id"`
-	Username  string   `json:"username"`
-	CompanyID string   `json:"company_id"`
-	RoleIDs   []string `json:"role_ids"`
+	UserID        string               `json:"user_id"`
+	Username      string               `json:"username"`
+	CompanyID     string               `json:"company_id"`
+	RoleIDs       []string             `json:"role_ids"`
+	Impersonation *ImpersonationClaims `json:"impersonation,omitempty"` // 模拟登录令牌才有，UserID 等为被模拟的用户
 	jwt.RegisteredClaims
 }
 
+// ImpersonationClaims 模拟登录声明，记录实际操作的平台管理员
+type ImpersonationClaims struct {
+	SessionID string `json:"session_id"` // 模拟登录会话ID
+	ActorID   string `json:"actor_id"`   // 实际操作人（平台管理员）用户ID
+	ActorName string `json:"actor_name"` // 实际操作人用户名
+	ReadOnly  bool   `json:"read_only"`  // 是否禁止写操作
+}
+
 // JWTUtil JWT工具类
 type JWTUtil struct {
-	secretKey        []byte
+	keySet           *JWTKeySet
 	expiresIn        time.Duration
 	refreshExpiresIn time.Duration
 }
 
-// NewJWTUtil 创建JWT工具实例
+// NewJWTUtil 创建JWT工具实例，使用单个HMAC共享密钥签发不带 kid 的令牌
 func NewJWTUtil(secretKey string, expiresIn, refreshExpiresIn time.Duration) *JWTUtil {
+	key := &jwtKey{method: jwt.SigningMethodHS256, signingKey: []byte(secretKey), verifyKey: []byte(secretKey)}
 	return &JWTUtil{
-		secretKey:        []byte(secretKey),
+		keySet:           &JWTKeySet{signing: key, legacy: key, keys: map[string]*jwtKey{}},
 		expiresIn:        expiresIn,
 		refreshExpiresIn: refreshExpiresIn,
 	}
 }
 
+// NewJWTUtilWithKeySet 创建使用签名密钥集合的JWT工具实例，支持密钥轮换和非对称算法
+func NewJWTUtilWithKeySet(keySet *JWTKeySet, expiresIn, refreshExpiresIn time.Duration) *JWTUtil {
+	return &JWTUtil{
+		keySet:           keySet,
+		expiresIn:        expiresIn,
+		refreshExpiresIn: refreshExpiresIn,
+	}
+}
+
+// JWKS 返回校验令牌所需的公钥集合
+func (j *JWTUtil) JWKS() *JWKS {
+	return j.keySet.JWKS()
+}
+
 // GenerateToken 生成访问令牌
 func (j *JWTUtil) GenerateToken(userID, username, companyID string, roleIDs []string) (string, time.Time, error) {
 	expiresAt := time.Now().Add(j.expiresIn)
@@ -49,8 +73,34 @@ func (j *JWTUtil) GenerateToken(userID, username, companyID string, roleIDs []st
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(j.secretKey)
+	tokenString, err := j.keySet.sign(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tokenString, expiresAt, nil
+}
+
+// GenerateImpersonationToken 生成模拟登录访问令牌，有效期由调用方指定
+func (j *JWTUtil) GenerateImpersonationToken(userID, username, companyID string, roleIDs []string, impersonation *ImpersonationClaims, expiresIn time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(expiresIn)
+	claims := Claims{
+		UserID:        userID,
+		Username:      username,
+		CompanyID:     companyID,
+		RoleIDs:       roleIDs,
+		Impersonation: impersonation,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "insurance-system",
+			Subject:   userID,
+		},
+	}
+
+	tokenString, err := j.keySet.sign(claims)
 	if err != nil {
 		return "", time.Time{}, err
 	}
@@ -69,15 +119,12 @@ func (j *JWTUtil) GenerateRefreshToken(userID string) (string, error) {
 		Subject:   userID,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.secretKey)
+	return j.keySet.sign(claims)
 }
 
 // ParseToken 解析令牌
 func (j *JWTUtil) ParseToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return j.secretKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, j.keySet.keyFunc)
 
 	if err != nil {
 		return nil, err
@@ -92,17 +139,24 @@ func (j *JWTUtil) ParseToken(tokenString string) (*Claims, error) {
 
 // ParseRefreshToken 解析刷新令牌
 func (j *JWTUtil) ParseRefreshToken(tokenString string) (string, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return j.secretKey, nil
-	})
-
+	claims, err := j.ParseRefreshClaims(tokenString)
 	if err != nil {
 		return "", err
 	}
+	return claims.Subject, nil
+}
+
+// ParseRefreshClaims 解析刷新令牌，返回完整声明（含签发时间）
+func (j *JWTUtil) ParseRefreshClaims(tokenString string) (*jwt.RegisteredClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, j.keySet.keyFunc)
+
+	if err != nil {
+		return nil, err
+	}
 
 	if claims, ok := token.Claims.(*jwt.RegisteredClaims); ok && token.Valid {
-		return claims.Subject, nil
+		return claims, nil
 	}
 
-	return "", errors.New("invalid refresh token")
+	return nil, errors.New("invalid refresh token")
 }
@@ -0,0 +1,239 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 支持的签名算法
+const (
+	JWTAlgorithmHS256 = "HS256" // HMAC共享密钥
+	JWTAlgorithmRS256 = "RS256" // RSA私钥签名、公钥校验
+	JWTAlgorithmEdDSA = "EdDSA" // Ed25519私钥签名、公钥校验
+)
+
+// JWTKeySpec 签名密钥配置：HS256 使用 Secret，RS256/EdDSA 从PEM文件加载；
+// 只用于校验旧令牌的非对称密钥可以只配置公钥
+type JWTKeySpec struct {
+	ID             string // 密钥标识，写入令牌头的 kid
+	Algorithm      string // HS256 / RS256 / EdDSA
+	Secret         string // HS256 共享密钥
+	PrivateKeyFile string // 私钥PEM文件路径
+	PublicKeyFile  string // 公钥PEM文件路径，配置了私钥时可省略
+}
+
+// jwtKey 已加载的签名密钥
+type jwtKey struct {
+	id         string
+	method     jwt.SigningMethod
+	signingKey interface{} // 签名用的密钥，只用于校验时为nil
+	verifyKey  interface{} // 校验用的密钥
+}
+
+// JWTKeySet 令牌签名密钥集合：第一个密钥签发新令牌，其余密钥只校验其签发的旧令牌，
+// 轮换密钥时将新密钥放在最前，旧令牌过期后再移除旧密钥
+type JWTKeySet struct {
+	signing *jwtKey
+	keys    map[string]*jwtKey
+	ordered []*jwtKey
+	legacy  *jwtKey // 不带 kid 的令牌使用的共享密钥，兼容引入密钥轮换前签发的令牌
+}
+
+// NewJWTKeySet 加载签名密钥集合；未配置密钥时使用 legacySecret 以 HS256 签发不带 kid 的令牌
+func NewJWTKeySet(legacySecret string, specs []JWTKeySpec) (*JWTKeySet, error) {
+	keySet := &JWTKeySet{keys: make(map[string]*jwtKey)}
+	if legacySecret != "" {
+		keySet.legacy = &jwtKey{
+			method:     jwt.SigningMethodHS256,
+			signingKey: []byte(legacySecret),
+			verifyKey:  []byte(legacySecret),
+		}
+	}
+
+	for i, spec := range specs {
+		key, err := loadJWTKey(spec)
+		if err != nil {
+			return nil, fmt.Errorf("加载JWT密钥 %q 失败: %w", spec.ID, err)
+		}
+		if _, exists := keySet.keys[key.id]; exists {
+			return nil, fmt.Errorf("JWT密钥标识重复: %s", key.id)
+		}
+		if i == 0 && key.signingKey == nil {
+			return nil, fmt.Errorf("JWT密钥 %q 用于签发新令牌，须配置私钥", key.id)
+		}
+		keySet.keys[key.id] = key
+		keySet.ordered = append(keySet.ordered, key)
+	}
+
+	switch {
+	case len(keySet.ordered) > 0:
+		keySet.signing = keySet.ordered[0]
+	case keySet.legacy != nil:
+		keySet.signing = keySet.legacy
+	default:
+		return nil, errors.New("未配置JWT签名密钥")
+	}
+
+	return keySet, nil
+}
+
+// loadJWTKey 按配置加载单个密钥
+func loadJWTKey(spec JWTKeySpec) (*jwtKey, error) {
+	if spec.ID == "" {
+		return nil, errors.New("未配置密钥标识 kid")
+	}
+	key := &jwtKey{id: spec.ID}
+
+	switch spec.Algorithm {
+	case JWTAlgorithmHS256:
+		if spec.Secret == "" {
+			return nil, errors.New("HS256 密钥须配置 secret")
+		}
+		key.method = jwt.SigningMethodHS256
+		key.signingKey = []byte(spec.Secret)
+		key.verifyKey = []byte(spec.Secret)
+
+	case JWTAlgorithmRS256:
+		key.method = jwt.SigningMethodRS256
+		if spec.PrivateKeyFile != "" {
+			data, err := os.ReadFile(spec.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			key.signingKey = privateKey
+			key.verifyKey = &privateKey.PublicKey
+		}
+		if spec.PublicKeyFile != "" {
+			data, err := os.ReadFile(spec.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			publicKey, err := jwt.ParseRSAPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			if key.signingKey != nil && !publicKey.Equal(key.verifyKey) {
+				return nil, errors.New("公钥与私钥不匹配")
+			}
+			key.verifyKey = publicKey
+		}
+
+	case JWTAlgorithmEdDSA:
+		key.method = jwt.SigningMethodEdDSA
+		if spec.PrivateKeyFile != "" {
+			data, err := os.ReadFile(spec.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			privateKey, err := jwt.ParseEdPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			key.signingKey = privateKey
+			key.verifyKey = privateKey.(crypto.Signer).Public()
+		}
+		if spec.PublicKeyFile != "" {
+			data, err := os.ReadFile(spec.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			publicKey, err := jwt.ParseEdPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			if key.signingKey != nil && !publicKey.(ed25519.PublicKey).Equal(key.verifyKey) {
+				return nil, errors.New("公钥与私钥不匹配")
+			}
+			key.verifyKey = publicKey
+		}
+
+	default:
+		return nil, fmt.Errorf("不支持的签名算法: %s", spec.Algorithm)
+	}
+
+	if key.verifyKey == nil {
+		return nil, errors.New("须配置私钥或公钥文件")
+	}
+	return key, nil
+}
+
+// sign 使用当前签名密钥签发令牌，令牌头写入 kid
+func (ks *JWTKeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	if ks.signing.id != "" {
+		token.Header["kid"] = ks.signing.id
+	}
+	return token.SignedString(ks.signing.signingKey)
+}
+
+// keyFunc 按令牌头的 kid 选择校验密钥，令牌声明的算法须与密钥一致
+func (ks *JWTKeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	key := ks.legacy
+	if kid, ok := token.Header["kid"].(string); ok {
+		key = ks.keys[kid]
+	}
+	if key == nil {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %s", token.Method.Alg())
+	}
+	return key.verifyKey, nil
+}
+
+// JWK 公钥的 JSON Web Key 表示
+type JWK struct {
+	KeyType   string `json:"kty"`           // 密钥类型：RSA / OKP
+	KeyID     string `json:"kid"`           // 密钥标识
+	Use       string `json:"use"`           // 用途，固定为 sig
+	Algorithm string `json:"alg"`           // 签名算法
+	N         string `json:"n,omitempty"`   // RSA 模数
+	E         string `json:"e,omitempty"`   // RSA 公钥指数
+	Curve     string `json:"crv,omitempty"` // OKP 曲线，Ed25519
+	X         string `json:"x,omitempty"`   // Ed25519 公钥
+}
+
+// JWKS JSON Web Key Set，供其他服务校验本系统签发的令牌
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 返回非对称密钥的公钥集合，HS256 共享密钥不对外公开
+func (ks *JWTKeySet) JWKS() *JWKS {
+	jwks := &JWKS{Keys: make([]JWK, 0, len(ks.ordered))}
+	for _, key := range ks.ordered {
+		switch publicKey := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				KeyType:   "RSA",
+				KeyID:     key.id,
+				Use:       "sig",
+				Algorithm: key.method.Alg(),
+				N:         base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				KeyType:   "OKP",
+				KeyID:     key.id,
+				Use:       "sig",
+				Algorithm: key.method.Alg(),
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(publicKey),
+			})
+		}
+	}
+	return jwks
+}
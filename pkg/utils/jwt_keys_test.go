@@ -0,0 +1,268 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testLegacySecret = "legacy-secret"
+
+// writePEM 将DER编码的密钥写入临时目录的PEM文件
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testKeyFiles 测试用的RSA和Ed25519密钥及其PEM文件
+type testKeyFiles struct {
+	rsaPrivate   *rsa.PrivateKey
+	rsaPublicPEM []byte
+	rsaPrivFile  string
+	rsaPubFile   string
+	edPrivate    ed25519.PrivateKey
+	edPrivFile   string
+}
+
+func newTestKeyFiles(t *testing.T) *testKeyFiles {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPublicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPrivateDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testKeyFiles{
+		rsaPrivate:   rsaKey,
+		rsaPublicPEM: pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rsaPublicDER}),
+		rsaPrivFile:  writePEM(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+		rsaPubFile:   writePEM(t, "rsa.pub.pem", "PUBLIC KEY", rsaPublicDER),
+		edPrivate:    edKey,
+		edPrivFile:   writePEM(t, "ed25519.pem", "PRIVATE KEY", edPrivateDER),
+	}
+}
+
+// testClaims 未过期的令牌声明
+func testClaims() *Claims {
+	now := time.Now()
+	return &Claims{
+		UserID:   "u1",
+		Username: "alice",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Subject:   "u1",
+		},
+	}
+}
+
+// signWith 使用指定算法、kid 和密钥手工签发令牌，kid 为空时不写入令牌头
+func signWith(t *testing.T, method jwt.SigningMethod, kid string, key interface{}) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, testClaims())
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokenString
+}
+
+func TestJWTKeySetParseToken(t *testing.T) {
+	files := newTestKeyFiles(t)
+	keySet, err := NewJWTKeySet(testLegacySecret, []JWTKeySpec{
+		{ID: "rsa-2024", Algorithm: JWTAlgorithmRS256, PrivateKeyFile: files.rsaPrivFile},
+		{ID: "ed-2023", Algorithm: JWTAlgorithmEdDSA, PrivateKeyFile: files.edPrivFile},
+		{ID: "hs-2022", Algorithm: JWTAlgorithmHS256, Secret: "old-secret"},
+	})
+	if err != nil {
+		t.Fatalf("NewJWTKeySet() error = %v", err)
+	}
+	jwtUtil := NewJWTUtilWithKeySet(keySet, time.Hour, time.Hour)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "当前密钥签发", token: signWith(t, jwt.SigningMethodRS256, "rsa-2024", files.rsaPrivate)},
+		{name: "轮换后的旧EdDSA密钥", token: signWith(t, jwt.SigningMethodEdDSA, "ed-2023", files.edPrivate)},
+		{name: "轮换后的旧HS256密钥", token: signWith(t, jwt.SigningMethodHS256, "hs-2022", []byte("old-secret"))},
+		{name: "不带kid的旧令牌", token: signWith(t, jwt.SigningMethodHS256, "", []byte(testLegacySecret))},
+		{name: "不带kid但密钥错误", token: signWith(t, jwt.SigningMethodHS256, "", []byte("wrong")), wantErr: true},
+		{name: "未知kid", token: signWith(t, jwt.SigningMethodHS256, "unknown", []byte(testLegacySecret)), wantErr: true},
+		{name: "以RSA公钥作HMAC密钥伪造", token: signWith(t, jwt.SigningMethodHS256, "rsa-2024", files.rsaPublicPEM), wantErr: true},
+		{name: "算法与密钥不符", token: signWith(t, jwt.SigningMethodEdDSA, "rsa-2024", files.edPrivate), wantErr: true},
+		{name: "HS256密钥用其他算法", token: signWith(t, jwt.SigningMethodRS256, "hs-2022", files.rsaPrivate), wantErr: true},
+		{name: "不签名的令牌", token: signWith(t, jwt.SigningMethodNone, "rsa-2024", jwt.UnsafeAllowNoneSignatureType), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := jwtUtil.ParseToken(tt.token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseToken() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseToken() error = %v", err)
+			}
+			if claims.UserID != "u1" {
+				t.Errorf("UserID = %q, want %q", claims.UserID, "u1")
+			}
+		})
+	}
+}
+
+func TestJWTKeySetWithoutLegacySecret(t *testing.T) {
+	keySet, err := NewJWTKeySet("", []JWTKeySpec{{ID: "hs-2024", Algorithm: JWTAlgorithmHS256, Secret: "secret"}})
+	if err != nil {
+		t.Fatalf("NewJWTKeySet() error = %v", err)
+	}
+	jwtUtil := NewJWTUtilWithKeySet(keySet, time.Hour, time.Hour)
+
+	if _, err := jwtUtil.ParseToken(signWith(t, jwt.SigningMethodHS256, "", []byte("secret"))); err == nil {
+		t.Errorf("未配置旧密钥时应拒绝不带kid的令牌")
+	}
+}
+
+func TestJWTKeySetSignsWithFirstKey(t *testing.T) {
+	files := newTestKeyFiles(t)
+
+	tests := []struct {
+		name    string
+		legacy  string
+		specs   []JWTKeySpec
+		wantAlg string
+		wantKid interface{}
+	}{
+		{
+			name:    "第一个密钥签发",
+			legacy:  testLegacySecret,
+			specs:   []JWTKeySpec{{ID: "ed-2024", Algorithm: JWTAlgorithmEdDSA, PrivateKeyFile: files.edPrivFile}},
+			wantAlg: "EdDSA",
+			wantKid: "ed-2024",
+		},
+		{
+			name:    "未配置密钥时使用旧共享密钥",
+			legacy:  testLegacySecret,
+			wantAlg: "HS256",
+			wantKid: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keySet, err := NewJWTKeySet(tt.legacy, tt.specs)
+			if err != nil {
+				t.Fatalf("NewJWTKeySet() error = %v", err)
+			}
+			jwtUtil := NewJWTUtilWithKeySet(keySet, time.Hour, time.Hour)
+
+			tokenString, _, err := jwtUtil.GenerateToken("u1", "alice", "c1", nil)
+			if err != nil {
+				t.Fatalf("GenerateToken() error = %v", err)
+			}
+			token, _, err := jwt.NewParser().ParseUnverified(tokenString, &Claims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token.Method.Alg() != tt.wantAlg || token.Header["kid"] != tt.wantKid {
+				t.Errorf("alg = %s, kid = %v, want %s, %v", token.Method.Alg(), token.Header["kid"], tt.wantAlg, tt.wantKid)
+			}
+			if _, err := jwtUtil.ParseToken(tokenString); err != nil {
+				t.Errorf("ParseToken() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestNewJWTKeySetInvalidSpecs(t *testing.T) {
+	files := newTestKeyFiles(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPublicDER, err := x509.MarshalPKIXPublicKey(&otherKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPubFile := writePEM(t, "other.pub.pem", "PUBLIC KEY", otherPublicDER)
+
+	tests := []struct {
+		name   string
+		legacy string
+		specs  []JWTKeySpec
+	}{
+		{name: "未配置任何密钥"},
+		{name: "缺少kid", specs: []JWTKeySpec{{Algorithm: JWTAlgorithmHS256, Secret: "s"}}},
+		{name: "kid重复", specs: []JWTKeySpec{
+			{ID: "k1", Algorithm: JWTAlgorithmHS256, Secret: "s1"},
+			{ID: "k1", Algorithm: JWTAlgorithmHS256, Secret: "s2"},
+		}},
+		{name: "不支持的算法", specs: []JWTKeySpec{{ID: "k1", Algorithm: "HS512", Secret: "s"}}},
+		{name: "HS256缺少secret", specs: []JWTKeySpec{{ID: "k1", Algorithm: JWTAlgorithmHS256}}},
+		{name: "签发密钥只有公钥", specs: []JWTKeySpec{{ID: "k1", Algorithm: JWTAlgorithmRS256, PublicKeyFile: files.rsaPubFile}}},
+		{name: "公钥与私钥不匹配", specs: []JWTKeySpec{{ID: "k1", Algorithm: JWTAlgorithmRS256, PrivateKeyFile: files.rsaPrivFile, PublicKeyFile: otherPubFile}}},
+		{name: "密钥类型与算法不符", specs: []JWTKeySpec{{ID: "k1", Algorithm: JWTAlgorithmRS256, PrivateKeyFile: files.edPrivFile}}},
+		{name: "密钥文件不存在", specs: []JWTKeySpec{{ID: "k1", Algorithm: JWTAlgorithmEdDSA, PrivateKeyFile: filepath.Join(t.TempDir(), "missing.pem")}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewJWTKeySet(tt.legacy, tt.specs); err == nil {
+				t.Errorf("NewJWTKeySet() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestJWTKeySetJWKS(t *testing.T) {
+	files := newTestKeyFiles(t)
+	keySet, err := NewJWTKeySet(testLegacySecret, []JWTKeySpec{
+		{ID: "rsa-2024", Algorithm: JWTAlgorithmRS256, PrivateKeyFile: files.rsaPrivFile},
+		{ID: "hs-2023", Algorithm: JWTAlgorithmHS256, Secret: "secret"},
+		{ID: "ed-2022", Algorithm: JWTAlgorithmEdDSA, PrivateKeyFile: files.edPrivFile},
+	})
+	if err != nil {
+		t.Fatalf("NewJWTKeySet() error = %v", err)
+	}
+
+	keys := keySet.JWKS().Keys
+	if len(keys) != 2 {
+		t.Fatalf("JWKS() 返回 %d 个密钥, want 2（HS256 共享密钥不公开）", len(keys))
+	}
+	if keys[0].KeyID != "rsa-2024" || keys[0].KeyType != "RSA" || keys[0].Algorithm != "RS256" || keys[0].N == "" {
+		t.Errorf("RSA JWK = %+v", keys[0])
+	}
+	if keys[1].KeyID != "ed-2022" || keys[1].KeyType != "OKP" || keys[1].Curve != "Ed25519" || keys[1].X == "" {
+		t.Errorf("Ed25519 JWK = %+v", keys[1])
+	}
+}
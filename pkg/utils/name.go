@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// traditionalPairs 常用姓名用字的繁简对照（每两个字符为一组：繁体、简体）
+const traditionalPairs = "" +
+	"陳陈張张劉刘黃黄楊杨趙赵吳吴孫孙馬马許许鄭郑謝谢韓韩馮冯鄧邓蕭萧葉叶羅罗蘇苏盧卢" +
+	"蔣蒋鍾钟鐘钟陸陆譚谭賴赖龍龙賀贺錢钱湯汤顧顾龔龚嚴严萬万閻阎閆闫鄒邹餘余範范華华" +
+	"關关歐欧陽阳寧宁紀纪鄺邝麥麦駱骆區区鄔邬喬乔聶聂莊庄藍蓝廣广龐庞溫温師师賈贾嶽岳" +
+	"鮑鲍諸诸費费偉伟傑杰強强軍军國国榮荣興兴東东門门開开寶宝貴贵順顺勝胜鳳凤麗丽豔艳" +
+	"艷艳嬌娇嬋婵鶯莺瑩莹穎颖靜静潔洁紅红蓮莲雲云潤润濤涛濱滨鴻鸿鵬鹏飛飞輝辉煒炜權权" +
+	"儀仪樂乐愛爱銘铭鋒锋鐵铁錦锦銀银書书詩诗語语誠诚聰聪賢贤義义禮礼達达遠远進进運运" +
+	"連连時时曉晓暉晖歡欢學学業业發发財财傳传億亿兒儿為为憲宪懷怀應应慶庆萊莱濟济澤泽" +
+	"灣湾漢汉瀾澜無无爾尔現现環环瑋玮畢毕碩硕禎祯禕祎穩稳競竞簡简紹绍經经維维綺绮緒绪" +
+	"繼继聖圣聲声蘭兰衛卫衞卫親亲觀观譽誉豐丰貞贞賓宾贊赞軒轩農农鈞钧鈺钰銳锐錫锡鎮镇" +
+	"長长雙双靈灵韋韦韻韵頌颂頤颐顏颜風风騰腾魯鲁鳴鸣鶴鹤齊齐祿禄楨桢瑤瑶嫻娴婭娅綠绿" +
+	"嵐岚鋼钢駿骏煥焕燦灿曄晔韜韬瀟潇蕓芸薈荟鈴铃儷俪彥彦寬宽滿满倫伦綸纶凱凯愷恺顯显" +
+	"賦赋剛刚勳勋勵励匯汇協协"
+
+// traditionalToSimplified 繁体字到简体字的映射
+var traditionalToSimplified = func() map[rune]rune {
+	runes := []rune(traditionalPairs)
+	mapping := make(map[rune]rune, len(runes)/2)
+	for i := 0; i+1 < len(runes); i += 2 {
+		mapping[runes[i]] = runes[i+1]
+	}
+	return mapping
+}()
+
+// foldRune 将全角字符转换为半角、繁体字转换为简体并转为小写
+func foldRune(r rune) rune {
+	switch {
+	case r == '　':
+		r = ' '
+	case r >= '！' && r <= '～':
+		r -= 0xFEE0
+	}
+	if simplified, ok := traditionalToSimplified[r]; ok {
+		r = simplified
+	}
+	return unicode.ToLower(r)
+}
+
+// NormalizeName 规范化姓名用于比较：全角转半角、繁体转简体、忽略大小写，并去除空格和标点
+func NormalizeName(name string) string {
+	var builder strings.Builder
+	for _, r := range name {
+		r = foldRune(r)
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// NameTokens 将姓名按空格和标点拆分为规范化后的词（如英文名或拼音 "ZHANG, San" -> [zhang san]）
+func NameTokens(name string) []string {
+	var tokens []string
+	var builder strings.Builder
+	flush := func() {
+		if builder.Len() > 0 {
+			tokens = append(tokens, builder.String())
+			builder.Reset()
+		}
+	}
+	for _, r := range name {
+		r = foldRune(r)
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			builder.WriteRune(r)
+			continue
+		}
+		flush()
+	}
+	flush()
+	return tokens
+}
@@ -0,0 +1,619 @@
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 声明式字段验证规则引擎
+// 规则来源：结构体标签（json 字段名、label 显示名、rule 规则）以及自定义字段的验证规则，
+// API、导入和前端规则接口共用同一套规则，产生相同的错误提示
+//
+// rule 标签语法（逗号分隔）：
+//   - required                      必填
+//   - min=N / max=N                 文本为长度限制，数字为取值范围
+//   - oneof=A B C                   枚举值
+//   - pattern=REGEX                 正则表达式（不能包含逗号）
+//   - required_if=field V1 V2       指定字段等于任一值时必填
+//   - required_without=field1 field2 指定字段均为空时必填
+
+// 字段值类型
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeDate    = "date"
+	TypeBoolean = "boolean"
+)
+
+// DateLayout 日期文本格式
+const DateLayout = "2006-01-02"
+
+// Condition 条件：Field 的值等于 Values 中任一值时成立
+type Condition struct {
+	Field  string   `json:"field"`
+	Values []string `json:"values"`
+}
+
+// Rule 单个字段的验证规则
+type Rule struct {
+	Field           string     `json:"field"`                     // 字段名（json），自定义字段为 custom_fields.<字段名>
+	Label           string     `json:"label"`                     // 显示名称，用于错误提示
+	Type            string     `json:"type"`                      // 值类型
+	Required        bool       `json:"required"`                  // 是否必填
+	RequiredIf      *Condition `json:"requiredIf,omitempty"`      // 条件必填
+	RequiredWithout []string   `json:"requiredWithout,omitempty"` // 这些字段均为空时必填
+	MinLength       *int       `json:"minLength,omitempty"`       // 最小长度（文本）
+	MaxLength       *int       `json:"maxLength,omitempty"`       // 最大长度（文本）
+	Pattern         string     `json:"pattern,omitempty"`         // 正则表达式（文本）
+	Min             *float64   `json:"min,omitempty"`             // 最小值（数字）
+	Max             *float64   `json:"max,omitempty"`             // 最大值（数字）
+	Enum            []string   `json:"enum,omitempty"`            // 可选值（文本）
+	MinDate         string     `json:"minDate,omitempty"`         // 最早日期 YYYY-MM-DD
+	MaxDate         string     `json:"maxDate,omitempty"`         // 最晚日期 YYYY-MM-DD
+}
+
+// FieldError 字段验证错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Message
+}
+
+// Errors 验证错误列表
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	return strings.Join(e.Messages(), "; ")
+}
+
+// Messages 获取错误提示列表
+func (e Errors) Messages() []string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return messages
+}
+
+// RuleSet 字段规则集合，按声明顺序校验
+type RuleSet struct {
+	rules []Rule
+	index map[string]int
+}
+
+// NewRuleSet 创建规则集合，校验正则表达式是否有效
+func NewRuleSet(rules ...Rule) (*RuleSet, error) {
+	rs := &RuleSet{index: make(map[string]int, len(rules))}
+	for _, rule := range rules {
+		if rule.Pattern != "" {
+			if _, err := compilePattern(rule.Pattern); err != nil {
+				return nil, fmt.Errorf("%s正则表达式无效: %v", rule.Label, err)
+			}
+		}
+		if i, ok := rs.index[rule.Field]; ok {
+			rs.rules[i] = rule
+			continue
+		}
+		rs.index[rule.Field] = len(rs.rules)
+		rs.rules = append(rs.rules, rule)
+	}
+	return rs, nil
+}
+
+// ParseStruct 根据结构体的 json、label、rule 标签生成规则集合（map、切片等复合字段忽略）
+func ParseStruct(v interface{}) (*RuleSet, error) {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s不是结构体", t)
+	}
+
+	var rules []Rule
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := jsonName(sf)
+		if name == "" {
+			continue
+		}
+		valueType := fieldValueType(sf.Type)
+		if valueType == "" {
+			continue
+		}
+
+		rule := Rule{Field: name, Label: sf.Tag.Get("label"), Type: valueType}
+		if rule.Label == "" {
+			rule.Label = name
+		}
+		if err := rule.parseTag(sf.Tag.Get("rule")); err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", t.Name(), sf.Name, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return NewRuleSet(rules...)
+}
+
+// MustParseStruct 同 ParseStruct，标签错误时 panic，用于包级变量初始化
+func MustParseStruct(v interface{}) *RuleSet {
+	rs, err := ParseStruct(v)
+	if err != nil {
+		panic(err)
+	}
+	return rs
+}
+
+// parseTag 解析 rule 标签
+func (r *Rule) parseTag(tag string) error {
+	if tag == "" {
+		return nil
+	}
+
+	for _, item := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(item), "=")
+		switch key {
+		case "", "omitempty":
+		case "required":
+			r.Required = true
+		case "min", "max":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("规则%s的值无效", key)
+			}
+			if r.Type == TypeString {
+				length := int(n)
+				if key == "min" {
+					r.MinLength = &length
+				} else {
+					r.MaxLength = &length
+				}
+			} else if key == "min" {
+				r.Min = &n
+			} else {
+				r.Max = &n
+			}
+		case "oneof":
+			r.Enum = strings.Fields(value)
+		case "pattern":
+			r.Pattern = value
+		case "required_if":
+			parts := strings.Fields(value)
+			if len(parts) < 2 {
+				return fmt.Errorf("规则required_if格式应为「字段 值...」")
+			}
+			r.RequiredIf = &Condition{Field: parts[0], Values: parts[1:]}
+		case "required_without":
+			r.RequiredWithout = strings.Fields(value)
+		default:
+			return fmt.Errorf("不支持的规则%s", key)
+		}
+	}
+	return nil
+}
+
+// With 返回追加了规则的新集合，同名字段的规则被覆盖
+func (rs *RuleSet) With(rules ...Rule) (*RuleSet, error) {
+	return NewRuleSet(append(rs.Rules(), rules...)...)
+}
+
+// Rules 获取全部规则
+func (rs *RuleSet) Rules() []Rule {
+	rules := make([]Rule, len(rs.rules))
+	copy(rules, rs.rules)
+	return rules
+}
+
+// Rule 根据字段名获取规则
+func (rs *RuleSet) Rule(field string) *Rule {
+	if i, ok := rs.index[field]; ok {
+		return &rs.rules[i]
+	}
+	return nil
+}
+
+// label 获取字段显示名称，未声明规则的字段返回字段名
+func (rs *RuleSet) label(field string) string {
+	if rule := rs.Rule(field); rule != nil {
+		return rule.Label
+	}
+	return field
+}
+
+// Parse 按规则将文本值转换为对应类型（空值忽略），未声明规则的字段原样保留
+func (rs *RuleSet) Parse(raw map[string]string) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(raw))
+	var errs Errors
+	for _, rule := range rs.rules {
+		text, ok := raw[rule.Field]
+		if !ok || strings.TrimSpace(text) == "" {
+			continue
+		}
+		value, err := rule.Parse(text)
+		if err != nil {
+			errs = append(errs, err.(FieldError))
+			continue
+		}
+		values[rule.Field] = value
+	}
+	for field, text := range raw {
+		if rs.Rule(field) == nil && strings.TrimSpace(text) != "" {
+			values[field] = strings.TrimSpace(text)
+		}
+	}
+
+	if len(errs) > 0 {
+		return values, errs
+	}
+	return values, nil
+}
+
+// Validate 校验完整记录：检查必填、条件必填及各字段取值
+func (rs *RuleSet) Validate(values map[string]interface{}) error {
+	var errs Errors
+	for i := range rs.rules {
+		rule := &rs.rules[i]
+		value := values[rule.Field]
+		if IsEmpty(value) {
+			if rule.Required {
+				errs = append(errs, rule.errorf("%s不能为空", rule.Label))
+			} else if err := rs.checkConditions(rule, values); err != nil {
+				errs = append(errs, *err)
+			}
+			continue
+		}
+		if err := rule.Check(value); err != nil {
+			errs = append(errs, err.(FieldError))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidatePartial 校验部分更新：只校验 values 中出现的字段（显式清空必填字段视为错误），
+// 条件必填在本次修改涉及相关字段时，按 base 与 values 合并后的数据检查
+func (rs *RuleSet) ValidatePartial(values, base map[string]interface{}) error {
+	merged := make(map[string]interface{}, len(base)+len(values))
+	for field, value := range base {
+		merged[field] = value
+	}
+	for field, value := range values {
+		merged[field] = value
+	}
+
+	var errs Errors
+	for i := range rs.rules {
+		rule := &rs.rules[i]
+		value, ok := values[rule.Field]
+		if ok && !IsEmpty(value) {
+			if err := rule.Check(value); err != nil {
+				errs = append(errs, err.(FieldError))
+			}
+			continue
+		}
+		if ok && rule.Required {
+			errs = append(errs, rule.errorf("%s不能为空", rule.Label))
+			continue
+		}
+		if !ok && !rule.dependsOn(values) {
+			continue
+		}
+		if !IsEmpty(merged[rule.Field]) {
+			continue
+		}
+		if err := rs.checkConditions(rule, merged); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateStruct 按规则校验结构体（零值视为未填写）
+func (rs *RuleSet) ValidateStruct(v interface{}) error {
+	return rs.Validate(StructValues(v))
+}
+
+// checkConditions 检查条件必填
+func (rs *RuleSet) checkConditions(rule *Rule, values map[string]interface{}) *FieldError {
+	if cond := rule.RequiredIf; cond != nil {
+		actual := strings.TrimSpace(fmt.Sprintf("%v", values[cond.Field]))
+		if !IsEmpty(values[cond.Field]) && containsString(cond.Values, actual) {
+			err := rule.errorf("%s为%s时%s不能为空", rs.label(cond.Field), strings.Join(cond.Values, "/"), rule.Label)
+			return &err
+		}
+	}
+
+	if len(rule.RequiredWithout) > 0 {
+		var labels []string
+		for _, field := range rule.RequiredWithout {
+			if !IsEmpty(values[field]) {
+				return nil
+			}
+			labels = append(labels, rs.label(field))
+		}
+		err := rule.errorf("未填写%s时%s不能为空", strings.Join(labels, "、"), rule.Label)
+		return &err
+	}
+
+	return nil
+}
+
+// dependsOn 判断规则的条件是否引用了 values 中的字段
+func (r *Rule) dependsOn(values map[string]interface{}) bool {
+	if r.RequiredIf != nil {
+		if _, ok := values[r.RequiredIf.Field]; ok {
+			return true
+		}
+	}
+	for _, field := range r.RequiredWithout {
+		if _, ok := values[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse 将文本转换为规则对应的类型：整数为 int，数字为 float64，日期为 time.Time，布尔支持 是/否、true/false、1/0、yes/no
+func (r *Rule) Parse(raw string) (interface{}, error) {
+	raw = strings.TrimSpace(raw)
+
+	switch r.Type {
+	case TypeInteger:
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, r.errorf("%s必须是整数", r.Label)
+		}
+		return value, nil
+
+	case TypeNumber:
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, r.errorf("%s必须是数字", r.Label)
+		}
+		return value, nil
+
+	case TypeDate:
+		value, err := time.Parse(DateLayout, raw)
+		if err != nil {
+			return nil, r.errorf("%s日期格式应为YYYY-MM-DD", r.Label)
+		}
+		return value, nil
+
+	case TypeBoolean:
+		switch strings.ToLower(raw) {
+		case "true", "是", "1", "yes":
+			return true, nil
+		case "false", "否", "0", "no":
+			return false, nil
+		}
+		return nil, r.errorf("%s必须是「是」或「否」", r.Label)
+
+	default:
+		return raw, nil
+	}
+}
+
+// Check 校验非空值是否满足长度、正则、范围、枚举等规则
+func (r *Rule) Check(value interface{}) error {
+	switch r.Type {
+	case TypeInteger, TypeNumber:
+		n, ok := toFloat(value)
+		if !ok {
+			if r.Type == TypeInteger {
+				return r.errorf("%s必须是整数", r.Label)
+			}
+			return r.errorf("%s必须是数字", r.Label)
+		}
+		if r.Type == TypeInteger && n != float64(int64(n)) {
+			return r.errorf("%s必须是整数", r.Label)
+		}
+		if r.Min != nil && n < *r.Min {
+			return r.errorf("%s不能小于%v", r.Label, *r.Min)
+		}
+		if r.Max != nil && n > *r.Max {
+			return r.errorf("%s不能大于%v", r.Label, *r.Max)
+		}
+
+	case TypeDate:
+		var date string
+		switch v := value.(type) {
+		case time.Time:
+			date = v.Format(DateLayout)
+		case *time.Time:
+			date = v.Format(DateLayout)
+		case string:
+			if _, err := time.Parse(DateLayout, v); err != nil {
+				return r.errorf("%s日期格式应为YYYY-MM-DD", r.Label)
+			}
+			date = v
+		default:
+			return r.errorf("%s日期格式应为YYYY-MM-DD", r.Label)
+		}
+		if r.MinDate != "" && date < r.MinDate {
+			return r.errorf("%s不能早于%s", r.Label, r.MinDate)
+		}
+		if r.MaxDate != "" && date > r.MaxDate {
+			return r.errorf("%s不能晚于%s", r.Label, r.MaxDate)
+		}
+
+	case TypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return r.errorf("%s必须是「是」或「否」", r.Label)
+		}
+
+	default:
+		text, ok := value.(string)
+		if !ok {
+			return r.errorf("%s必须是文本", r.Label)
+		}
+		length := len([]rune(text))
+		if r.MinLength != nil && length < *r.MinLength {
+			return r.errorf("%s长度不能少于%d个字符", r.Label, *r.MinLength)
+		}
+		if r.MaxLength != nil && length > *r.MaxLength {
+			return r.errorf("%s长度不能超过%d个字符", r.Label, *r.MaxLength)
+		}
+		if len(r.Enum) > 0 && !containsString(r.Enum, text) {
+			return r.errorf("%s必须是%s之一", r.Label, strings.Join(r.Enum, "/"))
+		}
+		if r.Pattern != "" {
+			re, err := compilePattern(r.Pattern)
+			if err != nil || !re.MatchString(text) {
+				return r.errorf("%s格式不正确", r.Label)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *Rule) errorf(format string, args ...interface{}) FieldError {
+	return FieldError{Field: r.Field, Message: fmt.Sprintf(format, args...)}
+}
+
+// StructValues 将结构体按 json 字段名转换为取值映射：零值和 nil 指针视为未填写，指针取其指向的值
+func StructValues(v interface{}) map[string]interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return map[string]interface{}{}
+		}
+		rv = rv.Elem()
+	}
+
+	values := make(map[string]interface{})
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		name := jsonName(sf)
+		if name == "" || fieldValueType(sf.Type) == "" {
+			continue
+		}
+
+		field := rv.Field(i)
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		} else if field.IsZero() {
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			values[name] = int(field.Int())
+		case reflect.Float32, reflect.Float64:
+			values[name] = field.Float()
+		default:
+			values[name] = field.Interface()
+		}
+	}
+	return values
+}
+
+// IsEmpty 判断值是否为空：nil、空白文本、零时间视为空，数字0和false不视为空
+func IsEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case time.Time:
+		return v.IsZero()
+	case *time.Time:
+		return v == nil || v.IsZero()
+	}
+	return false
+}
+
+// jsonName 获取结构体字段的 json 名称，忽略的字段返回空
+func jsonName(sf reflect.StructField) string {
+	if !sf.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// fieldValueType 根据字段的 Go 类型推断值类型，不支持的类型返回空
+func fieldValueType(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return TypeString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return TypeInteger
+	case reflect.Float32, reflect.Float64:
+		return TypeNumber
+	case reflect.Bool:
+		return TypeBoolean
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return TypeDate
+		}
+	}
+	return ""
+}
+
+// toFloat 将数字类型的值转换为 float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+// patternCache 已编译的正则表达式
+var patternCache sync.Map
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, re)
+	return re, nil
+}
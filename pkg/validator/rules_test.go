@@ -0,0 +1,285 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type sampleRequest struct {
+	Name          string     `json:"name" rule:"required,max=5" label:"姓名"`
+	PaymentMethod string     `json:"payment_method" rule:"oneof=期缴 趸缴" label:"缴费方式"`
+	PaymentYears  int        `json:"payment_years" rule:"required_if=payment_method 期缴,min=1,max=30" label:"缴费年期"`
+	Rate          float64    `json:"rate" rule:"min=0,max=100" label:"费率"`
+	Code          string     `json:"code" rule:"pattern=^[A-Z]{2}[0-9]+$" label:"编号"`
+	ProductID     string     `json:"product_id" label:"产品"`
+	Company       string     `json:"company" rule:"required_without=product_id" label:"承保公司"`
+	EffectiveDate *time.Time `json:"effective_date" label:"生效日期"`
+	IsEmployee    bool       `json:"is_employee" label:"是否员工"`
+	Tags          []string   `json:"tags" label:"标签"`
+}
+
+func sampleRules(t *testing.T) *RuleSet {
+	t.Helper()
+	rs, err := ParseStruct(sampleRequest{})
+	if err != nil {
+		t.Fatalf("ParseStruct() error = %v", err)
+	}
+	return rs
+}
+
+// validSample 返回一条能通过全部规则的记录，各用例在此基础上修改单个字段
+func validSample() map[string]interface{} {
+	return map[string]interface{}{
+		"name":           "张三",
+		"payment_method": "期缴",
+		"payment_years":  10,
+		"rate":           2.5,
+		"code":           "AB123",
+		"company":        "友邦",
+	}
+}
+
+func messagesOf(err error) []string {
+	if err == nil {
+		return nil
+	}
+	return err.(Errors).Messages()
+}
+
+func TestRuleSetValidate(t *testing.T) {
+	rs := sampleRules(t)
+
+	tests := []struct {
+		name   string
+		change map[string]interface{}
+		want   []string
+	}{
+		{name: "有效记录", want: nil},
+		{name: "必填为空", change: map[string]interface{}{"name": ""}, want: []string{"姓名不能为空"}},
+		{name: "必填只有空白", change: map[string]interface{}{"name": "  "}, want: []string{"姓名不能为空"}},
+		{name: "长度等于上限", change: map[string]interface{}{"name": "一二三四五"}, want: nil},
+		{name: "长度超过上限按字符计", change: map[string]interface{}{"name": "一二三四五六"}, want: []string{"姓名长度不能超过5个字符"}},
+		{name: "枚举值无效", change: map[string]interface{}{"payment_method": "月缴"}, want: []string{"缴费方式必须是期缴/趸缴之一"}},
+		{name: "条件必填成立", change: map[string]interface{}{"payment_years": nil}, want: []string{"缴费方式为期缴时缴费年期不能为空"}},
+		{name: "条件必填不成立", change: map[string]interface{}{"payment_method": "趸缴", "payment_years": nil}, want: nil},
+		{name: "整数等于下限", change: map[string]interface{}{"payment_years": 1}, want: nil},
+		{name: "整数为0不视为空", change: map[string]interface{}{"payment_years": 0}, want: []string{"缴费年期不能小于1"}},
+		{name: "整数等于上限", change: map[string]interface{}{"payment_years": 30}, want: nil},
+		{name: "整数超过上限", change: map[string]interface{}{"payment_years": 31}, want: []string{"缴费年期不能大于30"}},
+		{name: "整数字段为小数", change: map[string]interface{}{"payment_years": 1.5}, want: []string{"缴费年期必须是整数"}},
+		{name: "数字等于上限", change: map[string]interface{}{"rate": 100.0}, want: nil},
+		{name: "数字超过上限", change: map[string]interface{}{"rate": 100.01}, want: []string{"费率不能大于100"}},
+		{name: "数字小于下限", change: map[string]interface{}{"rate": -0.01}, want: []string{"费率不能小于0"}},
+		{name: "数字字段为文本", change: map[string]interface{}{"rate": "abc"}, want: []string{"费率必须是数字"}},
+		{name: "正则不匹配", change: map[string]interface{}{"code": "ab123"}, want: []string{"编号格式不正确"}},
+		{name: "缺少替代字段", change: map[string]interface{}{"company": ""}, want: []string{"未填写产品时承保公司不能为空"}},
+		{name: "填写替代字段", change: map[string]interface{}{"company": "", "product_id": "PRD001"}, want: nil},
+		{name: "多个错误按声明顺序", change: map[string]interface{}{"name": nil, "rate": 101.0}, want: []string{"姓名不能为空", "费率不能大于100"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := validSample()
+			for field, value := range tt.change {
+				values[field] = value
+			}
+			if got := messagesOf(rs.Validate(values)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleSetValidatePartial(t *testing.T) {
+	rs := sampleRules(t)
+
+	tests := []struct {
+		name   string
+		values map[string]interface{}
+		base   map[string]interface{}
+		want   []string
+	}{
+		{name: "未涉及的必填字段不校验", values: map[string]interface{}{"rate": 5.0}, base: map[string]interface{}{}, want: nil},
+		{name: "显式清空必填字段", values: map[string]interface{}{"name": ""}, base: validSample(), want: []string{"姓名不能为空"}},
+		{name: "修改条件字段时按合并后数据检查", values: map[string]interface{}{"payment_method": "期缴"}, base: map[string]interface{}{"company": "友邦"}, want: []string{"缴费方式为期缴时缴费年期不能为空"}},
+		{name: "原记录已满足条件必填", values: map[string]interface{}{"payment_method": "期缴"}, base: map[string]interface{}{"payment_years": 5, "company": "友邦"}, want: nil},
+		{name: "修改的字段仍校验取值", values: map[string]interface{}{"payment_years": 31}, base: validSample(), want: []string{"缴费年期不能大于30"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messagesOf(rs.ValidatePartial(tt.values, tt.base)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidatePartial() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleParse(t *testing.T) {
+	rs := sampleRules(t)
+	date := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		field   string
+		raw     string
+		want    interface{}
+		wantErr string
+	}{
+		{name: "整数", field: "payment_years", raw: " 12 ", want: 12},
+		{name: "整数格式错误", field: "payment_years", raw: "12.5", wantErr: "缴费年期必须是整数"},
+		{name: "数字", field: "rate", raw: "3.75", want: 3.75},
+		{name: "数字格式错误", field: "rate", raw: "3,75", wantErr: "费率必须是数字"},
+		{name: "日期", field: "effective_date", raw: "2024-02-29", want: date},
+		{name: "日期格式错误", field: "effective_date", raw: "2024/02/29", wantErr: "生效日期日期格式应为YYYY-MM-DD"},
+		{name: "不存在的日期", field: "effective_date", raw: "2023-02-29", wantErr: "生效日期日期格式应为YYYY-MM-DD"},
+		{name: "布尔值是", field: "is_employee", raw: "是", want: true},
+		{name: "布尔值NO", field: "is_employee", raw: "NO", want: false},
+		{name: "布尔值无效", field: "is_employee", raw: "也许", wantErr: "是否员工必须是「是」或「否」"},
+		{name: "文本去除首尾空白", field: "name", raw: " 张三 ", want: "张三"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rs.Rule(tt.field).Parse(tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Parse() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleSetParseCollectsErrors(t *testing.T) {
+	rs := sampleRules(t)
+
+	values, err := rs.Parse(map[string]string{
+		"payment_years": "abc",
+		"rate":          "1.5",
+		"name":          "",
+		"remark":        " 备注 ",
+	})
+	if got, want := messagesOf(err), []string{"缴费年期必须是整数"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Parse() errors = %v, want %v", got, want)
+	}
+	if values["rate"] != 1.5 {
+		t.Errorf("rate = %#v, want 1.5", values["rate"])
+	}
+	if _, ok := values["name"]; ok {
+		t.Errorf("空值不应出现在结果中")
+	}
+	if values["remark"] != "备注" {
+		t.Errorf("未声明规则的字段 remark = %#v, want %q", values["remark"], "备注")
+	}
+}
+
+func TestParseStructRules(t *testing.T) {
+	rs := sampleRules(t)
+
+	if rs.Rule("tags") != nil {
+		t.Errorf("切片字段不应生成规则")
+	}
+
+	years := rs.Rule("payment_years")
+	if years == nil || years.Type != TypeInteger || *years.Min != 1 || *years.Max != 30 {
+		t.Fatalf("payment_years 规则 = %+v", years)
+	}
+	if years.RequiredIf == nil || years.RequiredIf.Field != "payment_method" || !reflect.DeepEqual(years.RequiredIf.Values, []string{"期缴"}) {
+		t.Errorf("payment_years 条件必填 = %+v", years.RequiredIf)
+	}
+
+	name := rs.Rule("name")
+	if name == nil || !name.Required || name.MaxLength == nil || *name.MaxLength != 5 || name.Max != nil {
+		t.Errorf("name 规则 = %+v，文本的 max 应为长度限制", name)
+	}
+}
+
+func TestParseStructInvalidTags(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{name: "不支持的规则", v: struct {
+			Name string `json:"name" rule:"unique"`
+		}{}},
+		{name: "数值无效", v: struct {
+			Name string `json:"name" rule:"max=abc"`
+		}{}},
+		{name: "条件必填缺少取值", v: struct {
+			Years int `json:"years" rule:"required_if=method"`
+		}{}},
+		{name: "正则无效", v: struct {
+			Code string `json:"code" rule:"pattern=[A-Z"`
+		}{}},
+		{name: "不是结构体", v: "name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseStruct(tt.v); err == nil {
+				t.Errorf("ParseStruct() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestRuleSetWithOverridesField(t *testing.T) {
+	rs := sampleRules(t)
+	max := 10
+	extended, err := rs.With(Rule{Field: "name", Label: "客户姓名", Type: TypeString, MaxLength: &max})
+	if err != nil {
+		t.Fatalf("With() error = %v", err)
+	}
+
+	if got := len(extended.Rules()); got != len(rs.Rules()) {
+		t.Errorf("同名字段应覆盖原规则, len = %d, want %d", got, len(rs.Rules()))
+	}
+	if extended.Rule("name").Required {
+		t.Errorf("覆盖后的规则不应保留原规则的必填")
+	}
+	if !rs.Rule("name").Required {
+		t.Errorf("With() 不应修改原规则集合")
+	}
+}
+
+func TestStructValues(t *testing.T) {
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	got := StructValues(&sampleRequest{Name: "张三", PaymentYears: 3, EffectiveDate: &date, Tags: []string{"a"}})
+	want := map[string]interface{}{"name": "张三", "payment_years": 3, "effective_date": date}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("StructValues() = %#v, want %#v", got, want)
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	var nilTime *time.Time
+	tests := []struct {
+		value interface{}
+		want  bool
+	}{
+		{nil, true},
+		{"", true},
+		{" \t", true},
+		{"0", false},
+		{0, false},
+		{false, false},
+		{time.Time{}, true},
+		{nilTime, true},
+		{time.Now(), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsEmpty(tt.value); got != tt.want {
+			t.Errorf("IsEmpty(%#v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
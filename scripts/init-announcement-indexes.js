@@ -0,0 +1,49 @@
+// MongoDB通知公告集合索引及权限菜单初始化脚本
+
+// 切换到项目数据库
+db = db.getSiblingDB('insurance_db');
+
+print('正在创建通知公告集合索引...');
+
+// 1. 公告业务主键索引
+db.announcements.createIndex({ "announcement_id": 1 }, { unique: true, name: "idx_announcement_id" });
+print('创建公告ID唯一索引: idx_announcement_id');
+
+// 2. 公告管理列表索引（company_id为空表示平台公告）
+db.announcements.createIndex({ "company_id": 1, "created_at": -1 }, { name: "idx_company_created_at" });
+print('创建公告管理列表索引: idx_company_created_at');
+
+// 3. 用户可见公告查询索引
+db.announcements.createIndex({ "status": 1, "publish_at": -1, "audience_type": 1 }, { name: "idx_status_publish_at" });
+print('创建可见公告查询索引: idx_status_publish_at');
+
+// 4. 阅读回执索引（每个用户每条公告一条）
+db.announcement_reads.createIndex({ "announcement_id": 1, "user_id": 1 }, { unique: true, name: "idx_announcement_user" });
+db.announcement_reads.createIndex({ "user_id": 1 }, { name: "idx_user_id" });
+print('创建阅读回执索引');
+
+// 5. 公告管理权限菜单
+print('创建公告管理权限菜单...');
+var now = new Date();
+var announcementMenus = [
+    { menu_id: "MENU_ANNOUNCEMENT_MGMT", parent_id: "MENU_SYSTEM_MGMT", menu_name: "通知公告", menu_type: "menu", route_path: "/system/announcement", component: "AnnouncementManagement", permission_code: "announcement:list", sort_order: 3 },
+    { menu_id: "BTN_ANNOUNCEMENT_CREATE", parent_id: "MENU_ANNOUNCEMENT_MGMT", menu_name: "新增公告", menu_type: "button", route_path: "", component: "", permission_code: "announcement:create", sort_order: 1 },
+    { menu_id: "BTN_ANNOUNCEMENT_EDIT", parent_id: "MENU_ANNOUNCEMENT_MGMT", menu_name: "编辑公告", menu_type: "button", route_path: "", component: "", permission_code: "announcement:edit", sort_order: 2 },
+    { menu_id: "BTN_ANNOUNCEMENT_DELETE", parent_id: "MENU_ANNOUNCEMENT_MGMT", menu_name: "删除公告", menu_type: "button", route_path: "", component: "", permission_code: "announcement:delete", sort_order: 3 }
+];
+
+announcementMenus.forEach(function(menu) {
+    if (db.menus.countDocuments({ menu_id: menu.menu_id }) === 0) {
+        menu.icon = "";
+        menu.visible = menu.menu_type !== "button";
+        menu.status = "enable";
+        menu.created_at = now;
+        menu.updated_at = now;
+        db.menus.insertOne(menu);
+        print('菜单创建成功: ' + menu.menu_name);
+    } else {
+        print('菜单已存在，跳过创建: ' + menu.menu_name);
+    }
+});
+
+print('通知公告集合索引及权限菜单创建完成！');
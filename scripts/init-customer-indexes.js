@@ -0,0 +1,34 @@
+// MongoDB客户档案集合索引初始化脚本
+
+// 切换到项目数据库
+db = db.getSiblingDB('insurance_db');
+
+print('正在创建客户档案集合索引...');
+
+// 1. 客户业务主键索引
+db.customers.createIndex({ "customer_id": 1 }, { unique: true, name: "idx_customer_id" });
+print('创建客户ID唯一索引: idx_customer_id');
+
+// 2. 客户号唯一索引（按公司隔离）
+db.customers.createIndex({ "company_id": 1, "customer_number": 1 }, { unique: true, name: "idx_company_customer_number" });
+print('创建客户号复合唯一索引: idx_company_customer_number');
+
+// 3. 客户姓名索引
+db.customers.createIndex({ "company_id": 1, "customer_name_cn": 1 }, { name: "idx_company_customer_name_cn" });
+print('创建客户中文名复合索引: idx_company_customer_name_cn');
+
+// 4. 曾用客户号索引（合并客户后按旧客户号关联保单）
+db.customers.createIndex({ "company_id": 1, "aliases": 1 }, { name: "idx_company_aliases" });
+print('创建曾用客户号复合索引: idx_company_aliases');
+
+// 5. 疑似重复客户索引
+db.customer_duplicates.createIndex({ "duplicate_id": 1 }, { unique: true, name: "idx_duplicate_id" });
+db.customer_duplicates.createIndex({ "company_id": 1, "customer_a_id": 1, "customer_b_id": 1 }, { unique: true, name: "idx_company_customer_pair" });
+db.customer_duplicates.createIndex({ "company_id": 1, "status": 1, "score": -1 }, { name: "idx_company_status_score" });
+print('创建疑似重复客户索引');
+
+// 6. 保单客户引用索引
+db.policies.createIndex({ "customer_id": 1 }, { name: "idx_customer_id" });
+print('创建保单客户引用索引');
+
+print('客户档案集合索引创建完成！');
@@ -0,0 +1,43 @@
+// MongoDB敏感字段盲索引初始化脚本（启用 security.encryption 后执行）
+
+// 切换到项目数据库
+db = db.getSiblingDB('insurance_db');
+
+print('正在创建敏感字段盲索引...');
+
+// 1. 保单按账户号精确查询、查重
+db.policies.createIndex({ "company_id": 1, "blind_index.account_number": 1 }, { name: "idx_company_blind_account_number" });
+print('创建账户号盲索引: idx_company_blind_account_number');
+
+// 2. 保单按客户号精确查询、关联客户档案
+db.policies.createIndex({ "company_id": 1, "blind_index.customer_number": 1 }, { name: "idx_company_blind_customer_number" });
+print('创建客户号盲索引: idx_company_blind_customer_number');
+
+// 3. 保单按客户中文名精确查询
+db.policies.createIndex({ "company_id": 1, "blind_index.customer_name_cn": 1 }, { name: "idx_company_blind_customer_name_cn" });
+print('创建客户中文名盲索引: idx_company_blind_customer_name_cn');
+
+// 4. 保单按客户英文名精确查询
+db.policies.createIndex({ "company_id": 1, "blind_index.customer_name_en": 1 }, { name: "idx_company_blind_customer_name_en" });
+print('创建客户英文名盲索引: idx_company_blind_customer_name_en');
+
+// 5. 客户档案按客户号精确查询、查重（客户号加密后原唯一索引不再生效，由盲索引保证唯一）
+db.customers.createIndex(
+    { "company_id": 1, "blind_index.customer_number": 1 },
+    { unique: true, partialFilterExpression: { "blind_index.customer_number": { $exists: true } }, name: "idx_company_blind_customer_number" }
+);
+print('创建客户档案客户号盲索引: idx_company_blind_customer_number');
+
+// 6. 客户档案按中文名精确查询
+db.customers.createIndex({ "company_id": 1, "blind_index.customer_name_cn": 1 }, { name: "idx_company_blind_customer_name_cn" });
+print('创建客户档案中文名盲索引: idx_company_blind_customer_name_cn');
+
+// 7. 客户档案按英文名精确查询
+db.customers.createIndex({ "company_id": 1, "blind_index.customer_name_en": 1 }, { name: "idx_company_blind_customer_name_en" });
+print('创建客户档案英文名盲索引: idx_company_blind_customer_name_en');
+
+// 8. 用户按邮箱登录、查重（用户仓库启动时也会创建）
+db.users.createIndex({ "blind_index.email": 1 }, { sparse: true, name: "idx_blind_index_email" });
+print('创建邮箱盲索引: idx_blind_index_email');
+
+print('敏感字段盲索引创建完成！');
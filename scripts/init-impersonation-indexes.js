@@ -0,0 +1,28 @@
+// MongoDB模拟登录会话集合索引初始化脚本
+
+// 切换到项目数据库
+db = db.getSiblingDB('insurance_db');
+
+print('正在创建模拟登录会话集合索引...');
+
+// 1. 会话ID唯一索引（认证中间件每次请求按会话ID校验）
+db.impersonation_sessions.createIndex({ "session_id": 1 }, { unique: true, name: "idx_session_id" });
+print('创建会话ID唯一索引: idx_session_id');
+
+// 2. 按操作人查询模拟登录记录
+db.impersonation_sessions.createIndex({ "actor_id": 1, "started_at": -1 }, { name: "idx_actor_started" });
+print('创建操作人索引: idx_actor_started');
+
+// 3. 按被模拟用户查询模拟登录记录
+db.impersonation_sessions.createIndex({ "target_user_id": 1, "started_at": -1 }, { name: "idx_target_user_started" });
+print('创建被模拟用户索引: idx_target_user_started');
+
+// 4. 按公司查询模拟登录记录
+db.impersonation_sessions.createIndex({ "target_company_id": 1, "started_at": -1 }, { name: "idx_target_company_started" });
+print('创建公司索引: idx_target_company_started');
+
+// 5. 按状态和过期时间查询进行中、已过期的会话
+db.impersonation_sessions.createIndex({ "status": 1, "expires_at": 1 }, { name: "idx_status_expires" });
+print('创建状态索引: idx_status_expires');
+
+print('模拟登录会话集合索引创建完成！');
@@ -0,0 +1,49 @@
+// MongoDB用户邀请集合索引及权限菜单初始化脚本
+
+// 切换到项目数据库
+db = db.getSiblingDB('insurance_db');
+
+print('正在创建用户邀请相关集合索引...');
+
+// 1. 邀请ID唯一索引
+db.invitations.createIndex({ "invitation_id": 1 }, { unique: true, name: "idx_invitation_id" });
+print('创建邀请ID唯一索引: idx_invitation_id');
+
+// 2. 令牌摘要唯一索引
+db.invitations.createIndex({ "token_hash": 1 }, { unique: true, name: "idx_token_hash" });
+print('创建令牌摘要唯一索引: idx_token_hash');
+
+// 3. 按公司查询、统计待接受邀请
+db.invitations.createIndex({ "company_id": 1, "status": 1, "expires_at": 1 }, { name: "idx_company_status_expires" });
+print('创建公司邀请索引: idx_company_status_expires');
+
+// 4. 按邮箱检查重复邀请
+db.invitations.createIndex({ "email": 1, "company_id": 1 }, { name: "idx_email_company" });
+print('创建邮箱索引: idx_email_company');
+
+// 5. 接受邀请锁，每个公司一条（依赖唯一索引实现互斥）
+db.invitation_locks.createIndex({ "company_id": 1 }, { unique: true, name: "idx_company_id" });
+print('创建接受邀请锁唯一索引: idx_company_id');
+
+// 6. 邀请管理权限菜单
+print('创建邀请管理权限菜单...');
+var now = new Date();
+var invitationMenus = [
+    { menu_id: "MENU_USER_INVITATION", parent_id: "MENU_USER_MGMT", menu_name: "邀请管理", menu_type: "menu", route_path: "/user/invitations", component: "InvitationList", permission_code: "user:invite", sort_order: 2 }
+];
+
+invitationMenus.forEach(function(menu) {
+    if (db.menus.countDocuments({ menu_id: menu.menu_id }) === 0) {
+        menu.icon = "";
+        menu.visible = menu.menu_type !== "button";
+        menu.status = "enable";
+        menu.created_at = now;
+        menu.updated_at = now;
+        db.menus.insertOne(menu);
+        print('菜单创建成功: ' + menu.menu_name);
+    } else {
+        print('菜单已存在，跳过创建: ' + menu.menu_name);
+    }
+});
+
+print('用户邀请相关集合索引及权限菜单创建完成！');
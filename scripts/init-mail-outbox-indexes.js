@@ -0,0 +1,21 @@
+// MongoDB邮件发件箱集合索引初始化脚本
+
+// 切换到项目数据库
+db = db.getSiblingDB('insurance_db');
+
+print('正在创建邮件发件箱集合索引...');
+
+// 1. 邮件业务主键索引
+db.mail_outbox.createIndex({ "message_id": 1 }, { unique: true, name: "idx_message_id" });
+print('创建邮件ID唯一索引: idx_message_id');
+
+// 2. 发件箱领取待发送邮件索引
+db.mail_outbox.createIndex({ "status": 1, "next_attempt_at": 1 }, { name: "idx_status_next_attempt_at" });
+print('创建待发送邮件索引: idx_status_next_attempt_at');
+
+// 3. 管理端列表索引
+db.mail_outbox.createIndex({ "created_at": -1 }, { name: "idx_created_at" });
+db.mail_outbox.createIndex({ "status": 1, "created_at": -1 }, { name: "idx_status_created_at" });
+print('创建发件箱列表索引');
+
+print('邮件发件箱集合索引创建完成！');
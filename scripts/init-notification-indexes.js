@@ -0,0 +1,25 @@
+// MongoDB站内通知集合索引初始化脚本
+
+// 切换到项目数据库
+db = db.getSiblingDB('insurance_db');
+
+print('正在创建站内通知集合索引...');
+
+// 1. 通知业务主键索引
+db.notifications.createIndex({ "notification_id": 1 }, { unique: true, name: "idx_notification_id" });
+print('创建通知ID唯一索引: idx_notification_id');
+
+// 2. 通知去重索引（同一用户同一去重键只生成一条通知）
+db.notifications.createIndex({ "user_id": 1, "dedup_key": 1 }, { unique: true, name: "idx_user_dedup_key" });
+print('创建通知去重唯一索引: idx_user_dedup_key');
+
+// 3. 通知列表及未读数量索引
+db.notifications.createIndex({ "user_id": 1, "is_read": 1, "created_at": -1 }, { name: "idx_user_read_created_at" });
+print('创建通知列表复合索引: idx_user_read_created_at');
+
+// 4. 通知偏好索引
+db.notification_preferences.createIndex({ "user_id": 1 }, { unique: true, name: "idx_user_id" });
+db.notification_preferences.createIndex({ "company_id": 1 }, { name: "idx_company_id" });
+print('创建通知偏好索引');
+
+print('站内通知集合索引创建完成！');
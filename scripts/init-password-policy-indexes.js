@@ -0,0 +1,33 @@
+// MongoDB密码策略集合索引及权限菜单初始化脚本
+
+// 切换到项目数据库
+db = db.getSiblingDB('insurance_db');
+
+print('正在创建密码策略集合索引...');
+
+// 1. 每个公司（平台策略为空字符串）只有一条策略
+db.password_policies.createIndex({ "company_id": 1 }, { unique: true, name: "idx_company_id" });
+print('创建公司ID唯一索引: idx_company_id');
+
+// 2. 密码策略权限菜单
+print('创建密码策略权限菜单...');
+var now = new Date();
+var policyMenus = [
+    { menu_id: "MENU_PASSWORD_POLICY", parent_id: "MENU_SYSTEM_MGMT", menu_name: "密码策略", menu_type: "menu", route_path: "/system/password-policy", component: "PasswordPolicy", permission_code: "password_policy:manage", sort_order: 4 }
+];
+
+policyMenus.forEach(function(menu) {
+    if (db.menus.countDocuments({ menu_id: menu.menu_id }) === 0) {
+        menu.icon = "";
+        menu.visible = menu.menu_type !== "button";
+        menu.status = "enable";
+        menu.created_at = now;
+        menu.updated_at = now;
+        db.menus.insertOne(menu);
+        print('菜单创建成功: ' + menu.menu_name);
+    } else {
+        print('菜单已存在，跳过创建: ' + menu.menu_name);
+    }
+});
+
+print('密码策略集合索引及权限菜单创建完成！');
@@ -0,0 +1,28 @@
+// MongoDB重置密码令牌与请求记录集合索引初始化脚本
+
+// 切换到项目数据库
+db = db.getSiblingDB('insurance_db');
+
+print('正在创建重置密码相关集合索引...');
+
+// 1. 令牌摘要唯一索引
+db.password_reset_tokens.createIndex({ "token_hash": 1 }, { unique: true, name: "idx_token_hash" });
+print('创建令牌摘要唯一索引: idx_token_hash');
+
+// 2. 按用户统计发送次数、作废未使用令牌
+db.password_reset_tokens.createIndex({ "user_id": 1, "created_at": -1 }, { name: "idx_user_id_created_at" });
+print('创建用户令牌索引: idx_user_id_created_at');
+
+// 3. 过期令牌保留一天后自动清理
+db.password_reset_tokens.createIndex({ "expires_at": 1 }, { expireAfterSeconds: 86400, name: "idx_expires_at_ttl" });
+print('创建令牌过期清理索引: idx_expires_at_ttl');
+
+// 4. 按IP统计忘记密码请求次数
+db.password_reset_requests.createIndex({ "ip": 1, "created_at": -1 }, { name: "idx_ip_created_at" });
+print('创建请求IP索引: idx_ip_created_at');
+
+// 5. 请求记录保留一天后自动清理
+db.password_reset_requests.createIndex({ "created_at": 1 }, { expireAfterSeconds: 86400, name: "idx_created_at_ttl" });
+print('创建请求记录清理索引: idx_created_at_ttl');
+
+print('重置密码相关集合索引创建完成！');
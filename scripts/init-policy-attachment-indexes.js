@@ -0,0 +1,20 @@
+// MongoDB保单附件集合索引初始化脚本
+
+// 切换到项目数据库
+db = db.getSiblingDB('insurance_db');
+
+print('正在创建保单附件集合索引...');
+
+// 1. 附件业务主键索引
+db.policy_attachments.createIndex({ "attachment_id": 1 }, { unique: true, name: "idx_attachment_id" });
+print('创建附件ID唯一索引: idx_attachment_id');
+
+// 2. 保单附件列表及数量统计索引
+db.policy_attachments.createIndex({ "policy_id": 1, "created_at": -1 }, { name: "idx_policy_created_at" });
+print('创建保单附件复合索引: idx_policy_created_at');
+
+// 3. 公司索引
+db.policy_attachments.createIndex({ "company_id": 1 }, { name: "idx_company_id" });
+print('创建公司索引: idx_company_id');
+
+print('保单附件集合索引创建完成！');
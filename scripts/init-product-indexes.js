@@ -0,0 +1,29 @@
+// MongoDB产品目录集合索引初始化脚本
+
+// 切换到项目数据库
+db = db.getSiblingDB('insurance_db');
+
+print('正在创建产品目录集合索引...');
+
+// 1. 承保公司业务主键索引
+db.insurers.createIndex({ "insurer_id": 1 }, { unique: true, name: "idx_insurer_id" });
+print('创建承保公司ID唯一索引: idx_insurer_id');
+
+// 2. 承保公司名称唯一索引（按公司隔离）
+db.insurers.createIndex({ "company_id": 1, "insurer_name": 1 }, { unique: true, name: "idx_company_insurer_name" });
+print('创建承保公司名称复合唯一索引: idx_company_insurer_name');
+
+// 3. 产品业务主键索引
+db.products.createIndex({ "product_id": 1 }, { unique: true, name: "idx_product_id" });
+print('创建产品ID唯一索引: idx_product_id');
+
+// 4. 产品名称唯一索引（同一承保公司下唯一）
+db.products.createIndex({ "company_id": 1, "insurer_id": 1, "product_name": 1 }, { unique: true, name: "idx_company_insurer_product" });
+print('创建产品名称复合唯一索引: idx_company_insurer_product');
+
+// 5. 保单产品引用索引
+db.policies.createIndex({ "product_id": 1 }, { name: "idx_product_id" });
+db.policies.createIndex({ "insurer_id": 1 }, { name: "idx_insurer_id" });
+print('创建保单产品引用索引');
+
+print('产品目录集合索引创建完成！');
@@ -0,0 +1,20 @@
+// MongoDB转介费规则集合索引初始化脚本
+
+// 切换到项目数据库
+db = db.getSiblingDB('insurance_db');
+
+print('正在创建转介费规则集合索引...');
+
+// 1. 业务主键索引
+db.referral_fee_rules.createIndex({ "rule_id": 1 }, { unique: true, name: "idx_rule_id" });
+print('创建规则ID唯一索引: idx_rule_id');
+
+// 2. 公司规则查询索引（按状态、优先级）
+db.referral_fee_rules.createIndex({ "company_id": 1, "status": 1, "priority": -1 }, { name: "idx_company_status_priority" });
+print('创建公司规则复合索引: idx_company_status_priority');
+
+// 3. 保单命中规则索引
+db.policies.createIndex({ "fee_rule_id": 1 }, { name: "idx_fee_rule_id" });
+print('创建保单转介费规则索引: idx_fee_rule_id');
+
+print('转介费规则集合索引创建完成！');
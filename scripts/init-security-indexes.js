@@ -0,0 +1,53 @@
+// MongoDB安全事件及登录地点集合索引及权限菜单初始化脚本
+
+// 切换到项目数据库
+db = db.getSiblingDB('insurance_db');
+
+print('正在创建安全管理相关集合索引...');
+
+// 1. 安全事件合并键唯一索引（同一用户同一IP的拒绝访问按小时合并）
+db.security_events.createIndex({ "dedup_key": 1 }, { unique: true, name: "idx_dedup_key" });
+print('创建安全事件合并键唯一索引: idx_dedup_key');
+
+// 2. 按公司、类型查询安全事件
+db.security_events.createIndex({ "company_id": 1, "type": 1, "last_seen_at": -1 }, { name: "idx_company_type_last_seen" });
+print('创建公司安全事件索引: idx_company_type_last_seen');
+
+// 3. 按用户查询安全事件
+db.security_events.createIndex({ "user_id": 1, "last_seen_at": -1 }, { name: "idx_user_last_seen" });
+print('创建用户安全事件索引: idx_user_last_seen');
+
+// 4. 登录地点唯一索引（用户 + IP + 设备）
+db.user_login_locations.createIndex({ "user_id": 1, "ip_address": 1, "device": 1 }, { unique: true, name: "idx_user_ip_device" });
+print('创建登录地点唯一索引: idx_user_ip_device');
+
+// 5. 按用户查询登录过的设备
+db.user_login_locations.createIndex({ "user_id": 1, "device": 1 }, { name: "idx_user_device" });
+print('创建登录设备索引: idx_user_device');
+
+// 6. 登录地点180天未再使用后自动清理，之后再从该IP或设备登录会重新提醒
+db.user_login_locations.createIndex({ "last_seen_at": 1 }, { expireAfterSeconds: 180 * 24 * 3600, name: "idx_last_seen_ttl" });
+print('创建登录地点过期索引: idx_last_seen_ttl');
+
+// 7. 安全管理权限菜单
+print('创建安全管理权限菜单...');
+var now = new Date();
+var securityMenus = [
+    { menu_id: "MENU_SECURITY_MGMT", parent_id: "MENU_SYSTEM_MGMT", menu_name: "安全管理", menu_type: "menu", route_path: "/system/security", component: "SecurityManagement", permission_code: "security:manage", sort_order: 6 }
+];
+
+securityMenus.forEach(function(menu) {
+    if (db.menus.countDocuments({ menu_id: menu.menu_id }) === 0) {
+        menu.icon = "";
+        menu.visible = menu.menu_type !== "button";
+        menu.status = "enable";
+        menu.created_at = now;
+        menu.updated_at = now;
+        db.menus.insertOne(menu);
+        print('菜单创建成功: ' + menu.menu_name);
+    } else {
+        print('菜单已存在，跳过创建: ' + menu.menu_name);
+    }
+});
+
+print('安全管理相关集合索引及权限菜单创建完成！');
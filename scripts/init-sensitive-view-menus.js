@@ -0,0 +1,29 @@
+// 敏感字段查看权限菜单初始化脚本
+// 启用 security.masking 后，未分配以下权限的角色查看保单、用户及导出时，账户号、客户号、客户姓名、保费、AUM、邮箱、手机号按脱敏规则显示；
+// 启用前请先运行本脚本并为需要查看完整信息的角色分配权限
+
+// 切换到项目数据库
+db = db.getSiblingDB('insurance_db');
+
+print('创建敏感字段查看权限菜单...');
+var now = new Date();
+var sensitiveViewMenus = [
+    { menu_id: "BTN_POLICY_VIEW_SENSITIVE", parent_id: "MENU_POLICY_MGMT", menu_name: "查看保单敏感信息", menu_type: "button", route_path: "", component: "", permission_code: "policy:view_sensitive", sort_order: 90 },
+    { menu_id: "BTN_USER_VIEW_SENSITIVE", parent_id: "MENU_USER_MGMT", menu_name: "查看用户联系方式", menu_type: "button", route_path: "", component: "", permission_code: "user:view_sensitive", sort_order: 90 }
+];
+
+sensitiveViewMenus.forEach(function(menu) {
+    if (db.menus.countDocuments({ menu_id: menu.menu_id }) === 0) {
+        menu.icon = "";
+        menu.visible = menu.menu_type !== "button";
+        menu.status = "enable";
+        menu.created_at = now;
+        menu.updated_at = now;
+        db.menus.insertOne(menu);
+        print('菜单创建成功: ' + menu.menu_name);
+    } else {
+        print('菜单已存在，跳过创建: ' + menu.menu_name);
+    }
+});
+
+print('敏感字段查看权限菜单创建完成！');
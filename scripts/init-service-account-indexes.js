@@ -0,0 +1,49 @@
+// MongoDB服务账号及API密钥集合索引及权限菜单初始化脚本
+
+// 切换到项目数据库
+db = db.getSiblingDB('insurance_db');
+
+print('正在创建服务账号相关集合索引...');
+
+// 1. 服务账号ID唯一索引
+db.service_accounts.createIndex({ "service_account_id": 1 }, { unique: true, name: "idx_service_account_id" });
+print('创建服务账号ID唯一索引: idx_service_account_id');
+
+// 2. 公司内服务账号名称唯一
+db.service_accounts.createIndex({ "company_id": 1, "name": 1 }, { unique: true, name: "idx_company_name" });
+print('创建公司服务账号名称唯一索引: idx_company_name');
+
+// 3. 密钥ID唯一索引
+db.api_keys.createIndex({ "key_id": 1 }, { unique: true, name: "idx_key_id" });
+print('创建密钥ID唯一索引: idx_key_id');
+
+// 4. 密钥前缀唯一索引（认证时按前缀查找密钥）
+db.api_keys.createIndex({ "prefix": 1 }, { unique: true, name: "idx_prefix" });
+print('创建密钥前缀唯一索引: idx_prefix');
+
+// 5. 按服务账号查询密钥
+db.api_keys.createIndex({ "service_account_id": 1, "created_at": -1 }, { name: "idx_service_account_created" });
+print('创建服务账号密钥索引: idx_service_account_created');
+
+// 6. 服务账号管理权限菜单
+print('创建服务账号管理权限菜单...');
+var now = new Date();
+var serviceAccountMenus = [
+    { menu_id: "MENU_SERVICE_ACCOUNT", parent_id: "MENU_SYSTEM_MGMT", menu_name: "服务账号", menu_type: "menu", route_path: "/system/service-accounts", component: "ServiceAccountList", permission_code: "service_account:manage", sort_order: 5 }
+];
+
+serviceAccountMenus.forEach(function(menu) {
+    if (db.menus.countDocuments({ menu_id: menu.menu_id }) === 0) {
+        menu.icon = "";
+        menu.visible = menu.menu_type !== "button";
+        menu.status = "enable";
+        menu.created_at = now;
+        menu.updated_at = now;
+        db.menus.insertOne(menu);
+        print('菜单创建成功: ' + menu.menu_name);
+    } else {
+        print('菜单已存在，跳过创建: ' + menu.menu_name);
+    }
+});
+
+print('服务账号相关集合索引及权限菜单创建完成！');
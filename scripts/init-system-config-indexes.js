@@ -96,6 +96,65 @@ try {
     );
     print("✅ 已创建文本搜索索引");
 
+    // 7. 配置类型 + 上级配置键复合索引（用于级联选择和下级配置项检查）
+    db.system_configs.createIndex(
+        {
+            "config_type": 1,
+            "parent_key": 1
+        },
+        {
+            name: "idx_type_parent_key",
+            background: true
+        }
+    );
+    print("✅ 已创建类型+上级配置键复合索引");
+
+    // 8. 字典类型注册表：公司ID + 类型编码唯一索引
+    db.system_config_types.createIndex(
+        {
+            "company_id": 1,
+            "type_code": 1
+        },
+        {
+            unique: true,
+            name: "idx_company_type_code_unique",
+            background: true
+        }
+    );
+    db.system_config_types.createIndex(
+        { "type_id": 1 },
+        {
+            unique: true,
+            name: "idx_type_id_unique",
+            background: true
+        }
+    );
+    print("✅ 已创建字典类型注册表索引");
+
+    // 9. 公司覆盖设置：公司ID + 配置项ID唯一索引
+    db.system_config_overrides.createIndex(
+        {
+            "company_id": 1,
+            "config_id": 1
+        },
+        {
+            unique: true,
+            name: "idx_company_config_unique",
+            background: true
+        }
+    );
+    db.system_config_overrides.createIndex(
+        {
+            "company_id": 1,
+            "config_type": 1
+        },
+        {
+            name: "idx_company_type",
+            background: true
+        }
+    );
+    print("✅ 已创建公司覆盖设置索引");
+
     print("✅ 系统配置表索引初始化完成！");
 
     // 显示所有索引
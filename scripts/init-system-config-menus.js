@@ -0,0 +1,27 @@
+// 系统配置权限菜单初始化脚本
+// 维护字典类型及配置项、批量重映射保单字典值须有 system_config:manage 权限，平台级配置只能由平台管理员维护
+
+// 切换到项目数据库
+db = db.getSiblingDB('insurance_db');
+
+print('创建系统配置权限菜单...');
+var now = new Date();
+var systemConfigMenus = [
+    { menu_id: "MENU_SYSTEM_CONFIG", parent_id: "MENU_SYSTEM_MGMT", menu_name: "系统配置", menu_type: "menu", route_path: "/system/config", component: "SystemConfigManagement", permission_code: "system_config:manage", sort_order: 7 }
+];
+
+systemConfigMenus.forEach(function(menu) {
+    if (db.menus.countDocuments({ menu_id: menu.menu_id }) === 0) {
+        menu.icon = "";
+        menu.visible = menu.menu_type !== "button";
+        menu.status = "enable";
+        menu.created_at = now;
+        menu.updated_at = now;
+        db.menus.insertOne(menu);
+        print('菜单创建成功: ' + menu.menu_name);
+    } else {
+        print('菜单已存在，跳过创建: ' + menu.menu_name);
+    }
+});
+
+print('系统配置权限菜单创建完成！');
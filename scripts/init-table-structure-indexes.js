@@ -0,0 +1,28 @@
+// MongoDB动态表结构版本集合索引初始化脚本
+
+// 切换到项目数据库
+db = db.getSiblingDB('insurance_db');
+
+print('正在创建表结构版本集合索引...');
+
+// 1. 业务主键索引
+db.table_structure_versions.createIndex({ "version_id": 1 }, { unique: true, name: "idx_version_id" });
+print('创建版本ID唯一索引: idx_version_id');
+
+// 2. 同一表结构的版本号唯一
+db.table_structure_versions.createIndex({ "table_id": 1, "version": -1 }, { unique: true, name: "idx_table_version" });
+print('创建表结构版本唯一索引: idx_table_version');
+
+// 3. 公司表结构按表名查询
+db.table_structures.createIndex({ "table_name": 1, "company_id": 1 }, { name: "idx_table_name_company" });
+print('创建表名公司复合索引: idx_table_name_company');
+
+// 4. 字段定义按排序号查询
+db.field_definitions.createIndex({ "table_id": 1, "sort_order": 1 }, { name: "idx_table_sort_order" });
+print('创建字段排序复合索引: idx_table_sort_order');
+
+// 5. 保单表结构版本索引（迁移及按版本排查数据）
+db.policies.createIndex({ "company_id": 1, "schema_version": 1 }, { name: "idx_company_schema_version" });
+print('创建保单表结构版本索引: idx_company_schema_version');
+
+print('表结构版本集合索引创建完成！');